@@ -47,6 +47,8 @@ const (
 	StorageDir       = "STORAGE_DIR"
 	StorageAddr      = "STORAGE_ADDR"
 	AgentServiceName = "AGENT_SERVICE_NAME"
+	PreferIPv6       = "PREFER_IPV6"
+	PasswordRotation = "PASSWORD_ROTATION"
 )
 
 // The Config interface is the sole way that the agent gets access to the
@@ -148,6 +150,10 @@ type ConfigSetterOnly interface {
 	// SetAPIHostPorts sets the API host/port addresses to connect to.
 	SetAPIHostPorts(servers [][]instance.HostPort)
 
+	// SetCACert sets the CA certificate used to validate the
+	// state and API servers.
+	SetCACert(caCert string)
+
 	// Migrate takes an existing agent config and applies the given
 	// parameters to change it.
 	//
@@ -448,6 +454,10 @@ func (c *configInternal) SetAPIHostPorts(servers [][]instance.HostPort) {
 	c.apiDetails.addresses = addrs
 }
 
+func (c *configInternal) SetCACert(caCert string) {
+	c.caCert = caCert
+}
+
 func (c *configInternal) SetValue(key, value string) {
 	if value == "" {
 		delete(c.values, key)
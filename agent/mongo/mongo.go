@@ -269,12 +269,16 @@ func upstartService(namespace, dataDir, dbDir string, port int) (*upstart.Conf,
 		return nil, "", err
 	}
 
+	bindIP := " --bind_ip 0.0.0.0"
+	if instance.PreferIPv6() {
+		bindIP = " --ipv6 --bind_ip 0.0.0.0,::"
+	}
 	mongoCmd := mongoPath + " --auth" +
 		" --dbpath=" + utils.ShQuote(dbDir) +
 		" --sslOnNormalPorts" +
 		" --sslPEMKeyFile " + utils.ShQuote(sslKeyPath(dataDir)) +
 		" --sslPEMKeyPassword ignored" +
-		" --bind_ip 0.0.0.0" +
+		bindIP +
 		" --port " + fmt.Sprint(port) +
 		" --noprealloc" +
 		" --syslog" +
@@ -220,6 +220,21 @@ func (s *MongoSuite) TestUpstartService(c *gc.C) {
 	c.Assert(strings.Contains(svc.Cmd, "--replSet"), jc.IsTrue)
 }
 
+func (s *MongoSuite) TestUpstartServiceIPv6(c *gc.C) {
+	dataDir := c.MkDir()
+
+	svc, _, err := mongo.UpstartService("", dataDir, dataDir, 1234)
+	c.Assert(err, gc.IsNil)
+	c.Assert(strings.Contains(svc.Cmd, " --bind_ip 0.0.0.0 "), jc.IsTrue)
+	c.Assert(strings.Contains(svc.Cmd, " --ipv6 "), jc.IsFalse)
+
+	instance.SetPreferIPv6(true)
+	defer instance.SetPreferIPv6(false)
+	svc, _, err = mongo.UpstartService("", dataDir, dataDir, 1234)
+	c.Assert(err, gc.IsNil)
+	c.Assert(strings.Contains(svc.Cmd, " --ipv6 --bind_ip 0.0.0.0,:: "), jc.IsTrue)
+}
+
 func (s *MongoSuite) TestUpstartServiceWithJournal(c *gc.C) {
 	dataDir := c.MkDir()
 
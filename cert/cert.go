@@ -38,6 +38,64 @@ func ParseCert(certPEM string) (*x509.Certificate, error) {
 	return nil, errors.New("no certificates found")
 }
 
+// ParseCerts parses all the PEM-formatted X509 certificates in the
+// given data. It is used for CA certificate bundles, which hold more
+// than one CA certificate while the environment's CA is being
+// replaced.
+func ParseCerts(certsPEM string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	certsPEMData := []byte(certsPEM)
+	for len(certsPEMData) > 0 {
+		var certBlock *pem.Block
+		certBlock, certsPEMData = pem.Decode(certsPEMData)
+		if certBlock == nil {
+			break
+		}
+		if certBlock.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(certBlock.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+	return certs, nil
+}
+
+// ParseCertPool returns a certificate pool holding all the
+// PEM-formatted X509 certificates in the given data.
+func ParseCertPool(certsPEM string) (*x509.CertPool, error) {
+	certs, err := ParseCerts(certsPEM)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return pool, nil
+}
+
+// Expiry returns the earliest time at which any of the PEM-formatted
+// X509 certificates in the given data expires.
+func Expiry(certsPEM string) (time.Time, error) {
+	certs, err := ParseCerts(certsPEM)
+	if err != nil {
+		return time.Time{}, err
+	}
+	expiry := certs[0].NotAfter
+	for _, cert := range certs[1:] {
+		if cert.NotAfter.Before(expiry) {
+			expiry = cert.NotAfter
+		}
+	}
+	return expiry, nil
+}
+
 // ParseCertAndKey parses the given PEM-formatted X509 certificate
 // and RSA private key.
 func ParseCertAndKey(certPEM, keyPEM string) (*x509.Certificate, *rsa.PrivateKey, error) {
@@ -59,9 +117,11 @@ func ParseCertAndKey(certPEM, keyPEM string) (*x509.Certificate, *rsa.PrivateKey
 }
 
 // Verify verifies that the given server certificate is valid with
-// respect to the given CA certificate at the given time.
+// respect to the given CA certificate at the given time. The CA
+// certificate may be a bundle of several CA certificates, in which
+// case the server certificate must be signed by one of them.
 func Verify(srvCertPEM, caCertPEM string, when time.Time) error {
-	caCert, err := ParseCert(caCertPEM)
+	pool, err := ParseCertPool(caCertPEM)
 	if err != nil {
 		return errors.Annotate(err, "cannot parse CA certificate")
 	}
@@ -69,10 +129,10 @@ func Verify(srvCertPEM, caCertPEM string, when time.Time) error {
 	if err != nil {
 		return errors.Annotate(err, "cannot parse server certificate")
 	}
-	pool := x509.NewCertPool()
-	pool.AddCert(caCert)
+	// Clients connect with this server name, which certificates
+	// listing host names must include.
 	opts := x509.VerifyOptions{
-		DNSName:     "anyServer",
+		DNSName:     "anything",
 		Roots:       pool,
 		CurrentTime: when,
 	}
@@ -127,29 +187,85 @@ func NewClient(caCertPEM, caKeyPEM string, expiry time.Time) (certPEM, keyPEM st
 	return newLeaf(caCertPEM, caKeyPEM, expiry, nil, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth})
 }
 
+// NewKey generates a private key for a server, and returns it along
+// with its public key, both PEM-formatted. A certificate for the key
+// may be issued with NewServerForKey by whoever holds the CA private
+// key, without the server's private key leaving the server.
+func NewKey() (keyPEM, publicKeyPEM string, err error) {
+	key, err := rsa.GenerateKey(rand.Reader, KeyBits)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot generate key: %v", err)
+	}
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+	keyPEMData := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	publicKeyPEMData := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: publicKeyDER,
+	})
+	return string(keyPEMData), string(publicKeyPEMData), nil
+}
+
+// NewServerForKey generates a certificate suitable for use by a server
+// holding the private key for the given PEM-formatted public key.
+func NewServerForKey(caCertPEM, caKeyPEM string, expiry time.Time, hostnames []string, publicKeyPEM string) (certPEM string, err error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return "", errors.New("no public key found")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	rsaKey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		return "", fmt.Errorf("public key has unexpected type %T", publicKey)
+	}
+	return signLeaf(caCertPEM, caKeyPEM, expiry, hostnames, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, rsaKey)
+}
+
 // newLeaf generates a certificate/key pair suitable for use by a leaf node.
 func newLeaf(caCertPEM, caKeyPEM string, expiry time.Time, hostnames []string, extKeyUsage []x509.ExtKeyUsage) (certPEM, keyPEM string, err error) {
-	tlsCert, err := tls.X509KeyPair([]byte(caCertPEM), []byte(caKeyPEM))
+	key, err := rsa.GenerateKey(rand.Reader, KeyBits)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot generate key: %v", err)
+	}
+	certPEM, err = signLeaf(caCertPEM, caKeyPEM, expiry, hostnames, extKeyUsage, &key.PublicKey)
 	if err != nil {
 		return "", "", err
 	}
+	keyPEMData := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	return certPEM, string(keyPEMData), nil
+}
+
+// signLeaf generates a certificate for the given public key,
+// signed by the given CA, suitable for use by a leaf node.
+func signLeaf(caCertPEM, caKeyPEM string, expiry time.Time, hostnames []string, extKeyUsage []x509.ExtKeyUsage, key *rsa.PublicKey) (certPEM string, err error) {
+	tlsCert, err := tls.X509KeyPair([]byte(caCertPEM), []byte(caKeyPEM))
+	if err != nil {
+		return "", err
+	}
 	if len(tlsCert.Certificate) != 1 {
-		return "", "", fmt.Errorf("more than one certificate for CA")
+		return "", fmt.Errorf("more than one certificate for CA")
 	}
 	caCert, err := x509.ParseCertificate(tlsCert.Certificate[0])
 	if err != nil {
-		return "", "", err
+		return "", err
 	}
 	if !caCert.BasicConstraintsValid || !caCert.IsCA {
-		return "", "", fmt.Errorf("CA certificate is not a valid CA")
+		return "", fmt.Errorf("CA certificate is not a valid CA")
 	}
 	caKey, ok := tlsCert.PrivateKey.(*rsa.PrivateKey)
 	if !ok {
-		return "", "", fmt.Errorf("CA private key has unexpected type %T", tlsCert.PrivateKey)
-	}
-	key, err := rsa.GenerateKey(rand.Reader, KeyBits)
-	if err != nil {
-		return "", "", fmt.Errorf("cannot generate key: %v", err)
+		return "", fmt.Errorf("CA private key has unexpected type %T", tlsCert.PrivateKey)
 	}
 	now := time.Now()
 	template := &x509.Certificate{
@@ -174,19 +290,15 @@ func newLeaf(caCertPEM, caKeyPEM string, expiry time.Time, hostnames []string, e
 			template.DNSNames = append(template.DNSNames, hostname)
 		}
 	}
-	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, key, caKey)
 	if err != nil {
-		return "", "", err
+		return "", err
 	}
 	certPEMData := pem.EncodeToMemory(&pem.Block{
 		Type:  "CERTIFICATE",
 		Bytes: certDER,
 	})
-	keyPEMData := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(key),
-	})
-	return string(certPEMData), string(keyPEMData), nil
+	return string(certPEMData), nil
 }
 
 func bigIntHash(n *big.Int) []byte {
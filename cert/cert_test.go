@@ -149,6 +149,34 @@ func (certSuite) TestNewServerWithInvalidCert(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "CA certificate is not a valid CA")
 }
 
+func (certSuite) TestNewServerForKey(c *gc.C) {
+	expiry := roundTime(time.Now().AddDate(1, 0, 0))
+	keyPEM, publicKeyPEM, err := cert.NewKey()
+	c.Assert(err, gc.IsNil)
+
+	hostnames := []string{"anything", "example.com", "10.0.0.1"}
+	srvCertPEM, err := cert.NewServerForKey(caCertPEM, caKeyPEM, expiry, hostnames, publicKeyPEM)
+	c.Assert(err, gc.IsNil)
+
+	// The certificate is for the key that was generated.
+	srvCert, _, err := cert.ParseCertAndKey(srvCertPEM, keyPEM)
+	c.Assert(err, gc.IsNil)
+	_, err = tls.X509KeyPair([]byte(srvCertPEM), []byte(keyPEM))
+	c.Assert(err, gc.IsNil)
+	c.Assert(srvCert.NotAfter.Equal(expiry), gc.Equals, true)
+	c.Assert(srvCert.DNSNames, gc.DeepEquals, []string{"anything", "example.com"})
+	c.Assert(srvCert.IPAddresses, gc.HasLen, 1)
+	c.Assert(srvCert.IPAddresses[0].String(), gc.Equals, "10.0.0.1")
+
+	err = cert.Verify(srvCertPEM, caCertPEM, time.Now())
+	c.Assert(err, gc.IsNil)
+
+	_, err = cert.NewServerForKey(caCertPEM, caKeyPEM, expiry, hostnames, keyPEM)
+	c.Assert(err, gc.ErrorMatches, "no public key found")
+	_, err = cert.NewServerForKey(nonCACert, nonCAKey, expiry, hostnames, publicKeyPEM)
+	c.Assert(err, gc.ErrorMatches, "CA certificate is not a valid CA")
+}
+
 func (certSuite) TestVerify(c *gc.C) {
 	now := time.Now()
 	caCert, caKey, err := cert.NewCA("foo", now.Add(1*time.Minute))
@@ -186,6 +214,64 @@ func (certSuite) TestVerify(c *gc.C) {
 	c.Check(err, gc.ErrorMatches, "x509: certificate signed by unknown authority")
 }
 
+func (certSuite) TestParseCerts(c *gc.C) {
+	expiry := roundTime(time.Now().AddDate(0, 0, 1))
+	caCert2, _, err := cert.NewCA("bar", expiry)
+	c.Assert(err, gc.IsNil)
+
+	certs, err := cert.ParseCerts(caCertPEM + caKeyPEM + caCert2)
+	c.Assert(err, gc.IsNil)
+	c.Assert(certs, gc.HasLen, 2)
+	c.Assert(certs[0].Subject.CommonName, gc.Equals, "juju testing")
+	c.Assert(certs[1].Subject.CommonName, gc.Equals, `juju-generated CA for environment "bar"`)
+
+	certs, err = cert.ParseCerts(caKeyPEM)
+	c.Check(certs, gc.IsNil)
+	c.Assert(err, gc.ErrorMatches, "no certificates found")
+
+	pool, err := cert.ParseCertPool(caCertPEM + caCert2)
+	c.Assert(err, gc.IsNil)
+	c.Assert(pool.Subjects(), gc.HasLen, 2)
+}
+
+func (certSuite) TestExpiry(c *gc.C) {
+	now := time.Now()
+	expiry1 := roundTime(now.AddDate(0, 0, 2))
+	caCert1, _, err := cert.NewCA("foo", expiry1)
+	c.Assert(err, gc.IsNil)
+	expiry2 := roundTime(now.AddDate(0, 0, 1))
+	caCert2, _, err := cert.NewCA("bar", expiry2)
+	c.Assert(err, gc.IsNil)
+
+	expiry, err := cert.Expiry(caCert1)
+	c.Assert(err, gc.IsNil)
+	c.Assert(expiry.Equal(expiry1), gc.Equals, true)
+
+	expiry, err = cert.Expiry(caCert1 + caCert2)
+	c.Assert(err, gc.IsNil)
+	c.Assert(expiry.Equal(expiry2), gc.Equals, true)
+
+	_, err = cert.Expiry("hello")
+	c.Assert(err, gc.ErrorMatches, "no certificates found")
+}
+
+func (certSuite) TestVerifyBundle(c *gc.C) {
+	now := time.Now()
+	caCert, _, err := cert.NewCA("foo", now.Add(1*time.Minute))
+	c.Assert(err, gc.IsNil)
+	caCert2, caKey2, err := cert.NewCA("bar", now.Add(1*time.Minute))
+	c.Assert(err, gc.IsNil)
+
+	var noHostnames []string
+	srvCert, _, err := cert.NewServer(caCert2, caKey2, now.Add(1*time.Minute), noHostnames)
+	c.Assert(err, gc.IsNil)
+
+	err = cert.Verify(srvCert, caCert, now)
+	c.Check(err, gc.ErrorMatches, "x509: certificate signed by unknown authority")
+	err = cert.Verify(srvCert, caCert+caCert2, now)
+	c.Assert(err, gc.IsNil)
+}
+
 // checkTLSConnection checks that we can correctly perform a TLS
 // handshake using the given credentials.
 func checkTLSConnection(c *gc.C, caCert, srvCert *x509.Certificate, srvKey *rsa.PrivateKey) (caName string) {
@@ -0,0 +1,213 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"launchpad.net/goyaml"
+)
+
+// BundleSeries holds the series used in the URLs of bundles,
+// such as cs:bundle/wordpress-simple or cs:~joe/bundle/wordpress.
+const BundleSeries = "bundle"
+
+// BundleData holds the contents of a bundle: a set of services to
+// deploy and the relations between them. Not to be confused with a
+// Bundle, which is a charm archive.
+type BundleData struct {
+	// Series holds the series of the charms that
+	// don't specify one.
+	Series string `yaml:"series,omitempty"`
+
+	// Services maps service names to their specification.
+	Services map[string]*ServiceSpec `yaml:"services"`
+
+	// Relations holds the relations to add between the services,
+	// each as a pair of endpoints. An endpoint is a service
+	// name, optionally followed by a colon and a relation name,
+	// as in "wordpress:db".
+	Relations [][]string `yaml:"relations,omitempty"`
+}
+
+// ServiceSpec describes a service in a bundle.
+type ServiceSpec struct {
+	// Charm holds the URL of the service's charm. The
+	// series may be omitted, as when deploying a charm.
+	Charm string `yaml:"charm"`
+
+	NumUnits    int                    `yaml:"num_units,omitempty"`
+	Options     map[string]interface{} `yaml:"options,omitempty"`
+	Constraints string                 `yaml:"constraints,omitempty"`
+}
+
+// ReadBundleData reads the content of a bundle.yaml file
+// and returns its representation.
+func ReadBundleData(r io.Reader) (*BundleData, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var bd BundleData
+	if err := goyaml.Unmarshal(data, &bd); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal bundle data: %v", err)
+	}
+	return &bd, nil
+}
+
+// CharmURL returns the URL of the charm of the named service.
+func (bd *BundleData) CharmURL(service string) (*URL, error) {
+	spec, ok := bd.Services[service]
+	if !ok {
+		return nil, fmt.Errorf("service %q not found in bundle", service)
+	}
+	curl, err := InferURL(spec.Charm, bd.Series)
+	if err != nil {
+		return nil, fmt.Errorf("invalid charm URL in service %q: %v", service, err)
+	}
+	if curl.Series == BundleSeries {
+		return nil, fmt.Errorf("service %q refers to bundle %q instead of a charm", service, curl)
+	}
+	return curl, nil
+}
+
+// VerificationError holds the problems found when verifying a bundle.
+type VerificationError struct {
+	Errors []error
+}
+
+func (err *VerificationError) Error() string {
+	msgs := make([]string, len(err.Errors))
+	for i, e := range err.Errors {
+		msgs[i] = e.Error()
+	}
+	return "bundle verification failed: " + strings.Join(msgs, "; ")
+}
+
+// Verify checks that the bundle is consistent: every service must have
+// a valid charm, found with getMeta, and the endpoints of every relation
+// must match interfaces implemented by those charms. If the bundle is
+// inconsistent, the returned error is a *VerificationError.
+func (bd *BundleData) Verify(getMeta func(curl *URL) (*Meta, error)) error {
+	var errs []error
+	if len(bd.Services) == 0 {
+		errs = append(errs, fmt.Errorf("bundle has no services"))
+	}
+	metas := make(map[string]*Meta)
+	for _, service := range bd.serviceNames() {
+		if bd.Services[service].NumUnits < 0 {
+			errs = append(errs, fmt.Errorf("negative number of units specified for service %q", service))
+		}
+		curl, err := bd.CharmURL(service)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		meta, err := getMeta(curl)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot get charm %q of service %q: %v", curl, service, err))
+			continue
+		}
+		metas[service] = meta
+	}
+	for _, endpoints := range bd.Relations {
+		if err := verifyRelation(endpoints, bd.Services, metas); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return &VerificationError{errs}
+	}
+	return nil
+}
+
+func (bd *BundleData) serviceNames() []string {
+	var names []string
+	for name := range bd.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// verifyRelation checks that exactly one pair of relations
+// of the charms of the given services matches the endpoints.
+func verifyRelation(endpoints []string, services map[string]*ServiceSpec, metas map[string]*Meta) error {
+	if len(endpoints) != 2 {
+		return fmt.Errorf("relation %q does not have two endpoints", endpoints)
+	}
+	var candidates [2][]Relation
+	for i, ep := range endpoints {
+		service, relName := ep, ""
+		if colon := strings.Index(ep, ":"); colon >= 0 {
+			service, relName = ep[:colon], ep[colon+1:]
+		}
+		if _, ok := services[service]; !ok {
+			return fmt.Errorf("relation %q refers to service %q not defined in this bundle", endpoints, service)
+		}
+		meta, ok := metas[service]
+		if !ok {
+			// The charm is invalid, which has already been reported.
+			return nil
+		}
+		candidates[i] = endpointRelations(meta, relName)
+		if len(candidates[i]) == 0 {
+			return fmt.Errorf("relation %q refers to relation %q not defined by the charm of service %q", endpoints, relName, service)
+		}
+	}
+	matches := 0
+	for _, r0 := range candidates[0] {
+		for _, r1 := range candidates[1] {
+			if r0.Interface == r1.Interface && counterpartRole(r0.Role) == r1.Role {
+				matches++
+			}
+		}
+	}
+	switch matches {
+	case 0:
+		return fmt.Errorf("relation %q has no matching endpoints", endpoints)
+	case 1:
+		return nil
+	}
+	return fmt.Errorf("relation %q is ambiguous", endpoints)
+}
+
+// endpointRelations returns the relations of the charm with the given
+// metadata that can be used in a relation between services. If name
+// is not empty, only the relation with that name is returned.
+func endpointRelations(meta *Meta, name string) []Relation {
+	rels := []Relation{{
+		Name:      "juju-info",
+		Role:      RoleProvider,
+		Interface: "juju-info",
+	}}
+	for _, m := range []map[string]Relation{meta.Provides, meta.Requires} {
+		for _, rel := range m {
+			rels = append(rels, rel)
+		}
+	}
+	if name == "" {
+		return rels
+	}
+	for _, rel := range rels {
+		if rel.Name == name {
+			return []Relation{rel}
+		}
+	}
+	return nil
+}
+
+func counterpartRole(role RelationRole) RelationRole {
+	switch role {
+	case RoleProvider:
+		return RoleRequirer
+	case RoleRequirer:
+		return RoleProvider
+	}
+	return role
+}
@@ -0,0 +1,157 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm_test
+
+import (
+	"fmt"
+	"strings"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/testing"
+)
+
+type BundleDataSuite struct{}
+
+var _ = gc.Suite(&BundleDataSuite{})
+
+const wordpressBundle = `
+series: quantal
+services:
+    wordpress:
+        charm: wordpress
+        num_units: 2
+        options:
+            blog-title: My Blog
+    mysql:
+        charm: cs:quantal/mysql
+        constraints: mem=4G
+    logging:
+        charm: logging
+relations:
+    - [wordpress, mysql]
+    - ["wordpress:logging-dir", logging]
+`
+
+func readBundleData(c *gc.C, data string) *charm.BundleData {
+	bd, err := charm.ReadBundleData(strings.NewReader(data))
+	c.Assert(err, gc.IsNil)
+	return bd
+}
+
+// getTestMeta returns the metadata of the charms in the testing
+// repository, ignoring the URL's series.
+func getTestMeta(curl *charm.URL) (*charm.Meta, error) {
+	if curl.Name == "missing" {
+		return nil, fmt.Errorf("charm not found")
+	}
+	return testing.Charms.Dir(curl.Name).Meta(), nil
+}
+
+func (*BundleDataSuite) TestReadBundleData(c *gc.C) {
+	bd := readBundleData(c, wordpressBundle)
+	c.Assert(bd, gc.DeepEquals, &charm.BundleData{
+		Series: "quantal",
+		Services: map[string]*charm.ServiceSpec{
+			"wordpress": {
+				Charm:    "wordpress",
+				NumUnits: 2,
+				Options:  map[string]interface{}{"blog-title": "My Blog"},
+			},
+			"mysql": {
+				Charm:       "cs:quantal/mysql",
+				Constraints: "mem=4G",
+			},
+			"logging": {
+				Charm: "logging",
+			},
+		},
+		Relations: [][]string{
+			{"wordpress", "mysql"},
+			{"wordpress:logging-dir", "logging"},
+		},
+	})
+	curl, err := bd.CharmURL("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(curl, gc.DeepEquals, charm.MustParseURL("cs:quantal/wordpress"))
+
+	_, err = charm.ReadBundleData(strings.NewReader("services: [wordpress]"))
+	c.Assert(err, gc.ErrorMatches, "cannot unmarshal bundle data: .*")
+}
+
+func (*BundleDataSuite) TestVerify(c *gc.C) {
+	bd := readBundleData(c, wordpressBundle)
+	err := bd.Verify(getTestMeta)
+	c.Assert(err, gc.IsNil)
+}
+
+var verifyErrorTests = []struct {
+	about  string
+	bundle string
+	errors []string
+}{{
+	about:  "no services",
+	bundle: "series: quantal",
+	errors: []string{"bundle has no services"},
+}, {
+	about: "invalid services",
+	bundle: `
+services:
+    wordpress:
+        charm: wordpress
+        num_units: -1
+    mysql:
+        charm: cs:quantal/missing
+    blog:
+        charm: cs:bundle/blog
+`,
+	errors: []string{
+		`service "blog" refers to bundle "cs:bundle/blog" instead of a charm`,
+		`cannot get charm "cs:quantal/missing" of service "mysql": charm not found`,
+		`negative number of units specified for service "wordpress"`,
+		`invalid charm URL in service "wordpress": cannot infer charm URL for "wordpress": no series provided`,
+	},
+}, {
+	about: "invalid relations",
+	bundle: `
+series: quantal
+services:
+    wordpress:
+        charm: wordpress
+    mysql:
+        charm: mysql
+    varnish:
+        charm: varnish
+    logging:
+        charm: logging
+relations:
+    - [wordpress, mysql, varnish]
+    - [wordpress, memcached]
+    - ["wordpress:cache", "mysql"]
+    - ["wordpress:bogus", "mysql"]
+    - [wordpress, logging]
+`,
+	errors: []string{
+		`relation \["wordpress" "mysql" "varnish"\] does not have two endpoints`,
+		`relation \["wordpress" "memcached"\] refers to service "memcached" not defined in this bundle`,
+		`relation \["wordpress:cache" "mysql"\] has no matching endpoints`,
+		`relation \["wordpress:bogus" "mysql"\] refers to relation "bogus" not defined by the charm of service "wordpress"`,
+		`relation \["wordpress" "logging"\] is ambiguous`,
+	},
+}}
+
+func (*BundleDataSuite) TestVerifyErrors(c *gc.C) {
+	for i, test := range verifyErrorTests {
+		c.Logf("test %d: %s", i, test.about)
+		bd := readBundleData(c, test.bundle)
+		err := bd.Verify(getTestMeta)
+		c.Assert(err, gc.FitsTypeOf, &charm.VerificationError{})
+		errs := err.(*charm.VerificationError).Errors
+		c.Assert(errs, gc.HasLen, len(test.errors))
+		for j, e := range errs {
+			c.Check(e, gc.ErrorMatches, test.errors[j])
+		}
+	}
+}
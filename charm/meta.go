@@ -102,7 +102,29 @@ type Meta struct {
 	Format      int                 `bson:",omitempty"`
 	OldRevision int                 `bson:",omitempty"` // Obsolete
 	Categories  []string            `bson:",omitempty"`
-	Series      string              `bson:",omitempty"`
+
+	// Series holds the charm's most preferred series, if it
+	// declares any. It is kept for clients that know of only
+	// one series per charm; see SupportedSeries.
+	Series string `bson:",omitempty"`
+
+	// SupportedSeries holds all the series the charm declares,
+	// most preferred first.
+	SupportedSeries []string `bson:",omitempty"`
+
+	// Resources holds the binary payloads the charm uses,
+	// keyed by name.
+	Resources map[string]Resource `bson:",omitempty"`
+}
+
+// Resource represents a binary payload declared in the charm
+// metadata.yaml file. Revisions of its content are attached to
+// services separately from the charm, and are fetched by units
+// with the resource-get hook tool.
+type Resource struct {
+	Name        string
+	Filename    string
+	Description string
 }
 
 func generateRelationHooks(relName string, allHooks map[string]bool) {
@@ -111,6 +133,31 @@ func generateRelationHooks(relName string, allHooks map[string]bool) {
 	}
 }
 
+// DeclaredSeries returns the series the charm supports, most preferred
+// first. A charm that declares no series supports the series of the
+// URL it is found at. Metadata stored before charms could declare
+// several series holds only Series.
+func (m Meta) DeclaredSeries() []string {
+	if len(m.SupportedSeries) == 0 && m.Series != "" {
+		return []string{m.Series}
+	}
+	return m.SupportedSeries
+}
+
+// SupportsSeries returns whether the charm can be deployed on series.
+func (m Meta) SupportsSeries(series string) bool {
+	declared := m.DeclaredSeries()
+	if len(declared) == 0 {
+		return true
+	}
+	for _, s := range declared {
+		if s == series {
+			return true
+		}
+	}
+	return false
+}
+
 // Hooks returns a map of all possible valid hooks, taking relations
 // into account. It's a map to enable fast lookups, and the value is
 // always true.
@@ -133,6 +180,38 @@ func (m Meta) Hooks() map[string]bool {
 	return allHooks
 }
 
+// parseSeries returns the series declared in metadata.yaml,
+// which may be a single series or a list of them.
+func parseSeries(series interface{}) []string {
+	switch series := series.(type) {
+	case string:
+		return []string{series}
+	case []interface{}:
+		result := make([]string, len(series))
+		for i, s := range series {
+			result[i] = s.(string)
+		}
+		return result
+	}
+	return nil
+}
+
+func parseResources(resources interface{}) map[string]Resource {
+	if resources == nil {
+		return nil
+	}
+	result := make(map[string]Resource)
+	for name, res := range resources.(map[string]interface{}) {
+		m := res.(map[string]interface{})
+		result[name] = Resource{
+			Name:        name,
+			Filename:    m["filename"].(string),
+			Description: m["description"].(string),
+		}
+	}
+	return result
+}
+
 func parseCategories(categories interface{}) []string {
 	if categories == nil {
 		return nil
@@ -180,9 +259,11 @@ func ReadMeta(r io.Reader) (meta *Meta, err error) {
 		// Obsolete
 		meta.OldRevision = int(m["revision"].(int64))
 	}
-	if series, ok := m["series"]; ok && series != nil {
-		meta.Series = series.(string)
+	if series := parseSeries(m["series"]); len(series) > 0 {
+		meta.Series = series[0]
+		meta.SupportedSeries = series
 	}
+	meta.Resources = parseResources(m["resources"])
 	if err := meta.Check(); err != nil {
 		return nil, err
 	}
@@ -248,9 +329,26 @@ func (meta Meta) Check() error {
 		}
 	}
 
-	if meta.Series != "" {
-		if !IsValidSeries(meta.Series) {
-			return fmt.Errorf("charm %q declares invalid series: %q", meta.Name, meta.Series)
+	seen := make(map[string]bool)
+	for _, series := range meta.DeclaredSeries() {
+		if !IsValidSeries(series) {
+			return fmt.Errorf("charm %q declares invalid series: %q", meta.Name, series)
+		}
+		if seen[series] {
+			return fmt.Errorf("charm %q declares duplicate series: %q", meta.Name, series)
+		}
+		seen[series] = true
+	}
+
+	for name, res := range meta.Resources {
+		if res.Name != name {
+			return fmt.Errorf("charm %q has mismatched resource name %q; expected %q", meta.Name, res.Name, name)
+		}
+		if !IsValidName(name) {
+			return fmt.Errorf("charm %q declares invalid resource name: %q", meta.Name, name)
+		}
+		if res.Filename == "" || res.Filename == "." || res.Filename == ".." || strings.Contains(res.Filename, "/") {
+			return fmt.Errorf("charm %q resource %q has invalid filename: %q", meta.Name, name, res.Filename)
 		}
 	}
 
@@ -356,6 +454,16 @@ var ifaceSchema = schema.FieldMap(
 	},
 )
 
+var resourceSchema = schema.FieldMap(
+	schema.Fields{
+		"filename":    schema.String(),
+		"description": schema.String(),
+	},
+	schema.Defaults{
+		"description": "",
+	},
+)
+
 var charmSchema = schema.FieldMap(
 	schema.Fields{
 		"name":        schema.String(),
@@ -368,7 +476,8 @@ var charmSchema = schema.FieldMap(
 		"format":      schema.Int(),
 		"subordinate": schema.Bool(),
 		"categories":  schema.List(schema.String()),
-		"series":      schema.String(),
+		"series":      schema.OneOf(schema.String(), schema.List(schema.String())),
+		"resources":   schema.StringMap(resourceSchema),
 	},
 	schema.Defaults{
 		"provides":    schema.Omit,
@@ -379,5 +488,6 @@ var charmSchema = schema.FieldMap(
 		"subordinate": schema.Omit,
 		"categories":  schema.Omit,
 		"series":      schema.Omit,
+		"resources":   schema.Omit,
 	},
 )
@@ -251,12 +251,93 @@ func (s *MetaSuite) TestSeries(c *gc.C) {
 	meta, err := charm.ReadMeta(strings.NewReader(dummyMetadata))
 	c.Assert(err, gc.IsNil)
 	c.Check(meta.Series, gc.Equals, "")
+	c.Check(meta.SupportedSeries, gc.HasLen, 0)
 
 	for _, seriesName := range []string{"precise", "trusty", "plan9"} {
 		meta, err := charm.ReadMeta(strings.NewReader(
 			fmt.Sprintf("%s\nseries: %s\n", dummyMetadata, seriesName)))
 		c.Assert(err, gc.IsNil)
 		c.Check(meta.Series, gc.Equals, seriesName)
+		c.Check(meta.SupportedSeries, gc.DeepEquals, []string{seriesName})
+	}
+}
+
+// TestMultipleSeries ensures that a list of series is parsed
+// in order when specified in the charm metadata.
+func (s *MetaSuite) TestMultipleSeries(c *gc.C) {
+	meta, err := charm.ReadMeta(strings.NewReader(
+		dummyMetadata + "\nseries: [trusty, precise]\n"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(meta.Series, gc.Equals, "trusty")
+	c.Assert(meta.SupportedSeries, gc.DeepEquals, []string{"trusty", "precise"})
+	c.Assert(meta.DeclaredSeries(), gc.DeepEquals, []string{"trusty", "precise"})
+	c.Assert(meta.SupportsSeries("precise"), gc.Equals, true)
+	c.Assert(meta.SupportsSeries("quantal"), gc.Equals, false)
+
+	meta, err = charm.ReadMeta(strings.NewReader(dummyMetadata))
+	c.Assert(err, gc.IsNil)
+	c.Assert(meta.SupportsSeries("quantal"), gc.Equals, true)
+
+	_, err = charm.ReadMeta(strings.NewReader(
+		dummyMetadata + "\nseries: [trusty, trusty]\n"))
+	c.Assert(err, gc.ErrorMatches, `charm "a" declares duplicate series: "trusty"`)
+	_, err = charm.ReadMeta(strings.NewReader(
+		dummyMetadata + "\nseries: [trusty, OpenVMS]\n"))
+	c.Assert(err, gc.ErrorMatches, `charm "a" declares invalid series: "OpenVMS"`)
+}
+
+// TestDeclaredSeriesOnlySeries ensures that metadata stored before
+// charms could declare several series keeps its series.
+func (s *MetaSuite) TestDeclaredSeriesOnlySeries(c *gc.C) {
+	meta := charm.Meta{Name: "a", Series: "precise"}
+	c.Assert(meta.DeclaredSeries(), gc.DeepEquals, []string{"precise"})
+	c.Assert(meta.SupportsSeries("precise"), gc.Equals, true)
+	c.Assert(meta.SupportsSeries("trusty"), gc.Equals, false)
+}
+
+func (s *MetaSuite) TestResources(c *gc.C) {
+	meta, err := charm.ReadMeta(strings.NewReader(dummyMetadata + `
+resources:
+  jdk:
+    filename: jdk.tar.gz
+    description: The Java runtime.
+  app:
+    filename: app.war
+`))
+	c.Assert(err, gc.IsNil)
+	c.Assert(meta.Resources, gc.DeepEquals, map[string]charm.Resource{
+		"jdk": {Name: "jdk", Filename: "jdk.tar.gz", Description: "The Java runtime."},
+		"app": {Name: "app", Filename: "app.war"},
+	})
+
+	meta, err = charm.ReadMeta(strings.NewReader(dummyMetadata))
+	c.Assert(err, gc.IsNil)
+	c.Assert(meta.Resources, gc.HasLen, 0)
+}
+
+var resourceErrorTests = []struct {
+	resources string
+	err       string
+}{{
+	resources: "jdk: {}",
+	err:       `metadata: resources.jdk.filename: expected string, got nothing`,
+}, {
+	resources: "Jdk: {filename: jdk.tar.gz}",
+	err:       `charm "a" declares invalid resource name: "Jdk"`,
+}, {
+	resources: "jdk: {filename: lib/jdk.tar.gz}",
+	err:       `charm "a" resource "jdk" has invalid filename: "lib/jdk.tar.gz"`,
+}, {
+	resources: "jdk: {filename: ..}",
+	err:       `charm "a" resource "jdk" has invalid filename: "\.\."`,
+}}
+
+func (s *MetaSuite) TestResourceErrors(c *gc.C) {
+	for i, t := range resourceErrorTests {
+		c.Logf("test %d: %s", i, t.resources)
+		_, err := charm.ReadMeta(strings.NewReader(
+			fmt.Sprintf("%s\nresources:\n  %s\n", dummyMetadata, t.resources)))
+		c.Assert(err, gc.ErrorMatches, t.err)
 	}
 }
 
@@ -4,6 +4,7 @@
 package charm
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -41,6 +42,33 @@ type EventResponse struct {
 	Time     string   `json:"time,omitempty"`
 }
 
+// SearchResult describes a charm found by a charm store search.
+type SearchResult struct {
+	URL      string `json:"url"`
+	Name     string `json:"name"`
+	Owner    string `json:"owner,omitempty"`
+	Series   string `json:"series"`
+	Revision int    `json:"revision"` // Zero is valid. Can't omitempty.
+	Summary  string `json:"summary,omitempty"`
+}
+
+// SearchResponse is sent by the charm store in response to search requests.
+type SearchResponse struct {
+	Results []SearchResult `json:"results"`
+}
+
+// SearchParams holds the criteria of a charm store search.
+// Empty fields are ignored.
+type SearchParams struct {
+	// Text holds the words to look for in the name, summary,
+	// description, categories and relation interfaces of charms.
+	Text   string
+	Series string
+	Owner  string
+	// Limit, if positive, restricts the number of results.
+	Limit int
+}
+
 // CharmRevision holds the revision number of a charm and any error
 // encountered in retrieving it.
 type CharmRevision struct {
@@ -125,6 +153,11 @@ func (s *CharmStore) get(url string) (resp *http.Response, err error) {
 	if err != nil {
 		return nil, err
 	}
+	return s.do(req)
+}
+
+// do sends req, adding custom auth header if necessary.
+func (s *CharmStore) do(req *http.Request) (resp *http.Response, err error) {
 	if s.authAttrs != "" {
 		// To comply with RFC 2617, we send the authentication data in
 		// the Authorization header with a custom auth scheme
@@ -159,10 +192,26 @@ func (s *CharmStore) Resolve(ref Reference) (*URL, error) {
 
 // Info returns details for all the specified charms in the charm store.
 func (s *CharmStore) Info(curls ...Location) ([]*InfoResponse, error) {
-	baseURL := s.BaseURL + "/charm-info?"
+	return s.info("charm", curls...)
+}
+
+// BundleInfo returns details for all the specified bundles in the
+// charm store.
+func (s *CharmStore) BundleInfo(burls ...*URL) ([]*InfoResponse, error) {
+	locations := make([]Location, len(burls))
+	for i, burl := range burls {
+		locations[i] = burl
+	}
+	return s.info("bundle", locations...)
+}
+
+// info returns details for all the specified entities of the given
+// kind ("charm" or "bundle") in the charm store.
+func (s *CharmStore) info(kind string, curls ...Location) ([]*InfoResponse, error) {
+	baseURL := s.BaseURL + "/" + kind + "-info?"
 	queryParams := make([]string, len(curls), len(curls)+1)
 	for i, curl := range curls {
-		queryParams[i] = "charms=" + url.QueryEscape(curl.String())
+		queryParams[i] = kind + "s=" + url.QueryEscape(curl.String())
 	}
 	if s.testMode {
 		queryParams = append(queryParams, "stats=0")
@@ -200,10 +249,10 @@ func (s *CharmStore) Info(curls ...Location) ([]*InfoResponse, error) {
 		key := curl.String()
 		info, found := infos[key]
 		if !found {
-			return nil, fmt.Errorf("charm store returned response without charm %q", key)
+			return nil, fmt.Errorf("charm store returned response without %s %q", kind, key)
 		}
 		if len(info.Errors) == 1 && info.Errors[0] == "entry not found" {
-			info.Errors[0] = fmt.Sprintf("charm not found: %s", curl)
+			info.Errors[0] = fmt.Sprintf("%s not found: %s", kind, curl)
 		}
 		result[i] = info
 	}
@@ -246,6 +295,72 @@ func (s *CharmStore) Event(curl *URL, digest string) (*EventResponse, error) {
 	return event, nil
 }
 
+// RequestPublish asks the charm store to publish the charm at curl
+// from the head of the Git repository at repoURL, expected to be the
+// commit identified by digest. Only the repository's head is ever
+// published. Publishing happens asynchronously; its outcome is reported
+// as a charm event.
+func (s *CharmStore) RequestPublish(curl *URL, repoURL, digest string) error {
+	form := url.Values{
+		"charm":      {curl.String()},
+		"repository": {repoURL},
+		"digest":     {digest},
+	}
+	req, err := http.NewRequest("POST", s.BaseURL+"/charm-publish", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("charm store refused to publish %s: %s", curl, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Search returns the latest revision of the charms
+// in the charm store that match the given criteria.
+func (s *CharmStore) Search(params SearchParams) ([]SearchResult, error) {
+	query := url.Values{}
+	if params.Text != "" {
+		query.Set("text", params.Text)
+	}
+	if params.Series != "" {
+		query.Set("series", params.Series)
+	}
+	if params.Owner != "" {
+		query.Set("owner", params.Owner)
+	}
+	if params.Limit > 0 {
+		query.Set("limit", fmt.Sprint(params.Limit))
+	}
+	if s.testMode {
+		query.Set("stats", "0")
+	}
+	resp, err := s.get(s.BaseURL + "/search?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("cannot search the charm store: %s: %s", resp.Status, body)
+	}
+	var response SearchResponse
+	if err = json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	return response.Results, nil
+}
+
 // revisions returns the revisions of the charms referenced by curls.
 func (s *CharmStore) revisions(curls ...Location) (revisions []CharmRevision, err error) {
 	infos, err := s.Info(curls...)
@@ -348,6 +463,45 @@ func verify(path, digest string) error {
 	return nil
 }
 
+// GetBundle returns the content of the bundle referenced by burl.
+func (s *CharmStore) GetBundle(burl *URL) (*BundleData, error) {
+	infos, err := s.BundleInfo(burl)
+	if err != nil {
+		return nil, err
+	}
+	info := infos[0]
+	if len(info.Errors) > 0 {
+		return nil, fmt.Errorf("bundle info errors for %q: %s", burl, strings.Join(info.Errors, "; "))
+	}
+	if burl.Revision == -1 {
+		burl = burl.WithRevision(info.Revision)
+	}
+	storeURL := s.BaseURL + "/bundle/" + url.QueryEscape(burl.Path())
+	if s.testMode {
+		storeURL += "?stats=0"
+	}
+	resp, err := s.get(storeURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("cannot get bundle %q from the charm store: %s", burl, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	hash, _, err := utils.ReadSHA256(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if hash != info.Sha256 {
+		return nil, fmt.Errorf("bad SHA256 of bundle %q", burl)
+	}
+	return ReadBundleData(bytes.NewReader(data))
+}
+
 // Get returns the charm referenced by curl.
 // CacheDir must have been set, otherwise Get will panic.
 func (s *CharmStore) Get(curl *URL) (Charm, error) {
@@ -408,13 +562,72 @@ func (s *CharmStore) Get(curl *URL) (Charm, error) {
 	return ReadBundle(path)
 }
 
+// SignatureSource is implemented by repositories that can provide
+// detached signatures for the charm archives they hold.
+type SignatureSource interface {
+	// Signature returns the armored detached OpenPGP signature
+	// of the archive of the charm at curl, which must include a
+	// revision, or nil if the charm archive isn't signed.
+	Signature(curl *URL) ([]byte, error)
+}
+
+var _ SignatureSource = (*CharmStore)(nil)
+
+// Signature returns the armored detached OpenPGP signature of the
+// archive of the charm at curl, as returned by Get.
+func (s *CharmStore) Signature(curl *URL) ([]byte, error) {
+	if curl.Revision == -1 {
+		return nil, fmt.Errorf("charm signature requested without revision: %q", curl)
+	}
+	resp, err := s.get(s.BaseURL + "/charm-signature/" + url.QueryEscape(curl.Path()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot get signature of charm %q from the charm store: %s", curl, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// UploadSignature stores signature in the charm store as the armored
+// detached OpenPGP signature of the archive of the charm at curl, which
+// must include a revision. The charm store only accepts signatures made
+// by the keys it trusts to sign charms.
+func (s *CharmStore) UploadSignature(curl *URL, signature []byte) error {
+	if curl.Revision == -1 {
+		return fmt.Errorf("charm signature uploaded without revision: %q", curl)
+	}
+	req, err := http.NewRequest("POST", s.BaseURL+"/charm-signature/"+url.QueryEscape(curl.Path()), bytes.NewReader(signature))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/pgp-signature")
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("charm store refused signature of %s: %s", curl, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
 // LocalRepository represents a local directory containing subdirectories
 // named after an Ubuntu series, each of which contains charms targeted for
-// that series. For example:
+// that series. Charms that declare the series they support in their
+// metadata may also be placed directly in the repository directory, and
+// are found for any of those series. For example:
 //
 //   /path/to/repository/oneiric/mongodb/
 //   /path/to/repository/precise/mongodb.charm
 //   /path/to/repository/precise/wordpress/
+//   /path/to/repository/haproxy/   (declaring series: [precise, trusty])
 type LocalRepository struct {
 	Path          string
 	defaultSeries string
@@ -430,7 +643,20 @@ func (r *LocalRepository) WithDefaultSeries(defaultSeries string) Repository {
 }
 
 // Resolve canonicalizes charm URLs, resolving references and implied series.
+// The default series is used unless the charm doesn't support it, in which
+// case the most preferred series the charm declares is used.
 func (r *LocalRepository) Resolve(ref Reference) (*URL, error) {
+	if r.defaultSeries != "" {
+		curl := &URL{Reference: ref, Series: r.defaultSeries}
+		if _, err := r.Get(curl); err == nil {
+			return curl, nil
+		}
+	}
+	if ch, err := r.find(r.Path, true, ref, ""); err != nil {
+		return nil, err
+	} else if ch != nil {
+		return &URL{Reference: ref, Series: ch.Meta().Series}, nil
+	}
 	if r.defaultSeries == "" {
 		return nil, fmt.Errorf("cannot resolve, repository has no default series: %q", ref)
 	}
@@ -468,8 +694,11 @@ func mightBeCharm(info os.FileInfo) bool {
 }
 
 // Get returns a charm matching curl, if one exists. If curl has a revision of
-// -1, it returns the latest charm that matches curl. If multiple candidates
-// satisfy the foregoing, the first one encountered will be returned.
+// -1, it returns the latest charm that matches curl, whether it's in the
+// series directory or is a multi-series charm at the top of the repository.
+// If multiple candidates satisfy the foregoing, a charm in the series
+// directory is preferred, and otherwise the first one encountered will be
+// returned.
 func (r *LocalRepository) Get(curl *URL) (Charm, error) {
 	if curl.Schema != "local" {
 		return nil, fmt.Errorf("local repository got URL with non-local schema: %q", curl)
@@ -484,14 +713,41 @@ func (r *LocalRepository) Get(curl *URL) (Charm, error) {
 	if !info.IsDir() {
 		return nil, repoNotFound(r.Path)
 	}
-	path := filepath.Join(r.Path, curl.Series)
-	infos, err := ioutil.ReadDir(path)
+	ch, err := r.find(filepath.Join(r.Path, curl.Series), false, curl.Reference, curl.Series)
+	if err != nil {
+		return nil, err
+	}
+	if ch != nil && curl.Revision != -1 {
+		return ch, nil
+	}
+	// The latest revision may be either in the series directory or
+	// among the multi-series charms; the series directory wins ties.
+	multiCh, err := r.find(r.Path, true, curl.Reference, curl.Series)
+	if err != nil {
+		return nil, err
+	}
+	if multiCh != nil && (ch == nil || multiCh.Revision() > ch.Revision()) {
+		ch = multiCh
+	}
+	if ch != nil {
+		return ch, nil
+	}
+	return nil, charmNotFound(curl, r.Path)
+}
+
+// find returns the charm in dir matching ref that supports series, or
+// nil if there's none. If ref has a revision of -1, it returns the latest
+// such charm. If multiSeries is true, dir is the repository directory,
+// which holds only charms that declare the series they support, and any
+// series is accepted if series is empty.
+func (r *LocalRepository) find(dir string, multiSeries bool, ref Reference, series string) (Charm, error) {
+	infos, err := ioutil.ReadDir(dir)
 	if err != nil {
-		return nil, charmNotFound(curl, r.Path)
+		return nil, nil
 	}
 	var latest Charm
 	for _, info := range infos {
-		chPath := filepath.Join(path, info.Name())
+		chPath := filepath.Join(dir, info.Name())
 		if info.Mode()&os.ModeSymlink != 0 {
 			var err error
 			if info, err = os.Stat(chPath); err != nil {
@@ -501,19 +757,36 @@ func (r *LocalRepository) Get(curl *URL) (Charm, error) {
 		if !mightBeCharm(info) {
 			continue
 		}
-		if ch, err := Read(chPath); err != nil {
-			logger.Warningf("failed to load charm at %q: %s", chPath, err)
-		} else if ch.Meta().Name == curl.Name {
-			if ch.Revision() == curl.Revision {
-				return ch, nil
-			}
-			if latest == nil || ch.Revision() > latest.Revision() {
-				latest = ch
+		if multiSeries && info.IsDir() {
+			// Skip the series directories.
+			if _, err := os.Stat(filepath.Join(chPath, "metadata.yaml")); err != nil {
+				continue
 			}
 		}
+		ch, err := Read(chPath)
+		if err != nil {
+			logger.Warningf("failed to load charm at %q: %s", chPath, err)
+			continue
+		}
+		meta := ch.Meta()
+		if meta.Name != ref.Name {
+			continue
+		}
+		if multiSeries && len(meta.DeclaredSeries()) == 0 {
+			continue
+		}
+		if series != "" && !meta.SupportsSeries(series) {
+			continue
+		}
+		if ch.Revision() == ref.Revision {
+			return ch, nil
+		}
+		if latest == nil || ch.Revision() > latest.Revision() {
+			latest = ch
+		}
 	}
-	if curl.Revision == -1 && latest != nil {
+	if ref.Revision == -1 {
 		return latest, nil
 	}
-	return nil, charmNotFound(curl, r.Path)
+	return nil, nil
 }
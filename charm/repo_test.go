@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	gc "launchpad.net/gocheck"
 
@@ -45,6 +46,7 @@ func (s *StoreSuite) SetUpTest(c *gc.C) {
 	s.server.DownloadsNoStats = nil
 	s.server.InfoRequestCount = 0
 	s.server.InfoRequestCountNoStats = 0
+	s.server.SearchRequests = nil
 }
 
 func (s *StoreSuite) TearDownSuite(c *gc.C) {
@@ -234,6 +236,57 @@ func (s *StoreSuite) TestInfoDNSError(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, expect)
 }
 
+func (s *StoreSuite) TestBundleInfo(c *gc.C) {
+	s.server.SetBundle("cs:bundle/wordpress", 3, []byte("services: {}"))
+	infos, err := s.store.BundleInfo(
+		charm.MustParseURL("cs:bundle/wordpress"),
+		charm.MustParseURL("cs:bundle/missing"),
+	)
+	c.Assert(err, gc.IsNil)
+	c.Assert(infos, gc.HasLen, 2)
+	c.Assert(infos[0].Errors, gc.IsNil)
+	c.Assert(infos[0].Revision, gc.Equals, 3)
+	c.Assert(infos[0].CanonicalURL, gc.Equals, "cs:bundle/wordpress")
+	c.Assert(infos[1].Errors, gc.DeepEquals, []string{"bundle not found: cs:bundle/missing"})
+}
+
+func (s *StoreSuite) TestGetBundle(c *gc.C) {
+	data := "series: precise\nservices:\n    wordpress:\n        charm: wordpress\n"
+	s.server.SetBundle("cs:~joe/bundle/blog", 1, []byte(data))
+	bd, err := s.store.GetBundle(charm.MustParseURL("cs:~joe/bundle/blog"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(bd, gc.DeepEquals, &charm.BundleData{
+		Series: "precise",
+		Services: map[string]*charm.ServiceSpec{
+			"wordpress": {Charm: "wordpress"},
+		},
+	})
+
+	_, err = s.store.GetBundle(charm.MustParseURL("cs:bundle/missing"))
+	c.Assert(err, gc.ErrorMatches, `bundle info errors for "cs:bundle/missing": bundle not found: cs:bundle/missing`)
+}
+
+func (s *StoreSuite) TestSignature(c *gc.C) {
+	charmURL := charm.MustParseURL("cs:series/good-23")
+	signature, err := s.store.Signature(charmURL)
+	c.Assert(err, gc.IsNil)
+	c.Assert(signature, gc.IsNil)
+
+	s.server.SignCharms(c, charmtesting.SigningPrivateKey)
+	signature, err = s.store.Signature(charmURL)
+	c.Assert(err, gc.IsNil)
+	ch, err := s.store.Get(charmURL)
+	c.Assert(err, gc.IsNil)
+	f, err := os.Open(ch.(*charm.Bundle).Path)
+	c.Assert(err, gc.IsNil)
+	defer f.Close()
+	_, err = charm.CheckArchiveSignature(f, signature, charmtesting.SigningPublicKey)
+	c.Assert(err, gc.IsNil)
+
+	_, err = s.store.Signature(charmURL.WithRevision(-1))
+	c.Assert(err, gc.ErrorMatches, `charm signature requested without revision: "cs:series/good"`)
+}
+
 func (s *StoreSuite) TestEvent(c *gc.C) {
 	charmURL := charm.MustParseURL("cs:series/good")
 	event, err := s.store.Event(charmURL, "")
@@ -328,6 +381,33 @@ func (s *StoreSuite) TestEventWarning(c *gc.C) {
 	c.Assert(event.Warnings, gc.DeepEquals, []string{"foolishness"})
 }
 
+func (s *StoreSuite) TestSearch(c *gc.C) {
+	results, err := s.store.Search(charm.SearchParams{Text: "be", Series: "series", Limit: 5})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.DeepEquals, []charm.SearchResult{{
+		URL:      "cs:series/best",
+		Name:     "best",
+		Series:   "series",
+		Revision: 25,
+		Summary:  "A best charm.",
+	}, {
+		URL:      "cs:series/better",
+		Name:     "better",
+		Series:   "series",
+		Revision: 24,
+		Summary:  "A better charm.",
+	}})
+	c.Assert(s.server.SearchRequests, gc.HasLen, 1)
+	c.Assert(s.server.SearchRequests[0].Get("text"), gc.Equals, "be")
+	c.Assert(s.server.SearchRequests[0].Get("series"), gc.Equals, "series")
+	c.Assert(s.server.SearchRequests[0].Get("limit"), gc.Equals, "5")
+	c.Assert(s.server.SearchRequests[0]["owner"], gc.HasLen, 0)
+
+	results, err = s.store.Search(charm.SearchParams{Text: "missing"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 0)
+}
+
 func (s *StoreSuite) TestBranchLocation(c *gc.C) {
 	charmURL := charm.MustParseURL("cs:series/name")
 	location := s.store.BranchLocation(charmURL)
@@ -525,3 +605,108 @@ func (s *LocalRepoSuite) TestFindsSymlinks(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	checkDummy(c, ch, linkPath)
 }
+
+// addMultiSeriesDir adds a copy of the named testing charm to the
+// top of the repository, declaring the given series.
+func (s *LocalRepoSuite) addMultiSeriesDir(c *gc.C, name string, series ...string) string {
+	path := testing.Charms.ClonedDirPath(s.repo.Path, name)
+	metaPath := filepath.Join(path, "metadata.yaml")
+	data, err := ioutil.ReadFile(metaPath)
+	c.Assert(err, gc.IsNil)
+	data = append(data, fmt.Sprintf("\nseries: [%s]\n", strings.Join(series, ", "))...)
+	err = ioutil.WriteFile(metaPath, data, 0644)
+	c.Assert(err, gc.IsNil)
+	return path
+}
+
+func (s *LocalRepoSuite) TestMultiSeries(c *gc.C) {
+	path := s.addMultiSeriesDir(c, "dummy", "precise", "trusty", "quantal")
+	for _, str := range []string{"local:precise/dummy", "local:trusty/dummy-1", "local:quantal/dummy"} {
+		ch, err := s.repo.Get(charm.MustParseURL(str))
+		c.Assert(err, gc.IsNil)
+		checkDummy(c, ch, path)
+		rev, err := charm.Latest(s.repo, charm.MustParseURL(str))
+		c.Assert(err, gc.IsNil)
+		c.Assert(rev, gc.Equals, 1)
+	}
+	charmURL := charm.MustParseURL("local:raring/dummy")
+	_, err := s.repo.Get(charmURL)
+	s.checkNotFoundErr(c, err, charmURL)
+
+	// A charm in a series directory takes precedence.
+	s.addDir("dummy")
+	ch, err := s.repo.Get(charm.MustParseURL("local:quantal/dummy"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(ch.Meta().DeclaredSeries(), gc.HasLen, 0)
+	c.Assert(c.GetTestLog(), gc.Equals, "")
+}
+
+func (s *LocalRepoSuite) TestMultiSeriesLatest(c *gc.C) {
+	seriesDir, err := charm.ReadDir(s.addDir("dummy"))
+	c.Assert(err, gc.IsNil)
+	multiDir, err := charm.ReadDir(s.addMultiSeriesDir(c, "dummy", "precise", "quantal"))
+	c.Assert(err, gc.IsNil)
+	curl := charm.MustParseURL("local:quantal/dummy")
+
+	// The latest revision is found among the multi-series charms...
+	err = multiDir.SetDiskRevision(5)
+	c.Assert(err, gc.IsNil)
+	ch, err := s.repo.Get(curl)
+	c.Assert(err, gc.IsNil)
+	c.Assert(ch.Revision(), gc.Equals, 5)
+	c.Assert(ch.Meta().DeclaredSeries(), gc.HasLen, 2)
+	rev, err := charm.Latest(s.repo, curl)
+	c.Assert(err, gc.IsNil)
+	c.Assert(rev, gc.Equals, 5)
+
+	// ...or in the series directory.
+	err = seriesDir.SetDiskRevision(7)
+	c.Assert(err, gc.IsNil)
+	ch, err = s.repo.Get(curl)
+	c.Assert(err, gc.IsNil)
+	c.Assert(ch.Revision(), gc.Equals, 7)
+	c.Assert(ch.Meta().DeclaredSeries(), gc.HasLen, 0)
+
+	// Specific revisions are found in either.
+	ch, err = s.repo.Get(curl.WithRevision(5))
+	c.Assert(err, gc.IsNil)
+	c.Assert(ch.Revision(), gc.Equals, 5)
+}
+
+func (s *LocalRepoSuite) TestIgnoresSeriesLessCharmsAtTop(c *gc.C) {
+	testing.Charms.ClonedDirPath(s.repo.Path, "dummy")
+	charmURL := charm.MustParseURL("local:quantal/dummy")
+	_, err := s.repo.Get(charmURL)
+	s.checkNotFoundErr(c, err, charmURL)
+}
+
+func (s *LocalRepoSuite) TestResolve(c *gc.C) {
+	s.addDir("dummy")
+	s.addMultiSeriesDir(c, "wordpress", "trusty", "precise")
+	ref, _, err := charm.ParseReference("local:wordpress")
+	c.Assert(err, gc.IsNil)
+
+	// The charm's preferred series is used by default...
+	curl, err := s.repo.Resolve(ref)
+	c.Assert(err, gc.IsNil)
+	c.Assert(curl.String(), gc.Equals, "local:trusty/wordpress")
+
+	// ...unless the default series is supported.
+	repo := s.repo.WithDefaultSeries("precise")
+	curl, err = repo.Resolve(ref)
+	c.Assert(err, gc.IsNil)
+	c.Assert(curl.String(), gc.Equals, "local:precise/wordpress")
+	repo = s.repo.WithDefaultSeries("quantal")
+	curl, err = repo.Resolve(ref)
+	c.Assert(err, gc.IsNil)
+	c.Assert(curl.String(), gc.Equals, "local:trusty/wordpress")
+
+	// Charms declaring no series need a default series.
+	ref, _, err = charm.ParseReference("local:dummy")
+	c.Assert(err, gc.IsNil)
+	_, err = s.repo.Resolve(ref)
+	c.Assert(err, gc.ErrorMatches, `cannot resolve, repository has no default series: "local:dummy"`)
+	curl, err = repo.Resolve(ref)
+	c.Assert(err, gc.IsNil)
+	c.Assert(curl.String(), gc.Equals, "local:quantal/dummy")
+}
@@ -0,0 +1,78 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"code.google.com/p/go.crypto/openpgp"
+)
+
+// SignatureSuffix is appended to the path of a charm archive to
+// find the detached signature accompanying it, if any.
+const SignatureSuffix = ".asc"
+
+// SignArchive returns an armored detached OpenPGP signature of the
+// charm archive read from r, made with the first key of the armored
+// private keyring, which is decrypted with passphrase if necessary.
+func SignArchive(r io.Reader, armoredPrivateKey, passphrase string) ([]byte, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredPrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read private key: %v", err)
+	}
+	signer := keyring[0]
+	if signer.PrivateKey == nil {
+		return nil, fmt.Errorf("cannot sign charm archive: no private key")
+	}
+	if signer.PrivateKey.Encrypted {
+		if err := signer.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+			return nil, fmt.Errorf("cannot decrypt private key: %v", err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&buf, signer, r, nil); err != nil {
+		return nil, fmt.Errorf("cannot sign charm archive: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SignatureError is returned by CheckArchiveSignature when a
+// charm archive isn't signed by a trusted key.
+type SignatureError struct {
+	Reason string
+}
+
+func (e *SignatureError) Error() string {
+	return "charm signature verification failed: " + e.Reason
+}
+
+// CheckArchiveSignature checks that signature is a valid armored
+// detached OpenPGP signature of the charm archive read from r, made
+// by one of the keys in the armored public keyring. It returns the
+// identities of the signing key. Unsigned archives, malformed
+// signatures and signatures made by untrusted keys result in a
+// *SignatureError.
+func CheckArchiveSignature(r io.Reader, signature []byte, armoredKeyring string) ([]string, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredKeyring))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read trusted keyring: %v", err)
+	}
+	if len(signature) == 0 {
+		return nil, &SignatureError{"charm archive is not signed"}
+	}
+	signer, err := openpgp.CheckArmoredDetachedSignature(keyring, r, bytes.NewReader(signature))
+	if err != nil {
+		return nil, &SignatureError{err.Error()}
+	}
+	var identities []string
+	for name := range signer.Identities {
+		identities = append(identities, name)
+	}
+	sort.Strings(identities)
+	return identities, nil
+}
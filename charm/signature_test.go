@@ -0,0 +1,73 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm_test
+
+import (
+	"bytes"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/charm"
+	charmtesting "github.com/juju/core/charm/testing"
+)
+
+type SignatureSuite struct{}
+
+var _ = gc.Suite(&SignatureSuite{})
+
+var archiveData = []byte("some charm archive content")
+
+func sign(c *gc.C, data []byte, privateKey string) []byte {
+	signature, err := charm.SignArchive(bytes.NewReader(data), privateKey, "")
+	c.Assert(err, gc.IsNil)
+	return signature
+}
+
+func (s *SignatureSuite) TestSignAndCheck(c *gc.C) {
+	signature := sign(c, archiveData, charmtesting.SigningPrivateKey)
+	c.Assert(string(signature), gc.Matches, "(?s)-----BEGIN PGP SIGNATURE-----.*")
+
+	identities, err := charm.CheckArchiveSignature(bytes.NewReader(archiveData), signature, charmtesting.SigningPublicKey)
+	c.Assert(err, gc.IsNil)
+	c.Assert(identities, gc.DeepEquals, []string{"Charm Signer <signer@example.com>"})
+}
+
+func (s *SignatureSuite) TestCheckFailures(c *gc.C) {
+	signature := sign(c, archiveData, charmtesting.SigningPrivateKey)
+	untrusted := sign(c, archiveData, charmtesting.UntrustedPrivateKey)
+	for i, test := range []struct {
+		data      []byte
+		signature []byte
+		err       string
+	}{{
+		data: archiveData,
+		err:  "charm archive is not signed",
+	}, {
+		data:      []byte("tampered charm archive content"),
+		signature: signature,
+		err:       "openpgp: .*",
+	}, {
+		data:      archiveData,
+		signature: untrusted,
+		err:       "openpgp: signature made by unknown entity",
+	}, {
+		data:      archiveData,
+		signature: []byte("not a signature"),
+		err:       ".*",
+	}} {
+		c.Logf("test %d", i)
+		_, err := charm.CheckArchiveSignature(bytes.NewReader(test.data), test.signature, charmtesting.SigningPublicKey)
+		c.Check(err, gc.ErrorMatches, "charm signature verification failed: "+test.err)
+		c.Check(err, gc.FitsTypeOf, &charm.SignatureError{})
+	}
+}
+
+func (s *SignatureSuite) TestBadKeys(c *gc.C) {
+	_, err := charm.SignArchive(bytes.NewReader(archiveData), "bad key", "")
+	c.Assert(err, gc.ErrorMatches, "cannot read private key: .*")
+	_, err = charm.SignArchive(bytes.NewReader(archiveData), charmtesting.SigningPublicKey, "")
+	c.Assert(err, gc.ErrorMatches, "cannot sign charm archive: no private key")
+	_, err = charm.CheckArchiveSignature(bytes.NewReader(archiveData), nil, "bad keyring")
+	c.Assert(err, gc.ErrorMatches, "cannot read trusted keyring: .*")
+}
@@ -8,9 +8,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -30,20 +33,32 @@ type MockStore struct {
 	listener                net.Listener
 	bundleBytes             []byte
 	bundleSha256            string
+	bundleSignature         []byte
 	Downloads               []*charm.URL
 	DownloadsNoStats        []*charm.URL
 	Authorizations          []string
 	Metadata                []string
 	InfoRequestCount        int
 	InfoRequestCountNoStats int
+	SearchRequests          []url.Values
 	DefaultSeries           string
 
-	charms map[string]int
+	charms  map[string]int
+	bundles map[string]mockBundle
+}
+
+type mockBundle struct {
+	revision int
+	data     []byte
 }
 
 // NewMockStore creates a mock charm store containing the specified charms.
 func NewMockStore(c *gc.C, charms map[string]int) *MockStore {
-	s := &MockStore{charms: charms, DefaultSeries: "precise"}
+	s := &MockStore{
+		charms:        charms,
+		bundles:       make(map[string]mockBundle),
+		DefaultSeries: "precise",
+	}
 	f, err := os.Open(testing.Charms.BundlePath(c.MkDir(), "dummy"))
 	c.Assert(err, gc.IsNil)
 	defer f.Close()
@@ -56,6 +71,10 @@ func NewMockStore(c *gc.C, charms map[string]int) *MockStore {
 	s.mux.HandleFunc("/charm-info", s.serveInfo)
 	s.mux.HandleFunc("/charm-event", s.serveEvent)
 	s.mux.HandleFunc("/charm/", s.serveCharm)
+	s.mux.HandleFunc("/charm-signature/", s.serveSignature)
+	s.mux.HandleFunc("/bundle-info", s.serveBundleInfo)
+	s.mux.HandleFunc("/bundle/", s.serveBundle)
+	s.mux.HandleFunc("/search", s.serveSearch)
 	lis, err := net.Listen("tcp", "127.0.0.1:0")
 	c.Assert(err, gc.IsNil)
 	s.listener = lis
@@ -78,6 +97,25 @@ func (s *MockStore) UpdateStoreRevision(ch string, rev int) {
 	s.charms[ch] = rev
 }
 
+// SetBundle makes the given bundle data available in the mock store
+// as the latest revision, rev, of the bundle with the given URL.
+func (s *MockStore) SetBundle(burl string, rev int, data []byte) {
+	s.bundles[burl] = mockBundle{rev, data}
+}
+
+// SignCharms makes the mock store serve signatures of its charm
+// archives made with the given armored private key. An empty key
+// makes it serve charms unsigned again.
+func (s *MockStore) SignCharms(c *gc.C, armoredPrivateKey string) {
+	if armoredPrivateKey == "" {
+		s.bundleSignature = nil
+		return
+	}
+	signature, err := charm.SignArchive(bytes.NewReader(s.bundleBytes), armoredPrivateKey, "")
+	c.Assert(err, gc.IsNil)
+	s.bundleSignature = signature
+}
+
 // ServeHTTP implements http.ServeHTTP
 func (s *MockStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.mux.ServeHTTP(w, r)
@@ -212,3 +250,120 @@ func (s *MockStore) serveCharm(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 }
+
+func (s *MockStore) serveSignature(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		// All charms share the same archive, so the
+		// uploaded signature is served for all of them.
+		signature, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+		s.bundleSignature = signature
+		return
+	}
+	if s.bundleSignature == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pgp-signature")
+	_, err := w.Write(s.bundleSignature)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// serveSearch finds the charms whose name contains all the words of
+// the searched text.
+func (s *MockStore) serveSearch(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	s.SearchRequests = append(s.SearchRequests, r.Form)
+	var names []string
+	for name := range s.charms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	limit, _ := strconv.Atoi(r.Form.Get("limit"))
+	response := &charm.SearchResponse{Results: []charm.SearchResult{}}
+	for _, name := range names {
+		if limit > 0 && len(response.Results) == limit {
+			break
+		}
+		charmURL := charm.MustParseURL(name)
+		if !searchMatches(charmURL, r.Form) {
+			continue
+		}
+		response.Results = append(response.Results, charm.SearchResult{
+			URL:      charmURL.String(),
+			Name:     charmURL.Name,
+			Owner:    charmURL.User,
+			Series:   charmURL.Series,
+			Revision: s.charms[name],
+			Summary:  "A " + charmURL.Name + " charm.",
+		})
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		panic(err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func searchMatches(charmURL *charm.URL, form url.Values) bool {
+	if series := form.Get("series"); series != "" && series != charmURL.Series {
+		return false
+	}
+	if owner := form.Get("owner"); owner != "" && owner != charmURL.User {
+		return false
+	}
+	for _, word := range strings.Fields(form.Get("text")) {
+		if !strings.Contains(charmURL.Name, strings.ToLower(word)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *MockStore) serveBundleInfo(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	response := map[string]*charm.InfoResponse{}
+	for _, burl := range r.Form["bundles"] {
+		br := &charm.InfoResponse{}
+		response[burl] = br
+		bundleURL := charm.MustParseURL(burl)
+		if b, ok := s.bundles[bundleURL.WithRevision(-1).String()]; ok {
+			br.Revision = b.revision
+			br.Sha256, _, _ = utils.ReadSHA256(bytes.NewReader(b.data))
+			br.CanonicalURL = bundleURL.String()
+		} else {
+			br.Errors = append(br.Errors, "entry not found")
+		}
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		panic(err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *MockStore) serveBundle(w http.ResponseWriter, r *http.Request) {
+	bundleURL := charm.MustParseURL("cs:" + r.URL.Path[len("/bundle/"):])
+	b, ok := s.bundles[bundleURL.WithRevision(-1).String()]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-yaml")
+	_, err := w.Write(b.data)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -5,10 +5,13 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
 
+	"github.com/juju/core/environs/charmmirror"
+	"github.com/juju/core/environs/filestorage"
 	"github.com/juju/core/store"
 )
 
@@ -34,6 +37,9 @@ func serve() error {
 	if err != nil {
 		return err
 	}
+	if conf.MirrorDir != "" {
+		return serveMirror(conf)
+	}
 	if conf.MongoURL == "" || conf.APIAddr == "" {
 		return fmt.Errorf("missing mongo-url or api-addr in config file")
 	}
@@ -46,5 +52,26 @@ func serve() error {
 	if err != nil {
 		return err
 	}
+	server.AllowGitPublishing(conf.GitHosts...)
+	if conf.SignersKeyring != "" {
+		keyring, err := ioutil.ReadFile(conf.SignersKeyring)
+		if err != nil {
+			return err
+		}
+		server.AllowSignatureUploads(string(keyring))
+	}
 	return http.ListenAndServe(conf.APIAddr, server)
 }
+
+// serveMirror serves the charm mirror in conf.MirrorDir,
+// as written by juju charm-mirror --local-dir.
+func serveMirror(conf *store.Config) error {
+	if conf.APIAddr == "" {
+		return fmt.Errorf("missing api-addr in config file")
+	}
+	stor, err := filestorage.NewFileStorageReader(conf.MirrorDir)
+	if err != nil {
+		return err
+	}
+	return http.ListenAndServe(conf.APIAddr, charmmirror.NewServer(stor))
+}
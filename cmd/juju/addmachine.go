@@ -34,7 +34,7 @@ When adding a new machine, you may specify constraints for the machine to be
 provisioned.  Constraints cannot be combined with deploying a container to an
 existing machine.
 
-Currently, the only supported container type is lxc.
+The supported container types are lxc, kvm and docker.
 
 Machines are created in a clean state and ready to have units deployed.
 
@@ -46,6 +46,7 @@ Examples:
    juju add-machine                      (starts a new machine)
    juju add-machine lxc                  (starts a new machine with an lxc container)
    juju add-machine lxc:4                (starts a new lxc container on machine 4)
+   juju add-machine docker:3             (starts a new docker container on machine 3)
    juju add-machine --constraints mem=8G (starts a machine with at least 8GB RAM)
    juju add-machine ssh:user@10.10.0.3   (manually provisions a machine with ssh)
 
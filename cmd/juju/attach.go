@@ -0,0 +1,85 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/juju"
+	"github.com/juju/core/names"
+)
+
+const attachDoc = `
+Upload the content of a file as a new revision of a resource of the
+specified service. The resource must be declared in the "resources"
+section of the metadata of the service's charm. The content is stored in
+the environment, and the service's units fetch the latest revision when
+their hooks run the resource-get tool.
+
+Example:
+   juju attach tomcat jdk ./jdk-7u60-linux-x64.tar.gz
+`
+
+// AttachCommand uploads the content of a resource of a service.
+type AttachCommand struct {
+	envcmd.EnvCommandBase
+	ServiceName  string
+	ResourceName string
+	Path         string
+}
+
+func (c *AttachCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "attach",
+		Args:    "<service> <resource> <file>",
+		Purpose: "upload a resource of a service",
+		Doc:     attachDoc,
+	}
+}
+
+func (c *AttachCommand) Init(args []string) error {
+	switch len(args) {
+	case 0:
+		return errors.New("no service name specified")
+	case 1:
+		return errors.New("no resource name specified")
+	case 2:
+		return errors.New("no file specified")
+	}
+	c.ServiceName, c.ResourceName, c.Path = args[0], args[1], args[2]
+	if !names.IsService(c.ServiceName) {
+		return fmt.Errorf("invalid service name %q", c.ServiceName)
+	}
+	if !charm.IsValidName(c.ResourceName) {
+		return fmt.Errorf("invalid resource name %q", c.ResourceName)
+	}
+	return cmd.CheckEmpty(args[3:])
+}
+
+// Run uploads the file and reports the revision of the
+// resource it became.
+func (c *AttachCommand) Run(ctx *cmd.Context) error {
+	f, err := os.Open(ctx.AbsPath(c.Path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	res, err := client.AttachResource(c.ServiceName, c.ResourceName, f)
+	if err != nil {
+		return err
+	}
+	ctx.Infof("added revision %d of resource %q to service %q", res.Revision, res.Name, c.ServiceName)
+	return nil
+}
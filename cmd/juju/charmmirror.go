@@ -0,0 +1,101 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/loggo"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/environs/charmmirror"
+	"github.com/juju/core/environs/filestorage"
+)
+
+var mirrorCharms = charmmirror.Mirror
+
+const charmMirrorDoc = `
+Copy charms and bundles from the charm store into the environment's
+storage, or into a local directory, so that they can be deployed in
+environments without access to the charm store. The charms used by
+bundles are copied as well. A charm URL without a revision copies the
+latest revision; revisions already mirrored are skipped, so the
+command can be run again to bring a mirror up to date.
+
+A mirror in the environment's storage is served over HTTP by the
+state servers when the environment's charm-mirror-port setting is
+set. A mirror in a local directory can be served by charmd with the
+mirror-dir setting. Environments use a mirror when their
+charm-store-url setting holds its address, such as
+http://<state server address>:<charm-mirror-port>.
+
+Examples:
+   juju charm-mirror cs:precise/mysql cs:precise/wordpress-12
+   juju charm-mirror --local-dir /srv/charm-mirror cs:bundle/wordpress-simple
+`
+
+// CharmMirrorCommand copies charms from the charm store
+// into a charm mirror.
+type CharmMirrorCommand struct {
+	envcmd.EnvCommandBase
+	source   string
+	localDir string
+	dryRun   bool
+	urls     []string
+}
+
+var _ cmd.Command = (*CharmMirrorCommand)(nil)
+
+func (c *CharmMirrorCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "charm-mirror",
+		Args:    "<charm or bundle url> ...",
+		Purpose: "copy charms from the charm store into a charm mirror",
+		Doc:     charmMirrorDoc,
+	}
+}
+
+func (c *CharmMirrorCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.source, "source", charm.Store.BaseURL, "the URL of the charm store to copy from")
+	f.StringVar(&c.localDir, "local-dir", "", "local destination directory")
+	f.BoolVar(&c.dryRun, "dry-run", false, "don't copy, just print what would be copied")
+}
+
+func (c *CharmMirrorCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no charm or bundle URL specified")
+	}
+	c.urls = args
+	return nil
+}
+
+func (c *CharmMirrorCommand) Run(ctx *cmd.Context) (resultErr error) {
+	// Register writer for output on screen.
+	loggo.RegisterWriter("charmmirror", cmd.NewCommandLogWriter("juju.environs.charmmirror", ctx.Stdout, ctx.Stderr), loggo.INFO)
+	defer loggo.RemoveWriter("charmmirror")
+	mctx := &charmmirror.Context{
+		Source: &charm.CharmStore{BaseURL: strings.TrimRight(c.source, "/")},
+		URLs:   c.urls,
+		DryRun: c.dryRun,
+	}
+	if c.localDir != "" {
+		target, err := filestorage.NewFileStorageWriter(c.localDir)
+		if err != nil {
+			return err
+		}
+		mctx.Target = target
+		return mirrorCharms(mctx)
+	}
+	environ, cleanup, err := environFromName(ctx, c.EnvName, &resultErr, "Charm-mirror")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	mctx.Target = environ.Storage()
+	return mirrorCharms(mctx)
+}
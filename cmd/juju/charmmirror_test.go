@@ -0,0 +1,89 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/environs"
+	"github.com/juju/core/environs/charmmirror"
+	"github.com/juju/core/environs/configstore"
+	"github.com/juju/core/provider/dummy"
+	coretesting "github.com/juju/core/testing"
+)
+
+type charmMirrorSuite struct {
+	coretesting.FakeJujuHomeSuite
+	configStore configstore.Storage
+}
+
+var _ = gc.Suite(&charmMirrorSuite{})
+
+func (s *charmMirrorSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	coretesting.WriteEnvironments(c, `
+environments:
+    test-target:
+        type: dummy
+        state-server: false
+        authorized-keys: "not-really-one"
+`)
+	var err error
+	s.configStore, err = configstore.Default()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *charmMirrorSuite) TearDownTest(c *gc.C) {
+	dummy.Reset()
+	s.FakeJujuHomeSuite.TearDownTest(c)
+}
+
+func runCharmMirrorCommand(c *gc.C, args ...string) (*cmd.Context, error) {
+	return coretesting.RunCommand(c, envcmd.Wrap(&CharmMirrorCommand{}), args...)
+}
+
+func (s *charmMirrorSuite) TestNoURLs(c *gc.C) {
+	_, err := runCharmMirrorCommand(c, "-e", "test-target")
+	c.Assert(err, gc.ErrorMatches, "no charm or bundle URL specified")
+}
+
+func (s *charmMirrorSuite) TestMirrorToEnvironment(c *gc.C) {
+	targetEnv, err := environs.PrepareFromName("test-target", nullContext(c), s.configStore)
+	c.Assert(err, gc.IsNil)
+	called := false
+	s.PatchValue(&mirrorCharms, func(mctx *charmmirror.Context) error {
+		c.Assert(mctx.Source.BaseURL, gc.Equals, charm.Store.BaseURL)
+		c.Assert(mctx.URLs, gc.DeepEquals, []string{"cs:precise/mysql", "cs:bundle/blog-2"})
+		c.Assert(mctx.DryRun, jc.IsFalse)
+		c.Assert(dummy.IsSameStorage(mctx.Target, targetEnv.Storage()), jc.IsTrue)
+		called = true
+		return nil
+	})
+	_, err = runCharmMirrorCommand(c, "-e", "test-target", "cs:precise/mysql", "cs:bundle/blog-2")
+	c.Assert(err, gc.IsNil)
+	c.Assert(called, jc.IsTrue)
+}
+
+func (s *charmMirrorSuite) TestMirrorToLocalDirectory(c *gc.C) {
+	dir := c.MkDir()
+	called := false
+	s.PatchValue(&mirrorCharms, func(mctx *charmmirror.Context) error {
+		c.Assert(mctx.Source.BaseURL, gc.Equals, "http://10.0.0.1:8080")
+		c.Assert(mctx.URLs, gc.DeepEquals, []string{"cs:precise/mysql"})
+		c.Assert(mctx.DryRun, jc.IsTrue)
+		url, err := mctx.Target.URL("")
+		c.Assert(err, gc.IsNil)
+		c.Assert(url, gc.Equals, "file://"+dir)
+		called = true
+		return nil
+	})
+	_, err := runCharmMirrorCommand(c,
+		"--local-dir", dir, "--source", "http://10.0.0.1:8080/", "--dry-run", "cs:precise/mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(called, jc.IsTrue)
+}
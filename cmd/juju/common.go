@@ -54,27 +54,34 @@ func environFromName(
 
 // resolveCharmURL returns a resolved charm URL, given a charm location string.
 // If the series is not resolved, the environment default-series is used, or if
-// not set, the series is resolved with the state server.
-func resolveCharmURL(url string, client *api.Client, conf *config.Config) (*charm.URL, error) {
+// not set, the series is resolved with the state server. Local charms are
+// resolved in the local repository at repoPath, if given, so that charms
+// declaring the series they support are resolved to a series they support.
+func resolveCharmURL(url string, client *api.Client, conf *config.Config, repoPath string) (*charm.URL, error) {
 	ref, series, err := charm.ParseReference(url)
 	if err != nil {
 		return nil, err
 	}
-	// If series is not set, use configured default series
-	if series == "" {
-		if defaultSeries, ok := conf.DefaultSeries(); ok {
-			series = defaultSeries
+	if series != "" {
+		return &charm.URL{Reference: ref, Series: series}, nil
+	}
+	defaultSeries, _ := conf.DefaultSeries()
+	if ref.Schema == "local" && repoPath != "" {
+		repo := &charm.LocalRepository{Path: repoPath}
+		if curl, err := repo.WithDefaultSeries(defaultSeries).Resolve(ref); err == nil {
+			return curl, nil
 		}
 	}
+	// If series is not set, use configured default series
+	if defaultSeries != "" {
+		return &charm.URL{Reference: ref, Series: defaultSeries}, nil
+	}
 	// Otherwise, look up the best supported series for this charm
-	if series == "" {
-		if ref.Schema == "local" {
-			possibleUrl := &charm.URL{Reference: ref, Series: "precise"}
-			logger.Errorf(`The series is not specified in the environment (default-series) or with the charm. Did you mean:
+	if ref.Schema == "local" {
+		possibleUrl := &charm.URL{Reference: ref, Series: "precise"}
+		logger.Errorf(`The series is not specified in the environment (default-series) or with the charm. Did you mean:
 	%s`, possibleUrl.String())
-			return nil, fmt.Errorf("cannot resolve series for charm: %q", ref)
-		}
-		return client.ResolveCharm(ref)
+		return nil, fmt.Errorf("cannot resolve series for charm: %q", ref)
 	}
-	return &charm.URL{Reference: ref, Series: series}, nil
+	return client.ResolveCharm(ref)
 }
@@ -0,0 +1,98 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/juju"
+)
+
+const configHistoryDoc = `
+Show the recorded changes to the configuration of the specified service,
+oldest first. Each change made with the set or unset commands, or by
+rolling back, is recorded as a new settings revision, along with the user
+that made it, when it was made, the charm the service was running and the
+options that changed. The configuration can be restored to that of an
+earlier revision with "juju set --rollback <revision> <service>".
+`
+
+// ConfigHistoryCommand shows the configuration history of a service.
+type ConfigHistoryCommand struct {
+	envcmd.EnvCommandBase
+	ServiceName string
+	out         cmd.Output
+}
+
+func (c *ConfigHistoryCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "config-history",
+		Args:    "<service>",
+		Purpose: "show the configuration history of a service",
+		Doc:     configHistoryDoc,
+	}
+}
+
+func (c *ConfigHistoryCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+func (c *ConfigHistoryCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no service name specified")
+	}
+	c.ServiceName = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+type configRevision struct {
+	Revision int                     `yaml:"revision" json:"revision"`
+	User     string                  `yaml:"user,omitempty" json:"user,omitempty"`
+	Time     string                  `yaml:"time" json:"time"`
+	Charm    string                  `yaml:"charm" json:"charm"`
+	Changes  map[string]configChange `yaml:"changes" json:"changes"`
+}
+
+type configChange struct {
+	Old interface{} `yaml:"old,omitempty" json:"old,omitempty"`
+	New interface{} `yaml:"new,omitempty" json:"new,omitempty"`
+}
+
+// Run fetches the configuration history of the service
+// and writes it out.
+func (c *ConfigHistoryCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	revisions, err := client.ServiceConfigHistory(c.ServiceName)
+	if err != nil {
+		return err
+	}
+	result := []configRevision{}
+	for _, rev := range revisions {
+		changes := make(map[string]configChange)
+		for _, change := range rev.Changes {
+			changes[change.Key] = configChange{change.Old, change.New}
+		}
+		result = append(result, configRevision{
+			Revision: rev.Revision,
+			User:     rev.User,
+			Time:     rev.Time.UTC().Format(time.RFC3339),
+			Charm:    rev.Charm,
+			Changes:  changes,
+		})
+	}
+	return c.out.Write(ctx, result)
+}
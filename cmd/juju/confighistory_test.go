@@ -0,0 +1,64 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/juju/testing"
+	coretesting "github.com/juju/core/testing"
+)
+
+type ConfigHistorySuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&ConfigHistorySuite{})
+
+func (s *ConfigHistorySuite) TestConfigHistory(c *gc.C) {
+	svc := s.AddTestingService(c, "dummy-service", s.AddTestingCharm(c, "dummy"))
+	err := svc.UpdateConfigSettingsBy(charm.Settings{"title": "foo"}, "admin", -1)
+	c.Assert(err, gc.IsNil)
+	err = svc.UpdateConfigSettingsBy(charm.Settings{"title": "bar", "outlook": "fine"}, "admin", -1)
+	c.Assert(err, gc.IsNil)
+
+	ctx := coretesting.Context(c)
+	code := cmd.Main(envcmd.Wrap(&ConfigHistoryCommand{}), ctx, []string{"dummy-service"})
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(ctx.Stderr.(*bytes.Buffer).String(), gc.Equals, "")
+
+	var actual []map[string]interface{}
+	err = goyaml.Unmarshal(ctx.Stdout.(*bytes.Buffer).Bytes(), &actual)
+	c.Assert(err, gc.IsNil)
+	c.Assert(actual, gc.HasLen, 2)
+	for i, rev := range actual {
+		c.Check(rev["time"], gc.NotNil)
+		delete(rev, "time")
+		c.Check(rev["revision"], gc.Equals, i+1)
+		c.Check(rev["user"], gc.Equals, "admin")
+		c.Check(rev["charm"], gc.Equals, "local:quantal/dummy-1")
+	}
+	c.Assert(actual[0]["changes"], gc.DeepEquals, map[interface{}]interface{}{
+		"title": map[interface{}]interface{}{"new": "foo"},
+	})
+	c.Assert(actual[1]["changes"], gc.DeepEquals, map[interface{}]interface{}{
+		"title":   map[interface{}]interface{}{"old": "foo", "new": "bar"},
+		"outlook": map[interface{}]interface{}{"new": "fine"},
+	})
+}
+
+func (s *ConfigHistorySuite) TestConfigHistoryErrors(c *gc.C) {
+	_, err := coretesting.RunCommand(c, envcmd.Wrap(&ConfigHistoryCommand{}))
+	c.Assert(err, gc.ErrorMatches, "no service name specified")
+	_, err = coretesting.RunCommand(c, envcmd.Wrap(&ConfigHistoryCommand{}), "foo", "bar")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["bar"\]`)
+	_, err = coretesting.RunCommand(c, envcmd.Wrap(&ConfigHistoryCommand{}), "unknown")
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+}
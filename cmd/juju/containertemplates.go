@@ -0,0 +1,131 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/juju"
+	"github.com/juju/core/names"
+	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/version"
+)
+
+const containerTemplatesDoc = `
+List the templates that containers on the specified machine are cloned
+from, as last reported by the machine agent. Templates are built for a
+series, architecture and tools version, and are replaced automatically
+when the tools are upgraded.
+
+With --purge, the machine agent is asked to destroy the named templates,
+or all of the templates if none are named, to reclaim their disk space.
+Templates that containers are still using are not purged. The purge
+happens asynchronously; list the templates again to see its effect.
+
+Examples:
+  juju container-templates 1
+  juju container-templates 1 --purge
+  juju container-templates 1 --purge juju-trusty-amd64-1.20.0-template
+`
+
+// ContainerTemplatesCommand lists and purges the container templates
+// cached on a machine.
+type ContainerTemplatesCommand struct {
+	envcmd.EnvCommandBase
+	out       cmd.Output
+	MachineId string
+	Purge     bool
+	Names     []string
+}
+
+func (c *ContainerTemplatesCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "container-templates",
+		Args:    "<machine> [<template name> ...]",
+		Purpose: "list or purge the container templates cached on a machine",
+		Doc:     containerTemplatesDoc,
+	}
+}
+
+func (c *ContainerTemplatesCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+	f.BoolVar(&c.Purge, "purge", false, "purge the templates")
+}
+
+func (c *ContainerTemplatesCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no machine specified")
+	}
+	if !names.IsMachine(args[0]) {
+		return fmt.Errorf("invalid machine id %q", args[0])
+	}
+	c.MachineId = args[0]
+	if len(args) > 1 && !c.Purge {
+		return errors.New("template names can only be specified with --purge")
+	}
+	c.Names = args[1:]
+	return nil
+}
+
+type containerTemplate struct {
+	Name    string `yaml:"name" json:"name"`
+	Type    string `yaml:"type" json:"type"`
+	Series  string `yaml:"series" json:"series"`
+	Arch    string `yaml:"arch,omitempty" json:"arch,omitempty"`
+	Tools   string `yaml:"tools,omitempty" json:"tools,omitempty"`
+	Created string `yaml:"created" json:"created"`
+}
+
+type containerTemplates struct {
+	Reported  string              `yaml:"reported,omitempty" json:"reported,omitempty"`
+	Templates []containerTemplate `yaml:"templates" json:"templates"`
+}
+
+func (c *ContainerTemplatesCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if c.Purge {
+		return client.PurgeContainerTemplates(c.MachineId, c.Names...)
+	}
+	result, err := client.ContainerTemplates(c.MachineId)
+	if err != nil {
+		return err
+	}
+	out := containerTemplates{Templates: []containerTemplate{}}
+	if !result.Reported.IsZero() {
+		out.Reported = result.Reported.UTC().Format(time.RFC3339)
+	}
+	for _, t := range result.Templates {
+		out.Templates = append(out.Templates, newContainerTemplate(t))
+	}
+	return c.out.Write(ctx, out)
+}
+
+func newContainerTemplate(t params.ContainerTemplate) containerTemplate {
+	result := containerTemplate{
+		Name:    t.Name,
+		Type:    string(t.Type),
+		Series:  t.Series,
+		Arch:    t.Arch,
+		Created: t.Created.UTC().Format(time.RFC3339),
+	}
+	// Legacy templates have no recorded tools version.
+	if t.Tools != version.Zero {
+		result.Tools = t.Tools.String()
+	}
+	return result
+}
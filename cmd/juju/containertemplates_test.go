@@ -0,0 +1,85 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/instance"
+	jujutesting "github.com/juju/core/juju/testing"
+	"github.com/juju/core/state"
+	coretesting "github.com/juju/core/testing"
+	"github.com/juju/core/version"
+)
+
+type ContainerTemplatesSuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&ContainerTemplatesSuite{})
+
+func runContainerTemplates(c *gc.C, args ...string) (string, error) {
+	ctx, err := coretesting.RunCommand(c, envcmd.Wrap(&ContainerTemplatesCommand{}), args...)
+	if err != nil {
+		return "", err
+	}
+	return coretesting.Stdout(ctx), nil
+}
+
+func (s *ContainerTemplatesSuite) TestInit(c *gc.C) {
+	_, err := runContainerTemplates(c)
+	c.Assert(err, gc.ErrorMatches, "no machine specified")
+	_, err = runContainerTemplates(c, "foo")
+	c.Assert(err, gc.ErrorMatches, `invalid machine id "foo"`)
+	_, err = runContainerTemplates(c, "0", "juju-trusty-amd64-1.20.0-template")
+	c.Assert(err, gc.ErrorMatches, "template names can only be specified with --purge")
+}
+
+func (s *ContainerTemplatesSuite) TestList(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	out, err := runContainerTemplates(c, machine.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, "templates: []\n")
+
+	err = machine.SetContainerTemplates([]state.ContainerTemplate{{
+		Name:    "juju-trusty-amd64-1.20.0-template",
+		Type:    instance.LXC,
+		Series:  "trusty",
+		Arch:    "amd64",
+		Tools:   version.MustParse("1.20.0"),
+		Created: time.Date(2014, 6, 1, 12, 0, 0, 0, time.UTC),
+	}, {
+		Name:    "juju-precise-template",
+		Type:    instance.LXC,
+		Series:  "precise",
+		Created: time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC),
+	}}, 0)
+	c.Assert(err, gc.IsNil)
+	out, err = runContainerTemplates(c, machine.Id(), "--format", "json")
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Matches, `{"reported":"[^"]+","templates":\[`+
+		`{"name":"juju-trusty-amd64-1.20.0-template","type":"lxc","series":"trusty","arch":"amd64","tools":"1.20.0","created":"2014-06-01T12:00:00Z"},`+
+		`{"name":"juju-precise-template","type":"lxc","series":"precise","created":"2014-05-01T12:00:00Z"}`+
+		`\]}\n`)
+}
+
+func (s *ContainerTemplatesSuite) TestPurge(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	_, err = runContainerTemplates(c, machine.Id(), "--purge", "juju-precise-template")
+	c.Assert(err, gc.IsNil)
+	purge, _, err := machine.ContainerTemplatePurge()
+	c.Assert(err, gc.IsNil)
+	c.Assert(purge, gc.DeepEquals, state.ContainerTemplatePurge{
+		Revno: 1,
+		Names: []string{"juju-precise-template"},
+	})
+
+	_, err = runContainerTemplates(c, "42", "--purge")
+	c.Assert(err, gc.ErrorMatches, "machine 42 not found")
+}
@@ -29,6 +29,7 @@ type DeployCommand struct {
 	UnitCommandBase
 	CharmName    string
 	ServiceName  string
+	Series       string
 	Config       cmd.FileVar
 	Constraints  constraints.Value
 	Networks     string
@@ -57,6 +58,15 @@ However, for local charms, when the default-series is not specified in the
 environment, one must specify the series. For example:
   local:precise/mysql
 
+Charms that support several series declare them in their metadata, most
+preferred first:
+  series: [trusty, precise]
+Such charms are deployed on the default-series if they support it, and on
+their preferred series otherwise. Local charms of this kind may be placed
+directly in the repository directory rather than in a series directory.
+The series to deploy on can be chosen with the --series flag:
+  juju deploy --series precise local:haproxy
+
 <service name>, if omitted, will be derived from <charm name>.
 
 Constraints can be specified when using deploy by specifying the --constraints
@@ -69,8 +79,10 @@ If the destination is an LXC container the default is to use lxc-clone
 to create the container where possible. For Ubuntu deployments, lxc-clone
 is supported for the trusty OS series and later. A 'template' container is
 created with the name
-  juju-<series>-template
-where <series> is the OS series, for example 'juju-trusty-template'.
+  juju-<series>-<arch>-<tools>-template
+where <series> is the OS series, <arch> the architecture and <tools> the
+juju tools version, for example 'juju-trusty-amd64-1.20.0-template'. The
+template is replaced when the tools are upgraded.
 
 You can override the use of clone by changing the provider configuration:
   lxc-clone: false
@@ -87,6 +99,7 @@ Examples:
    juju deploy mysql --to 23       (deploy to machine 23)
    juju deploy mysql --to 24/lxc/3 (deploy to lxc container 3 on host machine 24)
    juju deploy mysql --to lxc:25   (deploy to a new lxc container on host machine 25)
+   juju deploy mysql --to docker:3 (deploy to a new docker container on host machine 3)
 
    juju deploy mysql -n 5 --constraints mem=8G (deploy 5 instances of mysql with at least 8 GB of RAM each)
 
@@ -122,6 +135,7 @@ func (c *DeployCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.Var(&c.Config, "config", "path to yaml-formatted service config")
 	f.Var(constraints.ConstraintsValue{Target: &c.Constraints}, "constraints", "set service constraints")
 	f.StringVar(&c.Networks, "networks", "", "enable networks for service")
+	f.StringVar(&c.Series, "series", "", "the series to deploy the charm on")
 	f.StringVar(&c.RepoPath, "repository", os.Getenv(osenv.JujuRepositoryEnvKey), "local charm repository")
 }
 
@@ -134,9 +148,18 @@ func (c *DeployCommand) Init(args []string) error {
 		c.ServiceName = args[1]
 		fallthrough
 	case 1:
-		if _, err := charm.InferURL(args[0], "fake"); err != nil {
+		curl, err := charm.InferURL(args[0], "fake")
+		if err != nil {
 			return fmt.Errorf("invalid charm name %q", args[0])
 		}
+		if c.Series != "" {
+			if !charm.IsValidSeries(c.Series) {
+				return fmt.Errorf("invalid series %q", c.Series)
+			}
+			if curl.Series != "fake" && curl.Series != c.Series {
+				return fmt.Errorf("charm URL series %q conflicts with --series %q", curl.Series, c.Series)
+			}
+		}
 		c.CharmName = args[0]
 	case 0:
 		return errors.New("no charm specified")
@@ -162,7 +185,12 @@ func (c *DeployCommand) Run(ctx *cmd.Context) error {
 		return err
 	}
 
-	curl, err := resolveCharmURL(c.CharmName, client, conf)
+	var curl *charm.URL
+	if c.Series != "" {
+		curl, err = charm.InferURL(c.CharmName, c.Series)
+	} else {
+		curl, err = resolveCharmURL(c.CharmName, client, conf, ctx.AbsPath(c.RepoPath))
+	}
 	if err != nil {
 		return err
 	}
@@ -4,6 +4,9 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/juju/errors"
@@ -13,11 +16,14 @@ import (
 	"github.com/juju/core/charm"
 	"github.com/juju/core/cmd/envcmd"
 	"github.com/juju/core/constraints"
+	envtesting "github.com/juju/core/environs/testing"
+	envtools "github.com/juju/core/environs/tools"
 	"github.com/juju/core/instance"
 	"github.com/juju/core/juju/testing"
 	"github.com/juju/core/state"
 	coretesting "github.com/juju/core/testing"
 	"github.com/juju/core/utils"
+	"github.com/juju/core/version"
 )
 
 type DeploySuite struct {
@@ -269,3 +275,86 @@ func (s *DeploySuite) TestForceMachineSubordinate(c *gc.C) {
 	_, err = s.State.Service("dummy")
 	c.Assert(err, gc.ErrorMatches, `service "dummy" not found`)
 }
+
+// addMultiSeriesCharm adds a copy of the dummy charm declaring the
+// given series to the top of the local repository.
+func (s *DeploySuite) addMultiSeriesCharm(c *gc.C, series string) {
+	path := coretesting.Charms.ClonedDirPath(filepath.Dir(s.SeriesPath), "dummy")
+	f, err := os.OpenFile(filepath.Join(path, "metadata.yaml"), os.O_APPEND|os.O_WRONLY, 0)
+	c.Assert(err, gc.IsNil)
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "\nseries: %s\n", series)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *DeploySuite) TestMultiSeriesCharm(c *gc.C) {
+	s.addMultiSeriesCharm(c, "[trusty, precise]")
+
+	// The default series is used if the charm supports it.
+	err := runDeploy(c, "local:dummy")
+	c.Assert(err, gc.IsNil)
+	service, _ := s.AssertService(c, "dummy", charm.MustParseURL("local:precise/dummy-1"), 1, 0)
+	c.Assert(service.Series(), gc.Equals, "precise")
+
+	err = runDeploy(c, "local:dummy", "other", "--series", "trusty")
+	c.Assert(err, gc.IsNil)
+	service, _ = s.AssertService(c, "other", charm.MustParseURL("local:trusty/dummy-1"), 1, 0)
+	c.Assert(service.Series(), gc.Equals, "trusty")
+	units, err := service.AllUnits()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units[0].Series(), gc.Equals, "trusty")
+
+	err = runDeploy(c, "local:dummy", "another", "--series", "quantal")
+	c.Assert(err, gc.ErrorMatches, `charm not found in ".*": local:quantal/dummy`)
+}
+
+func (s *DeploySuite) TestMultiSeriesCharmMachinesAndTools(c *gc.C) {
+	s.addMultiSeriesCharm(c, "[trusty, precise]")
+	envtesting.MustUploadFakeToolsVersions(s.Conn.Environ.Storage(),
+		version.Binary{Number: version.Current.Number, Series: "precise", Arch: "amd64"},
+		version.Binary{Number: version.Current.Number, Series: "trusty", Arch: "amd64"},
+	)
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	agentVersion, ok := cfg.AgentVersion()
+	c.Assert(ok, gc.Equals, true)
+
+	// The units of a multi-series charm are deployed on machines of
+	// the chosen series, which are given tools for that series.
+	for _, series := range []string{"precise", "trusty"} {
+		c.Logf("deploying on %s", series)
+		err := runDeploy(c, "local:dummy", "dummy-"+series, "--series", series)
+		c.Assert(err, gc.IsNil)
+		service, _ := s.AssertService(c, "dummy-"+series, charm.MustParseURL("local:"+series+"/dummy-1"), 1, 0)
+		c.Assert(service.Series(), gc.Equals, series)
+		units, err := service.AllUnits()
+		c.Assert(err, gc.IsNil)
+		mid, err := units[0].AssignedMachineId()
+		c.Assert(err, gc.IsNil)
+		machine, err := s.State.Machine(mid)
+		c.Assert(err, gc.IsNil)
+		c.Assert(machine.Series(), gc.Equals, series)
+		possibleTools, err := envtools.FindInstanceTools(s.Conn.Environ, agentVersion, machine.Series(), nil)
+		c.Assert(err, gc.IsNil)
+		c.Assert(possibleTools, gc.Not(gc.HasLen), 0)
+		for _, tools := range possibleTools {
+			c.Assert(tools.Version.Series, gc.Equals, series)
+		}
+	}
+}
+
+func (s *DeploySuite) TestMultiSeriesCharmPreferredSeries(c *gc.C) {
+	// The charm doesn't support the default series.
+	s.addMultiSeriesCharm(c, "[trusty, raring]")
+	err := runDeploy(c, "local:dummy")
+	c.Assert(err, gc.IsNil)
+	service, _ := s.AssertService(c, "dummy", charm.MustParseURL("local:trusty/dummy-1"), 1, 0)
+	c.Assert(service.Series(), gc.Equals, "trusty")
+}
+
+func (s *DeploySuite) TestSeriesErrors(c *gc.C) {
+	err := coretesting.InitCommand(envcmd.Wrap(&DeployCommand{}), []string{"--series", "Trusty", "dummy"})
+	c.Assert(err, gc.ErrorMatches, `invalid series "Trusty"`)
+	err = coretesting.InitCommand(envcmd.Wrap(&DeployCommand{}), []string{"--series", "trusty", "precise/dummy"})
+	c.Assert(err, gc.ErrorMatches, `charm URL series "precise" conflicts with --series "trusty"`)
+}
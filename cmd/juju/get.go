@@ -59,9 +59,10 @@ func (c *GetCommand) Run(ctx *cmd.Context) error {
 	}
 
 	resultsMap := map[string]interface{}{
-		"service":  results.Service,
-		"charm":    results.Charm,
-		"settings": results.Config,
+		"service":           results.Service,
+		"charm":             results.Charm,
+		"settings":          results.Config,
+		"settings-revision": results.SettingsRevision,
 	}
 	return c.out.Write(ctx, resultsMap)
 }
@@ -29,8 +29,9 @@ var getTests = []struct {
 	{
 		"dummy-service",
 		map[string]interface{}{
-			"service": "dummy-service",
-			"charm":   "dummy",
+			"service":           "dummy-service",
+			"charm":             "dummy",
+			"settings-revision": 1,
 			"settings": map[string]interface{}{
 				"title": map[string]interface{}{
 					"description": "A descriptive title used for the service.",
@@ -82,8 +82,10 @@ You deploy charms from the charm store using the following commands:
 For Ubuntu deployments, the local provider will prefer to use lxc-clone to create
 the machines for the trusty OS series and later.
 A 'template' container is created with the name
-  juju-<series>-template
-where <series> is the OS series, for example 'juju-trusty-template'.
+  juju-<series>-<arch>-<tools>-template
+where <series> is the OS series, <arch> the architecture and <tools> the juju
+tools version, for example 'juju-trusty-amd64-1.20.0-template'. The template
+is replaced when the tools are upgraded.
 You can override the use of clone by specifying
   lxc-clone: true
 or
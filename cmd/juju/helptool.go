@@ -10,6 +10,7 @@ import (
 
 	"github.com/juju/core/charm"
 	"github.com/juju/core/cmd"
+	"github.com/juju/core/state/api/params"
 	"github.com/juju/core/worker/uniter/jujuc"
 )
 
@@ -52,6 +53,14 @@ func (dummyHookContext) OwnerTag() string {
 	return ""
 }
 
+func (dummyHookContext) NetworkConfig(networkName string) (params.UnitNetworkConfig, error) {
+	return params.UnitNetworkConfig{}, fmt.Errorf("no network config")
+}
+
+func (dummyHookContext) ResourcePath(name string) (string, error) {
+	return "", fmt.Errorf("no resources")
+}
+
 type HelpToolCommand struct {
 	cmd.CommandBase
 	tool string
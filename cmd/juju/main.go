@@ -115,12 +115,16 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(wrapEnvCommand(&DebugLogCommand{}))
 	r.Register(wrapEnvCommand(&DebugHooksCommand{}))
 	r.Register(wrapEnvCommand(&RetryProvisioningCommand{}))
+	r.Register(wrapEnvCommand(&ContainerTemplatesCommand{}))
 
 	// Configuration commands.
 	r.Register(&InitCommand{})
 	r.Register(wrapEnvCommand(&GetCommand{}))
 	r.Register(wrapEnvCommand(&SetCommand{}))
 	r.Register(wrapEnvCommand(&UnsetCommand{}))
+	r.Register(wrapEnvCommand(&ConfigHistoryCommand{}))
+	r.Register(wrapEnvCommand(&AttachCommand{}))
+	r.Register(wrapEnvCommand(&ResourcesCommand{}))
 	r.Register(wrapEnvCommand(&GetConstraintsCommand{}))
 	r.Register(wrapEnvCommand(&SetConstraintsCommand{}))
 	r.Register(wrapEnvCommand(&GetEnvironmentCommand{}))
@@ -135,6 +139,11 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	// Charm publishing commands.
 	r.Register(wrapEnvCommand(&PublishCommand{}))
 
+	// Charm store commands.
+	r.Register(&SearchCommand{})
+	r.Register(wrapEnvCommand(&CharmMirrorCommand{}))
+	r.Register(&SignCharmCommand{})
+
 	// Charm tool commands.
 	r.Register(&HelpToolCommand{})
 
@@ -144,9 +153,16 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	// Manage users and access
 	r.Register(NewUserCommand())
 
+	// Manage webhook subscriptions.
+	r.Register(NewWebhooksCommand())
+
 	// Manage state server availability.
 	r.Register(wrapEnvCommand(&EnsureAvailabilityCommand{}))
 
+	// Manage certificates and agent credentials.
+	r.Register(wrapEnvCommand(&RotateCertificatesCommand{}))
+	r.Register(wrapEnvCommand(&RotateAgentCredentialsCommand{}))
+
 	// Common commands.
 	r.Register(&cmd.VersionCommand{})
 }
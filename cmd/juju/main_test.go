@@ -191,9 +191,13 @@ var commandNames = []string{
 	"add-relation",
 	"add-unit",
 	"api-endpoints",
+	"attach",
 	"authorised-keys", // alias for authorized-keys
 	"authorized-keys",
 	"bootstrap",
+	"charm-mirror",
+	"config-history",
+	"container-templates",
 	"debug-hooks",
 	"debug-log",
 	"deploy",
@@ -219,13 +223,18 @@ var commandNames = []string{
 	"remove-service",  // alias for destroy-service
 	"remove-unit",     // alias for destroy-unit
 	"resolved",
+	"resources",
 	"retry-provisioning",
+	"rotate-agent-credentials",
+	"rotate-certificates",
 	"run",
 	"scp",
+	"search",
 	"set",
 	"set-constraints",
 	"set-env", // alias for set-environment
 	"set-environment",
+	"sign-charm",
 	"ssh",
 	"stat", // alias for status
 	"status",
@@ -240,6 +249,7 @@ var commandNames = []string{
 	"upgrade-juju",
 	"user",
 	"version",
+	"webhooks",
 }
 
 func (s *MainSuite) TestHelpCommands(c *gc.C) {
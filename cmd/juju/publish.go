@@ -5,16 +5,15 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
 	"launchpad.net/gnuflag"
 
-	"github.com/juju/core/bzr"
 	"github.com/juju/core/charm"
 	"github.com/juju/core/cmd"
 	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/vcs"
 )
 
 type PublishCommand struct {
@@ -27,6 +26,7 @@ type PublishCommand struct {
 	changePushLocation func(loc string) string
 
 	pollDelay time.Duration
+	timeout   time.Duration
 }
 
 const publishDoc = `
@@ -43,6 +43,15 @@ For cs:~user/precise/mysql
 There is no default series, so one must be provided explicitly when
 informing a charm URL. If the URL isn't provided, an attempt will be
 made to infer it from the current branch push URL.
+
+The charm may live in either a Bazaar branch or a Git repository.
+Bazaar branches are pushed to the charm store's branch location for
+the charm URL. Git repositories are pushed to their own push location (the "origin"
+remote), and the charm store is then asked to publish the pushed
+commit from the location the repository is fetched from.
+
+The command waits for the charm store to publish the charm, for up to
+the time given with --timeout.
 `
 
 func (c *PublishCommand) Info() *cmd.Info {
@@ -56,6 +65,7 @@ func (c *PublishCommand) Info() *cmd.Info {
 
 func (c *PublishCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.StringVar(&c.CharmPath, "from", ".", "path for charm to be published")
+	f.DurationVar(&c.timeout, "timeout", 10*time.Minute, "how long to wait for the charm to be published")
 }
 
 func (c *PublishCommand) Init(args []string) error {
@@ -77,9 +87,10 @@ func (c *PublishCommand) SetPollDelay(delay time.Duration) {
 // Wording guideline to avoid confusion: charms have *URLs*, branches have *locations*.
 
 func (c *PublishCommand) Run(ctx *cmd.Context) (err error) {
-	branch := bzr.New(ctx.AbsPath(c.CharmPath))
-	if _, err := os.Stat(branch.Join(".bzr")); err != nil {
-		return fmt.Errorf("not a charm branch: %s", branch.Location())
+	path := ctx.AbsPath(c.CharmPath)
+	branch, err := vcs.Detect(path)
+	if err != nil {
+		return fmt.Errorf("not a charm branch: %s", path)
 	}
 	if err := branch.CheckClean(); err != nil {
 		return err
@@ -104,7 +115,15 @@ func (c *PublishCommand) Run(ctx *cmd.Context) (err error) {
 		}
 	}
 
-	pushLocation := charm.Store.BranchLocation(curl)
+	var pushLocation string
+	if branch.Kind() == "git" {
+		pushLocation, err = branch.PushLocation()
+		if err != nil {
+			return fmt.Errorf("cannot publish git repository without a push location (origin remote)")
+		}
+	} else {
+		pushLocation = charm.Store.BranchLocation(curl)
+	}
 	if c.changePushLocation != nil {
 		pushLocation = c.changePushLocation(pushLocation)
 	}
@@ -149,13 +168,23 @@ func (c *PublishCommand) Run(ctx *cmd.Context) (err error) {
 
 	logger.Infof("sending charm to the charm store...")
 
-	err = branch.Push(&bzr.PushAttr{Location: pushLocation, Remember: true})
+	err = branch.Push(&vcs.PushAttr{Location: pushLocation, Remember: true})
 	if err != nil {
 		return err
 	}
+	if branch.Kind() == "git" {
+		if err := requestPublish(branch, curl, localDigest); err != nil {
+			return err
+		}
+	}
 	logger.Infof("charm sent; waiting for it to be published...")
+	timeout := time.After(c.timeout)
 	for {
-		time.Sleep(c.pollDelay)
+		select {
+		case <-time.After(c.pollDelay):
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for charm %s to be published", curl)
+		}
 		newEvent, err := charm.Store.Event(curl, "")
 		if _, ok := err.(*charm.NotFoundError); ok {
 			continue
@@ -174,6 +203,28 @@ func (c *PublishCommand) Run(ctx *cmd.Context) (err error) {
 	}
 }
 
+// fetchLocator is implemented by branches that may be fetched from
+// a different location than they are pushed to, as Git repositories.
+type fetchLocator interface {
+	FetchLocation() (string, error)
+}
+
+// requestPublish asks the charm store to publish curl from the head
+// of the Git repository in branch, expected to be the commit identified
+// by digest. The store fetches the repository from the location it is fetched from locally,
+// since its push location may not be readable by others.
+func requestPublish(branch vcs.Branch, curl *charm.URL, digest string) error {
+	b, ok := branch.(fetchLocator)
+	if !ok {
+		return fmt.Errorf("cannot obtain repository location of %s branch", branch.Kind())
+	}
+	location, err := b.FetchLocation()
+	if err != nil {
+		return fmt.Errorf("cannot obtain repository location: %v", err)
+	}
+	return charm.Store.RequestPublish(curl, location, digest)
+}
+
 func handleEvent(ctx *cmd.Context, curl *charm.URL, event *charm.EventResponse) error {
 	switch event.Kind {
 	case "published":
@@ -6,6 +6,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/exec"
 
 	gc "launchpad.net/gocheck"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/juju/core/cmd"
 	"github.com/juju/core/cmd/envcmd"
 	"github.com/juju/core/testing"
+	"github.com/juju/core/vcs"
 )
 
 // Sadly, this is a very slow test suite, heavily dominated by calls to bzr.
@@ -24,7 +26,7 @@ type PublishSuite struct {
 
 	dir        string
 	oldBaseURL string
-	branch     *bzr.Branch
+	branch     vcs.Branch
 }
 
 var _ = gc.Suite(&PublishSuite{})
@@ -35,7 +37,7 @@ func touch(c *gc.C, filename string) {
 	f.Close()
 }
 
-func addMeta(c *gc.C, branch *bzr.Branch, meta string) {
+func addMeta(c *gc.C, branch vcs.Branch, meta string) {
 	if meta == "" {
 		meta = "name: wordpress\nsummary: Some summary\ndescription: Some description.\n"
 	}
@@ -80,7 +82,7 @@ func (s *PublishSuite) SetUpTest(c *gc.C) {
 	})
 
 	s.dir = c.MkDir()
-	s.branch = bzr.New(s.dir)
+	s.branch = vcs.NewBzr(s.dir)
 	err := s.branch.Init()
 	c.Assert(err, gc.IsNil)
 }
@@ -125,7 +127,7 @@ func (s *PublishSuite) TestNoPushLocation(c *gc.C) {
 
 func (s *PublishSuite) TestUnknownPushLocation(c *gc.C) {
 	addMeta(c, s.branch, "")
-	err := s.branch.Push(&bzr.PushAttr{Location: c.MkDir() + "/foo", Remember: true})
+	err := s.branch.Push(&vcs.PushAttr{Location: c.MkDir() + "/foo", Remember: true})
 	c.Assert(err, gc.IsNil)
 	_, err = s.runPublish(c)
 	c.Assert(err, gc.ErrorMatches, `cannot infer charm URL from branch location: ".*/foo"`)
@@ -395,3 +397,116 @@ func (s *PublishSuite) TestFullPublishRace(c *gc.C) {
 		c.Assert(req.Form.Get("charms"), gc.Equals, "cs:~user/precise/wordpress")
 	}
 }
+
+// gitBranch returns a new git repository, with metadata
+// for the wordpress charm committed to it.
+func (s *PublishSuite) gitBranch(c *gc.C) vcs.Branch {
+	s.PatchEnvironment("GIT_AUTHOR_NAME", "Test")
+	s.PatchEnvironment("GIT_AUTHOR_EMAIL", "testing@testing.invalid")
+	s.PatchEnvironment("GIT_COMMITTER_NAME", "Test")
+	s.PatchEnvironment("GIT_COMMITTER_EMAIL", "testing@testing.invalid")
+	branch := vcs.NewGit(c.MkDir())
+	err := branch.Init()
+	c.Assert(err, gc.IsNil)
+	addMeta(c, branch, "")
+	return branch
+}
+
+func (s *PublishSuite) TestGitNoPushLocation(c *gc.C) {
+	branch := s.gitBranch(c)
+	_, err := testing.RunCommandInDir(c, envcmd.Wrap(&PublishCommand{}), []string{"cs:precise/wordpress"}, branch.Location())
+	c.Assert(err, gc.ErrorMatches, `cannot publish git repository without a push location \(origin remote\)`)
+}
+
+func (s *PublishSuite) TestGitFullPublish(c *gc.C) {
+	branch := s.gitBranch(c)
+	digest, err := branch.RevisionId()
+	c.Assert(err, gc.IsNil)
+
+	// Git can only push to repositories without a working tree.
+	origin := c.MkDir()
+	output, err := exec.Command("git", "init", "-q", "--bare", origin).CombinedOutput()
+	c.Assert(err, gc.IsNil, gc.Commentf("%s", output))
+	output, err = exec.Command("git", "-C", branch.Location(), "remote", "add", "origin", origin).CombinedOutput()
+	c.Assert(err, gc.IsNil, gc.Commentf("%s", output))
+
+	cmd := &PublishCommand{}
+	cmd.SetPollDelay(pollDelay)
+
+	// Neither the local digest nor tip are found.
+	body := `{"cs:~user/precise/wordpress": {"kind": "", "errors": ["entry not found"]}}`
+	testing.Server.Response(200, nil, []byte(body))
+	testing.Server.Response(200, nil, []byte(body))
+
+	// Once the repository is pushed the store is asked to publish it.
+	testing.Server.Response(202, nil, nil)
+
+	// And it does.
+	body = `{"cs:~user/precise/wordpress": {"kind": "published", "digest": %q, "revision": 1}}`
+	testing.Server.Response(200, nil, []byte(fmt.Sprintf(body, digest)))
+
+	ctx, err := testing.RunCommandInDir(c, envcmd.Wrap(cmd), []string{"cs:~user/precise/wordpress"}, branch.Location())
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "cs:~user/precise/wordpress-1\n")
+
+	// Ensure the repository was pushed to its own push location.
+	pushDigest, err := vcs.NewGit(origin).RevisionId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pushDigest, gc.Equals, digest)
+
+	req := testing.Server.WaitRequest()
+	c.Assert(req.URL.Path, gc.Equals, "/charm-event")
+	c.Assert(req.Form.Get("charms"), gc.Equals, "cs:~user/precise/wordpress@"+digest)
+	req = testing.Server.WaitRequest()
+	c.Assert(req.URL.Path, gc.Equals, "/charm-event")
+	c.Assert(req.Form.Get("charms"), gc.Equals, "cs:~user/precise/wordpress")
+
+	// The store was asked to publish the pushed commit.
+	req = testing.Server.WaitRequest()
+	c.Assert(req.Method, gc.Equals, "POST")
+	c.Assert(req.URL.Path, gc.Equals, "/charm-publish")
+	c.Assert(req.Form.Get("charm"), gc.Equals, "cs:~user/precise/wordpress")
+	c.Assert(req.Form.Get("repository"), gc.Equals, origin)
+	c.Assert(req.Form.Get("digest"), gc.Equals, digest)
+}
+
+func (s *PublishSuite) TestGitPublishRefused(c *gc.C) {
+	branch := s.gitBranch(c)
+	origin := c.MkDir()
+	output, err := exec.Command("git", "init", "-q", "--bare", origin).CombinedOutput()
+	c.Assert(err, gc.IsNil, gc.Commentf("%s", output))
+	output, err = exec.Command("git", "-C", branch.Location(), "remote", "add", "origin", origin).CombinedOutput()
+	c.Assert(err, gc.IsNil, gc.Commentf("%s", output))
+
+	body := `{"cs:~user/precise/wordpress": {"kind": "", "errors": ["entry not found"]}}`
+	testing.Server.Response(200, nil, []byte(body))
+	testing.Server.Response(200, nil, []byte(body))
+	testing.Server.Response(403, nil, []byte("publishing from git repositories is not enabled\n"))
+
+	_, err = testing.RunCommandInDir(c, envcmd.Wrap(&PublishCommand{}), []string{"cs:~user/precise/wordpress"}, branch.Location())
+	c.Assert(err, gc.ErrorMatches, "charm store refused to publish cs:~user/precise/wordpress: publishing from git repositories is not enabled")
+}
+
+func (s *PublishSuite) TestPublishTimeout(c *gc.C) {
+	addMeta(c, s.branch, "")
+	pushBranch := bzr.New(c.MkDir())
+	err := pushBranch.Init()
+	c.Assert(err, gc.IsNil)
+
+	cmd := &PublishCommand{}
+	cmd.ChangePushLocation(func(location string) string {
+		return pushBranch.Location()
+	})
+	cmd.SetPollDelay(pollDelay)
+
+	// The local digest isn't found, and the charm is
+	// never published with it.
+	body := `{"cs:~user/precise/wordpress": {"kind": "", "errors": ["entry not found"]}}`
+	testing.Server.Response(200, nil, []byte(body))
+	body = `{"cs:~user/precise/wordpress": {"kind": "published", "digest": "other-digest"}}`
+	testing.Server.Responses(20, 200, nil, []byte(body))
+
+	args := []string{"--timeout", (4 * pollDelay).String(), "cs:~user/precise/wordpress"}
+	_, err = testing.RunCommandInDir(c, envcmd.Wrap(cmd), args, s.dir)
+	c.Assert(err, gc.ErrorMatches, "timed out waiting for charm cs:~user/precise/wordpress to be published")
+}
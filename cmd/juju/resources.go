@@ -0,0 +1,92 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/juju"
+)
+
+const resourcesDoc = `
+Show the resources declared by the charm of the specified service, with
+the revision of each resource's content uploaded with "juju attach", if
+any: its SHA256 hash and size, the user that uploaded it and when.
+`
+
+// ResourcesCommand shows the resources of a service.
+type ResourcesCommand struct {
+	envcmd.EnvCommandBase
+	ServiceName string
+	out         cmd.Output
+}
+
+func (c *ResourcesCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "resources",
+		Args:    "<service>",
+		Purpose: "show the resources of a service",
+		Doc:     resourcesDoc,
+	}
+}
+
+func (c *ResourcesCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+func (c *ResourcesCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no service name specified")
+	}
+	c.ServiceName = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+type resourceInfo struct {
+	Filename    string `yaml:"filename,omitempty" json:"filename,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Revision    int    `yaml:"revision" json:"revision"`
+	SHA256      string `yaml:"sha256,omitempty" json:"sha256,omitempty"`
+	Size        int64  `yaml:"size,omitempty" json:"size,omitempty"`
+	User        string `yaml:"user,omitempty" json:"user,omitempty"`
+	Time        string `yaml:"time,omitempty" json:"time,omitempty"`
+}
+
+// Run fetches the resources of the service and writes them out.
+func (c *ResourcesCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	resources, err := client.ServiceResources(c.ServiceName)
+	if err != nil {
+		return err
+	}
+	result := make(map[string]resourceInfo)
+	for _, res := range resources {
+		info := resourceInfo{
+			Filename:    res.Filename,
+			Description: res.Description,
+			Revision:    res.Revision,
+			SHA256:      res.SHA256,
+			Size:        res.Size,
+			User:        res.User,
+		}
+		if res.Revision > 0 {
+			info.Time = res.Time.UTC().Format(time.RFC3339)
+		}
+		result[res.Name] = info
+	}
+	return c.out.Write(ctx, result)
+}
@@ -0,0 +1,104 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/juju/testing"
+	coretesting "github.com/juju/core/testing"
+)
+
+type ResourcesSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&ResourcesSuite{})
+
+func (s *ResourcesSuite) TestAttachAndResources(c *gc.C) {
+	s.AddTestingService(c, "resources", s.AddTestingCharm(c, "resources"))
+	path := filepath.Join(c.MkDir(), "jdk.tar.gz")
+	err := ioutil.WriteFile(path, []byte("the jdk"), 0644)
+	c.Assert(err, gc.IsNil)
+
+	ctx, err := coretesting.RunCommand(c, envcmd.Wrap(&AttachCommand{}), "resources", "jdk", path)
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stderr(ctx), gc.Equals, `added revision 1 of resource "jdk" to service "resources"`+"\n")
+	service, err := s.State.Service("resources")
+	c.Assert(err, gc.IsNil)
+	res, err := service.Resource("jdk")
+	c.Assert(err, gc.IsNil)
+	c.Assert(res.Size, gc.Equals, int64(7))
+
+	ctx = coretesting.Context(c)
+	code := cmd.Main(envcmd.Wrap(&ResourcesCommand{}), ctx, []string{"resources"})
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(ctx.Stderr.(*bytes.Buffer).String(), gc.Equals, "")
+	var actual map[string]map[string]interface{}
+	err = goyaml.Unmarshal(ctx.Stdout.(*bytes.Buffer).Bytes(), &actual)
+	c.Assert(err, gc.IsNil)
+	c.Assert(actual["jdk"]["time"], gc.NotNil)
+	delete(actual["jdk"], "time")
+	c.Assert(actual, gc.DeepEquals, map[string]map[string]interface{}{
+		"app": {
+			"filename": "app.war",
+			"revision": 0,
+		},
+		"jdk": {
+			"filename":    "jdk.tar.gz",
+			"description": "The Java runtime the charm installs.",
+			"revision":    1,
+			"sha256":      res.SHA256,
+			"size":        7,
+			"user":        "admin",
+		},
+	})
+}
+
+func (s *ResourcesSuite) TestAttachErrors(c *gc.C) {
+	s.AddTestingService(c, "resources", s.AddTestingCharm(c, "resources"))
+	for i, t := range []struct {
+		args []string
+		err  string
+	}{{
+		args: nil,
+		err:  "no service name specified",
+	}, {
+		args: []string{"resources"},
+		err:  "no resource name specified",
+	}, {
+		args: []string{"resources", "jdk"},
+		err:  "no file specified",
+	}, {
+		args: []string{"Resources", "jdk", "file"},
+		err:  `invalid service name "Resources"`,
+	}, {
+		args: []string{"resources", "$jdk", "file"},
+		err:  `invalid resource name "\$jdk"`,
+	}, {
+		args: []string{"resources", "jdk", "file", "other"},
+		err:  `unrecognized args: \["other"\]`,
+	}, {
+		args: []string{"resources", "jdk", "missing-file"},
+		err:  `open .*missing-file: no such file or directory`,
+	}} {
+		c.Logf("test %d: %v", i, t.args)
+		_, err := coretesting.RunCommand(c, envcmd.Wrap(&AttachCommand{}), t.args...)
+		c.Assert(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *ResourcesSuite) TestResourcesErrors(c *gc.C) {
+	_, err := coretesting.RunCommand(c, envcmd.Wrap(&ResourcesCommand{}))
+	c.Assert(err, gc.ErrorMatches, "no service name specified")
+	_, err = coretesting.RunCommand(c, envcmd.Wrap(&ResourcesCommand{}), "unknown")
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+}
@@ -0,0 +1,66 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/juju"
+	"github.com/juju/core/names"
+)
+
+// RotateAgentCredentialsCommand asks agents to replace their passwords.
+type RotateAgentCredentialsCommand struct {
+	envcmd.EnvCommandBase
+	MachineId string
+}
+
+const rotateAgentCredentialsDoc = `
+Rotate-agent-credentials asks the agents of the environment to replace
+their passwords. Each agent chooses a new password, records it in its
+configuration while keeping the old one as a fallback, and only then
+registers it with the state servers, so agents are never locked out.
+
+With --machine, only the agents of the given machine and of the units
+assigned to it are asked.
+
+Examples:
+  juju rotate-agent-credentials
+  juju rotate-agent-credentials --machine 3
+`
+
+func (c *RotateAgentCredentialsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "rotate-agent-credentials",
+		Purpose: "ask agents to replace their passwords",
+		Doc:     rotateAgentCredentialsDoc,
+	}
+}
+
+func (c *RotateAgentCredentialsCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.MachineId, "machine", "", "only rotate the credentials of agents on this machine")
+}
+
+func (c *RotateAgentCredentialsCommand) Init(args []string) error {
+	if c.MachineId != "" && !names.IsMachine(c.MachineId) {
+		return fmt.Errorf("invalid machine id %q", c.MachineId)
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *RotateAgentCredentialsCommand) Run(_ *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if c.MachineId == "" {
+		return client.RotateAgentCredentials()
+	}
+	return client.RotateAgentCredentials(c.MachineId)
+}
@@ -0,0 +1,51 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/cmd/envcmd"
+	jujutesting "github.com/juju/core/juju/testing"
+	"github.com/juju/core/state"
+	coretesting "github.com/juju/core/testing"
+)
+
+type RotateAgentCredentialsSuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&RotateAgentCredentialsSuite{})
+
+func runRotateAgentCredentials(c *gc.C, args ...string) error {
+	_, err := coretesting.RunCommand(c, envcmd.Wrap(&RotateAgentCredentialsCommand{}), args...)
+	return err
+}
+
+func (s *RotateAgentCredentialsSuite) assertRevnos(c *gc.C, expect map[string]int) {
+	for tag, revno := range expect {
+		got, err := s.State.PasswordRotation(tag)
+		c.Assert(err, gc.IsNil)
+		c.Check(got, gc.Equals, revno, gc.Commentf("entity %s", tag))
+	}
+}
+
+func (s *RotateAgentCredentialsSuite) TestRotateAgentCredentials(c *gc.C) {
+	for i := 0; i < 2; i++ {
+		_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+		c.Assert(err, gc.IsNil)
+	}
+	err := runRotateAgentCredentials(c, "--machine", "1")
+	c.Assert(err, gc.IsNil)
+	s.assertRevnos(c, map[string]int{"machine-0": 0, "machine-1": 1})
+
+	err = runRotateAgentCredentials(c)
+	c.Assert(err, gc.IsNil)
+	s.assertRevnos(c, map[string]int{"machine-0": 2, "machine-1": 3})
+}
+
+func (s *RotateAgentCredentialsSuite) TestInvalidMachine(c *gc.C) {
+	err := runRotateAgentCredentials(c, "--machine", "foo")
+	c.Assert(err, gc.ErrorMatches, `invalid machine id "foo"`)
+}
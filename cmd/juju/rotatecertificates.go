@@ -0,0 +1,202 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/core/cert"
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/environs/configstore"
+	"github.com/juju/core/juju"
+	"github.com/juju/core/state/api"
+)
+
+// RotateCertificatesCommand issues new certificates for the state
+// servers of an environment.
+type RotateCertificatesCommand struct {
+	envcmd.EnvCommandBase
+	NewCA     bool
+	DropOldCA bool
+	Days      int
+	Timeout   time.Duration
+}
+
+// caCertPollDelay holds how long to wait between checks that all
+// agents have picked up newly published CA certificates.
+var caCertPollDelay = 5 * time.Second
+
+const rotateCertificatesDoc = `
+Rotate-certificates issues a new certificate for the environment's state
+servers, signed by the environment's CA certificate. The state servers
+start serving the new certificate, and all agents and the local .jenv file
+are updated to trust it.
+
+The rotation happens in two phases. First the CA certificates are
+published to all agents, and the command waits for every running agent
+to acknowledge them, for at most the time given with --timeout. Only
+then is the state server certificate switched, so no agent loses its
+connection to the state servers. The state server generates the key for
+its new certificate itself; only its public half is sent to the client
+to be signed.
+
+With --new-ca, a new CA certificate is generated as well. During the
+transition, agents and clients trust both the new and the old CA
+certificates. Once all agents have picked up the new certificate, run
+the command again with --drop-old-ca to stop trusting the old one.
+
+The CA private key is read from the environment's .jenv file, so the
+command must be run from the machine that bootstrapped the environment.
+
+Examples:
+  juju rotate-certificates
+  juju rotate-certificates --new-ca
+  juju rotate-certificates --drop-old-ca
+`
+
+func (c *RotateCertificatesCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "rotate-certificates",
+		Purpose: "issue new certificates for the state servers",
+		Doc:     rotateCertificatesDoc,
+	}
+}
+
+func (c *RotateCertificatesCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.BoolVar(&c.NewCA, "new-ca", false, "generate a new CA certificate as well")
+	f.BoolVar(&c.DropOldCA, "drop-old-ca", false, "stop trusting previous CA certificates")
+	f.IntVar(&c.Days, "days", 3650, "number of days the new certificates are valid for")
+	f.DurationVar(&c.Timeout, "timeout", 10*time.Minute, "how long to wait for agents to trust the CA certificates")
+}
+
+func (c *RotateCertificatesCommand) Init(args []string) error {
+	if c.Days <= 0 {
+		return fmt.Errorf("--days must be positive")
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// Run generates the new certificates, sends them to the state
+// servers and records them in the environment's .jenv file.
+func (c *RotateCertificatesCommand) Run(ctx *cmd.Context) error {
+	store, err := configstore.Default()
+	if err != nil {
+		return err
+	}
+	info, err := store.ReadInfo(c.EnvName)
+	if err != nil {
+		return err
+	}
+	attrs := info.BootstrapConfig()
+	caCert, _ := attrs["ca-cert"].(string)
+	caKey, _ := attrs["ca-private-key"].(string)
+	if caCert == "" || caKey == "" {
+		return fmt.Errorf("environment %q has no CA private key available", c.EnvName)
+	}
+	expiry := time.Now().UTC().AddDate(0, 0, c.Days)
+	var trusted []string
+	if c.NewCA {
+		trusted = append(trusted, caCert)
+		caCert, caKey, err = cert.NewCA(c.EnvName, expiry)
+		if err != nil {
+			return fmt.Errorf("cannot generate CA certificate: %v", err)
+		}
+	}
+	if !c.DropOldCA {
+		trusted = append(trusted, info.APIEndpoint().CACert)
+	}
+	bundle, err := certBundle(caCert, trusted...)
+	if err != nil {
+		return err
+	}
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.PublishCACert(bundle); err != nil {
+		return err
+	}
+	// Record the new CA as soon as it is published, so that it is
+	// not lost if the rotation fails part way. The bootstrap config
+	// returned by the store is the one that will be written, so it
+	// can be updated in place.
+	attrs["ca-cert"] = caCert
+	attrs["ca-private-key"] = caKey
+	endpoint := info.APIEndpoint()
+	endpoint.CACert = bundle
+	info.SetAPIEndpoint(endpoint)
+	if err := info.Write(); err != nil {
+		return fmt.Errorf("cannot update environment information: %v", err)
+	}
+	if err := c.waitForAgents(client); err != nil {
+		return err
+	}
+	publicKey, hostnames, err := client.NewStateServerKey()
+	if err != nil {
+		return err
+	}
+	srvCert, err := cert.NewServerForKey(caCert, caKey, expiry, hostnames, publicKey)
+	if err != nil {
+		return fmt.Errorf("cannot generate state server certificate: %v", err)
+	}
+	if err := client.SetStateServerCert(srvCert); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ctx.Stderr, "certificates rotated; new certificates expire on %s\n", expiry.Format(time.RFC1123))
+	return nil
+}
+
+// waitForAgents waits until every running agent has acknowledged
+// the published CA certificates.
+func (c *RotateCertificatesCommand) waitForAgents(client *api.Client) error {
+	timeout := time.After(c.Timeout)
+	for {
+		pending, err := client.CACertPendingAgents()
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		logger.Infof("waiting for agents to trust the CA certificates: %s", strings.Join(pending, ", "))
+		select {
+		case <-time.After(caCertPollDelay):
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for agents to trust the CA certificates: %s", strings.Join(pending, ", "))
+		}
+	}
+}
+
+// certBundle returns a PEM bundle holding caCert followed by every
+// distinct certificate found in the trusted PEM blocks.
+func certBundle(caCert string, trusted ...string) (string, error) {
+	var buf bytes.Buffer
+	seen := make(map[string]bool)
+	for i, certsPEM := range append([]string{caCert}, trusted...) {
+		if i > 0 && certsPEM == "" {
+			continue
+		}
+		certs, err := cert.ParseCerts(certsPEM)
+		if err != nil {
+			return "", fmt.Errorf("invalid CA certificate: %v", err)
+		}
+		for _, x509Cert := range certs {
+			if seen[string(x509Cert.Raw)] {
+				continue
+			}
+			seen[string(x509Cert.Raw)] = true
+			pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: x509Cert.Raw})
+		}
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/cert"
+	"github.com/juju/core/cmd/envcmd"
+	jujutesting "github.com/juju/core/juju/testing"
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api/params"
+	coretesting "github.com/juju/core/testing"
+)
+
+type RotateCertificatesSuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&RotateCertificatesSuite{})
+
+func (s *RotateCertificatesSuite) SetUpTest(c *gc.C) {
+	s.RepoSuite.SetUpTest(c)
+	err := s.State.SetStateServingInfo(params.StateServingInfo{
+		APIPort:    1234,
+		StatePort:  4321,
+		Cert:       coretesting.ServerCert,
+		PrivateKey: coretesting.ServerKey,
+	})
+	c.Assert(err, gc.IsNil)
+}
+
+func runRotateCertificates(c *gc.C, args ...string) error {
+	_, err := coretesting.RunCommand(c, envcmd.Wrap(&RotateCertificatesCommand{}), args...)
+	return err
+}
+
+func (s *RotateCertificatesSuite) assertRotated(c *gc.C, expectTrusted int) (caCert string) {
+	info, err := s.ConfigStore.ReadInfo("dummyenv")
+	c.Assert(err, gc.IsNil)
+	caCert, _ = info.BootstrapConfig()["ca-cert"].(string)
+	caKey, _ := info.BootstrapConfig()["ca-private-key"].(string)
+	_, _, err = cert.ParseCertAndKey(caCert, caKey)
+	c.Assert(err, gc.IsNil)
+
+	bundle := info.APIEndpoint().CACert
+	trusted, err := cert.ParseCerts(bundle)
+	c.Assert(err, gc.IsNil)
+	c.Assert(trusted, gc.HasLen, expectTrusted)
+
+	servingInfo, err := s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(servingInfo.Cert, gc.Not(gc.Equals), coretesting.ServerCert)
+	err = cert.Verify(servingInfo.Cert, caCert, time.Now())
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.State.CACert(), gc.Equals, bundle)
+	return caCert
+}
+
+func (s *RotateCertificatesSuite) TestRotateServerCertificate(c *gc.C) {
+	err := runRotateCertificates(c)
+	c.Assert(err, gc.IsNil)
+	caCert := s.assertRotated(c, 1)
+	c.Assert(caCert, gc.Equals, coretesting.CACert)
+}
+
+func (s *RotateCertificatesSuite) TestRotateWithNewCA(c *gc.C) {
+	err := runRotateCertificates(c, "--new-ca")
+	c.Assert(err, gc.IsNil)
+	caCert := s.assertRotated(c, 2)
+	c.Assert(caCert, gc.Not(gc.Equals), coretesting.CACert)
+
+	err = runRotateCertificates(c, "--drop-old-ca")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.assertRotated(c, 1), gc.Equals, caCert)
+}
+
+func (s *RotateCertificatesSuite) TestWaitsForAgents(c *gc.C) {
+	s.PatchValue(&caCertPollDelay, coretesting.ShortWait)
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	pinger, err := machine.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+	s.State.StartSync()
+	err = machine.WaitAgentAlive(coretesting.LongWait)
+	c.Assert(err, gc.IsNil)
+
+	err = runRotateCertificates(c, "--new-ca", "--timeout", (4 * coretesting.ShortWait).String())
+	c.Assert(err, gc.ErrorMatches, "timed out waiting for agents to trust the CA certificates: "+machine.Tag())
+
+	// The CA certificates are published, but the state server
+	// certificate is left alone.
+	info, err := s.ConfigStore.ReadInfo("dummyenv")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.State.CACert(), gc.Equals, info.APIEndpoint().CACert)
+	servingInfo, err := s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(servingInfo.Cert, gc.Equals, coretesting.ServerCert)
+
+	// Once the agent trusts them, the rotation can complete.
+	err = s.State.AcknowledgeCACert(machine.Tag(), s.State.CACert())
+	c.Assert(err, gc.IsNil)
+	err = runRotateCertificates(c)
+	c.Assert(err, gc.IsNil)
+	s.assertRotated(c, 2)
+}
+
+func (s *RotateCertificatesSuite) TestInvalidDays(c *gc.C) {
+	err := runRotateCertificates(c, "--days", "0")
+	c.Assert(err, gc.ErrorMatches, "--days must be positive")
+}
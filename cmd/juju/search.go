@@ -0,0 +1,89 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/cmd"
+)
+
+const searchDoc = `
+Search the charm store for charms whose name, summary, description,
+categories or relation interfaces contain all the given words. Without
+words, all the charms are listed. Only the latest revision of each
+charm is reported.
+
+Examples:
+   juju search mysql                  (charms related to mysql)
+   juju search --series trusty blog   (blog charms for trusty)
+   juju search --owner bob            (charms published by bob)
+`
+
+// SearchCommand searches the charm store.
+type SearchCommand struct {
+	cmd.CommandBase
+	out    cmd.Output
+	Text   string
+	Series string
+	Owner  string
+	Limit  int
+}
+
+func (c *SearchCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "search",
+		Args:    "[<word> ...]",
+		Purpose: "search the charm store for charms",
+		Doc:     searchDoc,
+	}
+}
+
+func (c *SearchCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+	f.StringVar(&c.Series, "series", "", "only find charms of the given series")
+	f.StringVar(&c.Owner, "owner", "", "only find charms published by the given user")
+	f.IntVar(&c.Limit, "limit", 0, "the maximum number of charms to find")
+}
+
+func (c *SearchCommand) Init(args []string) error {
+	if c.Limit < 0 {
+		return fmt.Errorf("invalid limit %d", c.Limit)
+	}
+	c.Text = strings.Join(args, " ")
+	return nil
+}
+
+// searchResult holds the details of a charm written by the search command.
+type searchResult struct {
+	Revision int    `yaml:"revision" json:"revision"`
+	Summary  string `yaml:"summary,omitempty" json:"summary,omitempty"`
+}
+
+func (c *SearchCommand) Run(ctx *cmd.Context) error {
+	results, err := charm.Store.Search(charm.SearchParams{
+		Text:   c.Text,
+		Series: c.Series,
+		Owner:  c.Owner,
+		Limit:  c.Limit,
+	})
+	if err != nil {
+		return err
+	}
+	found := make(map[string]searchResult)
+	for _, result := range results {
+		found[result.URL] = searchResult{
+			Revision: result.Revision,
+			Summary:  result.Summary,
+		}
+	}
+	return c.out.Write(ctx, found)
+}
@@ -0,0 +1,68 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/charm"
+	charmtesting "github.com/juju/core/charm/testing"
+	"github.com/juju/core/testing"
+)
+
+type SearchSuite struct {
+	testing.FakeJujuHomeSuite
+	server *charmtesting.MockStore
+}
+
+var _ = gc.Suite(&SearchSuite{})
+
+func (s *SearchSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.server = charmtesting.NewMockStore(c, map[string]int{
+		"cs:precise/mysql":          3,
+		"cs:trusty/mysql":           1,
+		"cs:~bob/precise/wordpress": 7,
+	})
+	s.AddCleanup(func(*gc.C) { s.server.Close() })
+	s.PatchValue(&charm.Store, &charm.CharmStore{BaseURL: s.server.Address()})
+}
+
+func (s *SearchSuite) TestInit(c *gc.C) {
+	_, err := testing.RunCommand(c, &SearchCommand{}, "--limit", "-1")
+	c.Assert(err, gc.ErrorMatches, "invalid limit -1")
+}
+
+func (s *SearchSuite) TestSearch(c *gc.C) {
+	ctx, err := testing.RunCommand(c, &SearchCommand{}, "MySQL")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"cs:precise/mysql:\n"+
+		"  revision: 3\n"+
+		"  summary: A mysql charm.\n"+
+		"cs:trusty/mysql:\n"+
+		"  revision: 1\n"+
+		"  summary: A mysql charm.\n")
+	c.Assert(s.server.SearchRequests, gc.HasLen, 1)
+	c.Assert(s.server.SearchRequests[0].Get("text"), gc.Equals, "MySQL")
+}
+
+func (s *SearchSuite) TestSearchFilters(c *gc.C) {
+	ctx, err := testing.RunCommand(c, &SearchCommand{},
+		"--series", "precise", "--owner", "bob", "--limit", "10", "--format", "json")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals,
+		`{"cs:~bob/precise/wordpress":{"revision":7,"summary":"A wordpress charm."}}`+"\n")
+	c.Assert(s.server.SearchRequests, gc.HasLen, 1)
+	req := s.server.SearchRequests[0]
+	c.Assert(req.Get("series"), gc.Equals, "precise")
+	c.Assert(req.Get("owner"), gc.Equals, "bob")
+	c.Assert(req.Get("limit"), gc.Equals, "10")
+}
+
+func (s *SearchSuite) TestSearchNothingFound(c *gc.C) {
+	ctx, err := testing.RunCommand(c, &SearchCommand{}, "nothing", "here")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "{}\n")
+}
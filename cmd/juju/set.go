@@ -21,12 +21,25 @@ type SetCommand struct {
 	ServiceName     string
 	SettingsStrings map[string]string
 	SettingsYAML    cmd.FileVar
+	Rollback        int
+	Revision        int
 }
 
 const setDoc = `
 Set one or more configuration options for the specified service. See also the
 unset command which sets one or more configuration options for a specified
 service to their default value. 
+
+Every change to a service's configuration is recorded as a new settings
+revision; see the config-history command. With --rollback, the configuration
+is restored to that of the given revision, and revision 0 restores the
+configuration from before the first recorded change. A rollback changes
+nothing if the configuration is changed by someone else meanwhile, or if
+the options of the revision are no longer valid for the service's charm,
+as when the charm has since been upgraded to one that removed an option
+or changed its type; set the options explicitly instead. With --revision, the
+options are set only if the configuration is still at the given revision,
+so that concurrent changes aren't overwritten.
 `
 
 func (c *SetCommand) Info() *cmd.Info {
@@ -34,12 +47,14 @@ func (c *SetCommand) Info() *cmd.Info {
 		Name:    "set",
 		Args:    "<service> name=value ...",
 		Purpose: "set service config options",
-		Doc:     "Set one or more configuration options for the specified service.",
+		Doc:     setDoc,
 	}
 }
 
 func (c *SetCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.Var(&c.SettingsYAML, "config", "path to yaml-formatted service config")
+	f.IntVar(&c.Rollback, "rollback", -1, "restore the config of the given settings revision")
+	f.IntVar(&c.Revision, "revision", -1, "set only if the config is at the given settings revision")
 }
 
 func (c *SetCommand) Init(args []string) error {
@@ -49,6 +64,20 @@ func (c *SetCommand) Init(args []string) error {
 	if c.SettingsYAML.Path != "" && len(args) > 1 {
 		return errors.New("cannot specify --config when using key=value arguments")
 	}
+	if c.Rollback != -1 {
+		if c.Rollback < 0 {
+			return fmt.Errorf("invalid settings revision %d", c.Rollback)
+		}
+		if c.SettingsYAML.Path != "" || len(args) > 1 {
+			return errors.New("cannot specify --rollback with config options")
+		}
+		if c.Revision != -1 {
+			return errors.New("cannot specify --rollback with --revision")
+		}
+	}
+	if c.Revision < -1 {
+		return fmt.Errorf("invalid settings revision %d", c.Revision)
+	}
 	c.ServiceName = args[0]
 	settings, err := parse(args[1:])
 	if err != nil {
@@ -66,15 +95,24 @@ func (c *SetCommand) Run(ctx *cmd.Context) error {
 	}
 	defer api.Close()
 
+	if c.Rollback != -1 {
+		return api.ServiceRollbackConfig(c.ServiceName, c.Rollback)
+	}
 	if c.SettingsYAML.Path != "" {
 		b, err := c.SettingsYAML.Read(ctx)
 		if err != nil {
 			return err
 		}
+		if c.Revision != -1 {
+			return api.ServiceSetYAMLAtRevision(c.ServiceName, string(b), c.Revision)
+		}
 		return api.ServiceSetYAML(c.ServiceName, string(b))
 	} else if len(c.SettingsStrings) == 0 {
 		return nil
 	}
+	if c.Revision != -1 {
+		return api.ServiceSetAtRevision(c.ServiceName, c.SettingsStrings, c.Revision)
+	}
 	return api.ServiceSet(c.ServiceName, c.SettingsStrings)
 }
 
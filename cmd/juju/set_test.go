@@ -71,6 +71,69 @@ func (s *SetSuite) TestSetConfig(c *gc.C) {
 	})
 }
 
+func (s *SetSuite) TestSetAtRevision(c *gc.C) {
+	assertSetSuccess(c, s.dir, s.svc, []string{
+		"--revision", "0",
+		"username=hello",
+	}, charm.Settings{
+		"username": "hello",
+	})
+	assertSetFail(c, s.dir, []string{
+		"--revision", "0",
+		"username=goodbye",
+	}, `error: cannot update settings of service "dummy-service": settings changed since revision 0 \(now at revision 1\)\n`)
+	assertSetSuccess(c, s.dir, s.svc, []string{
+		"--revision", "1",
+		"--config", "testconfig.yaml",
+	}, charm.Settings{
+		"username":    "admin001",
+		"skill-level": int64(9000),
+	})
+}
+
+func (s *SetSuite) TestSetRollback(c *gc.C) {
+	assertSetSuccess(c, s.dir, s.svc, []string{
+		"username=hello",
+	}, charm.Settings{
+		"username": "hello",
+	})
+	assertSetSuccess(c, s.dir, s.svc, []string{
+		"outlook=hello@world.tld",
+	}, charm.Settings{
+		"username": "hello",
+		"outlook":  "hello@world.tld",
+	})
+	assertSetSuccess(c, s.dir, s.svc, []string{
+		"--rollback", "1",
+	}, charm.Settings{
+		"username": "hello",
+	})
+	assertSetSuccess(c, s.dir, s.svc, []string{
+		"--rollback", "0",
+	}, charm.Settings{})
+	assertSetFail(c, s.dir, []string{
+		"--rollback", "9",
+	}, `error: cannot roll back settings of service "dummy-service" to revision 9: no such revision\n`)
+}
+
+func (s *SetSuite) TestSetRollbackFail(c *gc.C) {
+	assertSetFail(c, s.dir, []string{
+		"--rollback", "1",
+		"username=hello",
+	}, "error: cannot specify --rollback with config options\n")
+	assertSetFail(c, s.dir, []string{
+		"--rollback", "1",
+		"--config", "testconfig.yaml",
+	}, "error: cannot specify --rollback with config options\n")
+	assertSetFail(c, s.dir, []string{
+		"--rollback", "1",
+		"--revision", "1",
+	}, "error: cannot specify --rollback with --revision\n")
+	assertSetFail(c, s.dir, []string{
+		"--rollback=-2",
+	}, "error: invalid settings revision -2\n")
+}
+
 // assertSetSuccess sets configuration options and checks the expected settings.
 func assertSetSuccess(c *gc.C, dir string, svc *state.Service, args []string, expect charm.Settings) {
 	ctx := coretesting.ContextForDir(c, dir)
@@ -0,0 +1,144 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/cmd"
+)
+
+const signCharmDoc = `
+Sign a charm archive with an OpenPGP private key, so that environments
+whose charm-verification setting is strict or warn accept the charm
+when the key is among their charm-trusted-keys.
+
+Given a charm store URL, the archive of the charm in the store is signed
+and the signature is uploaded to the store, which accepts signatures
+made by the keys it trusts to sign charms. A URL without a revision
+signs the latest revision.
+
+Given the path of a local charm archive, the signature is written next
+to it, to the archive path with ".asc" appended, where deploy and
+upgrade-charm look for it.
+
+The private key is read in armored form from the file given with --key.
+If it is protected by a passphrase, --passphrase-file names the file
+holding the passphrase.
+
+Examples:
+   juju sign-charm --key signer.asc cs:~joe/precise/wordpress
+   juju sign-charm --key signer.asc ~/charms/precise/wordpress.charm
+`
+
+// SignCharmCommand signs charm archives.
+type SignCharmCommand struct {
+	cmd.CommandBase
+	keyFile        string
+	passphraseFile string
+	storeURL       string
+	curl           *charm.URL
+	archivePath    string
+}
+
+var _ cmd.Command = (*SignCharmCommand)(nil)
+
+func (c *SignCharmCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "sign-charm",
+		Args:    "<charm url | charm archive path>",
+		Purpose: "sign a charm archive",
+		Doc:     signCharmDoc,
+	}
+}
+
+func (c *SignCharmCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.keyFile, "key", "", "file holding the armored private key to sign with")
+	f.StringVar(&c.passphraseFile, "passphrase-file", "", "file holding the passphrase of the private key")
+	f.StringVar(&c.storeURL, "store", charm.Store.BaseURL, "the URL of the charm store holding the charm")
+}
+
+func (c *SignCharmCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no charm specified")
+	}
+	if c.keyFile == "" {
+		return fmt.Errorf("no private key specified; use --key")
+	}
+	if strings.HasPrefix(args[0], "cs:") {
+		curl, err := charm.ParseURL(args[0])
+		if err != nil {
+			return err
+		}
+		c.curl = curl
+	} else {
+		c.archivePath = args[0]
+	}
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *SignCharmCommand) Run(ctx *cmd.Context) error {
+	key, err := ioutil.ReadFile(ctx.AbsPath(c.keyFile))
+	if err != nil {
+		return err
+	}
+	var passphrase []byte
+	if c.passphraseFile != "" {
+		if passphrase, err = ioutil.ReadFile(ctx.AbsPath(c.passphraseFile)); err != nil {
+			return err
+		}
+	}
+	sign := func(path string) ([]byte, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return charm.SignArchive(f, string(key), strings.TrimRight(string(passphrase), "\n"))
+	}
+	if c.curl == nil {
+		path := ctx.AbsPath(c.archivePath)
+		signature, err := sign(path)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path+charm.SignatureSuffix, signature, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(ctx.Stdout, "wrote %s\n", path+charm.SignatureSuffix)
+		return nil
+	}
+
+	store := &charm.CharmStore{BaseURL: strings.TrimRight(c.storeURL, "/")}
+	curl := c.curl
+	if curl.Revision == -1 {
+		revs, err := store.Latest(curl)
+		if err != nil {
+			return err
+		}
+		if revs[0].Err != nil {
+			return revs[0].Err
+		}
+		curl = curl.WithRevision(revs[0].Revision)
+	}
+	ch, err := store.Get(curl)
+	if err != nil {
+		return err
+	}
+	signature, err := sign(ch.(*charm.Bundle).Path)
+	if err != nil {
+		return err
+	}
+	if err := store.UploadSignature(curl, signature); err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.Stdout, "signed %s\n", curl)
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/charm"
+	charmtesting "github.com/juju/core/charm/testing"
+	"github.com/juju/core/cmd"
+	jujutesting "github.com/juju/core/juju/testing"
+	"github.com/juju/core/state/apiserver/client"
+	"github.com/juju/core/testing"
+)
+
+type SignCharmSuite struct {
+	jujutesting.RepoSuite
+	mockstore *charmtesting.MockStore
+	keyFile   string
+}
+
+var _ = gc.Suite(&SignCharmSuite{})
+
+func (s *SignCharmSuite) SetUpTest(c *gc.C) {
+	s.RepoSuite.SetUpTest(c)
+	s.mockstore = charmtesting.NewMockStore(c, map[string]int{"cs:precise/dummy": 1})
+	s.AddCleanup(func(*gc.C) { s.mockstore.Close() })
+	store := &charm.CharmStore{BaseURL: s.mockstore.Address()}
+	s.PatchValue(&charm.Store, store)
+	s.PatchValue(&client.CharmStore, charm.Repository(store))
+	s.PatchValue(&charm.CacheDir, c.MkDir())
+	s.keyFile = filepath.Join(c.MkDir(), "signer.asc")
+	err := ioutil.WriteFile(s.keyFile, []byte(charmtesting.SigningPrivateKey), 0600)
+	c.Assert(err, gc.IsNil)
+}
+
+func runSignCharm(c *gc.C, args ...string) (*cmd.Context, error) {
+	return testing.RunCommand(c, &SignCharmCommand{}, args...)
+}
+
+func (s *SignCharmSuite) TestInitErrors(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		args: nil,
+		err:  "no charm specified",
+	}, {
+		args: []string{"cs:precise/dummy"},
+		err:  "no private key specified; use --key",
+	}, {
+		args: []string{"--key", s.keyFile, "cs:dummy"},
+		err:  "charm url series is not resolved",
+	}, {
+		args: []string{"--key", s.keyFile, "cs:precise/dummy", "extra"},
+		err:  `unrecognized args: \["extra"\]`,
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		err := testing.InitCommand(&SignCharmCommand{}, test.args)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *SignCharmSuite) TestSignLocalArchive(c *gc.C) {
+	path := testing.Charms.BundlePath(c.MkDir(), "dummy")
+	ctx, err := runSignCharm(c, "--key", s.keyFile, path)
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "wrote "+path+charm.SignatureSuffix+"\n")
+
+	signature, err := ioutil.ReadFile(path + charm.SignatureSuffix)
+	c.Assert(err, gc.IsNil)
+	f, err := os.Open(path)
+	c.Assert(err, gc.IsNil)
+	defer f.Close()
+	identities, err := charm.CheckArchiveSignature(f, signature, charmtesting.SigningPublicKey)
+	c.Assert(err, gc.IsNil)
+	c.Assert(identities, gc.DeepEquals, []string{"Charm Signer <signer@example.com>"})
+}
+
+func (s *SignCharmSuite) TestSignStoreCharmAndDeploy(c *gc.C) {
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"charm-verification": "strict",
+		"charm-trusted-keys": charmtesting.SigningPublicKey,
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	err = runDeploy(c, "cs:precise/dummy")
+	c.Assert(err, gc.ErrorMatches, `.*charm signature verification failed: charm archive is not signed`)
+
+	ctx, err := runSignCharm(c, "--key", s.keyFile, "--store", s.mockstore.Address(), "cs:precise/dummy")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "signed cs:precise/dummy-1\n")
+
+	// The signed charm is accepted by the environment.
+	err = runDeploy(c, "cs:precise/dummy")
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.Service("dummy")
+	c.Assert(err, gc.IsNil)
+}
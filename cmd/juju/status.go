@@ -6,6 +6,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"launchpad.net/gnuflag"
 
@@ -85,10 +86,34 @@ func (c *StatusCommand) Run(ctx *cmd.Context) error {
 	if err != nil {
 		fmt.Fprintf(ctx.Stderr, "%v\n", err)
 	}
+	warnCertificatesExpiry(ctx, apiclient)
 	result := formatStatus(status)
 	return c.out.Write(ctx, result)
 }
 
+// certificatesExpiryWarning is how long before the environment's
+// certificates expire that status starts warning about them.
+const certificatesExpiryWarning = 30 * 24 * time.Hour
+
+// warnCertificatesExpiry prints a warning if the environment's
+// certificates are due to expire soon. Errors are ignored, as older
+// API servers do not report certificate expiry.
+func warnCertificatesExpiry(ctx *cmd.Context, apiclient *api.Client) {
+	expiry, err := apiclient.CertificatesExpiry()
+	if err != nil {
+		return
+	}
+	earliest := expiry.CACert
+	if expiry.ServerCert.Before(earliest) {
+		earliest = expiry.ServerCert
+	}
+	if time.Now().Add(certificatesExpiryWarning).Before(earliest) {
+		return
+	}
+	fmt.Fprintf(ctx.Stderr, "WARNING: environment certificates expire on %s; run juju rotate-certificates to renew them\n",
+		earliest.Format(time.RFC1123))
+}
+
 type formattedStatus struct {
 	Environment string                   `json:"environment"`
 	Machines    map[string]machineStatus `json:"machines"`
@@ -101,19 +126,21 @@ type errorStatus struct {
 }
 
 type machineStatus struct {
-	Err            error                    `json:"-" yaml:",omitempty"`
-	AgentState     params.Status            `json:"agent-state,omitempty" yaml:"agent-state,omitempty"`
-	AgentStateInfo string                   `json:"agent-state-info,omitempty" yaml:"agent-state-info,omitempty"`
-	AgentVersion   string                   `json:"agent-version,omitempty" yaml:"agent-version,omitempty"`
-	DNSName        string                   `json:"dns-name,omitempty" yaml:"dns-name,omitempty"`
-	InstanceId     instance.Id              `json:"instance-id,omitempty" yaml:"instance-id,omitempty"`
-	InstanceState  string                   `json:"instance-state,omitempty" yaml:"instance-state,omitempty"`
-	Life           string                   `json:"life,omitempty" yaml:"life,omitempty"`
-	Series         string                   `json:"series,omitempty" yaml:"series,omitempty"`
-	Id             string                   `json:"-" yaml:"-"`
-	Containers     map[string]machineStatus `json:"containers,omitempty" yaml:"containers,omitempty"`
-	Hardware       string                   `json:"hardware,omitempty" yaml:"hardware,omitempty"`
-	HAStatus       string                   `json:"state-server-member-status,omitempty" yaml:"state-server-member-status,omitempty"`
+	Err             error                    `json:"-" yaml:",omitempty"`
+	AgentState      params.Status            `json:"agent-state,omitempty" yaml:"agent-state,omitempty"`
+	AgentStateInfo  string                   `json:"agent-state-info,omitempty" yaml:"agent-state-info,omitempty"`
+	AgentVersion    string                   `json:"agent-version,omitempty" yaml:"agent-version,omitempty"`
+	AgentDownSince  string                   `json:"agent-down-since,omitempty" yaml:"agent-down-since,omitempty"`
+	AgentAPIAddress string                   `json:"agent-api-address,omitempty" yaml:"agent-api-address,omitempty"`
+	DNSName         string                   `json:"dns-name,omitempty" yaml:"dns-name,omitempty"`
+	InstanceId      instance.Id              `json:"instance-id,omitempty" yaml:"instance-id,omitempty"`
+	InstanceState   string                   `json:"instance-state,omitempty" yaml:"instance-state,omitempty"`
+	Life            string                   `json:"life,omitempty" yaml:"life,omitempty"`
+	Series          string                   `json:"series,omitempty" yaml:"series,omitempty"`
+	Id              string                   `json:"-" yaml:"-"`
+	Containers      map[string]machineStatus `json:"containers,omitempty" yaml:"containers,omitempty"`
+	Hardware        string                   `json:"hardware,omitempty" yaml:"hardware,omitempty"`
+	HAStatus        string                   `json:"state-server-member-status,omitempty" yaml:"state-server-member-status,omitempty"`
 }
 
 // A goyaml bug means we can't declare these types
@@ -148,10 +175,21 @@ type serviceStatus struct {
 	Networks      map[string][]string   `json:"networks,omitempty" yaml:"networks,omitempty"`
 	SubordinateTo []string              `json:"subordinate-to,omitempty" yaml:"subordinate-to,omitempty"`
 	Units         map[string]unitStatus `json:"units,omitempty" yaml:"units,omitempty"`
+	Rollout       *rolloutStatus        `json:"rollout,omitempty" yaml:"rollout,omitempty"`
 }
 
 type serviceStatusNoMarshal serviceStatus
 
+type rolloutStatus struct {
+	From        string   `json:"from" yaml:"from"`
+	BatchSize   int      `json:"batch-size" yaml:"batch-size"`
+	WaitHealthy bool     `json:"wait-healthy,omitempty" yaml:"wait-healthy,omitempty"`
+	Upgrading   []string `json:"upgrading,omitempty" yaml:"upgrading,omitempty"`
+	Upgraded    []string `json:"upgraded,omitempty" yaml:"upgraded,omitempty"`
+	Pending     []string `json:"pending,omitempty" yaml:"pending,omitempty"`
+	Halted      string   `json:"halted,omitempty" yaml:"halted,omitempty"`
+}
+
 func (s serviceStatus) MarshalJSON() ([]byte, error) {
 	if s.Err != nil {
 		return json.Marshal(errorStatus{s.Err.Error()})
@@ -169,16 +207,18 @@ func (s serviceStatus) GetYAML() (tag string, value interface{}) {
 }
 
 type unitStatus struct {
-	Err            error                 `json:"-" yaml:",omitempty"`
-	Charm          string                `json:"upgrading-from,omitempty" yaml:"upgrading-from,omitempty"`
-	AgentState     params.Status         `json:"agent-state,omitempty" yaml:"agent-state,omitempty"`
-	AgentStateInfo string                `json:"agent-state-info,omitempty" yaml:"agent-state-info,omitempty"`
-	AgentVersion   string                `json:"agent-version,omitempty" yaml:"agent-version,omitempty"`
-	Life           string                `json:"life,omitempty" yaml:"life,omitempty"`
-	Machine        string                `json:"machine,omitempty" yaml:"machine,omitempty"`
-	OpenedPorts    []string              `json:"open-ports,omitempty" yaml:"open-ports,omitempty"`
-	PublicAddress  string                `json:"public-address,omitempty" yaml:"public-address,omitempty"`
-	Subordinates   map[string]unitStatus `json:"subordinates,omitempty" yaml:"subordinates,omitempty"`
+	Err             error                 `json:"-" yaml:",omitempty"`
+	Charm           string                `json:"upgrading-from,omitempty" yaml:"upgrading-from,omitempty"`
+	AgentState      params.Status         `json:"agent-state,omitempty" yaml:"agent-state,omitempty"`
+	AgentStateInfo  string                `json:"agent-state-info,omitempty" yaml:"agent-state-info,omitempty"`
+	AgentVersion    string                `json:"agent-version,omitempty" yaml:"agent-version,omitempty"`
+	AgentDownSince  string                `json:"agent-down-since,omitempty" yaml:"agent-down-since,omitempty"`
+	AgentAPIAddress string                `json:"agent-api-address,omitempty" yaml:"agent-api-address,omitempty"`
+	Life            string                `json:"life,omitempty" yaml:"life,omitempty"`
+	Machine         string                `json:"machine,omitempty" yaml:"machine,omitempty"`
+	OpenedPorts     []string              `json:"open-ports,omitempty" yaml:"open-ports,omitempty"`
+	PublicAddress   string                `json:"public-address,omitempty" yaml:"public-address,omitempty"`
+	Subordinates    map[string]unitStatus `json:"subordinates,omitempty" yaml:"subordinates,omitempty"`
 }
 
 type unitStatusNoMarshal unitStatus
@@ -249,18 +289,20 @@ func formatStatus(status *api.Status) formattedStatus {
 
 func formatMachine(machine api.MachineStatus) machineStatus {
 	out := machineStatus{
-		Err:            machine.Err,
-		AgentState:     machine.AgentState,
-		AgentStateInfo: machine.AgentStateInfo,
-		AgentVersion:   machine.AgentVersion,
-		DNSName:        machine.DNSName,
-		InstanceId:     machine.InstanceId,
-		InstanceState:  machine.InstanceState,
-		Life:           machine.Life,
-		Series:         machine.Series,
-		Id:             machine.Id,
-		Containers:     make(map[string]machineStatus),
-		Hardware:       machine.Hardware,
+		Err:             machine.Err,
+		AgentState:      machine.AgentState,
+		AgentStateInfo:  machine.AgentStateInfo,
+		AgentVersion:    machine.AgentVersion,
+		AgentDownSince:  formatDownSince(machine.AgentState, machine.AgentLastSeen),
+		AgentAPIAddress: machine.AgentAPIAddress,
+		DNSName:         machine.DNSName,
+		InstanceId:      machine.InstanceId,
+		InstanceState:   machine.InstanceState,
+		Life:            machine.Life,
+		Series:          machine.Series,
+		Id:              machine.Id,
+		Containers:      make(map[string]machineStatus),
+		Hardware:        machine.Hardware,
 	}
 	for k, m := range machine.Containers {
 		out.Containers[k] = formatMachine(m)
@@ -275,6 +317,16 @@ func formatMachine(machine api.MachineStatus) machineStatus {
 	return out
 }
 
+// formatDownSince returns the time since which an agent in the given
+// state has been down, or the empty string if the agent is not down
+// or has never been seen alive.
+func formatDownSince(agentState params.Status, lastSeen time.Time) string {
+	if agentState != params.StatusDown || lastSeen.IsZero() {
+		return ""
+	}
+	return lastSeen.UTC().Format(time.RFC3339)
+}
+
 func makeHAStatus(hasVote, wantsVote bool) string {
 	var s string
 	switch {
@@ -311,21 +363,36 @@ func formatService(service api.ServiceStatus) serviceStatus {
 	for k, m := range service.Units {
 		out.Units[k] = formatUnit(m)
 	}
+	if r := service.Rollout; r != nil {
+		out.Rollout = &rolloutStatus{
+			From:        r.FromCharm,
+			BatchSize:   r.BatchSize,
+			WaitHealthy: r.WaitHealthy,
+			Upgrading:   r.Upgrading,
+			Upgraded:    r.Upgraded,
+			Pending:     r.Pending,
+		}
+		if r.Halted {
+			out.Rollout.Halted = r.Message
+		}
+	}
 	return out
 }
 
 func formatUnit(unit api.UnitStatus) unitStatus {
 	out := unitStatus{
-		Err:            unit.Err,
-		AgentState:     unit.AgentState,
-		AgentStateInfo: unit.AgentStateInfo,
-		AgentVersion:   unit.AgentVersion,
-		Life:           unit.Life,
-		Machine:        unit.Machine,
-		OpenedPorts:    unit.OpenedPorts,
-		PublicAddress:  unit.PublicAddress,
-		Charm:          unit.Charm,
-		Subordinates:   make(map[string]unitStatus),
+		Err:             unit.Err,
+		AgentState:      unit.AgentState,
+		AgentStateInfo:  unit.AgentStateInfo,
+		AgentVersion:    unit.AgentVersion,
+		AgentDownSince:  formatDownSince(unit.AgentState, unit.AgentLastSeen),
+		AgentAPIAddress: unit.AgentAPIAddress,
+		Life:            unit.Life,
+		Machine:         unit.Machine,
+		OpenedPorts:     unit.OpenedPorts,
+		PublicAddress:   unit.PublicAddress,
+		Charm:           unit.Charm,
+		Subordinates:    make(map[string]unitStatus),
 	}
 	for k, m := range unit.Subordinates {
 		out.Subordinates[k] = formatUnit(m)
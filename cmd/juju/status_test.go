@@ -15,6 +15,7 @@ import (
 	gc "launchpad.net/gocheck"
 	"launchpad.net/goyaml"
 
+	"github.com/juju/core/cert"
 	"github.com/juju/core/charm"
 	"github.com/juju/core/cmd"
 	"github.com/juju/core/cmd/envcmd"
@@ -1568,6 +1569,66 @@ var statusTests = []testCase{
 				},
 			},
 		},
+	), test(
+		"service with a halted rolling charm upgrade",
+		addMachine{machineId: "0", job: state.JobManageEnviron},
+		setAddresses{"0", []instance.Address{instance.NewAddress("dummyenv-0.dns", instance.NetworkUnknown)}},
+		startAliveMachine{"0"},
+		setMachineStatus{"0", params.StatusStarted, ""},
+		addMachine{machineId: "1", job: state.JobHostUnits},
+		setAddresses{"1", []instance.Address{instance.NewAddress("dummyenv-1.dns", instance.NetworkUnknown)}},
+		startAliveMachine{"1"},
+		setMachineStatus{"1", params.StatusStarted, ""},
+		addCharm{"mysql"},
+		addService{name: "mysql", charm: "mysql"},
+		addAliveUnit{"mysql", "1"},
+		setUnitCharmURL{"mysql/0", "cs:quantal/mysql-1"},
+		addAliveUnit{"mysql", "1"},
+		setUnitCharmURL{"mysql/1", "cs:quantal/mysql-1"},
+		addCharmWithRevision{addCharm{"mysql"}, "cs", 2},
+		setServiceCharmRollout{"mysql", "cs:quantal/mysql-2", 1},
+		advanceRollouts{},
+		setUnitStatus{"mysql/0", params.StatusError, `hook failed: "upgrade-charm"`},
+		advanceRollouts{},
+
+		expect{
+			"rolling upgrade halted by a hook error",
+			M{
+				"environment": "dummyenv",
+				"machines": M{
+					"0": machine0,
+					"1": machine1,
+				},
+				"services": M{
+					"mysql": M{
+						"charm":   "cs:quantal/mysql-2",
+						"exposed": false,
+						"units": M{
+							"mysql/0": M{
+								"machine":          "1",
+								"agent-state":      "error",
+								"agent-state-info": `hook failed: "upgrade-charm"`,
+								"upgrading-from":   "cs:quantal/mysql-1",
+								"public-address":   "dummyenv-1.dns",
+							},
+							"mysql/1": M{
+								"machine":        "1",
+								"agent-state":    "started",
+								"upgrading-from": "cs:quantal/mysql-1",
+								"public-address": "dummyenv-1.dns",
+							},
+						},
+						"rollout": M{
+							"from":       "cs:quantal/mysql-1",
+							"batch-size": 1,
+							"upgrading":  L{"mysql/0"},
+							"pending":    L{"mysql/1"},
+							"halted":     `unit mysql/0: hook failed: "upgrade-charm"`,
+						},
+					},
+				},
+			},
+		},
 	),
 }
 
@@ -1765,6 +1826,28 @@ func (ssc setServiceCharm) step(c *gc.C, ctx *context) {
 	c.Assert(err, gc.IsNil)
 }
 
+type setServiceCharmRollout struct {
+	name      string
+	charm     string
+	batchSize int
+}
+
+func (ssc setServiceCharmRollout) step(c *gc.C, ctx *context) {
+	ch, err := ctx.st.Charm(charm.MustParseURL(ssc.charm))
+	c.Assert(err, gc.IsNil)
+	s, err := ctx.st.Service(ssc.name)
+	c.Assert(err, gc.IsNil)
+	err = s.SetCharmRollout(ch, false, ssc.batchSize, false)
+	c.Assert(err, gc.IsNil)
+}
+
+type advanceRollouts struct{}
+
+func (advanceRollouts) step(c *gc.C, ctx *context) {
+	err := ctx.st.AdvanceRollouts()
+	c.Assert(err, gc.IsNil)
+}
+
 type addCharmPlaceholder struct {
 	name string
 	rev  int
@@ -2031,3 +2114,78 @@ func (s *StatusSuite) TestStatusFilterErrors(c *gc.C) {
 	c.Assert(code, gc.Not(gc.Equals), 0)
 	c.Assert(string(stderr), gc.Equals, `error: pattern "[*" contains invalid characters`+"\n")
 }
+
+func (s *StatusSuite) TestStatusWarnsCertificatesExpiry(c *gc.C) {
+	srvCert, srvKey, err := cert.NewServer(coretesting.CACert, coretesting.CAKey, time.Now().AddDate(0, 0, 7), nil)
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetStateServingInfo(params.StateServingInfo{
+		APIPort:    1234,
+		StatePort:  4321,
+		Cert:       srvCert,
+		PrivateKey: srvKey,
+	})
+	c.Assert(err, gc.IsNil)
+
+	code, _, stderr := runStatus(c)
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(string(stderr), gc.Matches, "WARNING: environment certificates expire on .*; run juju rotate-certificates to renew them\n")
+}
+
+func (s *StatusSuite) TestStatusNoCertificatesWarning(c *gc.C) {
+	err := s.State.SetStateServingInfo(params.StateServingInfo{
+		APIPort:    1234,
+		StatePort:  4321,
+		Cert:       coretesting.ServerCert,
+		PrivateKey: coretesting.ServerKey,
+	})
+	c.Assert(err, gc.IsNil)
+
+	code, _, stderr := runStatus(c)
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(string(stderr), gc.Equals, "")
+}
+
+func (s *StatusSuite) TestStatusAgentDownSince(c *gc.C) {
+	steps := []stepper{
+		addMachine{machineId: "0", job: state.JobManageEnviron},
+		startAliveMachine{"0"},
+		setMachineStatus{"0", params.StatusStarted, ""},
+	}
+	ctx := s.newContext()
+	defer s.resetContext(c, ctx)
+	ctx.run(c, steps)
+
+	m, err := ctx.st.Machine("0")
+	c.Assert(err, gc.IsNil)
+	err = m.SetAgentAPIAddress("10.0.0.1:17070")
+	c.Assert(err, gc.IsNil)
+	err = ctx.pingers["0"].Kill()
+	c.Assert(err, gc.IsNil)
+	delete(ctx.pingers, "0")
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		ctx.st.StartSync()
+		alive, err := m.AgentAlive()
+		c.Assert(err, gc.IsNil)
+		if !alive {
+			break
+		}
+		if !a.HasNext() {
+			c.Fatalf("agent still alive")
+		}
+	}
+
+	code, stdout, _ := runStatus(c, "--format", "json")
+	c.Assert(code, gc.Equals, 0)
+	var status struct {
+		Machines map[string]map[string]interface{}
+	}
+	err = json.Unmarshal(stdout, &status)
+	c.Assert(err, gc.IsNil)
+	machine := status.Machines["0"]
+	c.Assert(machine["agent-state"], gc.Equals, "down")
+	c.Assert(machine["agent-api-address"], gc.Equals, "10.0.0.1:17070")
+	downSince, ok := machine["agent-down-since"].(string)
+	c.Assert(ok, gc.Equals, true)
+	_, err = time.Parse(time.RFC3339, downSince)
+	c.Assert(err, gc.IsNil)
+}
@@ -6,7 +6,11 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
 
 	"launchpad.net/gnuflag"
 
@@ -16,6 +20,7 @@ import (
 	"github.com/juju/core/environs/config"
 	"github.com/juju/core/juju"
 	"github.com/juju/core/names"
+	"github.com/juju/core/state/api/params"
 )
 
 // UpgradeCharm is responsible for upgrading a service's charm.
@@ -26,6 +31,9 @@ type UpgradeCharmCommand struct {
 	RepoPath    string // defaults to JUJU_REPOSITORY
 	SwitchURL   string
 	Revision    int // defaults to -1 (latest)
+	DryRun      bool
+	BatchSize   int
+	WaitHealthy bool
 }
 
 const upgradeCharmDoc = `
@@ -61,6 +69,22 @@ The new charm may add new relations and configuration settings.
 number with --switch, give it in the charm URL, for instance "cs:wordpress-5"
 would specify revision number 5 of the wordpress charm.
 
+The --dry-run flag reports what the upgrade would change instead of
+upgrading: the config options added, removed or changed, the current
+settings that the new charm would drop as invalid, the relation
+endpoints and hooks added or removed, the peer relations that would be
+created and the existing relations that would prevent the upgrade. The
+new charm is still added to the environment.
+
+By default all of the service's units are upgraded at once. The
+--batch-size flag upgrades the existing units that many at a time
+instead: the state server releases the next batch of units only when
+the units of the current batch have upgraded, and, with --wait-healthy,
+have started again. The upgrade halts while an upgrading unit has a
+hook error, and carries on once the error is resolved. juju status
+shows the progress of the upgrade. Units added during the upgrade
+start with the new charm.
+
 Use of the --force flag is not generally recommended; units upgraded while in an
 error state will not have upgrade-charm hooks executed, and may cause unexpected
 behavior.
@@ -80,6 +104,9 @@ func (c *UpgradeCharmCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.StringVar(&c.RepoPath, "repository", os.Getenv("JUJU_REPOSITORY"), "local charm repository path")
 	f.StringVar(&c.SwitchURL, "switch", "", "crossgrade to a different charm")
 	f.IntVar(&c.Revision, "revision", -1, "explicit revision of current charm")
+	f.BoolVar(&c.DryRun, "dry-run", false, "report the changes the upgrade would make, without upgrading")
+	f.IntVar(&c.BatchSize, "batch-size", 0, "upgrade this many units at a time")
+	f.BoolVar(&c.WaitHealthy, "wait-healthy", false, "wait for each batch of units to start before upgrading the next")
 }
 
 func (c *UpgradeCharmCommand) Init(args []string) error {
@@ -97,6 +124,12 @@ func (c *UpgradeCharmCommand) Init(args []string) error {
 	if c.SwitchURL != "" && c.Revision != -1 {
 		return fmt.Errorf("--switch and --revision are mutually exclusive")
 	}
+	if c.BatchSize < 0 {
+		return fmt.Errorf("invalid batch size %d", c.BatchSize)
+	}
+	if c.WaitHealthy && c.BatchSize == 0 {
+		return fmt.Errorf("--wait-healthy requires --batch-size")
+	}
 	return nil
 }
 
@@ -124,7 +157,7 @@ func (c *UpgradeCharmCommand) Run(ctx *cmd.Context) error {
 
 	var newURL *charm.URL
 	if c.SwitchURL != "" {
-		newURL, err = resolveCharmURL(c.SwitchURL, client, conf)
+		newURL, err = resolveCharmURL(c.SwitchURL, client, conf, ctx.AbsPath(c.RepoPath))
 		if err != nil {
 			return err
 		}
@@ -165,6 +198,89 @@ func (c *UpgradeCharmCommand) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return err
 	}
+	if c.DryRun {
+		diff, err := client.CharmDiff(c.ServiceName, addedURL.String())
+		if err != nil {
+			return err
+		}
+		writeCharmDiff(ctx.Stdout, c.ServiceName, diff)
+		return nil
+	}
 
+	if c.BatchSize > 0 {
+		return client.ServiceSetCharmRollout(c.ServiceName, addedURL.String(), c.Force, c.BatchSize, c.WaitHealthy)
+	}
 	return client.ServiceSetCharm(c.ServiceName, addedURL.String(), c.Force)
 }
+
+// writeCharmDiff writes a description of diff, as returned by the
+// CharmDiff API call, for an upgrade of the named service.
+func writeCharmDiff(w io.Writer, serviceName string, diff *params.CharmDiffResults) {
+	fmt.Fprintf(w, "upgrading service %q from %s to %s would:\n", serviceName, diff.OldCharmURL, diff.NewCharmURL)
+	var changes []string
+	add := func(format string, args ...interface{}) {
+		changes = append(changes, fmt.Sprintf(format, args...))
+	}
+	for _, name := range sortedKeys(diff.AddedOptions) {
+		option := diff.AddedOptions[name]
+		add("add config option %q (%s, default %v)", name, option.Type, option.Default)
+	}
+	for _, name := range sortedKeys(diff.RemovedOptions) {
+		add("remove config option %q", name)
+	}
+	for _, name := range sortedKeys(diff.ChangedOptions) {
+		change := diff.ChangedOptions[name]
+		switch {
+		case change.Old.Type != change.New.Type:
+			add("change the type of config option %q from %s to %s", name, change.Old.Type, change.New.Type)
+		case !reflect.DeepEqual(change.Old.Default, change.New.Default):
+			add("change the default of config option %q from %v to %v", name, change.Old.Default, change.New.Default)
+		default:
+			add("change the description of config option %q", name)
+		}
+	}
+	for _, name := range sortedKeys(diff.InvalidSettings) {
+		add("drop the invalid setting %s=%v", name, diff.InvalidSettings[name])
+	}
+	for _, rel := range diff.AddedRelations {
+		add("add %s relation %q (interface %s)", rel.Role, rel.Name, rel.Interface)
+	}
+	for _, rel := range diff.RemovedRelations {
+		add("remove %s relation %q (interface %s)", rel.Role, rel.Name, rel.Interface)
+	}
+	for _, change := range diff.ChangedRelations {
+		add("change %s relation %q (interface %s, scope %s, limit %d to interface %s, scope %s, limit %d)",
+			change.New.Role, change.New.Name,
+			change.Old.Interface, change.Old.Scope, change.Old.Limit,
+			change.New.Interface, change.New.Scope, change.New.Limit)
+	}
+	if len(diff.AddedHooks) > 0 {
+		add("add hooks %s", strings.Join(diff.AddedHooks, ", "))
+	}
+	if len(diff.RemovedHooks) > 0 {
+		add("remove hooks %s", strings.Join(diff.RemovedHooks, ", "))
+	}
+	for _, name := range diff.AddedPeers {
+		add("create peer relation %q", name)
+	}
+	for _, key := range diff.BrokenRelations {
+		add("break relation %q, so the upgrade would fail", key)
+	}
+	if len(changes) == 0 {
+		changes = []string{"change nothing in config options, relations or hooks"}
+	}
+	for _, change := range changes {
+		fmt.Fprintf(w, "  %s\n", change)
+	}
+}
+
+// sortedKeys returns the keys of m, which must be a map
+// with string keys, in alphabetical order.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
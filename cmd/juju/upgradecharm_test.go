@@ -94,6 +94,14 @@ func (s *UpgradeCharmErrorsSuite) TestInvalidRevision(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `invalid value "blah" for flag --revision: strconv.ParseInt: parsing "blah": invalid syntax`)
 }
 
+func (s *UpgradeCharmErrorsSuite) TestInvalidBatchSize(c *gc.C) {
+	s.deployService(c)
+	err := runUpgradeCharm(c, "riak", "--batch-size=-1")
+	c.Assert(err, gc.ErrorMatches, "invalid batch size -1")
+	err = runUpgradeCharm(c, "riak", "--wait-healthy")
+	c.Assert(err, gc.ErrorMatches, "--wait-healthy requires --batch-size")
+}
+
 type UpgradeCharmSuccessSuite struct {
 	jujutesting.RepoSuite
 	path string
@@ -178,6 +186,25 @@ func (s *UpgradeCharmSuccessSuite) TestForcedUpgrade(c *gc.C) {
 	s.assertLocalRevision(c, 7, s.path)
 }
 
+func (s *UpgradeCharmSuccessSuite) TestRollingUpgrade(c *gc.C) {
+	units, err := s.riak.AllUnits()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.HasLen, 1)
+	oldURL, _ := s.riak.CharmURL()
+	err = units[0].SetCharmURL(oldURL)
+	c.Assert(err, gc.IsNil)
+
+	err = runUpgradeCharm(c, "riak", "--batch-size=1", "--wait-healthy")
+	c.Assert(err, gc.IsNil)
+	s.assertUpgraded(c, 8, false)
+	rollout, ok := s.riak.Rollout()
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(rollout.FromCharmURL, gc.DeepEquals, oldURL)
+	c.Assert(rollout.BatchSize, gc.Equals, 1)
+	c.Assert(rollout.WaitHealthy, gc.Equals, true)
+	c.Assert(rollout.Pending, gc.DeepEquals, []string{units[0].Name()})
+}
+
 var myriakMeta = []byte(`
 name: myriak
 summary: "K/V storage engine"
@@ -217,3 +244,37 @@ func (s *UpgradeCharmSuccessSuite) TestSwitch(c *gc.C) {
 	c.Assert(curl.String(), gc.Equals, "local:precise/myriak-42")
 	s.assertLocalRevision(c, 42, myriakPath)
 }
+
+var riakDryRunMeta = []byte(`
+name: riak
+summary: "K/V storage engine"
+description: "Scalable K/V Store in Erlang with Clocks :-)"
+provides:
+  endpoint:
+    interface: http
+peers:
+  ring:
+    interface: riak
+  gossip:
+    interface: riak-gossip
+`)
+
+func (s *UpgradeCharmSuccessSuite) TestDryRun(c *gc.C) {
+	err := ioutil.WriteFile(path.Join(s.path, "metadata.yaml"), riakDryRunMeta, 0644)
+	c.Assert(err, gc.IsNil)
+	err = os.Mkdir(path.Join(s.path, "hooks"), 0755)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(path.Join(s.path, "hooks", "gossip-relation-joined"), []byte("#!/bin/sh\n"), 0755)
+	c.Assert(err, gc.IsNil)
+
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&UpgradeCharmCommand{}), "riak", "--dry-run")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, `upgrading service "riak" from local:precise/riak-7 to local:precise/riak-8 would:
+  add peer relation "gossip" (interface riak-gossip)
+  remove provider relation "admin" (interface http)
+  add hooks gossip-relation-joined
+  create peer relation "gossip"
+`)
+	// The service still runs the old charm.
+	s.assertUpgraded(c, 7, false)
+}
@@ -0,0 +1,100 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/environs/configstore"
+	"github.com/juju/core/juju"
+	"github.com/juju/core/utils"
+)
+
+const userChangePasswordCommandDoc = `
+Change the password of a user in an existing environment.
+
+If no user name is given, the password of the user in the environment
+file (.jenv) is changed, and the environment file is updated to use
+the new password. Only the admin user may change the passwords of
+other users.
+
+Examples:
+  juju user change-password                     (Change your password. A strong password will be generated and printed)
+  juju user change-password --password=mypass   (Change your password to "mypass")
+  juju user change-password foobar              (Change the password of user "foobar")
+`
+
+type UserChangePasswordCommand struct {
+	envcmd.EnvCommandBase
+	User     string
+	Password string
+}
+
+func (c *UserChangePasswordCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "change-password",
+		Args:    "[<username>]",
+		Purpose: "changes the password of a user",
+		Doc:     userChangePasswordCommandDoc,
+	}
+}
+
+func (c *UserChangePasswordCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&(c.Password), "password", "", "New password for the user")
+}
+
+func (c *UserChangePasswordCommand) Init(args []string) error {
+	if len(args) > 0 {
+		c.User = args[0]
+		args = args[1:]
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *UserChangePasswordCommand) Run(ctx *cmd.Context) error {
+	store, err := configstore.Default()
+	if err != nil {
+		return err
+	}
+	info, err := store.ReadInfo(c.EnvName)
+	if err != nil {
+		return err
+	}
+	creds := info.APICredentials()
+	user := c.User
+	if user == "" {
+		user = creds.User
+	}
+
+	client, err := juju.NewUserManagerClient(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if c.Password == "" {
+		c.Password, err = utils.RandomPassword()
+		if err != nil {
+			return fmt.Errorf("Failed to generate password: %v", err)
+		}
+	}
+	if err := client.SetPassword(user, c.Password); err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.Stdout, "password of user \"%s\" changed to \"%s\"\n", user, c.Password)
+
+	if user != creds.User {
+		return nil
+	}
+	// The environment file must now use the new password.
+	creds.Password = c.Password
+	info.SetAPICredentials(creds)
+	if err := info.Write(); err != nil {
+		return fmt.Errorf("cannot update environment file: %v", err)
+	}
+	return nil
+}
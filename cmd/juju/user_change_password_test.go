@@ -0,0 +1,66 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	jujutesting "github.com/juju/core/juju/testing"
+	"github.com/juju/core/testing"
+)
+
+// All of the functionality of the SetPassword api call is contained
+// elsewhere. This suite provides basic tests for the
+// "user change-password" command.
+type UserChangePasswordCommandSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&UserChangePasswordCommandSuite{})
+
+func newUserChangePasswordCommand() cmd.Command {
+	return envcmd.Wrap(&UserChangePasswordCommand{})
+}
+
+func (s *UserChangePasswordCommandSuite) TestChangeOtherUserPassword(c *gc.C) {
+	_, err := s.State.AddUser("foobar", "password")
+	c.Assert(err, gc.IsNil)
+
+	ctx, err := testing.RunCommand(c, newUserChangePasswordCommand(), "foobar", "--password", "frogdog")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, `password of user "foobar" changed to "frogdog"`+"\n")
+	user, err := s.State.User("foobar")
+	c.Assert(err, gc.IsNil)
+	c.Assert(user.PasswordValid("frogdog"), gc.Equals, true)
+
+	// The environment file still holds the admin password.
+	info, err := s.ConfigStore.ReadInfo("dummyenv")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.APICredentials().Password, gc.Not(gc.Equals), "frogdog")
+}
+
+func (s *UserChangePasswordCommandSuite) TestChangeOwnPassword(c *gc.C) {
+	_, err := testing.RunCommand(c, newUserChangePasswordCommand())
+	c.Assert(err, gc.IsNil)
+
+	info, err := s.ConfigStore.ReadInfo("dummyenv")
+	c.Assert(err, gc.IsNil)
+	creds := info.APICredentials()
+	c.Assert(creds.Password, gc.Matches, "..........+")
+	user, err := s.State.User(creds.User)
+	c.Assert(err, gc.IsNil)
+	c.Assert(user.PasswordValid(creds.Password), gc.Equals, true)
+}
+
+func (s *UserChangePasswordCommandSuite) TestUnknownUser(c *gc.C) {
+	_, err := testing.RunCommand(c, newUserChangePasswordCommand(), "unknown", "--password", "frogdog")
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *UserChangePasswordCommandSuite) TestTooManyArgs(c *gc.C) {
+	_, err := testing.RunCommand(c, newUserChangePasswordCommand(), "foobar", "whoops")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["whoops"\]`)
+}
@@ -19,6 +19,7 @@ var _ = gc.Suite(&UserCommandSuite{})
 
 var expectedUserCommmandNames = []string{
 	"add",
+	"change-password",
 	"help",
 }
 
@@ -0,0 +1,46 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+)
+
+type WebhooksCommand struct {
+	*cmd.SuperCommand
+}
+
+const webhooksCommandDoc = `
+"juju webhooks" is used to manage the URLs that changes in the Juju
+environment are posted to as they happen.
+
+Each change is posted as a JSON-encoded list of the changed entities,
+in the same form as they are reported by the API's WatchAll call. The
+request carries an X-Juju-Signature header holding "sha256=" followed
+by the hex-encoded HMAC-SHA256 of the request body, keyed with the
+webhook's secret. Deliveries are retried, and those that still fail
+are counted in the output of "juju webhooks list" and shown by
+"juju webhooks failures".
+`
+
+const webhooksCommandPurpose = "manage webhook subscriptions to environment changes"
+
+func NewWebhooksCommand() cmd.Command {
+	webhookscmd := &WebhooksCommand{
+		SuperCommand: cmd.NewSuperCommand(cmd.SuperCommandParams{
+			Name:        "webhooks",
+			Doc:         webhooksCommandDoc,
+			UsagePrefix: "juju",
+			Purpose:     webhooksCommandPurpose,
+		}),
+	}
+	// Define each subcommand in a separate "webhooks_FOO.go" source
+	// file (with tests in webhooks_FOO_test.go) and wire in here.
+	webhookscmd.Register(envcmd.Wrap(&WebhooksAddCommand{}))
+	webhookscmd.Register(envcmd.Wrap(&WebhooksFailuresCommand{}))
+	webhookscmd.Register(envcmd.Wrap(&WebhooksListCommand{}))
+	webhookscmd.Register(envcmd.Wrap(&WebhooksRemoveCommand{}))
+	return webhookscmd
+}
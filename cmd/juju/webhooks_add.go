@@ -0,0 +1,88 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/juju"
+	"github.com/juju/core/state/api/params"
+)
+
+const webhooksAddCommandDoc = `
+Subscribe a URL to changes in the environment. The URL must be an
+absolute http or https URL.
+
+By default, changes to all entities are posted. Use --kind to post only
+changes to entities of the given kinds (machine, service, unit, relation
+or annotation), and --entity to post only changes to entities with the
+given ids, such as "0" for a machine or "wordpress/0" for a unit.
+
+The secret used to sign the posted changes is generated unless given
+with --secret, and is printed when the webhook is added.
+
+Examples:
+  juju webhooks add https://example.com/hook
+  juju webhooks add --kind unit,service https://example.com/hook
+  juju webhooks add --entity wordpress/0 --secret s3cret https://example.com/hook
+`
+
+// WebhooksAddCommand subscribes a URL to changes in the environment.
+type WebhooksAddCommand struct {
+	envcmd.EnvCommandBase
+	URL         string
+	Secret      string
+	EntityKinds []string
+	EntityIds   []string
+}
+
+func (c *WebhooksAddCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "add",
+		Args:    "<url>",
+		Purpose: "subscribe a URL to environment changes",
+		Doc:     webhooksAddCommandDoc,
+	}
+}
+
+func (c *WebhooksAddCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.Secret, "secret", "", "the secret used to sign the posted changes")
+	f.Var(cmd.NewStringsValue(nil, &c.EntityKinds), "kind", "post only changes to entities of these kinds")
+	f.Var(cmd.NewStringsValue(nil, &c.EntityIds), "entity", "post only changes to entities with these ids")
+}
+
+func (c *WebhooksAddCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no URL specified")
+	}
+	c.URL = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *WebhooksAddCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	w, err := client.WebhookAdd(params.WebhookAdd{
+		URL:         c.URL,
+		Secret:      c.Secret,
+		EntityKinds: c.EntityKinds,
+		EntityIds:   c.EntityIds,
+	})
+	if params.IsCodeNotImplemented(err) {
+		return fmt.Errorf("webhooks are not supported by the API server")
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.Stdout, "webhook %s added with secret %q\n", w.Id, w.Secret)
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/juju"
+	"github.com/juju/core/state/api/params"
+)
+
+const webhooksFailuresCommandDoc = `
+Show the most recent deliveries to a webhook, given its id as shown by
+"juju webhooks list", that failed even after retrying, oldest first.
+Each holds the changes that were to be posted, so that they can be
+posted again by hand.
+`
+
+// WebhooksFailuresCommand shows the failed deliveries to a webhook.
+type WebhooksFailuresCommand struct {
+	envcmd.EnvCommandBase
+	out cmd.Output
+	Id  string
+}
+
+func (c *WebhooksFailuresCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "failures",
+		Args:    "<id>",
+		Purpose: "show failed deliveries to a webhook",
+		Doc:     webhooksFailuresCommandDoc,
+	}
+}
+
+func (c *WebhooksFailuresCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+func (c *WebhooksFailuresCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no webhook id specified")
+	}
+	c.Id, args = args[0], args[1:]
+	return cmd.CheckEmpty(args)
+}
+
+// webhookFailureInfo holds the details of a failed
+// delivery written by the failures command.
+type webhookFailureInfo struct {
+	Time     string `yaml:"time" json:"time"`
+	URL      string `yaml:"url" json:"url"`
+	Attempts int    `yaml:"attempts" json:"attempts"`
+	Error    string `yaml:"error" json:"error"`
+	Payload  string `yaml:"payload" json:"payload"`
+}
+
+func (c *WebhooksFailuresCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	failures, err := client.WebhookFailures(c.Id)
+	if params.IsCodeNotImplemented(err) {
+		return fmt.Errorf("listing failed webhook deliveries is not supported by the API server")
+	}
+	if err != nil {
+		return err
+	}
+	result := make([]webhookFailureInfo, len(failures))
+	for i, f := range failures {
+		result[i] = webhookFailureInfo{
+			Time:     f.Time.UTC().Format(time.RFC3339),
+			URL:      f.URL,
+			Attempts: f.Attempts,
+			Error:    f.Error,
+			Payload:  f.Payload,
+		}
+	}
+	return c.out.Write(ctx, result)
+}
@@ -0,0 +1,78 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/juju"
+	"github.com/juju/core/state/api/params"
+)
+
+const webhooksListCommandDoc = `
+List the webhook subscriptions in the environment, along with the
+number of deliveries to each that failed even after retrying.
+`
+
+// WebhooksListCommand lists the webhook subscriptions.
+type WebhooksListCommand struct {
+	envcmd.EnvCommandBase
+	out cmd.Output
+}
+
+func (c *WebhooksListCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "list",
+		Purpose: "list webhook subscriptions",
+		Doc:     webhooksListCommandDoc,
+	}
+}
+
+func (c *WebhooksListCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+func (c *WebhooksListCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+// webhookInfo holds the details of a webhook written by the list command.
+type webhookInfo struct {
+	URL         string   `yaml:"url" json:"url"`
+	EntityKinds []string `yaml:"kinds,omitempty" json:"kinds,omitempty"`
+	EntityIds   []string `yaml:"entities,omitempty" json:"entities,omitempty"`
+	Failures    int      `yaml:"failed-deliveries" json:"failed-deliveries"`
+}
+
+func (c *WebhooksListCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	webhooks, err := client.Webhooks()
+	if params.IsCodeNotImplemented(err) {
+		return fmt.Errorf("webhooks are not supported by the API server")
+	}
+	if err != nil {
+		return err
+	}
+	result := make(map[string]webhookInfo)
+	for _, w := range webhooks {
+		result[w.Id] = webhookInfo{
+			URL:         w.URL,
+			EntityKinds: w.EntityKinds,
+			EntityIds:   w.EntityIds,
+			Failures:    w.Failures,
+		}
+	}
+	return c.out.Write(ctx, result)
+}
@@ -0,0 +1,61 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/cmd/envcmd"
+	"github.com/juju/core/juju"
+	"github.com/juju/core/state/api/params"
+)
+
+const webhooksRemoveCommandDoc = `
+Remove webhook subscriptions, given their ids as shown by
+"juju webhooks list". Any failed deliveries recorded for
+them are forgotten.
+`
+
+// WebhooksRemoveCommand removes webhook subscriptions.
+type WebhooksRemoveCommand struct {
+	envcmd.EnvCommandBase
+	Ids []string
+}
+
+func (c *WebhooksRemoveCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "remove",
+		Args:    "<id> [...]",
+		Purpose: "remove webhook subscriptions",
+		Doc:     webhooksRemoveCommandDoc,
+	}
+}
+
+func (c *WebhooksRemoveCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no webhook id specified")
+	}
+	c.Ids = args
+	return nil
+}
+
+func (c *WebhooksRemoveCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	for _, id := range c.Ids {
+		err := client.WebhookRemove(id)
+		if params.IsCodeNotImplemented(err) {
+			return fmt.Errorf("webhooks are not supported by the API server")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"strings"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/cmd/envcmd"
+	jujutesting "github.com/juju/core/juju/testing"
+	"github.com/juju/core/state"
+	coretesting "github.com/juju/core/testing"
+)
+
+type WebhooksCommandSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&WebhooksCommandSuite{})
+
+func (s *WebhooksCommandSuite) TestHelp(c *gc.C) {
+	ctx, err := coretesting.RunCommand(c, NewWebhooksCommand(), "--help")
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Matches,
+		"(?s)usage: webhooks <command> .+"+webhooksCommandPurpose+".+")
+
+	var namesFound []string
+	commandHelp := strings.SplitAfter(coretesting.Stdout(ctx), "commands:")[1]
+	commandHelp = strings.TrimSpace(commandHelp)
+	for _, line := range strings.Split(commandHelp, "\n") {
+		namesFound = append(namesFound, strings.TrimSpace(strings.Split(line, " - ")[0]))
+	}
+	c.Assert(namesFound, gc.DeepEquals, []string{"add", "failures", "help", "list", "remove"})
+}
+
+func (s *WebhooksCommandSuite) TestAdd(c *gc.C) {
+	ctx, err := coretesting.RunCommand(c, envcmd.Wrap(&WebhooksAddCommand{}),
+		"--kind", "unit,service", "--entity", "wordpress/0", "--secret", "s3cret", "https://example.com/hook")
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Equals, `webhook 0 added with secret "s3cret"`+"\n")
+
+	w, err := s.State.Webhook("0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(w.URL(), gc.Equals, "https://example.com/hook")
+	c.Assert(w.EntityKinds(), gc.DeepEquals, []string{"unit", "service"})
+	c.Assert(w.EntityIds(), gc.DeepEquals, []string{"wordpress/0"})
+}
+
+func (s *WebhooksCommandSuite) TestAddInit(c *gc.C) {
+	_, err := coretesting.RunCommand(c, envcmd.Wrap(&WebhooksAddCommand{}))
+	c.Assert(err, gc.ErrorMatches, "no URL specified")
+	_, err = coretesting.RunCommand(c, envcmd.Wrap(&WebhooksAddCommand{}), "http://a", "http://b")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["http://b"\]`)
+}
+
+func (s *WebhooksCommandSuite) TestListAndRemove(c *gc.C) {
+	w, err := s.State.AddWebhook(state.WebhookInfo{
+		URL:         "http://example.com/hook",
+		Secret:      "s3cret",
+		EntityKinds: []string{"machine"},
+	})
+	c.Assert(err, gc.IsNil)
+	ctx, err := coretesting.RunCommand(c, envcmd.Wrap(&WebhooksListCommand{}))
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Equals, `"`+w.Id()+`":
+  url: http://example.com/hook
+  kinds:
+  - machine
+  failed-deliveries: 0
+`)
+
+	_, err = coretesting.RunCommand(c, envcmd.Wrap(&WebhooksRemoveCommand{}), w.Id())
+	c.Assert(err, gc.IsNil)
+	ctx, err = coretesting.RunCommand(c, envcmd.Wrap(&WebhooksListCommand{}))
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Equals, "{}\n")
+
+	_, err = coretesting.RunCommand(c, envcmd.Wrap(&WebhooksRemoveCommand{}), w.Id())
+	c.Assert(err, gc.ErrorMatches, "webhook .* not found")
+}
+
+func (s *WebhooksCommandSuite) TestFailures(c *gc.C) {
+	w, err := s.State.AddWebhook(state.WebhookInfo{URL: "http://example.com/hook", Secret: "s3cret"})
+	c.Assert(err, gc.IsNil)
+	err = s.State.AddWebhookFailure(state.WebhookFailure{
+		WebhookId: w.Id(),
+		URL:       w.URL(),
+		Payload:   "[]",
+		Attempts:  3,
+		Error:     "connection refused",
+		Time:      time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC),
+	})
+	c.Assert(err, gc.IsNil)
+	ctx, err := coretesting.RunCommand(c, envcmd.Wrap(&WebhooksFailuresCommand{}), "--format", "json", w.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Equals, `[{"time":"2014-05-01T12:00:00Z","url":"http://example.com/hook",`+
+		`"attempts":3,"error":"connection refused","payload":"[]"}]`+"\n")
+
+	_, err = coretesting.RunCommand(c, envcmd.Wrap(&WebhooksFailuresCommand{}))
+	c.Assert(err, gc.ErrorMatches, "no webhook id specified")
+}
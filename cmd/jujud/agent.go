@@ -92,6 +92,13 @@ func (a *AgentConf) SetAPIHostPorts(servers [][]instance.HostPort) error {
 	})
 }
 
+// SetCACert satisfies worker/certupdater/CACertSetter.
+func (a *AgentConf) SetCACert(caCert string) error {
+	return a.ChangeConfig(func(c agent.ConfigSetter) {
+		c.SetCACert(caCert)
+	})
+}
+
 func importance(err error) int {
 	switch {
 	case err == nil:
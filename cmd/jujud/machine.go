@@ -21,6 +21,7 @@ import (
 	"github.com/juju/core/agent/mongo"
 	"github.com/juju/core/charm"
 	"github.com/juju/core/cmd"
+	"github.com/juju/core/container"
 	"github.com/juju/core/container/kvm"
 	"github.com/juju/core/environs"
 	"github.com/juju/core/instance"
@@ -39,8 +40,12 @@ import (
 	"github.com/juju/core/worker"
 	"github.com/juju/core/worker/apiaddressupdater"
 	"github.com/juju/core/worker/authenticationworker"
+	"github.com/juju/core/worker/certupdater"
+	"github.com/juju/core/worker/charmmirror"
 	"github.com/juju/core/worker/charmrevisionworker"
+	"github.com/juju/core/worker/charmrollout"
 	"github.com/juju/core/worker/cleaner"
+	"github.com/juju/core/worker/containertemplates"
 	"github.com/juju/core/worker/deployer"
 	"github.com/juju/core/worker/firewaller"
 	"github.com/juju/core/worker/instancepoller"
@@ -49,6 +54,7 @@ import (
 	"github.com/juju/core/worker/machineenvironmentworker"
 	"github.com/juju/core/worker/machiner"
 	"github.com/juju/core/worker/minunitsworker"
+	"github.com/juju/core/worker/passwordrotator"
 	"github.com/juju/core/worker/peergrouper"
 	"github.com/juju/core/worker/provisioner"
 	"github.com/juju/core/worker/resumer"
@@ -56,6 +62,7 @@ import (
 	"github.com/juju/core/worker/singular"
 	"github.com/juju/core/worker/terminationworker"
 	"github.com/juju/core/worker/upgrader"
+	"github.com/juju/core/worker/webhooks"
 )
 
 var logger = loggo.GetLogger("juju.cmd.jujud")
@@ -154,6 +161,7 @@ func (a *MachineAgent) Run(_ *cmd.Context) error {
 	}
 	a.configChangedVal.Set(struct{}{})
 	agentConfig := a.CurrentConfig()
+	instance.SetPreferIPv6(agentConfig.Value(agent.PreferIPv6) == "true")
 	charm.CacheDir = filepath.Join(agentConfig.DataDir(), "charmcache")
 	if err := a.createJujuRun(agentConfig.DataDir()); err != nil {
 		return fmt.Errorf("cannot create juju run symlink: %v", err)
@@ -180,6 +188,13 @@ func (a *MachineAgent) ChangeConfig(mutate func(config agent.ConfigSetter)) erro
 	return err
 }
 
+// SetStateServingInfo satisfies worker/certupdater/StateServingInfoSetter.
+func (a *MachineAgent) SetStateServingInfo(info params.StateServingInfo) error {
+	return a.ChangeConfig(func(config agent.ConfigSetter) {
+		config.SetStateServingInfo(info)
+	})
+}
+
 // newStateStarterWorker wraps stateStarter in a simple worker for use in
 // a.runner.StartWorker.
 func (a *MachineAgent) newStateStarterWorker() (worker.Worker, error) {
@@ -190,7 +205,8 @@ func (a *MachineAgent) newStateStarterWorker() (worker.Worker, error) {
 // starts or stops the state worker as appropriate. We watch the agent
 // configuration because the agent configuration has all the details
 // that we need to start a state server, whether they have been cached
-// or read from the state.
+// or read from the state. When the state server certificate changes,
+// the state worker is restarted so that it serves the new certificate.
 //
 // It will stop working as soon as stopch is closed.
 func (a *MachineAgent) stateStarter(stopch <-chan struct{}) error {
@@ -202,18 +218,25 @@ func (a *MachineAgent) stateStarter(stopch <-chan struct{}) error {
 			watchCh <- struct{}{}
 		}
 	}()
+	var servingCert string
 	for {
 		select {
 		case <-watchCh:
 			agentConfig := a.CurrentConfig()
 
 			// N.B. StartWorker and StopWorker are idempotent.
-			_, ok := agentConfig.StateServingInfo()
+			info, ok := agentConfig.StateServingInfo()
 			if ok {
+				if servingCert != "" && servingCert != info.Cert {
+					logger.Infof("state server certificate changed; restarting state worker")
+					a.runner.StopWorker("state")
+				}
+				servingCert = info.Cert
 				a.runner.StartWorker("state", func() (worker.Worker, error) {
 					return a.StateWorker()
 				})
 			} else {
+				servingCert = ""
 				a.runner.StopWorker("state")
 			}
 		case <-stopch:
@@ -235,8 +258,10 @@ func (a *MachineAgent) APIWorker() (worker.Worker, error) {
 	// Refresh the configuration, since it may have been updated after opening state.
 	agentConfig = a.CurrentConfig()
 
+	needsState := false
 	for _, job := range entity.Jobs() {
 		if job.NeedsState() {
+			needsState = true
 			info, err := st.Agent().StateServingInfo()
 			if err != nil {
 				return nil, fmt.Errorf("cannot get state serving info: %v", err)
@@ -284,6 +309,15 @@ func (a *MachineAgent) APIWorker() (worker.Worker, error) {
 	a.startWorkerAfterUpgrade(runner, "apiaddressupdater", func() (worker.Worker, error) {
 		return apiaddressupdater.NewAPIAddressUpdater(st.Machiner(), a), nil
 	})
+	a.startWorkerAfterUpgrade(runner, "certupdater", func() (worker.Worker, error) {
+		if needsState {
+			return certupdater.NewStateServerCertificateUpdater(st.Machiner(), st.Agent(), a, entity), nil
+		}
+		return certupdater.NewCertificateUpdater(st.Machiner(), a, entity), nil
+	})
+	a.startWorkerAfterUpgrade(runner, "passwordrotator", func() (worker.Worker, error) {
+		return passwordrotator.NewPasswordRotator(entity, a), nil
+	})
 	a.startWorkerAfterUpgrade(runner, "logger", func() (worker.Worker, error) {
 		return workerlogger.NewLogger(st.Logger(), agentConfig), nil
 	})
@@ -354,6 +388,22 @@ func (a *MachineAgent) setupContainerSupport(runner worker.Runner, st *api.State
 	if err == nil && supportsKvm {
 		supportedContainers = append(supportedContainers, instance.KVM)
 	}
+	// Docker is installed on demand, but it can't be nested inside LXC
+	// or docker containers.
+	if ctype := entity.ContainerType(); ctype != instance.LXC && ctype != instance.DOCKER {
+		supportedContainers = append(supportedContainers, instance.DOCKER)
+	}
+	var templateManagers []container.TemplateManager
+	for _, ctype := range supportedContainers {
+		if manager, err := newTemplateManager(ctype); err == nil {
+			templateManagers = append(templateManagers, manager)
+		}
+	}
+	if len(templateManagers) > 0 {
+		a.startWorkerAfterUpgrade(runner, "containertemplates", func() (worker.Worker, error) {
+			return containertemplates.NewWorker(entity, templateManagers), nil
+		})
+	}
 	return a.updateSupportedContainers(runner, st, entity.Tag(), supportedContainers, agentConfig)
 }
 
@@ -476,6 +526,9 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 				return apiserver.NewServer(
 					st, fmt.Sprintf(":%d", port), cert, key, dataDir, logDir)
 			})
+			a.startWorkerAfterUpgrade(runner, "charmmirror", func() (worker.Worker, error) {
+				return charmmirror.NewWorker(st), nil
+			})
 			a.startWorkerAfterUpgrade(singularRunner, "cleaner", func() (worker.Worker, error) {
 				return cleaner.NewCleaner(st), nil
 			})
@@ -488,6 +541,12 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 			a.startWorkerAfterUpgrade(singularRunner, "minunitsworker", func() (worker.Worker, error) {
 				return minunitsworker.NewMinUnitsWorker(st), nil
 			})
+			a.startWorkerAfterUpgrade(singularRunner, "charmrollout", func() (worker.Worker, error) {
+				return charmrollout.NewWorker(st), nil
+			})
+			a.startWorkerAfterUpgrade(singularRunner, "webhooks", func() (worker.Worker, error) {
+				return webhooks.NewWorker(st), nil
+			})
 		case state.JobManageStateDeprecated:
 			// Legacy environments may set this, but we ignore it.
 		default:
@@ -395,11 +395,13 @@ func (s *MachineSuite) TestManageEnviron(c *gc.C) {
 
 	c.Assert(s.singularRecord.started(), jc.DeepEquals, []string{
 		"charm-revision-updater",
+		"charmrollout",
 		"cleaner",
 		"environ-provisioner",
 		"firewaller",
 		"minunitsworker",
 		"resumer",
+		"webhooks",
 	})
 }
 
@@ -103,6 +103,7 @@ func jujuDMain(args []string, ctx *cmd.Context) (code int, err error) {
 	jujud.Register(&BootstrapCommand{})
 	jujud.Register(&MachineAgent{})
 	jujud.Register(&UnitAgent{})
+	jujud.Register(&ContainerTemplatesCommand{})
 	jujud.Register(&cmd.VersionCommand{})
 	code = cmd.Main(jujud, ctx, args[1:])
 	return code, nil
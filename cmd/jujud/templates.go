@@ -0,0 +1,171 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/container"
+	"github.com/juju/core/container/factory"
+	"github.com/juju/core/instance"
+)
+
+// templateContainerTypes holds the container types that keep a cache of
+// templates on the host.
+var templateContainerTypes = []instance.ContainerType{instance.LXC, instance.KVM}
+
+// newTemplateManager is overridden in tests.
+var newTemplateManager = factory.NewTemplateManager
+
+// ContainerTemplatesCommand lists and purges the container templates
+// cached on this machine.
+type ContainerTemplatesCommand struct {
+	cmd.CommandBase
+	out           cmd.Output
+	containerType string
+	purge         bool
+	names         []string
+}
+
+const containerTemplatesDoc = `
+List the templates that containers on this machine are cloned from.
+Templates are built for a series, architecture and tools version, and
+are replaced automatically when the tools are upgraded.
+
+With --purge, the named templates, or all templates if none are named,
+are destroyed to reclaim their disk space. Templates that containers are
+still using are not purged.
+
+The machine agent reports the templates to the state servers, so they
+can also be listed and purged remotely with "juju container-templates".
+
+Examples:
+  jujud container-templates
+  jujud container-templates --type kvm --purge
+  jujud container-templates --purge juju-trusty-amd64-1.19.4-template
+`
+
+func (c *ContainerTemplatesCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "container-templates",
+		Args:    "[<template name> ...]",
+		Purpose: "list or purge cached container templates",
+		Doc:     containerTemplatesDoc,
+	}
+}
+
+func (c *ContainerTemplatesCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters)
+	f.StringVar(&c.containerType, "type", "", "only consider templates for this container type")
+	f.BoolVar(&c.purge, "purge", false, "destroy the templates")
+}
+
+func (c *ContainerTemplatesCommand) Init(args []string) error {
+	if c.containerType != "" {
+		ctype, err := instance.ParseContainerType(c.containerType)
+		if err != nil {
+			return err
+		}
+		if _, err := newTemplateManager(ctype); err != nil {
+			return err
+		}
+	}
+	if len(args) > 0 && !c.purge {
+		return fmt.Errorf("template names can only be specified with --purge")
+	}
+	c.names = args
+	return nil
+}
+
+// templateInfo holds the details of a template for output.
+type templateInfo struct {
+	Name    string `yaml:"name" json:"name"`
+	Type    string `yaml:"type" json:"type"`
+	Series  string `yaml:"series" json:"series"`
+	Arch    string `yaml:"arch,omitempty" json:"arch,omitempty"`
+	Tools   string `yaml:"tools,omitempty" json:"tools,omitempty"`
+	Created string `yaml:"created" json:"created"`
+}
+
+func (c *ContainerTemplatesCommand) Run(ctx *cmd.Context) error {
+	types := templateContainerTypes
+	if c.containerType != "" {
+		types = []instance.ContainerType{instance.ContainerType(c.containerType)}
+	}
+	var result []templateInfo
+	for _, ctype := range types {
+		manager, err := newTemplateManager(ctype)
+		if err != nil {
+			return err
+		}
+		templates, err := manager.ListTemplates()
+		if err != nil {
+			return err
+		}
+		if c.purge {
+			names := c.namesFor(templates)
+			if len(names) == 0 {
+				continue
+			}
+			if err := manager.PurgeTemplates(names...); err != nil {
+				return err
+			}
+			for _, name := range names {
+				fmt.Fprintf(ctx.Stderr, "purged %s template %s\n", ctype, name)
+			}
+			continue
+		}
+		for _, info := range templates {
+			result = append(result, newTemplateInfo(info))
+		}
+	}
+	if c.purge {
+		return nil
+	}
+	return c.out.Write(ctx, result)
+}
+
+// namesFor returns the names of the templates in the list that should be
+// purged. If no names were given on the command line, that is all of the
+// templates.
+func (c *ContainerTemplatesCommand) namesFor(templates []container.TemplateInfo) []string {
+	var names []string
+	for _, info := range templates {
+		names = append(names, info.Name)
+	}
+	if len(c.names) == 0 {
+		return names
+	}
+	// Templates of other types may be named, so only purge those the
+	// command line asked for.
+	wanted := make(map[string]bool)
+	for _, name := range c.names {
+		wanted[name] = true
+	}
+	var selected []string
+	for _, name := range names {
+		if wanted[name] {
+			selected = append(selected, name)
+		}
+	}
+	return selected
+}
+
+func newTemplateInfo(info container.TemplateInfo) templateInfo {
+	result := templateInfo{
+		Name:    info.Name,
+		Type:    string(info.Type),
+		Series:  info.Series,
+		Arch:    info.Arch,
+		Created: info.Created.UTC().Format(time.RFC3339),
+	}
+	if !info.IsLegacy() {
+		result.Tools = info.Tools.String()
+	}
+	return result
+}
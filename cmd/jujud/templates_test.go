@@ -0,0 +1,104 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/container"
+	"github.com/juju/core/instance"
+	"github.com/juju/core/testing"
+	"github.com/juju/core/version"
+)
+
+type ContainerTemplatesSuite struct {
+	testing.BaseSuite
+	managers map[instance.ContainerType]*fakeTemplateManager
+}
+
+var _ = gc.Suite(&ContainerTemplatesSuite{})
+
+type fakeTemplateManager struct {
+	templates []container.TemplateInfo
+	purged    []string
+}
+
+func (m *fakeTemplateManager) ListTemplates() ([]container.TemplateInfo, error) {
+	return m.templates, nil
+}
+
+func (m *fakeTemplateManager) PurgeTemplates(names ...string) error {
+	m.purged = append(m.purged, names...)
+	return nil
+}
+
+func (s *ContainerTemplatesSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	created := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.managers = map[instance.ContainerType]*fakeTemplateManager{
+		instance.LXC: {templates: []container.TemplateInfo{{
+			Name:    "juju-trusty-amd64-1.19.4-template",
+			Type:    instance.LXC,
+			Series:  "trusty",
+			Arch:    "amd64",
+			Tools:   version.MustParse("1.19.4"),
+			Created: created,
+		}}},
+		instance.KVM: {templates: []container.TemplateInfo{{
+			Name:    "juju-precise-amd64-1.19.4-template",
+			Type:    instance.KVM,
+			Series:  "precise",
+			Arch:    "amd64",
+			Tools:   version.MustParse("1.19.4"),
+			Created: created,
+		}}},
+	}
+	s.PatchValue(&newTemplateManager, func(ctype instance.ContainerType) (container.TemplateManager, error) {
+		if manager, ok := s.managers[ctype]; ok {
+			return manager, nil
+		}
+		return nil, fmt.Errorf("container type %q does not use templates", ctype)
+	})
+}
+
+func (s *ContainerTemplatesSuite) TestInit(c *gc.C) {
+	err := testing.InitCommand(&ContainerTemplatesCommand{}, []string{"--type", "docker"})
+	c.Assert(err, gc.ErrorMatches, `container type "docker" does not use templates`)
+	err = testing.InitCommand(&ContainerTemplatesCommand{}, []string{"--type", "foo"})
+	c.Assert(err, gc.ErrorMatches, `invalid container type "foo"`)
+	err = testing.InitCommand(&ContainerTemplatesCommand{}, []string{"juju-foo-template"})
+	c.Assert(err, gc.ErrorMatches, "template names can only be specified with --purge")
+}
+
+func (s *ContainerTemplatesSuite) TestList(c *gc.C) {
+	ctx, err := testing.RunCommand(c, &ContainerTemplatesCommand{}, "--format", "json")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, `[`+
+		`{"name":"juju-trusty-amd64-1.19.4-template","type":"lxc","series":"trusty","arch":"amd64","tools":"1.19.4","created":"2014-05-01T12:00:00Z"},`+
+		`{"name":"juju-precise-amd64-1.19.4-template","type":"kvm","series":"precise","arch":"amd64","tools":"1.19.4","created":"2014-05-01T12:00:00Z"}`+
+		"]\n")
+}
+
+func (s *ContainerTemplatesSuite) TestListType(c *gc.C) {
+	ctx, err := testing.RunCommand(c, &ContainerTemplatesCommand{}, "--type", "kvm", "--format", "json")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Matches, `\[\{"name":"juju-precise-amd64-1.19.4-template",.*\}\]\n`)
+}
+
+func (s *ContainerTemplatesSuite) TestPurgeAll(c *gc.C) {
+	_, err := testing.RunCommand(c, &ContainerTemplatesCommand{}, "--purge")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.managers[instance.LXC].purged, gc.DeepEquals, []string{"juju-trusty-amd64-1.19.4-template"})
+	c.Assert(s.managers[instance.KVM].purged, gc.DeepEquals, []string{"juju-precise-amd64-1.19.4-template"})
+}
+
+func (s *ContainerTemplatesSuite) TestPurgeNamed(c *gc.C) {
+	_, err := testing.RunCommand(c, &ContainerTemplatesCommand{}, "--purge", "juju-precise-amd64-1.19.4-template")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.managers[instance.LXC].purged, gc.HasLen, 0)
+	c.Assert(s.managers[instance.KVM].purged, gc.DeepEquals, []string{"juju-precise-amd64-1.19.4-template"})
+}
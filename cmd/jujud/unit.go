@@ -16,7 +16,9 @@ import (
 	"github.com/juju/core/version"
 	"github.com/juju/core/worker"
 	"github.com/juju/core/worker/apiaddressupdater"
+	"github.com/juju/core/worker/certupdater"
 	workerlogger "github.com/juju/core/worker/logger"
+	"github.com/juju/core/worker/passwordrotator"
 	"github.com/juju/core/worker/rsyslog"
 	"github.com/juju/core/worker/uniter"
 	"github.com/juju/core/worker/upgrader"
@@ -104,6 +106,12 @@ func (a *UnitAgent) APIWorkers() (worker.Worker, error) {
 	runner.StartWorker("apiaddressupdater", func() (worker.Worker, error) {
 		return apiaddressupdater.NewAPIAddressUpdater(st.Uniter(), a), nil
 	})
+	runner.StartWorker("certupdater", func() (worker.Worker, error) {
+		return certupdater.NewCertificateUpdater(st.Uniter(), a, entity), nil
+	})
+	runner.StartWorker("passwordrotator", func() (worker.Worker, error) {
+		return passwordrotator.NewPasswordRotator(entity, a), nil
+	})
 	runner.StartWorker("rsyslog", func() (worker.Worker, error) {
 		return newRsyslogConfigWorker(st.Rsyslog(), agentConfig, rsyslog.RsyslogModeForwarding)
 	})
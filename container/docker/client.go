@@ -0,0 +1,153 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package docker
+
+// This file contains wrappers around the docker executable, found in the
+// docker.io package.
+//
+// The docker client provides Juju's interface to dealing with docker
+// containers. It defines how we build images, start, stop and list running
+// containers on the host.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/core/utils"
+	"github.com/juju/core/version/ubuntu"
+)
+
+// dockerCommand is the name of the docker client executable. On Ubuntu the
+// docker.io package installs the client as docker.io to avoid a clash with
+// the unrelated docker package.
+const dockerCommand = "docker.io"
+
+// ImagePrefix is prepended to the series name to give the name of the
+// image that juju builds for containers of that series.
+const ImagePrefix = "juju/"
+
+// cloudInitSeedDir is the directory within the container where the NoCloud
+// datasource for cloud-init looks for user-data.
+const cloudInitSeedDir = "/var/lib/cloud/seed/nocloud-net"
+
+// imageTemplate is the Dockerfile used to build the image for a series. It
+// starts from the series cloud image published by Ubuntu, adds cloud-init
+// and arranges for init to run so that the juju cloud-init bootstrap is
+// executed when the container starts.
+const imageTemplate = `FROM ubuntu:%s
+RUN apt-get update && apt-get install -y cloud-init
+RUN mkdir -p %s && touch %s/meta-data
+CMD ["/sbin/init"]
+`
+
+// run the command and return the combined output.
+func run(command string, args ...string) (output string, err error) {
+	logger.Tracef("%s %v", command, args)
+	output, err = utils.RunCommand(command, args...)
+	logger.Tracef("output: %v", output)
+	return output, err
+}
+
+// ImageName returns the name of the juju image for the given series.
+func ImageName(series string) string {
+	return ImagePrefix + series
+}
+
+// EnsureImage builds the juju image for the specified series unless it
+// already exists in the local docker image cache.
+func EnsureImage(series string) error {
+	image := ImageName(series)
+	if _, err := run(dockerCommand, "inspect", image); err == nil {
+		logger.Debugf("image %s already exists", image)
+		return nil
+	}
+	release, err := ubuntu.SeriesVersion(series)
+	if err != nil {
+		return fmt.Errorf("cannot build image for series %q: %v", series, err)
+	}
+	dir, err := ioutil.TempDir("", "juju-docker-image")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	dockerfile := fmt.Sprintf(imageTemplate, release, cloudInitSeedDir, cloudInitSeedDir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644); err != nil {
+		return err
+	}
+	logger.Infof("building docker image %s from ubuntu:%s", image, release)
+	_, err = run(dockerCommand, "build", "-t", image, dir)
+	return err
+}
+
+type RunContainerParams struct {
+	Name         string
+	Series       string
+	UserDataFile string
+	Memory       uint64
+	CpuShares    uint64
+}
+
+// RunContainer creates a container from the series image and starts it.
+// The user data file is bind mounted into the cloud-init seed directory
+// so that the juju bootstrap runs when the container boots.
+func RunContainer(params RunContainerParams) error {
+	if params.Name == "" {
+		return fmt.Errorf("Name is required")
+	}
+	args := []string{
+		"run",
+		"--detach",
+		"--name", params.Name,
+		"--hostname", params.Name,
+		"--net", "bridge",
+	}
+	if params.UserDataFile != "" {
+		args = append(args, "--volume",
+			fmt.Sprintf("%s:%s/user-data:ro", params.UserDataFile, cloudInitSeedDir))
+	}
+	if params.Memory != 0 {
+		args = append(args, "--memory", fmt.Sprintf("%dm", params.Memory))
+	}
+	if params.CpuShares != 0 {
+		args = append(args, "--cpu-shares", fmt.Sprint(params.CpuShares))
+	}
+	args = append(args, ImageName(params.Series))
+	_, err := run(dockerCommand, args...)
+	return err
+}
+
+// RemoveContainer forcibly stops and removes the named container.
+func RemoveContainer(name string) error {
+	_, err := run(dockerCommand, "rm", "--force", name)
+	return err
+}
+
+// ListContainers returns a map of container name to whether or not that
+// container is running.
+func ListContainers() (map[string]bool, error) {
+	output, err := run(dockerCommand, "ps", "--all", "--no-trunc")
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]bool)
+	lines := strings.Split(output, "\n")
+	if len(lines) < 2 {
+		return result, nil
+	}
+	// The first line is the header. The container names are the last
+	// column, and the status column starts with "Up" for running
+	// containers.
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		name := fields[len(fields)-1]
+		result[name] = strings.Contains(line, " Up ")
+	}
+	return result, nil
+}
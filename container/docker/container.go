@@ -0,0 +1,71 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package docker
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/core/container"
+)
+
+type dockerContainer struct {
+	factory *containerFactory
+	name    string
+	// started is a three state boolean, true, false, or unknown
+	// this allows for checking when we don't know, but using a
+	// value if we already know it (like in the list situation).
+	started *bool
+}
+
+var _ Container = (*dockerContainer)(nil)
+
+func (c *dockerContainer) Name() string {
+	return c.name
+}
+
+func (c *dockerContainer) Start(params StartParams) error {
+	// Docker attaches containers to the bridge the daemon was configured
+	// with by the initialiser, so only bridged networking is possible.
+	if params.Network != nil && params.Network.NetworkType != container.BridgeNetwork {
+		return errors.LoggedErrorf(logger, "Non-bridge network devices not yet supported")
+	}
+	logger.Debugf("Ensure image for %s", params.Series)
+	if err := EnsureImage(params.Series); err != nil {
+		return err
+	}
+	logger.Debugf("Run the container %s", c.name)
+	return RunContainer(RunContainerParams{
+		Name:         c.name,
+		Series:       params.Series,
+		UserDataFile: params.UserDataFile,
+		Memory:       params.Memory,
+		CpuShares:    params.CpuShares,
+	})
+}
+
+func (c *dockerContainer) Stop() error {
+	// Make started state unknown again.
+	c.started = nil
+	logger.Debugf("Remove %s", c.name)
+	return RemoveContainer(c.name)
+}
+
+func (c *dockerContainer) IsRunning() bool {
+	if c.started != nil {
+		return *c.started
+	}
+	containers, err := ListContainers()
+	if err != nil {
+		return false
+	}
+	running := containers[c.name]
+	c.started = &running
+	return running
+}
+
+func (c *dockerContainer) String() string {
+	return fmt.Sprintf("<Docker container %v>", *c)
+}
@@ -0,0 +1,32 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package docker
+
+type containerFactory struct {
+}
+
+var _ ContainerFactory = (*containerFactory)(nil)
+
+func (factory *containerFactory) New(name string) Container {
+	return &dockerContainer{
+		factory: factory,
+		name:    name,
+	}
+}
+
+func (factory *containerFactory) List() (result []Container, err error) {
+	containers, err := ListContainers()
+	if err != nil {
+		return nil, err
+	}
+	for name, running := range containers {
+		running := running
+		result = append(result, &dockerContainer{
+			factory: factory,
+			name:    name,
+			started: &running,
+		})
+	}
+	return result, nil
+}
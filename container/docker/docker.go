@@ -0,0 +1,175 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package docker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+
+	"github.com/juju/core/constraints"
+	"github.com/juju/core/container"
+	"github.com/juju/core/environs/cloudinit"
+	"github.com/juju/core/instance"
+	"github.com/juju/core/names"
+	"github.com/juju/core/version"
+)
+
+var (
+	logger = loggo.GetLogger("juju.container.docker")
+
+	DockerObjectFactory ContainerFactory = &containerFactory{}
+
+	// DefaultDockerBridge is the bridge created by the docker.io package.
+	DefaultDockerBridge = "docker0"
+
+	// CpuSharesPerCore is the number of docker cpu shares given to a
+	// container for each core requested in its constraints. Docker gives
+	// a container 1024 shares by default. Shares are a relative weight,
+	// not a limit on the CPU time the container may use.
+	CpuSharesPerCore uint64 = 1024
+)
+
+// NewContainerManager returns a manager object that can start and stop docker
+// containers. The containers that are created are namespaced by the name
+// parameter.
+func NewContainerManager(conf container.ManagerConfig) (container.Manager, error) {
+	name := conf.PopValue(container.ConfigName)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	// Docker containers get their logs from the host via cloud-init, so
+	// the log dir is accepted but not used.
+	conf.PopValue(container.ConfigLogDir)
+	conf.WarnAboutUnused()
+	return &containerManager{name: name}, nil
+}
+
+// containerManager handles all of the business logic at the juju specific
+// level. It makes sure that the necessary directories are in place, that the
+// user-data is written out in the right place.
+type containerManager struct {
+	name string
+}
+
+var _ container.Manager = (*containerManager)(nil)
+
+func (manager *containerManager) CreateContainer(
+	machineConfig *cloudinit.MachineConfig,
+	series string,
+	network *container.NetworkConfig) (instance.Instance, *instance.HardwareCharacteristics, error) {
+
+	name := names.MachineTag(machineConfig.MachineId)
+	if manager.name != "" {
+		name = fmt.Sprintf("%s-%s", manager.name, name)
+	}
+	// Note here that the DockerObjectFactory only returns a valid container
+	// object, and doesn't actually create the underlying docker container.
+	dockerContainer := DockerObjectFactory.New(name)
+
+	// Create the cloud-init.
+	directory, err := container.NewDirectory(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create container directory: %v", err)
+	}
+	logger.Tracef("write cloud-init")
+	userDataFilename, err := container.WriteUserData(machineConfig, directory)
+	if err != nil {
+		return nil, nil, errors.LoggedErrorf(logger, "failed to write user data: %v", err)
+	}
+	// Create the container.
+	startParams := ParseConstraintsToStartParams(machineConfig.Constraints)
+	startParams.Series = series
+	startParams.Network = network
+	startParams.UserDataFile = userDataFilename
+
+	hardware := hardwareCharacteristics(startParams)
+
+	logger.Tracef("create the container, constraints: %v", machineConfig.Constraints)
+	if err := dockerContainer.Start(startParams); err != nil {
+		return nil, nil, errors.LoggedErrorf(logger, "docker container creation failed: %v", err)
+	}
+	logger.Tracef("docker container created")
+	return &dockerInstance{dockerContainer, name}, hardware, nil
+}
+
+func (manager *containerManager) DestroyContainer(id instance.Id) error {
+	name := string(id)
+	dockerContainer := DockerObjectFactory.New(name)
+	if err := dockerContainer.Stop(); err != nil {
+		logger.Errorf("failed to stop docker container: %v", err)
+		return err
+	}
+	return container.RemoveDirectory(name)
+}
+
+func (manager *containerManager) ListContainers() (result []instance.Instance, err error) {
+	containers, err := DockerObjectFactory.List()
+	if err != nil {
+		logger.Errorf("failed getting all instances: %v", err)
+		return
+	}
+	managerPrefix := fmt.Sprintf("%s-", manager.name)
+	for _, container := range containers {
+		// Filter out those not starting with our name.
+		name := container.Name()
+		if !strings.HasPrefix(name, managerPrefix) {
+			continue
+		}
+		if container.IsRunning() {
+			result = append(result, &dockerInstance{container, name})
+		}
+	}
+	return
+}
+
+// hardwareCharacteristics returns the hardware characteristics of a
+// container started with the given parameters. Docker containers share
+// the host kernel, so the architecture is always that of the host.
+// CPU shares only weight the container against others under
+// contention rather than limiting it, so no cores are reported.
+func hardwareCharacteristics(params StartParams) *instance.HardwareCharacteristics {
+	arch := version.Current.Arch
+	hardware := &instance.HardwareCharacteristics{
+		Arch: &arch,
+	}
+	if params.Memory != 0 {
+		mem := params.Memory
+		hardware.Mem = &mem
+	}
+	return hardware
+}
+
+// ParseConstraintsToStartParams takes a constraints object and returns a
+// bare StartParams object that has Memory and CpuShares populated. Values
+// that are not specified in the constraints are left as zero, which means
+// the container is not limited. Other constraints cause an informational
+// message to be logged.
+func ParseConstraintsToStartParams(cons constraints.Value) StartParams {
+	var params StartParams
+	if cons.Mem != nil {
+		params.Memory = *cons.Mem
+	}
+	if cons.CpuCores != nil && *cons.CpuCores > 0 {
+		params.CpuShares = *cons.CpuCores * CpuSharesPerCore
+	}
+	if cons.Arch != nil {
+		logger.Infof("arch constraint of %q being ignored as not supported", *cons.Arch)
+	}
+	if cons.Container != nil {
+		logger.Infof("container constraint of %q being ignored as not supported", *cons.Container)
+	}
+	if cons.CpuPower != nil {
+		logger.Infof("cpu-power constraint of %v being ignored as not supported", *cons.CpuPower)
+	}
+	if cons.RootDisk != nil {
+		logger.Infof("root-disk constraint of %v being ignored as not supported", *cons.RootDisk)
+	}
+	if cons.Tags != nil {
+		logger.Infof("tags constraint of %q being ignored as not supported", strings.Join(*cons.Tags, ","))
+	}
+	return params
+}
@@ -0,0 +1,164 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package docker_test
+
+import (
+	"path/filepath"
+
+	"github.com/juju/loggo"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/constraints"
+	"github.com/juju/core/container"
+	"github.com/juju/core/container/docker"
+	dockertesting "github.com/juju/core/container/docker/testing"
+	containertesting "github.com/juju/core/container/testing"
+	"github.com/juju/core/instance"
+	coretesting "github.com/juju/core/testing"
+)
+
+type DockerSuite struct {
+	dockertesting.TestSuite
+	manager container.Manager
+}
+
+var _ = gc.Suite(&DockerSuite{})
+
+func (s *DockerSuite) SetUpTest(c *gc.C) {
+	s.TestSuite.SetUpTest(c)
+	var err error
+	s.manager, err = docker.NewContainerManager(container.ManagerConfig{container.ConfigName: "test"})
+	c.Assert(err, gc.IsNil)
+}
+
+func (*DockerSuite) TestManagerNameNeeded(c *gc.C) {
+	manager, err := docker.NewContainerManager(container.ManagerConfig{container.ConfigName: ""})
+	c.Assert(err, gc.ErrorMatches, "name is required")
+	c.Assert(manager, gc.IsNil)
+}
+
+func (*DockerSuite) TestManagerWarnsAboutUnknownOption(c *gc.C) {
+	_, err := docker.NewContainerManager(container.ManagerConfig{
+		container.ConfigName: "BillyBatson",
+		"shazam":             "Captain Marvel",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(c.GetTestLog(), jc.Contains, `WARNING juju.container unused config option: "shazam" -> "Captain Marvel"`)
+}
+
+func (s *DockerSuite) TestListInitiallyEmpty(c *gc.C) {
+	containers, err := s.manager.ListContainers()
+	c.Assert(err, gc.IsNil)
+	c.Assert(containers, gc.HasLen, 0)
+}
+
+func (s *DockerSuite) createRunningContainer(c *gc.C, name string) docker.Container {
+	dockerContainer := s.Factory.New(name)
+	network := container.BridgeNetworkConfig("testbr0")
+	c.Assert(dockerContainer.Start(docker.StartParams{
+		Series:       "quantal",
+		UserDataFile: "userdata.txt",
+		Network:      network}), gc.IsNil)
+	return dockerContainer
+}
+
+func (s *DockerSuite) TestListMatchesManagerName(c *gc.C) {
+	s.createRunningContainer(c, "test-match1")
+	s.createRunningContainer(c, "test-match2")
+	s.createRunningContainer(c, "testNoMatch")
+	s.createRunningContainer(c, "other")
+	containers, err := s.manager.ListContainers()
+	c.Assert(err, gc.IsNil)
+	c.Assert(containers, gc.HasLen, 2)
+	expectedIds := []instance.Id{"test-match1", "test-match2"}
+	ids := []instance.Id{containers[0].Id(), containers[1].Id()}
+	c.Assert(ids, jc.SameContents, expectedIds)
+}
+
+func (s *DockerSuite) TestListMatchesRunningContainers(c *gc.C) {
+	running := s.createRunningContainer(c, "test-running")
+	s.Factory.New("test-stopped")
+	containers, err := s.manager.ListContainers()
+	c.Assert(err, gc.IsNil)
+	c.Assert(containers, gc.HasLen, 1)
+	c.Assert(string(containers[0].Id()), gc.Equals, running.Name())
+}
+
+func (s *DockerSuite) TestCreateContainer(c *gc.C) {
+	instance := containertesting.CreateContainer(c, s.manager, "1/docker/0")
+	name := string(instance.Id())
+	c.Assert(name, gc.Equals, "test-machine-1-docker-0")
+	cloudInitFilename := filepath.Join(s.ContainerDir, name, "cloud-init")
+	containertesting.AssertCloudInit(c, cloudInitFilename)
+}
+
+func (s *DockerSuite) TestDestroyContainer(c *gc.C) {
+	instance := containertesting.CreateContainer(c, s.manager, "1/docker/0")
+
+	err := s.manager.DestroyContainer(instance.Id())
+	c.Assert(err, gc.IsNil)
+
+	name := string(instance.Id())
+	// Check that the container dir is no longer in the container dir
+	c.Assert(filepath.Join(s.ContainerDir, name), jc.DoesNotExist)
+	// but instead, in the removed container dir
+	c.Assert(filepath.Join(s.RemovedDir, name), jc.IsDirectory)
+}
+
+type ConstraintsSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&ConstraintsSuite{})
+
+func (s *ConstraintsSuite) TestParseConstraints(c *gc.C) {
+	for i, test := range []struct {
+		cons     string
+		expected docker.StartParams
+		infoLog  []string
+	}{{
+		expected: docker.StartParams{},
+	}, {
+		cons: "mem=4G",
+		expected: docker.StartParams{
+			Memory: 4 * 1024,
+		},
+	}, {
+		cons: "cpu-cores=2",
+		expected: docker.StartParams{
+			CpuShares: 2 * docker.CpuSharesPerCore,
+		},
+	}, {
+		cons:     "cpu-cores=0",
+		expected: docker.StartParams{},
+	}, {
+		cons:     "root-disk=20G",
+		expected: docker.StartParams{},
+		infoLog: []string{
+			`root-disk constraint of 20480 being ignored as not supported`,
+		},
+	}, {
+		cons: "mem=4G cpu-cores=4 arch=armhf cpu-power=100 container=lxc tags=foo,bar",
+		expected: docker.StartParams{
+			Memory:    4 * 1024,
+			CpuShares: 4 * docker.CpuSharesPerCore,
+		},
+		infoLog: []string{
+			`arch constraint of "armhf" being ignored as not supported`,
+			`container constraint of "lxc" being ignored as not supported`,
+			`cpu-power constraint of 100 being ignored as not supported`,
+			`tags constraint of "foo,bar" being ignored as not supported`,
+		},
+	}} {
+		c.Logf("test %d: %s", i, test.cons)
+		tw := &loggo.TestWriter{}
+		c.Assert(loggo.RegisterWriter("constraint-tester", tw, loggo.DEBUG), gc.IsNil)
+		cons := constraints.MustParse(test.cons)
+		params := docker.ParseConstraintsToStartParams(cons)
+		c.Check(params, gc.DeepEquals, test.expected)
+		c.Check(tw.Log, jc.LogMatches, test.infoLog)
+		loggo.RemoveWriter("constraint-tester")
+	}
+}
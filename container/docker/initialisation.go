@@ -0,0 +1,68 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package docker
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/juju/core/container"
+	"github.com/juju/core/upstart"
+	"github.com/juju/core/utils/apt"
+)
+
+var requiredPackages = []string{
+	"docker.io",
+}
+
+// DockerDefaultsFile is the file read by the docker.io upstart job to
+// get the options the docker daemon is started with.
+var DockerDefaultsFile = "/etc/default/docker.io"
+
+// dockerServiceName is the name of the upstart job for the docker daemon.
+const dockerServiceName = "docker.io"
+
+type containerInitialiser struct {
+	bridge string
+}
+
+// containerInitialiser implements container.Initialiser.
+var _ container.Initialiser = (*containerInitialiser)(nil)
+
+// NewContainerInitialiser returns an instance used to perform the steps
+// required to allow a host machine to run a docker container. If bridge
+// is specified, and isn't the default docker bridge, the docker daemon is
+// configured to attach containers to it.
+func NewContainerInitialiser(bridge string) container.Initialiser {
+	return &containerInitialiser{bridge}
+}
+
+// Initialise is specified on the container.Initialiser interface.
+func (ci *containerInitialiser) Initialise() error {
+	if err := ensureDependencies(); err != nil {
+		return err
+	}
+	if ci.bridge == "" || ci.bridge == DefaultDockerBridge {
+		return nil
+	}
+	return configureBridge(ci.bridge)
+}
+
+func ensureDependencies() error {
+	return apt.GetInstall(requiredPackages...)
+}
+
+// configureBridge sets the bridge the docker daemon attaches containers to
+// and restarts the daemon so the change takes effect.
+func configureBridge(bridge string) error {
+	options := fmt.Sprintf("DOCKER_OPTS=\"--bridge=%s\"\n", bridge)
+	if err := ioutil.WriteFile(DockerDefaultsFile, []byte(options), 0644); err != nil {
+		return fmt.Errorf("cannot write docker defaults: %v", err)
+	}
+	service := upstart.NewService(dockerServiceName)
+	if err := service.Stop(); err != nil {
+		return err
+	}
+	return service.Start()
+}
@@ -0,0 +1,70 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package docker
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/core/instance"
+)
+
+type dockerInstance struct {
+	container Container
+	id        string
+}
+
+var _ instance.Instance = (*dockerInstance)(nil)
+
+// Id implements instance.Instance.Id.
+func (docker *dockerInstance) Id() instance.Id {
+	return instance.Id(docker.id)
+}
+
+// Status implements instance.Instance.Status.
+func (docker *dockerInstance) Status() string {
+	if docker.container.IsRunning() {
+		return "running"
+	}
+	return "stopped"
+}
+
+func (*dockerInstance) Refresh() error {
+	return nil
+}
+
+func (docker *dockerInstance) Addresses() ([]instance.Address, error) {
+	return nil, errors.NotImplementedf("dockerInstance.Addresses")
+}
+
+// DNSName implements instance.Instance.DNSName.
+func (docker *dockerInstance) DNSName() (string, error) {
+	return "", instance.ErrNoDNSName
+}
+
+// WaitDNSName implements instance.Instance.WaitDNSName.
+func (docker *dockerInstance) WaitDNSName() (string, error) {
+	return "", instance.ErrNoDNSName
+}
+
+// OpenPorts implements instance.Instance.OpenPorts.
+func (docker *dockerInstance) OpenPorts(machineId string, ports []instance.Port) error {
+	return fmt.Errorf("not implemented")
+}
+
+// ClosePorts implements instance.Instance.ClosePorts.
+func (docker *dockerInstance) ClosePorts(machineId string, ports []instance.Port) error {
+	return fmt.Errorf("not implemented")
+}
+
+// Ports implements instance.Instance.Ports.
+func (docker *dockerInstance) Ports(machineId string) ([]instance.Port, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+// Add a string representation of the id.
+func (docker *dockerInstance) String() string {
+	return fmt.Sprintf("docker:%s", docker.id)
+}
@@ -0,0 +1,49 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package docker
+
+import (
+	"github.com/juju/core/container"
+)
+
+// StartParams is a simple parameter struct for Container.Start.
+type StartParams struct {
+	Series       string
+	UserDataFile string
+	Network      *container.NetworkConfig
+	Memory       uint64 // MB
+	CpuShares    uint64
+}
+
+// Container represents a docker container instance and provides
+// operations to create, maintain and destroy the container.
+type Container interface {
+
+	// Name returns the name of the container.
+	Name() string
+
+	// Start runs the container as a daemon.
+	Start(params StartParams) error
+
+	// Stop terminates the running container and removes it.
+	Stop() error
+
+	// IsRunning returns whether or not the container is running and active.
+	IsRunning() bool
+
+	// String returns information about the container, like the name, state,
+	// and process id.
+	String() string
+}
+
+// ContainerFactory represents the methods used to create Containers. This
+// wraps the low level docker client calls for dealing with the containers.
+type ContainerFactory interface {
+	// New returns a container instance which can then be used for operations
+	// like Start() and Stop()
+	New(string) Container
+
+	// List returns all the existing containers on the system.
+	List() ([]Container, error)
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	"github.com/juju/core/container"
+	"github.com/juju/core/container/docker"
 	"github.com/juju/core/container/kvm"
 	"github.com/juju/core/container/lxc"
 	"github.com/juju/core/instance"
@@ -22,6 +23,20 @@ func NewContainerManager(forType instance.ContainerType, conf container.ManagerC
 		return lxc.NewContainerManager(conf)
 	case instance.KVM:
 		return kvm.NewContainerManager(conf)
+	case instance.DOCKER:
+		return docker.NewContainerManager(conf)
 	}
 	return nil, fmt.Errorf("unknown container type: %q", forType)
 }
+
+// NewTemplateManager returns the container.TemplateManager for the cached
+// templates of the specified container type.
+func NewTemplateManager(forType instance.ContainerType) (container.TemplateManager, error) {
+	switch forType {
+	case instance.LXC:
+		return lxc.NewTemplateManager(), nil
+	case instance.KVM:
+		return kvm.NewTemplateManager(), nil
+	}
+	return nil, fmt.Errorf("container type %q does not use templates", forType)
+}
@@ -28,6 +28,9 @@ func (*factorySuite) TestNewContainerManager(c *gc.C) {
 	}, {
 		containerType: instance.KVM,
 		valid:         true,
+	}, {
+		containerType: instance.DOCKER,
+		valid:         true,
 	}, {
 		containerType: instance.NONE,
 		valid:         false,
@@ -46,3 +49,14 @@ func (*factorySuite) TestNewContainerManager(c *gc.C) {
 		}
 	}
 }
+
+func (*factorySuite) TestNewTemplateManager(c *gc.C) {
+	for _, containerType := range []instance.ContainerType{instance.LXC, instance.KVM} {
+		manager, err := factory.NewTemplateManager(containerType)
+		c.Assert(err, gc.IsNil)
+		c.Assert(manager, gc.NotNil)
+	}
+	manager, err := factory.NewTemplateManager(instance.DOCKER)
+	c.Assert(err, gc.ErrorMatches, `container type "docker" does not use templates`)
+	c.Assert(manager, gc.IsNil)
+}
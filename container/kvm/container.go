@@ -41,14 +41,15 @@ func (c *kvmContainer) Start(params StartParams) error {
 	}
 	logger.Debugf("Create the machine %s", c.name)
 	if err := CreateMachine(CreateMachineParams{
-		Hostname:      c.name,
-		Series:        params.Series,
-		Arch:          params.Arch,
-		UserDataFile:  params.UserDataFile,
-		NetworkBridge: bridge,
-		Memory:        params.Memory,
-		CpuCores:      params.CpuCores,
-		RootDisk:      params.RootDisk,
+		Hostname:         c.name,
+		Series:           params.Series,
+		Arch:             params.Arch,
+		UserDataFile:     params.UserDataFile,
+		NetworkBridge:    bridge,
+		Memory:           params.Memory,
+		CpuCores:         params.CpuCores,
+		RootDisk:         params.RootDisk,
+		BackingImageFile: params.BackingImageFile,
 	}); err != nil {
 		return err
 	}
@@ -68,6 +69,17 @@ func (c *kvmContainer) Stop() error {
 	return DestroyMachine(c.name)
 }
 
+func (c *kvmContainer) Destroy() error {
+	// Make started state unknown again.
+	c.started = nil
+	logger.Debugf("Destroy %s", c.name)
+	return DestroyMachine(c.name)
+}
+
+func (c *kvmContainer) ImagePath() (string, error) {
+	return MachineImagePath(c.name)
+}
+
 func (c *kvmContainer) IsRunning() bool {
 	if c.started != nil {
 		return *c.started
@@ -16,6 +16,9 @@ type StartParams struct {
 	Memory       uint64 // MB
 	CpuCores     uint64
 	RootDisk     uint64 // GB
+	// BackingImageFile, if set, is the template image the container's
+	// disk is a copy-on-write clone of.
+	BackingImageFile string
 }
 
 // Container represents a virtualized container instance and provides
@@ -31,6 +34,12 @@ type Container interface {
 	// Stop terminates the running container.
 	Stop() error
 
+	// Destroy removes the container, whether it is running or not.
+	Destroy() error
+
+	// ImagePath returns the path of the container's root disk image.
+	ImagePath() (string, error)
+
 	// IsRunning returns wheter or not the container is running and active.
 	IsRunning() bool
 
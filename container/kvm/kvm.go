@@ -6,6 +6,7 @@ package kvm
 import (
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/juju/errors"
@@ -64,16 +65,20 @@ func NewContainerManager(conf container.ManagerConfig) (container.Manager, error
 	if logDir == "" {
 		logDir = agent.DefaultLogDir
 	}
+	// Explicitly ignore the error result from ParseBool. If it fails to
+	// parse, the value is false, and containers are created from scratch.
+	useClone, _ := strconv.ParseBool(conf.PopValue("use-clone"))
 	conf.WarnAboutUnused()
-	return &containerManager{name: name, logdir: logDir}, nil
+	return &containerManager{name: name, logdir: logDir, createWithClone: useClone}, nil
 }
 
 // containerManager handles all of the business logic at the juju specific
 // level. It makes sure that the necessary directories are in place, that the
 // user-data is written out in the right place.
 type containerManager struct {
-	name   string
-	logdir string
+	name            string
+	logdir          string
+	createWithClone bool
 }
 
 var _ container.Manager = (*containerManager)(nil)
@@ -97,6 +102,16 @@ func (manager *containerManager) CreateContainer(
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create container directory: %v", err)
 	}
+	var backingImageFile string
+	if manager.createWithClone {
+		key := container.NewTemplateKey(machineConfig, series)
+		backingImageFile, err = useTemplate(name, key, network, machineConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The template has already done the apt-get steps.
+		machineConfig.DisablePackageCommands = true
+	}
 	logger.Tracef("write cloud-init")
 	userDataFilename, err := container.WriteUserData(machineConfig, directory)
 	if err != nil {
@@ -108,6 +123,7 @@ func (manager *containerManager) CreateContainer(
 	startParams.Series = series
 	startParams.Network = network
 	startParams.UserDataFile = userDataFilename
+	startParams.BackingImageFile = backingImageFile
 
 	var hardware instance.HardwareCharacteristics
 	hardware, err = instance.ParseHardware(
@@ -183,7 +199,8 @@ func ParseConstraintsToStartParams(cons constraints.Value) StartParams {
 		}
 	}
 	if cons.RootDisk != nil {
-		size := *cons.RootDisk / 1024
+		// Round up, so the disk is never smaller than requested.
+		size := (*cons.RootDisk + 1023) / 1024
 		if size < MinDisk {
 			params.RootDisk = MinDisk
 		} else {
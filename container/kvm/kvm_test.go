@@ -5,6 +5,7 @@ package kvm_test
 
 import (
 	"path/filepath"
+	"time"
 
 	"github.com/juju/loggo"
 	jc "github.com/juju/testing/checkers"
@@ -108,6 +109,88 @@ func (s *KVMSuite) TestDestroyContainer(c *gc.C) {
 	c.Assert(filepath.Join(s.RemovedDir, name), jc.IsDirectory)
 }
 
+func (s *KVMSuite) stopTemplateWhenStarted(c *gc.C, name string) {
+	// The template machines are created with an upstart job that shuts
+	// them down once cloud-init has finished. We emulate that here.
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+			template := s.Factory.New(name)
+			if template.IsRunning() {
+				template.Stop()
+			}
+		}
+	}()
+	s.AddCleanup(func(*gc.C) { close(done) })
+}
+
+func (s *KVMSuite) TestCreateContainerWithClone(c *gc.C) {
+	s.PatchValue(kvm.TemplatePollInterval, 10*time.Millisecond)
+	template := "juju-series-bar-2.3.4-template"
+	s.stopTemplateWhenStarted(c, template)
+	manager, err := kvm.NewContainerManager(container.ManagerConfig{
+		container.ConfigName: "test",
+		"use-clone":          "true",
+	})
+	c.Assert(err, gc.IsNil)
+	inst := containertesting.CreateContainer(c, manager, "1/kvm/0")
+	c.Assert(inst.Status(), gc.Equals, "running")
+
+	templateManager := kvm.NewTemplateManager()
+	templates, err := templateManager.ListTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 1)
+	c.Assert(templates[0].Name, gc.Equals, template)
+	c.Assert(templates[0].Type, gc.Equals, instance.KVM)
+
+	// The template can't be purged while the container's disk is
+	// backed by it.
+	err = templateManager.PurgeTemplates()
+	c.Assert(err, gc.ErrorMatches, "cannot purge templates: "+template)
+
+	err = manager.DestroyContainer(inst.Id())
+	c.Assert(err, gc.IsNil)
+	err = templateManager.PurgeTemplates(template)
+	c.Assert(err, gc.IsNil)
+	templates, err = templateManager.ListTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 0)
+	c.Assert(filepath.Join(s.RemovedDir, template), jc.IsDirectory)
+}
+
+func (s *KVMSuite) TestCreateContainerWithCloneDestroysUnfinishedTemplate(c *gc.C) {
+	s.PatchValue(kvm.TemplatePollInterval, 10*time.Millisecond)
+	s.PatchValue(&kvm.TemplateStopTimeout, 50*time.Millisecond)
+	template := "juju-series-bar-2.3.4-template"
+	manager, err := kvm.NewContainerManager(container.ManagerConfig{
+		container.ConfigName: "test",
+		"use-clone":          "true",
+	})
+	c.Assert(err, gc.IsNil)
+	machineConfig := containertesting.MockMachineConfig("1/kvm/0")
+	network := container.BridgeNetworkConfig("virbr0")
+	_, _, err = manager.CreateContainer(machineConfig, "series", network)
+	c.Assert(err, gc.ErrorMatches, `template machine "`+template+`" did not stop`)
+
+	// The half-built template machine is destroyed, so the next
+	// attempt builds it afresh.
+	containers, err := s.Factory.List()
+	c.Assert(err, gc.IsNil)
+	for _, container := range containers {
+		c.Assert(container.Name(), gc.Not(gc.Equals), template)
+	}
+	c.Assert(filepath.Join(s.ContainerDir, template), jc.DoesNotExist)
+	c.Assert(filepath.Join(s.RemovedDir, template), jc.IsDirectory)
+	templates, err := kvm.NewTemplateManager().ListTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 0)
+}
+
 type ConstraintsSuite struct {
 	coretesting.BaseSuite
 }
@@ -161,6 +244,13 @@ func (s *ConstraintsSuite) TestDefaults(c *gc.C) {
 			CpuCores: kvm.DefaultCpu,
 			RootDisk: kvm.MinDisk,
 		},
+	}, {
+		cons: "root-disk=4500M",
+		expected: kvm.StartParams{
+			Memory:   kvm.DefaultMemory,
+			CpuCores: kvm.DefaultCpu,
+			RootDisk: 5,
+		},
 	}, {
 		cons: "root-disk=4G",
 		expected: kvm.StartParams{
@@ -22,6 +22,10 @@ import (
 	"github.com/juju/core/utils"
 )
 
+// uvtoolPool is the libvirt storage pool that uvt-kvm creates the
+// machine volumes in.
+const uvtoolPool = "uvtool"
+
 var (
 	// The regular expression for breaking up the results of 'virsh list'
 	// (?m) - specify that this is a multiline regex
@@ -51,14 +55,15 @@ func SyncImages(series string, arch string) error {
 }
 
 type CreateMachineParams struct {
-	Hostname      string
-	Series        string
-	Arch          string
-	UserDataFile  string
-	NetworkBridge string
-	Memory        uint64
-	CpuCores      uint64
-	RootDisk      uint64
+	Hostname         string
+	Series           string
+	Arch             string
+	UserDataFile     string
+	NetworkBridge    string
+	Memory           uint64
+	CpuCores         uint64
+	RootDisk         uint64
+	BackingImageFile string
 }
 
 // CreateMachine creates a virtual machine and starts it.
@@ -85,6 +90,11 @@ func CreateMachine(params CreateMachineParams) error {
 	if params.RootDisk != 0 {
 		args = append(args, "--disk", fmt.Sprint(params.RootDisk))
 	}
+	if params.BackingImageFile != "" {
+		// The new machine's disk is a qcow2 overlay on the backing
+		// image, so only the blocks it changes are copied.
+		args = append(args, "--backing-image-file", params.BackingImageFile)
+	}
 	// TODO add memory, cpu and disk prior to hostname
 	args = append(args, params.Hostname)
 	if params.Series != "" {
@@ -104,6 +114,16 @@ func DestroyMachine(hostname string) error {
 	return err
 }
 
+// MachineImagePath returns the path of the root disk image of the virtual
+// machine identified by hostname.
+func MachineImagePath(hostname string) (string, error) {
+	output, err := run("virsh", "vol-path", "--pool", uvtoolPool, hostname+".qcow")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
 // AutostartMachine indicates that the virtual machines should automatically
 // restart when the host restarts.
 func AutostartMachine(hostname string) error {
@@ -5,6 +5,7 @@ package mock
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/juju/core/container/kvm"
 )
@@ -19,6 +20,8 @@ const (
 	Started Action = iota
 	// A container has been stopped.
 	Stopped
+	// A container has been destroyed.
+	Destroyed
 )
 
 func (action Action) String() string {
@@ -27,6 +30,8 @@ func (action Action) String() string {
 		return "Started"
 	case Stopped:
 		return "Stopped"
+	case Destroyed:
+		return "Destroyed"
 	}
 	return "unknown"
 }
@@ -47,6 +52,9 @@ type ContainerFactory interface {
 type mockFactory struct {
 	instances map[string]kvm.Container
 	listeners []chan<- Event
+	// mutex guards the instances and the started state of the
+	// containers, so tests can stop containers from other goroutines.
+	mutex sync.Mutex
 }
 
 func MockFactory() ContainerFactory {
@@ -66,26 +74,49 @@ func (mock *mockContainer) Name() string {
 	return mock.name
 }
 
+func (mock *mockContainer) setStarted(started bool) (changed bool) {
+	mock.factory.mutex.Lock()
+	defer mock.factory.mutex.Unlock()
+	changed = mock.started != started
+	mock.started = started
+	return changed
+}
+
 func (mock *mockContainer) Start(params kvm.StartParams) error {
-	if mock.started {
+	if !mock.setStarted(true) {
 		return fmt.Errorf("container is already running")
 	}
-	mock.started = true
 	mock.factory.notify(Started, mock.name)
 	return nil
 }
 
 // Stop terminates the running container.
 func (mock *mockContainer) Stop() error {
-	if !mock.started {
+	if !mock.setStarted(false) {
 		return fmt.Errorf("container is not running")
 	}
-	mock.started = false
 	mock.factory.notify(Stopped, mock.name)
 	return nil
 }
 
+// Destroy removes the container, whether it is running or not.
+func (mock *mockContainer) Destroy() error {
+	mock.setStarted(false)
+	mock.factory.mutex.Lock()
+	delete(mock.factory.instances, mock.name)
+	mock.factory.mutex.Unlock()
+	mock.factory.notify(Destroyed, mock.name)
+	return nil
+}
+
+// ImagePath returns a fake path for the container's root disk image.
+func (mock *mockContainer) ImagePath() (string, error) {
+	return fmt.Sprintf("/var/lib/uvtool/libvirt/images/%s.qcow", mock.name), nil
+}
+
 func (mock *mockContainer) IsRunning() bool {
+	mock.factory.mutex.Lock()
+	defer mock.factory.mutex.Unlock()
 	return mock.started
 }
 
@@ -99,6 +130,8 @@ func (mock *mockFactory) String() string {
 }
 
 func (mock *mockFactory) New(name string) kvm.Container {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
 	container, ok := mock.instances[name]
 	if ok {
 		return container
@@ -112,6 +145,8 @@ func (mock *mockFactory) New(name string) kvm.Container {
 }
 
 func (mock *mockFactory) List() (result []kvm.Container, err error) {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
 	for _, container := range mock.instances {
 		result = append(result, container)
 	}
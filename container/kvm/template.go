@@ -0,0 +1,249 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package kvm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/juju/core/container"
+	"github.com/juju/core/environs/cloudinit"
+	"github.com/juju/core/instance"
+	"github.com/juju/core/utils/proxy"
+)
+
+var (
+	// TemplateStopTimeout is how long to wait for a template machine to
+	// finish its cloud-init and shut itself down.
+	TemplateStopTimeout = 30 * time.Minute
+
+	// templatePollInterval is how often the template machine is checked
+	// while waiting for it to shut down.
+	templatePollInterval = 5 * time.Second
+)
+
+// EnsureTemplate makes sure a template image exists for the key, and
+// returns the path to it. Containers are started with their disk as a
+// copy-on-write overlay of the template image. Templates for the same
+// series and architecture that were built with other tools are purged,
+// unless they are still in use.
+func EnsureTemplate(
+	key container.TemplateKey,
+	network *container.NetworkConfig,
+	authorizedKeys string,
+	aptProxy proxy.Settings,
+) (string, error) {
+	purgeStaleTemplates(key)
+	lock, err := container.AcquireTemplateLock(key.Name(), "ensure template exists")
+	if err != nil {
+		return "", err
+	}
+	defer lock.Unlock()
+	return ensureTemplate(key, network, authorizedKeys, aptProxy)
+}
+
+// useTemplate makes sure the template image for the key exists and
+// records that the disk of the named container is backed by it, and
+// returns the path to the image. The template lock is held throughout,
+// so the template can't be purged before its use is recorded.
+func useTemplate(
+	containerName string,
+	key container.TemplateKey,
+	network *container.NetworkConfig,
+	machineConfig *cloudinit.MachineConfig,
+) (string, error) {
+	purgeStaleTemplates(key)
+	lock, err := container.AcquireTemplateLock(key.Name(), "use template")
+	if err != nil {
+		return "", err
+	}
+	defer lock.Unlock()
+	imagePath, err := ensureTemplate(key, network, machineConfig.AuthorizedKeys, machineConfig.AptProxySettings)
+	if err != nil {
+		return "", err
+	}
+	if err := container.RecordTemplateUse(containerName, key.Name()); err != nil {
+		return "", err
+	}
+	return imagePath, nil
+}
+
+// ensureTemplate builds the template image for the key unless it has
+// been built before, and returns the path to it. The caller must hold
+// the template lock, so the template can't be purged until the caller
+// has recorded its use.
+func ensureTemplate(
+	key container.TemplateKey,
+	network *container.NetworkConfig,
+	authorizedKeys string,
+	aptProxy proxy.Settings,
+) (string, error) {
+	name := key.Name()
+	containerDirectory, err := container.NewDirectory(name)
+	if err != nil {
+		return "", err
+	}
+	// Early exit if the template has been built before.
+	templates, err := container.ListTemplates(instance.KVM)
+	if err != nil {
+		return "", err
+	}
+	template := KvmObjectFactory.New(name)
+	for _, info := range templates {
+		if info.Name == name {
+			logger.Infof("template exists, continuing")
+			return template.ImagePath()
+		}
+	}
+	logger.Infof("template does not exist, creating")
+
+	userData, err := container.TemplateUserData(key.Series, authorizedKeys, aptProxy)
+	if err != nil {
+		logger.Tracef("failed to create template user data for template: %v", err)
+		return "", err
+	}
+	userDataFilename, err := container.WriteCloudInitFile(containerDirectory, userData)
+	if err != nil {
+		return "", err
+	}
+	startParams := StartParams{
+		Series:       key.Series,
+		Arch:         key.Arch,
+		UserDataFile: userDataFilename,
+		Network:      network,
+		Memory:       DefaultMemory,
+		CpuCores:     DefaultCpu,
+		RootDisk:     DefaultDisk,
+	}
+	if err := template.Start(startParams); err != nil {
+		logger.Errorf("template machine failed to start: %v", err)
+		discardTemplate(name)
+		return "", err
+	}
+	logger.Infof("template machine started, now wait for it to stop")
+	if err := waitTemplateStopped(name); err != nil {
+		discardTemplate(name)
+		return "", err
+	}
+	if err := container.WriteTemplateInfo(container.TemplateInfo{
+		Name:    name,
+		Type:    instance.KVM,
+		Series:  key.Series,
+		Arch:    key.Arch,
+		Tools:   key.Tools,
+		Created: time.Now(),
+	}); err != nil {
+		discardTemplate(name)
+		return "", err
+	}
+	return template.ImagePath()
+}
+
+// discardTemplate destroys a template machine that could not be built,
+// along with its directory, so the next attempt builds it afresh rather
+// than leaving a half-built machine running. Failures are logged, as the
+// error that stopped the build is the one to report.
+func discardTemplate(name string) {
+	logger.Infof("destroying unfinished template machine %q", name)
+	if err := KvmObjectFactory.New(name).Destroy(); err != nil {
+		logger.Warningf("cannot destroy template machine %q: %v", name, err)
+	}
+	if err := container.RemoveDirectory(name); err != nil {
+		logger.Warningf("cannot remove directory of template %q: %v", name, err)
+	}
+}
+
+// waitTemplateStopped waits for the named template machine to shut down
+// once cloud-init has finished preparing it.
+func waitTemplateStopped(name string) error {
+	timeout := time.After(TemplateStopTimeout)
+	for {
+		// Get a new container each time, so the running state is not
+		// cached.
+		if !KvmObjectFactory.New(name).IsRunning() {
+			return nil
+		}
+		select {
+		case <-timeout:
+			return fmt.Errorf("template machine %q did not stop", name)
+		case <-time.After(templatePollInterval):
+		}
+	}
+}
+
+// purgeStaleTemplates destroys the templates superseded by the template
+// for key. Failures are logged, as they don't stop the new template being
+// built.
+func purgeStaleTemplates(key container.TemplateKey) {
+	stale, err := container.StaleTemplates(instance.KVM, key)
+	if err != nil {
+		logger.Warningf("cannot list stale templates: %v", err)
+		return
+	}
+	for _, info := range stale {
+		logger.Infof("purging template %q superseded by %s", info.Name, key)
+		if err := destroyTemplate(info.Name); err != nil {
+			logger.Warningf("cannot purge template %q: %v", info.Name, err)
+		}
+	}
+}
+
+// destroyTemplate destroys the named template machine, along with its
+// image, and removes it from the cache, unless there are containers whose
+// disks are backed by the image.
+func destroyTemplate(name string) error {
+	lock, err := container.AcquireTemplateLock(name, "destroy template")
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	users, err := container.TemplateUsers(name)
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		return fmt.Errorf("template in use by %s", strings.Join(users, ", "))
+	}
+	if err := KvmObjectFactory.New(name).Destroy(); err != nil {
+		return err
+	}
+	return container.RemoveDirectory(name)
+}
+
+type templateManager struct{}
+
+// NewTemplateManager returns a container.TemplateManager for the kvm
+// template images on this host.
+func NewTemplateManager() container.TemplateManager {
+	return templateManager{}
+}
+
+// ListTemplates is specified on the container.TemplateManager interface.
+func (templateManager) ListTemplates() ([]container.TemplateInfo, error) {
+	return container.ListTemplates(instance.KVM)
+}
+
+// PurgeTemplates is specified on the container.TemplateManager interface.
+func (templateManager) PurgeTemplates(names ...string) error {
+	templates, err := container.ListTemplates(instance.KVM)
+	if err != nil {
+		return err
+	}
+	selected, err := container.SelectTemplates(templates, names)
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, info := range selected {
+		if err := destroyTemplate(info.Name); err != nil {
+			logger.Errorf("cannot purge template %q: %v", info.Name, err)
+			failed = append(failed, info.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("cannot purge templates: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
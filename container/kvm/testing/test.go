@@ -31,6 +31,7 @@ func (s *TestSuite) SetUpTest(c *gc.C) {
 	s.PatchValue(&container.ContainerDir, s.ContainerDir)
 	s.RemovedDir = c.MkDir()
 	s.PatchValue(&container.RemovedContainerDir, s.RemovedDir)
+	s.PatchValue(&container.TemplateLockDir, c.MkDir())
 	s.Factory = mock.MockFactory()
 	s.PatchValue(&kvm.KvmObjectFactory, s.Factory)
 }
@@ -6,109 +6,58 @@ package lxc
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"launchpad.net/golxc"
 
-	coreCloudinit "github.com/juju/core/cloudinit"
 	"github.com/juju/core/container"
-	"github.com/juju/core/environs/cloudinit"
-	"github.com/juju/core/utils"
-	"github.com/juju/core/utils/fslock"
+	"github.com/juju/core/instance"
 	"github.com/juju/core/utils/proxy"
 	"github.com/juju/core/utils/tailer"
 )
 
-const (
-	templateShutdownUpstartFilename = "/etc/init/juju-template-restart.conf"
-	templateShutdownUpstartScript   = `
-description "Juju lxc template shutdown job"
-author "Juju Team <juju@lists.ubuntu.com>"
-start on stopped cloud-final
-
-script
-  shutdown -h now
-end script
-
-post-stop script
-  rm ` + templateShutdownUpstartFilename + `
-end script
-`
-)
-
-var (
-	TemplateLockDir = "/var/lib/juju/locks"
+var TemplateStopTimeout = 5 * time.Minute
 
-	TemplateStopTimeout = 5 * time.Minute
-)
-
-// templateUserData returns a minimal user data necessary for the template.
-// This should have the authorized keys, base packages, the cloud archive if
-// necessary,  initial apt proxy config, and it should do the apt-get
-// update/upgrade initially.
-func templateUserData(
-	series string,
+// EnsureCloneTemplate makes sure a template exists for the key that we can
+// clone from. Templates for the same series and architecture that were
+// built with other tools are purged, unless they are still in use.
+func EnsureCloneTemplate(
+	backingFilesystem string,
+	key container.TemplateKey,
+	network *container.NetworkConfig,
 	authorizedKeys string,
 	aptProxy proxy.Settings,
-) ([]byte, error) {
-	config := coreCloudinit.New()
-	config.AddScripts(
-		"set -xe", // ensure we run all the scripts or abort.
-	)
-	config.AddSSHAuthorizedKeys(authorizedKeys)
-	cloudinit.MaybeAddCloudArchiveCloudTools(config, series)
-	cloudinit.AddAptCommands(aptProxy, config)
-	config.AddScripts(
-		fmt.Sprintf(
-			"printf '%%s\n' %s > %s",
-			utils.ShQuote(templateShutdownUpstartScript),
-			templateShutdownUpstartFilename,
-		))
-	data, err := config.Render()
-	if err != nil {
-		return nil, err
-	}
-	return data, nil
-}
-
-func AcquireTemplateLock(name, message string) (*fslock.Lock, error) {
-	logger.Infof("wait for fslock on %v", name)
-	lock, err := fslock.NewLock(TemplateLockDir, name)
-	if err != nil {
-		logger.Tracef("failed to create fslock for template: %v", err)
-		return nil, err
-	}
-	err = lock.Lock(message)
+) (golxc.Container, error) {
+	purgeStaleTemplates(key)
+	lock, err := container.AcquireTemplateLock(key.Name(), "ensure clone exists")
 	if err != nil {
-		logger.Tracef("failed to acquire lock for template: %v", err)
 		return nil, err
 	}
-	return lock, nil
+	defer lock.Unlock()
+	return ensureCloneTemplate(backingFilesystem, key, network, authorizedKeys, aptProxy)
 }
 
-// Make sure a template exists that we can clone from.
-func EnsureCloneTemplate(
+// ensureCloneTemplate builds the template for the key unless it has been
+// built before. The caller must hold the template lock, so the template
+// can't be purged until the caller has cloned it and recorded its use.
+func ensureCloneTemplate(
 	backingFilesystem string,
-	series string,
+	key container.TemplateKey,
 	network *container.NetworkConfig,
 	authorizedKeys string,
 	aptProxy proxy.Settings,
 ) (golxc.Container, error) {
-	name := fmt.Sprintf("juju-%s-template", series)
+	name := key.Name()
+	series := key.Series
 	containerDirectory, err := container.NewDirectory(name)
 	if err != nil {
 		return nil, err
 	}
-
-	lock, err := AcquireTemplateLock(name, "ensure clone exists")
-	if err != nil {
-		return nil, err
-	}
-	defer lock.Unlock()
-
 	lxcContainer := LxcObjectFactory.New(name)
 	// Early exit if the container has been constructed before.
 	if lxcContainer.IsConstructed() {
@@ -117,7 +66,7 @@ func EnsureCloneTemplate(
 	}
 	logger.Infof("template does not exist, creating")
 
-	userData, err := templateUserData(series, authorizedKeys, aptProxy)
+	userData, err := container.TemplateUserData(series, authorizedKeys, aptProxy)
 	if err != nil {
 		logger.Tracef("failed to create template user data for template: %v", err)
 		return nil, err
@@ -192,10 +141,136 @@ func EnsureCloneTemplate(
 		}
 		time.Sleep(time.Second)
 	}
-
+	if err := container.WriteTemplateInfo(container.TemplateInfo{
+		Name:    name,
+		Type:    instance.LXC,
+		Series:  key.Series,
+		Arch:    key.Arch,
+		Tools:   key.Tools,
+		Created: time.Now(),
+	}); err != nil {
+		return nil, err
+	}
 	return lxcContainer, nil
 }
 
+// purgeStaleTemplates destroys the templates superseded by the template
+// for key. Failures are logged, as they don't stop the new template being
+// built.
+func purgeStaleTemplates(key container.TemplateKey) {
+	stale, err := container.StaleTemplates(instance.LXC, key)
+	if err != nil {
+		logger.Warningf("cannot list stale templates: %v", err)
+		return
+	}
+	for _, info := range stale {
+		logger.Infof("purging template %q superseded by %s", info.Name, key)
+		if err := destroyTemplate(info.Name); err != nil {
+			logger.Warningf("cannot purge template %q: %v", info.Name, err)
+		}
+	}
+}
+
+// destroyTemplate destroys the named template container and removes it
+// from the cache, unless there are containers using it.
+func destroyTemplate(name string) error {
+	lock, err := container.AcquireTemplateLock(name, "destroy template")
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	users, err := container.TemplateUsers(name)
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		// Containers cloned from legacy templates didn't record the
+		// template they use, so look for overlays on the template too.
+		users, err = overlayClones(name)
+		if err != nil {
+			return err
+		}
+	}
+	if len(users) > 0 {
+		return fmt.Errorf("template in use by %s", strings.Join(users, ", "))
+	}
+	lxcContainer := LxcObjectFactory.New(name)
+	if lxcContainer.IsConstructed() {
+		if err := lxcContainer.Destroy(); err != nil {
+			return err
+		}
+	}
+	return container.RemoveDirectory(name)
+}
+
+// overlayClones returns the names of the lxc containers with a root
+// filesystem that overlays the root filesystem of the named template.
+func overlayClones(name string) ([]string, error) {
+	dirs, err := ioutil.ReadDir(LxcContainerDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	rootfs := filepath.Join(LxcContainerDir, name, "rootfs") + ":"
+	var clones []string
+	for _, dir := range dirs {
+		if dir.Name() == name {
+			continue
+		}
+		data, err := ioutil.ReadFile(containerConfigFilename(dir.Name()))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "lxc.rootfs") && strings.Contains(line, rootfs) {
+				clones = append(clones, dir.Name())
+				break
+			}
+		}
+	}
+	return clones, nil
+}
+
+type templateManager struct{}
+
+// NewTemplateManager returns a container.TemplateManager for the lxc
+// clone templates on this host.
+func NewTemplateManager() container.TemplateManager {
+	return templateManager{}
+}
+
+// ListTemplates is specified on the container.TemplateManager interface.
+func (templateManager) ListTemplates() ([]container.TemplateInfo, error) {
+	return container.ListTemplates(instance.LXC)
+}
+
+// PurgeTemplates is specified on the container.TemplateManager interface.
+func (templateManager) PurgeTemplates(names ...string) error {
+	templates, err := container.ListTemplates(instance.LXC)
+	if err != nil {
+		return err
+	}
+	selected, err := container.SelectTemplates(templates, names)
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, info := range selected {
+		if err := destroyTemplate(info.Name); err != nil {
+			logger.Errorf("cannot purge template %q: %v", info.Name, err)
+			failed = append(failed, info.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("cannot purge templates: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
 type logTail struct {
 	tick  time.Time
 	mutex sync.Mutex
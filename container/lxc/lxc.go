@@ -17,6 +17,7 @@ import (
 	"launchpad.net/golxc"
 
 	"github.com/juju/core/agent"
+	"github.com/juju/core/constraints"
 	"github.com/juju/core/container"
 	"github.com/juju/core/environs/cloudinit"
 	"github.com/juju/core/instance"
@@ -40,6 +41,11 @@ const (
 	Btrfs = "btrfs"
 )
 
+// CpuPeriod is the cgroup CFS period, in microseconds, over which a
+// container's CPU time is limited. A container with a cpu-cores
+// constraint may use that many periods of CPU time in each period.
+const CpuPeriod = 100000
+
 // DefaultNetworkConfig returns a valid NetworkConfig to use the
 // defaultLxcBridge that is created by the lxc package.
 func DefaultNetworkConfig() *container.NetworkConfig {
@@ -172,37 +178,13 @@ func (manager *containerManager) CreateContainer(
 
 	var lxcContainer golxc.Container
 	if manager.createWithClone {
-		templateContainer, err := EnsureCloneTemplate(
-			manager.backingFilesystem,
-			series,
-			network,
-			machineConfig.AuthorizedKeys,
-			machineConfig.AptProxySettings,
-		)
-		if err != nil {
-			return nil, nil, err
-		}
 		templateParams := []string{
 			"--debug",                      // Debug errors in the cloud image
 			"--userdata", userDataFilename, // Our groovey cloud-init
 			"--hostid", name, // Use the container name as the hostid
 		}
-		var extraCloneArgs []string
-		if manager.backingFilesystem == Btrfs || manager.useAUFS {
-			extraCloneArgs = append(extraCloneArgs, "--snapshot")
-		}
-		if manager.backingFilesystem != Btrfs && manager.useAUFS {
-			extraCloneArgs = append(extraCloneArgs, "--backingstore", "aufs")
-		}
-
-		lock, err := AcquireTemplateLock(templateContainer.Name(), "clone")
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to acquire lock on template: %v", err)
-		}
-		defer lock.Unlock()
-		lxcContainer, err = templateContainer.Clone(name, extraCloneArgs, templateParams)
+		lxcContainer, err = manager.cloneFromTemplate(name, machineConfig, series, network, templateParams)
 		if err != nil {
-			logger.Errorf("lxc container cloning failed: %v", err)
 			return nil, nil, err
 		}
 	} else {
@@ -227,6 +209,10 @@ func (manager *containerManager) CreateContainer(
 	if err := autostartContainer(name); err != nil {
 		return nil, nil, err
 	}
+	limits := parseConstraintsToLimits(machineConfig.Constraints)
+	if err := limitResources(name, limits); err != nil {
+		return nil, nil, err
+	}
 	if err := mountHostLogDir(name, manager.logdir); err != nil {
 		return nil, nil, err
 	}
@@ -243,14 +229,62 @@ func (manager *containerManager) CreateContainer(
 		logger.Errorf("container failed to start: %v", err)
 		return nil, nil, err
 	}
-	arch := version.Current.Arch
-	hardware := &instance.HardwareCharacteristics{
-		Arch: &arch,
-	}
+	hardware := limits.hardwareCharacteristics()
 	logger.Tracef("container %q started: %v", name, time.Now().Sub(start))
 	return &lxcInstance{lxcContainer, name}, hardware, nil
 }
 
+// cloneFromTemplate clones the named container from the template for the
+// machine config and series, building the template first if necessary.
+// The template lock is held throughout, so the template can't be purged
+// before the clone has been made and its use recorded.
+func (manager *containerManager) cloneFromTemplate(
+	name string,
+	machineConfig *cloudinit.MachineConfig,
+	series string,
+	network *container.NetworkConfig,
+	templateParams []string,
+) (golxc.Container, error) {
+	key := container.NewTemplateKey(machineConfig, series)
+	purgeStaleTemplates(key)
+	lock, err := container.AcquireTemplateLock(key.Name(), "clone")
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock on template: %v", err)
+	}
+	defer lock.Unlock()
+	templateContainer, err := ensureCloneTemplate(
+		manager.backingFilesystem,
+		key,
+		network,
+		machineConfig.AuthorizedKeys,
+		machineConfig.AptProxySettings,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var extraCloneArgs []string
+	snapshot := manager.backingFilesystem == Btrfs || manager.useAUFS
+	if snapshot {
+		extraCloneArgs = append(extraCloneArgs, "--snapshot")
+	}
+	if manager.backingFilesystem != Btrfs && manager.useAUFS {
+		extraCloneArgs = append(extraCloneArgs, "--backingstore", "aufs")
+	}
+	lxcContainer, err := templateContainer.Clone(name, extraCloneArgs, templateParams)
+	if err != nil {
+		logger.Errorf("lxc container cloning failed: %v", err)
+		return nil, err
+	}
+	if snapshot {
+		// Snapshot clones share storage with the template, so it
+		// must not be purged while the clone exists.
+		if err := container.RecordTemplateUse(name, templateContainer.Name()); err != nil {
+			return nil, err
+		}
+	}
+	return lxcContainer, nil
+}
+
 func appendToContainerConfig(name, line string) error {
 	file, err := os.OpenFile(
 		containerConfigFilename(name), os.O_RDWR|os.O_APPEND, 0644)
@@ -282,6 +316,80 @@ func autostartContainer(name string) error {
 	return nil
 }
 
+// resourceLimits holds the cgroup limits applied to a container. Zero
+// values mean the resource is not limited.
+type resourceLimits struct {
+	// Memory is the memory limit in MB.
+	Memory uint64
+	// CpuCores is the number of cores' worth of CPU time
+	// the container may use.
+	CpuCores uint64
+}
+
+// parseConstraintsToLimits returns the cgroup limits for a container
+// with the given constraints. Constraints that cannot be enforced with
+// cgroups cause an informational message to be logged.
+func parseConstraintsToLimits(cons constraints.Value) resourceLimits {
+	var limits resourceLimits
+	if cons.Mem != nil {
+		limits.Memory = *cons.Mem
+	}
+	if cons.CpuCores != nil {
+		limits.CpuCores = *cons.CpuCores
+	}
+	if cons.CpuPower != nil {
+		logger.Infof("cpu-power constraint of %v being ignored as not supported", *cons.CpuPower)
+	}
+	if cons.RootDisk != nil {
+		logger.Infof("root-disk constraint of %v being ignored as not supported", *cons.RootDisk)
+	}
+	return limits
+}
+
+// cgroupConfig returns the lxc config lines that apply the limits.
+func (limits resourceLimits) cgroupConfig() string {
+	var config string
+	if limits.Memory != 0 {
+		config += fmt.Sprintf("lxc.cgroup.memory.limit_in_bytes = %d\n", limits.Memory*1024*1024)
+	}
+	if limits.CpuCores != 0 {
+		config += fmt.Sprintf("lxc.cgroup.cpu.cfs_period_us = %d\n", CpuPeriod)
+		config += fmt.Sprintf("lxc.cgroup.cpu.cfs_quota_us = %d\n", limits.CpuCores*CpuPeriod)
+	}
+	return config
+}
+
+// hardwareCharacteristics returns the hardware characteristics of a
+// container with the limits applied. LXC containers share the host
+// kernel, so the architecture is always that of the host.
+func (limits resourceLimits) hardwareCharacteristics() *instance.HardwareCharacteristics {
+	arch := version.Current.Arch
+	hardware := &instance.HardwareCharacteristics{
+		Arch: &arch,
+	}
+	if limits.Memory != 0 {
+		mem := limits.Memory
+		hardware.Mem = &mem
+	}
+	if limits.CpuCores != 0 {
+		cores := limits.CpuCores
+		hardware.CpuCores = &cores
+	}
+	return hardware
+}
+
+// limitResources adds the cgroup limits to the named container's config.
+// This is done after the container is created, so cloned containers get
+// their own limits rather than the template's.
+func limitResources(name string, limits resourceLimits) error {
+	config := limits.cgroupConfig()
+	if config == "" {
+		return nil
+	}
+	logger.Tracef("limiting container %q resources: %+v", name, limits)
+	return appendToContainerConfig(name, config)
+}
+
 func mountHostLogDir(name, logDir string) error {
 	// Make sure that the mount dir has been created.
 	logger.Tracef("make the mount dir for the shared logs")
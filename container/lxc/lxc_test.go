@@ -19,16 +19,25 @@ import (
 	"launchpad.net/goyaml"
 
 	"github.com/juju/core/agent"
+	"github.com/juju/core/constraints"
 	"github.com/juju/core/container"
 	"github.com/juju/core/container/lxc"
 	"github.com/juju/core/container/lxc/mock"
 	lxctesting "github.com/juju/core/container/lxc/testing"
 	containertesting "github.com/juju/core/container/testing"
+	"github.com/juju/core/instance"
 	instancetest "github.com/juju/core/instance/testing"
 	coretesting "github.com/juju/core/testing"
 	"github.com/juju/core/utils/proxy"
+	"github.com/juju/core/version"
 )
 
+var testTemplateKey = container.TemplateKey{
+	Series: "series",
+	Arch:   "bar",
+	Tools:  version.MustParse("2.3.4"),
+}
+
 func Test(t *stdtesting.T) {
 	gc.TestingT(t)
 }
@@ -48,7 +57,7 @@ func (s *LxcSuite) SetUpTest(c *gc.C) {
 	loggo.GetLogger("juju.container.lxc").SetLogLevel(loggo.TRACE)
 	s.events = make(chan mock.Event, 25)
 	s.TestSuite.Factory.AddListener(s.events)
-	s.PatchValue(&lxc.TemplateLockDir, c.MkDir())
+	s.PatchValue(&container.TemplateLockDir, c.MkDir())
 	s.PatchValue(&lxc.TemplateStopTimeout, 500*time.Millisecond)
 }
 
@@ -245,7 +254,7 @@ func (s *LxcSuite) TestCreateContainerEventsWithClone(c *gc.C) {
 	s.PatchValue(&s.useClone, true)
 	// The template containers are created with an upstart job that
 	// stops them once cloud init has finished.  We emulate that here.
-	template := "juju-series-template"
+	template := "juju-series-bar-2.3.4-template"
 	s.ensureTemplateStopped(template)
 	manager := s.makeManager(c, "test")
 	instance := containertesting.CreateContainer(c, manager, "1")
@@ -258,13 +267,13 @@ func (s *LxcSuite) TestCreateContainerEventsWithClone(c *gc.C) {
 }
 
 func (s *LxcSuite) createTemplate(c *gc.C) golxc.Container {
-	name := "juju-series-template"
+	name := "juju-series-bar-2.3.4-template"
 	s.ensureTemplateStopped(name)
 	network := container.BridgeNetworkConfig("nic42")
 	authorizedKeys := "authorized keys list"
 	aptProxy := proxy.Settings{}
 	template, err := lxc.EnsureCloneTemplate(
-		"ext4", "series", network, authorizedKeys, aptProxy)
+		"ext4", testTemplateKey, network, authorizedKeys, aptProxy)
 	c.Assert(err, gc.IsNil)
 	c.Assert(template.Name(), gc.Equals, name)
 	s.AssertEvent(c, <-s.events, mock.Created, name)
@@ -293,7 +302,7 @@ func (s *LxcSuite) TestCreateContainerEventsWithCloneExistingTemplate(c *gc.C) {
 	instance := containertesting.CreateContainer(c, manager, "1")
 	name := string(instance.Id())
 	cloned := <-s.events
-	s.AssertEvent(c, cloned, mock.Cloned, "juju-series-template")
+	s.AssertEvent(c, cloned, mock.Cloned, "juju-series-bar-2.3.4-template")
 	c.Assert(cloned.Args, gc.IsNil)
 	s.AssertEvent(c, <-s.events, mock.Started, name)
 }
@@ -306,7 +315,7 @@ func (s *LxcSuite) TestCreateContainerEventsWithCloneExistingTemplateAUFS(c *gc.
 	instance := containertesting.CreateContainer(c, manager, "1")
 	name := string(instance.Id())
 	cloned := <-s.events
-	s.AssertEvent(c, cloned, mock.Cloned, "juju-series-template")
+	s.AssertEvent(c, cloned, mock.Cloned, "juju-series-bar-2.3.4-template")
 	c.Assert(cloned.Args, gc.DeepEquals, []string{"--snapshot", "--backingstore", "aufs"})
 	s.AssertEvent(c, <-s.events, mock.Started, name)
 }
@@ -326,6 +335,140 @@ func (s *LxcSuite) TestCreateContainerWithCloneMountsAndAutostarts(c *gc.C) {
 	c.Assert(autostartLink, jc.IsSymlink)
 }
 
+func (s *LxcSuite) createContainerWithConstraints(c *gc.C, cons string) (string, *instance.HardwareCharacteristics) {
+	manager := s.makeManager(c, "test")
+	machineConfig := containertesting.MockMachineConfig("1/lxc/0")
+	machineConfig.Constraints = constraints.MustParse(cons)
+	inst, hardware := containertesting.CreateContainerWithMachineConfig(c, manager, machineConfig)
+	config, err := ioutil.ReadFile(lxc.ContainerConfigFilename(string(inst.Id())))
+	c.Assert(err, gc.IsNil)
+	return string(config), hardware
+}
+
+func (s *LxcSuite) TestCreateContainerLimitsResources(c *gc.C) {
+	config, hardware := s.createContainerWithConstraints(c, "mem=512M cpu-cores=2 root-disk=8G")
+	c.Assert(config, jc.Contains, "lxc.cgroup.memory.limit_in_bytes = 536870912\n")
+	c.Assert(config, jc.Contains, "lxc.cgroup.cpu.cfs_period_us = 100000\nlxc.cgroup.cpu.cfs_quota_us = 200000\n")
+	c.Assert(hardware.String(), gc.Equals, "arch="+version.Current.Arch+" cpu-cores=2 mem=512M")
+}
+
+func (s *LxcSuite) TestCreateContainerWithCloneLimitsResources(c *gc.C) {
+	s.createTemplate(c)
+	s.PatchValue(&s.useClone, true)
+	config, hardware := s.createContainerWithConstraints(c, "mem=1G")
+	c.Assert(config, jc.Contains, "lxc.cgroup.memory.limit_in_bytes = 1073741824\n")
+	c.Assert(config, gc.Not(jc.Contains), "lxc.cgroup.cpu.")
+	c.Assert(hardware.String(), gc.Equals, "arch="+version.Current.Arch+" mem=1024M")
+}
+
+func (s *LxcSuite) TestCreateContainerWithoutConstraintsIsUnlimited(c *gc.C) {
+	config, hardware := s.createContainerWithConstraints(c, "")
+	c.Assert(config, gc.Not(jc.Contains), "lxc.cgroup")
+	c.Assert(hardware.String(), gc.Equals, "arch="+version.Current.Arch)
+}
+
+func (s *LxcSuite) ensureTemplate(c *gc.C, key container.TemplateKey) {
+	s.ensureTemplateStopped(key.Name())
+	network := container.BridgeNetworkConfig("nic42")
+	_, err := lxc.EnsureCloneTemplate("ext4", key, network, "", proxy.Settings{})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *LxcSuite) TestEnsureCloneTemplateRecordsTemplate(c *gc.C) {
+	s.createTemplate(c)
+	templates, err := lxc.NewTemplateManager().ListTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 1)
+	c.Assert(templates[0].Name, gc.Equals, "juju-series-bar-2.3.4-template")
+	c.Assert(templates[0].Key(), gc.Equals, testTemplateKey)
+}
+
+func (s *LxcSuite) TestEnsureCloneTemplatePurgesStaleTemplates(c *gc.C) {
+	oldKey := testTemplateKey
+	oldKey.Tools = version.MustParse("2.3.3")
+	otherSeriesKey := oldKey
+	otherSeriesKey.Series = "other"
+	s.ensureTemplate(c, oldKey)
+	s.ensureTemplate(c, otherSeriesKey)
+	s.ensureTemplate(c, testTemplateKey)
+
+	templates, err := lxc.NewTemplateManager().ListTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 2)
+	c.Assert(templates[0].Key(), gc.Equals, otherSeriesKey)
+	c.Assert(templates[1].Key(), gc.Equals, testTemplateKey)
+	c.Assert(s.Factory.New(oldKey.Name()).IsConstructed(), jc.IsFalse)
+}
+
+func (s *LxcSuite) TestPurgeTemplatesSkipsTemplatesInUse(c *gc.C) {
+	s.createTemplate(c)
+	s.PatchValue(&s.useClone, true)
+	s.PatchValue(&s.useAUFS, true)
+	manager := s.makeManager(c, "test")
+	instance := containertesting.CreateContainer(c, manager, "1")
+
+	templateManager := lxc.NewTemplateManager()
+	err := templateManager.PurgeTemplates()
+	c.Assert(err, gc.ErrorMatches, "cannot purge templates: juju-series-bar-2.3.4-template")
+	templates, err := templateManager.ListTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 1)
+
+	err = manager.DestroyContainer(instance.Id())
+	c.Assert(err, gc.IsNil)
+	err = templateManager.PurgeTemplates("juju-series-bar-2.3.4-template")
+	c.Assert(err, gc.IsNil)
+	templates, err = templateManager.ListTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 0)
+}
+
+func (s *LxcSuite) TestPurgeLegacyTemplate(c *gc.C) {
+	const legacy = "juju-series-template"
+	_, err := container.NewDirectory(legacy)
+	c.Assert(err, gc.IsNil)
+	templateManager := lxc.NewTemplateManager()
+	templates, err := templateManager.ListTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 1)
+	c.Assert(templates[0].Name, gc.Equals, legacy)
+	c.Assert(templates[0].IsLegacy(), jc.IsTrue)
+
+	// Containers cloned from legacy templates with an overlay keep the
+	// template in use.
+	cloneDir := filepath.Join(s.LxcDir, "juju-machine-1-lxc-0")
+	err = os.MkdirAll(cloneDir, 0755)
+	c.Assert(err, gc.IsNil)
+	rootfs := fmt.Sprintf("lxc.rootfs = overlayfs:%s/%s/rootfs:%s/delta0\n", s.LxcDir, legacy, cloneDir)
+	err = ioutil.WriteFile(filepath.Join(cloneDir, "config"), []byte(rootfs), 0644)
+	c.Assert(err, gc.IsNil)
+	err = templateManager.PurgeTemplates(legacy)
+	c.Assert(err, gc.ErrorMatches, "cannot purge templates: juju-series-template")
+
+	err = os.RemoveAll(cloneDir)
+	c.Assert(err, gc.IsNil)
+	err = templateManager.PurgeTemplates(legacy)
+	c.Assert(err, gc.IsNil)
+	templates, err = templateManager.ListTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 0)
+}
+
+func (s *LxcSuite) TestEnsureCloneTemplatePurgesLegacyTemplate(c *gc.C) {
+	_, err := container.NewDirectory("juju-series-template")
+	c.Assert(err, gc.IsNil)
+	s.createTemplate(c)
+	templates, err := lxc.NewTemplateManager().ListTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 1)
+	c.Assert(templates[0].Key(), gc.Equals, testTemplateKey)
+}
+
+func (s *LxcSuite) TestPurgeUnknownTemplate(c *gc.C) {
+	err := lxc.NewTemplateManager().PurgeTemplates("juju-foo-template")
+	c.Assert(err, gc.ErrorMatches, `template "juju-foo-template" not found`)
+}
+
 func (s *LxcSuite) TestContainerState(c *gc.C) {
 	manager := s.makeManager(c, "test")
 	c.Logf("%#v", manager)
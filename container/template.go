@@ -0,0 +1,321 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package container
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	coreCloudinit "github.com/juju/core/cloudinit"
+	"github.com/juju/core/environs/cloudinit"
+	"github.com/juju/core/instance"
+	"github.com/juju/core/utils"
+	"github.com/juju/core/utils/fslock"
+	"github.com/juju/core/utils/proxy"
+	"github.com/juju/core/version"
+)
+
+const (
+	templateShutdownUpstartFilename = "/etc/init/juju-template-restart.conf"
+	templateShutdownUpstartScript   = `
+description "Juju template shutdown job"
+author "Juju Team <juju@lists.ubuntu.com>"
+start on stopped cloud-final
+
+script
+  shutdown -h now
+end script
+
+post-stop script
+  rm ` + templateShutdownUpstartFilename + `
+end script
+`
+
+	// templateInfoFilename is the name of the file, in the container
+	// directory of a template, that records the template details.
+	templateInfoFilename = "template.json"
+
+	// templateUseFilename is the name of the file, in the container
+	// directory of a container, that records the template the container
+	// was cloned from.
+	templateUseFilename = "template"
+)
+
+var TemplateLockDir = "/var/lib/juju/locks"
+
+// legacyTemplateName matches the names of the lxc templates made before
+// the cache was keyed by architecture and tools version, for example
+// juju-trusty-template.
+var legacyTemplateName = regexp.MustCompile(`^juju-([a-z]+)-template$`)
+
+// TemplateKey identifies a cached container template. Templates contain the
+// packages and juju tools current when they were built, so a template is
+// only usable for containers of the same series and architecture, started
+// by an agent running the same tools version.
+type TemplateKey struct {
+	Series string
+	Arch   string
+	Tools  version.Number
+}
+
+// NewTemplateKey returns the key for the template to use for containers
+// of the given series started with the machine config.
+func NewTemplateKey(machineConfig *cloudinit.MachineConfig, series string) TemplateKey {
+	toolsVersion := version.Current
+	if machineConfig.Tools != nil {
+		toolsVersion = machineConfig.Tools.Version
+	}
+	return TemplateKey{
+		Series: series,
+		Arch:   toolsVersion.Arch,
+		Tools:  toolsVersion.Number,
+	}
+}
+
+// Name returns the name used for the template container.
+func (key TemplateKey) Name() string {
+	return fmt.Sprintf("juju-%s-%s-%s-template", key.Series, key.Arch, key.Tools)
+}
+
+// String returns a readable description of the key.
+func (key TemplateKey) String() string {
+	return fmt.Sprintf("%s/%s tools %s", key.Series, key.Arch, key.Tools)
+}
+
+// TemplateInfo describes a template in the cache on a host.
+type TemplateInfo struct {
+	Name    string
+	Type    instance.ContainerType
+	Series  string
+	Arch    string
+	Tools   version.Number
+	Created time.Time
+}
+
+// IsLegacy reports whether the template was made before templates were
+// recorded in the cache. Only the series of these templates is known.
+func (info TemplateInfo) IsLegacy() bool {
+	return info.Tools == version.Zero
+}
+
+// Key returns the key the template was built for.
+func (info TemplateInfo) Key() TemplateKey {
+	return TemplateKey{
+		Series: info.Series,
+		Arch:   info.Arch,
+		Tools:  info.Tools,
+	}
+}
+
+// TemplateManager is implemented by the container types that keep a cache
+// of templates to create containers from. It allows operators to see and
+// reclaim the space used by the templates on a host.
+type TemplateManager interface {
+	// ListTemplates returns the templates in the cache.
+	ListTemplates() ([]TemplateInfo, error)
+
+	// PurgeTemplates destroys the named templates, or all the templates
+	// in the cache if no names are given. Templates that are still used
+	// by containers are not purged.
+	PurgeTemplates(names ...string) error
+}
+
+// TemplateUserData returns a minimal user data necessary for a template.
+// This should have the authorized keys, base packages, the cloud archive if
+// necessary, initial apt proxy config, and it should do the apt-get
+// update/upgrade initially. The template shuts itself down once cloud-init
+// has finished.
+func TemplateUserData(
+	series string,
+	authorizedKeys string,
+	aptProxy proxy.Settings,
+) ([]byte, error) {
+	config := coreCloudinit.New()
+	config.AddScripts(
+		"set -xe", // ensure we run all the scripts or abort.
+	)
+	config.AddSSHAuthorizedKeys(authorizedKeys)
+	cloudinit.MaybeAddCloudArchiveCloudTools(config, series)
+	cloudinit.AddAptCommands(aptProxy, config)
+	config.AddScripts(
+		fmt.Sprintf(
+			"printf '%%s\n' %s > %s",
+			utils.ShQuote(templateShutdownUpstartScript),
+			templateShutdownUpstartFilename,
+		))
+	data, err := config.Render()
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// AcquireTemplateLock blocks until it holds the lock for the named
+// template. Callers must unlock the returned lock when they are done.
+func AcquireTemplateLock(name, message string) (*fslock.Lock, error) {
+	logger.Infof("wait for fslock on %v", name)
+	lock, err := fslock.NewLock(TemplateLockDir, name)
+	if err != nil {
+		logger.Tracef("failed to create fslock for template: %v", err)
+		return nil, err
+	}
+	err = lock.Lock(message)
+	if err != nil {
+		logger.Tracef("failed to acquire lock for template: %v", err)
+		return nil, err
+	}
+	return lock, nil
+}
+
+// WriteTemplateInfo records the template in the cache. The container
+// directory for the template must already exist.
+func WriteTemplateInfo(info TemplateInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(dirForName(info.Name), templateInfoFilename)
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// ListTemplates returns all the cached templates of the given container
+// type, ordered by name. Legacy lxc templates are included, so they can
+// be purged.
+func ListTemplates(containerType instance.ContainerType) ([]TemplateInfo, error) {
+	dirs, err := ioutil.ReadDir(ContainerDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var result []TemplateInfo
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		filename := filepath.Join(ContainerDir, dir.Name(), templateInfoFilename)
+		data, err := ioutil.ReadFile(filename)
+		if os.IsNotExist(err) {
+			if info, ok := legacyTemplateInfo(dir); ok && info.Type == containerType {
+				result = append(result, info)
+			}
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		var info TemplateInfo
+		if err := json.Unmarshal(data, &info); err != nil {
+			logger.Warningf("cannot read template info %q: %v", filename, err)
+			continue
+		}
+		if info.Type == containerType {
+			result = append(result, info)
+		}
+	}
+	sort.Sort(templatesByName(result))
+	return result, nil
+}
+
+// legacyTemplateInfo returns the details of the legacy lxc template in
+// the container directory, if it is one.
+func legacyTemplateInfo(dir os.FileInfo) (TemplateInfo, bool) {
+	match := legacyTemplateName.FindStringSubmatch(dir.Name())
+	if match == nil {
+		return TemplateInfo{}, false
+	}
+	return TemplateInfo{
+		Name:    dir.Name(),
+		Type:    instance.LXC,
+		Series:  match[1],
+		Created: dir.ModTime(),
+	}, true
+}
+
+// StaleTemplates returns the cached templates of the given container type
+// that were built for the same series and architecture as key, but with
+// different tools, and any legacy templates for the series. These are
+// superseded by the template for key.
+func StaleTemplates(containerType instance.ContainerType, key TemplateKey) ([]TemplateInfo, error) {
+	templates, err := ListTemplates(containerType)
+	if err != nil {
+		return nil, err
+	}
+	var stale []TemplateInfo
+	for _, info := range templates {
+		if info.Series != key.Series {
+			continue
+		}
+		if info.IsLegacy() || info.Arch == key.Arch && info.Tools != key.Tools {
+			stale = append(stale, info)
+		}
+	}
+	return stale, nil
+}
+
+// SelectTemplates returns the templates with the given names, or all the
+// templates if no names are specified. It is an error to name a template
+// that is not in the list.
+func SelectTemplates(templates []TemplateInfo, names []string) ([]TemplateInfo, error) {
+	if len(names) == 0 {
+		return templates, nil
+	}
+	byName := make(map[string]TemplateInfo)
+	for _, info := range templates {
+		byName[info.Name] = info
+	}
+	var result []TemplateInfo
+	for _, name := range names {
+		info, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("template %q not found", name)
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// RecordTemplateUse notes that the named container was created from the
+// named template, so the template is not purged while the container uses
+// it.
+func RecordTemplateUse(containerName, templateName string) error {
+	filename := filepath.Join(dirForName(containerName), templateUseFilename)
+	return ioutil.WriteFile(filename, []byte(templateName+"\n"), 0644)
+}
+
+// TemplateUsers returns the names of the containers that were created
+// from the named template, and have not since been removed.
+func TemplateUsers(templateName string) ([]string, error) {
+	dirs, err := ioutil.ReadDir(ContainerDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var users []string
+	for _, dir := range dirs {
+		data, err := ioutil.ReadFile(filepath.Join(ContainerDir, dir.Name(), templateUseFilename))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(string(data)) == templateName {
+			users = append(users, dir.Name())
+		}
+	}
+	return users, nil
+}
+
+type templatesByName []TemplateInfo
+
+func (t templatesByName) Len() int           { return len(t) }
+func (t templatesByName) Less(i, j int) bool { return t[i].Name < t[j].Name }
+func (t templatesByName) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
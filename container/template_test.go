@@ -0,0 +1,167 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package container_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/container"
+	"github.com/juju/core/environs"
+	"github.com/juju/core/instance"
+	"github.com/juju/core/testing"
+	"github.com/juju/core/tools"
+	"github.com/juju/core/version"
+)
+
+type TemplateSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&TemplateSuite{})
+
+func (s *TemplateSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.PatchValue(&container.ContainerDir, c.MkDir())
+	s.PatchValue(&container.RemovedContainerDir, c.MkDir())
+}
+
+func templateKey(series, arch, toolsVersion string) container.TemplateKey {
+	return container.TemplateKey{
+		Series: series,
+		Arch:   arch,
+		Tools:  version.MustParse(toolsVersion),
+	}
+}
+
+func (s *TemplateSuite) addTemplate(c *gc.C, ctype instance.ContainerType, key container.TemplateKey) container.TemplateInfo {
+	_, err := container.NewDirectory(key.Name())
+	c.Assert(err, gc.IsNil)
+	info := container.TemplateInfo{
+		Name:    key.Name(),
+		Type:    ctype,
+		Series:  key.Series,
+		Arch:    key.Arch,
+		Tools:   key.Tools,
+		Created: time.Now().UTC().Round(time.Second),
+	}
+	err = container.WriteTemplateInfo(info)
+	c.Assert(err, gc.IsNil)
+	return info
+}
+
+func (*TemplateSuite) TestNewTemplateKey(c *gc.C) {
+	machineConfig := environs.NewMachineConfig("1/lxc/0", "fake-nonce", nil, nil, nil, nil)
+	machineConfig.Tools = &tools.Tools{
+		Version: version.MustParseBinary("2.3.4-foo-bar"),
+	}
+	key := container.NewTemplateKey(machineConfig, "trusty")
+	c.Assert(key, gc.Equals, container.TemplateKey{
+		Series: "trusty",
+		Arch:   "bar",
+		Tools:  version.MustParse("2.3.4"),
+	})
+	c.Assert(key.Name(), gc.Equals, "juju-trusty-bar-2.3.4-template")
+}
+
+func (s *TemplateSuite) TestListTemplates(c *gc.C) {
+	templates, err := container.ListTemplates(instance.LXC)
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 0)
+
+	trusty := s.addTemplate(c, instance.LXC, templateKey("trusty", "amd64", "1.19.4"))
+	precise := s.addTemplate(c, instance.LXC, templateKey("precise", "amd64", "1.19.4"))
+	s.addTemplate(c, instance.KVM, templateKey("saucy", "amd64", "1.19.4"))
+	// Ordinary containers are not templates.
+	_, err = container.NewDirectory("juju-machine-1-lxc-0")
+	c.Assert(err, gc.IsNil)
+
+	templates, err = container.ListTemplates(instance.LXC)
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.DeepEquals, []container.TemplateInfo{precise, trusty})
+}
+
+func (s *TemplateSuite) TestListLegacyTemplates(c *gc.C) {
+	_, err := container.NewDirectory("juju-trusty-template")
+	c.Assert(err, gc.IsNil)
+
+	templates, err := container.ListTemplates(instance.LXC)
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 1)
+	c.Assert(templates[0].Name, gc.Equals, "juju-trusty-template")
+	c.Assert(templates[0].Series, gc.Equals, "trusty")
+	c.Assert(templates[0].IsLegacy(), jc.IsTrue)
+
+	// Only lxc had legacy templates.
+	templates, err = container.ListTemplates(instance.KVM)
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 0)
+}
+
+func (s *TemplateSuite) TestStaleTemplates(c *gc.C) {
+	current := templateKey("trusty", "amd64", "1.19.4")
+	s.addTemplate(c, instance.LXC, current)
+	old := s.addTemplate(c, instance.LXC, templateKey("trusty", "amd64", "1.19.3"))
+	s.addTemplate(c, instance.LXC, templateKey("trusty", "i386", "1.19.3"))
+	s.addTemplate(c, instance.LXC, templateKey("precise", "amd64", "1.19.3"))
+	s.addTemplate(c, instance.KVM, templateKey("trusty", "amd64", "1.19.3"))
+
+	stale, err := container.StaleTemplates(instance.LXC, current)
+	c.Assert(err, gc.IsNil)
+	c.Assert(stale, gc.DeepEquals, []container.TemplateInfo{old})
+
+	// Legacy templates for the series are stale too.
+	for _, name := range []string{"juju-trusty-template", "juju-precise-template"} {
+		_, err = container.NewDirectory(name)
+		c.Assert(err, gc.IsNil)
+	}
+	stale, err = container.StaleTemplates(instance.LXC, current)
+	c.Assert(err, gc.IsNil)
+	c.Assert(stale, gc.HasLen, 2)
+	c.Assert(stale[0], gc.DeepEquals, old)
+	c.Assert(stale[1].Name, gc.Equals, "juju-trusty-template")
+}
+
+func (s *TemplateSuite) TestSelectTemplates(c *gc.C) {
+	first := s.addTemplate(c, instance.LXC, templateKey("precise", "amd64", "1.19.4"))
+	second := s.addTemplate(c, instance.LXC, templateKey("trusty", "amd64", "1.19.4"))
+	templates := []container.TemplateInfo{first, second}
+
+	selected, err := container.SelectTemplates(templates, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(selected, gc.DeepEquals, templates)
+
+	selected, err = container.SelectTemplates(templates, []string{second.Name})
+	c.Assert(err, gc.IsNil)
+	c.Assert(selected, gc.DeepEquals, []container.TemplateInfo{second})
+
+	_, err = container.SelectTemplates(templates, []string{"juju-foo-template"})
+	c.Assert(err, gc.ErrorMatches, `template "juju-foo-template" not found`)
+}
+
+func (s *TemplateSuite) TestTemplateUsers(c *gc.C) {
+	template := s.addTemplate(c, instance.LXC, templateKey("trusty", "amd64", "1.19.4"))
+	users, err := container.TemplateUsers(template.Name)
+	c.Assert(err, gc.IsNil)
+	c.Assert(users, gc.HasLen, 0)
+
+	for _, name := range []string{"juju-machine-1-lxc-0", "juju-machine-1-lxc-1"} {
+		_, err := container.NewDirectory(name)
+		c.Assert(err, gc.IsNil)
+		err = container.RecordTemplateUse(name, template.Name)
+		c.Assert(err, gc.IsNil)
+	}
+	users, err = container.TemplateUsers(template.Name)
+	c.Assert(err, gc.IsNil)
+	c.Assert(users, jc.SameContents, []string{"juju-machine-1-lxc-0", "juju-machine-1-lxc-1"})
+
+	// Removed containers no longer use the template.
+	err = container.RemoveDirectory("juju-machine-1-lxc-0")
+	c.Assert(err, gc.IsNil)
+	users, err = container.TemplateUsers(template.Name)
+	c.Assert(err, gc.IsNil)
+	c.Assert(users, gc.DeepEquals, []string{"juju-machine-1-lxc-1"})
+}
@@ -11,13 +11,16 @@ import (
 
 	"github.com/juju/core/container"
 	"github.com/juju/core/environs"
+	"github.com/juju/core/environs/cloudinit"
 	"github.com/juju/core/instance"
 	jujutesting "github.com/juju/core/juju/testing"
 	"github.com/juju/core/tools"
 	"github.com/juju/core/version"
 )
 
-func CreateContainer(c *gc.C, manager container.Manager, machineId string) instance.Instance {
+// MockMachineConfig returns a machine config suitable for creating a
+// container for the given machine id.
+func MockMachineConfig(machineId string) *cloudinit.MachineConfig {
 	stateInfo := jujutesting.FakeStateInfo(machineId)
 	apiInfo := jujutesting.FakeAPIInfo(machineId)
 	machineConfig := environs.NewMachineConfig(machineId, "fake-nonce", nil, nil, stateInfo, apiInfo)
@@ -25,14 +28,29 @@ func CreateContainer(c *gc.C, manager container.Manager, machineId string) insta
 		Version: version.MustParseBinary("2.3.4-foo-bar"),
 		URL:     "http://tools.testing.invalid/2.3.4-foo-bar.tgz",
 	}
+	return machineConfig
+}
 
+func CreateContainer(c *gc.C, manager container.Manager, machineId string) instance.Instance {
+	inst, _ := CreateContainerWithMachineConfig(c, manager, MockMachineConfig(machineId))
+	return inst
+}
+
+// CreateContainerWithMachineConfig creates a container using the given
+// machine config, and returns the instance and its hardware
+// characteristics.
+func CreateContainerWithMachineConfig(
+	c *gc.C,
+	manager container.Manager,
+	machineConfig *cloudinit.MachineConfig,
+) (instance.Instance, *instance.HardwareCharacteristics) {
 	series := "series"
 	network := container.BridgeNetworkConfig("nic42")
 	inst, hardware, err := manager.CreateContainer(machineConfig, series, network)
 	c.Assert(err, gc.IsNil)
 	c.Assert(hardware, gc.NotNil)
 	c.Assert(hardware.String(), gc.Not(gc.Equals), "")
-	return inst
+	return inst, hardware
 }
 
 func AssertCloudInit(c *gc.C, filename string) []byte {
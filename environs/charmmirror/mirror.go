@@ -0,0 +1,355 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The charmmirror package copies charms and bundles from a charm
+// store into storage, and serves them from there to environments
+// that cannot reach the charm store.
+package charmmirror
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"launchpad.net/goyaml"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/environs/storage"
+	"github.com/juju/core/utils"
+)
+
+var logger = loggo.GetLogger("juju.environs.charmmirror")
+
+// StoragePrefix is the path in storage under which
+// everything belonging to a mirror is kept.
+const StoragePrefix = "charm-mirror/"
+
+// IndexPath is the path in storage of the mirror's index.
+const IndexPath = StoragePrefix + "index.json"
+
+// Revision describes a mirrored revision of a charm or bundle.
+type Revision struct {
+	Revision int    `json:"revision"`
+	Sha256   string `json:"sha256"`
+	Signed   bool   `json:"signed,omitempty"`
+}
+
+// Index records the content of a mirror. It maps the URL of each
+// mirrored charm or bundle, without a revision, to the revisions
+// held, in ascending order.
+type Index struct {
+	Entries map[string][]Revision `json:"entries"`
+}
+
+// Latest returns the most recent mirrored revision of the charm or
+// bundle at curl, reporting whether there is any.
+func (index *Index) Latest(curl *charm.URL) (Revision, bool) {
+	revisions := index.Entries[curl.WithRevision(-1).String()]
+	if len(revisions) == 0 {
+		return Revision{}, false
+	}
+	return revisions[len(revisions)-1], true
+}
+
+// Find returns the revision of the charm or bundle at curl,
+// reporting whether it is mirrored. If curl holds no revision,
+// the latest one is returned.
+func (index *Index) Find(curl *charm.URL) (Revision, bool) {
+	if curl.Revision == -1 {
+		return index.Latest(curl)
+	}
+	for _, rev := range index.Entries[curl.WithRevision(-1).String()] {
+		if rev.Revision == curl.Revision {
+			return rev, true
+		}
+	}
+	return Revision{}, false
+}
+
+func (index *Index) add(curl *charm.URL, rev Revision) {
+	key := curl.WithRevision(-1).String()
+	revisions := append(index.Entries[key], rev)
+	sort.Sort(byRevision(revisions))
+	index.Entries[key] = revisions
+}
+
+type byRevision []Revision
+
+func (r byRevision) Len() int           { return len(r) }
+func (r byRevision) Less(i, j int) bool { return r[i].Revision < r[j].Revision }
+func (r byRevision) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+// ArchivePath returns the path in storage of the charm
+// archive or bundle data at curl, which must hold a revision.
+func ArchivePath(curl *charm.URL) string {
+	if curl.Series == charm.BundleSeries {
+		return StoragePrefix + "bundles/" + charm.Quote(curl.String()) + ".yaml"
+	}
+	return StoragePrefix + "charms/" + charm.Quote(curl.String()) + ".charm"
+}
+
+// SignaturePath returns the path in storage of the detached
+// signature of the charm archive at curl.
+func SignaturePath(curl *charm.URL) string {
+	return ArchivePath(curl) + charm.SignatureSuffix
+}
+
+// ReadIndex reads the index of the mirror held in stor.
+// An empty index is returned if there is none.
+func ReadIndex(stor storage.StorageReader) (*Index, error) {
+	index := &Index{Entries: make(map[string][]Revision)}
+	r, err := storage.Get(stor, IndexPath)
+	if errors.IsNotFound(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read charm mirror index: %v", err)
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("invalid charm mirror index: %v", err)
+	}
+	if index.Entries == nil {
+		index.Entries = make(map[string][]Revision)
+	}
+	return index, nil
+}
+
+func writeIndex(stor storage.StorageWriter, index *Index) error {
+	data, err := json.MarshalIndent(index, "", "    ")
+	if err != nil {
+		return err
+	}
+	return stor.Put(IndexPath, bytes.NewReader(data), int64(len(data)))
+}
+
+// Context describes what to copy into a mirror.
+type Context struct {
+	// Source holds the charm store to copy from.
+	Source *charm.CharmStore
+
+	// Target holds the storage of the mirror.
+	Target storage.Storage
+
+	// URLs holds the URLs of the charms and bundles to copy. The
+	// latest revision is copied when a URL holds no revision. The
+	// charms used by bundles are copied too.
+	URLs []string
+
+	// DryRun controls that nothing is copied. Instead it's logged
+	// what would be copied.
+	DryRun bool
+}
+
+// Mirror copies the charms and bundles described by ctx from the
+// charm store to the mirror. Revisions already mirrored are skipped.
+// CacheDir must have been set in the charm package, as charms are
+// downloaded there first.
+func Mirror(ctx *Context) error {
+	index, err := ReadIndex(ctx.Target)
+	if err != nil {
+		return err
+	}
+	var charmURLs, bundleURLs []*charm.URL
+	for _, s := range ctx.URLs {
+		curl, err := resolve(ctx.Source, s)
+		if err != nil {
+			return err
+		}
+		if curl.Series == charm.BundleSeries {
+			bundleURLs = append(bundleURLs, curl)
+		} else {
+			charmURLs = append(charmURLs, curl)
+		}
+	}
+	m := &mirror{ctx: ctx, index: index}
+	for _, burl := range bundleURLs {
+		curls, err := m.copyBundle(burl)
+		if err != nil {
+			return fmt.Errorf("cannot mirror bundle %q: %v", burl, err)
+		}
+		charmURLs = append(charmURLs, curls...)
+	}
+	for _, curl := range charmURLs {
+		if err := m.copyCharm(curl); err != nil {
+			return fmt.Errorf("cannot mirror charm %q: %v", curl, err)
+		}
+	}
+	if ctx.DryRun {
+		return nil
+	}
+	if !m.changed {
+		logger.Infof("charm mirror is up to date")
+		return nil
+	}
+	return writeIndex(ctx.Target, index)
+}
+
+// resolve parses s as a charm or bundle URL, asking the
+// charm store for the series if it's missing.
+func resolve(source *charm.CharmStore, s string) (*charm.URL, error) {
+	curl, err := charm.ParseURL(s)
+	if err != charm.ErrUnresolvedUrl {
+		return curl, err
+	}
+	ref, _, err := charm.ParseReference(s)
+	if err != nil {
+		return nil, err
+	}
+	return source.Resolve(ref)
+}
+
+type mirror struct {
+	ctx     *Context
+	index   *Index
+	changed bool
+}
+
+// info returns the revision and SHA256 of the charm
+// or bundle at curl in the charm store.
+func (m *mirror) info(curl *charm.URL) (Revision, error) {
+	var infos []*charm.InfoResponse
+	var err error
+	if curl.Series == charm.BundleSeries {
+		infos, err = m.ctx.Source.BundleInfo(curl)
+	} else {
+		infos, err = m.ctx.Source.Info(curl)
+	}
+	if err != nil {
+		return Revision{}, err
+	}
+	info := infos[0]
+	if len(info.Errors) > 0 {
+		return Revision{}, fmt.Errorf("%s", strings.Join(info.Errors, "; "))
+	}
+	return Revision{Revision: info.Revision, Sha256: info.Sha256}, nil
+}
+
+// isMirrored reports whether the revision of the charm or
+// bundle at curl is already mirrored.
+func (m *mirror) isMirrored(curl *charm.URL) bool {
+	if _, ok := m.index.Find(curl); ok {
+		logger.Infof("%s is already mirrored", curl)
+		return true
+	}
+	return false
+}
+
+// copyCharm copies the charm at curl and its signature, if any.
+func (m *mirror) copyCharm(curl *charm.URL) error {
+	if curl.Revision != -1 && m.isMirrored(curl) {
+		return nil
+	}
+	rev, err := m.info(curl)
+	if err != nil {
+		return err
+	}
+	curl = curl.WithRevision(rev.Revision)
+	if m.isMirrored(curl) {
+		return nil
+	}
+	if m.ctx.DryRun {
+		logger.Infof("copying %s (dry run)", curl)
+		return nil
+	}
+	logger.Infof("copying %s", curl)
+	ch, err := m.ctx.Source.Get(curl)
+	if err != nil {
+		return err
+	}
+	bundle, ok := ch.(*charm.Bundle)
+	if !ok || bundle.Path == "" {
+		return fmt.Errorf("charm store returned no charm archive")
+	}
+	if err := putFile(m.ctx.Target, ArchivePath(curl), bundle.Path); err != nil {
+		return err
+	}
+	signature, err := m.ctx.Source.Signature(curl)
+	if err != nil {
+		return err
+	}
+	if signature != nil {
+		err := m.ctx.Target.Put(SignaturePath(curl), bytes.NewReader(signature), int64(len(signature)))
+		if err != nil {
+			return err
+		}
+		rev.Signed = true
+	}
+	m.index.add(curl, rev)
+	m.changed = true
+	return nil
+}
+
+// copyBundle copies the bundle at burl and returns
+// the URLs of the charms it uses.
+func (m *mirror) copyBundle(burl *charm.URL) ([]*charm.URL, error) {
+	rev, err := m.info(burl)
+	if err != nil {
+		return nil, err
+	}
+	burl = burl.WithRevision(rev.Revision)
+	bd, err := m.ctx.Source.GetBundle(burl)
+	if err != nil {
+		return nil, err
+	}
+	var services []string
+	for service := range bd.Services {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	var curls []*charm.URL
+	for _, service := range services {
+		curl, err := bd.CharmURL(service)
+		if err != nil {
+			return nil, err
+		}
+		curls = append(curls, curl)
+	}
+	if m.isMirrored(burl) {
+		return curls, nil
+	}
+	if m.ctx.DryRun {
+		logger.Infof("copying %s (dry run)", burl)
+		return curls, nil
+	}
+	logger.Infof("copying %s", burl)
+	data, err := goyaml.Marshal(bd)
+	if err != nil {
+		return nil, err
+	}
+	// The bundle data is stored as marshalled here,
+	// so the digest must be computed again.
+	rev.Sha256, _, err = utils.ReadSHA256(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := m.ctx.Target.Put(ArchivePath(burl), bytes.NewReader(data), int64(len(data))); err != nil {
+		return nil, err
+	}
+	m.index.add(burl, rev)
+	m.changed = true
+	return curls, nil
+}
+
+func putFile(stor storage.StorageWriter, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return stor.Put(name, f, info.Size())
+}
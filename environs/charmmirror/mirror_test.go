@@ -0,0 +1,189 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charmmirror_test
+
+import (
+	"net/http/httptest"
+	stdtesting "testing"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/charm"
+	charmtesting "github.com/juju/core/charm/testing"
+	"github.com/juju/core/environs/charmmirror"
+	"github.com/juju/core/environs/filestorage"
+	"github.com/juju/core/environs/storage"
+	coretesting "github.com/juju/core/testing"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type mirrorSuite struct {
+	coretesting.BaseSuite
+	server *charmtesting.MockStore
+	source *charm.CharmStore
+	target storage.Storage
+}
+
+var _ = gc.Suite(&mirrorSuite{})
+
+const blogBundle = `
+series: precise
+services:
+    wordpress:
+        charm: wordpress
+    mysql:
+        charm: cs:precise/mysql
+relations:
+    - [wordpress, mysql]
+`
+
+func (s *mirrorSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.PatchValue(&charm.CacheDir, c.MkDir())
+	s.server = charmtesting.NewMockStore(c, map[string]int{
+		"cs:precise/wordpress": 3,
+		"cs:precise/mysql":     5,
+		"cs:precise/varnish":   1,
+	})
+	s.server.SetBundle("cs:bundle/blog", 2, []byte(blogBundle))
+	s.source = charm.NewStore(s.server.Address())
+	var err error
+	s.target, err = filestorage.NewFileStorageWriter(c.MkDir())
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *mirrorSuite) TearDownTest(c *gc.C) {
+	s.server.Close()
+	s.BaseSuite.TearDownTest(c)
+}
+
+func (s *mirrorSuite) mirror(c *gc.C, dryRun bool, urls ...string) {
+	err := charmmirror.Mirror(&charmmirror.Context{
+		Source: s.source,
+		Target: s.target,
+		URLs:   urls,
+		DryRun: dryRun,
+	})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *mirrorSuite) TestMirror(c *gc.C) {
+	s.server.SignCharms(c, charmtesting.SigningPrivateKey)
+	s.mirror(c, false, "cs:bundle/blog", "cs:precise/wordpress-2")
+
+	index, err := charmmirror.ReadIndex(s.target)
+	c.Assert(err, gc.IsNil)
+	c.Assert(index.Entries, gc.HasLen, 3)
+	c.Assert(index.Entries["cs:bundle/blog"], gc.HasLen, 1)
+	c.Assert(index.Entries["cs:bundle/blog"][0].Revision, gc.Equals, 2)
+	var revisions []int
+	for _, rev := range index.Entries["cs:precise/wordpress"] {
+		c.Check(rev.Signed, gc.Equals, true)
+		revisions = append(revisions, rev.Revision)
+	}
+	c.Assert(revisions, gc.DeepEquals, []int{2, 3})
+	rev, ok := index.Latest(charm.MustParseURL("cs:precise/mysql"))
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(rev.Revision, gc.Equals, 5)
+
+	names, err := storage.List(s.target, charmmirror.StoragePrefix)
+	c.Assert(err, gc.IsNil)
+	c.Assert(names, gc.DeepEquals, []string{
+		"charm-mirror/bundles/cs_3a_bundle_2f_blog-2.yaml",
+		"charm-mirror/charms/cs_3a_precise_2f_mysql-5.charm",
+		"charm-mirror/charms/cs_3a_precise_2f_mysql-5.charm.asc",
+		"charm-mirror/charms/cs_3a_precise_2f_wordpress-2.charm",
+		"charm-mirror/charms/cs_3a_precise_2f_wordpress-2.charm.asc",
+		"charm-mirror/charms/cs_3a_precise_2f_wordpress-3.charm",
+		"charm-mirror/charms/cs_3a_precise_2f_wordpress-3.charm.asc",
+		"charm-mirror/index.json",
+	})
+}
+
+func (s *mirrorSuite) TestMirrorSkipsMirroredRevisions(c *gc.C) {
+	s.mirror(c, false, "cs:precise/wordpress")
+	c.Assert(s.server.Downloads, gc.HasLen, 1)
+	s.mirror(c, false, "cs:precise/wordpress", "cs:precise/wordpress-3")
+	c.Assert(s.server.Downloads, gc.HasLen, 1)
+
+	s.server.UpdateStoreRevision("cs:precise/wordpress", 4)
+	s.mirror(c, false, "cs:precise/wordpress")
+	c.Assert(s.server.Downloads, gc.HasLen, 2)
+	index, err := charmmirror.ReadIndex(s.target)
+	c.Assert(err, gc.IsNil)
+	c.Assert(index.Entries["cs:precise/wordpress"], gc.HasLen, 2)
+}
+
+func (s *mirrorSuite) TestMirrorResolvesSeries(c *gc.C) {
+	s.mirror(c, false, "cs:varnish")
+	index, err := charmmirror.ReadIndex(s.target)
+	c.Assert(err, gc.IsNil)
+	_, ok := index.Find(charm.MustParseURL("cs:precise/varnish-1"))
+	c.Assert(ok, gc.Equals, true)
+}
+
+func (s *mirrorSuite) TestDryRun(c *gc.C) {
+	s.mirror(c, true, "cs:bundle/blog")
+	c.Assert(s.server.Downloads, gc.HasLen, 0)
+	names, err := storage.List(s.target, "")
+	c.Assert(err, gc.IsNil)
+	c.Assert(names, gc.HasLen, 0)
+	c.Assert(c.GetTestLog(), gc.Matches, `(?s).*copying cs:precise/mysql-5 \(dry run\).*`)
+}
+
+func (s *mirrorSuite) TestMirrorErrors(c *gc.C) {
+	err := charmmirror.Mirror(&charmmirror.Context{
+		Source: s.source,
+		Target: s.target,
+		URLs:   []string{"cs:precise/missing"},
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot mirror charm "cs:precise/missing": charm not found: cs:precise/missing`)
+
+	err = charmmirror.Mirror(&charmmirror.Context{
+		Source: s.source,
+		Target: s.target,
+		URLs:   []string{"cs:bundle/missing"},
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot mirror bundle "cs:bundle/missing": bundle not found: cs:bundle/missing`)
+}
+
+func (s *mirrorSuite) TestServer(c *gc.C) {
+	s.server.SignCharms(c, charmtesting.SigningPrivateKey)
+	s.mirror(c, false, "cs:bundle/blog")
+	s.server.Close()
+
+	httpServer := httptest.NewServer(charmmirror.NewServer(s.target))
+	defer httpServer.Close()
+	mirror := charm.NewStore(httpServer.URL)
+	s.PatchValue(&charm.CacheDir, c.MkDir())
+
+	ref, _, err := charm.ParseReference("cs:wordpress")
+	c.Assert(err, gc.IsNil)
+	curl, err := mirror.Resolve(ref)
+	c.Assert(err, gc.IsNil)
+	c.Assert(curl.String(), gc.Equals, "cs:precise/wordpress")
+
+	ch, err := mirror.Get(curl)
+	c.Assert(err, gc.IsNil)
+	c.Assert(ch.Meta().Name, gc.Equals, "dummy")
+	signature, err := mirror.Signature(curl.WithRevision(3))
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(signature), gc.Matches, "(?s)-----BEGIN PGP SIGNATURE-----.*")
+
+	bd, err := mirror.GetBundle(charm.MustParseURL("cs:bundle/blog"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(bd.Services, gc.HasLen, 2)
+
+	infos, err := mirror.Info(charm.MustParseURL("cs:precise/varnish"), charm.MustParseURL("cs:bundle/blog"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(infos[0].Errors, gc.DeepEquals, []string{"charm not found: cs:precise/varnish"})
+	c.Assert(infos[1].Errors, gc.DeepEquals, []string{"charm not found: cs:bundle/blog"})
+
+	signature, err = mirror.Signature(charm.MustParseURL("cs:precise/varnish-1"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(signature, gc.IsNil)
+}
@@ -0,0 +1,167 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charmmirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/environs/storage"
+)
+
+// Server is a read-only charm store serving the content of a mirror.
+// It implements enough of the charm store API for a *charm.CharmStore
+// with the server's URL as its BaseURL to get charms, their
+// signatures and bundles from it.
+type Server struct {
+	stor storage.StorageReader
+	mux  *http.ServeMux
+}
+
+// NewServer returns a new *Server serving the mirror held in stor.
+// The index of the mirror is read again for every request, so
+// changes to the mirror are served without restarting.
+func NewServer(stor storage.StorageReader) *Server {
+	s := &Server{
+		stor: stor,
+		mux:  http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/charm-info", func(w http.ResponseWriter, r *http.Request) {
+		s.serveInfo(w, r, "charm")
+	})
+	s.mux.HandleFunc("/bundle-info", func(w http.ResponseWriter, r *http.Request) {
+		s.serveInfo(w, r, "bundle")
+	})
+	s.mux.HandleFunc("/charm/", func(w http.ResponseWriter, r *http.Request) {
+		s.serveContent(w, r, "/charm/", ArchivePath, "application/octet-stream")
+	})
+	s.mux.HandleFunc("/charm-signature/", func(w http.ResponseWriter, r *http.Request) {
+		s.serveContent(w, r, "/charm-signature/", SignaturePath, "application/pgp-signature")
+	})
+	s.mux.HandleFunc("/bundle/", func(w http.ResponseWriter, r *http.Request) {
+		s.serveContent(w, r, "/bundle/", ArchivePath, "application/x-yaml")
+	})
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// resolveURL parses url as a charm or bundle URL. When the series
+// is missing, the alphabetically last series under which the charm
+// is mirrored is used.
+func resolveURL(index *Index, url string) (*charm.URL, error) {
+	ref, series, err := charm.ParseReference(url)
+	if err != nil {
+		return nil, err
+	}
+	var revision int
+	if series == "" {
+		ref.Revision, revision = -1, ref.Revision
+		var candidates []string
+		for key := range index.Entries {
+			curl, err := charm.ParseURL(key)
+			if err == nil && curl.Reference == ref {
+				candidates = append(candidates, curl.Series)
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, errNotFound
+		}
+		sort.Strings(candidates)
+		series = candidates[len(candidates)-1]
+		ref.Revision = revision
+	}
+	return &charm.URL{Reference: ref, Series: series}, nil
+}
+
+var errNotFound = fmt.Errorf("entry not found")
+
+// serveInfo answers charm-info and bundle-info requests.
+func (s *Server) serveInfo(w http.ResponseWriter, r *http.Request, kind string) {
+	index, err := ReadIndex(s.stor)
+	if err != nil {
+		logger.Errorf("cannot read charm mirror index: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	r.ParseForm()
+	response := map[string]*charm.InfoResponse{}
+	for _, url := range r.Form[kind+"s"] {
+		info := &charm.InfoResponse{}
+		response[url] = info
+		curl, err := resolveURL(index, url)
+		if err == nil && (curl.Series == charm.BundleSeries) != (kind == "bundle") {
+			err = errNotFound
+		}
+		if err != nil {
+			info.Errors = append(info.Errors, err.Error())
+			continue
+		}
+		rev, ok := index.Find(curl)
+		if !ok {
+			info.Errors = append(info.Errors, errNotFound.Error())
+			continue
+		}
+		info.CanonicalURL = curl.String()
+		info.Revision = rev.Revision
+		info.Sha256 = rev.Sha256
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		logger.Errorf("cannot marshal %s info: %v", kind, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		logger.Errorf("cannot write content: %v", err)
+	}
+}
+
+// serveContent serves the file at the storage path returned by
+// storagePath for the charm or bundle whose path follows prefix
+// in the request URL.
+func (s *Server) serveContent(w http.ResponseWriter, r *http.Request, prefix string, storagePath func(*charm.URL) string, contentType string) {
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	curl, err := charm.ParseURL("cs:" + r.URL.Path[len(prefix):])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if curl.Revision == -1 {
+		index, err := ReadIndex(s.stor)
+		if err != nil {
+			logger.Errorf("cannot read charm mirror index: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rev, ok := index.Latest(curl)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		curl = curl.WithRevision(rev.Revision)
+	}
+	content, err := storage.Get(s.stor, storagePath(curl))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	defer content.Close()
+	w.Header().Set("Content-Type", contentType)
+	if _, err := io.Copy(w, content); err != nil {
+		logger.Errorf("cannot write content: %v", err)
+	}
+}
@@ -115,6 +115,9 @@ func FinishMachineConfig(mcfg *cloudinit.MachineConfig, cfg *config.Config, cons
 	); err != nil {
 		return err
 	}
+	if cfg.PreferIPv6() {
+		mcfg.AgentEnvironment[agent.PreferIPv6] = "true"
+	}
 
 	// The following settings are only appropriate at bootstrap time. At the
 	// moment, the only state server is the bootstrap node, but this
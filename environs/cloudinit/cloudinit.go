@@ -122,7 +122,9 @@ type MachineConfig struct {
 	// Config holds the initial environment configuration.
 	Config *config.Config
 
-	// Constraints holds the initial environment constraints.
+	// Constraints holds the initial environment constraints. For a
+	// container, it holds the machine's constraints, which the container
+	// manager uses to size and limit the container.
 	Constraints constraints.Value
 
 	// DisableSSLHostnameVerification can be set to true to tell cloud-init
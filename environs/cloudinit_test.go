@@ -92,6 +92,26 @@ func (s *CloudInitSuite) TestFinishMachineConfigNonDefault(c *gc.C) {
 	})
 }
 
+func (s *CloudInitSuite) TestFinishMachineConfigPreferIPv6(c *gc.C) {
+	attrs := dummySampleConfig().Merge(testing.Attrs{
+		"authorized-keys": "we-are-the-keys",
+		"prefer-ipv6":     true,
+	})
+	cfg, err := config.New(config.NoDefaults, attrs)
+	c.Assert(err, gc.IsNil)
+	mcfg := &cloudinit.MachineConfig{
+		StateInfo: &state.Info{Tag: "not touched"},
+		APIInfo:   &api.Info{Tag: "not touched"},
+	}
+	err = environs.FinishMachineConfig(mcfg, cfg, constraints.Value{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(mcfg.AgentEnvironment, gc.DeepEquals, map[string]string{
+		agent.ProviderType:  "dummy",
+		agent.ContainerType: "",
+		agent.PreferIPv6:    "true",
+	})
+}
+
 func (s *CloudInitSuite) TestFinishBootstrapConfig(c *gc.C) {
 	attrs := dummySampleConfig().Merge(testing.Attrs{
 		"authorized-keys": "we-are-the-keys",
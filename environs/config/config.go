@@ -6,6 +6,7 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -13,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"code.google.com/p/go.crypto/openpgp"
 	"github.com/juju/errors"
 	"github.com/juju/loggo"
 
@@ -36,6 +38,19 @@ const (
 	// port opened.
 	FwGlobal = "global"
 
+	// CharmVerificationStrict refuses charm archives that aren't
+	// signed by one of the environment's trusted keys.
+	CharmVerificationStrict = "strict"
+
+	// CharmVerificationWarn accepts charm archives that aren't
+	// signed by one of the environment's trusted keys, but logs
+	// a warning about them.
+	CharmVerificationWarn = "warn"
+
+	// CharmVerificationOff disables the verification of charm
+	// archive signatures.
+	CharmVerificationOff = "off"
+
 	// DefaultStatePort is the default port the state server is listening on.
 	DefaultStatePort int = 37017
 
@@ -335,6 +350,29 @@ func Validate(cfg, old *Config) error {
 		}
 	}
 
+	// Check charm verification mode and the keys it trusts.
+	switch mode := cfg.CharmVerification(); mode {
+	case CharmVerificationStrict, CharmVerificationWarn, CharmVerificationOff:
+	default:
+		return fmt.Errorf("invalid charm verification mode in environment configuration: %q", mode)
+	}
+	if keys, ok := cfg.CharmTrustedKeys(); ok {
+		if _, err := openpgp.ReadArmoredKeyRing(strings.NewReader(keys)); err != nil {
+			return fmt.Errorf("invalid charm trusted keys in environment configuration: %v", err)
+		}
+	} else if cfg.CharmVerification() == CharmVerificationStrict {
+		return fmt.Errorf("strict charm verification requires charm-trusted-keys")
+	}
+
+	if storeURL, ok := cfg.CharmStoreURL(); ok {
+		if u, err := url.Parse(storeURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid charm store URL in environment configuration: %q", storeURL)
+		}
+	}
+	if port, ok := cfg.CharmMirrorPort(); ok && (port <= 0 || port > 65535) {
+		return fmt.Errorf("invalid charm mirror port in environment configuration: %d", port)
+	}
+
 	// Ensure that the auth token is a set of key=value pairs.
 	authToken, _ := cfg.CharmStoreAuth()
 	validAuthToken := regexp.MustCompile(`^([^\s=]+=[^\s=]+(,\s*)?)*$`)
@@ -684,6 +722,47 @@ func (c *Config) CharmStoreAuth() (string, bool) {
 	return auth, auth != ""
 }
 
+// CharmStoreURL returns the URL of the charm store used by the
+// environment in place of the public one, such as a charm mirror,
+// and whether it has been set.
+func (c *Config) CharmStoreURL() (string, bool) {
+	storeURL := c.asString("charm-store-url")
+	return storeURL, storeURL != ""
+}
+
+// CharmMirrorPort returns the port on which state servers serve
+// the charm mirror held in the environment's storage, and whether
+// it has been set. The mirror is not served when it is unset.
+func (c *Config) CharmMirrorPort() (int, bool) {
+	port, ok := c.defined["charm-mirror-port"].(int)
+	return port, ok
+}
+
+// CharmVerification returns how signatures of charm archives
+// are verified: CharmVerificationStrict, CharmVerificationWarn or
+// CharmVerificationOff, which is the default.
+func (c *Config) CharmVerification() string {
+	if mode, _ := c.defined["charm-verification"].(string); mode != "" {
+		return mode
+	}
+	return CharmVerificationOff
+}
+
+// CharmTrustedKeys returns the armored OpenPGP public keyring holding
+// the keys trusted to sign charm archives, and whether it has been set.
+func (c *Config) CharmTrustedKeys() (string, bool) {
+	keys := c.asString("charm-trusted-keys")
+	return keys, keys != ""
+}
+
+// MetricsPassword returns the password required to read the
+// API server metrics, and whether it has been set. Metrics
+// cannot be read when it is unset.
+func (c *Config) MetricsPassword() (string, bool) {
+	password := c.asString("metrics-password")
+	return password, password != ""
+}
+
 // ProvisionerSafeMode reports whether the provisioner should not
 // destroy machines it does not know about.
 func (c *Config) ProvisionerSafeMode() bool {
@@ -723,6 +802,13 @@ func (c *Config) LXCUseCloneAUFS() (bool, bool) {
 	return v, ok
 }
 
+// KVMUseClone reports whether the KVM provisioner should create a
+// template image and start containers from copy-on-write clones of it.
+func (c *Config) KVMUseClone() (bool, bool) {
+	v, ok := c.defined["kvm-clone"].(bool)
+	return v, ok
+}
+
 // UnknownAttrs returns a copy of the raw configuration attributes
 // that are supposedly specific to the environment type. They could
 // also be wrong attributes, though. Only the specific environment
@@ -762,6 +848,14 @@ func (c *Config) Apply(attrs map[string]interface{}) (*Config, error) {
 	return New(NoDefaults, defined)
 }
 
+// PreferIPv6 reports whether IPv6 addresses should be preferred over
+// IPv4 addresses when selecting machine addresses, and whether state
+// servers should listen on IPv6.
+func (c *Config) PreferIPv6() bool {
+	v, _ := c.defined["prefer-ipv6"].(bool)
+	return v
+}
+
 var fields = schema.Fields{
 	"type":                      schema.String(),
 	"name":                      schema.String(),
@@ -801,6 +895,13 @@ var fields = schema.Fields{
 	"proxy-ssh":                 schema.Bool(),
 	"lxc-clone":                 schema.Bool(),
 	"lxc-clone-aufs":            schema.Bool(),
+	"kvm-clone":                 schema.Bool(),
+	"prefer-ipv6":               schema.Bool(),
+	"metrics-password":          schema.String(),
+	"charm-verification":        schema.String(),
+	"charm-trusted-keys":        schema.String(),
+	"charm-store-url":           schema.String(),
+	"charm-mirror-port":         schema.ForceInt(),
 
 	// Deprecated fields, retain for backwards compatibility.
 	"tools-url":     schema.String(),
@@ -836,6 +937,13 @@ var alwaysOptional = schema.Defaults{
 	"apt-https-proxy":           schema.Omit,
 	"apt-ftp-proxy":             schema.Omit,
 	"lxc-clone":                 schema.Omit,
+	"kvm-clone":                 schema.Omit,
+	"prefer-ipv6":               schema.Omit,
+	"metrics-password":          schema.Omit,
+	"charm-verification":        schema.Omit,
+	"charm-trusted-keys":        schema.Omit,
+	"charm-store-url":           schema.Omit,
+	"charm-mirror-port":         schema.Omit,
 
 	// Deprecated fields, retain for backwards compatibility.
 	"tools-url":     "",
@@ -927,6 +1035,8 @@ var immutableAttributes = []string{
 	"bootstrap-addresses-delay",
 	"lxc-clone",
 	"lxc-clone-aufs",
+	"kvm-clone",
+	"prefer-ipv6",
 	"syslog-port",
 }
 
@@ -981,8 +1091,16 @@ type Specializer interface {
 }
 
 // SpecializeCharmRepo returns a repository customized for given configuration.
-// It adds authentication if necessary and sets a charm store's testMode flag.
+// It points a charm store at the configured charm store URL, adds
+// authentication if necessary and sets a charm store's testMode flag.
 func SpecializeCharmRepo(repo charm.Repository, cfg *Config) charm.Repository {
+	if storeURL, ok := cfg.CharmStoreURL(); ok {
+		if store, isStore := repo.(*charm.CharmStore); isStore {
+			mirror := *store
+			mirror.BaseURL = strings.TrimRight(storeURL, "/")
+			repo = &mirror
+		}
+	}
 	// If a charm store auth token is set, pass it on to the charm store
 	if auth, authSet := cfg.CharmStoreAuth(); authSet {
 		if CS, isCS := repo.(Specializer); isCS {
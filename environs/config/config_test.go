@@ -14,6 +14,8 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/core/cert"
+	"github.com/juju/core/charm"
+	charmtesting "github.com/juju/core/charm/testing"
 	"github.com/juju/core/environs/config"
 	"github.com/juju/core/juju/osenv"
 	"github.com/juju/core/schema"
@@ -150,6 +152,22 @@ var configTests = []configTest{
 			"lxc-clone":      true,
 			"lxc-clone-aufs": true,
 		},
+	}, {
+		about:       "KVM clone value",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":      "my-type",
+			"name":      "my-name",
+			"kvm-clone": true,
+		},
+	}, {
+		about:       "Prefer IPv6",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":        "my-type",
+			"name":        "my-name",
+			"prefer-ipv6": true,
+		},
 	}, {
 		about:       "Deprecated lxc-use-clone used",
 		useDefaults: config.UseDefaults,
@@ -1026,6 +1044,18 @@ func (test configTest) check(c *gc.C, home *testing.FakeHome) {
 	} else {
 		c.Assert(useLxcCloneAufs, gc.Equals, false)
 	}
+	useKvmClone, useKvmClonePresent := cfg.KVMUseClone()
+	if v, ok := test.attrs["kvm-clone"]; ok {
+		c.Assert(useKvmClone, gc.Equals, v)
+		c.Assert(useKvmClonePresent, jc.IsTrue)
+	} else {
+		c.Assert(useKvmClonePresent, jc.IsFalse)
+	}
+	if v, ok := test.attrs["prefer-ipv6"]; ok {
+		c.Assert(cfg.PreferIPv6(), gc.Equals, v)
+	} else {
+		c.Assert(cfg.PreferIPv6(), jc.IsFalse)
+	}
 }
 
 func (test configTest) assertDuration(c *gc.C, name string, actual time.Duration, defaultInSeconds int) {
@@ -1170,6 +1200,16 @@ var validationTests = []validationTest{{
 	old:   testing.Attrs{"lxc-clone-aufs": false},
 	new:   testing.Attrs{"lxc-clone-aufs": true},
 	err:   `cannot change lxc-clone-aufs from false to true`,
+}, {
+	about: "Cannot change kvm-clone",
+	old:   testing.Attrs{"kvm-clone": false},
+	new:   testing.Attrs{"kvm-clone": true},
+	err:   `cannot change kvm-clone from false to true`,
+}, {
+	about: "Cannot change prefer-ipv6",
+	old:   testing.Attrs{"prefer-ipv6": false},
+	new:   testing.Attrs{"prefer-ipv6": true},
+	err:   `cannot change prefer-ipv6 from false to true`,
 }}
 
 func (s *ConfigSuite) TestValidateChange(c *gc.C) {
@@ -1274,6 +1314,99 @@ func (s *ConfigSuite) TestLoggingConfigFromEnvironment(c *gc.C) {
 	c.Assert(config.LoggingConfig(), gc.Equals, "<root>=INFO;unit=DEBUG")
 }
 
+func (s *ConfigSuite) TestMetricsPassword(c *gc.C) {
+	s.addJujuFiles(c)
+	config := newTestConfig(c, nil)
+	password, ok := config.MetricsPassword()
+	c.Assert(ok, jc.IsFalse)
+	c.Assert(password, gc.Equals, "")
+
+	config = newTestConfig(c, testing.Attrs{"metrics-password": "s3cret"})
+	password, ok = config.MetricsPassword()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(password, gc.Equals, "s3cret")
+}
+
+func (s *ConfigSuite) TestCharmVerification(c *gc.C) {
+	s.addJujuFiles(c)
+	cfg := newTestConfig(c, nil)
+	c.Assert(cfg.CharmVerification(), gc.Equals, config.CharmVerificationOff)
+	keys, ok := cfg.CharmTrustedKeys()
+	c.Assert(ok, jc.IsFalse)
+	c.Assert(keys, gc.Equals, "")
+
+	cfg = newTestConfig(c, testing.Attrs{
+		"charm-verification": "strict",
+		"charm-trusted-keys": charmtesting.SigningPublicKey,
+	})
+	c.Assert(cfg.CharmVerification(), gc.Equals, config.CharmVerificationStrict)
+	keys, ok = cfg.CharmTrustedKeys()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(keys, gc.Equals, charmtesting.SigningPublicKey)
+
+	cfg = newTestConfig(c, testing.Attrs{"charm-verification": "warn"})
+	c.Assert(cfg.CharmVerification(), gc.Equals, config.CharmVerificationWarn)
+}
+
+func (s *ConfigSuite) TestCharmVerificationErrors(c *gc.C) {
+	s.addJujuFiles(c)
+	for i, test := range []struct {
+		attrs testing.Attrs
+		err   string
+	}{{
+		attrs: testing.Attrs{"charm-verification": "sometimes"},
+		err:   `invalid charm verification mode in environment configuration: "sometimes"`,
+	}, {
+		attrs: testing.Attrs{"charm-verification": "strict"},
+		err:   "strict charm verification requires charm-trusted-keys",
+	}, {
+		attrs: testing.Attrs{"charm-trusted-keys": "not a key"},
+		err:   "invalid charm trusted keys in environment configuration: .*",
+	}} {
+		c.Logf("test %d", i)
+		attrs := testing.Attrs{"type": "my-type", "name": "my-name"}.Merge(test.attrs)
+		_, err := config.New(config.UseDefaults, attrs)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *ConfigSuite) TestCharmStoreURL(c *gc.C) {
+	s.addJujuFiles(c)
+	cfg := newTestConfig(c, nil)
+	_, ok := cfg.CharmStoreURL()
+	c.Assert(ok, jc.IsFalse)
+	repo := config.SpecializeCharmRepo(charm.Store, cfg)
+	c.Assert(repo.(*charm.CharmStore).BaseURL, gc.Equals, charm.Store.BaseURL)
+
+	cfg = newTestConfig(c, testing.Attrs{"charm-store-url": "http://10.0.0.1:8080/"})
+	storeURL, ok := cfg.CharmStoreURL()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(storeURL, gc.Equals, "http://10.0.0.1:8080/")
+	repo = config.SpecializeCharmRepo(charm.Store, cfg)
+	c.Assert(repo.(*charm.CharmStore).BaseURL, gc.Equals, "http://10.0.0.1:8080")
+	c.Assert(charm.Store.BaseURL, gc.Equals, "https://store.juju.ubuntu.com")
+
+	attrs := testing.Attrs{"type": "my-type", "name": "my-name", "charm-store-url": "10.0.0.1"}
+	_, err := config.New(config.UseDefaults, attrs)
+	c.Assert(err, gc.ErrorMatches, `invalid charm store URL in environment configuration: "10.0.0.1"`)
+}
+
+func (s *ConfigSuite) TestCharmMirrorPort(c *gc.C) {
+	s.addJujuFiles(c)
+	cfg := newTestConfig(c, nil)
+	_, ok := cfg.CharmMirrorPort()
+	c.Assert(ok, jc.IsFalse)
+
+	cfg = newTestConfig(c, testing.Attrs{"charm-mirror-port": 8081})
+	port, ok := cfg.CharmMirrorPort()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(port, gc.Equals, 8081)
+
+	attrs := testing.Attrs{"type": "my-type", "name": "my-name", "charm-mirror-port": 70000}
+	_, err := config.New(config.UseDefaults, attrs)
+	c.Assert(err, gc.ErrorMatches, `invalid charm mirror port in environment configuration: 70000`)
+}
+
 func (s *ConfigSuite) TestProxyValuesWithFallback(c *gc.C) {
 	s.addJujuFiles(c)
 
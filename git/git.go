@@ -0,0 +1,176 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package git offers an interface to manage repositories of the Git VCS.
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// Repository represents a Git repository with a working tree.
+type Repository struct {
+	location string
+	env      []string
+}
+
+// New returns a new Repository for the Git working tree at location.
+func New(location string) *Repository {
+	r := &Repository{location, cenv()}
+	if _, err := os.Stat(location); err == nil {
+		stdout, err := r.git("rev-parse", "--show-toplevel")
+		if err == nil {
+			r.location = strings.TrimRight(string(stdout), "\n")
+		}
+	}
+	return r
+}
+
+// cenv returns a copy of the current process environment with LC_ALL=C.
+func cenv() []string {
+	env := os.Environ()
+	for i, pair := range env {
+		if strings.HasPrefix(pair, "LC_ALL=") {
+			env[i] = "LC_ALL=C"
+			return env
+		}
+	}
+	return append(env, "LC_ALL=C")
+}
+
+// Location returns the location of the working tree of r.
+func (r *Repository) Location() string {
+	return r.location
+}
+
+// Join returns r's location with parts appended as path components.
+func (r *Repository) Join(parts ...string) string {
+	return path.Join(append([]string{r.location}, parts...)...)
+}
+
+func (r *Repository) git(subcommand string, args ...string) (stdout []byte, err error) {
+	cmd := exec.Command("git", append([]string{subcommand}, args...)...)
+	if _, err := os.Stat(r.location); err == nil {
+		cmd.Dir = r.location
+	}
+	errbuf := &bytes.Buffer{}
+	cmd.Stderr = errbuf
+	cmd.Env = r.env
+	stdout, err = cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf(`error running "git %s": %s%s%s`, subcommand, stdout, errbuf.Bytes(), err)
+	}
+	return stdout, nil
+}
+
+// Init initializes a new repository at r's location.
+func (r *Repository) Init() error {
+	_, err := r.git("init", "-q", r.location)
+	return err
+}
+
+// Add adds to r the path resultant from calling r.Join(parts...).
+func (r *Repository) Add(parts ...string) error {
+	_, err := r.git("add", r.Join(parts...))
+	return err
+}
+
+// Commit commits pending changes into r.
+func (r *Repository) Commit(message string) error {
+	_, err := r.git("commit", "-q", "-m", message)
+	return err
+}
+
+// RevisionId returns the commit id of the head of r.
+func (r *Repository) RevisionId() (string, error) {
+	if _, err := r.git("rev-parse", "-q", "--verify", "HEAD^{commit}"); err != nil {
+		return "", fmt.Errorf("repository has no content")
+	}
+	stdout, err := r.git("rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(stdout)), nil
+}
+
+// PushLocation returns the location that r is pushed to by default,
+// which is the push URL of its "origin" remote.
+func (r *Repository) PushLocation() (string, error) {
+	for _, key := range []string{"remote.origin.pushurl", "remote.origin.url"} {
+		// git config fails when the key is not set.
+		stdout, err := r.git("config", "--get", key)
+		if err == nil {
+			return strings.TrimSpace(string(stdout)), nil
+		}
+	}
+	return "", fmt.Errorf("no push location defined")
+}
+
+// FetchLocation returns the location that r is fetched from by
+// default, which is the URL of its "origin" remote.
+func (r *Repository) FetchLocation() (string, error) {
+	stdout, err := r.git("config", "--get", "remote.origin.url")
+	if err != nil {
+		return "", fmt.Errorf("no fetch location defined")
+	}
+	return strings.TrimSpace(string(stdout)), nil
+}
+
+// PushAttr holds options for the Repository.Push method.
+type PushAttr struct {
+	Location string // Location to push to. Use the default push location if empty.
+	Remember bool   // Whether to remember the location being pushed to as the default.
+}
+
+// Push pushes the current branch of r to attr.Location if that's
+// provided, or to the default push location otherwise. As with
+// Bazaar, the location is remembered as the default if there is
+// none yet. See PushAttr for other options.
+func (r *Repository) Push(attr *PushAttr) error {
+	location := "origin"
+	if attr != nil && attr.Location != "" {
+		current, err := r.PushLocation()
+		switch {
+		case err == nil && current == attr.Location:
+			// Pushing to the default push location leaves the
+			// configuration of origin alone.
+		case attr.Remember || err != nil:
+			if err := r.setPushLocation(attr.Location); err != nil {
+				return err
+			}
+		default:
+			location = attr.Location
+		}
+	}
+	_, err := r.git("push", "-q", location, "HEAD")
+	return err
+}
+
+// setPushLocation makes location the default push location of r. If r
+// already has an "origin" remote, only its push URL is changed, so the
+// location it is fetched from is preserved.
+func (r *Repository) setPushLocation(location string) error {
+	if _, err := r.git("config", "--get", "remote.origin.url"); err != nil {
+		_, err := r.git("remote", "add", "origin", location)
+		return err
+	}
+	_, err := r.git("config", "remote.origin.pushurl", location)
+	return err
+}
+
+// CheckClean returns an error if 'git status' is not clean.
+func (r *Repository) CheckClean() error {
+	stdout, err := r.git("status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	if len(stdout) > 0 {
+		return fmt.Errorf("repository is not clean (git status)")
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package git_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	stdtesting "testing"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/git"
+	"github.com/juju/core/testing"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+var _ = gc.Suite(&GitSuite{})
+
+type GitSuite struct {
+	testing.GitSuite
+	r *git.Repository
+}
+
+func (s *GitSuite) SetUpTest(c *gc.C) {
+	s.GitSuite.SetUpTest(c)
+	s.r = git.New(c.MkDir())
+	c.Assert(s.r.Init(), gc.IsNil)
+}
+
+// addFile creates the named file in r and commits it.
+func addFile(c *gc.C, r *git.Repository, name string) {
+	f, err := os.Create(r.Join(name))
+	c.Assert(err, gc.IsNil)
+	f.Close()
+	err = r.Add(name)
+	c.Assert(err, gc.IsNil)
+	err = r.Commit("added " + name)
+	c.Assert(err, gc.IsNil)
+}
+
+// bareRepository returns the location of a new repository without
+// a working tree, that can be pushed to.
+func bareRepository(c *gc.C) string {
+	dir := c.MkDir()
+	output, err := exec.Command("git", "init", "-q", "--bare", dir).CombinedOutput()
+	c.Assert(err, gc.IsNil, gc.Commentf("%s", output))
+	return dir
+}
+
+// headOf returns the commit id of the head of the repository at dir.
+func headOf(c *gc.C, dir string) string {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	c.Assert(err, gc.IsNil)
+	return strings.TrimSpace(string(output))
+}
+
+func (s *GitSuite) TestNewFindsRoot(c *gc.C) {
+	err := os.Mkdir(s.r.Join("dir"), 0755)
+	c.Assert(err, gc.IsNil)
+	r := git.New(s.r.Join("dir"))
+	path, err := filepath.EvalSymlinks(s.r.Location())
+	c.Assert(err, gc.IsNil)
+	c.Assert(r.Location(), gc.Equals, path)
+}
+
+func (s *GitSuite) TestJoin(c *gc.C) {
+	path := git.New("/foo").Join("baz", "bar")
+	c.Assert(path, gc.Equals, "/foo/baz/bar")
+}
+
+func (s *GitSuite) TestErrorHandling(c *gc.C) {
+	err := git.New(s.r.Location()).Add("non-existent")
+	c.Assert(err, gc.ErrorMatches, `(?s)error running "git add":.*did not match any files.*`)
+}
+
+func (s *GitSuite) TestInit(c *gc.C) {
+	_, err := os.Stat(s.r.Join(".git"))
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *GitSuite) TestRevisionIdOnEmpty(c *gc.C) {
+	revid, err := s.r.RevisionId()
+	c.Assert(err, gc.ErrorMatches, "repository has no content")
+	c.Assert(revid, gc.Equals, "")
+}
+
+func (s *GitSuite) TestCommit(c *gc.C) {
+	addFile(c, s.r, "myfile")
+
+	revid, err := s.r.RevisionId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revid, gc.Matches, "[0-9a-f]{40}")
+
+	cmd := exec.Command("git", "log", "--name-only", "--format=%H %s")
+	cmd.Dir = s.r.Location()
+	output, err := cmd.CombinedOutput()
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(output), gc.Equals, revid+" added myfile\n\nmyfile\n")
+}
+
+func (s *GitSuite) TestPush(c *gc.C) {
+	r2 := bareRepository(c)
+	r3 := bareRepository(c)
+	addFile(c, s.r, "file")
+	revid, err := s.r.RevisionId()
+	c.Assert(err, gc.IsNil)
+
+	_, err = s.r.PushLocation()
+	c.Assert(err, gc.ErrorMatches, "no push location defined")
+
+	// Push to r2.
+	err = s.r.Push(&git.PushAttr{Location: r2})
+	c.Assert(err, gc.IsNil)
+
+	// Push location should be set to r2.
+	location, err := s.r.PushLocation()
+	c.Assert(err, gc.IsNil)
+	c.Assert(location, gc.Equals, r2)
+
+	// Now push it to r3.
+	err = s.r.Push(&git.PushAttr{Location: r3})
+	c.Assert(err, gc.IsNil)
+
+	// Push location is still set to r2.
+	location, err = s.r.PushLocation()
+	c.Assert(err, gc.IsNil)
+	c.Assert(location, gc.Equals, r2)
+
+	// Push it again, this time with the remember flag set.
+	err = s.r.Push(&git.PushAttr{Location: r3, Remember: true})
+	c.Assert(err, gc.IsNil)
+
+	// Now the push location has shifted to r3.
+	location, err = s.r.PushLocation()
+	c.Assert(err, gc.IsNil)
+	c.Assert(location, gc.Equals, r3)
+
+	// Both r2 and r3 should have the commit.
+	c.Assert(headOf(c, r2), gc.Equals, revid)
+	c.Assert(headOf(c, r3), gc.Equals, revid)
+
+	// Pushing without a location uses the push location.
+	addFile(c, s.r, "another")
+	err = s.r.Push(nil)
+	c.Assert(err, gc.IsNil)
+	revid, err = s.r.RevisionId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(headOf(c, r3), gc.Equals, revid)
+}
+
+func (s *GitSuite) TestPushPreservesFetchLocation(c *gc.C) {
+	fetch := bareRepository(c)
+	push := bareRepository(c)
+	addFile(c, s.r, "file")
+
+	_, err := s.r.FetchLocation()
+	c.Assert(err, gc.ErrorMatches, "no fetch location defined")
+	err = s.r.Push(&git.PushAttr{Location: fetch})
+	c.Assert(err, gc.IsNil)
+
+	// Remembering another location only changes the push location.
+	err = s.r.Push(&git.PushAttr{Location: push, Remember: true})
+	c.Assert(err, gc.IsNil)
+	location, err := s.r.PushLocation()
+	c.Assert(err, gc.IsNil)
+	c.Assert(location, gc.Equals, push)
+	location, err = s.r.FetchLocation()
+	c.Assert(err, gc.IsNil)
+	c.Assert(location, gc.Equals, fetch)
+
+	// Pushing to the push location again leaves both alone.
+	addFile(c, s.r, "another")
+	err = s.r.Push(&git.PushAttr{Location: push, Remember: true})
+	c.Assert(err, gc.IsNil)
+	location, err = s.r.PushLocation()
+	c.Assert(err, gc.IsNil)
+	c.Assert(location, gc.Equals, push)
+	location, err = s.r.FetchLocation()
+	c.Assert(err, gc.IsNil)
+	c.Assert(location, gc.Equals, fetch)
+
+	revid, err := s.r.RevisionId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(headOf(c, push), gc.Equals, revid)
+}
+
+func (s *GitSuite) TestCheckClean(c *gc.C) {
+	err := s.r.CheckClean()
+	c.Assert(err, gc.IsNil)
+
+	f, err := os.Create(s.r.Join("file"))
+	c.Assert(err, gc.IsNil)
+	f.Close()
+
+	err = s.r.CheckClean()
+	c.Assert(err, gc.ErrorMatches, `repository is not clean \(git status\)`)
+}
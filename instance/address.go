@@ -17,6 +17,11 @@ var (
 	classCPrivate = mustParseCIDR("192.168.0.0/16")
 )
 
+// Unique local addresses for IPv6, the equivalent of the IPv4 private
+// network ranges.
+// See: http://tools.ietf.org/html/rfc4193
+var ipv6UniqueLocal = mustParseCIDR("fc00::/7")
+
 func mustParseCIDR(s string) *net.IPNet {
 	_, net, err := net.ParseCIDR(s)
 	if err != nil {
@@ -46,8 +51,27 @@ const (
 	NetworkPublic       NetworkScope = "public"
 	NetworkCloudLocal   NetworkScope = "local-cloud"
 	NetworkMachineLocal NetworkScope = "local-machine"
+	NetworkLinkLocal    NetworkScope = "link-local"
 )
 
+// preferIPv6 determines whether IPv6 addresses are selected in
+// preference to IPv4 addresses and host names.
+var preferIPv6 = false
+
+// SetPreferIPv6 determines whether the address selection functions
+// select IPv6 addresses, preferring them to IPv4 addresses and host
+// names when they are equally appropriate. When false, IPv6 addresses
+// are never selected.
+func SetPreferIPv6(prefer bool) {
+	preferIPv6 = prefer
+}
+
+// PreferIPv6 reports whether IPv6 addresses are selected in preference
+// to other addresses.
+func PreferIPv6() bool {
+	return preferIPv6
+}
+
 // Address represents the location of a machine, including metadata about what
 // kind of location the address describes.
 type Address struct {
@@ -158,7 +182,15 @@ func deriveNetworkScope(addr Address) NetworkScope {
 		// network address, then it's publicly routable.
 		return NetworkPublic
 	case Ipv6Address:
-		// TODO(axw) check for IPv6 unique local address, if/when we care.
+		// Link-local addresses are only reachable from the same
+		// network segment, so they're not useful for anything.
+		if ip.IsLinkLocalUnicast() {
+			return NetworkLinkLocal
+		}
+		if ipv6UniqueLocal.Contains(ip) {
+			return NetworkCloudLocal
+		}
+		return NetworkPublic
 	}
 	return addr.NetworkScope
 }
@@ -274,22 +306,40 @@ const (
 // bestAddressIndex returns the index of the first address
 // with an exactly matching scope, or the first address with
 // a matching fallback scope if there are no exact matches.
+// IPv6 addresses are only considered when IPv6 is preferred,
+// and are then chosen over other addresses that match equally
+// well.
 // If there are no suitable addresses, -1 is returned.
 func bestAddressIndex(numAddr int, getAddr func(i int) Address, match func(addr Address) scopeMatch) int {
-	fallbackAddressIndex := -1
+	// The first exact and fallback matches, indexed by whether
+	// the address belongs to the preferred family (0) or not (1).
+	exactIndex := [2]int{-1, -1}
+	fallbackIndex := [2]int{-1, -1}
 	for i := 0; i < numAddr; i++ {
 		addr := getAddr(i)
-		if addr.Type != Ipv6Address {
-			switch match(addr) {
-			case exactScope:
-				return i
-			case fallbackScope:
-				// Use the first fallback address if there are no exact matches.
-				if fallbackAddressIndex == -1 {
-					fallbackAddressIndex = i
-				}
+		isIPv6 := addr.Type == Ipv6Address
+		if isIPv6 && !preferIPv6 {
+			continue
+		}
+		family := 0
+		if isIPv6 != preferIPv6 {
+			family = 1
+		}
+		switch match(addr) {
+		case exactScope:
+			if exactIndex[family] == -1 {
+				exactIndex[family] = i
 			}
+		case fallbackScope:
+			if fallbackIndex[family] == -1 {
+				fallbackIndex[family] = i
+			}
+		}
+	}
+	for _, index := range []int{exactIndex[0], exactIndex[1], fallbackIndex[0], fallbackIndex[1]} {
+		if index != -1 {
+			return index
 		}
 	}
-	return fallbackAddressIndex
+	return -1
 }
@@ -67,7 +67,18 @@ func (s *AddressSuite) TestNewAddressIpv6(c *gc.C) {
 	addr = NewAddress("2001:DB8::1", NetworkUnknown)
 	c.Check(addr.Value, gc.Equals, "2001:DB8::1")
 	c.Check(addr.Type, gc.Equals, Ipv6Address)
-	c.Check(addr.NetworkScope, gc.Equals, NetworkUnknown)
+	c.Check(addr.NetworkScope, gc.Equals, NetworkPublic)
+
+	addr = NewAddress("fc00::1", NetworkUnknown)
+	c.Check(addr.Type, gc.Equals, Ipv6Address)
+	c.Check(addr.NetworkScope, gc.Equals, NetworkCloudLocal)
+
+	addr = NewAddress("fe80::1", NetworkUnknown)
+	c.Check(addr.Type, gc.Equals, Ipv6Address)
+	c.Check(addr.NetworkScope, gc.Equals, NetworkLinkLocal)
+
+	addr = NewAddress("fe80::1", NetworkPublic)
+	c.Check(addr.NetworkScope, gc.Equals, NetworkPublic)
 }
 
 func (s *AddressSuite) TestNewAddresses(c *gc.C) {
@@ -266,6 +277,117 @@ func (s *AddressSuite) TestSelectInternalMachineHostPort(c *gc.C) {
 	}
 }
 
+var selectPreferIPv6PublicTests = []selectTest{{
+	"a public ipv6 address is selected",
+	[]Address{
+		{"2001:DB8::1", Ipv6Address, "", NetworkPublic},
+	},
+	0,
+}, {
+	"a public ipv6 address is preferred to a public ipv4 address",
+	[]Address{
+		{"8.8.8.8", Ipv4Address, "public", NetworkPublic},
+		{"2001:DB8::1", Ipv6Address, "", NetworkPublic},
+	},
+	1,
+}, {
+	"a public ipv4 address is preferred to a cloud local ipv6 address",
+	[]Address{
+		{"fc00::1", Ipv6Address, "", NetworkCloudLocal},
+		{"8.8.8.8", Ipv4Address, "public", NetworkPublic},
+	},
+	1,
+}, {
+	"a link local ipv6 address is not selected",
+	[]Address{
+		{"fe80::1", Ipv6Address, "", NetworkLinkLocal},
+	},
+	-1,
+}, {
+	"an ipv4 address is selected if there are no ipv6 addresses",
+	[]Address{
+		{"127.0.0.1", Ipv4Address, "machine", NetworkMachineLocal},
+		{"10.0.0.1", Ipv4Address, "cloud", NetworkCloudLocal},
+	},
+	1,
+}}
+
+func (s *AddressSuite) TestSelectPublicAddressPreferIPv6(c *gc.C) {
+	s.PatchValue(&preferIPv6, true)
+	for i, t := range selectPreferIPv6PublicTests {
+		c.Logf("test %d. %s", i, t.about)
+		c.Check(SelectPublicAddress(t.addresses), gc.Equals, t.expected())
+	}
+}
+
+func (s *AddressSuite) TestSelectPublicHostPortPreferIPv6(c *gc.C) {
+	s.PatchValue(&preferIPv6, true)
+	for i, t0 := range selectPreferIPv6PublicTests {
+		t := t0.hostPortTest()
+		c.Logf("test %d. %s", i, t.about)
+		c.Assert(SelectPublicHostPort(t.hostPorts), gc.DeepEquals, t.expected())
+	}
+}
+
+var selectPreferIPv6InternalTests = []selectTest{{
+	"a cloud local ipv6 address is selected",
+	[]Address{
+		{"fc00::1", Ipv6Address, "", NetworkCloudLocal},
+	},
+	0,
+}, {
+	"a cloud local ipv6 address is preferred to a cloud local ipv4 address",
+	[]Address{
+		{"10.0.0.1", Ipv4Address, "cloud", NetworkCloudLocal},
+		{"fc00::1", Ipv6Address, "", NetworkCloudLocal},
+	},
+	1,
+}, {
+	"a cloud local ipv4 address is preferred to a public ipv6 address",
+	[]Address{
+		{"2001:DB8::1", Ipv6Address, "", NetworkPublic},
+		{"10.0.0.1", Ipv4Address, "cloud", NetworkCloudLocal},
+	},
+	1,
+}, {
+	"a public ipv6 address is preferred to a public ipv4 address",
+	[]Address{
+		{"8.8.8.8", Ipv4Address, "public", NetworkPublic},
+		{"2001:DB8::1", Ipv6Address, "", NetworkPublic},
+	},
+	1,
+}, {
+	"a machine local ipv6 address is not selected",
+	[]Address{
+		{"::1", Ipv6Address, "", NetworkMachineLocal},
+	},
+	-1,
+}}
+
+func (s *AddressSuite) TestSelectInternalAddressPreferIPv6(c *gc.C) {
+	s.PatchValue(&preferIPv6, true)
+	for i, t := range selectPreferIPv6InternalTests {
+		c.Logf("test %d. %s", i, t.about)
+		c.Check(SelectInternalAddress(t.addresses, false), gc.Equals, t.expected())
+	}
+}
+
+func (s *AddressSuite) TestSelectInternalHostPortPreferIPv6(c *gc.C) {
+	s.PatchValue(&preferIPv6, true)
+	for i, t0 := range selectPreferIPv6InternalTests {
+		t := t0.hostPortTest()
+		c.Logf("test %d. %s", i, t.about)
+		c.Assert(SelectInternalHostPort(t.hostPorts, false), gc.DeepEquals, t.expected())
+	}
+}
+
+func (s *AddressSuite) TestSetPreferIPv6(c *gc.C) {
+	s.PatchValue(&preferIPv6, false)
+	c.Assert(PreferIPv6(), gc.Equals, false)
+	SetPreferIPv6(true)
+	c.Assert(PreferIPv6(), gc.Equals, true)
+}
+
 var stringTests = []struct {
 	addr Address
 	str  string
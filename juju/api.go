@@ -212,7 +212,7 @@ func newAPIFromStore(envName string, store configstore.Storage, apiOpen apiOpenF
 
 	st := val0.(apiState)
 	// Even though we are about to update API addresses based on
-	// APIHostPorts in cacheChangedAPIAddresses, we first cache the
+	// APIHostPorts in api.CacheChangedAddrs, we first cache the
 	// addresses based on the provider lookup. This is because older API
 	// servers didn't return their HostPort information on Login, and we
 	// still want to cache our connection information to them.
@@ -231,7 +231,7 @@ func newAPIFromStore(envName string, store configstore.Storage, apiOpen apiOpenF
 		}
 	}
 	// Update API addresses if they've changed. Error is non-fatal.
-	if localerr := cacheChangedAPIAddresses(info, st); localerr != nil {
+	if localerr := api.CacheChangedAddrs(info, st.APIHostPorts()); localerr != nil {
 		logger.Warningf("cannot failed to cache API addresses: %v", localerr)
 	}
 	return st, nil
@@ -359,47 +359,6 @@ func cacheAPIInfo(info configstore.EnvironInfo, apiInfo *api.Info) error {
 	return info.Write()
 }
 
-// cacheChangedAPIAddresses updates the local environment settings (.jenv file)
-// with the provided API server addresses if they have changed.
-func cacheChangedAPIAddresses(info configstore.EnvironInfo, st apiState) error {
-	var addrs []string
-	for _, serverHostPorts := range st.APIHostPorts() {
-		for _, hostPort := range serverHostPorts {
-			// Only cache addresses that are likely to be usable,
-			// exclude IPv6 for now and localhost style ones.
-			if hostPort.Type != instance.Ipv6Address && hostPort.NetworkScope != instance.NetworkMachineLocal {
-				addrs = append(addrs, hostPort.NetAddr())
-			}
-		}
-	}
-	endpoint := info.APIEndpoint()
-	if len(addrs) == 0 || !addrsChanged(endpoint.Addresses, addrs) {
-		return nil
-	}
-	logger.Debugf("API addresses changed from %q to %q", endpoint.Addresses, addrs)
-	endpoint.Addresses = addrs
-	info.SetAPIEndpoint(endpoint)
-	if err := info.Write(); err != nil {
-		return err
-	}
-	logger.Infof("updated API connection settings cache")
-	return nil
-}
-
-// addrsChanged returns true iff the two
-// slices are not equal. Order is important.
-func addrsChanged(a, b []string) bool {
-	if len(a) != len(b) {
-		return true
-	}
-	for i := range a {
-		if a[i] != b[i] {
-			return true
-		}
-	}
-	return false
-}
-
 // APIEndpointForEnv returns the endpoint information for a given environment
 // It tries to just return the information from the cached settings unless
 // there is nothing cached or refresh is True
@@ -708,3 +708,28 @@ func (s *APIEndpointForEnvSuite) TestAPIEndpointNotMachineLocal(c *gc.C) {
 		"1.0.0.2:1235",
 	})
 }
+
+func (s *APIEndpointForEnvSuite) TestAPIEndpointPreferIPv6(c *gc.C) {
+	instance.SetPreferIPv6(true)
+	defer instance.SetPreferIPv6(false)
+	store := newConfigStore("env-name", dummyStoreInfo)
+	hostPorts := [][]instance.HostPort{
+		instance.AddressesWithPort([]instance.Address{
+			instance.NewAddress("1.0.0.1", instance.NetworkPublic),
+			instance.NewAddress("2002:0:0:0:0:0:100:2", instance.NetworkUnknown),
+			instance.NewAddress("fe80::1", instance.NetworkUnknown),
+			instance.NewAddress("::1", instance.NetworkMachineLocal),
+		}, 1234),
+	}
+
+	expectState := &mockAPIState{apiHostPorts: hostPorts}
+	apiOpen := func(_ *api.Info, _ api.DialOpts) (juju.APIState, error) {
+		return expectState, nil
+	}
+	endpoint, err := juju.APIEndpointInStore("env-name", true, store, apiOpen)
+	c.Assert(err, gc.IsNil)
+	c.Check(endpoint.Addresses, gc.DeepEquals, []string{
+		"1.0.0.1:1234",
+		"[2002:0:0:0:0:0:100:2]:1234",
+	})
+}
@@ -756,6 +756,12 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 		firewallMode: e.Config().FirewallMode(),
 		state:        estate,
 	}
+	if e.Config().PreferIPv6() {
+		i.addresses = append(i.addresses,
+			instance.NewAddress(fmt.Sprintf("fc00::%x", estate.maxId), instance.NetworkUnknown),
+			instance.NewAddress(fmt.Sprintf("2001:db8::%x", estate.maxId), instance.NetworkUnknown),
+		)
+	}
 
 	var hc *instance.HardwareCharacteristics
 	// To match current system capability, only provide hardware characteristics for
@@ -115,6 +115,32 @@ func (s *suite) TestAllocateAddress(c *gc.C) {
 	assertAllocateAddress(c, e, opc, inst.Id(), netId, expectAddress)
 }
 
+func (s *suite) TestStartInstancePreferIPv6(c *gc.C) {
+	attrs := s.TestConfig.Merge(testing.Attrs{"prefer-ipv6": true})
+	cfg, err := config.New(config.NoDefaults, attrs)
+	c.Assert(err, gc.IsNil)
+	e, err := environs.Prepare(cfg, testing.Context(c), s.ConfigStore)
+	c.Assert(err, gc.IsNil)
+
+	envtesting.UploadFakeTools(c, e.Storage())
+	err = bootstrap.Bootstrap(testing.Context(c), e, environs.BootstrapParams{})
+	c.Assert(err, gc.IsNil)
+
+	inst, _ := jujutesting.AssertStartInstance(c, e, "0")
+	addrs, err := inst.Addresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(addrs, gc.DeepEquals, []instance.Address{
+		instance.NewAddress("only-0.dns", instance.NetworkUnknown),
+		instance.NewAddress("fc00::0", instance.NetworkCloudLocal),
+		instance.NewAddress("2001:db8::0", instance.NetworkPublic),
+	})
+
+	instance.SetPreferIPv6(true)
+	defer instance.SetPreferIPv6(false)
+	c.Assert(instance.SelectInternalAddress(addrs, false), gc.Equals, "fc00::0")
+	c.Assert(instance.SelectPublicAddress(addrs), gc.Equals, "2001:db8::0")
+}
+
 func assertAllocateAddress(c *gc.C, e environs.Environ, opc chan dummy.Operation, expectInstId instance.Id, expectNetId network.Id, expectAddress instance.Address) {
 	select {
 	case op := <-opc:
@@ -119,7 +119,7 @@ func (s *localJujuTestSuite) SetUpTest(c *gc.C) {
 	s.testPath = c.MkDir()
 	s.fakesudo = filepath.Join(s.testPath, "sudo")
 	s.PatchEnvPathPrepend(s.testPath)
-	s.PatchValue(&lxc.TemplateLockDir, c.MkDir())
+	s.PatchValue(&container.TemplateLockDir, c.MkDir())
 	s.PatchValue(&lxc.TemplateStopTimeout, 500*time.Millisecond)
 
 	// Write a fake "sudo" which records its args to sudo.args.
@@ -1075,6 +1075,17 @@ func (e *environ) machinesFilter() *nova.Filter {
 	return filter
 }
 
+// ruleCidrs returns the CIDRs that ports opened to the world should be
+// opened to. IPv6 rules are only added when the environment prefers
+// IPv6.
+func (e *environ) ruleCidrs() []string {
+	cidrs := []string{"0.0.0.0/0"}
+	if e.Config().PreferIPv6() {
+		cidrs = append(cidrs, "::/0")
+	}
+	return cidrs
+}
+
 func (e *environ) openPortsInGroup(name string, ports []instance.Port) error {
 	novaclient := e.nova()
 	group, err := novaclient.SecurityGroupByName(name)
@@ -1082,16 +1093,18 @@ func (e *environ) openPortsInGroup(name string, ports []instance.Port) error {
 		return err
 	}
 	for _, port := range ports {
-		_, err := novaclient.CreateSecurityGroupRule(nova.RuleInfo{
-			ParentGroupId: group.Id,
-			FromPort:      port.Number,
-			ToPort:        port.Number,
-			IPProtocol:    port.Protocol,
-			Cidr:          "0.0.0.0/0",
-		})
-		if err != nil {
-			// TODO: if err is not rule already exists, raise?
-			logger.Debugf("error creating security group rule: %v", err.Error())
+		for _, cidr := range e.ruleCidrs() {
+			_, err := novaclient.CreateSecurityGroupRule(nova.RuleInfo{
+				ParentGroupId: group.Id,
+				FromPort:      port.Number,
+				ToPort:        port.Number,
+				IPProtocol:    port.Protocol,
+				Cidr:          cidr,
+			})
+			if err != nil {
+				// TODO: if err is not rule already exists, raise?
+				logger.Debugf("error creating security group rule: %v", err.Error())
+			}
 		}
 	}
 	return nil
@@ -1108,6 +1121,7 @@ func (e *environ) closePortsInGroup(name string, ports []instance.Port) error {
 	}
 	// TODO: Hey look ma, it's quadratic
 	for _, port := range ports {
+		// There may be a rule for each of IPv4 and IPv6.
 		for _, p := range (*group).Rules {
 			if p.IPProtocol == nil || *p.IPProtocol != port.Protocol ||
 				p.FromPort == nil || *p.FromPort != port.Number ||
@@ -1118,7 +1132,6 @@ func (e *environ) closePortsInGroup(name string, ports []instance.Port) error {
 			if err != nil {
 				return err
 			}
-			break
 		}
 	}
 	return nil
@@ -1129,12 +1142,19 @@ func (e *environ) portsInGroup(name string) (ports []instance.Port, err error) {
 	if err != nil {
 		return nil, err
 	}
+	seen := make(map[instance.Port]bool)
 	for _, p := range (*group).Rules {
 		for i := *p.FromPort; i <= *p.ToPort; i++ {
-			ports = append(ports, instance.Port{
+			port := instance.Port{
 				Protocol: *p.IPProtocol,
 				Number:   i,
-			})
+			}
+			// IPv4 and IPv6 rules open the same port.
+			if seen[port] {
+				continue
+			}
+			seen[port] = true
+			ports = append(ports, port)
 		}
 	}
 	instance.SortPorts(ports)
@@ -1180,26 +1200,19 @@ func (e *environ) Provider() environs.EnvironProvider {
 }
 
 func (e *environ) setUpGlobalGroup(groupName string, statePort, apiPort int) (nova.SecurityGroup, error) {
-	return e.ensureGroup(groupName,
-		[]nova.RuleInfo{
-			{
-				IPProtocol: "tcp",
-				FromPort:   22,
-				ToPort:     22,
-				Cidr:       "0.0.0.0/0",
-			},
-			{
-				IPProtocol: "tcp",
-				FromPort:   statePort,
-				ToPort:     statePort,
-				Cidr:       "0.0.0.0/0",
-			},
-			{
+	var rules []nova.RuleInfo
+	for _, cidr := range e.ruleCidrs() {
+		for _, port := range []int{22, statePort, apiPort} {
+			rules = append(rules, nova.RuleInfo{
 				IPProtocol: "tcp",
-				FromPort:   apiPort,
-				ToPort:     apiPort,
-				Cidr:       "0.0.0.0/0",
-			},
+				FromPort:   port,
+				ToPort:     port,
+				Cidr:       cidr,
+			})
+		}
+	}
+	return e.ensureGroup(groupName,
+		append(rules, []nova.RuleInfo{
 			{
 				IPProtocol: "tcp",
 				FromPort:   1,
@@ -1215,7 +1228,7 @@ func (e *environ) setUpGlobalGroup(groupName string, statePort, apiPort int) (no
 				FromPort:   -1,
 				ToPort:     -1,
 			},
-		})
+		}...))
 }
 
 // setUpGroups creates the security groups for the new machine, and
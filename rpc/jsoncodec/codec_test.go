@@ -45,6 +45,18 @@ var readTests = []struct {
 		},
 	},
 	expectBody: &value{X: "param"},
+}, {
+	msg: `{"RequestId": 1, "Type": "foo", "Version": 2, "Id": "id", "Request": "frob", "Params": {"X": "param"}}`,
+	expectHdr: rpc.Header{
+		RequestId: 1,
+		Request: rpc.Request{
+			Type:    "foo",
+			Version: 2,
+			Id:      "id",
+			Action:  "frob",
+		},
+	},
+	expectBody: &value{X: "param"},
 }, {
 	msg: `{"RequestId": 2, "Error": "an error", "ErrorCode": "a code"}`,
 	expectHdr: rpc.Header{
@@ -217,6 +229,18 @@ var writeTests = []struct {
 	},
 	body:   &value{X: "param"},
 	expect: `{"RequestId": 1, "Type": "foo","Id":"id", "Request": "frob", "Params": {"X": "param"}}`,
+}, {
+	hdr: &rpc.Header{
+		RequestId: 1,
+		Request: rpc.Request{
+			Type:    "foo",
+			Version: 2,
+			Id:      "id",
+			Action:  "frob",
+		},
+	},
+	body:   &value{X: "param"},
+	expect: `{"RequestId": 1, "Type": "foo", "Version": 2, "Id":"id", "Request": "frob", "Params": {"X": "param"}}`,
 }, {
 	hdr: &rpc.Header{
 		RequestId: 2,
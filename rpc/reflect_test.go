@@ -97,7 +97,7 @@ func (*reflectSuite) TestObjTypeOf(c *gc.C) {
 func (*reflectSuite) TestValueOf(c *gc.C) {
 	v := rpcreflect.ValueOf(reflect.ValueOf(nil))
 	c.Check(v.IsValid(), jc.IsFalse)
-	c.Check(func() { v.MethodCaller("foo", "bar") }, gc.PanicMatches, "MethodCaller called on invalid Value")
+	c.Check(func() { v.MethodCaller("foo", 0, "bar") }, gc.PanicMatches, "MethodCaller called on invalid Value")
 
 	root := &Root{}
 	v = rpcreflect.ValueOf(reflect.ValueOf(root))
@@ -115,17 +115,17 @@ func (*reflectSuite) TestMethodCaller(c *gc.C) {
 	root.simple["a99"] = &SimpleMethods{root: root, id: "a99"}
 	v := rpcreflect.ValueOf(reflect.ValueOf(root))
 
-	m, err := v.MethodCaller("foo", "bar")
+	m, err := v.MethodCaller("foo", 0, "bar")
 	c.Assert(err, gc.ErrorMatches, `unknown object type "foo"`)
 	c.Assert(err, gc.FitsTypeOf, (*rpcreflect.CallNotImplementedError)(nil))
 	c.Assert(m, gc.DeepEquals, rpcreflect.MethodCaller{})
 
-	m, err = v.MethodCaller("SimpleMethods", "bar")
+	m, err = v.MethodCaller("SimpleMethods", 0, "bar")
 	c.Assert(err, gc.ErrorMatches, "no such request - method SimpleMethods.bar is not implemented")
 	c.Assert(err, gc.FitsTypeOf, (*rpcreflect.CallNotImplementedError)(nil))
 	c.Assert(m, gc.DeepEquals, rpcreflect.MethodCaller{})
 
-	m, err = v.MethodCaller("SimpleMethods", "Call1r1e")
+	m, err = v.MethodCaller("SimpleMethods", 0, "Call1r1e")
 	c.Assert(err, gc.IsNil)
 	c.Assert(m.ParamsType, gc.Equals, reflect.TypeOf(stringVal{}))
 	c.Assert(m.ResultType, gc.Equals, reflect.TypeOf(stringVal{}))
@@ -133,4 +133,35 @@ func (*reflectSuite) TestMethodCaller(c *gc.C) {
 	ret, err := m.Call("a99", reflect.ValueOf(stringVal{"foo"}))
 	c.Assert(err, gc.IsNil)
 	c.Assert(ret.Interface(), gc.Equals, stringVal{"Call1r1e ret"})
+
+	m, err = v.MethodCaller("SimpleMethods", 1, "Call1r1e")
+	c.Assert(err, gc.ErrorMatches, `unknown version \(1\) of interface "SimpleMethods"`)
+	c.Assert(err, gc.FitsTypeOf, (*rpcreflect.CallNotImplementedError)(nil))
+	c.Assert(m, gc.DeepEquals, rpcreflect.MethodCaller{})
+}
+
+func (*reflectSuite) TestVersionedMethodCaller(c *gc.C) {
+	v := rpcreflect.ValueOf(reflect.ValueOf(VersionedRoot{}))
+	for version, expect := range []string{"v0", "v1"} {
+		m, err := v.MethodCaller("Versioned", version, "Version")
+		c.Assert(err, gc.IsNil)
+		ret, err := m.Call("", reflect.Value{})
+		c.Assert(err, gc.IsNil)
+		c.Assert(ret.Interface(), gc.Equals, stringVal{expect})
+	}
+}
+
+func (*reflectSuite) TestVersions(c *gc.C) {
+	rtype := rpcreflect.TypeOf(reflect.TypeOf(VersionedRoot{}))
+	c.Assert(rtype.Versions(), gc.DeepEquals, map[string][]int{
+		"Versioned": {0, 1},
+	})
+	rtype = rpcreflect.TypeOf(reflect.TypeOf(&Root{}))
+	c.Assert(rtype.Versions()["SimpleMethods"], gc.DeepEquals, []int{0})
+}
+
+func (*reflectSuite) TestRootMethodName(c *gc.C) {
+	c.Assert(rpcreflect.RootMethodName("Client", 0), gc.Equals, "Client")
+	c.Assert(rpcreflect.RootMethodName("Client", 1), gc.Equals, "ClientV1")
+	c.Assert(rpcreflect.RootMethodName("Client", 12), gc.Equals, "ClientV12")
 }
@@ -122,6 +122,29 @@ func (r *Root) ChangeAPIMethods(string) (*ChangeAPIMethods, error) {
 	return &ChangeAPIMethods{r}, nil
 }
 
+// VersionedRoot serves two versions of the Versioned object type.
+type VersionedRoot struct{}
+
+func (VersionedRoot) Versioned(id string) (versionedV0, error) {
+	return versionedV0{}, nil
+}
+
+func (VersionedRoot) VersionedV1(id string) (versionedV1, error) {
+	return versionedV1{}, nil
+}
+
+type versionedV0 struct{}
+
+func (versionedV0) Version() stringVal {
+	return stringVal{"v0"}
+}
+
+type versionedV1 struct{}
+
+func (versionedV1) Version() stringVal {
+	return stringVal{"v1"}
+}
+
 func (t *Root) called(rcvr interface{}, method string, arg interface{}) {
 	t.mu.Lock()
 	t.calls = append(t.calls, &callInfo{rcvr, method, arg})
@@ -230,7 +253,7 @@ func (a *CallbackMethods) Factorial(x int64val) (int64val, error) {
 		return int64val{1}, nil
 	}
 	var r int64val
-	err := a.root.conn.Call(rpc.Request{"CallbackMethods", "", "Factorial"}, int64val{x.I - 1}, &r)
+	err := a.root.conn.Call(rpc.Request{"CallbackMethods", 0, "", "Factorial"}, int64val{x.I - 1}, &r)
 	if err != nil {
 		return int64val{}, err
 	}
@@ -505,7 +528,7 @@ func (*rpcSuite) TestConcurrentCalls(c *gc.C) {
 	defer closeClient(c, client, srvDone)
 	call := func(id string, done chan<- struct{}) {
 		var r stringVal
-		err := client.Call(rpc.Request{"DelayedMethods", id, "Delay"}, nil, &r)
+		err := client.Call(rpc.Request{"DelayedMethods", 0, id, "Delay"}, nil, &r)
 		c.Check(err, gc.IsNil)
 		c.Check(r.Val, gc.Equals, "return "+id)
 		done <- struct{}{}
@@ -545,7 +568,7 @@ func (*rpcSuite) TestErrorCode(c *gc.C) {
 	}
 	client, srvDone, _, _ := newRPCClientServer(c, root, nil, false)
 	defer closeClient(c, client, srvDone)
-	err := client.Call(rpc.Request{"ErrorMethods", "", "Call"}, nil, nil)
+	err := client.Call(rpc.Request{"ErrorMethods", 0, "", "Call"}, nil, nil)
 	c.Assert(err, gc.ErrorMatches, `request error: message \(code\)`)
 	c.Assert(err.(rpc.ErrorCoder).ErrorCode(), gc.Equals, "code")
 }
@@ -566,18 +589,18 @@ func (*rpcSuite) TestTransformErrors(c *gc.C) {
 	}
 	client, srvDone, _, _ := newRPCClientServer(c, root, tfErr, false)
 	defer closeClient(c, client, srvDone)
-	err := client.Call(rpc.Request{"ErrorMethods", "", "Call"}, nil, nil)
+	err := client.Call(rpc.Request{"ErrorMethods", 0, "", "Call"}, nil, nil)
 	c.Assert(err, gc.DeepEquals, &rpc.RequestError{
 		Message: "transformed: message",
 		Code:    "transformed: code",
 	})
 
 	root.errorInst.err = nil
-	err = client.Call(rpc.Request{"ErrorMethods", "", "Call"}, nil, nil)
+	err = client.Call(rpc.Request{"ErrorMethods", 0, "", "Call"}, nil, nil)
 	c.Assert(err, gc.IsNil)
 
 	root.errorInst = nil
-	err = client.Call(rpc.Request{"ErrorMethods", "", "Call"}, nil, nil)
+	err = client.Call(rpc.Request{"ErrorMethods", 0, "", "Call"}, nil, nil)
 	c.Assert(err, gc.DeepEquals, &rpc.RequestError{
 		Message: "transformed: no error methods",
 	})
@@ -600,7 +623,7 @@ func (*rpcSuite) TestServerWaitsForOutstandingCalls(c *gc.C) {
 	done := make(chan struct{})
 	go func() {
 		var r stringVal
-		err := client.Call(rpc.Request{"DelayedMethods", "1", "Delay"}, nil, &r)
+		err := client.Call(rpc.Request{"DelayedMethods", 0, "1", "Delay"}, nil, &r)
 		c.Check(err, gc.Equals, rpc.ErrShutdown)
 		done <- struct{}{}
 	}()
@@ -635,7 +658,7 @@ func (*rpcSuite) TestCompatibility(c *gc.C) {
 	defer closeClient(c, client, srvDone)
 	call := func(method string, arg, ret interface{}) (passedArg interface{}) {
 		root.calls = nil
-		err := client.Call(rpc.Request{"SimpleMethods", "a0", method}, arg, ret)
+		err := client.Call(rpc.Request{"SimpleMethods", 0, "a0", method}, arg, ret)
 		c.Assert(err, gc.IsNil)
 		c.Assert(root.calls, gc.HasLen, 1)
 		info := root.calls[0]
@@ -678,25 +701,42 @@ func (*rpcSuite) TestBadCall(c *gc.C) {
 	defer closeClient(c, client, srvDone)
 
 	testBadCall(c, client, clientNotifier, serverNotifier,
-		rpc.Request{"BadSomething", "a0", "No"},
+		rpc.Request{"BadSomething", 0, "a0", "No"},
 		`unknown object type "BadSomething"`,
 		rpc.CodeNotImplemented,
 		false,
 	)
 	testBadCall(c, client, clientNotifier, serverNotifier,
-		rpc.Request{"SimpleMethods", "xx", "No"},
+		rpc.Request{"SimpleMethods", 0, "xx", "No"},
 		"no such request - method SimpleMethods.No is not implemented",
 		rpc.CodeNotImplemented,
 		false,
 	)
 	testBadCall(c, client, clientNotifier, serverNotifier,
-		rpc.Request{"SimpleMethods", "xx", "Call0r0"},
+		rpc.Request{"SimpleMethods", 0, "xx", "Call0r0"},
 		`unknown SimpleMethods id`,
 		"",
 		true,
 	)
 }
 
+func (*rpcSuite) TestVersionedCalls(c *gc.C) {
+	client, srvDone, _, _ := newRPCClientServer(c, VersionedRoot{}, nil, false)
+	defer closeClient(c, client, srvDone)
+
+	for version, expect := range []string{"v0", "v1"} {
+		var r stringVal
+		err := client.Call(rpc.Request{"Versioned", version, "", "Version"}, nil, &r)
+		c.Assert(err, gc.IsNil)
+		c.Assert(r.Val, gc.Equals, expect)
+	}
+	err := client.Call(rpc.Request{"Versioned", 2, "", "Version"}, nil, nil)
+	c.Assert(err, gc.ErrorMatches, `request error: unknown version \(2\) of interface "Versioned" \(not implemented\)`)
+	c.Assert(err.(*rpc.RequestError).Code, gc.Equals, rpc.CodeNotImplemented)
+	err = client.Call(rpc.Request{"Unknown", 1, "", "Version"}, nil, nil)
+	c.Assert(err, gc.ErrorMatches, `request error: unknown object type "Unknown" \(not implemented\)`)
+}
+
 func testBadCall(
 	c *gc.C,
 	client *rpc.Conn,
@@ -786,10 +826,10 @@ func (*rpcSuite) TestContinueAfterReadBodyError(c *gc.C) {
 	}{
 		X: map[string]int{"hello": 65},
 	}
-	err := client.Call(rpc.Request{"SimpleMethods", "a0", "SliceArg"}, arg0, &ret)
+	err := client.Call(rpc.Request{"SimpleMethods", 0, "a0", "SliceArg"}, arg0, &ret)
 	c.Assert(err, gc.ErrorMatches, `request error: json: cannot unmarshal object into Go value of type \[\]string`)
 
-	err = client.Call(rpc.Request{"SimpleMethods", "a0", "SliceArg"}, arg0, &ret)
+	err = client.Call(rpc.Request{"SimpleMethods", 0, "a0", "SliceArg"}, arg0, &ret)
 	c.Assert(err, gc.ErrorMatches, `request error: json: cannot unmarshal object into Go value of type \[\]string`)
 
 	arg1 := struct {
@@ -797,7 +837,7 @@ func (*rpcSuite) TestContinueAfterReadBodyError(c *gc.C) {
 	}{
 		X: []string{"one"},
 	}
-	err = client.Call(rpc.Request{"SimpleMethods", "a0", "SliceArg"}, arg1, &ret)
+	err = client.Call(rpc.Request{"SimpleMethods", 0, "a0", "SliceArg"}, arg1, &ret)
 	c.Assert(err, gc.IsNil)
 	c.Assert(ret.Val, gc.Equals, "SliceArg ret")
 }
@@ -806,7 +846,7 @@ func (*rpcSuite) TestErrorAfterClientClose(c *gc.C) {
 	client, srvDone, _, _ := newRPCClientServer(c, &Root{}, nil, false)
 	err := client.Close()
 	c.Assert(err, gc.IsNil)
-	err = client.Call(rpc.Request{"Foo", "", "Bar"}, nil, nil)
+	err = client.Call(rpc.Request{"Foo", 0, "", "Bar"}, nil, nil)
 	c.Assert(err, gc.Equals, rpc.ErrShutdown)
 	err = chanReadError(c, srvDone, "server done")
 	c.Assert(err, gc.IsNil)
@@ -848,7 +888,7 @@ func (*rpcSuite) TestBidirectional(c *gc.C) {
 	clientRoot := &Root{conn: client}
 	client.Serve(clientRoot, nil)
 	var r int64val
-	err := client.Call(rpc.Request{"CallbackMethods", "", "Factorial"}, int64val{12}, &r)
+	err := client.Call(rpc.Request{"CallbackMethods", 0, "", "Factorial"}, int64val{12}, &r)
 	c.Assert(err, gc.IsNil)
 	c.Assert(r.I, gc.Equals, int64(479001600))
 }
@@ -858,7 +898,7 @@ func (*rpcSuite) TestServerRequestWhenNotServing(c *gc.C) {
 	client, srvDone, _, _ := newRPCClientServer(c, srvRoot, nil, true)
 	defer closeClient(c, client, srvDone)
 	var r int64val
-	err := client.Call(rpc.Request{"CallbackMethods", "", "Factorial"}, int64val{12}, &r)
+	err := client.Call(rpc.Request{"CallbackMethods", 0, "", "Factorial"}, int64val{12}, &r)
 	c.Assert(err, gc.ErrorMatches, "request error: request error: no service")
 }
 
@@ -867,13 +907,13 @@ func (*rpcSuite) TestChangeAPI(c *gc.C) {
 	client, srvDone, _, _ := newRPCClientServer(c, srvRoot, nil, true)
 	defer closeClient(c, client, srvDone)
 	var s stringVal
-	err := client.Call(rpc.Request{"NewlyAvailable", "", "NewMethod"}, nil, &s)
+	err := client.Call(rpc.Request{"NewlyAvailable", 0, "", "NewMethod"}, nil, &s)
 	c.Assert(err, gc.ErrorMatches, `request error: unknown object type "NewlyAvailable" \(not implemented\)`)
-	err = client.Call(rpc.Request{"ChangeAPIMethods", "", "ChangeAPI"}, nil, nil)
+	err = client.Call(rpc.Request{"ChangeAPIMethods", 0, "", "ChangeAPI"}, nil, nil)
 	c.Assert(err, gc.IsNil)
-	err = client.Call(rpc.Request{"ChangeAPIMethods", "", "ChangeAPI"}, nil, nil)
+	err = client.Call(rpc.Request{"ChangeAPIMethods", 0, "", "ChangeAPI"}, nil, nil)
 	c.Assert(err, gc.ErrorMatches, `request error: unknown object type "ChangeAPIMethods" \(not implemented\)`)
-	err = client.Call(rpc.Request{"NewlyAvailable", "", "NewMethod"}, nil, &s)
+	err = client.Call(rpc.Request{"NewlyAvailable", 0, "", "NewMethod"}, nil, &s)
 	c.Assert(err, gc.IsNil)
 	c.Assert(s, gc.Equals, stringVal{"new method result"})
 }
@@ -883,10 +923,10 @@ func (*rpcSuite) TestChangeAPIToNil(c *gc.C) {
 	client, srvDone, _, _ := newRPCClientServer(c, srvRoot, nil, true)
 	defer closeClient(c, client, srvDone)
 
-	err := client.Call(rpc.Request{"ChangeAPIMethods", "", "RemoveAPI"}, nil, nil)
+	err := client.Call(rpc.Request{"ChangeAPIMethods", 0, "", "RemoveAPI"}, nil, nil)
 	c.Assert(err, gc.IsNil)
 
-	err = client.Call(rpc.Request{"ChangeAPIMethods", "", "RemoveAPI"}, nil, nil)
+	err = client.Call(rpc.Request{"ChangeAPIMethods", 0, "", "RemoveAPI"}, nil, nil)
 	c.Assert(err, gc.ErrorMatches, "request error: no service")
 }
 
@@ -906,11 +946,11 @@ func (*rpcSuite) TestChangeAPIWhileServingRequest(c *gc.C) {
 
 	result := make(chan error)
 	go func() {
-		result <- client.Call(rpc.Request{"DelayedMethods", "1", "Delay"}, nil, nil)
+		result <- client.Call(rpc.Request{"DelayedMethods", 0, "1", "Delay"}, nil, nil)
 	}()
 	chanRead(c, ready, "method ready")
 
-	err := client.Call(rpc.Request{"ChangeAPIMethods", "", "ChangeAPI"}, nil, nil)
+	err := client.Call(rpc.Request{"ChangeAPIMethods", 0, "", "ChangeAPI"}, nil, nil)
 	c.Assert(err, gc.IsNil)
 
 	// Ensure that not only does the request in progress complete,
@@ -7,6 +7,8 @@ import (
 	"errors"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -67,6 +69,48 @@ func (r *Type) DiscardedMethods() []string {
 	return append([]string(nil), r.discarded...)
 }
 
+// Versions returns the versions of each object type served by
+// the root-level methods, keyed by object type name and sorted
+// in ascending order. See RootMethodName for how versions are
+// mapped to root-level methods.
+func (r *Type) Versions() map[string][]int {
+	versions := make(map[string][]int)
+	for name := range r.method {
+		objType, version := parseRootMethodName(name)
+		versions[objType] = append(versions[objType], version)
+	}
+	for _, v := range versions {
+		sort.Ints(v)
+	}
+	return versions
+}
+
+// RootMethodName returns the name of the root-level method that
+// serves the given version of an object type. Version 0 is served
+// by the method named after the object type itself; later versions
+// are served by methods with "V" and the version number appended,
+// so version 1 of "Client" is served by a method named "ClientV1".
+func RootMethodName(objType string, version int) string {
+	if version == 0 {
+		return objType
+	}
+	return objType + "V" + strconv.Itoa(version)
+}
+
+// parseRootMethodName returns the object type and version
+// served by the root-level method with the given name.
+func parseRootMethodName(name string) (objType string, version int) {
+	i := strings.LastIndex(name, "V")
+	if i <= 0 || i == len(name)-1 || name[i+1] == '0' {
+		return name, 0
+	}
+	version, err := strconv.Atoi(name[i+1:])
+	if err != nil || version <= 0 {
+		return name, 0
+	}
+	return name[:i], version
+}
+
 // RootMethod holds information on a root-level method.
 type RootMethod struct {
 	// Call invokes the method. The rcvr parameter must be
@@ -13,10 +13,14 @@ import (
 type CallNotImplementedError struct {
 	Method     string
 	RootMethod string
+	Version    int
 }
 
 func (e *CallNotImplementedError) Error() string {
 	if e.Method == "" {
+		if e.Version != 0 {
+			return fmt.Sprintf("unknown version (%d) of interface %q", e.Version, e.RootMethod)
+		}
 		return fmt.Sprintf("unknown object type %q", e.RootMethod)
 	}
 	return fmt.Sprintf("no such request - method %s.%s is not implemented", e.RootMethod, e.Method)
@@ -65,11 +69,12 @@ func (v Value) GoValue() reflect.Value {
 }
 
 // MethodCaller returns an object that can be used to make calls on
-// the given root value to the given root method and object method.
+// the given root value to the given version of the given root method
+// and object method. See RootMethodName for how versions are served.
 // It returns an error if either the root method or the object
 // method were not found.
 // It panics if called on the zero Value.
-func (v Value) MethodCaller(rootMethodName, objMethodName string) (MethodCaller, error) {
+func (v Value) MethodCaller(rootMethodName string, version int, objMethodName string) (MethodCaller, error) {
 	if !v.IsValid() {
 		panic("MethodCaller called on invalid Value")
 	}
@@ -77,11 +82,16 @@ func (v Value) MethodCaller(rootMethodName, objMethodName string) (MethodCaller,
 		rootValue: v.rootValue,
 	}
 	var err error
-	caller.rootMethod, err = v.rootType.Method(rootMethodName)
+	caller.rootMethod, err = v.rootType.Method(RootMethodName(rootMethodName, version))
 	if err != nil {
-		return MethodCaller{}, &CallNotImplementedError{
+		notImplemented := &CallNotImplementedError{
 			RootMethod: rootMethodName,
 		}
+		if _, err := v.rootType.Method(rootMethodName); err == nil {
+			// The object type exists, but not in this version.
+			notImplemented.Version = version
+		}
+		return MethodCaller{}, notImplemented
 	}
 	caller.objMethod, err = caller.rootMethod.ObjType.Method(objMethodName)
 	if err != nil {
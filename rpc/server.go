@@ -69,6 +69,10 @@ type Request struct {
 	// Type holds the type of object to act on.
 	Type string
 
+	// Version holds the version of Type we will be acting on.
+	// Version 0 is served by servers that predate versioning.
+	Version int
+
 	// Id holds the id of the object to act on.
 	Id string
 
@@ -462,7 +466,7 @@ func (conn *Conn) bindRequest(hdr *Header) (boundRequest, error) {
 	if !rootValue.IsValid() {
 		return boundRequest{}, fmt.Errorf("no service")
 	}
-	caller, err := rootValue.MethodCaller(hdr.Request.Type, hdr.Request.Action)
+	caller, err := rootValue.MethodCaller(hdr.Request.Type, hdr.Request.Version, hdr.Request.Action)
 	if err != nil {
 		if _, ok := err.(*rpcreflect.CallNotImplementedError); ok {
 			err = &serverError{
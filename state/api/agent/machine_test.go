@@ -14,12 +14,15 @@ import (
 
 	"github.com/juju/core/agent/mongo"
 	"github.com/juju/core/environs"
+	"github.com/juju/core/instance"
 	"github.com/juju/core/juju/testing"
 	"github.com/juju/core/state"
 	"github.com/juju/core/state/api"
 	"github.com/juju/core/state/api/params"
 	apiserveragent "github.com/juju/core/state/apiserver/agent"
+	statetesting "github.com/juju/core/state/testing"
 	coretesting "github.com/juju/core/testing"
+	"github.com/juju/core/version"
 )
 
 func TestAll(t *stdtesting.T) {
@@ -138,6 +141,70 @@ func (s *machineSuite) TestEntitySetPassword(c *gc.C) {
 	c.Assert(err, jc.Satisfies, errors.IsUnauthorized)
 }
 
+func (s *machineSuite) TestContainerTemplates(c *gc.C) {
+	entity, err := s.st.Agent().Entity(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+	w, err := entity.WatchContainerTemplates()
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.BackingState, w)
+	wc.AssertOneChange()
+
+	err = s.machine.RequestContainerTemplatePurge("juju-trusty-amd64-1.19.4-template")
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+	purge, err := entity.ContainerTemplatePurge()
+	c.Assert(err, gc.IsNil)
+	c.Assert(purge, gc.DeepEquals, params.ContainerTemplatePurgeResult{
+		Revno: 1,
+		Names: []string{"juju-trusty-amd64-1.19.4-template"},
+	})
+
+	templates := []params.ContainerTemplate{{
+		Name:   "juju-precise-amd64-1.19.4-template",
+		Type:   instance.LXC,
+		Series: "precise",
+		Arch:   "amd64",
+		Tools:  version.MustParse("1.19.4"),
+	}}
+	err = entity.SetContainerTemplates(templates, 1)
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+	purge, err = entity.ContainerTemplatePurge()
+	c.Assert(err, gc.IsNil)
+	c.Assert(purge.PurgedRevno, gc.Equals, 1)
+	stored, _, err := s.machine.ContainerTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stored, gc.HasLen, 1)
+	c.Assert(stored[0].Name, gc.Equals, templates[0].Name)
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
+
+func (s *machineSuite) TestEntityAcknowledgeCACert(c *gc.C) {
+	entity, err := s.st.Agent().Entity(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+	pinger, err := s.machine.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+	s.BackingState.StartSync()
+	err = s.machine.WaitAgentAlive(coretesting.LongWait)
+	c.Assert(err, gc.IsNil)
+
+	err = s.State.PublishCACert("New CA cert")
+	c.Assert(err, gc.IsNil)
+	pending, err := s.State.AgentsPendingCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.DeepEquals, []string{s.machine.Tag()})
+
+	err = entity.AcknowledgeCACert("New CA cert")
+	c.Assert(err, gc.IsNil)
+	pending, err = s.State.AgentsPendingCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.HasLen, 0)
+}
+
 func tryOpenState(info *state.Info) error {
 	st, err := state.Open(info, state.DialOpts{}, environs.NewStatePolicy())
 	if err == nil {
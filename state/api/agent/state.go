@@ -9,6 +9,7 @@ import (
 	"github.com/juju/core/instance"
 	"github.com/juju/core/state/api/base"
 	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/state/api/watcher"
 )
 
 // State provides access to an agent's view of the state.
@@ -115,3 +116,125 @@ func (m *Entity) SetPassword(password string) error {
 	}
 	return results.OneError()
 }
+
+// PasswordRotation returns the revno of the pending request for the
+// agent to replace its password, or 0 if there is none. Revnos
+// increase with every request.
+func (m *Entity) PasswordRotation() (int, error) {
+	var results params.IntResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: m.tag}},
+	}
+	err := m.st.caller.Call("Agent", "", "PasswordRotation", args, &results)
+	if err != nil {
+		return 0, err
+	}
+	if len(results.Results) != 1 {
+		return 0, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.Result, nil
+}
+
+// WatchPasswordRotation returns a watcher that notifies when the
+// agent is asked to replace its password.
+func (m *Entity) WatchPasswordRotation() (watcher.NotifyWatcher, error) {
+	var results params.NotifyWatchResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: m.tag}},
+	}
+	err := m.st.caller.Call("Agent", "", "WatchPasswordRotation", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return watcher.NewNotifyWatcher(m.st.caller, result), nil
+}
+
+// AcknowledgeCACert records that the agent has started trusting
+// the given CA certificates.
+func (m *Entity) AcknowledgeCACert(caCert string) error {
+	var results params.ErrorResults
+	args := params.AgentCACerts{
+		Agents: []params.AgentCACert{{
+			Tag:    m.tag,
+			CACert: caCert,
+		}},
+	}
+	err := m.st.caller.Call("Agent", "", "AcknowledgeCACert", args, &results)
+	if err != nil {
+		return err
+	}
+	return results.OneError()
+}
+
+// ContainerTemplatePurge returns the latest request to purge the
+// container templates cached on the agent's machine, and the revno of
+// the last request the agent acted upon.
+func (m *Entity) ContainerTemplatePurge() (params.ContainerTemplatePurgeResult, error) {
+	var results params.ContainerTemplatePurgeResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: m.tag}},
+	}
+	err := m.st.caller.Call("Agent", "", "ContainerTemplatePurge", args, &results)
+	if err != nil {
+		return params.ContainerTemplatePurgeResult{}, err
+	}
+	if len(results.Results) != 1 {
+		return params.ContainerTemplatePurgeResult{}, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return params.ContainerTemplatePurgeResult{}, result.Error
+	}
+	return result, nil
+}
+
+// SetContainerTemplates records the container templates cached on the
+// agent's machine, and the revno of the last purge request acted upon.
+func (m *Entity) SetContainerTemplates(templates []params.ContainerTemplate, purgedRevno int) error {
+	var results params.ErrorResults
+	args := params.SetMachinesContainerTemplates{
+		Machines: []params.MachineContainerTemplates{{
+			Tag:         m.tag,
+			Templates:   templates,
+			PurgedRevno: purgedRevno,
+		}},
+	}
+	err := m.st.caller.Call("Agent", "", "SetContainerTemplates", args, &results)
+	if err != nil {
+		return err
+	}
+	return results.OneError()
+}
+
+// WatchContainerTemplates returns a watcher that notifies when the
+// container templates cached on the agent's machine are reported or
+// purged.
+func (m *Entity) WatchContainerTemplates() (watcher.NotifyWatcher, error) {
+	var results params.NotifyWatchResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: m.tag}},
+	}
+	err := m.st.caller.Call("Agent", "", "WatchContainerTemplates", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return watcher.NewNotifyWatcher(m.st.caller, result), nil
+}
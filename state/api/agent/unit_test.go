@@ -13,6 +13,7 @@ import (
 	"github.com/juju/core/state"
 	"github.com/juju/core/state/api"
 	"github.com/juju/core/state/api/params"
+	statetesting "github.com/juju/core/state/testing"
 	"github.com/juju/core/utils"
 )
 
@@ -59,3 +60,27 @@ func (s *unitSuite) TestUnitEntity(c *gc.C) {
 	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
 	c.Assert(m, gc.IsNil)
 }
+
+func (s *unitSuite) TestPasswordRotation(c *gc.C) {
+	m, err := s.st.Agent().Entity(s.unit.Tag())
+	c.Assert(err, gc.IsNil)
+	revno, err := m.PasswordRotation()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revno, gc.Equals, 0)
+
+	w, err := m.WatchPasswordRotation()
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.BackingState, w)
+	wc.AssertOneChange()
+
+	err = s.State.RequestPasswordRotation(s.unit.Tag())
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+	revno, err = m.PasswordRotation()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revno, gc.Equals, 1)
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
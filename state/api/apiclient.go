@@ -44,6 +44,10 @@ type State struct {
 	// authTag holds the authenticated entity's tag after login.
 	authTag string
 
+	// serverFacades holds the versions of each facade
+	// supported by the API server, as returned from Login.
+	serverFacades map[string][]int
+
 	// broken is a channel that gets closed when the connection is
 	// broken.
 	broken chan struct{}
@@ -126,12 +130,10 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 	if len(info.Addrs) == 0 {
 		return nil, fmt.Errorf("no API addresses to connect to")
 	}
-	pool := x509.NewCertPool()
-	xcert, err := cert.ParseCert(info.CACert)
+	pool, err := cert.ParseCertPool(info.CACert)
 	if err != nil {
 		return nil, err
 	}
-	pool.AddCert(xcert)
 
 	// Dial all addresses at reasonable intervals.
 	try := parallel.NewTry(0, nil)
@@ -250,13 +252,28 @@ func (s *State) Ping() error {
 // "non-empty-id",...)
 func (s *State) Call(objType, id, request string, args, response interface{}) error {
 	err := s.client.Call(rpc.Request{
-		Type:   objType,
-		Id:     id,
-		Action: request,
+		Type:    objType,
+		Version: s.BestFacadeVersion(objType),
+		Id:      id,
+		Action:  request,
 	}, args, response)
 	return params.ClientError(err)
 }
 
+// BestFacadeVersion returns the newest version of the given facade
+// that both this client and the API server support. Calls made
+// through the State use that version. It returns 0 before login,
+// and when the API server predates facade versioning.
+func (s *State) BestFacadeVersion(facade string) int {
+	best := 0
+	for _, version := range s.serverFacades[facade] {
+		if version > best && version <= facadeVersions[facade] {
+			best = version
+		}
+	}
+	return best
+}
+
 func (s *State) Close() error {
 	return s.client.Close()
 }
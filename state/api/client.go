@@ -45,23 +45,31 @@ func (c *Client) call(method string, params, result interface{}) error {
 	return c.st.Call("Client", "", method, params, result)
 }
 
+// BestAPIVersion returns the version of the Client
+// facade that is used to talk to the API server.
+func (c *Client) BestAPIVersion() int {
+	return c.st.BestFacadeVersion("Client")
+}
+
 // MachineStatus holds status info about a machine.
 type MachineStatus struct {
-	Err            error
-	AgentState     params.Status
-	AgentStateInfo string
-	AgentVersion   string
-	DNSName        string
-	InstanceId     instance.Id
-	InstanceState  string
-	Life           string
-	Series         string
-	Id             string
-	Containers     map[string]MachineStatus
-	Hardware       string
-	Jobs           []params.MachineJob
-	HasVote        bool
-	WantsVote      bool
+	Err             error
+	AgentState      params.Status
+	AgentStateInfo  string
+	AgentVersion    string
+	AgentLastSeen   time.Time
+	AgentAPIAddress string
+	DNSName         string
+	InstanceId      instance.Id
+	InstanceState   string
+	Life            string
+	Series          string
+	Id              string
+	Containers      map[string]MachineStatus
+	Hardware        string
+	Jobs            []params.MachineJob
+	HasVote         bool
+	WantsVote       bool
 }
 
 // ServiceStatus holds status info about a service.
@@ -75,20 +83,35 @@ type ServiceStatus struct {
 	CanUpgradeTo  string
 	SubordinateTo []string
 	Units         map[string]UnitStatus
+	Rollout       *RolloutStatus
+}
+
+// RolloutStatus holds the progress of a service's rolling charm upgrade.
+type RolloutStatus struct {
+	FromCharm   string
+	BatchSize   int
+	WaitHealthy bool
+	Upgrading   []string
+	Upgraded    []string
+	Pending     []string
+	Halted      bool
+	Message     string
 }
 
 // UnitStatus holds status info about a unit.
 type UnitStatus struct {
-	Err            error
-	AgentState     params.Status
-	AgentStateInfo string
-	AgentVersion   string
-	Life           string
-	Machine        string
-	OpenedPorts    []string
-	PublicAddress  string
-	Charm          string
-	Subordinates   map[string]UnitStatus
+	Err             error
+	AgentState      params.Status
+	AgentStateInfo  string
+	AgentVersion    string
+	AgentLastSeen   time.Time
+	AgentAPIAddress string
+	Life            string
+	Machine         string
+	OpenedPorts     []string
+	PublicAddress   string
+	Charm           string
+	Subordinates    map[string]UnitStatus
 }
 
 // NetworkStatus holds status info about a network.
@@ -207,6 +230,78 @@ func (c *Client) ServiceSetYAML(service string, yaml string) error {
 	return c.call("ServiceSetYAML", p, nil)
 }
 
+// ServiceSetAtRevision sets configuration options on a service
+// like ServiceSet, provided its settings are still at the given
+// revision.
+func (c *Client) ServiceSetAtRevision(service string, options map[string]string, revision int) error {
+	if err := c.checkSettingsRevisionSupported(); err != nil {
+		return err
+	}
+	p := params.ServiceSet{
+		ServiceName:      service,
+		Options:          options,
+		SettingsRevision: &revision,
+	}
+	return c.call("NewServiceSetForClientAPI", p, nil)
+}
+
+// ServiceSetYAMLAtRevision sets configuration options on a service
+// like ServiceSetYAML, provided its settings are still at the given
+// revision.
+func (c *Client) ServiceSetYAMLAtRevision(service string, yaml string, revision int) error {
+	if err := c.checkSettingsRevisionSupported(); err != nil {
+		return err
+	}
+	p := params.ServiceSetYAML{
+		ServiceName:      service,
+		Config:           yaml,
+		SettingsRevision: &revision,
+	}
+	return c.call("ServiceSetYAML", p, nil)
+}
+
+// checkSettingsRevisionSupported returns an error if the API server
+// may ignore the settings revision when setting service configuration.
+// Older servers would set the configuration unconditionally.
+func (c *Client) checkSettingsRevisionSupported() error {
+	if c.BestAPIVersion() < 2 {
+		return errors.NotSupportedf("setting service configuration at a revision")
+	}
+	return nil
+}
+
+// ServiceConfigHistory returns the recorded changes to the
+// configuration of the named service, oldest first.
+func (c *Client) ServiceConfigHistory(service string) ([]params.ServiceConfigRevision, error) {
+	var results params.ServiceConfigHistoryResults
+	p := params.ServiceConfigHistory{ServiceName: service}
+	if err := c.call("ServiceConfigHistory", p, &results); err != nil {
+		return nil, err
+	}
+	return results.Revisions, nil
+}
+
+// ServiceRollbackConfig restores the configuration of the named
+// service to that of the given revision in its history.
+func (c *Client) ServiceRollbackConfig(service string, revision int) error {
+	p := params.ServiceRollbackConfig{
+		ServiceName: service,
+		Revision:    revision,
+	}
+	return c.call("ServiceRollbackConfig", p, nil)
+}
+
+// ServiceResources returns the resources of the named service,
+// sorted by name.
+func (c *Client) ServiceResources(service string) ([]params.ServiceResource, error) {
+	var results params.ServiceResourcesResults
+	p := params.ServiceResources{ServiceName: service}
+	if err := c.call("ServiceResources", p, &results); err != nil {
+		return nil, err
+	}
+	return results.Resources, nil
+}
+
 // ServiceGet returns the configuration for the named service.
 func (c *Client) ServiceGet(service string) (*params.ServiceGetResults, error) {
 	var results params.ServiceGetResults
@@ -237,6 +332,35 @@ func (c *Client) ServiceCharmRelations(service string) ([]string, error) {
 	return results.CharmRelations, err
 }
 
+// WebhookAdd subscribes the given URL to changes in the environment.
+// The returned webhook holds the secret used to sign the changes
+// posted to it.
+func (c *Client) WebhookAdd(args params.WebhookAdd) (params.Webhook, error) {
+	var result params.Webhook
+	err := c.call("WebhookAdd", args, &result)
+	return result, err
+}
+
+// Webhooks returns the webhook subscriptions in the environment.
+func (c *Client) Webhooks() ([]params.Webhook, error) {
+	var results params.WebhooksResults
+	err := c.call("Webhooks", nil, &results)
+	return results.Webhooks, err
+}
+
+// WebhookRemove removes the webhook subscription with the given id.
+func (c *Client) WebhookRemove(id string) error {
+	return c.call("WebhookRemove", params.WebhookRemove{Id: id}, nil)
+}
+
+// WebhookFailures returns the most recent deliveries to the webhook
+// with the given id that failed, oldest first.
+func (c *Client) WebhookFailures(id string) ([]params.WebhookFailure, error) {
+	var results params.WebhookFailuresResults
+	err := c.call("WebhookFailures", params.WebhookFailures{Id: id}, &results)
+	return results.Failures, err
+}
+
 // AddMachines1dot18 adds new machines with the supplied parameters.
 //
 // TODO(axw) 2014-04-11 #XXX
@@ -256,7 +380,14 @@ func (c *Client) AddMachines(machineParams []params.AddMachineParams) ([]params.
 		MachineParams: machineParams,
 	}
 	results := new(params.AddMachinesResults)
-	err := c.call("AddMachinesV2", args, results)
+	method := "AddMachines"
+	if c.BestAPIVersion() < 1 {
+		// Older servers may ignore placement directives
+		// passed to AddMachines; AddMachinesV2 is not
+		// implemented by those servers, so it fails instead.
+		method = "AddMachinesV2"
+	}
+	err := c.call(method, args, results)
 	return results.Machines, err
 }
 
@@ -311,7 +442,10 @@ func (c *Client) ServiceDeployWithNetworks(charmURL string, serviceName string,
 		IncludeNetworks: includeNetworks,
 		ExcludeNetworks: excludeNetworks,
 	}
-	return c.st.Call("Client", "", "ServiceDeployWithNetworks", params, nil)
+	if c.BestAPIVersion() < 1 {
+		return c.call("ServiceDeployWithNetworks", params, nil)
+	}
+	return c.call("ServiceDeploy", params, nil)
 }
 
 // ServiceDeploy obtains the charm, either locally or from the charm store,
@@ -345,6 +479,21 @@ func (c *Client) ServiceSetCharm(serviceName string, charmUrl string, force bool
 	return c.call("ServiceSetCharm", args, nil)
 }
 
+// ServiceSetCharmRollout sets the charm for a given service, and
+// upgrades its units batchSize at a time. If waitHealthy is true, the
+// units of each batch must be started again before the next batch is
+// upgraded.
+func (c *Client) ServiceSetCharmRollout(serviceName string, charmUrl string, force bool, batchSize int, waitHealthy bool) error {
+	args := params.ServiceSetCharm{
+		ServiceName: serviceName,
+		CharmUrl:    charmUrl,
+		Force:       force,
+		BatchSize:   batchSize,
+		WaitHealthy: waitHealthy,
+	}
+	return c.call("ServiceSetCharm", args, nil)
+}
+
 // ServiceGetCharmURL returns the charm URL the given service is
 // running at present.
 func (c *Client) ServiceGetCharmURL(serviceName string) (*charm.URL, error) {
@@ -357,6 +506,20 @@ func (c *Client) ServiceGetCharmURL(serviceName string) (*charm.URL, error) {
 	return charm.ParseURL(result.Result)
 }
 
+// CharmDiff reports what upgrading the given service to the charm at
+// charmURL, which must have been added to the environment, would change.
+func (c *Client) CharmDiff(serviceName, charmURL string) (*params.CharmDiffResults, error) {
+	args := params.CharmDiff{
+		ServiceName: serviceName,
+		CharmURL:    charmURL,
+	}
+	result := new(params.CharmDiffResults)
+	if err := c.call("CharmDiff", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // AddServiceUnits adds a given number of units to a service.
 func (c *Client) AddServiceUnits(service string, numUnits int, machineSpec string) ([]string, error) {
 	args := params.AddServiceUnits{
@@ -512,6 +675,50 @@ func (c *Client) SetEnvironAgentVersion(version version.Number) error {
 	return c.call("SetEnvironAgentVersion", args, nil)
 }
 
+// PublishCACert replaces the CA certificates that agents and clients
+// trust, without changing the state server certificate. The first
+// certificate in the bundle is the one that will sign the next state
+// server certificate.
+func (c *Client) PublishCACert(caCert string) error {
+	args := params.PublishCACert{CACert: caCert}
+	return c.call("PublishCACert", args, nil)
+}
+
+// CACertPendingAgents returns the tags of the running agents that
+// have not yet acknowledged the published CA certificates.
+func (c *Client) CACertPendingAgents() ([]string, error) {
+	var result params.CACertPendingAgents
+	err := c.call("CACertPendingAgents", nil, &result)
+	return result.Agents, err
+}
+
+// NewStateServerKey asks the state server to generate a new key,
+// and returns its PEM-encoded public key along with the host names
+// the state server certificate should be valid for.
+func (c *Client) NewStateServerKey() (publicKey string, hostnames []string, err error) {
+	var result params.StateServerKey
+	if err := c.call("NewStateServerKey", nil, &result); err != nil {
+		return "", nil, err
+	}
+	return result.PublicKey, result.Hostnames, nil
+}
+
+// SetStateServerCert switches the state server certificate to the
+// given one, which must be signed for the key returned by
+// NewStateServerKey.
+func (c *Client) SetStateServerCert(cert string) error {
+	args := params.SetStateServerCert{Cert: cert}
+	return c.call("SetStateServerCert", args, nil)
+}
+
+// CertificatesExpiry returns the times at which the environment's CA
+// certificate and the state server certificate expire.
+func (c *Client) CertificatesExpiry() (params.CertificatesExpiry, error) {
+	var result params.CertificatesExpiry
+	err := c.call("CertificatesExpiry", nil, &result)
+	return result, err
+}
+
 // FindTools returns a List containing all tools matching the specified parameters.
 func (c *Client) FindTools(majorVersion, minorVersion int,
 	series, arch string) (result params.FindToolsResults, err error) {
@@ -561,6 +768,7 @@ func (c *Client) AddLocalCharm(curl *charm.URL, ch charm.Charm) (*charm.URL, err
 	}
 	// Package the charm for uploading.
 	var archive *os.File
+	var signature []byte
 	switch ch := ch.(type) {
 	case *charm.Dir:
 		var err error
@@ -581,13 +789,21 @@ func (c *Client) AddLocalCharm(curl *charm.URL, ch charm.Charm) (*charm.URL, err
 			return nil, fmt.Errorf("cannot read charm archive: %v", err)
 		}
 		defer archive.Close()
+		// Send along the detached signature of the archive, if there's one.
+		signature, err = ioutil.ReadFile(ch.Path + charm.SignatureSuffix)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("cannot read charm signature: %v", err)
+		}
 	default:
 		return nil, fmt.Errorf("unknown charm type %T", ch)
 	}
 
 	// Prepare the upload request.
-	url := fmt.Sprintf("%s/charms?series=%s", c.st.serverRoot, curl.Series)
-	req, err := http.NewRequest("POST", url, archive)
+	uploadURL := fmt.Sprintf("%s/charms?series=%s", c.st.serverRoot, curl.Series)
+	if len(signature) > 0 {
+		uploadURL += "&signature=" + url.QueryEscape(string(signature))
+	}
+	req, err := http.NewRequest("POST", uploadURL, archive)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create upload request: %v", err)
 	}
@@ -633,6 +849,48 @@ func (c *Client) AddLocalCharm(curl *charm.URL, ch charm.Charm) (*charm.URL, err
 	return charm.MustParseURL(jsonResponse.CharmURL), nil
 }
 
+// AttachResource uploads the given content as a new revision of the
+// named resource of a service, and returns the resource.
+func (c *Client) AttachResource(service, name string, content io.Reader) (params.ServiceResource, error) {
+	nothing := params.ServiceResource{}
+	uploadURL := fmt.Sprintf("%s/resources?service=%s&name=%s",
+		c.st.serverRoot, url.QueryEscape(service), url.QueryEscape(name))
+	req, err := http.NewRequest("POST", uploadURL, content)
+	if err != nil {
+		return nothing, fmt.Errorf("cannot create upload request: %v", err)
+	}
+	req.SetBasicAuth(c.st.tag, c.st.password)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	// See the comment in AddLocalCharm about the HTTP client.
+	resp, err := utils.GetNonValidatingHTTPClient().Do(req)
+	if err != nil {
+		return nothing, fmt.Errorf("cannot upload resource: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nothing, &params.Error{
+			Message: "resource upload is not supported by the API server",
+			Code:    params.CodeNotImplemented,
+		}
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nothing, fmt.Errorf("cannot read resource upload response: %v", err)
+	}
+	var jsonResponse params.ResourcesResponse
+	if err := json.Unmarshal(body, &jsonResponse); err != nil {
+		return nothing, fmt.Errorf("cannot unmarshal upload response: %v", err)
+	}
+	if jsonResponse.Error != "" {
+		return nothing, fmt.Errorf("error uploading resource: %v", jsonResponse.Error)
+	}
+	if jsonResponse.Resource == nil {
+		return nothing, fmt.Errorf("resource upload response holds no resource")
+	}
+	return *jsonResponse.Resource, nil
+}
+
 // AddCharm adds the given charm URL (which must include revision) to
 // the environment, if it does not exist yet. Local charms are not
 // supported, only charm store URLs. See also AddLocalCharm() in the
@@ -738,6 +996,33 @@ func (c *Client) EnsureAvailability(numStateServers int, cons constraints.Value,
 	return c.call("EnsureAvailability", args, nil)
 }
 
+// RotateAgentCredentials asks the agents of the given machines, and of
+// their units, to replace their passwords. If no machines are given,
+// all agents in the environment are asked.
+func (c *Client) RotateAgentCredentials(machineIds ...string) error {
+	args := params.RotateAgentCredentials{MachineIds: machineIds}
+	return c.call("RotateAgentCredentials", args, nil)
+}
+
+// ContainerTemplates returns the container templates cached on the
+// given machine, and the time its agent last reported them.
+func (c *Client) ContainerTemplates(machineId string) (params.ContainerTemplatesResult, error) {
+	var result params.ContainerTemplatesResult
+	args := params.ContainerTemplates{Machine: machineId}
+	err := c.call("ContainerTemplates", args, &result)
+	return result, err
+}
+
+// PurgeContainerTemplates asks the agent of the given machine to purge
+// the named container templates, or all of them if no names are given.
+func (c *Client) PurgeContainerTemplates(machineId string, names ...string) error {
+	args := params.PurgeContainerTemplates{
+		Machine: machineId,
+		Names:   names,
+	}
+	return c.call("PurgeContainerTemplates", args, nil)
+}
+
 // AgentVersion reports the version number of the api server.
 func (c *Client) AgentVersion() (version.Number, error) {
 	var result params.AgentVersionResult
@@ -16,11 +16,13 @@ import (
 	"strings"
 
 	"code.google.com/p/go.net/websocket"
+	"github.com/juju/errors"
 	"github.com/juju/loggo"
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/core/charm"
+	charmtesting "github.com/juju/core/charm/testing"
 	jujutesting "github.com/juju/core/juju/testing"
 	"github.com/juju/core/state/api"
 	"github.com/juju/core/state/api/params"
@@ -44,6 +46,18 @@ func (s *clientSuite) TestCloseMultipleOk(c *gc.C) {
 	c.Assert(client.Close(), gc.IsNil)
 }
 
+func (s *clientSuite) TestServiceSetAtRevisionNotSupported(c *gc.C) {
+	// Servers serving only version 1 of the Client facade may
+	// ignore the settings revision.
+	api.SetServerFacades(s.APIState, map[string][]int{"Client": {0, 1}})
+	client := s.APIState.Client()
+	err := client.ServiceSetAtRevision("dummy", map[string]string{"title": "foo"}, 0)
+	c.Assert(err, gc.ErrorMatches, "setting service configuration at a revision not supported")
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+	err = client.ServiceSetYAMLAtRevision("dummy", "dummy:\n  title: foo\n", 0)
+	c.Assert(err, gc.ErrorMatches, "setting service configuration at a revision not supported")
+}
+
 func (s *clientSuite) TestAddLocalCharm(c *gc.C) {
 	charmArchive := testing.Charms.Bundle(c.MkDir(), "dummy")
 	curl := charm.MustParseURL(
@@ -93,6 +107,33 @@ func (s *clientSuite) TestAddLocalCharm(c *gc.C) {
 	c.Assert(err, jc.Satisfies, params.IsCodeNotImplemented)
 }
 
+func (s *clientSuite) TestAddLocalCharmSendsSignature(c *gc.C) {
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"charm-verification": "strict",
+		"charm-trusted-keys": charmtesting.SigningPublicKey,
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	charmArchive := testing.Charms.Bundle(c.MkDir(), "dummy")
+	curl := charm.MustParseURL(
+		fmt.Sprintf("local:quantal/%s-%d", charmArchive.Meta().Name, charmArchive.Revision()),
+	)
+	client := s.APIState.Client()
+
+	_, err = client.AddLocalCharm(curl, charmArchive)
+	c.Assert(err, gc.ErrorMatches, ".*charm archive is not signed")
+
+	// The signature is picked up from next to the archive.
+	data, err := ioutil.ReadFile(charmArchive.Path)
+	c.Assert(err, gc.IsNil)
+	signature, err := charm.SignArchive(bytes.NewReader(data), charmtesting.SigningPrivateKey, "")
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(charmArchive.Path+charm.SignatureSuffix, signature, 0644)
+	c.Assert(err, gc.IsNil)
+	savedURL, err := client.AddLocalCharm(curl, charmArchive)
+	c.Assert(err, gc.IsNil)
+	c.Assert(savedURL.String(), gc.Equals, curl.String())
+}
+
 func (s *clientSuite) TestWatchDebugLogConnected(c *gc.C) {
 	// Shows both the unmarshalling of a real error, and
 	// that the api server is connected.
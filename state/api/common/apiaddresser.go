@@ -69,3 +69,15 @@ func (a *APIAddresser) WatchAPIHostPorts() (watcher.NotifyWatcher, error) {
 	}
 	return watcher.NewNotifyWatcher(a.caller, result), nil
 }
+
+// WatchCACert watches the CA certificate used to validate the API and
+// state connections, which changes when the environment's
+// certificates are rotated.
+func (a *APIAddresser) WatchCACert() (watcher.NotifyWatcher, error) {
+	var result params.NotifyWatchResult
+	err := a.caller.Call(a.facadeName, "", "WatchCACert", nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return watcher.NewNotifyWatcher(a.caller, result), nil
+}
@@ -15,3 +15,11 @@ var (
 func SetServerRoot(c *Client, root string) {
 	c.st.serverRoot = root
 }
+
+var FacadeVersions = &facadeVersions
+
+// SetServerFacades replaces the facade versions the
+// API server reported as supported when st logged in.
+func SetServerFacades(st *State, facades map[string][]int) {
+	st.serverFacades = facades
+}
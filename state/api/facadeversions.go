@@ -0,0 +1,11 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package api
+
+// facadeVersions holds the newest version of each facade that
+// this client knows how to use. Facades not listed here are
+// only used at version 0.
+var facadeVersions = map[string]int{
+	"Client": 2,
+}
@@ -67,6 +67,11 @@ const (
 	StatusDown Status = "down"
 )
 
+// StatusInfoUpgradingCharm is the status info of a unit that is
+// upgrading its charm: the unit agent reports StatusInstalled with it
+// from setting the new charm URL until the upgrade-charm hook succeeds.
+const StatusInfoUpgradingCharm = "upgrading charm"
+
 // Valid returns true if status has a known value.
 func (status Status) Valid() bool {
 	switch status {
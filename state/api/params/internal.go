@@ -148,6 +148,18 @@ type BoolResults struct {
 	Results []BoolResult
 }
 
+// IntResult holds the result of an API call that returns an
+// int or an error.
+type IntResult struct {
+	Error  *Error
+	Result int
+}
+
+// IntResults holds multiple results with IntResult each.
+type IntResults struct {
+	Results []IntResult
+}
+
 // RelationSettings holds relation settings names and values.
 type RelationSettings map[string]string
 
@@ -203,6 +215,87 @@ type RelationIds struct {
 	RelationIds []int
 }
 
+// UnitNetwork holds a unit and a network tag.
+type UnitNetwork struct {
+	Unit    string
+	Network string
+}
+
+// UnitNetworks holds the parameters for API calls expecting a pair
+// of unit and network tags.
+type UnitNetworks struct {
+	UnitNetworks []UnitNetwork
+}
+
+// UnitNetworkConfig describes the configuration of a unit on a
+// single network.
+type UnitNetworkConfig struct {
+	// NetworkTag is the network's tag.
+	NetworkTag string
+
+	// CIDR of the network, in "123.45.67.89/12" format.
+	CIDR string
+
+	// VLANTag is between 1 and 4094 for VLANs and 0 for normal
+	// networks.
+	VLANTag int
+
+	// InterfaceNames holds the OS-specific names of the unit's
+	// machine interfaces on the network (e.g. "eth0" or "eth1.42").
+	InterfaceNames []string
+
+	// Addresses holds the unit's machine addresses on the network.
+	Addresses []string
+}
+
+// UnitNetworkConfigResult holds a unit's network configuration or an
+// error.
+type UnitNetworkConfigResult struct {
+	Error  *Error
+	Config UnitNetworkConfig
+}
+
+// UnitNetworkConfigResults holds the bulk operation result of an API
+// call that returns unit network configurations.
+type UnitNetworkConfigResults struct {
+	Results []UnitNetworkConfigResult
+}
+
+// UnitResource holds a unit tag and the name of a resource
+// of the unit's service.
+type UnitResource struct {
+	Unit     string
+	Resource string
+}
+
+// UnitResources holds the parameters for API calls expecting
+// pairs of unit tags and resource names.
+type UnitResources struct {
+	UnitResources []UnitResource
+}
+
+// UnitResourceInfo describes the current revision of a resource of
+// a unit's service, and where the unit can download its content.
+type UnitResourceInfo struct {
+	Filename                       string
+	Revision                       int
+	URL                            string
+	SHA256                         string
+	DisableSSLHostnameVerification bool
+}
+
+// UnitResourceResult holds a unit's resource information or an error.
+type UnitResourceResult struct {
+	Error *Error
+	Info  UnitResourceInfo
+}
+
+// UnitResourceResults holds the bulk operation result of an API call
+// that returns unit resource information.
+type UnitResourceResults struct {
+	Results []UnitResourceResult
+}
+
 // RelationUnitPair holds a relation tag, a local and remote unit tags.
 type RelationUnitPair struct {
 	Relation   string
@@ -568,6 +661,12 @@ type CharmsResponse struct {
 	Files    []string `json:",omitempty"`
 }
 
+// ResourcesResponse is the server response to resource upload requests.
+type ResourcesResponse struct {
+	Error    string           `json:",omitempty"`
+	Resource *ServiceResource `json:",omitempty"`
+}
+
 // RunParams is used to provide the parameters to the Run method.
 // Commands and Timeout are expected to have values, and one or more
 // values should be in the Machines, Services, or Units slices.
@@ -619,3 +718,33 @@ type ProvisioningInfoResult struct {
 type ProvisioningInfoResults struct {
 	Results []ProvisioningInfoResult
 }
+
+// ContainerTemplatePurgeResult holds the latest request to purge the
+// container templates on a machine, or an error. PurgedRevno is the
+// revno of the last request the machine agent acted upon.
+type ContainerTemplatePurgeResult struct {
+	Error       *Error
+	Revno       int
+	Names       []string
+	PurgedRevno int
+}
+
+// ContainerTemplatePurgeResults holds multiple container template
+// purge requests.
+type ContainerTemplatePurgeResults struct {
+	Results []ContainerTemplatePurgeResult
+}
+
+// MachineContainerTemplates holds the container templates cached on
+// a machine, and the revno of the last purge request acted upon.
+type MachineContainerTemplates struct {
+	Tag         string
+	Templates   []ContainerTemplate
+	PurgedRevno int
+}
+
+// SetMachinesContainerTemplates holds the arguments for making a
+// SetContainerTemplates API call.
+type SetMachinesContainerTemplates struct {
+	Machines []MachineContainerTemplates
+}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/juju/core/charm"
 	"github.com/juju/core/constraints"
@@ -139,6 +140,12 @@ type AddMachinesResult struct {
 	Error   *Error
 }
 
+// RotateAgentCredentials holds parameters for the
+// RotateAgentCredentials call.
+type RotateAgentCredentials struct {
+	MachineIds []string
+}
+
 // DestroyMachines holds parameters for the DestroyMachines call.
 type DestroyMachines struct {
 	MachineNames []string
@@ -171,11 +178,16 @@ type ServiceUpdate struct {
 	Constraints     *constraints.Value
 }
 
-// ServiceSetCharm sets the charm for a given service.
+// ServiceSetCharm sets the charm for a given service. If BatchSize
+// is not zero, the service's units are upgraded BatchSize at a time,
+// and if WaitHealthy is true each batch must be started again before
+// the next one is upgraded.
 type ServiceSetCharm struct {
 	ServiceName string
 	CharmUrl    string
 	Force       bool
+	BatchSize   int
+	WaitHealthy bool
 }
 
 // ServiceExpose holds the parameters for making the ServiceExpose call.
@@ -188,14 +200,20 @@ type ServiceExpose struct {
 type ServiceSet struct {
 	ServiceName string
 	Options     map[string]string
+
+	// SettingsRevision, if set, holds the settings revision the
+	// change was based on; the change fails if the settings have
+	// changed since.
+	SettingsRevision *int
 }
 
 // ServiceSetYAML holds the parameters for
 // a ServiceSetYAML command. Config contains the
 // configuration data in YAML format.
 type ServiceSetYAML struct {
-	ServiceName string
-	Config      string
+	ServiceName      string
+	Config           string
+	SettingsRevision *int
 }
 
 // ServiceUnset holds the parameters for a ServiceUnset
@@ -214,10 +232,77 @@ type ServiceGet struct {
 
 // ServiceGetResults holds results of the ServiceGet call.
 type ServiceGetResults struct {
-	Service     string
-	Charm       string
-	Config      map[string]interface{}
-	Constraints constraints.Value
+	Service          string
+	Charm            string
+	Config           map[string]interface{}
+	Constraints      constraints.Value
+	SettingsRevision int
+}
+
+// ServiceConfigHistory holds parameters for making
+// the ServiceConfigHistory call.
+type ServiceConfigHistory struct {
+	ServiceName string
+}
+
+// SettingChange holds a change to a single config setting. Old is
+// omitted if the setting was added, and New if it was deleted.
+type SettingChange struct {
+	Key string
+	Old interface{} `json:",omitempty"`
+	New interface{} `json:",omitempty"`
+}
+
+// ServiceConfigRevision holds a recorded change
+// to a service's config settings.
+type ServiceConfigRevision struct {
+	Revision int
+	User     string
+	Time     time.Time
+	Charm    string
+	Settings map[string]interface{}
+	Changes  []SettingChange
+}
+
+// ServiceConfigHistoryResults holds results of the
+// ServiceConfigHistory call, oldest change first.
+type ServiceConfigHistoryResults struct {
+	Revisions []ServiceConfigRevision
+}
+
+// ServiceRollbackConfig holds parameters for making the
+// ServiceRollbackConfig call.
+type ServiceRollbackConfig struct {
+	ServiceName string
+	Revision    int
+}
+
+// ServiceResources holds parameters for making
+// the ServiceResources call.
+type ServiceResources struct {
+	ServiceName string
+}
+
+// ServiceResource describes a resource of a service: the resource
+// as declared by the service's charm, and the revision of its
+// content attached to the service, if any. Filename is empty if the
+// charm no longer declares the resource, and Revision is 0 if no
+// content is attached.
+type ServiceResource struct {
+	Name        string
+	Filename    string `json:",omitempty"`
+	Description string `json:",omitempty"`
+	Revision    int
+	SHA256      string `json:",omitempty"`
+	Size        int64  `json:",omitempty"`
+	User        string `json:",omitempty"`
+	Time        time.Time
+}
+
+// ServiceResourcesResults holds the results of the
+// ServiceResources call, sorted by resource name.
+type ServiceResourcesResults struct {
+	Resources []ServiceResource
 }
 
 // ServiceCharmRelations holds parameters for making the ServiceCharmRelations call.
@@ -335,6 +420,58 @@ type CharmInfo struct {
 	CharmURL string
 }
 
+// CharmDiff stores parameters for a CharmDiff call.
+type CharmDiff struct {
+	ServiceName string
+	CharmURL    string
+}
+
+// CharmOptionChange holds a config option changed between
+// two charms.
+type CharmOptionChange struct {
+	Old charm.Option
+	New charm.Option
+}
+
+// CharmRelationChange holds a relation endpoint changed
+// between two charms.
+type CharmRelationChange struct {
+	Old charm.Relation
+	New charm.Relation
+}
+
+// CharmDiffResults holds the differences between the charm a
+// service is running and the charm it would be upgraded to.
+type CharmDiffResults struct {
+	OldCharmURL string
+	NewCharmURL string
+
+	AddedOptions   map[string]charm.Option
+	RemovedOptions map[string]charm.Option
+	ChangedOptions map[string]CharmOptionChange
+
+	// InvalidSettings holds the current settings of the service
+	// that the new charm doesn't accept, which would be dropped
+	// by the upgrade.
+	InvalidSettings charm.Settings
+
+	AddedRelations   []charm.Relation
+	RemovedRelations []charm.Relation
+	ChangedRelations []CharmRelationChange
+
+	AddedHooks   []string
+	RemovedHooks []string
+
+	// AddedPeers holds the names of the peer relations
+	// the upgrade would create.
+	AddedPeers []string
+
+	// BrokenRelations holds the existing relations of the
+	// service that the new charm doesn't implement, which
+	// prevent the upgrade.
+	BrokenRelations []string
+}
+
 // ResolveCharms stores charm references for a ResolveCharms call.
 type ResolveCharms struct {
 	References []charm.Reference
@@ -388,6 +525,35 @@ type ModifyUser struct {
 	Password string
 }
 
+// LoginLockout holds the failed logins recorded against a user
+// ("user" kind) or a remote address ("address" kind).
+type LoginLockout struct {
+	Kind        string
+	Name        string
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// LoginLockoutsResult holds the result of a
+// UserManager.LoginLockouts call.
+type LoginLockoutsResult struct {
+	Lockouts []LoginLockout
+}
+
+// LoginLockoutId identifies a user or remote address
+// in a UserManager.ClearLoginLockouts call.
+type LoginLockoutId struct {
+	Kind string
+	Name string
+}
+
+// LoginLockoutIds holds the parameters for a
+// UserManager.ClearLoginLockouts call.
+type LoginLockoutIds struct {
+	Ids []LoginLockoutId
+}
+
 // MarshalJSON implements json.Marshaler.
 func (d *Delta) MarshalJSON() ([]byte, error) {
 	b, err := json.Marshal(d.Entity)
@@ -664,6 +830,65 @@ type SetEnvironAgentVersion struct {
 	Version version.Number
 }
 
+// PublishCACert contains the arguments for the PublishCACert
+// client API call.
+type PublishCACert struct {
+	// CACert holds the CA certificates that agents and clients
+	// should trust. The first certificate is the one that will
+	// sign the next state server certificate; any others are CA
+	// certificates being replaced.
+	CACert string
+}
+
+// CACertPendingAgents holds the result of the CACertPendingAgents
+// client API call.
+type CACertPendingAgents struct {
+	// Agents holds the tags of the running agents that have not
+	// yet acknowledged the published CA certificates.
+	Agents []string
+}
+
+// StateServerKey holds the result of the NewStateServerKey
+// client API call.
+type StateServerKey struct {
+	// PublicKey holds the PEM-encoded public half of a key
+	// generated on the state server. The private half never
+	// leaves the state server.
+	PublicKey string
+
+	// Hostnames holds the names and addresses that the new state
+	// server certificate should be valid for.
+	Hostnames []string
+}
+
+// SetStateServerCert contains the arguments for the
+// SetStateServerCert client API call.
+type SetStateServerCert struct {
+	// Cert holds the state server certificate, signed
+	// for the key returned by NewStateServerKey.
+	Cert string
+}
+
+// AgentCACert holds the CA certificates an agent has
+// started trusting.
+type AgentCACert struct {
+	Tag    string
+	CACert string
+}
+
+// AgentCACerts holds the arguments for the AcknowledgeCACert
+// agent API call.
+type AgentCACerts struct {
+	Agents []AgentCACert
+}
+
+// CertificatesExpiry holds the result of the CertificatesExpiry
+// client API call.
+type CertificatesExpiry struct {
+	CACert     time.Time
+	ServerCert time.Time
+}
+
 // DeployerConnectionValues containers the result of deployer.ConnectionInfo
 // API call.
 type DeployerConnectionValues struct {
@@ -720,6 +945,19 @@ type APIHostPortsResult struct {
 // LoginResult holds the result of a Login call.
 type LoginResult struct {
 	Servers [][]instance.HostPort
+
+	// Facades holds the versions of each facade the API server
+	// supports. It is empty when the server predates facade
+	// versioning, which means only version 0 of each facade
+	// is supported.
+	Facades []FacadeVersions
+}
+
+// FacadeVersions holds the versions of a facade
+// supported by the API server.
+type FacadeVersions struct {
+	Name     string
+	Versions []int
 }
 
 // EnsureAvailability contains arguments for
@@ -731,3 +969,86 @@ type EnsureAvailability struct {
 	// If this is empty, then the environment's default series is used.
 	Series string
 }
+
+// WebhookAdd holds the parameters for making the WebhookAdd call.
+// If Secret is empty, one is generated.
+type WebhookAdd struct {
+	URL         string
+	Secret      string
+	EntityKinds []string
+	EntityIds   []string
+}
+
+// Webhook describes a webhook subscription.
+type Webhook struct {
+	Id          string
+	URL         string
+	Secret      string `json:",omitempty"`
+	EntityKinds []string
+	EntityIds   []string
+	// Failures holds the number of deliveries to
+	// the webhook that failed, even after retrying.
+	Failures int
+}
+
+// WebhooksResults holds the result of the Webhooks call.
+type WebhooksResults struct {
+	Webhooks []Webhook
+}
+
+// WebhookRemove holds the parameters for making the WebhookRemove call.
+type WebhookRemove struct {
+	Id string
+}
+
+// WebhookFailures holds the parameters for making the WebhookFailures call.
+type WebhookFailures struct {
+	Id string
+}
+
+// WebhookFailure describes a delivery to a webhook
+// that failed, even after retrying.
+type WebhookFailure struct {
+	URL      string
+	Payload  string
+	Attempts int
+	Error    string
+	Time     time.Time
+}
+
+// WebhookFailuresResults holds the result of the WebhookFailures call.
+type WebhookFailuresResults struct {
+	Failures []WebhookFailure
+}
+
+// ContainerTemplate describes a template that containers are cloned
+// from, cached on the machine hosting the containers.
+type ContainerTemplate struct {
+	Name    string
+	Type    instance.ContainerType
+	Series  string
+	Arch    string
+	Tools   version.Number
+	Created time.Time
+}
+
+// ContainerTemplates holds the parameters for making the
+// ContainerTemplates call.
+type ContainerTemplates struct {
+	Machine string
+}
+
+// ContainerTemplatesResult holds the container templates cached on a
+// machine, and the time they were last reported by its agent.
+type ContainerTemplatesResult struct {
+	Templates []ContainerTemplate
+	Reported  time.Time
+}
+
+// PurgeContainerTemplates holds the parameters for making the
+// PurgeContainerTemplates call. All the templates on the machine
+// are purged if no names are given.
+type PurgeContainerTemplates struct {
+	Machine string
+	Names   []string
+}
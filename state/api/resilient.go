@@ -0,0 +1,364 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"launchpad.net/tomb"
+
+	"github.com/juju/core/environs/configstore"
+	"github.com/juju/core/instance"
+	"github.com/juju/core/state/api/params"
+)
+
+// The following define how long a ResilientState waits between
+// attempts to reconnect: the delay starts at ReconnectDelay and is
+// doubled after each failed attempt, up to MaxReconnectDelay. They
+// are variables so they can be changed in tests.
+var (
+	ReconnectDelay    = time.Second
+	MaxReconnectDelay = time.Minute
+)
+
+// ErrConnectionClosed is returned when a ResilientState
+// is used after it has been closed.
+var ErrConnectionClosed = errors.New("API connection closed")
+
+// ResilientState is a connection to the API server that survives the
+// loss of the server it is connected to. When the connection breaks,
+// it reconnects, trying concurrently all the API server addresses it
+// knows about, including those reported by the servers themselves.
+type ResilientState struct {
+	tomb  tomb.Tomb
+	info  Info
+	opts  DialOpts
+	cache configstore.EnvironInfo
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// st holds the current connection.
+	st *State
+	// changed is closed, and replaced, when st changes.
+	changed chan struct{}
+	// addrs holds the addresses tried when reconnecting.
+	addrs []string
+}
+
+// OpenResilient opens a connection to the API server, as Open does,
+// that reconnects whenever it is broken. If cache is not nil, the API
+// server addresses stored in it are updated whenever the servers report
+// new ones, so that they can be used by later connections.
+func OpenResilient(info *Info, opts DialOpts, cache configstore.EnvironInfo) (*ResilientState, error) {
+	st, err := Open(info, opts)
+	if err != nil {
+		return nil, err
+	}
+	r := &ResilientState{
+		info:    *info,
+		opts:    opts,
+		cache:   cache,
+		changed: make(chan struct{}),
+		addrs:   append([]string{}, info.Addrs...),
+	}
+	r.setState(st)
+	go func() {
+		defer r.tomb.Done()
+		r.tomb.Kill(r.loop())
+	}()
+	return r, nil
+}
+
+// State returns the current connection to the API server. While the
+// connection is being re-established, the broken connection is
+// returned, so calls made on it fail rather than block.
+func (r *ResilientState) State() (*State, error) {
+	return r.stateAfter(nil)
+}
+
+// Client returns an object that can be used to access client-specific
+// functionality through the current connection.
+func (r *ResilientState) Client() (*Client, error) {
+	st, err := r.State()
+	if err != nil {
+		return nil, err
+	}
+	return st.Client(), nil
+}
+
+// Addrs returns the addresses that are tried when reconnecting.
+func (r *ResilientState) Addrs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.addrs...)
+}
+
+// Close closes the current connection and stops reconnecting.
+func (r *ResilientState) Close() error {
+	r.tomb.Kill(nil)
+	return r.tomb.Wait()
+}
+
+// Dead returns a channel that is closed when
+// the ResilientState has stopped reconnecting.
+func (r *ResilientState) Dead() <-chan struct{} {
+	return r.tomb.Dead()
+}
+
+// stateAfter returns the current connection, waiting for
+// a new one to be established if it is the given one.
+func (r *ResilientState) stateAfter(old *State) (*State, error) {
+	for {
+		select {
+		case <-r.tomb.Dying():
+			return nil, ErrConnectionClosed
+		default:
+		}
+		r.mu.Lock()
+		st, changed := r.st, r.changed
+		r.mu.Unlock()
+		if st != old {
+			return st, nil
+		}
+		select {
+		case <-changed:
+		case <-r.tomb.Dying():
+			return nil, ErrConnectionClosed
+		}
+	}
+}
+
+// setState makes st the current connection, and records
+// the API server addresses that it reports.
+func (r *ResilientState) setState(st *State) {
+	addrs := mergeAddrs(r.info.Addrs, CacheableAddrs(st.APIHostPorts()))
+	r.mu.Lock()
+	r.st = st
+	r.addrs = addrs
+	close(r.changed)
+	r.changed = make(chan struct{})
+	r.mu.Unlock()
+	if r.cache != nil {
+		if err := CacheChangedAddrs(r.cache, st.APIHostPorts()); err != nil {
+			logger.Warningf("cannot cache API addresses: %v", err)
+		}
+	}
+}
+
+func (r *ResilientState) loop() error {
+	for {
+		r.mu.Lock()
+		st := r.st
+		r.mu.Unlock()
+		select {
+		case <-r.tomb.Dying():
+			st.Close()
+			return tomb.ErrDying
+		case <-st.Broken():
+		case <-st.client.Dead():
+		}
+		logger.Warningf("connection to API server %q broken; reconnecting", st.Addr())
+		st.Close()
+		newSt, err := r.reconnect()
+		if err != nil {
+			return err
+		}
+		logger.Infof("reconnected to API server %q", newSt.Addr())
+		r.setState(newSt)
+	}
+}
+
+// reconnect tries to connect to the API server until it
+// succeeds, or the ResilientState is closed.
+func (r *ResilientState) reconnect() (*State, error) {
+	delay := ReconnectDelay
+	for {
+		info := r.info
+		info.Addrs = r.Addrs()
+		st, err := Open(&info, r.opts)
+		if err == nil {
+			return st, nil
+		}
+		logger.Warningf("cannot reconnect to API server, will retry in %v: %v", delay, err)
+		select {
+		case <-r.tomb.Dying():
+			return nil, tomb.ErrDying
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > MaxReconnectDelay {
+			delay = MaxReconnectDelay
+		}
+	}
+}
+
+// isBroken returns whether the connection is known to be broken.
+func (s *State) isBroken() bool {
+	select {
+	case <-s.broken:
+		return true
+	case <-s.client.Dead():
+		return true
+	default:
+		return false
+	}
+}
+
+// WatchAll returns an AllWatcher that survives reconnections. When the
+// connection is re-established, the watcher is restarted, and the next
+// deltas describe the whole environment again, as they do when a
+// watcher is first started.
+func (r *ResilientState) WatchAll() *ResilientAllWatcher {
+	return &ResilientAllWatcher{r: r}
+}
+
+// ResilientAllWatcher is an AllWatcher that is restarted
+// when the connection to the API server is re-established.
+type ResilientAllWatcher struct {
+	r *ResilientState
+
+	// mu guards the fields below.
+	mu      sync.Mutex
+	st      *State
+	watcher *AllWatcher
+	stopped bool
+}
+
+// Next returns the changes to the environment since Next was
+// last called, blocking until there are some.
+func (w *ResilientAllWatcher) Next() ([]params.Delta, error) {
+	for {
+		st, watcher, err := w.start()
+		if err != nil {
+			return nil, err
+		}
+		deltas, err := watcher.Next()
+		if err == nil || !st.isBroken() {
+			return deltas, err
+		}
+		logger.Debugf("restarting AllWatcher after connection failure: %v", err)
+		if err := w.restart(st); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Stop stops the watcher.
+func (w *ResilientAllWatcher) Stop() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopped = true
+	if w.watcher == nil {
+		return nil
+	}
+	err := w.watcher.Stop()
+	if w.st.isBroken() {
+		// The watcher has gone with the connection.
+		return nil
+	}
+	return err
+}
+
+// start returns the current watcher, starting one
+// on the current connection if there is none.
+func (w *ResilientAllWatcher) start() (*State, *AllWatcher, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return nil, nil, ErrConnectionClosed
+	}
+	if w.watcher != nil {
+		return w.st, w.watcher, nil
+	}
+	st, err := w.r.State()
+	if err != nil {
+		return nil, nil, err
+	}
+	watcher, err := st.Client().WatchAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	w.st, w.watcher = st, watcher
+	return st, watcher, nil
+}
+
+// restart waits for a connection to replace the broken one,
+// and arranges for the watcher to be started on it.
+func (w *ResilientAllWatcher) restart(broken *State) error {
+	if _, err := w.r.stateAfter(broken); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.st, w.watcher = nil, nil
+	w.mu.Unlock()
+	return nil
+}
+
+// CacheableAddrs returns the addresses of the given API servers
+// that are worth storing for later connections, excluding localhost
+// style ones, and IPv6 ones unless IPv6 is preferred.
+func CacheableAddrs(hostPorts [][]instance.HostPort) []string {
+	var addrs []string
+	for _, serverHostPorts := range hostPorts {
+		for _, hostPort := range serverHostPorts {
+			switch {
+			case hostPort.Type == instance.Ipv6Address && !instance.PreferIPv6():
+			case hostPort.NetworkScope == instance.NetworkMachineLocal:
+			case hostPort.NetworkScope == instance.NetworkLinkLocal:
+			default:
+				addrs = append(addrs, hostPort.NetAddr())
+			}
+		}
+	}
+	return addrs
+}
+
+// CacheChangedAddrs updates the API endpoint stored in the given
+// environment information (.jenv file) with the cacheable addresses
+// of the given API servers, if they have changed.
+func CacheChangedAddrs(info configstore.EnvironInfo, hostPorts [][]instance.HostPort) error {
+	addrs := CacheableAddrs(hostPorts)
+	endpoint := info.APIEndpoint()
+	if len(addrs) == 0 || equalAddrs(endpoint.Addresses, addrs) {
+		return nil
+	}
+	logger.Debugf("API addresses changed from %q to %q", endpoint.Addresses, addrs)
+	endpoint.Addresses = addrs
+	info.SetAPIEndpoint(endpoint)
+	if err := info.Write(); err != nil {
+		return err
+	}
+	logger.Infof("updated API connection settings cache")
+	return nil
+}
+
+// mergeAddrs returns the addresses in a followed
+// by those in b that are not in a.
+func mergeAddrs(a, b []string) []string {
+	merged := append([]string{}, a...)
+	seen := make(map[string]bool)
+	for _, addr := range a {
+		seen[addr] = true
+	}
+	for _, addr := range b {
+		if !seen[addr] {
+			seen[addr] = true
+			merged = append(merged, addr)
+		}
+	}
+	return merged
+}
+
+func equalAddrs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
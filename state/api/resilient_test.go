@@ -0,0 +1,223 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package api_test
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/environs/configstore"
+	"github.com/juju/core/instance"
+	jujutesting "github.com/juju/core/juju/testing"
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api"
+	coretesting "github.com/juju/core/testing"
+)
+
+type resilientSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&resilientSuite{})
+
+func (s *resilientSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.PatchValue(&api.ReconnectDelay, 10*time.Millisecond)
+}
+
+// proxy forwards TCP connections to a target
+// address, and can break the connections it makes.
+type proxy struct {
+	listener net.Listener
+	target   string
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newProxy(c *gc.C, target string) *proxy {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, gc.IsNil)
+	p := &proxy{listener: listener, target: target}
+	go p.run()
+	return p
+}
+
+func (p *proxy) run() {
+	for {
+		client, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		server, err := net.Dial("tcp", p.target)
+		if err != nil {
+			client.Close()
+			continue
+		}
+		p.mu.Lock()
+		p.conns = append(p.conns, client, server)
+		p.mu.Unlock()
+		go io.Copy(client, server)
+		go io.Copy(server, client)
+	}
+}
+
+func (p *proxy) addr() string {
+	return p.listener.Addr().String()
+}
+
+// breakConns closes all the connections made through the proxy.
+func (p *proxy) breakConns() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range p.conns {
+		conn.Close()
+	}
+	p.conns = nil
+}
+
+func (p *proxy) close() {
+	p.listener.Close()
+	p.breakConns()
+}
+
+// openThroughProxy opens a resilient connection
+// to the API server through a new proxy.
+func (s *resilientSuite) openThroughProxy(c *gc.C, cache configstore.EnvironInfo) (*api.ResilientState, *proxy) {
+	info := s.APIInfo(c)
+	p := newProxy(c, info.Addrs[0])
+	info.Addrs = []string{p.addr()}
+	r, err := api.OpenResilient(info, api.DialOpts{}, cache)
+	c.Assert(err, gc.IsNil)
+	return r, p
+}
+
+// waitForNewState waits for r to replace the given connection.
+func waitForNewState(c *gc.C, r *api.ResilientState, old *api.State) *api.State {
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		st, err := r.State()
+		c.Assert(err, gc.IsNil)
+		if st != old {
+			return st
+		}
+	}
+	c.Fatalf("timed out waiting for reconnection")
+	panic("unreachable")
+}
+
+func (s *resilientSuite) TestReconnect(c *gc.C) {
+	r, p := s.openThroughProxy(c, nil)
+	defer p.close()
+	defer r.Close()
+	st, err := r.State()
+	c.Assert(err, gc.IsNil)
+	c.Assert(st.Ping(), gc.IsNil)
+
+	p.breakConns()
+	newSt := waitForNewState(c, r, st)
+	c.Assert(newSt.Ping(), gc.IsNil)
+	client, err := r.Client()
+	c.Assert(err, gc.IsNil)
+	_, err = client.Status(nil)
+	c.Assert(err, gc.IsNil)
+}
+
+// setPublicAPIAddress makes the API server report its
+// address as a public one, and returns that address.
+func (s *resilientSuite) setPublicAPIAddress(c *gc.C) string {
+	serverAddr := s.APIInfo(c).Addrs[0]
+	host, portString, err := net.SplitHostPort(serverAddr)
+	c.Assert(err, gc.IsNil)
+	port, err := strconv.Atoi(portString)
+	c.Assert(err, gc.IsNil)
+	hostPort := instance.HostPort{instance.NewAddress(host, instance.NetworkPublic), port}
+	err = s.State.SetAPIHostPorts([][]instance.HostPort{{hostPort}})
+	c.Assert(err, gc.IsNil)
+	return hostPort.NetAddr()
+}
+
+func (s *resilientSuite) TestFailover(c *gc.C) {
+	serverAddr := s.setPublicAPIAddress(c)
+	r, p := s.openThroughProxy(c, nil)
+	defer r.Close()
+	c.Assert(r.Addrs(), gc.DeepEquals, []string{p.addr(), serverAddr})
+	st, err := r.State()
+	c.Assert(err, gc.IsNil)
+
+	// With the proxy gone, the only way to reconnect is
+	// through the address reported by the API server.
+	p.close()
+	newSt := waitForNewState(c, r, st)
+	c.Assert(newSt.Addr(), gc.Equals, serverAddr)
+	c.Assert(newSt.Ping(), gc.IsNil)
+}
+
+func (s *resilientSuite) TestCachesAddresses(c *gc.C) {
+	serverAddr := s.setPublicAPIAddress(c)
+	info, err := configstore.NewMem().CreateInfo("foo")
+	c.Assert(err, gc.IsNil)
+	info.SetAPIEndpoint(configstore.APIEndpoint{Addresses: []string{"0.1.2.3:1234"}})
+	r, p := s.openThroughProxy(c, info)
+	defer p.close()
+	defer r.Close()
+	c.Assert(info.APIEndpoint().Addresses, gc.DeepEquals, []string{serverAddr})
+}
+
+func (s *resilientSuite) TestWatchAllRestarts(c *gc.C) {
+	r, p := s.openThroughProxy(c, nil)
+	defer p.close()
+	defer r.Close()
+	w := r.WatchAll()
+	defer w.Stop()
+	_, err := w.Next()
+	c.Assert(err, gc.IsNil)
+
+	p.breakConns()
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	// The restarted watcher reports the whole environment,
+	// including the machine added while it was disconnected.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			deltas, err := w.Next()
+			if !c.Check(err, gc.IsNil) {
+				return
+			}
+			for _, d := range deltas {
+				id := d.Entity.EntityId()
+				if id.Kind == "machine" && id.Id == m.Id() {
+					return
+				}
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for machine delta")
+	}
+}
+
+func (s *resilientSuite) TestClose(c *gc.C) {
+	r, p := s.openThroughProxy(c, nil)
+	defer p.close()
+	err := r.Close()
+	c.Assert(err, gc.IsNil)
+	_, err = r.State()
+	c.Assert(err, gc.Equals, api.ErrConnectionClosed)
+	_, err = r.WatchAll().Next()
+	c.Assert(err, gc.Equals, api.ErrConnectionClosed)
+	select {
+	case <-r.Dead():
+	default:
+		c.Fatalf("resilient state not dead after close")
+	}
+}
@@ -42,6 +42,10 @@ func (st *State) Login(tag, password, nonce string) error {
 			return err
 		}
 		st.hostPorts = hostPorts
+		st.serverFacades = make(map[string][]int)
+		for _, facade := range result.Facades {
+			st.serverFacades[facade.Name] = facade.Versions
+		}
 	}
 	return err
 }
@@ -36,6 +36,24 @@ func (s *stateSuite) TestCloseMultipleOk(c *gc.C) {
 	c.Assert(s.APIState.Close(), gc.IsNil)
 }
 
+func (s *stateSuite) TestBestFacadeVersion(c *gc.C) {
+	// The server reports the facades it supports at login.
+	c.Assert(s.APIState.BestFacadeVersion("Client"), gc.Equals, 2)
+	c.Assert(s.APIState.BestFacadeVersion("Machiner"), gc.Equals, 0)
+	c.Assert(s.APIState.BestFacadeVersion("Unknown"), gc.Equals, 0)
+
+	// A version is only used if the client knows it.
+	s.PatchValue(api.FacadeVersions, map[string]int{"Client": 0})
+	c.Assert(s.APIState.BestFacadeVersion("Client"), gc.Equals, 0)
+	s.PatchValue(api.FacadeVersions, map[string]int{"Client": 3})
+	api.SetServerFacades(s.APIState, map[string][]int{"Client": {0, 1, 2, 3, 4}})
+	c.Assert(s.APIState.BestFacadeVersion("Client"), gc.Equals, 3)
+
+	// Servers that predate versioning report no facades.
+	api.SetServerFacades(s.APIState, nil)
+	c.Assert(s.APIState.BestFacadeVersion("Client"), gc.Equals, 0)
+}
+
 func (s *stateSuite) TestAPIHostPortsAlwaysIncludesTheConnection(c *gc.C) {
 	hostportslist := s.APIState.APIHostPorts()
 	c.Check(hostportslist, gc.HasLen, 1)
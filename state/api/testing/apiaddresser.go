@@ -29,6 +29,7 @@ type APIAddresserFacade interface {
 	CACert() (string, error)
 	APIHostPorts() ([][]instance.HostPort, error)
 	WatchAPIHostPorts() (watcher.NotifyWatcher, error)
+	WatchCACert() (watcher.NotifyWatcher, error)
 }
 
 func (s *APIAddresserTests) TestAPIAddresses(c *gc.C) {
@@ -104,3 +105,26 @@ func (s *APIAddresserTests) TestWatchAPIHostPorts(c *gc.C) {
 	statetesting.AssertStop(c, w)
 	wc.AssertClosed()
 }
+
+func (s *APIAddresserTests) TestWatchCACert(c *gc.C) {
+	w, err := s.facade.WatchCACert()
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, w)
+
+	wc := statetesting.NewNotifyWatcherC(c, s.state, w)
+
+	// Initial event.
+	wc.AssertOneChange()
+
+	// Rotate the certificates and check that we get a notification.
+	err = s.state.PublishCACert("new CA cert")
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	caCert, err := s.facade.CACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(caCert, gc.Equals, "new CA cert")
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
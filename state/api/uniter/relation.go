@@ -89,3 +89,34 @@ func (r *Relation) Unit(u *Unit) (*RelationUnit, error) {
 		st:       r.st,
 	}, nil
 }
+
+// Networks returns the names of the networks shared by the services
+// in the relation.
+func (r *Relation) Networks() ([]string, error) {
+	var results params.StringsResults
+	args := params.RelationUnits{
+		RelationUnits: []params.RelationUnit{
+			{Relation: r.tag, Unit: r.st.unitTag},
+		},
+	}
+	err := r.st.call("RelationNetworks", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	networkNames := make([]string, len(result.Result))
+	for i, tag := range result.Result {
+		_, name, err := names.ParseTag(tag, names.NetworkTagKind)
+		if err != nil {
+			return nil, err
+		}
+		networkNames[i] = name
+	}
+	return networkNames, nil
+}
@@ -115,3 +115,10 @@ func (s *relationSuite) TestRelationById(c *gc.C) {
 		c.Assert(apiRel, gc.IsNil)
 	}
 }
+
+func (s *relationSuite) TestNetworks(c *gc.C) {
+	// Neither service in the relation is associated with networks.
+	networks, err := s.apiRelation.Networks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(networks, gc.HasLen, 0)
+}
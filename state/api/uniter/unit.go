@@ -450,3 +450,50 @@ func (u *Unit) JoinedRelations() ([]string, error) {
 	}
 	return result.Result, nil
 }
+
+// NetworkConfig returns the unit's configuration on the named network.
+func (u *Unit) NetworkConfig(networkName string) (params.UnitNetworkConfig, error) {
+	nothing := params.UnitNetworkConfig{}
+	var results params.UnitNetworkConfigResults
+	args := params.UnitNetworks{
+		UnitNetworks: []params.UnitNetwork{
+			{Unit: u.tag, Network: names.NetworkTag(networkName)},
+		},
+	}
+	err := u.st.call("NetworkConfig", args, &results)
+	if err != nil {
+		return nothing, err
+	}
+	if len(results.Results) != 1 {
+		return nothing, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nothing, result.Error
+	}
+	return result.Config, nil
+}
+
+// Resource returns the current revision of the named resource of
+// the unit's service, and where its content can be downloaded.
+func (u *Unit) Resource(name string) (params.UnitResourceInfo, error) {
+	nothing := params.UnitResourceInfo{}
+	var results params.UnitResourceResults
+	args := params.UnitResources{
+		UnitResources: []params.UnitResource{
+			{Unit: u.tag, Resource: name},
+		},
+	}
+	err := u.st.call("Resource", args, &results)
+	if err != nil {
+		return nothing, err
+	}
+	if len(results.Results) != 1 {
+		return nothing, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nothing, result.Error
+	}
+	return result.Info, nil
+}
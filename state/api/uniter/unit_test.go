@@ -223,6 +223,43 @@ func (s *unitSuite) TestPrivateAddress(c *gc.C) {
 	c.Assert(address, gc.Equals, "1.2.3.4")
 }
 
+func (s *unitSuite) TestNetworkConfig(c *gc.C) {
+	_, err := s.apiUnit.NetworkConfig("net1")
+	c.Assert(err, gc.ErrorMatches, `network "net1" not found`)
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+
+	err = s.wordpressMachine.SetInstanceInfo("i-am", "fake_nonce", nil,
+		[]state.NetworkInfo{{
+			Name:       "net1",
+			ProviderId: "net1",
+			CIDR:       "0.1.2.0/24",
+		}},
+		[]state.NetworkInterfaceInfo{{
+			MACAddress:    "aa:bb:cc:dd:ee:f0",
+			InterfaceName: "eth0",
+			NetworkName:   "net1",
+		}},
+	)
+	c.Assert(err, gc.IsNil)
+	err = s.wordpressMachine.SetAddresses(instance.NewAddress("0.1.2.3", instance.NetworkCloudLocal))
+	c.Assert(err, gc.IsNil)
+
+	config, err := s.apiUnit.NetworkConfig("net1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(config, gc.DeepEquals, params.UnitNetworkConfig{
+		NetworkTag:     "network-net1",
+		CIDR:           "0.1.2.0/24",
+		InterfaceNames: []string{"eth0"},
+		Addresses:      []string{"0.1.2.3"},
+	})
+}
+
+func (s *unitSuite) TestResource(c *gc.C) {
+	_, err := s.apiUnit.Resource("jdk")
+	c.Assert(err, gc.ErrorMatches, `resource "jdk" in charm "local:quantal/wordpress-3" not found`)
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+}
+
 func (s *unitSuite) TestOpenClosePort(c *gc.C) {
 	ports := s.wordpressUnit.OpenedPorts()
 	c.Assert(ports, gc.HasLen, 0)
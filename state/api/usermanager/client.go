@@ -11,8 +11,6 @@ import (
 	"github.com/juju/core/state/api/params"
 )
 
-// TODO(mattyw) 2014-03-07 bug #1288750
-// Need a SetPassword method.
 type Client struct {
 	st *api.State
 }
@@ -53,3 +51,38 @@ func (c *Client) RemoveUser(tag string) error {
 	}
 	return results.OneError()
 }
+
+// SetPassword changes the password of the given user.
+func (c *Client) SetPassword(tag, password string) error {
+	u := params.EntityPassword{Tag: tag, Password: password}
+	p := params.EntityPasswords{Changes: []params.EntityPassword{u}}
+	results := new(params.ErrorResults)
+	err := c.call("SetPassword", p, results)
+	if err != nil {
+		return err
+	}
+	return results.OneError()
+}
+
+// LoginLockouts returns the failed logins recorded against
+// users and remote addresses, including any current lockouts.
+func (c *Client) LoginLockouts() ([]params.LoginLockout, error) {
+	var result params.LoginLockoutsResult
+	if err := c.call("LoginLockouts", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Lockouts, nil
+}
+
+// ClearLoginLockout forgets the failed logins recorded against the
+// given user ("user" kind) or remote address ("address" kind),
+// lifting any lockout.
+func (c *Client) ClearLoginLockout(kind, name string) error {
+	p := params.LoginLockoutIds{Ids: []params.LoginLockoutId{{Kind: kind, Name: name}}}
+	results := new(params.ErrorResults)
+	err := c.call("ClearLoginLockouts", p, results)
+	if err != nil {
+		return err
+	}
+	return results.OneError()
+}
@@ -4,6 +4,8 @@
 package usermanager_test
 
 import (
+	"time"
+
 	gc "launchpad.net/gocheck"
 
 	jujutesting "github.com/juju/core/juju/testing"
@@ -57,3 +59,48 @@ func (s *usermanagerSuite) TestCantRemoveAdminUser(c *gc.C) {
 	err := s.usermanager.RemoveUser(state.AdminUser)
 	c.Assert(err, gc.ErrorMatches, "Failed to remove user: Can't deactivate admin user")
 }
+
+func (s *usermanagerSuite) TestSetPassword(c *gc.C) {
+	err := s.usermanager.AddUser("foobar", "password")
+	c.Assert(err, gc.IsNil)
+
+	err = s.usermanager.SetPassword("foobar", "new-password")
+	c.Assert(err, gc.IsNil)
+	user, err := s.State.User("foobar")
+	c.Assert(err, gc.IsNil)
+	c.Assert(user.PasswordValid("new-password"), gc.Equals, true)
+}
+
+func (s *usermanagerSuite) TestSetPasswordUnknownUser(c *gc.C) {
+	err := s.usermanager.SetPassword("unknown", "new-password")
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *usermanagerSuite) TestLoginLockouts(c *gc.C) {
+	lockouts, err := s.usermanager.LoginLockouts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(lockouts, gc.HasLen, 0)
+
+	now := time.Now()
+	_, err = s.State.RecordLoginFailure(state.LoginFailureUser, "user-foobar", now)
+	c.Assert(err, gc.IsNil)
+	err = s.State.LockLogins(state.LoginFailureUser, "user-foobar", now.Add(time.Hour))
+	c.Assert(err, gc.IsNil)
+
+	lockouts, err = s.usermanager.LoginLockouts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(lockouts, gc.HasLen, 1)
+	c.Assert(lockouts[0].Kind, gc.Equals, "user")
+	c.Assert(lockouts[0].Name, gc.Equals, "user-foobar")
+	c.Assert(lockouts[0].Failures, gc.Equals, 1)
+	c.Assert(lockouts[0].LockedUntil.After(now), gc.Equals, true)
+
+	err = s.usermanager.ClearLoginLockout("user", "user-foobar")
+	c.Assert(err, gc.IsNil)
+	lockouts, err = s.usermanager.LoginLockouts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(lockouts, gc.HasLen, 0)
+
+	err = s.usermanager.ClearLoginLockout("user", "user-foobar")
+	c.Assert(err, gc.ErrorMatches, `login failures for user "user-foobar" not found`)
+}
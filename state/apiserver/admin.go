@@ -5,12 +5,16 @@ package apiserver
 
 import (
 	stderrors "errors"
+	"net/http"
+	"reflect"
+	"sort"
 	"sync"
 
 	"github.com/juju/errors"
 
 	"github.com/juju/core/names"
 	"github.com/juju/core/rpc"
+	"github.com/juju/core/rpc/rpcreflect"
 	"github.com/juju/core/state"
 	"github.com/juju/core/state/api/params"
 	"github.com/juju/core/state/apiserver/common"
@@ -18,10 +22,15 @@ import (
 	"github.com/juju/core/utils"
 )
 
-func newStateServer(srv *Server, rpcConn *rpc.Conn, reqNotifier *requestNotifier, limiter utils.Limiter) *initialRoot {
+func newStateServer(srv *Server, rpcConn *rpc.Conn, req *http.Request, reqNotifier *requestNotifier, limiter utils.Limiter) *initialRoot {
 	r := &initialRoot{
 		srv:     srv,
 		rpcConn: rpcConn,
+		// The Host header holds the address the client used to
+		// reach this server, which is more useful to report than
+		// the address the server is listening on.
+		serverAddr: req.Host,
+		remoteAddr: remoteHost(req.RemoteAddr),
 	}
 	r.admin = &srvAdmin{
 		root:        r,
@@ -38,6 +47,14 @@ type initialRoot struct {
 	srv     *Server
 	rpcConn *rpc.Conn
 
+	// serverAddr holds the address of this
+	// server as dialed by the client.
+	serverAddr string
+
+	// remoteAddr holds the address of the client,
+	// without any port.
+	remoteAddr string
+
 	admin *srvAdmin
 }
 
@@ -75,15 +92,26 @@ func (a *srvAdmin) Login(c params.Creds) (params.LoginResult, error) {
 		// This can only happen if Login is called concurrently.
 		return params.LoginResult{}, errAlreadyLoggedIn
 	}
-	// Users are not rate limited, all other entities are
-	if kind, err := names.TagKind(c.AuthTag); err != nil || kind != names.UserTagKind {
+	st := a.root.srv.state
+	// Users are not rate limited, all other entities are. Instead,
+	// failed user logins are throttled to defeat password guessing;
+	// other entities have long random passwords, and agents retrying
+	// with stale passwords must not be locked out.
+	var entity taggedAuthenticator
+	var err error
+	if isUserTag(c.AuthTag) {
+		entity, err = checkUserCreds(st, a.root.srv.metrics, c, a.root.remoteAddr)
+	} else {
 		if !a.limiter.Acquire() {
 			logger.Debugf("rate limiting, try again later")
 			return params.LoginResult{}, common.ErrTryAgain
 		}
 		defer a.limiter.Release()
+		entity, err = doCheckCreds(st, c)
+		if err == common.ErrBadCreds {
+			a.root.srv.metrics.loginFailed()
+		}
 	}
-	entity, err := doCheckCreds(a.root.srv.state, c)
 	if err != nil {
 		return params.LoginResult{}, err
 	}
@@ -99,18 +127,46 @@ func (a *srvAdmin) Login(c params.Creds) (params.LoginResult, error) {
 	}
 
 	// Fetch the API server addresses from state.
-	hostPorts, err := a.root.srv.state.APIHostPorts()
+	hostPorts, err := st.APIHostPorts()
 	if err != nil {
 		return params.LoginResult{}, err
 	}
 	logger.Debugf("hostPorts: %v", hostPorts)
 
 	a.root.rpcConn.Serve(newRoot, serverError)
-	return params.LoginResult{hostPorts}, nil
+	return params.LoginResult{
+		Servers: hostPorts,
+		Facades: facadeVersions(newRoot),
+	}, nil
+}
+
+// facadeVersions returns the versions of each
+// facade served by the given root, sorted by name.
+func facadeVersions(root interface{}) []params.FacadeVersions {
+	versions := rpcreflect.TypeOf(reflect.TypeOf(root)).Versions()
+	names := make([]string, 0, len(versions))
+	for name := range versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]params.FacadeVersions, len(names))
+	for i, name := range names {
+		result[i] = params.FacadeVersions{
+			Name:     name,
+			Versions: versions[name],
+		}
+	}
+	return result
 }
 
 var doCheckCreds = checkCreds
 
+// isUserTag returns whether the given tag identifies a user.
+func isUserTag(tag string) bool {
+	kind, err := names.TagKind(tag)
+	return err == nil && kind == names.UserTagKind
+}
+
 func checkCreds(st *state.State, c params.Creds) (taggedAuthenticator, error) {
 	entity0, err := st.FindEntity(c.AuthTag)
 	if err != nil && !errors.IsNotFound(err) {
@@ -176,6 +232,15 @@ func (a *srvAdmin) startPingerIfAgent(newRoot *srvRoot, entity taggedAuthenticat
 		return err
 	}
 	newRoot.resources.Register(&machinePinger{pinger})
+	// Record which API server the agent is connected to, so
+	// that it can be reported in the status.
+	if addrSetter, ok := entity.(interface {
+		SetAgentAPIAddress(addr string) error
+	}); ok && a.root.serverAddr != "" {
+		if err := addrSetter.SetAgentAPIAddress(a.root.serverAddr); err != nil {
+			return err
+		}
+	}
 	action := func() {
 		if err := newRoot.rpcConn.Close(); err != nil {
 			logger.Errorf("error closing the RPC connection: %v", err)
@@ -10,19 +10,21 @@ import (
 	"github.com/juju/core/state"
 	"github.com/juju/core/state/api/params"
 	"github.com/juju/core/state/apiserver/common"
+	"github.com/juju/core/state/watcher"
 )
 
 // API implements the API provided to an agent.
 type API struct {
 	*common.PasswordChanger
 
-	st   *state.State
-	auth common.Authorizer
+	st        *state.State
+	resources *common.Resources
+	auth      common.Authorizer
 }
 
 // NewAPI returns an object implementing an agent API
 // with the given authorizer representing the currently logged in client.
-func NewAPI(st *state.State, auth common.Authorizer) (*API, error) {
+func NewAPI(st *state.State, resources *common.Resources, auth common.Authorizer) (*API, error) {
 	// Agents are defined to be any user that's not a client user.
 	if !auth.AuthMachineAgent() && !auth.AuthUnitAgent() {
 		return nil, common.ErrPerm
@@ -33,6 +35,7 @@ func NewAPI(st *state.State, auth common.Authorizer) (*API, error) {
 	return &API{
 		PasswordChanger: common.NewPasswordChanger(st, getCanChange),
 		st:              st,
+		resources:       resources,
 		auth:            auth,
 	}, nil
 }
@@ -99,6 +102,142 @@ func (api *API) IsMaster() (params.IsMasterResult, error) {
 	return params.IsMasterResult{Master: isMaster}, err
 }
 
+// PasswordRotation returns, for each given entity, the revno of the
+// pending request for its agent to replace its password, or 0 if there
+// is none. Revnos increase with every request.
+func (api *API) PasswordRotation(args params.Entities) (params.IntResults, error) {
+	result := params.IntResults{
+		Results: make([]params.IntResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		if !api.auth.AuthOwner(entity.Tag) {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		revno, err := api.st.PasswordRotation(entity.Tag)
+		result.Results[i].Result = revno
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// WatchPasswordRotation starts a NotifyWatcher for each given entity
+// that notifies when its agent is asked to replace its password.
+func (api *API) WatchPasswordRotation(args params.Entities) (params.NotifyWatchResults, error) {
+	result := params.NotifyWatchResults{
+		Results: make([]params.NotifyWatchResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		if !api.auth.AuthOwner(entity.Tag) {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		watch := api.st.WatchPasswordRotation(entity.Tag)
+		// Consume the initial event.
+		if _, ok := <-watch.Changes(); ok {
+			result.Results[i].NotifyWatcherId = api.resources.Register(watch)
+		} else {
+			result.Results[i].Error = common.ServerError(watcher.MustErr(watch))
+		}
+	}
+	return result, nil
+}
+
+// AcknowledgeCACert records, for each given agent, that it has started
+// trusting the given CA certificates.
+func (api *API) AcknowledgeCACert(args params.AgentCACerts) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Agents)),
+	}
+	for i, arg := range args.Agents {
+		if !api.auth.AuthOwner(arg.Tag) {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		err := api.st.AcknowledgeCACert(arg.Tag, arg.CACert)
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+func (api *API) getMachine(tag string) (*state.Machine, error) {
+	if !api.auth.AuthOwner(tag) {
+		return nil, common.ErrPerm
+	}
+	entity, err := api.st.FindEntity(tag)
+	if err != nil {
+		return nil, err
+	}
+	machine, ok := entity.(*state.Machine)
+	if !ok {
+		return nil, common.NotSupportedError(tag, "container templates")
+	}
+	return machine, nil
+}
+
+// ContainerTemplatePurge returns, for each given machine, the latest
+// request to purge the container templates cached on it.
+func (api *API) ContainerTemplatePurge(args params.Entities) (params.ContainerTemplatePurgeResults, error) {
+	result := params.ContainerTemplatePurgeResults{
+		Results: make([]params.ContainerTemplatePurgeResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		machine, err := api.getMachine(entity.Tag)
+		if err == nil {
+			var purge state.ContainerTemplatePurge
+			purge, result.Results[i].PurgedRevno, err = machine.ContainerTemplatePurge()
+			result.Results[i].Revno = purge.Revno
+			result.Results[i].Names = purge.Names
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// SetContainerTemplates records the container templates cached on
+// each given machine.
+func (api *API) SetContainerTemplates(args params.SetMachinesContainerTemplates) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Machines)),
+	}
+	for i, arg := range args.Machines {
+		machine, err := api.getMachine(arg.Tag)
+		if err == nil {
+			templates := make([]state.ContainerTemplate, len(arg.Templates))
+			for j, t := range arg.Templates {
+				templates[j] = state.ContainerTemplate(t)
+			}
+			err = machine.SetContainerTemplates(templates, arg.PurgedRevno)
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// WatchContainerTemplates starts a NotifyWatcher for each given machine
+// that notifies when the container templates cached on it are reported
+// or purged.
+func (api *API) WatchContainerTemplates(args params.Entities) (params.NotifyWatchResults, error) {
+	result := params.NotifyWatchResults{
+		Results: make([]params.NotifyWatchResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		machine, err := api.getMachine(entity.Tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(err)
+			continue
+		}
+		watch := machine.WatchContainerTemplates()
+		// Consume the initial event.
+		if _, ok := <-watch.Changes(); ok {
+			result.Results[i].NotifyWatcherId = api.resources.Register(watch)
+		} else {
+			result.Results[i].Error = common.ServerError(watcher.MustErr(watch))
+		}
+	}
+	return result, nil
+}
+
 func stateJobsToAPIParamsJobs(jobs []state.MachineJob) []params.MachineJob {
 	pjobs := make([]params.MachineJob, len(jobs))
 	for i, job := range jobs {
@@ -10,8 +10,11 @@ import (
 	"github.com/juju/core/state"
 	"github.com/juju/core/state/api/params"
 	"github.com/juju/core/state/apiserver/agent"
+	"github.com/juju/core/state/apiserver/common"
 	apiservertesting "github.com/juju/core/state/apiserver/testing"
+	statetesting "github.com/juju/core/state/testing"
 	coretesting "github.com/juju/core/testing"
+	"github.com/juju/core/version"
 )
 
 func TestPackage(t *stdtesting.T) {
@@ -27,6 +30,7 @@ type agentSuite struct {
 	machine1  *state.Machine
 	container *state.Machine
 	agent     *agent.API
+	resources *common.Resources
 }
 
 var _ = gc.Suite(&agentSuite{})
@@ -56,8 +60,11 @@ func (s *agentSuite) SetUpTest(c *gc.C) {
 		MachineAgent: true,
 	}
 
+	s.resources = common.NewResources()
+	s.AddCleanup(func(_ *gc.C) { s.resources.StopAll() })
+
 	// Create a machiner API for machine 1.
-	s.agent, err = agent.NewAPI(s.State, s.authorizer)
+	s.agent, err = agent.NewAPI(s.State, s.resources, s.authorizer)
 	c.Assert(err, gc.IsNil)
 }
 
@@ -65,7 +72,7 @@ func (s *agentSuite) TestAgentFailsWithNonAgent(c *gc.C) {
 	auth := s.authorizer
 	auth.MachineAgent = false
 	auth.UnitAgent = false
-	api, err := agent.NewAPI(s.State, auth)
+	api, err := agent.NewAPI(s.State, s.resources, auth)
 	c.Assert(err, gc.NotNil)
 	c.Assert(api, gc.IsNil)
 	c.Assert(err, gc.ErrorMatches, "permission denied")
@@ -75,7 +82,7 @@ func (s *agentSuite) TestAgentSucceedsWithUnitAgent(c *gc.C) {
 	auth := s.authorizer
 	auth.MachineAgent = false
 	auth.UnitAgent = true
-	_, err := agent.NewAPI(s.State, auth)
+	_, err := agent.NewAPI(s.State, s.resources, auth)
 	c.Assert(err, gc.IsNil)
 }
 
@@ -108,7 +115,7 @@ func (s *agentSuite) TestGetEntities(c *gc.C) {
 	auth.MachineAgent = true
 	auth.UnitAgent = false
 	auth.Tag = s.container.Tag()
-	containerAgent, err := agent.NewAPI(s.State, auth)
+	containerAgent, err := agent.NewAPI(s.State, s.resources, auth)
 	c.Assert(err, gc.IsNil)
 
 	results = containerAgent.GetEntities(args)
@@ -188,3 +195,145 @@ func (s *agentSuite) TestShortSetPasswords(c *gc.C) {
 	c.Assert(results.Results[0].Error, gc.ErrorMatches,
 		"password is only 3 bytes long, and is not a valid Agent password")
 }
+
+func (s *agentSuite) TestPasswordRotation(c *gc.C) {
+	err := s.State.RequestPasswordRotation("machine-1")
+	c.Assert(err, gc.IsNil)
+	results, err := s.agent.PasswordRotation(params.Entities{
+		Entities: []params.Entity{{Tag: "machine-1"}, {Tag: "machine-0"}},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.DeepEquals, params.IntResults{
+		Results: []params.IntResult{
+			{Result: 1},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *agentSuite) TestAcknowledgeCACert(c *gc.C) {
+	pinger, err := s.machine1.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+	s.State.StartSync()
+	err = s.machine1.WaitAgentAlive(coretesting.LongWait)
+	c.Assert(err, gc.IsNil)
+
+	results, err := s.agent.AcknowledgeCACert(params.AgentCACerts{
+		Agents: []params.AgentCACert{
+			{Tag: "machine-1", CACert: s.State.CACert()},
+			{Tag: "machine-0", CACert: s.State.CACert()},
+		},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+	pending, err := s.State.AgentsPendingCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.HasLen, 0)
+}
+
+func (s *agentSuite) TestWatchPasswordRotation(c *gc.C) {
+	c.Assert(s.resources.Count(), gc.Equals, 0)
+
+	results, err := s.agent.WatchPasswordRotation(params.Entities{
+		Entities: []params.Entity{{Tag: "machine-1"}, {Tag: "machine-0"}},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.DeepEquals, params.NotifyWatchResults{
+		Results: []params.NotifyWatchResult{
+			{NotifyWatcherId: "1"},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Verify the resource was registered and stop when done.
+	c.Assert(s.resources.Count(), gc.Equals, 1)
+	resource := s.resources.Get("1")
+	defer statetesting.AssertStop(c, resource)
+
+	// The initial event has been consumed by the Watch call.
+	wc := statetesting.NewNotifyWatcherC(c, s.State, resource.(state.NotifyWatcher))
+	wc.AssertNoChange()
+
+	err = s.State.RequestPasswordRotation("machine-1")
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+}
+
+func (s *agentSuite) TestContainerTemplatePurge(c *gc.C) {
+	err := s.machine1.RequestContainerTemplatePurge("juju-trusty-amd64-1.19.4-template")
+	c.Assert(err, gc.IsNil)
+	results, err := s.agent.ContainerTemplatePurge(params.Entities{
+		Entities: []params.Entity{{Tag: "machine-1"}, {Tag: "machine-0"}},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.DeepEquals, params.ContainerTemplatePurgeResults{
+		Results: []params.ContainerTemplatePurgeResult{
+			{Revno: 1, Names: []string{"juju-trusty-amd64-1.19.4-template"}},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *agentSuite) TestSetContainerTemplates(c *gc.C) {
+	template := params.ContainerTemplate{
+		Name:   "juju-trusty-amd64-1.19.4-template",
+		Type:   instance.LXC,
+		Series: "trusty",
+		Arch:   "amd64",
+		Tools:  version.MustParse("1.19.4"),
+	}
+	results, err := s.agent.SetContainerTemplates(params.SetMachinesContainerTemplates{
+		Machines: []params.MachineContainerTemplates{
+			{Tag: "machine-1", Templates: []params.ContainerTemplate{template}, PurgedRevno: 2},
+			{Tag: "machine-0"},
+		},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+	templates, _, err := s.machine1.ContainerTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 1)
+	c.Assert(templates[0].Name, gc.Equals, template.Name)
+	_, purgedRevno, err := s.machine1.ContainerTemplatePurge()
+	c.Assert(err, gc.IsNil)
+	c.Assert(purgedRevno, gc.Equals, 2)
+}
+
+func (s *agentSuite) TestWatchContainerTemplates(c *gc.C) {
+	c.Assert(s.resources.Count(), gc.Equals, 0)
+
+	results, err := s.agent.WatchContainerTemplates(params.Entities{
+		Entities: []params.Entity{{Tag: "machine-1"}, {Tag: "machine-0"}},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.DeepEquals, params.NotifyWatchResults{
+		Results: []params.NotifyWatchResult{
+			{NotifyWatcherId: "1"},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Verify the resource was registered and stop when done.
+	c.Assert(s.resources.Count(), gc.Equals, 1)
+	resource := s.resources.Get("1")
+	defer statetesting.AssertStop(c, resource)
+
+	// The initial event has been consumed by the Watch call.
+	wc := statetesting.NewNotifyWatcherC(c, s.State, resource.(state.NotifyWatcher))
+	wc.AssertNoChange()
+
+	err = s.machine1.RequestContainerTemplatePurge()
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+}
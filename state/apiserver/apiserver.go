@@ -14,6 +14,7 @@ import (
 
 	"code.google.com/p/go.net/websocket"
 	"github.com/juju/loggo"
+	"labix.org/v2/mgo"
 	"launchpad.net/tomb"
 
 	"github.com/juju/core/rpc"
@@ -38,6 +39,7 @@ type Server struct {
 	dataDir string
 	logDir  string
 	limiter utils.Limiter
+	metrics *apiMetrics
 }
 
 // NewServer serves the given state by accepting requests on the given
@@ -59,7 +61,10 @@ func NewServer(s *state.State, addr string, cert, key []byte, datadir, logDir st
 		dataDir: datadir,
 		logDir:  logDir,
 		limiter: utils.NewLimiter(loginRateLimit),
+		metrics: newAPIMetrics(),
 	}
+	// Gather the mongo connection statistics reported as metrics.
+	mgo.SetStats(true)
 	// TODO(rog) check that *srvRoot is a valid type for using
 	// as an RPC server.
 	lis = tls.NewListener(lis, &tls.Config{
@@ -92,8 +97,12 @@ func (srv *Server) Wait() error {
 }
 
 type requestNotifier struct {
-	id    int64
-	start time.Time
+	id      int64
+	start   time.Time
+	metrics *apiMetrics
+
+	// logging holds whether requests are logged.
+	logging bool
 
 	mu   sync.Mutex
 	tag_ string
@@ -101,11 +110,13 @@ type requestNotifier struct {
 
 var globalCounter int64
 
-func newRequestNotifier() *requestNotifier {
+func newRequestNotifier(metrics *apiMetrics) *requestNotifier {
 	return &requestNotifier{
-		id:    atomic.AddInt64(&globalCounter, 1),
-		tag_:  "<unknown>",
-		start: time.Now(),
+		id:      atomic.AddInt64(&globalCounter, 1),
+		tag_:    "<unknown>",
+		start:   time.Now(),
+		metrics: metrics,
+		logging: logger.EffectiveLogLevel() <= loggo.DEBUG,
 	}
 }
 
@@ -123,7 +134,7 @@ func (n *requestNotifier) tag() (tag string) {
 }
 
 func (n *requestNotifier) ServerRequest(hdr *rpc.Header, body interface{}) {
-	if hdr.Request.Type == "Pinger" && hdr.Request.Action == "Ping" {
+	if !n.logging || hdr.Request.Type == "Pinger" && hdr.Request.Action == "Ping" {
 		return
 	}
 	// TODO(rog) 2013-10-11 remove secrets from some requests.
@@ -131,17 +142,20 @@ func (n *requestNotifier) ServerRequest(hdr *rpc.Header, body interface{}) {
 }
 
 func (n *requestNotifier) ServerReply(req rpc.Request, hdr *rpc.Header, body interface{}, timeSpent time.Duration) {
-	if req.Type == "Pinger" && req.Action == "Ping" {
+	n.metrics.requestDone(metricsKey(req), hdr.Error != "", timeSpent)
+	if !n.logging || req.Type == "Pinger" && req.Action == "Ping" {
 		return
 	}
 	logger.Debugf("-> [%X] %s %s %s %s[%q].%s", n.id, n.tag(), timeSpent, jsoncodec.DumpRequest(hdr, body), req.Type, req.Id, req.Action)
 }
 
 func (n *requestNotifier) join(req *http.Request) {
+	n.metrics.connectionOpened()
 	logger.Infof("[%X] API connection from %s", n.id, req.RemoteAddr)
 }
 
 func (n *requestNotifier) leave() {
+	n.metrics.connectionClosed()
 	logger.Infof("[%X] %s API connection terminated after %v", n.id, n.tag(), time.Since(n.start))
 }
 
@@ -170,20 +184,29 @@ func (srv *Server) run(lis net.Listener) {
 	mux.HandleFunc("/", srv.apiHandler)
 	mux.Handle("/log",
 		&debugLogHandler{
-			httpHandler: httpHandler{state: srv.state},
+			httpHandler: httpHandler{state: srv.state, metrics: srv.metrics},
 			logDir:      srv.logDir})
 	mux.Handle("/charms",
 		&charmsHandler{
-			httpHandler: httpHandler{state: srv.state},
+			httpHandler: httpHandler{state: srv.state, metrics: srv.metrics},
 			dataDir:     srv.dataDir})
+	mux.Handle("/resources",
+		&resourcesHandler{httpHandler{state: srv.state, metrics: srv.metrics}})
 	mux.Handle("/tools",
-		&toolsHandler{httpHandler{state: srv.state}})
+		&toolsHandler{httpHandler{state: srv.state, metrics: srv.metrics}})
+	mux.Handle("/metrics",
+		&metricsHandler{httpHandler{state: srv.state, metrics: srv.metrics}})
+	mux.Handle(restPrefix,
+		&restHandler{
+			httpHandler: httpHandler{state: srv.state, metrics: srv.metrics},
+			dataDir:     srv.dataDir,
+			stop:        srv.tomb.Dying()})
 	// The error from http.Serve is not interesting.
 	http.Serve(lis, mux)
 }
 
 func (srv *Server) apiHandler(w http.ResponseWriter, req *http.Request) {
-	reqNotifier := newRequestNotifier()
+	reqNotifier := newRequestNotifier(srv.metrics)
 	reqNotifier.join(req)
 	defer reqNotifier.leave()
 	wsServer := websocket.Server{
@@ -215,14 +238,10 @@ func (srv *Server) serveConn(wsConn *websocket.Conn, reqNotifier *requestNotifie
 	if loggo.GetLogger("juju.rpc.jsoncodec").EffectiveLogLevel() <= loggo.TRACE {
 		codec.SetLogging(true)
 	}
-	var notifier rpc.RequestNotifier
-	if logger.EffectiveLogLevel() <= loggo.DEBUG {
-		// Incur request monitoring overhead only if we
-		// know we'll need it.
-		notifier = reqNotifier
-	}
-	conn := rpc.NewConn(codec, notifier)
-	conn.Serve(newStateServer(srv, conn, reqNotifier, srv.limiter), serverError)
+	// The notifier always gathers request metrics, but
+	// logs requests only at debug level.
+	conn := rpc.NewConn(codec, reqNotifier)
+	conn.Serve(newStateServer(srv, conn, wsConn.Request(), reqNotifier, srv.limiter), serverError)
 	conn.Start()
 	select {
 	case <-conn.Dead():
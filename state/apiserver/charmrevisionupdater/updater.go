@@ -8,6 +8,7 @@ import (
 	"github.com/juju/loggo"
 
 	"github.com/juju/core/charm"
+	"github.com/juju/core/environs/config"
 	"github.com/juju/core/state"
 	"github.com/juju/core/state/api/params"
 	"github.com/juju/core/state/apiserver/common"
@@ -57,8 +58,12 @@ func (api *CharmRevisionUpdaterAPI) UpdateLatestRevisions() (params.ErrorResult,
 	if err != nil {
 		return params.ErrorResult{Error: common.ServerError(err)}, nil
 	}
+	envConfig, err := api.state.EnvironConfig()
+	if err != nil {
+		return params.ErrorResult{Error: common.ServerError(err)}, nil
+	}
 	// Look up the revision information for all the deployed charms.
-	curls, err := retrieveLatestCharmInfo(deployedCharms, uuid)
+	curls, err := retrieveLatestCharmInfo(deployedCharms, uuid, envConfig)
 	if err != nil {
 		return params.ErrorResult{Error: common.ServerError(err)}, nil
 	}
@@ -90,8 +95,9 @@ func fetchAllDeployedCharms(st *state.State) (map[string]*charm.URL, error) {
 }
 
 // retrieveLatestCharmInfo looks up the charm store to return the charm URLs for the
-// latest revision of the deployed charms.
-func retrieveLatestCharmInfo(deployedCharms map[string]*charm.URL, uuid string) ([]*charm.URL, error) {
+// latest revision of the deployed charms. The charm store at the
+// environment's charm-store-url is used, if set.
+func retrieveLatestCharmInfo(deployedCharms map[string]*charm.URL, uuid string, envConfig *config.Config) ([]*charm.URL, error) {
 	var curls []*charm.URL
 	for _, curl := range deployedCharms {
 		if curl.Schema == "local" {
@@ -105,7 +111,8 @@ func retrieveLatestCharmInfo(deployedCharms map[string]*charm.URL, uuid string)
 
 	// Do a bulk call to get the revision info for all charms.
 	logger.Infof("retrieving revision information for %d charms", len(curls))
-	store := charm.Store.WithJujuAttrs("environment_uuid=" + uuid)
+	repo := config.SpecializeCharmRepo(charm.Store, envConfig)
+	store := repo.(*charm.CharmStore).WithJujuAttrs("environment_uuid=" + uuid)
 	revInfo, err := store.Latest(curls...)
 	if err != nil {
 		return nil, errors.LoggedErrorf(logger, "finding charm revision info: %v", err)
@@ -130,3 +130,20 @@ func (s *charmVersionSuite) TestEnvironmentUUIDUsed(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(s.Server.Metadata, gc.DeepEquals, []string{"environment_uuid=" + env.UUID()})
 }
+
+func (s *charmVersionSuite) TestCharmStoreURLUsed(c *gc.C) {
+	s.AddMachine(c, "0", state.JobManageEnviron)
+	s.SetupScenario(c)
+	s.PatchValue(&charm.Store, &charm.CharmStore{BaseURL: "http://0.1.2.3:1234"})
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"charm-store-url": s.Server.Address(),
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+
+	result, err := s.charmrevisionupdater.UpdateLatestRevisions()
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Error, gc.IsNil)
+	pending, err := s.State.LatestPlaceholderCharm(charm.MustParseURL("cs:quantal/mysql"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending.String(), gc.Equals, "cs:quantal/mysql-23")
+}
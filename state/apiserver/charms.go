@@ -26,6 +26,7 @@ import (
 	"github.com/juju/core/charm"
 	"github.com/juju/core/environs"
 	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/state/apiserver/common"
 	ziputil "github.com/juju/core/utils/zip"
 )
 
@@ -187,6 +188,20 @@ func (h *charmsHandler) processPost(r *http.Request) (*charm.URL, error) {
 	if _, err := io.Copy(tempFile, r.Body); err != nil {
 		return nil, fmt.Errorf("error processing file upload: %v", err)
 	}
+	// Check the signature of the archive as uploaded, before it
+	// gets repackaged. The signature, if any, is given armored in
+	// the "signature" query argument.
+	envConfig, err := h.state.EnvironConfig()
+	if err != nil {
+		return nil, err
+	}
+	var signature []byte
+	if query.Get("signature") != "" {
+		signature = []byte(query.Get("signature"))
+	}
+	if err := common.VerifyCharmArchive(envConfig, "uploaded charm", tempFile.Name(), signature); err != nil {
+		return nil, err
+	}
 	err = h.processUploadedArchive(tempFile.Name())
 	if err != nil {
 		return nil, err
@@ -19,6 +19,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/core/charm"
+	charmtesting "github.com/juju/core/charm/testing"
 	"github.com/juju/core/environs"
 	jujutesting "github.com/juju/core/juju/testing"
 	"github.com/juju/core/state"
@@ -151,6 +152,35 @@ func (s *charmsSuite) TestUploadFailsWithInvalidZip(c *gc.C) {
 	s.assertErrorResponse(c, resp, http.StatusBadRequest, "expected Content-Type: application/zip, got: application/octet-stream")
 }
 
+func (s *charmsSuite) TestUploadVerifiesSignature(c *gc.C) {
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"charm-verification": "strict",
+		"charm-trusted-keys": charmtesting.SigningPublicKey,
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	ch := coretesting.Charms.Bundle(c.MkDir(), "dummy")
+	sign := func(privateKey string) string {
+		f, err := os.Open(ch.Path)
+		c.Assert(err, gc.IsNil)
+		defer f.Close()
+		signature, err := charm.SignArchive(f, privateKey, "")
+		c.Assert(err, gc.IsNil)
+		return "&signature=" + url.QueryEscape(string(signature))
+	}
+
+	resp, err := s.uploadRequest(c, s.charmsURI(c, "?series=quantal"), true, ch.Path)
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusBadRequest, "cannot accept uploaded charm: charm signature verification failed: charm archive is not signed")
+
+	resp, err = s.uploadRequest(c, s.charmsURI(c, "?series=quantal"+sign(charmtesting.UntrustedPrivateKey)), true, ch.Path)
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusBadRequest, "cannot accept uploaded charm: charm signature verification failed: .*")
+
+	resp, err = s.uploadRequest(c, s.charmsURI(c, "?series=quantal"+sign(charmtesting.SigningPrivateKey)), true, ch.Path)
+	c.Assert(err, gc.IsNil)
+	s.assertUploadResponse(c, resp, "local:quantal/dummy-1")
+}
+
 func (s *charmsSuite) TestUploadBumpsRevision(c *gc.C) {
 	// Add the dummy charm with revision 1.
 	ch := coretesting.Charms.Bundle(c.MkDir(), "dummy")
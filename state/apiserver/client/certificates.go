@@ -0,0 +1,135 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/juju/core/cert"
+	"github.com/juju/core/state/api/params"
+)
+
+// stateServerHostnames holds the names that every state server
+// certificate is valid for, in addition to the state server addresses.
+// Clients and agents connect with the server name "anything".
+var stateServerHostnames = []string{"localhost", "juju-apiserver", "juju-mongodb", "anything"}
+
+// PublishCACert replaces the CA certificates that agents and clients
+// trust, leaving the state server certificate alone. The first
+// certificate is the one that will sign the next state server
+// certificate; any others are CA certificates being replaced, which
+// must stay trusted until the state server certificate is switched.
+func (c *Client) PublishCACert(args params.PublishCACert) error {
+	if _, err := cert.ParseCerts(args.CACert); err != nil {
+		return fmt.Errorf("invalid CA certificate: %v", err)
+	}
+	return c.api.state.PublishCACert(args.CACert)
+}
+
+// CACertPendingAgents returns the running agents that have not yet
+// acknowledged the published CA certificates.
+func (c *Client) CACertPendingAgents() (params.CACertPendingAgents, error) {
+	agents, err := c.api.state.AgentsPendingCACert()
+	if err != nil {
+		return params.CACertPendingAgents{}, err
+	}
+	return params.CACertPendingAgents{Agents: agents}, nil
+}
+
+// NewStateServerKey generates a new key for the state server and
+// returns its public half, along with the host names the certificate
+// signed for it should be valid for. The private key is kept on the
+// state server until SetStateServerCert is called.
+func (c *Client) NewStateServerKey() (params.StateServerKey, error) {
+	var result params.StateServerKey
+	hostPorts, err := c.api.state.APIHostPorts()
+	if err != nil {
+		return result, err
+	}
+	privateKey, publicKey, err := cert.NewKey()
+	if err != nil {
+		return result, fmt.Errorf("cannot generate state server key: %v", err)
+	}
+	if err := c.api.state.SetPendingStateServerKey(privateKey); err != nil {
+		return result, err
+	}
+	hostnames := append([]string{}, stateServerHostnames...)
+	seen := make(map[string]bool)
+	for _, server := range hostPorts {
+		for _, hp := range server {
+			if !seen[hp.Value] {
+				seen[hp.Value] = true
+				hostnames = append(hostnames, hp.Value)
+			}
+		}
+	}
+	result.PublicKey = publicKey
+	result.Hostnames = hostnames
+	return result, nil
+}
+
+// SetStateServerCert switches the state server certificate to the
+// given one, which must be signed for the key returned by
+// NewStateServerKey by the first of the published CA certificates.
+// All running agents must have acknowledged the published CA
+// certificates first, so that none of them lose their connection.
+func (c *Client) SetStateServerCert(args params.SetStateServerCert) error {
+	privateKey, err := c.api.state.PendingStateServerKey()
+	if err != nil {
+		return err
+	}
+	if _, err := tls.X509KeyPair([]byte(args.Cert), []byte(privateKey)); err != nil {
+		return fmt.Errorf("invalid state server certificate: %v", err)
+	}
+	caCerts, err := cert.ParseCerts(c.api.state.CACert())
+	if err != nil {
+		return fmt.Errorf("cannot parse CA certificate: %v", err)
+	}
+	caCertPEM := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: caCerts[0].Raw,
+	}))
+	if err := cert.Verify(args.Cert, caCertPEM, time.Now()); err != nil {
+		return fmt.Errorf("cannot verify state server certificate: %v", err)
+	}
+	pending, err := c.api.state.AgentsPendingCACert()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("agents have not acknowledged the CA certificate: %s", strings.Join(pending, ", "))
+	}
+	if err := c.api.state.SetStateServerCert(args.Cert, privateKey); err != nil {
+		return err
+	}
+	// The environment configuration only records the CA certificate
+	// that signs the state server certificate.
+	attrs := map[string]interface{}{"ca-cert": caCertPEM}
+	return c.api.state.UpdateEnvironConfig(attrs, nil, nil)
+}
+
+// CertificatesExpiry returns the times at which the environment's CA
+// certificate and the state server certificate expire.
+func (c *Client) CertificatesExpiry() (params.CertificatesExpiry, error) {
+	var result params.CertificatesExpiry
+	caCert, err := cert.ParseCert(c.api.state.CACert())
+	if err != nil {
+		return result, fmt.Errorf("cannot parse CA certificate: %v", err)
+	}
+	info, err := c.api.state.StateServingInfo()
+	if err != nil {
+		return result, err
+	}
+	serverCert, err := cert.ParseCert(info.Cert)
+	if err != nil {
+		return result, fmt.Errorf("cannot parse state server certificate: %v", err)
+	}
+	result.CACert = caCert.NotAfter
+	result.ServerCert = serverCert.NotAfter
+	return result, nil
+}
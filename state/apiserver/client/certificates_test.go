@@ -0,0 +1,163 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/cert"
+	"github.com/juju/core/instance"
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api/params"
+	coretesting "github.com/juju/core/testing"
+)
+
+type certificatesSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&certificatesSuite{})
+
+func (s *certificatesSuite) SetUpTest(c *gc.C) {
+	s.baseSuite.SetUpTest(c)
+	err := s.State.SetStateServingInfo(params.StateServingInfo{
+		APIPort:    1234,
+		StatePort:  4321,
+		Cert:       coretesting.ServerCert,
+		PrivateKey: coretesting.ServerKey,
+	})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *certificatesSuite) TestRotateCertificates(c *gc.C) {
+	expiry := time.Now().AddDate(1, 0, 0).UTC().Truncate(time.Second)
+	caCert, caKey, err := cert.NewCA("dummyenv", expiry)
+	c.Assert(err, gc.IsNil)
+
+	// Trust both the new CA and the one it replaces.
+	bundle := caCert + coretesting.CACert
+	err = s.APIState.Client().PublishCACert(bundle)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.State.CACert(), gc.Equals, bundle)
+	info, err := s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Cert, gc.Equals, coretesting.ServerCert)
+
+	err = s.State.SetAPIHostPorts([][]instance.HostPort{
+		instance.AddressesWithPort(instance.NewAddresses("10.0.0.1", "example.com"), 1234),
+	})
+	c.Assert(err, gc.IsNil)
+	publicKey, hostnames, err := s.APIState.Client().NewStateServerKey()
+	c.Assert(err, gc.IsNil)
+	c.Assert(hostnames, gc.DeepEquals, []string{
+		"localhost", "juju-apiserver", "juju-mongodb", "anything", "10.0.0.1", "example.com",
+	})
+	srvKey, err := s.State.PendingStateServerKey()
+	c.Assert(err, gc.IsNil)
+	srvCert, err := cert.NewServerForKey(caCert, caKey, expiry, hostnames, publicKey)
+	c.Assert(err, gc.IsNil)
+
+	err = s.APIState.Client().SetStateServerCert(srvCert)
+	c.Assert(err, gc.IsNil)
+	info, err = s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Cert, gc.Equals, srvCert)
+	c.Assert(info.PrivateKey, gc.Equals, srvKey)
+	c.Assert(s.State.CACert(), gc.Equals, bundle)
+	envConfig, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	envCACert, _ := envConfig.CACert()
+	c.Assert(envCACert, gc.Equals, caCert)
+
+	certsExpiry, err := s.APIState.Client().CertificatesExpiry()
+	c.Assert(err, gc.IsNil)
+	c.Assert(certsExpiry.CACert.Equal(expiry), gc.Equals, true)
+	c.Assert(certsExpiry.ServerCert.Equal(expiry), gc.Equals, true)
+}
+
+func (s *certificatesSuite) TestSetStateServerCertWaitsForAgents(c *gc.C) {
+	expiry := time.Now().AddDate(1, 0, 0)
+	caCert, caKey, err := cert.NewCA("dummyenv", expiry)
+	c.Assert(err, gc.IsNil)
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	pinger, err := machine.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+	s.State.StartSync()
+	err = machine.WaitAgentAlive(coretesting.LongWait)
+	c.Assert(err, gc.IsNil)
+
+	bundle := caCert + coretesting.CACert
+	err = s.APIState.Client().PublishCACert(bundle)
+	c.Assert(err, gc.IsNil)
+	pending, err := s.APIState.Client().CACertPendingAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.DeepEquals, []string{machine.Tag()})
+
+	publicKey, hostnames, err := s.APIState.Client().NewStateServerKey()
+	c.Assert(err, gc.IsNil)
+	srvCert, err := cert.NewServerForKey(caCert, caKey, expiry, hostnames, publicKey)
+	c.Assert(err, gc.IsNil)
+	err = s.APIState.Client().SetStateServerCert(srvCert)
+	c.Assert(err, gc.ErrorMatches, "agents have not acknowledged the CA certificate: "+machine.Tag())
+	info, err := s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Cert, gc.Equals, coretesting.ServerCert)
+
+	err = s.State.AcknowledgeCACert(machine.Tag(), bundle)
+	c.Assert(err, gc.IsNil)
+	pending, err = s.APIState.Client().CACertPendingAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.HasLen, 0)
+	err = s.APIState.Client().SetStateServerCert(srvCert)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *certificatesSuite) TestSetStateServerCertUnknownCA(c *gc.C) {
+	expiry := time.Now().AddDate(1, 0, 0)
+	caCert, caKey, err := cert.NewCA("dummyenv", expiry)
+	c.Assert(err, gc.IsNil)
+	publicKey, hostnames, err := s.APIState.Client().NewStateServerKey()
+	c.Assert(err, gc.IsNil)
+	srvCert, err := cert.NewServerForKey(caCert, caKey, expiry, hostnames, publicKey)
+	c.Assert(err, gc.IsNil)
+
+	// The new CA certificate has not been published.
+	err = s.APIState.Client().SetStateServerCert(srvCert)
+	c.Assert(err, gc.ErrorMatches, "cannot verify state server certificate: x509: certificate signed by unknown authority")
+	info, err := s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Cert, gc.Equals, coretesting.ServerCert)
+}
+
+func (s *certificatesSuite) TestSetStateServerCertInvalid(c *gc.C) {
+	err := s.APIState.Client().SetStateServerCert(coretesting.ServerCert)
+	c.Assert(err, gc.ErrorMatches, "pending state server key not found")
+
+	_, _, err = s.APIState.Client().NewStateServerKey()
+	c.Assert(err, gc.IsNil)
+	err = s.APIState.Client().SetStateServerCert(coretesting.ServerCert)
+	c.Assert(err, gc.ErrorMatches, "invalid state server certificate: .*")
+}
+
+func (s *certificatesSuite) TestPublishCACertInvalid(c *gc.C) {
+	err := s.APIState.Client().PublishCACert("bad")
+	c.Assert(err, gc.ErrorMatches, "invalid CA certificate: no certificates found")
+	c.Assert(s.State.CACert(), gc.Equals, coretesting.CACert)
+}
+
+func (s *certificatesSuite) TestCertificatesExpiry(c *gc.C) {
+	caCert, err := cert.ParseCert(coretesting.CACert)
+	c.Assert(err, gc.IsNil)
+	srvCert, err := cert.ParseCert(coretesting.ServerCert)
+	c.Assert(err, gc.IsNil)
+
+	certsExpiry, err := s.APIState.Client().CertificatesExpiry()
+	c.Assert(err, gc.IsNil)
+	c.Assert(certsExpiry.CACert.Equal(caCert.NotAfter), gc.Equals, true)
+	c.Assert(certsExpiry.ServerCert.Equal(srvCert.NotAfter), gc.Equals, true)
+}
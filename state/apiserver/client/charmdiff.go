@@ -0,0 +1,214 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"path"
+	"reflect"
+	"sort"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/environs"
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/utils/set"
+)
+
+// CharmDiff reports what upgrading a service to the given charm,
+// which must have been added to the environment, would change:
+// config options, relation endpoints, hooks and relations. The
+// service is left untouched.
+func (c *Client) CharmDiff(args params.CharmDiff) (params.CharmDiffResults, error) {
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return params.CharmDiffResults{}, err
+	}
+	oldCharm, _, err := service.Charm()
+	if err != nil {
+		return params.CharmDiffResults{}, err
+	}
+	curl, err := charm.ParseURL(args.CharmURL)
+	if err != nil {
+		return params.CharmDiffResults{}, err
+	}
+	newCharm, err := c.api.state.Charm(curl)
+	if err != nil {
+		return params.CharmDiffResults{}, err
+	}
+	settings, err := service.ConfigSettings()
+	if err != nil {
+		return params.CharmDiffResults{}, err
+	}
+	relations, err := service.Relations()
+	if err != nil {
+		return params.CharmDiffResults{}, err
+	}
+	result := params.CharmDiffResults{
+		OldCharmURL: oldCharm.URL().String(),
+		NewCharmURL: curl.String(),
+	}
+	diffOptions(&result, oldCharm.Config(), newCharm.Config())
+	result.InvalidSettings = invalidSettings(settings, newCharm.Config())
+	diffRelations(&result, oldCharm.Meta(), newCharm.Meta())
+	oldHooks, err := c.charmHooks(oldCharm)
+	if err != nil {
+		return params.CharmDiffResults{}, err
+	}
+	newHooks, err := c.charmHooks(newCharm)
+	if err != nil {
+		return params.CharmDiffResults{}, err
+	}
+	result.AddedHooks = newHooks.Difference(oldHooks).SortedValues()
+	result.RemovedHooks = oldHooks.Difference(newHooks).SortedValues()
+	for _, rel := range result.AddedRelations {
+		if rel.Role == charm.RolePeer {
+			result.AddedPeers = append(result.AddedPeers, rel.Name)
+		}
+	}
+	for _, rel := range relations {
+		ep, err := rel.Endpoint(service.Name())
+		if err != nil {
+			return params.CharmDiffResults{}, err
+		}
+		if !ep.ImplementedBy(newCharm) {
+			result.BrokenRelations = append(result.BrokenRelations, rel.String())
+		}
+	}
+	sort.Strings(result.BrokenRelations)
+	return result, nil
+}
+
+func diffOptions(result *params.CharmDiffResults, oldConfig, newConfig *charm.Config) {
+	for name, newOption := range newConfig.Options {
+		oldOption, ok := oldConfig.Options[name]
+		switch {
+		case !ok:
+			if result.AddedOptions == nil {
+				result.AddedOptions = make(map[string]charm.Option)
+			}
+			result.AddedOptions[name] = newOption
+		case !reflect.DeepEqual(oldOption, newOption):
+			if result.ChangedOptions == nil {
+				result.ChangedOptions = make(map[string]params.CharmOptionChange)
+			}
+			result.ChangedOptions[name] = params.CharmOptionChange{oldOption, newOption}
+		}
+	}
+	for name, oldOption := range oldConfig.Options {
+		if _, ok := newConfig.Options[name]; !ok {
+			if result.RemovedOptions == nil {
+				result.RemovedOptions = make(map[string]charm.Option)
+			}
+			result.RemovedOptions[name] = oldOption
+		}
+	}
+}
+
+// invalidSettings returns the settings that config doesn't accept
+// and that would be dropped by an upgrade.
+func invalidSettings(settings charm.Settings, config *charm.Config) charm.Settings {
+	valid := config.FilterSettings(settings)
+	var invalid charm.Settings
+	for name, value := range settings {
+		if _, ok := valid[name]; ok {
+			continue
+		}
+		if invalid == nil {
+			invalid = make(charm.Settings)
+		}
+		invalid[name] = value
+	}
+	return invalid
+}
+
+func diffRelations(result *params.CharmDiffResults, oldMeta, newMeta *charm.Meta) {
+	oldRelations := metaRelations(oldMeta)
+	newRelations := metaRelations(newMeta)
+	for _, newRel := range newRelations {
+		oldRel, ok := findRelation(oldRelations, newRel)
+		switch {
+		case !ok:
+			result.AddedRelations = append(result.AddedRelations, newRel)
+		case oldRel != newRel:
+			result.ChangedRelations = append(result.ChangedRelations, params.CharmRelationChange{oldRel, newRel})
+		}
+	}
+	for _, oldRel := range oldRelations {
+		if _, ok := findRelation(newRelations, oldRel); !ok {
+			result.RemovedRelations = append(result.RemovedRelations, oldRel)
+		}
+	}
+}
+
+// metaRelations returns all the relations declared in meta, sorted
+// by role and name.
+func metaRelations(meta *charm.Meta) []charm.Relation {
+	var relations []charm.Relation
+	for _, m := range []map[string]charm.Relation{meta.Provides, meta.Requires, meta.Peers} {
+		for _, rel := range m {
+			relations = append(relations, rel)
+		}
+	}
+	sort.Sort(relationsByRoleAndName(relations))
+	return relations
+}
+
+// findRelation returns the relation in relations with
+// the same role and name as rel, if any.
+func findRelation(relations []charm.Relation, rel charm.Relation) (charm.Relation, bool) {
+	for _, r := range relations {
+		if r.Role == rel.Role && r.Name == rel.Name {
+			return r, true
+		}
+	}
+	return charm.Relation{}, false
+}
+
+type relationsByRoleAndName []charm.Relation
+
+func (r relationsByRoleAndName) Len() int      { return len(r) }
+func (r relationsByRoleAndName) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r relationsByRoleAndName) Less(i, j int) bool {
+	if r[i].Role != r[j].Role {
+		return r[i].Role < r[j].Role
+	}
+	return r[i].Name < r[j].Name
+}
+
+// charmHooks returns the names of the hooks held in the
+// archive of the given charm in the environment's storage.
+func (c *Client) charmHooks(ch *state.Charm) (set.Strings, error) {
+	storage, err := environs.GetStorage(c.api.state)
+	if err != nil {
+		return set.Strings{}, errors.Annotate(err, "cannot access provider storage")
+	}
+	reader, err := storage.Get(charm.Quote(ch.URL().String()))
+	if err != nil {
+		return set.Strings{}, errors.Annotatef(err, "cannot get archive of charm %q", ch.URL())
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return set.Strings{}, errors.Annotatef(err, "cannot read archive of charm %q", ch.URL())
+	}
+	zipr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return set.Strings{}, errors.Annotatef(err, "cannot read archive of charm %q", ch.URL())
+	}
+	hooks := set.NewStrings()
+	for _, f := range zipr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if dir, name := path.Split(path.Clean(f.Name)); dir == "hooks/" {
+			hooks.Add(name)
+		}
+	}
+	return hooks, nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/state/api/params"
+	coretesting "github.com/juju/core/testing"
+)
+
+type charmDiffSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&charmDiffSuite{})
+
+func (s *charmDiffSuite) TestCharmDiff(c *gc.C) {
+	service := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	err := service.UpdateConfigSettings(charm.Settings{"title": "Mine", "skill-level": 5})
+	c.Assert(err, gc.IsNil)
+
+	// Make a new revision of the dummy charm that changes an
+	// option, adds a peer relation and changes its hooks.
+	repoPath := c.MkDir()
+	err = os.Mkdir(filepath.Join(repoPath, "quantal"), 0755)
+	c.Assert(err, gc.IsNil)
+	dir := coretesting.Charms.ClonedDirPath(filepath.Join(repoPath, "quantal"), "dummy")
+	err = ioutil.WriteFile(filepath.Join(dir, "revision"), []byte("100"), 0644)
+	c.Assert(err, gc.IsNil)
+	err = os.Remove(filepath.Join(dir, "hooks", "install"))
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(filepath.Join(dir, "hooks", "cluster-relation-changed"), []byte("#!/bin/sh\n"), 0755)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`
+options:
+  title: {default: My Title, description: A descriptive title used for the service., type: string}
+  outlook: {description: No default outlook., type: string}
+  skill-level: {description: A skill description., type: string}
+  color: {default: blue, description: The color of the service., type: string}
+`), 0644)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(filepath.Join(dir, "metadata.yaml"), []byte(`
+name: dummy
+summary: "That's a dummy charm."
+description: "A dummy charm with peers."
+peers:
+  cluster: dummy-cluster
+`), 0644)
+	c.Assert(err, gc.IsNil)
+	curl := charm.MustParseURL("local:quantal/dummy-100")
+	_, err = s.Conn.PutCharm(curl, &charm.LocalRepository{Path: repoPath}, false)
+	c.Assert(err, gc.IsNil)
+
+	diff, err := s.APIState.Client().CharmDiff("dummy", curl.String())
+	c.Assert(err, gc.IsNil)
+	c.Assert(diff.OldCharmURL, gc.Equals, "local:quantal/dummy-1")
+	c.Assert(diff.NewCharmURL, gc.Equals, "local:quantal/dummy-100")
+	c.Assert(diff.AddedOptions, gc.DeepEquals, map[string]charm.Option{
+		"color": {Type: "string", Description: "The color of the service.", Default: "blue"},
+	})
+	c.Assert(diff.RemovedOptions, gc.HasLen, 1)
+	c.Assert(diff.RemovedOptions["username"].Default, gc.Equals, "admin001")
+	c.Assert(diff.ChangedOptions, gc.DeepEquals, map[string]params.CharmOptionChange{
+		"skill-level": {
+			Old: charm.Option{Type: "int", Description: "A number indicating skill."},
+			New: charm.Option{Type: "string", Description: "A skill description."},
+		},
+	})
+	// The title is still valid, but the skill level isn't a string.
+	c.Assert(diff.InvalidSettings, gc.HasLen, 1)
+	c.Assert(diff.InvalidSettings["skill-level"], gc.Equals, float64(5))
+
+	c.Assert(diff.AddedRelations, gc.DeepEquals, []charm.Relation{{
+		Name:      "cluster",
+		Role:      charm.RolePeer,
+		Interface: "dummy-cluster",
+		Limit:     1,
+		Scope:     charm.ScopeGlobal,
+	}})
+	c.Assert(diff.RemovedRelations, gc.HasLen, 0)
+	c.Assert(diff.AddedPeers, gc.DeepEquals, []string{"cluster"})
+	// Hooks are compared by what the charm archives hold,
+	// not by what the charms' metadata allows.
+	c.Assert(diff.AddedHooks, gc.DeepEquals, []string{"cluster-relation-changed"})
+	c.Assert(diff.RemovedHooks, gc.DeepEquals, []string{"install"})
+	c.Assert(diff.BrokenRelations, gc.HasLen, 0)
+}
+
+func (s *charmDiffSuite) TestCharmDiffRelations(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	alternative := s.AddTestingCharm(c, "mysql-alternative")
+
+	diff, err := s.APIState.Client().CharmDiff("mysql", alternative.URL().String())
+	c.Assert(err, gc.IsNil)
+	c.Assert(diff.AddedOptions, gc.HasLen, 0)
+	c.Assert(diff.RemovedOptions, gc.HasLen, 0)
+	c.Assert(diff.ChangedOptions, gc.HasLen, 0)
+	c.Assert(diff.InvalidSettings, gc.HasLen, 0)
+	c.Assert(diff.AddedRelations, gc.DeepEquals, []charm.Relation{{
+		Name:      "dev",
+		Role:      charm.RoleProvider,
+		Interface: "mysql",
+		Limit:     2,
+		Scope:     charm.ScopeGlobal,
+	}, {
+		Name:      "prod",
+		Role:      charm.RoleProvider,
+		Interface: "mysql",
+		Scope:     charm.ScopeGlobal,
+	}})
+	c.Assert(diff.RemovedRelations, gc.DeepEquals, []charm.Relation{{
+		Name:      "server",
+		Role:      charm.RoleProvider,
+		Interface: "mysql",
+		Scope:     charm.ScopeGlobal,
+	}})
+	// Neither charm has any hooks.
+	c.Assert(diff.AddedHooks, gc.HasLen, 0)
+	c.Assert(diff.RemovedHooks, gc.HasLen, 0)
+	c.Assert(diff.AddedPeers, gc.HasLen, 0)
+	c.Assert(diff.BrokenRelations, gc.DeepEquals, []string{"wordpress:db mysql:server"})
+}
+
+func (s *charmDiffSuite) TestCharmDiffErrors(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	_, err := s.APIState.Client().CharmDiff("unknown", "local:quantal/wordpress-3")
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+	_, err = s.APIState.Client().CharmDiff("wordpress", "local:quantal/wordpress-99")
+	c.Assert(err, gc.ErrorMatches, `charm "local:quantal/wordpress-99" not found`)
+	_, err = s.APIState.Client().CharmDiff("wordpress", "wordpress")
+	c.Assert(err, gc.ErrorMatches, "charm url series is not resolved")
+}
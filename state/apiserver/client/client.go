@@ -72,6 +72,43 @@ func (r *API) Client(id string) (*Client, error) {
 	return r.client, nil
 }
 
+// ClientV1 serves version 1 of the Client facade. Clients using
+// version 1 can rely on AddMachines accepting placement directives
+// and ServiceDeploy accepting networks, rather than calling the
+// AddMachinesV2 and ServiceDeployWithNetworks variants that version
+// 0 provides for compatibility.
+type ClientV1 struct {
+	*Client
+}
+
+// ClientV1 returns an object that provides access to
+// version 1 of the methods accessible to non-agent clients.
+func (r *API) ClientV1(id string) (ClientV1, error) {
+	client, err := r.Client(id)
+	if err != nil {
+		return ClientV1{}, err
+	}
+	return ClientV1{client}, nil
+}
+
+// ClientV2 serves version 2 of the Client facade. Clients using
+// version 2 can rely on ServiceSet, NewServiceSetForClientAPI and
+// ServiceSetYAML honouring SettingsRevision; version 1 servers may
+// ignore it and set the configuration unconditionally.
+type ClientV2 struct {
+	ClientV1
+}
+
+// ClientV2 returns an object that provides access to
+// version 2 of the methods accessible to non-agent clients.
+func (r *API) ClientV2(id string) (ClientV2, error) {
+	client, err := r.ClientV1(id)
+	if err != nil {
+		return ClientV2{}, err
+	}
+	return ClientV2{client}, nil
+}
+
 func (c *Client) WatchAll() (params.AllWatcherId, error) {
 	w := c.api.state.Watch()
 	return params.AllWatcherId{
@@ -89,7 +126,7 @@ func (c *Client) ServiceSet(p params.ServiceSet) error {
 	if err != nil {
 		return err
 	}
-	return serviceSetSettingsStrings(svc, p.Options)
+	return serviceSetSettingsStrings(svc, p.Options, c.authUser(), settingsRevision(p.SettingsRevision))
 }
 
 // NewServiceSetForClientAPI implements the server side of
@@ -104,7 +141,7 @@ func (c *Client) NewServiceSetForClientAPI(p params.ServiceSet) error {
 	if err != nil {
 		return err
 	}
-	return newServiceSetSettingsStringsForClientAPI(svc, p.Options)
+	return newServiceSetSettingsStringsForClientAPI(svc, p.Options, c.authUser(), settingsRevision(p.SettingsRevision))
 }
 
 // ServiceUnset implements the server side of Client.ServiceUnset.
@@ -117,7 +154,7 @@ func (c *Client) ServiceUnset(p params.ServiceUnset) error {
 	for _, option := range p.Options {
 		settings[option] = nil
 	}
-	return svc.UpdateConfigSettings(settings)
+	return svc.UpdateConfigSettingsBy(settings, c.authUser(), -1)
 }
 
 // ServiceSetYAML implements the server side of Client.ServerSetYAML.
@@ -126,7 +163,7 @@ func (c *Client) ServiceSetYAML(p params.ServiceSetYAML) error {
 	if err != nil {
 		return err
 	}
-	return serviceSetSettingsYAML(svc, p.Config)
+	return serviceSetSettingsYAML(svc, p.Config, c.authUser(), settingsRevision(p.SettingsRevision))
 }
 
 // ServiceCharmRelations implements the server side of Client.ServiceCharmRelations.
@@ -343,11 +380,11 @@ func (c *Client) ServiceUpdate(args params.ServiceUpdate) error {
 	}
 	// Set up service's settings.
 	if args.SettingsYAML != "" {
-		if err = serviceSetSettingsYAML(service, args.SettingsYAML); err != nil {
+		if err = serviceSetSettingsYAML(service, args.SettingsYAML, c.authUser(), -1); err != nil {
 			return err
 		}
 	} else if len(args.SettingsStrings) > 0 {
-		if err = serviceSetSettingsStrings(service, args.SettingsStrings); err != nil {
+		if err = serviceSetSettingsStrings(service, args.SettingsStrings, c.authUser(), -1); err != nil {
 			return err
 		}
 	}
@@ -397,9 +434,29 @@ func (c *Client) serviceSetCharm1dot16(service *state.Service, curl *charm.URL,
 	return service.SetCharm(ch, force)
 }
 
-// serviceSetSettingsYAML updates the settings for the given service,
-// taking the configuration from a YAML string.
-func serviceSetSettingsYAML(service *state.Service, settings string) error {
+// authUser returns the name of the user the client is
+// authenticated as, or "" if it's not a user.
+func (c *Client) authUser() string {
+	_, user, err := names.ParseTag(c.api.auth.GetAuthTag(), names.UserTagKind)
+	if err != nil {
+		return ""
+	}
+	return user
+}
+
+// settingsRevision returns the settings revision a change to
+// a service's settings must be based on, or -1 if none.
+func settingsRevision(revision *int) int {
+	if revision == nil {
+		return -1
+	}
+	return *revision
+}
+
+// serviceSetSettingsYAML updates the settings for the given service
+// on behalf of user, taking the configuration from a YAML string. If
+// revision is not -1, the settings must be at that revision.
+func serviceSetSettingsYAML(service *state.Service, settings string, user string, revision int) error {
 	ch, _, err := service.Charm()
 	if err != nil {
 		return err
@@ -408,12 +465,13 @@ func serviceSetSettingsYAML(service *state.Service, settings string) error {
 	if err != nil {
 		return err
 	}
-	return service.UpdateConfigSettings(changes)
+	return service.UpdateConfigSettingsBy(changes, user, revision)
 }
 
-// serviceSetSettingsStrings updates the settings for the given service,
-// taking the configuration from a map of strings.
-func serviceSetSettingsStrings(service *state.Service, settings map[string]string) error {
+// serviceSetSettingsStrings updates the settings for the given service
+// like serviceSetSettingsYAML, taking the configuration from a map of
+// strings.
+func serviceSetSettingsStrings(service *state.Service, settings map[string]string, user string, revision int) error {
 	ch, _, err := service.Charm()
 	if err != nil {
 		return err
@@ -423,15 +481,16 @@ func serviceSetSettingsStrings(service *state.Service, settings map[string]strin
 	if err != nil {
 		return err
 	}
-	return service.UpdateConfigSettings(changes)
+	return service.UpdateConfigSettingsBy(changes, user, revision)
 }
 
 // newServiceSetSettingsStringsForClientAPI updates the settings for the given
-// service, taking the configuration from a map of strings.
+// service like serviceSetSettingsYAML, taking the configuration from a map of
+// strings.
 //
 // TODO(Nate): replace serviceSetSettingsStrings with this onces the GUI no
 // longer expects to be able to unset values by sending an empty string.
-func newServiceSetSettingsStringsForClientAPI(service *state.Service, settings map[string]string) error {
+func newServiceSetSettingsStringsForClientAPI(service *state.Service, settings map[string]string, user string, revision int) error {
 	ch, _, err := service.Charm()
 	if err != nil {
 		return err
@@ -443,7 +502,7 @@ func newServiceSetSettingsStringsForClientAPI(service *state.Service, settings m
 		return err
 	}
 
-	return service.UpdateConfigSettings(changes)
+	return service.UpdateConfigSettingsBy(changes, user, revision)
 }
 
 // ServiceSetCharm sets the charm for a given service.
@@ -452,7 +511,19 @@ func (c *Client) ServiceSetCharm(args params.ServiceSetCharm) error {
 	if err != nil {
 		return err
 	}
-	return c.serviceSetCharm(service, args.CharmUrl, args.Force)
+	if args.BatchSize == 0 {
+		return c.serviceSetCharm(service, args.CharmUrl, args.Force)
+	}
+	// Clients asking for a rolling upgrade always add the charm first.
+	curl, err := charm.ParseURL(args.CharmUrl)
+	if err != nil {
+		return err
+	}
+	sch, err := c.api.state.Charm(curl)
+	if err != nil {
+		return err
+	}
+	return service.SetCharmRollout(sch, args.Force, args.BatchSize, args.WaitHealthy)
 }
 
 // addServiceUnits adds a given number of units to a service.
@@ -958,6 +1029,13 @@ func (c *Client) AddCharm(args params.CharmURL) error {
 	if !ok {
 		return errors.Errorf("expected a charm archive, got %T", downloadedCharm)
 	}
+	signature, err := charmSignature(store, envConfig, charmURL)
+	if err != nil {
+		return err
+	}
+	if err := common.VerifyCharmArchive(envConfig, fmt.Sprintf("charm %q", charmURL), downloadedBundle.Path, signature); err != nil {
+		return err
+	}
 	archive, err := os.Open(downloadedBundle.Path)
 	if err != nil {
 		return errors.Annotate(err, "cannot read downloaded charm")
@@ -1009,6 +1087,21 @@ func (c *Client) AddCharm(args params.CharmURL) error {
 	return err
 }
 
+// charmSignature returns the signature of the archive of the charm
+// at curl held by repo, or nil if the archive isn't signed. Signatures
+// aren't looked for when the environment doesn't verify them.
+func charmSignature(repo charm.Repository, envConfig *config.Config, curl *charm.URL) ([]byte, error) {
+	source, ok := repo.(charm.SignatureSource)
+	if !ok || envConfig.CharmVerification() == config.CharmVerificationOff {
+		return nil, nil
+	}
+	signature, err := source.Signature(curl)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get signature of charm %q", curl.String())
+	}
+	return signature, nil
+}
+
 func (c *Client) ResolveCharms(args params.ResolveCharms) (params.ResolveCharmResults, error) {
 	var results params.ResolveCharmResults
 
@@ -1094,3 +1187,38 @@ func (c *Client) EnsureAvailability(args params.EnsureAvailability) error {
 	}
 	return c.api.state.EnsureAvailability(args.NumStateServers, args.Constraints, series)
 }
+
+// RotateAgentCredentials asks the agents of the given machines, and of
+// the units assigned to them, to replace their passwords. If no
+// machines are given, all agents in the environment are asked.
+func (c *Client) RotateAgentCredentials(args params.RotateAgentCredentials) error {
+	var machines []*state.Machine
+	if len(args.MachineIds) == 0 {
+		var err error
+		if machines, err = c.api.state.AllMachines(); err != nil {
+			return err
+		}
+	}
+	for _, id := range args.MachineIds {
+		machine, err := c.api.state.Machine(id)
+		if err != nil {
+			return err
+		}
+		machines = append(machines, machine)
+	}
+	for _, machine := range machines {
+		if err := c.api.state.RequestPasswordRotation(machine.Tag()); err != nil {
+			return err
+		}
+		units, err := machine.Units()
+		if err != nil {
+			return err
+		}
+		for _, unit := range units {
+			if err := c.api.state.RequestPasswordRotation(unit.Tag()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ package client_test
 import (
 	"fmt"
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,6 +17,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/core/charm"
+	charmtesting "github.com/juju/core/charm/testing"
 	"github.com/juju/core/constraints"
 	"github.com/juju/core/environs"
 	"github.com/juju/core/environs/config"
@@ -1113,6 +1115,36 @@ func (s *clientSuite) TestClientServiceSetCharmForce(c *gc.C) {
 	c.Assert(force, gc.Equals, true)
 }
 
+func (s *clientSuite) TestClientServiceSetCharmRollout(c *gc.C) {
+	service := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	for i := 0; i < 2; i++ {
+		unit, err := service.AddUnit()
+		c.Assert(err, gc.IsNil)
+		err = unit.SetCharmURL(charm.MustParseURL("local:quantal/mysql-1"))
+		c.Assert(err, gc.IsNil)
+	}
+	alternative := s.AddTestingCharm(c, "mysql-alternative")
+	err := s.APIState.Client().ServiceSetCharmRollout(
+		"mysql", alternative.URL().String(), false, 1, true,
+	)
+	c.Assert(err, gc.IsNil)
+
+	err = service.Refresh()
+	c.Assert(err, gc.IsNil)
+	curl, _ := service.CharmURL()
+	c.Assert(curl, gc.DeepEquals, alternative.URL())
+	rollout, ok := service.Rollout()
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(rollout.BatchSize, gc.Equals, 1)
+	c.Assert(rollout.WaitHealthy, gc.Equals, true)
+	c.Assert(rollout.Pending, gc.DeepEquals, []string{"mysql/0", "mysql/1"})
+
+	err = s.APIState.Client().ServiceSetCharmRollout(
+		"mysql", "local:quantal/mysql-99", false, 1, false,
+	)
+	c.Assert(err, gc.ErrorMatches, `charm "local:quantal/mysql-99" not found`)
+}
+
 func (s *clientSuite) TestClientServiceSetCharmInvalidService(c *gc.C) {
 	_, restore := makeMockCharmStore()
 	defer restore()
@@ -1993,6 +2025,46 @@ func (s *clientSuite) TestAddCharm(c *gc.C) {
 	s.assertUploaded(c, storage, sch.BundleURL(), sch.BundleSha256())
 }
 
+func (s *clientSuite) TestAddCharmVerification(c *gc.C) {
+	store, restore := makeMockCharmStore()
+	defer restore()
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"charm-verification": "strict",
+		"charm-trusted-keys": charmtesting.SigningPublicKey,
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	client := s.APIState.Client()
+	curl, bundle := addCharm(c, store, "wordpress")
+
+	// Unsigned charms are refused.
+	err = client.AddCharm(curl)
+	c.Assert(err, gc.ErrorMatches, `cannot accept charm "cs:precise/wordpress-3": charm signature verification failed: charm archive is not signed`)
+
+	// So are charms signed by untrusted keys.
+	store.SetSignature(curl, signArchive(c, bundle.(*charm.Bundle).Path, charmtesting.UntrustedPrivateKey))
+	err = client.AddCharm(curl)
+	c.Assert(err, gc.ErrorMatches, `cannot accept charm "cs:precise/wordpress-3": charm signature verification failed: .*`)
+	_, err = s.State.Charm(curl)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	// Charms signed by a trusted key are added.
+	store.SetSignature(curl, signArchive(c, bundle.(*charm.Bundle).Path, charmtesting.SigningPrivateKey))
+	err = client.AddCharm(curl)
+	c.Assert(err, gc.IsNil)
+	sch, err := s.State.Charm(curl)
+	c.Assert(err, gc.IsNil)
+	s.assertUploaded(c, s.Conn.Environ.Storage(), sch.BundleURL(), sch.BundleSha256())
+}
+
+func signArchive(c *gc.C, path, privateKey string) []byte {
+	f, err := os.Open(path)
+	c.Assert(err, gc.IsNil)
+	defer f.Close()
+	signature, err := charm.SignArchive(f, privateKey, "")
+	c.Assert(err, gc.IsNil)
+	return signature
+}
+
 var resolveCharmCases = []struct {
 	schema, defaultSeries, charmName string
 	parseErr                         string
@@ -2343,3 +2415,43 @@ func (s *clientSuite) TestClientAgentVersion(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(result, gc.Equals, current)
 }
+
+func (s *clientSuite) TestClientRotateAgentCredentials(c *gc.C) {
+	s.setUpScenario(c)
+	err := s.APIState.Client().RotateAgentCredentials("1")
+	c.Assert(err, gc.IsNil)
+	for tag, expect := range map[string]int{
+		"machine-0":        0,
+		"machine-1":        1,
+		"unit-wordpress-0": 1,
+		"unit-logging-0":   1,
+		"machine-2":        0,
+		"unit-wordpress-1": 0,
+	} {
+		revno, err := s.State.PasswordRotation(tag)
+		c.Assert(err, gc.IsNil)
+		c.Check(revno, gc.Equals, expect, gc.Commentf("entity %s", tag))
+	}
+
+	// With no machines given, every agent is asked.
+	err = s.APIState.Client().RotateAgentCredentials()
+	c.Assert(err, gc.IsNil)
+	for tag, expect := range map[string]int{
+		"machine-0":        1,
+		"machine-1":        2,
+		"unit-wordpress-0": 2,
+		"unit-logging-0":   2,
+		"machine-2":        1,
+		"unit-wordpress-1": 1,
+		"unit-logging-1":   1,
+	} {
+		revno, err := s.State.PasswordRotation(tag)
+		c.Assert(err, gc.IsNil)
+		c.Check(revno, gc.Equals, expect, gc.Commentf("entity %s", tag))
+	}
+}
+
+func (s *clientSuite) TestClientRotateAgentCredentialsUnknownMachine(c *gc.C) {
+	err := s.APIState.Client().RotateAgentCredentials("42")
+	c.Assert(err, gc.ErrorMatches, "machine 42 not found")
+}
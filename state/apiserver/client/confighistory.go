@@ -0,0 +1,58 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api/params"
+)
+
+// ServiceConfigHistory returns the recorded changes
+// to a service's config settings.
+func (c *Client) ServiceConfigHistory(args params.ServiceConfigHistory) (params.ServiceConfigHistoryResults, error) {
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return params.ServiceConfigHistoryResults{}, err
+	}
+	revisions, err := service.ConfigSettingsHistory()
+	if err != nil {
+		return params.ServiceConfigHistoryResults{}, err
+	}
+	var result params.ServiceConfigHistoryResults
+	for _, rev := range revisions {
+		result.Revisions = append(result.Revisions, params.ServiceConfigRevision{
+			Revision: rev.Revision,
+			User:     rev.User,
+			Time:     rev.Time,
+			Charm:    rev.CharmURL.String(),
+			Settings: rev.Settings,
+			Changes:  settingChanges(rev.Changes),
+		})
+	}
+	return result, nil
+}
+
+func settingChanges(changes []state.ItemChange) []params.SettingChange {
+	result := make([]params.SettingChange, len(changes))
+	for i, change := range changes {
+		result[i] = params.SettingChange{Key: change.Key}
+		if change.Type != state.ItemAdded {
+			result[i].Old = change.OldValue
+		}
+		if change.Type != state.ItemDeleted {
+			result[i].New = change.NewValue
+		}
+	}
+	return result
+}
+
+// ServiceRollbackConfig restores a service's config settings to
+// those of a revision recorded in its history.
+func (c *Client) ServiceRollbackConfig(args params.ServiceRollbackConfig) error {
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return err
+	}
+	return service.RollbackConfigSettings(args.Revision, c.authUser())
+}
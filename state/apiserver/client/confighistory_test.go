@@ -0,0 +1,85 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/state/api/params"
+)
+
+type configHistorySuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&configHistorySuite{})
+
+func (s *configHistorySuite) TestServiceConfigHistory(c *gc.C) {
+	service := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	client := s.APIState.Client()
+	revisions, err := client.ServiceConfigHistory("dummy")
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 0)
+
+	err = client.ServiceSet("dummy", map[string]string{"title": "foo"})
+	c.Assert(err, gc.IsNil)
+	err = client.ServiceSetYAML("dummy", "dummy:\n  title: bar\n  outlook: fine\n")
+	c.Assert(err, gc.IsNil)
+
+	revisions, err = client.ServiceConfigHistory("dummy")
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 2)
+	for i, rev := range revisions {
+		c.Check(rev.Revision, gc.Equals, i+1)
+		c.Check(rev.User, gc.Equals, "admin")
+		c.Check(rev.Charm, gc.Equals, "local:quantal/dummy-1")
+	}
+	c.Assert(revisions[0].Changes, gc.DeepEquals, []params.SettingChange{
+		{Key: "title", New: "foo"},
+	})
+	c.Assert(revisions[1].Settings, gc.DeepEquals, map[string]interface{}{
+		"title":   "bar",
+		"outlook": "fine",
+	})
+	c.Assert(revisions[1].Changes, gc.DeepEquals, []params.SettingChange{
+		{Key: "outlook", New: "fine"},
+		{Key: "title", Old: "foo", New: "bar"},
+	})
+
+	err = client.ServiceRollbackConfig("dummy", 1)
+	c.Assert(err, gc.IsNil)
+	settings, err := service.ConfigSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, charm.Settings{"title": "foo"})
+
+	_, err = client.ServiceConfigHistory("unknown")
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+}
+
+func (s *configHistorySuite) TestServiceSetAtRevision(c *gc.C) {
+	service := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	client := s.APIState.Client()
+	err := client.ServiceSetAtRevision("dummy", map[string]string{"title": "foo"}, 0)
+	c.Assert(err, gc.IsNil)
+	err = client.ServiceSetYAMLAtRevision("dummy", "dummy:\n  title: bar\n", 0)
+	c.Assert(err, gc.ErrorMatches, `cannot update settings of service "dummy": settings changed since revision 0 \(now at revision 1\)`)
+	err = client.ServiceSetYAMLAtRevision("dummy", "dummy:\n  title: bar\n", 1)
+	c.Assert(err, gc.IsNil)
+
+	settings, err := service.ConfigSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, charm.Settings{"title": "bar"})
+	result, err := client.ServiceGet("dummy")
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.SettingsRevision, gc.Equals, 2)
+}
+
+func (s *configHistorySuite) TestServiceRollbackConfigErrors(c *gc.C) {
+	s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	err := s.APIState.Client().ServiceRollbackConfig("dummy", 1)
+	c.Assert(err, gc.ErrorMatches, `cannot roll back settings of service "dummy" to revision 1: no such revision`)
+	err = s.APIState.Client().ServiceRollbackConfig("unknown", 1)
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+}
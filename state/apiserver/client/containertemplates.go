@@ -0,0 +1,37 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"github.com/juju/core/state/api/params"
+)
+
+// ContainerTemplates returns the container templates cached on a
+// machine, as last reported by its agent.
+func (c *Client) ContainerTemplates(args params.ContainerTemplates) (params.ContainerTemplatesResult, error) {
+	machine, err := c.api.state.Machine(args.Machine)
+	if err != nil {
+		return params.ContainerTemplatesResult{}, err
+	}
+	templates, reported, err := machine.ContainerTemplates()
+	if err != nil {
+		return params.ContainerTemplatesResult{}, err
+	}
+	result := params.ContainerTemplatesResult{Reported: reported}
+	for _, t := range templates {
+		result.Templates = append(result.Templates, params.ContainerTemplate(t))
+	}
+	return result, nil
+}
+
+// PurgeContainerTemplates asks the agent of a machine to purge the
+// named container templates cached on it, or all of them if no names
+// are given. Templates still used by containers are not purged.
+func (c *Client) PurgeContainerTemplates(args params.PurgeContainerTemplates) error {
+	machine, err := c.api.state.Machine(args.Machine)
+	if err != nil {
+		return err
+	}
+	return machine.RequestContainerTemplatePurge(args.Names...)
+}
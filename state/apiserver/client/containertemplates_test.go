@@ -0,0 +1,70 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/instance"
+	"github.com/juju/core/state"
+	"github.com/juju/core/version"
+)
+
+type containerTemplatesSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&containerTemplatesSuite{})
+
+func (s *containerTemplatesSuite) TestContainerTemplates(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	client := s.APIState.Client()
+	result, err := client.ContainerTemplates(machine.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Templates, gc.HasLen, 0)
+	c.Assert(result.Reported.IsZero(), gc.Equals, true)
+
+	err = machine.SetContainerTemplates([]state.ContainerTemplate{{
+		Name:   "juju-trusty-amd64-1.19.4-template",
+		Type:   instance.LXC,
+		Series: "trusty",
+		Arch:   "amd64",
+		Tools:  version.MustParse("1.19.4"),
+	}}, 0)
+	c.Assert(err, gc.IsNil)
+	result, err = client.ContainerTemplates(machine.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Templates, gc.HasLen, 1)
+	c.Assert(result.Templates[0].Name, gc.Equals, "juju-trusty-amd64-1.19.4-template")
+	c.Assert(result.Templates[0].Type, gc.Equals, instance.LXC)
+	c.Assert(result.Templates[0].Tools, gc.Equals, version.MustParse("1.19.4"))
+	c.Assert(result.Reported.IsZero(), gc.Equals, false)
+
+	_, err = client.ContainerTemplates("42")
+	c.Assert(err, gc.ErrorMatches, "machine 42 not found")
+}
+
+func (s *containerTemplatesSuite) TestPurgeContainerTemplates(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	client := s.APIState.Client()
+	err = client.PurgeContainerTemplates(machine.Id(), "juju-trusty-amd64-1.19.4-template")
+	c.Assert(err, gc.IsNil)
+	purge, _, err := machine.ContainerTemplatePurge()
+	c.Assert(err, gc.IsNil)
+	c.Assert(purge, gc.DeepEquals, state.ContainerTemplatePurge{
+		Revno: 1,
+		Names: []string{"juju-trusty-amd64-1.19.4-template"},
+	})
+
+	err = client.PurgeContainerTemplates(machine.Id())
+	c.Assert(err, gc.IsNil)
+	purge, _, err = machine.ContainerTemplatePurge()
+	c.Assert(err, gc.IsNil)
+	c.Assert(purge, gc.DeepEquals, state.ContainerTemplatePurge{Revno: 2})
+
+	err = client.PurgeContainerTemplates("42")
+	c.Assert(err, gc.ErrorMatches, "machine 42 not found")
+}
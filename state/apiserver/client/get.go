@@ -32,10 +32,11 @@ func (c *Client) ServiceGet(args params.ServiceGet) (params.ServiceGetResults, e
 		}
 	}
 	return params.ServiceGetResults{
-		Service:     args.ServiceName,
-		Charm:       charm.Meta().Name,
-		Config:      configInfo,
-		Constraints: constraints,
+		Service:          args.ServiceName,
+		Charm:            charm.Meta().Name,
+		Config:           configInfo,
+		Constraints:      constraints,
+		SettingsRevision: service.ConfigSettingsRevision(),
 	}, nil
 }
 
@@ -63,6 +63,7 @@ var getTests = []struct {
 		// Outlook is left unset.
 	},
 	expect: params.ServiceGetResults{
+		SettingsRevision: 1,
 		Config: map[string]interface{}{
 			"title": map[string]interface{}{
 				"description": "A descriptive title used for the service.",
@@ -100,6 +101,7 @@ var getTests = []struct {
 		"outlook": "phlegmatic",
 	},
 	expect: params.ServiceGetResults{
+		SettingsRevision: 1,
 		Config: map[string]interface{}{
 			"title": map[string]interface{}{
 				"description": "A descriptive title used for the service.",
@@ -5,6 +5,7 @@ package client_test
 
 import (
 	"strings"
+	"time"
 
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
@@ -128,10 +129,42 @@ var operationPermTests = []struct {
 	about: "Client.WatchAll",
 	op:    opClientWatchAll,
 	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.Webhooks",
+	op:    opClientWebhooks,
+	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.WebhookAdd",
+	op:    opClientWebhookAdd,
+	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.WebhookFailures",
+	op:    opClientWebhookFailures,
+	allow: []string{"user-admin", "user-other"},
 }, {
 	about: "Client.CharmInfo",
 	op:    opClientCharmInfo,
 	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.CharmDiff",
+	op:    opClientCharmDiff,
+	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.ServiceConfigHistory",
+	op:    opClientServiceConfigHistory,
+	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.ContainerTemplates",
+	op:    opClientContainerTemplates,
+	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.PurgeContainerTemplates",
+	op:    opClientPurgeContainerTemplates,
+	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.ServiceResources",
+	op:    opClientServiceResources,
+	allow: []string{"user-admin", "user-other"},
 }, {
 	about: "Client.AddRelation",
 	op:    opClientAddRelation,
@@ -197,6 +230,49 @@ func opClientCharmInfo(c *gc.C, st *api.State, mst *state.State) (func(), error)
 	return func() {}, nil
 }
 
+func opClientCharmDiff(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	diff, err := st.Client().CharmDiff("wordpress", "local:quantal/wordpress-3")
+	if err != nil {
+		c.Check(diff, gc.IsNil)
+		return func() {}, err
+	}
+	c.Assert(diff.OldCharmURL, gc.Equals, "local:quantal/wordpress-3")
+	c.Assert(diff.AddedRelations, gc.HasLen, 0)
+	return func() {}, nil
+}
+
+func opClientServiceConfigHistory(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().ServiceConfigHistory("wordpress")
+	if err != nil {
+		return func() {}, err
+	}
+	return func() {}, nil
+}
+
+func opClientContainerTemplates(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().ContainerTemplates("1")
+	if err != nil {
+		return func() {}, err
+	}
+	return func() {}, nil
+}
+
+func opClientPurgeContainerTemplates(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	err := st.Client().PurgeContainerTemplates("1")
+	if err != nil {
+		return func() {}, err
+	}
+	return func() {}, nil
+}
+
+func opClientServiceResources(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().ServiceResources("wordpress")
+	if err != nil {
+		return func() {}, err
+	}
+	return func() {}, nil
+}
+
 func opClientAddRelation(c *gc.C, st *api.State, mst *state.State) (func(), error) {
 	_, err := st.Client().AddRelation("nosuch1", "nosuch2")
 	if params.IsCodeNotFound(err) {
@@ -219,10 +295,32 @@ func opClientStatus(c *gc.C, st *api.State, mst *state.State) (func(), error) {
 		c.Check(status, gc.IsNil)
 		return func() {}, err
 	}
+	// Earlier operations may have been performed by the scenario's
+	// agents, leaving their presence details behind.
+	clearAgentPresence(status)
 	c.Assert(status, jc.DeepEquals, scenarioStatus)
 	return func() {}, nil
 }
 
+// clearAgentPresence clears the agent presence details in status.
+func clearAgentPresence(status *api.Status) {
+	for id, m := range status.Machines {
+		m.AgentLastSeen, m.AgentAPIAddress = time.Time{}, ""
+		status.Machines[id] = m
+	}
+	for _, svc := range status.Services {
+		clearUnitsAgentPresence(svc.Units)
+	}
+}
+
+func clearUnitsAgentPresence(units map[string]api.UnitStatus) {
+	for name, u := range units {
+		u.AgentLastSeen, u.AgentAPIAddress = time.Time{}, ""
+		clearUnitsAgentPresence(u.Subordinates)
+		units[name] = u
+	}
+}
+
 func resetBlogTitle(c *gc.C, st *api.State) func() {
 	return func() {
 		err := st.Client().ServiceSet("wordpress", map[string]string{
@@ -449,3 +547,29 @@ func opClientWatchAll(c *gc.C, st *api.State, mst *state.State) (func(), error)
 	}
 	return func() {}, err
 }
+
+func opClientWebhooks(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().Webhooks()
+	return func() {}, err
+}
+
+func opClientWebhookFailures(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	w, err := mst.AddWebhook(state.WebhookInfo{URL: "http://example.com/hook", Secret: "s3cret"})
+	c.Assert(err, gc.IsNil)
+	_, err = st.Client().WebhookFailures(w.Id())
+	return func() {
+		err := mst.RemoveWebhook(w.Id())
+		c.Assert(err, gc.IsNil)
+	}, err
+}
+
+func opClientWebhookAdd(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	w, err := st.Client().WebhookAdd(params.WebhookAdd{URL: "http://example.com/hook"})
+	if err != nil {
+		return func() {}, err
+	}
+	return func() {
+		err := st.Client().WebhookRemove(w.Id)
+		c.Assert(err, gc.IsNil)
+	}, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"sort"
+
+	"github.com/juju/core/state/api/params"
+)
+
+// ServiceResources returns the resources declared by a service's
+// charm, and the revisions of their content attached to the service.
+func (c *Client) ServiceResources(args params.ServiceResources) (params.ServiceResourcesResults, error) {
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return params.ServiceResourcesResults{}, err
+	}
+	ch, _, err := service.Charm()
+	if err != nil {
+		return params.ServiceResourcesResults{}, err
+	}
+	resources := make(map[string]*params.ServiceResource)
+	for name, declared := range ch.Meta().Resources {
+		resources[name] = &params.ServiceResource{
+			Name:        name,
+			Filename:    declared.Filename,
+			Description: declared.Description,
+		}
+	}
+	for _, attached := range service.Resources() {
+		res := resources[attached.Name]
+		if res == nil {
+			res = &params.ServiceResource{Name: attached.Name}
+			resources[attached.Name] = res
+		}
+		res.Revision = attached.Revision
+		res.SHA256 = attached.SHA256
+		res.Size = attached.Size
+		res.User = attached.User
+		res.Time = attached.Time
+	}
+	var names []string
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result params.ServiceResourcesResults
+	for _, name := range names {
+		result.Resources = append(result.Resources, *resources[name])
+	}
+	return result, nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"strings"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/state/api/params"
+)
+
+type resourcesSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&resourcesSuite{})
+
+func (s *resourcesSuite) TestServiceResources(c *gc.C) {
+	s.AddTestingService(c, "resources", s.AddTestingCharm(c, "resources"))
+	client := s.APIState.Client()
+	resources, err := client.ServiceResources("resources")
+	c.Assert(err, gc.IsNil)
+	c.Assert(resources, gc.DeepEquals, []params.ServiceResource{{
+		Name:     "app",
+		Filename: "app.war",
+	}, {
+		Name:        "jdk",
+		Filename:    "jdk.tar.gz",
+		Description: "The Java runtime the charm installs.",
+	}})
+
+	res, err := client.AttachResource("resources", "jdk", strings.NewReader("the jdk"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(res.Name, gc.Equals, "jdk")
+	c.Assert(res.Revision, gc.Equals, 1)
+	c.Assert(res.User, gc.Equals, "admin")
+
+	resources, err = client.ServiceResources("resources")
+	c.Assert(err, gc.IsNil)
+	c.Assert(resources, gc.HasLen, 2)
+	c.Assert(resources[0].Revision, gc.Equals, 0)
+	c.Assert(resources[1].Revision, gc.Equals, 1)
+	c.Assert(resources[1].SHA256, gc.Equals, res.SHA256)
+	c.Assert(resources[1].Size, gc.Equals, int64(7))
+
+	_, err = client.AttachResource("resources", "unknown", strings.NewReader("content"))
+	c.Assert(err, gc.ErrorMatches, `error uploading resource: charm "local:quantal/resources-1" declares no resource "unknown"`)
+	_, err = client.ServiceResources("unknown")
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+}
@@ -8,6 +8,7 @@ import (
 	"path"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/juju/errors"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/juju/core/state"
 	"github.com/juju/core/state/api"
 	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/state/presence"
 	"github.com/juju/core/tools"
 	"github.com/juju/core/utils/set"
 )
@@ -354,6 +356,7 @@ func (context *statusContext) makeMachineStatus(machine *state.Machine) (status
 		status.AgentState,
 		status.AgentStateInfo,
 		status.Err = processAgent(machine)
+	status.AgentLastSeen, status.AgentAPIAddress = processAgentPresence(machine)
 	status.Series = machine.Series()
 	status.Jobs = paramsJobsFromJobs(machine.Jobs())
 	status.WantsVote = machine.WantsVote()
@@ -449,6 +452,18 @@ func (context *statusContext) processService(service *state.Service) (status api
 	if service.IsPrincipal() {
 		status.Units = context.processUnits(context.units[service.Name()], serviceCharmURL.String())
 	}
+	if rollout, ok := service.Rollout(); ok {
+		status.Rollout = &api.RolloutStatus{
+			FromCharm:   rollout.FromCharmURL.String(),
+			BatchSize:   rollout.BatchSize,
+			WaitHealthy: rollout.WaitHealthy,
+			Upgrading:   rollout.Upgrading,
+			Upgraded:    rollout.Upgraded,
+			Pending:     rollout.Pending,
+			Halted:      rollout.Halted,
+			Message:     rollout.Message,
+		}
+	}
 	return status
 }
 
@@ -477,6 +492,7 @@ func (context *statusContext) processUnit(unit *state.Unit, serviceCharm string)
 		status.AgentState,
 		status.AgentStateInfo,
 		status.Err = processAgent(unit)
+	status.AgentLastSeen, status.AgentAPIAddress = processAgentPresence(unit)
 	if subUnits := unit.SubordinateNames(); len(subUnits) > 0 {
 		status.Subordinates = make(map[string]api.UnitStatus)
 		for _, name := range subUnits {
@@ -568,6 +584,22 @@ func processAgent(entity stateAgent) (life string, version string, status params
 	return
 }
 
+type agentPresencer interface {
+	AgentPresence() (presence.Info, error)
+}
+
+// processAgentPresence returns when the agent of the given entity was
+// last seen alive, and the address of the API server it is connected
+// to, if known. Presence details are informational only, so any error
+// retrieving them is treated as the details being unknown.
+func processAgentPresence(entity agentPresencer) (lastSeen time.Time, apiAddress string) {
+	info, err := entity.AgentPresence()
+	if err != nil {
+		return time.Time{}, ""
+	}
+	return info.LastSeen, info.Address
+}
+
 func processLife(entity lifer) string {
 	if life := entity.Life(); life != state.Alive {
 		// alive is the usual state so omit it by default.
@@ -0,0 +1,96 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/utils"
+)
+
+// WebhookAdd subscribes a URL to changes in the environment. The
+// returned webhook holds the secret used to sign the posted changes,
+// which is generated if none is given.
+func (c *Client) WebhookAdd(args params.WebhookAdd) (params.Webhook, error) {
+	secret := args.Secret
+	if secret == "" {
+		var err error
+		if secret, err = utils.RandomPassword(); err != nil {
+			return params.Webhook{}, err
+		}
+	}
+	w, err := c.api.state.AddWebhook(state.WebhookInfo{
+		URL:         args.URL,
+		Secret:      secret,
+		EntityKinds: args.EntityKinds,
+		EntityIds:   args.EntityIds,
+	})
+	if err != nil {
+		return params.Webhook{}, err
+	}
+	result := webhookParams(w, 0)
+	result.Secret = w.Secret()
+	return result, nil
+}
+
+// Webhooks returns the webhook subscriptions in the environment,
+// along with the number of deliveries to each that failed. Their
+// secrets are not returned.
+func (c *Client) Webhooks() (params.WebhooksResults, error) {
+	webhooks, err := c.api.state.AllWebhooks()
+	if err != nil {
+		return params.WebhooksResults{}, err
+	}
+	results := params.WebhooksResults{
+		Webhooks: make([]params.Webhook, len(webhooks)),
+	}
+	for i, w := range webhooks {
+		failures, err := c.api.state.WebhookFailureCount(w.Id())
+		if err != nil {
+			return params.WebhooksResults{}, err
+		}
+		results.Webhooks[i] = webhookParams(w, failures)
+	}
+	return results, nil
+}
+
+// WebhookRemove removes a webhook subscription.
+func (c *Client) WebhookRemove(args params.WebhookRemove) error {
+	return c.api.state.RemoveWebhook(args.Id)
+}
+
+// WebhookFailures returns the most recent deliveries to a webhook
+// that failed even after retrying, oldest first.
+func (c *Client) WebhookFailures(args params.WebhookFailures) (params.WebhookFailuresResults, error) {
+	if _, err := c.api.state.Webhook(args.Id); err != nil {
+		return params.WebhookFailuresResults{}, err
+	}
+	failures, err := c.api.state.WebhookFailures(args.Id)
+	if err != nil {
+		return params.WebhookFailuresResults{}, err
+	}
+	results := params.WebhookFailuresResults{
+		Failures: make([]params.WebhookFailure, len(failures)),
+	}
+	for i, f := range failures {
+		results.Failures[i] = params.WebhookFailure{
+			URL:      f.URL,
+			Payload:  f.Payload,
+			Attempts: f.Attempts,
+			Error:    f.Error,
+			Time:     f.Time,
+		}
+	}
+	return results, nil
+}
+
+func webhookParams(w *state.Webhook, failures int) params.Webhook {
+	return params.Webhook{
+		Id:          w.Id(),
+		URL:         w.URL(),
+		EntityKinds: w.EntityKinds(),
+		EntityIds:   w.EntityIds(),
+		Failures:    failures,
+	}
+}
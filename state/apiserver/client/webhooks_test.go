@@ -0,0 +1,118 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api/params"
+)
+
+type webhooksSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&webhooksSuite{})
+
+func (s *webhooksSuite) TestWebhookAdd(c *gc.C) {
+	w, err := s.APIState.Client().WebhookAdd(params.WebhookAdd{
+		URL:         "https://example.com/hook",
+		EntityKinds: []string{"unit"},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(w.URL, gc.Equals, "https://example.com/hook")
+	c.Assert(w.EntityKinds, gc.DeepEquals, []string{"unit"})
+	c.Assert(w.Secret, gc.Not(gc.Equals), "")
+
+	stored, err := s.State.Webhook(w.Id)
+	c.Assert(err, gc.IsNil)
+	c.Assert(stored.Secret(), gc.Equals, w.Secret)
+
+	w, err = s.APIState.Client().WebhookAdd(params.WebhookAdd{
+		URL:    "https://example.com/hook",
+		Secret: "s3cret",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(w.Secret, gc.Equals, "s3cret")
+}
+
+func (s *webhooksSuite) TestWebhookAddInvalid(c *gc.C) {
+	_, err := s.APIState.Client().WebhookAdd(params.WebhookAdd{URL: "not a url"})
+	c.Assert(err, gc.ErrorMatches, `cannot add webhook for "not a url": URL must be an absolute http or https URL`)
+}
+
+func (s *webhooksSuite) TestWebhooks(c *gc.C) {
+	w, err := s.State.AddWebhook(state.WebhookInfo{
+		URL:       "http://example.com/hook",
+		Secret:    "s3cret",
+		EntityIds: []string{"wordpress"},
+	})
+	c.Assert(err, gc.IsNil)
+	err = s.State.AddWebhookFailure(state.WebhookFailure{
+		WebhookId: w.Id(),
+		URL:       w.URL(),
+		Payload:   "[]",
+		Attempts:  3,
+		Error:     "connection refused",
+		Time:      time.Now(),
+	})
+	c.Assert(err, gc.IsNil)
+
+	webhooks, err := s.APIState.Client().Webhooks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(webhooks, gc.DeepEquals, []params.Webhook{{
+		Id:        w.Id(),
+		URL:       "http://example.com/hook",
+		EntityIds: []string{"wordpress"},
+		Failures:  1,
+	}})
+}
+
+func (s *webhooksSuite) TestWebhookFailures(c *gc.C) {
+	w, err := s.State.AddWebhook(state.WebhookInfo{URL: "http://example.com/hook", Secret: "s3cret"})
+	c.Assert(err, gc.IsNil)
+	now := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	err = s.State.AddWebhookFailure(state.WebhookFailure{
+		WebhookId: w.Id(),
+		URL:       w.URL(),
+		Payload:   "[]",
+		Attempts:  3,
+		Error:     "connection refused",
+		Time:      now,
+	})
+	c.Assert(err, gc.IsNil)
+
+	failures, err := s.APIState.Client().WebhookFailures(w.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(failures, gc.HasLen, 1)
+	c.Assert(failures[0].Time.Equal(now), gc.Equals, true)
+	failures[0].Time = time.Time{}
+	c.Assert(failures[0], gc.DeepEquals, params.WebhookFailure{
+		URL:      "http://example.com/hook",
+		Payload:  "[]",
+		Attempts: 3,
+		Error:    "connection refused",
+	})
+
+	_, err = s.APIState.Client().WebhookFailures("99")
+	c.Assert(err, gc.ErrorMatches, "webhook 99 not found")
+	c.Assert(params.IsCodeNotFound(err), gc.Equals, true)
+}
+
+func (s *webhooksSuite) TestWebhookRemove(c *gc.C) {
+	w, err := s.State.AddWebhook(state.WebhookInfo{URL: "http://example.com/hook", Secret: "s3cret"})
+	c.Assert(err, gc.IsNil)
+	err = s.APIState.Client().WebhookRemove(w.Id())
+	c.Assert(err, gc.IsNil)
+	webhooks, err := s.APIState.Client().Webhooks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(webhooks, gc.HasLen, 0)
+
+	err = s.APIState.Client().WebhookRemove(w.Id())
+	c.Assert(err, gc.ErrorMatches, "webhook .* not found")
+	c.Assert(params.IsCodeNotFound(err), gc.Equals, true)
+}
@@ -18,6 +18,7 @@ type AddressAndCertGetter interface {
 	CACert() string
 	APIHostPorts() ([][]instance.HostPort, error)
 	WatchAPIHostPorts() state.NotifyWatcher
+	WatchCACert() state.NotifyWatcher
 }
 
 // APIAddresser implements the APIAddresses method
@@ -82,6 +83,18 @@ func (a *APIAddresser) CACert() params.BytesResult {
 	}
 }
 
+// WatchCACert watches the CA certificate, which changes when the
+// environment's certificates are rotated.
+func (a *APIAddresser) WatchCACert() (params.NotifyWatchResult, error) {
+	watch := a.getter.WatchCACert()
+	if _, ok := <-watch.Changes(); ok {
+		return params.NotifyWatchResult{
+			NotifyWatcherId: a.resources.Register(watch),
+		}, nil
+	}
+	return params.NotifyWatchResult{}, watcher.MustErr(watch)
+}
+
 // StateAddresser implements a common set of methods for getting state
 // server addresses, and the CA certificate used to authenticate them.
 type StateAddresser struct {
@@ -79,3 +79,7 @@ func (fakeAddresses) APIHostPorts() ([][]instance.HostPort, error) {
 func (fakeAddresses) WatchAPIHostPorts() state.NotifyWatcher {
 	panic("should never be called")
 }
+
+func (fakeAddresses) WatchCACert() state.NotifyWatcher {
+	panic("should never be called")
+}
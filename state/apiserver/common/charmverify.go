@@ -0,0 +1,52 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/environs/config"
+)
+
+// VerifyCharmArchive checks the detached signature of the archive
+// at archivePath against the trusted keys of the environment, as its
+// charm-verification setting asks. In strict mode an archive that
+// isn't signed by a trusted key is refused; in warn mode it is
+// accepted with a logged warning. A nil signature means the archive
+// isn't signed. The archive is described as what in messages.
+func VerifyCharmArchive(cfg *config.Config, what, archivePath string, signature []byte) error {
+	mode := cfg.CharmVerification()
+	if mode == config.CharmVerificationOff {
+		return nil
+	}
+	err := checkCharmArchive(cfg, what, archivePath, signature)
+	if err == nil {
+		return nil
+	}
+	if mode == config.CharmVerificationWarn {
+		logger.Warningf("accepting %s: %v", what, err)
+		return nil
+	}
+	return fmt.Errorf("cannot accept %s: %v", what, err)
+}
+
+func checkCharmArchive(cfg *config.Config, what, archivePath string, signature []byte) error {
+	keys, ok := cfg.CharmTrustedKeys()
+	if !ok {
+		return fmt.Errorf("no charm-trusted-keys in environment configuration")
+	}
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	identities, err := charm.CheckArchiveSignature(f, signature, keys)
+	if err != nil {
+		return err
+	}
+	logger.Infof("%s signed by %v", what, identities)
+	return nil
+}
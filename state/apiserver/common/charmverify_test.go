@@ -0,0 +1,97 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/charm"
+	charmtesting "github.com/juju/core/charm/testing"
+	"github.com/juju/core/state/apiserver/common"
+	coretesting "github.com/juju/core/testing"
+)
+
+type charmVerifySuite struct {
+	coretesting.BaseSuite
+	archivePath string
+}
+
+var _ = gc.Suite(&charmVerifySuite{})
+
+var archiveData = []byte("charm archive content")
+
+func (s *charmVerifySuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.archivePath = filepath.Join(c.MkDir(), "archive.charm")
+	err := ioutil.WriteFile(s.archivePath, archiveData, 0644)
+	c.Assert(err, gc.IsNil)
+}
+
+func sign(c *gc.C, privateKey string) []byte {
+	signature, err := charm.SignArchive(bytes.NewReader(archiveData), privateKey, "")
+	c.Assert(err, gc.IsNil)
+	return signature
+}
+
+func (s *charmVerifySuite) TestVerifyCharmArchive(c *gc.C) {
+	signed := sign(c, charmtesting.SigningPrivateKey)
+	untrusted := sign(c, charmtesting.UntrustedPrivateKey)
+	for i, test := range []struct {
+		attrs     coretesting.Attrs
+		signature []byte
+		err       string
+	}{{
+		attrs: coretesting.Attrs{},
+	}, {
+		attrs:     coretesting.Attrs{"charm-verification": "off"},
+		signature: untrusted,
+	}, {
+		attrs: coretesting.Attrs{"charm-verification": "warn"},
+	}, {
+		attrs: coretesting.Attrs{
+			"charm-verification": "warn",
+			"charm-trusted-keys": charmtesting.SigningPublicKey,
+		},
+		signature: untrusted,
+	}, {
+		attrs: coretesting.Attrs{
+			"charm-verification": "strict",
+			"charm-trusted-keys": charmtesting.SigningPublicKey,
+		},
+		signature: signed,
+	}, {
+		attrs: coretesting.Attrs{
+			"charm-verification": "strict",
+			"charm-trusted-keys": charmtesting.SigningPublicKey,
+		},
+		err: `cannot accept charm "cs:precise/wordpress-1": charm signature verification failed: charm archive is not signed`,
+	}, {
+		attrs: coretesting.Attrs{
+			"charm-verification": "strict",
+			"charm-trusted-keys": charmtesting.SigningPublicKey,
+		},
+		signature: untrusted,
+		err:       `cannot accept charm "cs:precise/wordpress-1": charm signature verification failed: .*unknown entity`,
+	}} {
+		c.Logf("test %d", i)
+		cfg := coretesting.CustomEnvironConfig(c, test.attrs)
+		err := common.VerifyCharmArchive(cfg, `charm "cs:precise/wordpress-1"`, s.archivePath, test.signature)
+		if test.err == "" {
+			c.Check(err, gc.IsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.err)
+		}
+	}
+}
+
+func (s *charmVerifySuite) TestWarnLogs(c *gc.C) {
+	cfg := coretesting.CustomEnvironConfig(c, coretesting.Attrs{"charm-verification": "warn"})
+	err := common.VerifyCharmArchive(cfg, `charm "cs:precise/wordpress-1"`, s.archivePath, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(c.GetTestLog(), gc.Matches, `(?s).*WARNING juju.state.apiserver.common accepting charm "cs:precise/wordpress-1": no charm-trusted-keys in environment configuration.*`)
+}
@@ -54,6 +54,7 @@ var (
 	ErrStoppedWatcher = stderrors.New("watcher has been stopped")
 	ErrBadRequest     = stderrors.New("invalid request")
 	ErrTryAgain       = stderrors.New("try again")
+	ErrLoginLockedOut = stderrors.New("too many failed logins; try again later")
 )
 
 var singletonErrorCodes = map[error]string{
@@ -68,6 +69,7 @@ var singletonErrorCodes = map[error]string{
 	ErrUnknownWatcher:            params.CodeNotFound,
 	ErrStoppedWatcher:            params.CodeStopped,
 	ErrTryAgain:                  params.CodeTryAgain,
+	ErrLoginLockedOut:            params.CodeUnauthorized,
 }
 
 func singletonCode(err error) (string, bool) {
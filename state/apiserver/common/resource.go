@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/multiwatcher"
 )
 
 // Resource represents any resource that should be cleaned up when an
@@ -112,6 +115,20 @@ func (rs *Resources) Count() int {
 	return len(rs.resources)
 }
 
+// WatcherCount returns the number of watchers currently held.
+func (rs *Resources) WatcherCount() int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	count := 0
+	for _, r := range rs.resources {
+		switch r.(type) {
+		case state.Watcher, *multiwatcher.Watcher:
+			count++
+		}
+	}
+	return count
+}
+
 // StringResource is just a regular 'string' that matches the Resource
 // interface.
 type StringResource string
@@ -148,6 +148,20 @@ func (resourceSuite) TestStopAll(c *gc.C) {
 	c.Assert(rs.Count(), gc.Equals, 0)
 }
 
+func (resourceSuite) TestWatcherCount(c *gc.C) {
+	rs := common.NewResources()
+	c.Assert(rs.WatcherCount(), gc.Equals, 0)
+	rs.Register(&fakeResource{})
+	rs.RegisterNamed("foo", common.StringResource("bar"))
+	c.Assert(rs.WatcherCount(), gc.Equals, 0)
+	id := rs.Register(&fakeNotifyWatcher{})
+	rs.Register(&fakeNotifyWatcher{})
+	c.Assert(rs.WatcherCount(), gc.Equals, 2)
+	rs.Stop(id)
+	c.Assert(rs.WatcherCount(), gc.Equals, 1)
+	c.Assert(rs.Count(), gc.Equals, 3)
+}
+
 func (resourceSuite) TestStringResource(c *gc.C) {
 	rs := common.NewResources()
 	r1 := common.StringResource("foobar")
@@ -15,6 +15,11 @@ var (
 	NewPingTimeout        = newPingTimeout
 	MaxClientPingInterval = &maxClientPingInterval
 	MongoPingInterval     = &mongoPingInterval
+	TimeNow               = &timeNow
+	LoginFailureGrace     = &loginFailureGrace
+	LoginFailureDelay     = &loginFailureDelay
+	LoginFailureLockout   = &loginFailureLockout
+	LoginLockoutDuration  = &loginLockoutDuration
 )
 
 const LoginRateLimit = loginRateLimit
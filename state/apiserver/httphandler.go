@@ -9,7 +9,6 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/juju/core/names"
 	"github.com/juju/core/state"
 	"github.com/juju/core/state/api/params"
 	"github.com/juju/core/state/apiserver/common"
@@ -22,38 +21,56 @@ type errorSender interface {
 
 // httpHandler handles http requests through HTTPS in the API server.
 type httpHandler struct {
-	state *state.State
+	state   *state.State
+	metrics *apiMetrics
 }
 
 // authenticate parses HTTP basic authentication and authorizes the
 // request by looking up the provided tag and password against state.
+// Failed attempts are throttled just like failed API logins.
 func (h *httpHandler) authenticate(r *http.Request) error {
+	_, err := h.authenticateUser(r)
+	return err
+}
+
+// authenticateUser is like authenticate, but also
+// returns the user entity that made the request.
+func (h *httpHandler) authenticateUser(r *http.Request) (taggedAuthenticator, error) {
+	tag, password, err := parseBasicAuth(r)
+	if err != nil {
+		return nil, err
+	}
+	// Only allow users, not agents.
+	if !isUserTag(tag) {
+		return nil, common.ErrBadCreds
+	}
+	// Ensure the credentials are correct.
+	creds := params.Creds{
+		AuthTag:  tag,
+		Password: password,
+	}
+	return checkUserCreds(h.state, h.metrics, creds, remoteHost(r.RemoteAddr))
+}
+
+// parseBasicAuth returns the tag and password
+// held in the request's HTTP basic authentication.
+func parseBasicAuth(r *http.Request) (tag, password string, err error) {
 	parts := strings.Fields(r.Header.Get("Authorization"))
 	if len(parts) != 2 || parts[0] != "Basic" {
 		// Invalid header format or no header provided.
-		return fmt.Errorf("invalid request format")
+		return "", "", fmt.Errorf("invalid request format")
 	}
 	// Challenge is a base64-encoded "tag:pass" string.
 	// See RFC 2617, Section 2.
 	challenge, err := base64.StdEncoding.DecodeString(parts[1])
 	if err != nil {
-		return fmt.Errorf("invalid request format")
+		return "", "", fmt.Errorf("invalid request format")
 	}
 	tagPass := strings.SplitN(string(challenge), ":", 2)
 	if len(tagPass) != 2 {
-		return fmt.Errorf("invalid request format")
-	}
-	// Only allow users, not agents.
-	_, _, err = names.ParseTag(tagPass[0], names.UserTagKind)
-	if err != nil {
-		return common.ErrBadCreds
+		return "", "", fmt.Errorf("invalid request format")
 	}
-	// Ensure the credentials are correct.
-	_, err = checkCreds(h.state, params.Creds{
-		AuthTag:  tagPass[0],
-		Password: tagPass[1],
-	})
-	return err
+	return tagPass[0], tagPass[1], nil
 }
 
 // authError sends an unauthorized error.
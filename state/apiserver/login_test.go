@@ -188,6 +188,30 @@ func (s *loginSuite) TestLoginSetsLogIdentifier(c *gc.C) {
 	})
 }
 
+func (s *loginSuite) TestLoginReportsFacadeVersions(c *gc.C) {
+	info, cleanup := s.setupServer(c)
+	defer cleanup()
+	info.Tag = ""
+	info.Password = ""
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+
+	var result params.LoginResult
+	err = st.Call("Admin", "", "Login", &params.Creds{
+		AuthTag:  "user-admin",
+		Password: "dummy-secret",
+	}, &result)
+	c.Assert(err, gc.IsNil)
+	facades := make(map[string][]int)
+	for _, facade := range result.Facades {
+		facades[facade.Name] = facade.Versions
+	}
+	c.Assert(facades["Client"], gc.DeepEquals, []int{0, 1, 2})
+	c.Assert(facades["Machiner"], gc.DeepEquals, []int{0})
+	c.Assert(facades["ClientV1"], gc.IsNil)
+}
+
 func (s *loginSuite) TestLoginAddrs(c *gc.C) {
 	info, cleanup := s.setupMachineAndServer(c)
 	defer cleanup()
@@ -461,3 +485,199 @@ func (s *loginSuite) TestUsersAreNotRateLimited(c *gc.C) {
 		c.Check(err, gc.IsNil)
 	}
 }
+
+// attemptLogin opens an unauthenticated connection to the
+// server and attempts to log in with the given credentials.
+func attemptLogin(c *gc.C, info *api.Info, tag, password string) error {
+	info.Tag = ""
+	info.Password = ""
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+	return st.Login(tag, password, "")
+}
+
+func (s *loginSuite) patchLoginThrottle(c *gc.C) *time.Time {
+	now := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.PatchValue(apiserver.TimeNow, func() time.Time { return now })
+	s.PatchValue(apiserver.LoginFailureGrace, 2)
+	s.PatchValue(apiserver.LoginFailureDelay, time.Minute)
+	s.PatchValue(apiserver.LoginFailureLockout, 4)
+	s.PatchValue(apiserver.LoginLockoutDuration, time.Hour)
+	return &now
+}
+
+func (s *loginSuite) TestFailedUserLoginsDelayed(c *gc.C) {
+	now := s.patchLoginThrottle(c)
+	info, cleanup := s.setupServer(c)
+	defer cleanup()
+	_, err := s.State.AddUser("bob", "password")
+	c.Assert(err, gc.IsNil)
+
+	for i := 0; i < 3; i++ {
+		err := attemptLogin(c, info, "user-bob", "wrong password")
+		c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+	}
+	// Beyond the grace allowance, even the right
+	// password is refused until the delay has passed.
+	err = attemptLogin(c, info, "user-bob", "password")
+	c.Assert(err, gc.ErrorMatches, "try again")
+	c.Assert(params.IsCodeTryAgain(err), jc.IsTrue)
+
+	*now = now.Add(time.Minute)
+	err = attemptLogin(c, info, "user-bob", "password")
+	c.Assert(err, gc.IsNil)
+
+	// A successful login forgets the user's failures.
+	f, err := s.State.LoginFailures(state.LoginFailureUser, "user-bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(f.Count, gc.Equals, 0)
+}
+
+func (s *loginSuite) TestFailedUserLoginsLockedOut(c *gc.C) {
+	now := s.patchLoginThrottle(c)
+	info, cleanup := s.setupServer(c)
+	defer cleanup()
+	_, err := s.State.AddUser("bob", "password")
+	c.Assert(err, gc.IsNil)
+
+	for i := 0; i < 4; i++ {
+		err := attemptLogin(c, info, "user-bob", "wrong password")
+		c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+		*now = now.Add(10 * time.Minute)
+	}
+	err = attemptLogin(c, info, "user-bob", "password")
+	c.Assert(err, gc.ErrorMatches, "too many failed logins; try again later")
+	c.Assert(params.IsCodeUnauthorized(err), jc.IsTrue)
+
+	// Both the user and the address are locked out.
+	all, err := s.State.AllLoginFailures()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 2)
+	for _, f := range all {
+		c.Assert(f.Count, gc.Equals, 4)
+		c.Assert(f.Locked(*now), jc.IsTrue)
+	}
+
+	// The lockout expires.
+	*now = now.Add(time.Hour)
+	err = attemptLogin(c, info, "user-bob", "password")
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *loginSuite) TestFailedAdminLoginsCountedPerAddressOnly(c *gc.C) {
+	now := s.patchLoginThrottle(c)
+	info, cleanup := s.setupServer(c)
+	defer cleanup()
+
+	for i := 0; i < 4; i++ {
+		err := attemptLogin(c, info, "user-admin", "wrong password")
+		c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+		*now = now.Add(10 * time.Minute)
+	}
+	err := attemptLogin(c, info, "user-admin", "dummy-secret")
+	c.Assert(err, gc.ErrorMatches, "too many failed logins; try again later")
+
+	// Only the address is locked out, so guessing the admin
+	// password cannot lock the admin out everywhere.
+	all, err := s.State.AllLoginFailures()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 1)
+	c.Assert(all[0].Kind, gc.Equals, state.LoginFailureAddress)
+	c.Assert(all[0].Count, gc.Equals, 4)
+	f, err := s.State.LoginFailures(state.LoginFailureUser, "user-admin")
+	c.Assert(err, gc.IsNil)
+	c.Assert(f.Count, gc.Equals, 0)
+
+	// Logging in from an address that has not failed succeeds.
+	err = s.State.ClearLoginFailures(state.LoginFailureAddress, all[0].Name)
+	c.Assert(err, gc.IsNil)
+	err = attemptLogin(c, info, "user-admin", "dummy-secret")
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *loginSuite) TestFailedLoginsCountedPerAddress(c *gc.C) {
+	now := s.patchLoginThrottle(c)
+	info, cleanup := s.setupServer(c)
+	defer cleanup()
+	_, err := s.State.AddUser("bob", "password")
+	c.Assert(err, gc.IsNil)
+
+	// Guessing the passwords of different users from the
+	// same address is throttled too.
+	for _, tag := range []string{"user-admin", "user-bob", "user-foo"} {
+		err := attemptLogin(c, info, tag, "wrong password")
+		c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+	}
+	err = attemptLogin(c, info, "user-bob", "password")
+	c.Assert(err, gc.ErrorMatches, "try again")
+
+	// A successful login does not reset the address's failures,
+	// so the next failure locks the address out.
+	*now = now.Add(time.Minute)
+	err = attemptLogin(c, info, "user-bob", "password")
+	c.Assert(err, gc.IsNil)
+	err = attemptLogin(c, info, "user-admin", "wrong password")
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+	err = attemptLogin(c, info, "user-bob", "password")
+	c.Assert(err, gc.ErrorMatches, "too many failed logins; try again later")
+}
+
+func (s *loginSuite) TestConcurrentUserLoginsThrottled(c *gc.C) {
+	now := s.patchLoginThrottle(c)
+	info, cleanup := s.setupServer(c)
+	defer cleanup()
+	_, err := s.State.AddUser("bob", "password")
+	c.Assert(err, gc.IsNil)
+
+	// Attempts still being checked count against the grace
+	// allowance, so that guesses made concurrently can't all
+	// go ahead before any of their failures are recorded.
+	for i := 0; i < 3; i++ {
+		_, err := s.State.ReserveLoginAttempt(state.LoginFailureUser, "user-bob", *now)
+		c.Assert(err, gc.IsNil)
+	}
+	err = attemptLogin(c, info, "user-bob", "password")
+	c.Assert(err, gc.ErrorMatches, "try again")
+	f, err := s.State.LoginFailures(state.LoginFailureUser, "user-bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(f.Pending, gc.Equals, 3)
+
+	err = s.State.ReleaseLoginAttempts(state.LoginFailureUser, "user-bob", 1)
+	c.Assert(err, gc.IsNil)
+	err = attemptLogin(c, info, "user-bob", "password")
+	c.Assert(err, gc.IsNil)
+
+	// Attempts abandoned by a server that stopped
+	// while checking them are eventually forgotten.
+	for i := 0; i < 3; i++ {
+		_, err := s.State.ReserveLoginAttempt(state.LoginFailureUser, "user-bob", *now)
+		c.Assert(err, gc.IsNil)
+	}
+	*now = now.Add(2 * time.Minute)
+	err = attemptLogin(c, info, "user-bob", "password")
+	c.Assert(err, gc.IsNil)
+	f, err = s.State.LoginFailures(state.LoginFailureUser, "user-bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(f.Pending, gc.Equals, 0)
+}
+
+func (s *loginSuite) TestFailedAgentLoginsNotThrottled(c *gc.C) {
+	s.patchLoginThrottle(c)
+	info, cleanup := s.setupMachineAndServer(c)
+	defer cleanup()
+	password := info.Password
+
+	for i := 0; i < 5; i++ {
+		info.Password = "wrong password"
+		_, err := api.Open(info, fastDialOpts)
+		c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+	}
+	info.Password = password
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+	st.Close()
+	all, err := s.State.AllLoginFailures()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 0)
+}
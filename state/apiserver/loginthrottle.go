@@ -0,0 +1,273 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"net"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/core/audit"
+	"github.com/juju/core/names"
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/state/apiserver/common"
+)
+
+// The login throttling policy. Failed user logins are counted
+// against both the user and the remote address they come from,
+// except for the admin user, whose failures are only counted
+// against the address so that nobody can lock out the admin.
+// Once the grace allowance is used up, each further attempt must
+// wait for a delay that doubles with every failure, and after
+// enough failures logins are locked out altogether for a while.
+var (
+	// loginFailureGrace is the number of failed logins
+	// allowed before attempts are delayed.
+	loginFailureGrace = 3
+
+	// loginFailureDelay is the delay imposed after the
+	// first failed login beyond the grace allowance.
+	loginFailureDelay = 1 * time.Second
+
+	// loginFailureMaxDelay caps the delay between attempts.
+	loginFailureMaxDelay = 30 * time.Second
+
+	// loginFailureLockout is the number of failed logins
+	// after which logins are locked out.
+	loginFailureLockout = 10
+
+	// loginLockoutDuration is how long a lockout lasts.
+	loginLockoutDuration = 15 * time.Minute
+
+	// loginFailureWindow is how long failed logins are
+	// remembered after the last one.
+	loginFailureWindow = 1 * time.Hour
+
+	// loginAttemptTimeout is how long a login attempt may be in
+	// progress. Attempts counted as in progress for longer were
+	// abandoned, by a server that stopped while checking them.
+	loginAttemptTimeout = 1 * time.Minute
+)
+
+// timeNow is replaced by tests.
+var timeNow = time.Now
+
+// loginThrottleKey identifies something failed
+// logins are counted against.
+type loginThrottleKey struct {
+	kind state.LoginFailureKind
+	name string
+}
+
+// adminTag is the tag of the admin user.
+var adminTag = names.UserTag(state.AdminUser)
+
+// loginThrottleKeys returns what failed logins by the user with the
+// given tag from the given remote address are counted against. The
+// tag is empty for credentials that do not belong to a user.
+func loginThrottleKeys(tag, remoteAddr string) []loginThrottleKey {
+	var keys []loginThrottleKey
+	if tag != "" && tag != adminTag {
+		keys = append(keys, loginThrottleKey{state.LoginFailureUser, tag})
+	}
+	if remoteAddr != "" {
+		keys = append(keys, loginThrottleKey{state.LoginFailureAddress, remoteAddr})
+	}
+	return keys
+}
+
+// isStale returns whether the given failures should be forgotten
+// rather than counted towards further delays and lockouts.
+func isStale(f state.LoginFailures, now time.Time) bool {
+	if f.Count == 0 {
+		return false
+	}
+	if !f.LockedUntil.IsZero() && !f.Locked(now) {
+		// The lockout has expired, so start afresh.
+		return true
+	}
+	return now.Sub(f.LastFailure) > loginFailureWindow
+}
+
+// nextLoginAttempt returns the earliest time at which
+// a login may be attempted after the given failures.
+func nextLoginAttempt(f state.LoginFailures) time.Time {
+	if f.Count <= loginFailureGrace {
+		return time.Time{}
+	}
+	delay := loginFailureMaxDelay
+	if shift := uint(f.Count - loginFailureGrace - 1); shift < 32 {
+		if d := loginFailureDelay << shift; d > 0 && d < delay {
+			delay = d
+		}
+	}
+	return f.LastFailure.Add(delay)
+}
+
+// checkUserCreds checks the credentials presented by a user from the
+// given remote address, whether logging in to the API or
+// authenticating an HTTP request. Repeated failures are delayed and
+// eventually locked out to defeat password guessing.
+func checkUserCreds(st *state.State, metrics *apiMetrics, c params.Creds, remoteAddr string) (taggedAuthenticator, error) {
+	attempt, err := reserveLoginAttempt(st, c.AuthTag, remoteAddr)
+	defer attempt.release()
+	if err != nil {
+		metrics.loginThrottled()
+		logger.Debugf("throttling login for %q from %q: %v", c.AuthTag, remoteAddr, err)
+		return nil, err
+	}
+	entity, err := doCheckCreds(st, c)
+	if err == common.ErrBadCreds {
+		metrics.loginFailed()
+		if err := recordLoginFailure(st, c.AuthTag, remoteAddr); err != nil {
+			logger.Errorf("cannot record failed login for %q: %v", c.AuthTag, err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if attempt.userFailed {
+		if err := clearUserLoginFailures(st, c.AuthTag); err != nil {
+			logger.Errorf("cannot clear failed logins for %q: %v", c.AuthTag, err)
+		}
+	}
+	return entity, nil
+}
+
+// loginAttempt holds a login attempt reserved by reserveLoginAttempt.
+type loginAttempt struct {
+	st *state.State
+
+	// reserved holds what the attempt is counted against.
+	reserved []loginThrottleKey
+
+	// userFailed holds whether failed logins were
+	// recorded against the user when the attempt began.
+	userFailed bool
+}
+
+// reserveLoginAttempt counts a login attempt by the user with the
+// given tag from the given remote address as in progress, and returns
+// an error if logins for either are currently delayed or locked out.
+// The attempt is reserved before the credentials are checked, so that
+// concurrent attempts can't all go ahead on the strength of the same
+// record: once the grace allowance is used up by failures and other
+// attempts in progress, attempts must wait for those to finish. The
+// returned attempt must be released once its credentials have been
+// checked and any failure recorded, even if an error is returned.
+func reserveLoginAttempt(st *state.State, tag, remoteAddr string) (*loginAttempt, error) {
+	now := timeNow()
+	attempt := &loginAttempt{st: st}
+	for _, key := range loginThrottleKeys(tag, remoteAddr) {
+		f, err := st.ReserveLoginAttempt(key.kind, key.name, now)
+		if err != nil {
+			return attempt, err
+		}
+		attempt.reserved = append(attempt.reserved, key)
+		if key.kind == state.LoginFailureUser && f.Count > 0 {
+			attempt.userFailed = true
+		}
+		others := f.Pending
+		if others > 0 && now.Sub(f.LastAttempt) > loginAttemptTimeout {
+			logger.Warningf("forgetting %d abandoned login attempts for %s %q", others, key.kind, key.name)
+			if err := st.ReleaseLoginAttempts(key.kind, key.name, others); err != nil && !errors.IsNotFound(err) {
+				return attempt, err
+			}
+			others = 0
+		}
+		if isStale(f, now) {
+			f.Count = 0
+		}
+		if f.Locked(now) && f.Count > 0 {
+			return attempt, common.ErrLoginLockedOut
+		}
+		if others > 0 && f.Count+others > loginFailureGrace {
+			return attempt, common.ErrTryAgain
+		}
+		if now.Before(nextLoginAttempt(f)) {
+			return attempt, common.ErrTryAgain
+		}
+	}
+	return attempt, nil
+}
+
+// release counts the attempt as no longer in progress.
+func (a *loginAttempt) release() {
+	for _, key := range a.reserved {
+		err := a.st.ReleaseLoginAttempts(key.kind, key.name, 1)
+		if err != nil && !errors.IsNotFound(err) {
+			logger.Errorf("cannot release login attempt for %s %q: %v", key.kind, key.name, err)
+		}
+	}
+}
+
+// recordLoginFailure records a failed login for the user with the
+// given tag from the given remote address, locking out further logins
+// if there have been too many failures.
+func recordLoginFailure(st *state.State, tag, remoteAddr string) error {
+	now := timeNow()
+	for _, key := range loginThrottleKeys(tag, remoteAddr) {
+		f, err := st.LoginFailures(key.kind, key.name)
+		if err != nil {
+			return err
+		}
+		if isStale(f, now) {
+			if err := st.ClearLoginFailures(key.kind, key.name); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		f, err = st.RecordLoginFailure(key.kind, key.name, now)
+		if err != nil {
+			return err
+		}
+		if f.Count < loginFailureLockout || f.Locked(now) {
+			continue
+		}
+		until := now.Add(loginLockoutDuration)
+		if err := st.LockLogins(key.kind, key.name, until); err != nil {
+			return err
+		}
+		// Failures not made with a user's credentials
+		// are only counted against the address.
+		who := auditTag(tag)
+		if tag == "" {
+			who = auditTag(remoteAddr)
+		}
+		audit.Audit(who, "logins for %s %q locked out until %v after %d failed attempts",
+			key.kind, key.name, until.UTC().Format(time.RFC3339), f.Count)
+	}
+	return nil
+}
+
+// clearUserLoginFailures forgets the failed logins recorded against
+// the user with the given tag after it has logged in successfully.
+// It is only called when checkLoginThrottle has found some.
+// Failures recorded against the remote address are kept, so that
+// a successful login cannot be used to reset them.
+func clearUserLoginFailures(st *state.State, tag string) error {
+	err := st.ClearLoginFailures(state.LoginFailureUser, tag)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// auditTag implements audit.Tagger for a tag that
+// may not correspond to any existing entity.
+type auditTag string
+
+func (t auditTag) Tag() string {
+	return string(t)
+}
+
+// remoteHost returns the host part of the given remote
+// address, which failed logins are counted against.
+func remoteHost(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
@@ -0,0 +1,393 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"labix.org/v2/mgo"
+
+	"github.com/juju/core/rpc"
+	"github.com/juju/core/rpc/rpcreflect"
+	"github.com/juju/core/state"
+)
+
+// metricsUser is the user name that must be presented,
+// along with the environment's metrics-password, to
+// read the API server metrics.
+const metricsUser = "metrics"
+
+// requestLatencyBuckets holds the upper bounds, in seconds, of
+// the buckets of the API request latency histogram.
+var requestLatencyBuckets = []float64{
+	0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+// requestKey identifies the API method a request was made to.
+type requestKey struct {
+	facade string
+	method string
+}
+
+// unknownRequest is the key under which requests to facades or
+// methods that don't exist are recorded, so that clients can't
+// make the metrics grow without bound by making up names.
+var unknownRequest = requestKey{"unknown", "unknown"}
+
+// rootTypes holds the types of the API roots that serve requests
+// before and after login.
+var rootTypes = []*rpcreflect.Type{
+	rpcreflect.TypeOf(reflect.TypeOf((*initialRoot)(nil))),
+	rpcreflect.TypeOf(reflect.TypeOf((*srvRoot)(nil))),
+}
+
+// metricsKey returns the key under which the request is recorded:
+// its facade and method when an API root serves them, and
+// unknownRequest when the request could not be bound to a method.
+func metricsKey(req rpc.Request) requestKey {
+	for _, rootType := range rootTypes {
+		m, err := rootType.Method(rpcreflect.RootMethodName(req.Type, req.Version))
+		if err != nil {
+			continue
+		}
+		if _, err := m.ObjType.Method(req.Action); err == nil {
+			return requestKey{req.Type, req.Action}
+		}
+	}
+	return unknownRequest
+}
+
+// requestStats holds the statistics gathered
+// for requests to a single API method.
+type requestStats struct {
+	count   int64
+	errors  int64
+	total   time.Duration
+	buckets []int64
+}
+
+// apiMetrics gathers the operational metrics of an API server.
+type apiMetrics struct {
+	mu            sync.Mutex
+	requests      map[requestKey]*requestStats
+	connections   int64
+	loginFailures int64
+	loginThrottle int64
+	roots         map[*srvRoot]bool
+}
+
+func newAPIMetrics() *apiMetrics {
+	return &apiMetrics{
+		requests: make(map[requestKey]*requestStats),
+		roots:    make(map[*srvRoot]bool),
+	}
+}
+
+// requestDone records the completion of a request
+// that took the given time.
+func (m *apiMetrics) requestDone(req requestKey, failed bool, timeSpent time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.requests[req]
+	if stats == nil {
+		stats = &requestStats{
+			buckets: make([]int64, len(requestLatencyBuckets)),
+		}
+		m.requests[req] = stats
+	}
+	stats.count++
+	if failed {
+		stats.errors++
+	}
+	stats.total += timeSpent
+	for i, bound := range requestLatencyBuckets {
+		if timeSpent.Seconds() <= bound {
+			stats.buckets[i]++
+		}
+	}
+}
+
+// connectionOpened and connectionClosed track
+// the number of active API connections.
+func (m *apiMetrics) connectionOpened() {
+	m.mu.Lock()
+	m.connections++
+	m.mu.Unlock()
+}
+
+func (m *apiMetrics) connectionClosed() {
+	m.mu.Lock()
+	m.connections--
+	m.mu.Unlock()
+}
+
+// loginFailed records a login that failed because
+// of bad credentials.
+func (m *apiMetrics) loginFailed() {
+	m.mu.Lock()
+	m.loginFailures++
+	m.mu.Unlock()
+}
+
+// loginThrottled records a login that was refused
+// because of previous failures.
+func (m *apiMetrics) loginThrottled() {
+	m.mu.Lock()
+	m.loginThrottle++
+	m.mu.Unlock()
+}
+
+// addRoot and removeRoot track the logged in connections,
+// so that the watchers they hold can be counted.
+func (m *apiMetrics) addRoot(r *srvRoot) {
+	m.mu.Lock()
+	m.roots[r] = true
+	m.mu.Unlock()
+}
+
+func (m *apiMetrics) removeRoot(r *srvRoot) {
+	m.mu.Lock()
+	delete(m.roots, r)
+	m.mu.Unlock()
+}
+
+// metricsWriter writes metrics in the Prometheus text format.
+type metricsWriter struct {
+	w io.Writer
+}
+
+// header writes the help and type lines that precede
+// the samples of a metric.
+func (mw metricsWriter) header(name, kind, help string) {
+	fmt.Fprintf(mw.w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(mw.w, "# TYPE %s %s\n", name, kind)
+}
+
+// sample writes a single sample of a metric. The labels
+// are given as alternating names and values.
+func (mw metricsWriter) sample(name string, value float64, labels ...string) {
+	fmt.Fprint(mw.w, name)
+	if len(labels) > 0 {
+		pairs := make([]string, 0, len(labels)/2)
+		for i := 0; i+1 < len(labels); i += 2 {
+			pairs = append(pairs, fmt.Sprintf("%s=%q", labels[i], labels[i+1]))
+		}
+		fmt.Fprintf(mw.w, "{%s}", strings.Join(pairs, ","))
+	}
+	fmt.Fprintf(mw.w, " %g\n", value)
+}
+
+// metric writes a metric with a single unlabelled sample.
+func (mw metricsWriter) metric(name, kind, help string, value float64) {
+	mw.header(name, kind, help)
+	mw.sample(name, value)
+}
+
+// requestKeys returns the keys of the given requests in a stable order.
+func requestKeys(requests map[requestKey]*requestStats) []requestKey {
+	keys := make([]requestKey, 0, len(requests))
+	for key := range requests {
+		keys = append(keys, key)
+	}
+	sort.Sort(requestKeySlice(keys))
+	return keys
+}
+
+type requestKeySlice []requestKey
+
+func (s requestKeySlice) Len() int      { return len(s) }
+func (s requestKeySlice) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s requestKeySlice) Less(i, j int) bool {
+	if s[i].facade != s[j].facade {
+		return s[i].facade < s[j].facade
+	}
+	return s[i].method < s[j].method
+}
+
+// write writes all the metrics gathered by m, along with
+// those taken from the given state, to w.
+func (m *apiMetrics) write(w io.Writer, st *state.State) error {
+	// Query the state before taking the lock, so that
+	// recording metrics is never blocked on the database.
+	locked, err := lockedOutLogins(st)
+	if err != nil {
+		return err
+	}
+	watcherStats := st.WatcherStats()
+	mgoStats := mgo.GetStats()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mw := metricsWriter{w}
+
+	keys := requestKeys(m.requests)
+	mw.header("juju_api_requests_total", "counter", "Number of API requests served.")
+	for _, key := range keys {
+		mw.sample("juju_api_requests_total", float64(m.requests[key].count), "facade", key.facade, "method", key.method)
+	}
+	mw.header("juju_api_request_errors_total", "counter", "Number of API requests that returned an error.")
+	for _, key := range keys {
+		mw.sample("juju_api_request_errors_total", float64(m.requests[key].errors), "facade", key.facade, "method", key.method)
+	}
+	mw.header("juju_api_request_duration_seconds", "histogram", "Time taken to serve API requests.")
+	for _, key := range keys {
+		stats := m.requests[key]
+		for i, bound := range requestLatencyBuckets {
+			mw.sample("juju_api_request_duration_seconds_bucket", float64(stats.buckets[i]),
+				"facade", key.facade, "method", key.method, "le", fmt.Sprint(bound))
+		}
+		mw.sample("juju_api_request_duration_seconds_bucket", float64(stats.count),
+			"facade", key.facade, "method", key.method, "le", "+Inf")
+		mw.sample("juju_api_request_duration_seconds_sum", stats.total.Seconds(),
+			"facade", key.facade, "method", key.method)
+		mw.sample("juju_api_request_duration_seconds_count", float64(stats.count),
+			"facade", key.facade, "method", key.method)
+	}
+
+	mw.metric("juju_api_connections", "gauge",
+		"Number of active API connections.", float64(m.connections))
+	apiWatchers := 0
+	for r := range m.roots {
+		apiWatchers += r.resources.WatcherCount()
+	}
+	mw.metric("juju_api_watchers", "gauge",
+		"Number of watchers held by API connections.", float64(apiWatchers))
+
+	mw.metric("juju_state_watches", "gauge",
+		"Number of watches registered with the txn watcher.", float64(watcherStats.Watches))
+	mw.metric("juju_state_watcher_syncs_total", "counter",
+		"Number of times the txn watcher has synchronized.", float64(watcherStats.Syncs))
+	mw.metric("juju_state_watcher_sync_seconds_total", "counter",
+		"Total time the txn watcher has spent synchronizing.", watcherStats.SyncTime.Seconds())
+	mw.metric("juju_state_watcher_last_sync_seconds", "gauge",
+		"Time taken by the most recent txn watcher sync.", watcherStats.LastSyncTime.Seconds())
+
+	mw.metric("juju_mongo_sockets_alive", "gauge",
+		"Number of mongo sockets open.", float64(mgoStats.SocketsAlive))
+	mw.metric("juju_mongo_sockets_in_use", "gauge",
+		"Number of mongo sockets in use by sessions.", float64(mgoStats.SocketsInUse))
+	mw.metric("juju_mongo_socket_refs", "gauge",
+		"Number of references held to mongo sockets.", float64(mgoStats.SocketRefs))
+	mw.metric("juju_mongo_sent_ops_total", "counter",
+		"Number of operations sent to mongo.", float64(mgoStats.SentOps))
+	mw.metric("juju_mongo_received_ops_total", "counter",
+		"Number of replies received from mongo.", float64(mgoStats.ReceivedOps))
+
+	mw.metric("juju_api_login_failures_total", "counter",
+		"Number of logins that failed because of bad credentials.", float64(m.loginFailures))
+	mw.metric("juju_api_logins_throttled_total", "counter",
+		"Number of logins refused because of previous failures.", float64(m.loginThrottle))
+	mw.header("juju_api_logins_locked_out", "gauge",
+		"Number of users and addresses currently locked out.")
+	for _, kind := range []state.LoginFailureKind{state.LoginFailureUser, state.LoginFailureAddress} {
+		mw.sample("juju_api_logins_locked_out", float64(locked[kind]), "kind", string(kind))
+	}
+	return nil
+}
+
+// lockedOutLogins returns the number of users and
+// addresses whose logins are currently locked out.
+func lockedOutLogins(st *state.State) (map[state.LoginFailureKind]int, error) {
+	all, err := st.AllLoginFailures()
+	if err != nil {
+		return nil, err
+	}
+	now := timeNow()
+	locked := make(map[state.LoginFailureKind]int)
+	for _, f := range all {
+		if f.Locked(now) {
+			locked[f.Kind]++
+		}
+	}
+	return locked, nil
+}
+
+// metricsHandler serves the API server metrics in the
+// Prometheus text format through HTTPS.
+type metricsHandler struct {
+	httpHandler
+}
+
+func (h *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.authenticateMetrics(r); err != nil {
+		h.authError(w, h)
+		return
+	}
+	if r.Method != "GET" {
+		h.sendError(w, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method: %q", r.Method))
+		return
+	}
+	var buf bytes.Buffer
+	if err := h.metrics.write(&buf, h.state); err != nil {
+		logger.Errorf("cannot gather metrics: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "cannot gather metrics")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// authenticateMetrics checks that the request carries the metrics
+// credentials. Metrics cannot be read unless the environment's
+// metrics-password is set. The metrics credentials do not belong to
+// a user, so failed attempts are only throttled by remote address.
+func (h *metricsHandler) authenticateMetrics(r *http.Request) error {
+	remoteAddr := remoteHost(r.RemoteAddr)
+	attempt, err := reserveLoginAttempt(h.state, "", remoteAddr)
+	defer attempt.release()
+	if err != nil {
+		h.metrics.loginThrottled()
+		logger.Debugf("throttling metrics login from %q: %v", remoteAddr, err)
+		return err
+	}
+	err = h.checkMetricsCreds(r)
+	if err == errMetricsCreds {
+		h.metrics.loginFailed()
+		if err := recordLoginFailure(h.state, "", remoteAddr); err != nil {
+			logger.Errorf("cannot record failed metrics login from %q: %v", remoteAddr, err)
+		}
+	}
+	return err
+}
+
+// checkMetricsCreds checks the metrics credentials
+// held in the request's HTTP basic authentication.
+func (h *metricsHandler) checkMetricsCreds(r *http.Request) error {
+	user, password, err := parseBasicAuth(r)
+	if err != nil {
+		return err
+	}
+	cfg, err := h.state.EnvironConfig()
+	if err != nil {
+		return err
+	}
+	want, ok := cfg.MetricsPassword()
+	if !ok || user != metricsUser {
+		return errMetricsCreds
+	}
+	if subtle.ConstantTimeCompare([]byte(password), []byte(want)) != 1 {
+		return errMetricsCreds
+	}
+	return nil
+}
+
+var errMetricsCreds = fmt.Errorf("invalid metrics credentials")
+
+// sendError sends a plain text error response.
+func (h *metricsHandler) sendError(w http.ResponseWriter, statusCode int, message string) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(statusCode)
+	_, err := fmt.Fprintln(w, message)
+	return err
+}
@@ -0,0 +1,115 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"net/http"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/state"
+)
+
+type metricsSuite struct {
+	authHttpSuite
+}
+
+var _ = gc.Suite(&metricsSuite{})
+
+func (s *metricsSuite) metricsURI(c *gc.C) string {
+	_, info, err := s.APIConn.Environ.StateInfo()
+	c.Assert(err, gc.IsNil)
+	return "https://" + info.Addrs[0] + "/metrics"
+}
+
+func (s *metricsSuite) setMetricsPassword(c *gc.C, password string) {
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"metrics-password": password,
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *metricsSuite) assertUnauthorized(c *gc.C, user, password string) {
+	resp, err := s.sendRequest(c, user, password, "GET", s.metricsURI(c), "", nil)
+	c.Assert(err, gc.IsNil)
+	body := assertResponse(c, resp, http.StatusUnauthorized, "text/plain")
+	c.Assert(string(body), gc.Equals, "unauthorized\n")
+}
+
+func (s *metricsSuite) TestRequiresAuth(c *gc.C) {
+	s.setMetricsPassword(c, "s3cret")
+	s.assertUnauthorized(c, "", "")
+	s.assertUnauthorized(c, "metrics", "wrong")
+	s.assertUnauthorized(c, "someone", "s3cret")
+	// User credentials are not enough to read the metrics.
+	s.assertUnauthorized(c, s.userTag, s.password)
+}
+
+func (s *metricsSuite) TestFailedAuthCountedPerAddress(c *gc.C) {
+	s.setMetricsPassword(c, "s3cret")
+	s.assertUnauthorized(c, "metrics", "wrong")
+
+	// The metrics credentials belong to no user, so
+	// the failure is only counted against the address.
+	all, err := s.State.AllLoginFailures()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 1)
+	c.Assert(all[0].Kind, gc.Equals, state.LoginFailureAddress)
+	c.Assert(all[0].Count, gc.Equals, 1)
+}
+
+func (s *metricsSuite) TestDisabledWithoutPassword(c *gc.C) {
+	s.assertUnauthorized(c, "metrics", "")
+	s.assertUnauthorized(c, "metrics", "anything")
+}
+
+func (s *metricsSuite) TestRequiresGET(c *gc.C) {
+	s.setMetricsPassword(c, "s3cret")
+	resp, err := s.sendRequest(c, "metrics", "s3cret", "POST", s.metricsURI(c), "", nil)
+	c.Assert(err, gc.IsNil)
+	body := assertResponse(c, resp, http.StatusMethodNotAllowed, "text/plain")
+	c.Assert(string(body), gc.Equals, `unsupported method: "POST"`+"\n")
+}
+
+func (s *metricsSuite) TestMetrics(c *gc.C) {
+	s.setMetricsPassword(c, "s3cret")
+	_, err := s.APIState.Client().Status(nil)
+	c.Assert(err, gc.IsNil)
+	watcher, err := s.APIState.Client().WatchAll()
+	c.Assert(err, gc.IsNil)
+	defer watcher.Stop()
+	err = attemptLogin(c, s.APIInfo(c), "user-admin", "wrong password")
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+	// Requests to facades and methods that don't exist
+	// are all recorded under the same label.
+	err = s.APIState.Call("Client", "", "NoSuchMethod", nil, nil)
+	c.Assert(err, gc.NotNil)
+	err = s.APIState.Call("NoSuchFacade", "", "Anything", nil, nil)
+	c.Assert(err, gc.NotNil)
+
+	resp, err := s.sendRequest(c, "metrics", "s3cret", "GET", s.metricsURI(c), "", nil)
+	c.Assert(err, gc.IsNil)
+	body := string(assertResponse(c, resp, http.StatusOK, "text/plain; version=0.0.4"))
+	for _, expect := range []string{
+		`(?m)^# TYPE juju_api_requests_total counter$`,
+		`(?m)^juju_api_requests_total\{facade="Client",method="FullStatus"\} 1$`,
+		`(?m)^juju_api_request_errors_total\{facade="Client",method="FullStatus"\} 0$`,
+		`(?m)^juju_api_request_duration_seconds_bucket\{facade="Client",method="FullStatus",le="\+Inf"\} 1$`,
+		`(?m)^juju_api_request_duration_seconds_count\{facade="Client",method="FullStatus"\} 1$`,
+		`(?m)^juju_api_requests_total\{facade="unknown",method="unknown"\} 2$`,
+		`(?m)^juju_api_request_errors_total\{facade="unknown",method="unknown"\} 2$`,
+		`(?m)^juju_api_connections [1-9]\d*$`,
+		`(?m)^juju_api_watchers 1$`,
+		`(?m)^juju_state_watches \d+$`,
+		`(?m)^juju_state_watcher_syncs_total [1-9]\d*$`,
+		`(?m)^juju_mongo_sockets_alive -?\d+$`,
+		`(?m)^juju_api_login_failures_total 1$`,
+		`(?m)^juju_api_logins_throttled_total 0$`,
+		`(?m)^juju_api_logins_locked_out\{kind="user"\} 0$`,
+		`(?m)^juju_api_logins_locked_out\{kind="address"\} 0$`,
+	} {
+		c.Check(body, gc.Matches, `(?s).*`+expect+`.*`)
+	}
+	c.Check(body, gc.Not(gc.Matches), `(?s).*NoSuch.*`)
+}
@@ -209,6 +209,10 @@ func (p *ProvisionerAPI) ContainerManagerConfig(args params.ContainerManagerConf
 		if useLxcCloneAufs, ok := config.LXCUseCloneAUFS(); ok {
 			cfg["use-aufs"] = fmt.Sprint(useLxcCloneAufs)
 		}
+	case instance.KVM:
+		if useKvmClone, ok := config.KVMUseClone(); ok {
+			cfg["use-clone"] = fmt.Sprint(useKvmClone)
+		}
 	}
 	result.ManagerConfig = cfg
 	return result, nil
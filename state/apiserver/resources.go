@@ -0,0 +1,152 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/core/environs"
+	"github.com/juju/core/names"
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/utils"
+)
+
+// resourcesHandler handles the upload of service resources
+// through HTTPS in the API server.
+type resourcesHandler struct {
+	httpHandler
+}
+
+func (h *resourcesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	entity, err := h.authenticateUser(r)
+	if err != nil {
+		h.authError(w, h)
+		return
+	}
+	switch r.Method {
+	case "POST":
+		// Attach new content to a resource of a service. Requires
+		// "service" and "name" queries specifying the service and
+		// the name of the resource.
+		_, user, err := names.ParseTag(entity.Tag(), names.UserTagKind)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		res, err := h.processPost(r, user)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.sendJSON(w, http.StatusOK, &params.ResourcesResponse{Resource: res})
+	default:
+		h.sendError(w, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method: %q", r.Method))
+	}
+}
+
+// sendJSON sends a JSON-encoded response to the client.
+func (h *resourcesHandler) sendJSON(w http.ResponseWriter, statusCode int, response *params.ResourcesResponse) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	body, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	w.Write(body)
+	return nil
+}
+
+// sendError sends a JSON-encoded error response.
+func (h *resourcesHandler) sendError(w http.ResponseWriter, statusCode int, message string) error {
+	return h.sendJSON(w, statusCode, &params.ResourcesResponse{Error: message})
+}
+
+// processPost handles a resource upload POST request made by the
+// named user after authentication. The content is uploaded to provider
+// storage, and then attached to the service as a new revision of the
+// resource.
+func (h *resourcesHandler) processPost(r *http.Request, user string) (*params.ServiceResource, error) {
+	query := r.URL.Query()
+	serviceName := query.Get("service")
+	if serviceName == "" {
+		return nil, fmt.Errorf("expected service=name argument")
+	}
+	name := query.Get("name")
+	if name == "" {
+		return nil, fmt.Errorf("expected name=resource argument")
+	}
+	service, err := h.state.Service(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	ch, _, err := service.Charm()
+	if err != nil {
+		return nil, err
+	}
+	declared, ok := ch.Meta().Resources[name]
+	if !ok {
+		return nil, fmt.Errorf("charm %q declares no resource %q", ch.URL(), name)
+	}
+
+	tempFile, err := ioutil.TempFile("", "resource")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create temp file: %v", err)
+	}
+	defer tempFile.Close()
+	defer os.Remove(tempFile.Name())
+	sha256, size, err := utils.ReadSHA256(io.TeeReader(r.Body, tempFile))
+	if err != nil {
+		return nil, fmt.Errorf("error processing file upload: %v", err)
+	}
+	if _, err := tempFile.Seek(0, 0); err != nil {
+		return nil, errors.Annotate(err, "cannot rewind the resource file reader")
+	}
+
+	// The content is stored under its hash, so uploads of
+	// different content never overwrite each other.
+	storage, err := environs.GetStorage(h.state)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot access provider storage")
+	}
+	storagePath := state.ResourceStoragePath(serviceName, name, sha256)
+	if err := storage.Put(storagePath, tempFile, size); err != nil {
+		return nil, errors.Annotate(err, "cannot upload resource to provider storage")
+	}
+	storageURL, err := storage.URL(storagePath)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot get storage URL for resource")
+	}
+	res, err := service.AttachResource(name, storageURL, sha256, size, user)
+	if err != nil {
+		// Don't leave content nothing refers to behind,
+		// unless it was attached by a concurrent upload.
+		if service.Refresh() == nil {
+			if current, _ := service.Resource(name); current.SHA256 == sha256 {
+				return nil, err
+			}
+		}
+		if err := storage.Remove(storagePath); err != nil {
+			logger.Warningf("cannot remove unattached resource content %q: %v", storagePath, err)
+		}
+		return nil, err
+	}
+	return &params.ServiceResource{
+		Name:        name,
+		Filename:    declared.Filename,
+		Description: declared.Description,
+		Revision:    res.Revision,
+		SHA256:      res.SHA256,
+		Size:        res.Size,
+		User:        res.User,
+		Time:        res.Time,
+	}, nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/environs"
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/utils"
+)
+
+type resourcesSuite struct {
+	authHttpSuite
+}
+
+var _ = gc.Suite(&resourcesSuite{})
+
+func (s *resourcesSuite) SetUpTest(c *gc.C) {
+	s.authHttpSuite.SetUpTest(c)
+	s.AddTestingService(c, "resources", s.AddTestingCharm(c, "resources"))
+}
+
+func (s *resourcesSuite) resourcesURI(c *gc.C, query string) string {
+	_, info, err := s.APIConn.Environ.StateInfo()
+	c.Assert(err, gc.IsNil)
+	return "https://" + info.Addrs[0] + "/resources" + query
+}
+
+func (s *resourcesSuite) assertResponse(c *gc.C, resp *http.Response, expCode int) params.ResourcesResponse {
+	body := assertResponse(c, resp, expCode, "application/json")
+	var jsonResponse params.ResourcesResponse
+	err := json.Unmarshal(body, &jsonResponse)
+	c.Assert(err, gc.IsNil)
+	return jsonResponse
+}
+
+func (s *resourcesSuite) TestRequiresAuth(c *gc.C) {
+	resp, err := s.sendRequest(c, "", "", "POST", s.resourcesURI(c, ""), "", nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.assertResponse(c, resp, http.StatusUnauthorized).Error, gc.Equals, "unauthorized")
+}
+
+func (s *resourcesSuite) TestRequiresPOST(c *gc.C) {
+	resp, err := s.authRequest(c, "GET", s.resourcesURI(c, ""), "", nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.assertResponse(c, resp, http.StatusMethodNotAllowed).Error, gc.Equals, `unsupported method: "GET"`)
+}
+
+var resourceUploadErrorTests = []struct {
+	query string
+	err   string
+}{{
+	query: "",
+	err:   "expected service=name argument",
+}, {
+	query: "?service=resources",
+	err:   "expected name=resource argument",
+}, {
+	query: "?service=unknown&name=jdk",
+	err:   `service "unknown" not found`,
+}, {
+	query: "?service=resources&name=unknown",
+	err:   `charm "local:quantal/resources-1" declares no resource "unknown"`,
+}}
+
+func (s *resourcesSuite) TestUploadErrors(c *gc.C) {
+	for i, t := range resourceUploadErrorTests {
+		c.Logf("test %d: %q", i, t.query)
+		resp, err := s.authRequest(c, "POST", s.resourcesURI(c, t.query), "", strings.NewReader("content"))
+		c.Assert(err, gc.IsNil)
+		c.Assert(s.assertResponse(c, resp, http.StatusBadRequest).Error, gc.Equals, t.err)
+	}
+}
+
+func (s *resourcesSuite) TestUpload(c *gc.C) {
+	content := "the jdk"
+	expectSHA256, _, err := utils.ReadSHA256(strings.NewReader(content))
+	c.Assert(err, gc.IsNil)
+	for i := 1; i <= 2; i++ {
+		resp, err := s.authRequest(c, "POST", s.resourcesURI(c, "?service=resources&name=jdk"),
+			"application/octet-stream", strings.NewReader(content))
+		c.Assert(err, gc.IsNil)
+		jsonResponse := s.assertResponse(c, resp, http.StatusOK)
+		c.Assert(jsonResponse.Error, gc.Equals, "")
+		res := jsonResponse.Resource
+		c.Assert(res, gc.NotNil)
+		c.Assert(res.Name, gc.Equals, "jdk")
+		c.Assert(res.Filename, gc.Equals, "jdk.tar.gz")
+		c.Assert(res.Revision, gc.Equals, i)
+		c.Assert(res.SHA256, gc.Equals, expectSHA256)
+		c.Assert(res.Size, gc.Equals, int64(len(content)))
+		c.Assert(res.User, gc.Equals, "joe")
+	}
+
+	// The content is in provider storage.
+	service, err := s.State.Service("resources")
+	c.Assert(err, gc.IsNil)
+	res, err := service.Resource("jdk")
+	c.Assert(err, gc.IsNil)
+	c.Assert(res.Revision, gc.Equals, 2)
+	storage, err := environs.GetStorage(s.State)
+	c.Assert(err, gc.IsNil)
+	url, err := storage.URL("resources/resources/jdk/" + expectSHA256)
+	c.Assert(err, gc.IsNil)
+	c.Assert(res.URL, gc.Equals, url)
+	reader, err := storage.Get("resources/resources/jdk/" + expectSHA256)
+	c.Assert(err, gc.IsNil)
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, content)
+}
+
+func (s *resourcesSuite) TestUploadNewContentSupersedesOld(c *gc.C) {
+	var hashes []string
+	for _, content := range []string{"old jdk", "new jdk"} {
+		resp, err := s.authRequest(c, "POST", s.resourcesURI(c, "?service=resources&name=jdk"),
+			"application/octet-stream", strings.NewReader(content))
+		c.Assert(err, gc.IsNil)
+		res := s.assertResponse(c, resp, http.StatusOK).Resource
+		c.Assert(res, gc.NotNil)
+		hashes = append(hashes, res.SHA256)
+	}
+
+	// The old content is removed from provider storage
+	// once the resource content cleanup has run.
+	storage, err := environs.GetStorage(s.State)
+	c.Assert(err, gc.IsNil)
+	err = s.State.CleanupResourceContent(storage)
+	c.Assert(err, gc.IsNil)
+	paths, err := storage.List("resources/resources/")
+	c.Assert(err, gc.IsNil)
+	c.Assert(paths, gc.DeepEquals, []string{"resources/resources/jdk/" + hashes[1]})
+}
+
+func (s *resourcesSuite) TestUploadNotAttachedIsRemoved(c *gc.C) {
+	// The service is removed after the upload checks it,
+	// so the content can't be attached.
+	service, err := s.State.Service("resources")
+	c.Assert(err, gc.IsNil)
+	defer state.SetBeforeHooks(c, s.State, func() {
+		c.Assert(service.Destroy(), gc.IsNil)
+	}).Check()
+	resp, err := s.authRequest(c, "POST", s.resourcesURI(c, "?service=resources&name=jdk"),
+		"application/octet-stream", strings.NewReader("the jdk"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.assertResponse(c, resp, http.StatusBadRequest).Error, gc.Matches, `cannot attach resource "jdk" to service "resources": .*`)
+
+	storage, err := environs.GetStorage(s.State)
+	c.Assert(err, gc.IsNil)
+	paths, err := storage.List("resources/resources/")
+	c.Assert(err, gc.IsNil)
+	c.Assert(paths, gc.HasLen, 0)
+}
@@ -0,0 +1,454 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api"
+	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/state/apiserver/client"
+	"github.com/juju/core/state/apiserver/common"
+	"github.com/juju/core/state/multiwatcher"
+)
+
+// restPrefix holds the path under which the REST gateway is served.
+const restPrefix = "/rest/"
+
+// restHandler serves a REST-style gateway to the Client API facade
+// through HTTPS, so that the environment can be managed by tools that
+// cannot speak the websocket RPC protocol.
+type restHandler struct {
+	httpHandler
+	dataDir string
+	// stop is closed when the API server is stopping,
+	// so that any watch streams can be ended.
+	stop <-chan struct{}
+}
+
+// restError is returned for requests that cannot be routed.
+type restError struct {
+	status  int
+	message string
+}
+
+func (e *restError) Error() string {
+	return e.message
+}
+
+var errRestNotFound = &restError{http.StatusNotFound, "not found"}
+
+// ServeHTTP serves the REST gateway. Requests are authenticated with
+// the same user credentials used to log in to the API, presented as
+// HTTP basic authentication. The resources are:
+//   /rest/environment
+//      GET -> EnvironmentInfo
+//   /rest/environment/config
+//      GET -> EnvironmentGet
+//      POST -> EnvironmentSet
+//   /rest/status
+//      GET -> FullStatus, with the patterns taken from "pattern" query values
+//   /rest/services
+//      GET -> the services in the environment status
+//      POST -> ServiceDeploy
+//   /rest/services/<service>
+//      GET -> ServiceGet
+//      POST -> ServiceSet
+//      DELETE -> ServiceDestroy
+//   /rest/services/<service>/units
+//      POST -> AddServiceUnits
+//   /rest/units, /rest/units/<unit>
+//      GET -> the units in the environment status
+//      DELETE -> DestroyServiceUnits
+//   /rest/machines, /rest/machines/<machine>
+//      GET -> the machines in the environment status
+//      POST -> AddMachines (to /rest/machines only)
+//      DELETE -> DestroyMachines, forced if the "force" query is "true"
+//   /rest/relations
+//      GET -> the relations in the environment
+//      POST -> AddRelation
+//      DELETE -> DestroyRelation, with the endpoints taken from
+//                "endpoint" query values
+//   /rest/watch
+//      GET -> a stream of WatchAll deltas, sent as server-sent events
+// POST requests hold the JSON-encoded parameters of the corresponding
+// facade call in their body; names given in the URL take precedence over
+// those in the body. Results are returned as JSON, and errors as a JSON
+// encoded params.Error.
+func (h *restHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	entity, err := h.authenticateUser(r)
+	if err != nil {
+		h.authError(w, h)
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, restPrefix), "/")
+	if path == "watch" {
+		if r.Method != "GET" {
+			h.sendError(w, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method: %q", r.Method))
+			return
+		}
+		h.serveWatch(w, r)
+		return
+	}
+	resources := common.NewResources()
+	defer resources.StopAll()
+	resources.RegisterNamed("dataDir", common.StringResource(h.dataDir))
+	c, err := client.NewAPI(h.state, resources, restAuthorizer{entity}).Client("")
+	if err != nil {
+		h.sendServerError(w, err)
+		return
+	}
+	result, err := h.dispatch(c, r, strings.Split(path, "/"))
+	if err != nil {
+		h.sendServerError(w, err)
+		return
+	}
+	h.sendJSON(w, http.StatusOK, result)
+}
+
+// dispatch makes the client call identified by the
+// request method and the given path elements.
+func (h *restHandler) dispatch(c *client.Client, r *http.Request, path []string) (interface{}, error) {
+	resource, rest := path[0], strings.Join(path[1:], "/")
+	method := r.Method
+	switch {
+	case resource == "environment" && rest == "" && method == "GET":
+		return c.EnvironmentInfo()
+	case resource == "environment" && rest == "config":
+		switch method {
+		case "GET":
+			return c.EnvironmentGet()
+		case "POST":
+			var args params.EnvironmentSet
+			if err := decodeBody(r, &args); err != nil {
+				return nil, err
+			}
+			return nil, c.EnvironmentSet(args)
+		}
+	case resource == "status" && rest == "" && method == "GET":
+		return c.FullStatus(params.StatusParams{Patterns: r.URL.Query()["pattern"]})
+	case resource == "services":
+		return h.services(c, r, path[1:])
+	case resource == "units":
+		return h.units(c, r, rest)
+	case resource == "machines":
+		return h.machines(c, r, rest)
+	case resource == "relations" && rest == "":
+		return h.relations(c, r)
+	default:
+		return nil, errRestNotFound
+	}
+	return nil, unsupportedMethod(method)
+}
+
+func (h *restHandler) services(c *client.Client, r *http.Request, path []string) (interface{}, error) {
+	switch {
+	case len(path) == 0 && r.Method == "GET":
+		status, err := c.FullStatus(params.StatusParams{})
+		if err != nil {
+			return nil, err
+		}
+		return status.Services, nil
+	case len(path) == 0 && r.Method == "POST":
+		var args params.ServiceDeploy
+		if err := decodeBody(r, &args); err != nil {
+			return nil, err
+		}
+		return nil, c.ServiceDeploy(args)
+	case len(path) == 1 && r.Method == "GET":
+		return c.ServiceGet(params.ServiceGet{ServiceName: path[0]})
+	case len(path) == 1 && r.Method == "POST":
+		var args params.ServiceSet
+		if err := decodeBody(r, &args); err != nil {
+			return nil, err
+		}
+		args.ServiceName = path[0]
+		return nil, c.ServiceSet(args)
+	case len(path) == 1 && r.Method == "DELETE":
+		return nil, c.ServiceDestroy(params.ServiceDestroy{ServiceName: path[0]})
+	case len(path) == 2 && path[1] == "units" && r.Method == "POST":
+		var args params.AddServiceUnits
+		if err := decodeBody(r, &args); err != nil {
+			return nil, err
+		}
+		args.ServiceName = path[0]
+		return c.AddServiceUnits(args)
+	case len(path) > 2 || len(path) == 2 && path[1] != "units":
+		return nil, errRestNotFound
+	}
+	return nil, unsupportedMethod(r.Method)
+}
+
+func (h *restHandler) units(c *client.Client, r *http.Request, name string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		status, err := c.FullStatus(params.StatusParams{})
+		if err != nil {
+			return nil, err
+		}
+		units := make(map[string]api.UnitStatus)
+		for _, service := range status.Services {
+			collectUnits(units, service.Units)
+		}
+		if name == "" {
+			return units, nil
+		}
+		if unit, ok := units[name]; ok {
+			return unit, nil
+		}
+		return nil, errors.NotFoundf("unit %q", name)
+	case "DELETE":
+		if name == "" {
+			return nil, unsupportedMethod(r.Method)
+		}
+		return nil, c.DestroyServiceUnits(params.DestroyServiceUnits{UnitNames: []string{name}})
+	}
+	return nil, unsupportedMethod(r.Method)
+}
+
+// collectUnits adds the given units, and their
+// subordinates, to all.
+func collectUnits(all, units map[string]api.UnitStatus) {
+	for name, unit := range units {
+		all[name] = unit
+		collectUnits(all, unit.Subordinates)
+	}
+}
+
+func (h *restHandler) machines(c *client.Client, r *http.Request, id string) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		status, err := c.FullStatus(params.StatusParams{})
+		if err != nil {
+			return nil, err
+		}
+		if id == "" {
+			return status.Machines, nil
+		}
+		if machine, ok := findMachine(status.Machines, id); ok {
+			return machine, nil
+		}
+		return nil, errors.NotFoundf("machine %s", id)
+	case "POST":
+		if id != "" {
+			return nil, unsupportedMethod(r.Method)
+		}
+		var args params.AddMachines
+		if err := decodeBody(r, &args); err != nil {
+			return nil, err
+		}
+		return c.AddMachinesV2(args)
+	case "DELETE":
+		if id == "" {
+			return nil, unsupportedMethod(r.Method)
+		}
+		return nil, c.DestroyMachines(params.DestroyMachines{
+			MachineNames: []string{id},
+			Force:        r.URL.Query().Get("force") == "true",
+		})
+	}
+	return nil, unsupportedMethod(r.Method)
+}
+
+// findMachine returns the status of the machine or
+// container with the given id.
+func findMachine(machines map[string]api.MachineStatus, id string) (api.MachineStatus, bool) {
+	for machineId, machine := range machines {
+		if machineId == id {
+			return machine, true
+		}
+		if found, ok := findMachine(machine.Containers, id); ok {
+			return found, true
+		}
+	}
+	return api.MachineStatus{}, false
+}
+
+// restRelation describes a relation returned by the REST gateway.
+type restRelation struct {
+	Id        int
+	Key       string
+	Endpoints []string
+}
+
+func (h *restHandler) relations(c *client.Client, r *http.Request) (interface{}, error) {
+	switch r.Method {
+	case "GET":
+		// The Client facade has no call that lists relations,
+		// so they are read from the state directly.
+		relations, err := h.state.AllRelations()
+		if err != nil {
+			return nil, err
+		}
+		results := make([]restRelation, len(relations))
+		for i, rel := range relations {
+			results[i] = restRelation{
+				Id:  rel.Id(),
+				Key: rel.String(),
+			}
+			for _, ep := range rel.Endpoints() {
+				results[i].Endpoints = append(results[i].Endpoints, ep.String())
+			}
+			sort.Strings(results[i].Endpoints)
+		}
+		return results, nil
+	case "POST":
+		var args params.AddRelation
+		if err := decodeBody(r, &args); err != nil {
+			return nil, err
+		}
+		return c.AddRelation(args)
+	case "DELETE":
+		return nil, c.DestroyRelation(params.DestroyRelation{
+			Endpoints: r.URL.Query()["endpoint"],
+		})
+	}
+	return nil, unsupportedMethod(r.Method)
+}
+
+// serveWatch streams the deltas of an environment watcher to the
+// client as server-sent events, until the client goes away or the
+// API server is stopped.
+func (h *restHandler) serveWatch(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.sendError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	watcher := h.state.Watch()
+	defer watcher.Stop()
+	done := make(chan struct{})
+	defer close(done)
+	var closed <-chan bool
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		closed = notifier.CloseNotify()
+	}
+	go func() {
+		select {
+		case <-closed:
+		case <-h.stop:
+		case <-done:
+			return
+		}
+		watcher.Stop()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		deltas, err := watcher.Next()
+		if err == multiwatcher.ErrWatcherStopped {
+			return
+		}
+		if err != nil {
+			logger.Errorf("REST watch failed: %v", err)
+			data, _ := json.Marshal(common.ServerError(err))
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+			flusher.Flush()
+			return
+		}
+		data, err := json.Marshal(deltas)
+		if err != nil {
+			logger.Errorf("cannot marshal deltas: %v", err)
+			return
+		}
+		if _, err := fmt.Fprintf(w, "event: deltas\ndata: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// decodeBody decodes the JSON-encoded request body into v.
+func decodeBody(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return &restError{http.StatusBadRequest, fmt.Sprintf("cannot decode request body: %v", err)}
+	}
+	return nil
+}
+
+func unsupportedMethod(method string) error {
+	return &restError{http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method: %q", method)}
+}
+
+// sendServerError sends the given error, choosing the
+// response status from its error code.
+func (h *restHandler) sendServerError(w http.ResponseWriter, err error) error {
+	if err, ok := err.(*restError); ok {
+		return h.sendError(w, err.status, err.message)
+	}
+	serverErr := common.ServerError(err)
+	status := http.StatusBadRequest
+	switch serverErr.Code {
+	case params.CodeNotFound:
+		status = http.StatusNotFound
+	case params.CodeUnauthorized:
+		status = http.StatusForbidden
+	case params.CodeAlreadyExists:
+		status = http.StatusConflict
+	case params.CodeNotImplemented:
+		status = http.StatusNotImplemented
+	}
+	return h.sendJSON(w, status, serverErr)
+}
+
+// sendError sends a JSON-encoded error response.
+func (h *restHandler) sendError(w http.ResponseWriter, statusCode int, message string) error {
+	return h.sendJSON(w, statusCode, &params.Error{Message: message})
+}
+
+// sendJSON sends a JSON-encoded response to the client.
+func (h *restHandler) sendJSON(w http.ResponseWriter, statusCode int, response interface{}) error {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(body)
+	return nil
+}
+
+// restAuthorizer authorizes the Client facade
+// calls made on behalf of a REST request.
+type restAuthorizer struct {
+	entity taggedAuthenticator
+}
+
+func (a restAuthorizer) AuthMachineAgent() bool {
+	return false
+}
+
+func (a restAuthorizer) AuthUnitAgent() bool {
+	return false
+}
+
+func (a restAuthorizer) AuthOwner(tag string) bool {
+	return a.entity.Tag() == tag
+}
+
+func (a restAuthorizer) AuthEnvironManager() bool {
+	return false
+}
+
+func (a restAuthorizer) AuthClient() bool {
+	return !isAgent(a.entity)
+}
+
+func (a restAuthorizer) GetAuthTag() string {
+	return a.entity.Tag()
+}
+
+func (a restAuthorizer) GetAuthEntity() state.Entity {
+	return a.entity
+}
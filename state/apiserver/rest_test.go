@@ -0,0 +1,209 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api"
+	"github.com/juju/core/state/api/params"
+)
+
+type restSuite struct {
+	authHttpSuite
+}
+
+var _ = gc.Suite(&restSuite{})
+
+func (s *restSuite) restURI(c *gc.C, path string) string {
+	_, info, err := s.APIConn.Environ.StateInfo()
+	c.Assert(err, gc.IsNil)
+	return "https://" + info.Addrs[0] + "/rest/" + path
+}
+
+// restCall makes an authenticated request to the REST gateway and
+// decodes the JSON response into result.
+func (s *restSuite) restCall(c *gc.C, method, path, body string, expectCode int, result interface{}) {
+	resp, err := s.authRequest(c, method, s.restURI(c, path), "application/json", strings.NewReader(body))
+	c.Assert(err, gc.IsNil)
+	data := assertResponse(c, resp, expectCode, "application/json")
+	if result != nil {
+		err = json.Unmarshal(data, result)
+		c.Assert(err, gc.IsNil)
+	}
+}
+
+func (s *restSuite) TestRequiresAuth(c *gc.C) {
+	resp, err := s.sendRequest(c, "", "", "GET", s.restURI(c, "environment"), "", nil)
+	c.Assert(err, gc.IsNil)
+	assertResponse(c, resp, http.StatusUnauthorized, "application/json")
+	c.Assert(resp.Header.Get("WWW-Authenticate"), gc.Equals, `Basic realm="juju"`)
+
+	// Agents cannot use the gateway.
+	resp, err = s.sendRequest(c, "machine-0", "password", "GET", s.restURI(c, "environment"), "", nil)
+	c.Assert(err, gc.IsNil)
+	assertResponse(c, resp, http.StatusUnauthorized, "application/json")
+}
+
+func (s *restSuite) TestEnvironment(c *gc.C) {
+	var info api.EnvironmentInfo
+	s.restCall(c, "GET", "environment", "", http.StatusOK, &info)
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Name, gc.Equals, env.Name())
+	c.Assert(info.UUID, gc.Equals, env.UUID())
+
+	s.restCall(c, "POST", "environment/config", `{"Config": {"default-series": "precise"}}`, http.StatusOK, nil)
+	var config params.EnvironmentGetResults
+	s.restCall(c, "GET", "environment/config", "", http.StatusOK, &config)
+	c.Assert(config.Config["default-series"], gc.Equals, "precise")
+}
+
+func (s *restSuite) TestServicesAndUnits(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+
+	var services map[string]api.ServiceStatus
+	s.restCall(c, "GET", "services", "", http.StatusOK, &services)
+	c.Assert(services, gc.HasLen, 1)
+	c.Assert(services["wordpress"].Charm, gc.Matches, "local:quantal/wordpress-.*")
+
+	var added params.AddServiceUnitsResults
+	s.restCall(c, "POST", "services/wordpress/units", `{"NumUnits": 1}`, http.StatusOK, &added)
+	c.Assert(added.Units, gc.DeepEquals, []string{"wordpress/0"})
+
+	var unit api.UnitStatus
+	s.restCall(c, "GET", "units/wordpress/0", "", http.StatusOK, &unit)
+	c.Assert(unit.Machine, gc.Equals, "")
+	c.Assert(unit.Life, gc.Equals, "")
+
+	s.restCall(c, "DELETE", "units/wordpress/0", "", http.StatusOK, nil)
+	u, err := s.State.Unit("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.Life().String(), gc.Equals, "dying")
+
+	s.restCall(c, "DELETE", "services/wordpress", "", http.StatusOK, nil)
+	svc, err := s.State.Service("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.Life().String(), gc.Equals, "dying")
+}
+
+func (s *restSuite) TestMachines(c *gc.C) {
+	var added params.AddMachinesResults
+	s.restCall(c, "POST", "machines", `{"MachineParams": [{"Jobs": ["JobHostUnits"]}]}`, http.StatusOK, &added)
+	c.Assert(added.Machines, gc.HasLen, 1)
+	c.Assert(added.Machines[0].Error, gc.IsNil)
+	id := added.Machines[0].Machine
+
+	var machines map[string]api.MachineStatus
+	s.restCall(c, "GET", "machines", "", http.StatusOK, &machines)
+	_, ok := machines[id]
+	c.Assert(ok, jc.IsTrue)
+
+	s.restCall(c, "DELETE", "machines/"+id+"?force=true", "", http.StatusOK, nil)
+}
+
+func (s *restSuite) TestDeleteRoutes(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	// Collections can't be deleted.
+	var serverErr params.Error
+	for _, path := range []string{"services", "units", "machines"} {
+		c.Logf("DELETE %s", path)
+		s.restCall(c, "DELETE", path, "", http.StatusMethodNotAllowed, &serverErr)
+		c.Assert(serverErr.Message, gc.Equals, `unsupported method: "DELETE"`)
+	}
+	s.restCall(c, "DELETE", "environment", "", http.StatusNotFound, &serverErr)
+
+	// Unknown entities are reported as not found.
+	s.restCall(c, "DELETE", "services/unknown", "", http.StatusNotFound, &serverErr)
+	c.Assert(serverErr.Code, gc.Equals, params.CodeNotFound)
+
+	s.restCall(c, "DELETE", "machines/"+machine.Id(), "", http.StatusOK, nil)
+	err = machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machine.Life(), gc.Equals, state.Dying)
+
+	// The last remaining service is destroyed outright.
+	s.restCall(c, "DELETE", "services/wordpress", "", http.StatusOK, nil)
+	_, err = s.State.Service("wordpress")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *restSuite) TestRelations(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+
+	var relations []map[string]interface{}
+	s.restCall(c, "GET", "relations", "", http.StatusOK, &relations)
+	c.Assert(relations, gc.HasLen, 0)
+
+	var added params.AddRelationResults
+	s.restCall(c, "POST", "relations", `{"Endpoints": ["wordpress", "mysql"]}`, http.StatusOK, &added)
+	c.Assert(added.Endpoints, gc.HasLen, 2)
+	c.Assert(added.Endpoints["wordpress"].Name, gc.Equals, "db")
+	c.Assert(added.Endpoints["mysql"].Name, gc.Equals, "server")
+
+	s.restCall(c, "GET", "relations", "", http.StatusOK, &relations)
+	c.Assert(relations, gc.HasLen, 1)
+	c.Assert(relations[0]["Key"], gc.Equals, "wordpress:db mysql:server")
+	c.Assert(relations[0]["Endpoints"], gc.DeepEquals, []interface{}{"mysql:server", "wordpress:db"})
+
+	var serverErr params.Error
+	s.restCall(c, "POST", "relations", `{"Endpoints": ["wordpress", "mysql"]}`, http.StatusBadRequest, &serverErr)
+	c.Assert(serverErr.Message, gc.Matches, `cannot add relation .*: relation already exists`)
+	s.restCall(c, "DELETE", "relations", "", http.StatusBadRequest, &serverErr)
+	s.restCall(c, "PUT", "relations", "", http.StatusMethodNotAllowed, &serverErr)
+	s.restCall(c, "GET", "relations/0", "", http.StatusNotFound, &serverErr)
+
+	// A relation without units is removed outright.
+	s.restCall(c, "DELETE", "relations?endpoint=wordpress&endpoint=mysql", "", http.StatusOK, nil)
+	s.restCall(c, "GET", "relations", "", http.StatusOK, &relations)
+	c.Assert(relations, gc.HasLen, 0)
+	s.restCall(c, "DELETE", "relations?endpoint=wordpress&endpoint=mysql", "", http.StatusNotFound, &serverErr)
+	c.Assert(serverErr.Code, gc.Equals, params.CodeNotFound)
+}
+
+func (s *restSuite) TestErrors(c *gc.C) {
+	var serverErr params.Error
+	s.restCall(c, "GET", "services/unknown", "", http.StatusNotFound, &serverErr)
+	c.Assert(serverErr.Code, gc.Equals, params.CodeNotFound)
+	c.Assert(serverErr.Message, gc.Equals, `service "unknown" not found`)
+
+	s.restCall(c, "GET", "no/such/thing", "", http.StatusNotFound, &serverErr)
+	s.restCall(c, "PUT", "services", "", http.StatusMethodNotAllowed, &serverErr)
+	c.Assert(serverErr.Message, gc.Equals, `unsupported method: "PUT"`)
+	s.restCall(c, "POST", "services", "not json", http.StatusBadRequest, &serverErr)
+	c.Assert(serverErr.Message, gc.Matches, "cannot decode request body: .*")
+}
+
+func (s *restSuite) TestWatch(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	resp, err := s.authRequest(c, "GET", s.restURI(c, "watch"), "", nil)
+	c.Assert(err, gc.IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Content-Type"), gc.Equals, "text/event-stream")
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	c.Assert(err, gc.IsNil)
+	c.Assert(line, gc.Equals, "event: deltas\n")
+	line, err = reader.ReadString('\n')
+	c.Assert(err, gc.IsNil)
+	c.Assert(strings.HasPrefix(line, "data: "), jc.IsTrue)
+	var deltas []params.Delta
+	err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &deltas)
+	c.Assert(err, gc.IsNil)
+	c.Assert(deltas, gc.Not(gc.HasLen), 0)
+}
@@ -75,12 +75,14 @@ func newSrvRoot(root *initialRoot, entity taggedAuthenticator) *srvRoot {
 	}
 	r.resources.RegisterNamed("dataDir", common.StringResource(r.srv.dataDir))
 	r.clientAPI.API = client.NewAPI(r.srv.state, r.resources, r)
+	r.srv.metrics.addRoot(r)
 	return r
 }
 
 // Kill implements rpc.Killer.  It cleans up any resources that need
 // cleaning up to ensure that all outstanding requests return.
 func (r *srvRoot) Kill() {
+	r.srv.metrics.removeRoot(r)
 	r.resources.StopAll()
 }
 
@@ -175,7 +177,7 @@ func (r *srvRoot) Agent(id string) (*agent.API, error) {
 	if id != "" {
 		return nil, common.ErrBadId
 	}
-	return agent.NewAPI(r.srv.state, r)
+	return agent.NewAPI(r.srv.state, r.resources, r)
 }
 
 // Deployer returns an object that provides access to the Deployer API facade.
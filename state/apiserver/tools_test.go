@@ -9,13 +9,16 @@ import (
 	"net/http"
 	"path"
 	"path/filepath"
+	"time"
 
+	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/core/environs/tools"
 	toolstesting "github.com/juju/core/environs/tools/testing"
 	"github.com/juju/core/state"
 	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/state/apiserver"
 	coretools "github.com/juju/core/tools"
 	"github.com/juju/core/utils"
 	"github.com/juju/core/version"
@@ -46,6 +49,25 @@ func (s *toolsSuite) TestRequiresAuth(c *gc.C) {
 	s.assertErrorResponse(c, resp, http.StatusUnauthorized, "unauthorized")
 }
 
+func (s *toolsSuite) TestFailedAuthLockedOut(c *gc.C) {
+	s.PatchValue(apiserver.LoginFailureGrace, 10)
+	s.PatchValue(apiserver.LoginFailureLockout, 2)
+	for i := 0; i < 2; i++ {
+		resp, err := s.sendRequest(c, s.userTag, "wrong", "POST", s.toolsURI(c, ""), "", nil)
+		c.Assert(err, gc.IsNil)
+		s.assertErrorResponse(c, resp, http.StatusUnauthorized, "unauthorized")
+	}
+	// Once locked out, even the right password is refused.
+	resp, err := s.authRequest(c, "POST", s.toolsURI(c, ""), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusUnauthorized, "unauthorized")
+
+	f, err := s.State.LoginFailures(state.LoginFailureUser, s.userTag)
+	c.Assert(err, gc.IsNil)
+	c.Assert(f.Count, gc.Equals, 2)
+	c.Assert(f.Locked(time.Now()), jc.IsTrue)
+}
+
 func (s *toolsSuite) TestRequiresPOST(c *gc.C) {
 	resp, err := s.authRequest(c, "PUT", s.toolsURI(c, ""), "", nil)
 	c.Assert(err, gc.IsNil)
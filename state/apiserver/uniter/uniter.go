@@ -7,6 +7,7 @@ package uniter
 
 import (
 	"fmt"
+	"net"
 
 	"github.com/juju/errors"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/juju/core/state/api/params"
 	"github.com/juju/core/state/apiserver/common"
 	"github.com/juju/core/state/watcher"
+	"github.com/juju/core/utils/set"
 )
 
 // UniterAPI implements the API used by the uniter worker.
@@ -304,6 +306,9 @@ func (u *UniterAPI) HasSubordinates(args params.Entities) (params.BoolResults, e
 }
 
 // CharmURL returns the charm URL for all given units or services.
+// The charm URL of a service is the one the authenticated unit should
+// run, which differs from the service's while a rolling charm upgrade
+// holds the unit back.
 func (u *UniterAPI) CharmURL(args params.Entities) (params.StringBoolResults, error) {
 	result := params.StringBoolResults{
 		Results: make([]params.StringBoolResult, len(args.Entities)),
@@ -319,10 +324,16 @@ func (u *UniterAPI) CharmURL(args params.Entities) (params.StringBoolResults, er
 			var unitOrService state.Entity
 			unitOrService, err = u.st.FindEntity(entity.Tag)
 			if err == nil {
-				charmURLer := unitOrService.(interface {
-					CharmURL() (*charm.URL, bool)
-				})
-				curl, ok := charmURLer.CharmURL()
+				var curl *charm.URL
+				var ok bool
+				if service, isService := unitOrService.(*state.Service); isService {
+					curl, ok = service.CharmURLForUnit(u.auth.GetAuthEntity().(*state.Unit).Name())
+				} else {
+					charmURLer := unitOrService.(interface {
+						CharmURL() (*charm.URL, bool)
+					})
+					curl, ok = charmURLer.CharmURL()
+				}
 				if curl != nil {
 					result.Results[i].Result = curl.String()
 					result.Results[i].Ok = ok
@@ -970,6 +981,199 @@ func (u *UniterAPI) WatchRelationUnits(args params.RelationUnits) (params.Relati
 	return result, nil
 }
 
+// unitNetworkConfig returns the configuration of the unit's machine on
+// the named network. Machine addresses not explicitly associated with a
+// network are included when they lie within the network's CIDR.
+func (u *UniterAPI) unitNetworkConfig(unit *state.Unit, networkName string) (params.UnitNetworkConfig, error) {
+	nothing := params.UnitNetworkConfig{}
+	network, err := u.st.Network(networkName)
+	if err != nil {
+		return nothing, err
+	}
+	machineId, err := unit.AssignedMachineId()
+	if err != nil {
+		return nothing, err
+	}
+	machine, err := u.st.Machine(machineId)
+	if err != nil {
+		return nothing, err
+	}
+	config := params.UnitNetworkConfig{
+		NetworkTag: network.Tag(),
+		CIDR:       network.CIDR(),
+		VLANTag:    network.VLANTag(),
+	}
+	ifaces, err := machine.NetworkInterfaces()
+	if err != nil {
+		return nothing, err
+	}
+	for _, iface := range ifaces {
+		if iface.NetworkName() == networkName {
+			config.InterfaceNames = append(config.InterfaceNames, iface.InterfaceName())
+		}
+	}
+	var ipNet *net.IPNet
+	if network.CIDR() != "" {
+		if _, ipNet, err = net.ParseCIDR(network.CIDR()); err != nil {
+			return nothing, err
+		}
+	}
+	for _, addr := range machine.Addresses() {
+		switch {
+		case addr.NetworkName == networkName:
+		case addr.NetworkName == "" && ipNet != nil && ipNet.Contains(net.ParseIP(addr.Value)):
+		default:
+			continue
+		}
+		config.Addresses = append(config.Addresses, addr.Value)
+	}
+	if len(config.InterfaceNames) == 0 && len(config.Addresses) == 0 {
+		return nothing, errors.NotFoundf("network %q on unit %q", networkName, unit.Name())
+	}
+	return config, nil
+}
+
+// NetworkConfig returns the configuration of each given unit on the
+// given network.
+func (u *UniterAPI) NetworkConfig(args params.UnitNetworks) (params.UnitNetworkConfigResults, error) {
+	result := params.UnitNetworkConfigResults{
+		Results: make([]params.UnitNetworkConfigResult, len(args.UnitNetworks)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.UnitNetworkConfigResults{}, err
+	}
+	for i, arg := range args.UnitNetworks {
+		err := common.ErrPerm
+		if canAccess(arg.Unit) {
+			var unit *state.Unit
+			unit, err = u.getUnit(arg.Unit)
+			if err == nil {
+				var networkName string
+				_, networkName, err = names.ParseTag(arg.Network, names.NetworkTagKind)
+				if err == nil {
+					result.Results[i].Config, err = u.unitNetworkConfig(unit, networkName)
+				}
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// unitResource returns the current revision of the named resource of
+// the unit's service, which must be declared by the service's charm.
+func (u *UniterAPI) unitResource(unit *state.Unit, name string, disableSSLHostnameVerification bool) (params.UnitResourceInfo, error) {
+	nothing := params.UnitResourceInfo{}
+	service, err := unit.Service()
+	if err != nil {
+		return nothing, err
+	}
+	ch, _, err := service.Charm()
+	if err != nil {
+		return nothing, err
+	}
+	declared, ok := ch.Meta().Resources[name]
+	if !ok {
+		return nothing, errors.NotFoundf("resource %q in charm %q", name, ch.URL())
+	}
+	res, err := service.Resource(name)
+	if err != nil {
+		return nothing, err
+	}
+	return params.UnitResourceInfo{
+		Filename:                       declared.Filename,
+		Revision:                       res.Revision,
+		URL:                            res.URL,
+		SHA256:                         res.SHA256,
+		DisableSSLHostnameVerification: disableSSLHostnameVerification,
+	}, nil
+}
+
+// Resource returns the current revision of each given resource of
+// the given unit's service, and where its content can be downloaded.
+func (u *UniterAPI) Resource(args params.UnitResources) (params.UnitResourceResults, error) {
+	result := params.UnitResourceResults{
+		Results: make([]params.UnitResourceResult, len(args.UnitResources)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.UnitResourceResults{}, err
+	}
+	// See CharmArchiveURL.
+	envConfig, err := u.st.EnvironConfig()
+	if err != nil {
+		return params.UnitResourceResults{}, err
+	}
+	disableSSLHostnameVerification := !envConfig.SSLHostnameVerification()
+	for i, arg := range args.UnitResources {
+		err := common.ErrPerm
+		if canAccess(arg.Unit) {
+			var unit *state.Unit
+			unit, err = u.getUnit(arg.Unit)
+			if err == nil {
+				result.Results[i].Info, err = u.unitResource(unit, arg.Resource, disableSSLHostnameVerification)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// relationNetworkTags returns the tags of the networks that all the
+// services in the relation are associated with.
+func (u *UniterAPI) relationNetworkTags(rel *state.Relation) ([]string, error) {
+	var shared set.Strings
+	for i, ep := range rel.Endpoints() {
+		service, err := u.st.Service(ep.ServiceName)
+		if err != nil {
+			return nil, err
+		}
+		includeNetworks, _, err := service.Networks()
+		if err != nil {
+			return nil, err
+		}
+		networks := set.NewStrings(includeNetworks...)
+		if i == 0 {
+			shared = networks
+		} else {
+			shared = shared.Intersection(networks)
+		}
+	}
+	tags := []string{}
+	for _, name := range shared.SortedValues() {
+		tags = append(tags, names.NetworkTag(name))
+	}
+	return tags, nil
+}
+
+// RelationNetworks returns the tags of the networks shared by the
+// services in each given relation, as seen by the given unit.
+func (u *UniterAPI) RelationNetworks(args params.RelationUnits) (params.StringsResults, error) {
+	result := params.StringsResults{
+		Results: make([]params.StringsResult, len(args.RelationUnits)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.StringsResults{}, err
+	}
+	for i, arg := range args.RelationUnits {
+		rel, unit, err := u.getRelationAndUnit(canAccess, arg.Relation, arg.Unit)
+		if err == nil {
+			// Only units of services in the relation can see its
+			// networks.
+			if _, err = rel.Endpoint(unit.ServiceName()); err != nil {
+				err = common.ErrPerm
+			}
+		}
+		if err == nil {
+			result.Results[i].Result, err = u.relationNetworkTags(rel)
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 // TODO(dimitern) bug #1270795 2014-01-20
 // Add a doc comment here and use u.accessService()
 // below in the body to check for permissions.
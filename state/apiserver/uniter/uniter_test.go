@@ -4,6 +4,7 @@
 package uniter_test
 
 import (
+	"net/url"
 	stdtesting "testing"
 
 	"github.com/juju/errors"
@@ -612,6 +613,32 @@ func (s *uniterSuite) TestCharmURL(c *gc.C) {
 	})
 }
 
+func (s *uniterSuite) TestCharmURLRollout(c *gc.C) {
+	err := s.wordpressUnit.SetCharmURL(s.wpCharm.URL())
+	c.Assert(err, gc.IsNil)
+	newURL := charm.MustParseURL("local:quantal/wordpress-99")
+	newCharm, err := s.State.AddCharm(coretesting.Charms.Dir("wordpress"), newURL, &url.URL{}, "wordpress-99-sha256")
+	c.Assert(err, gc.IsNil)
+	err = s.wordpress.SetCharmRollout(newCharm, true, 1, false)
+	c.Assert(err, gc.IsNil)
+
+	// The unit is held at the old charm until it's released.
+	args := params.Entities{Entities: []params.Entity{{Tag: "service-wordpress"}}}
+	result, err := s.uniter.CharmURL(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.StringBoolResults{
+		Results: []params.StringBoolResult{{Result: s.wpCharm.String(), Ok: false}},
+	})
+
+	err = s.wordpress.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+	result, err = s.uniter.CharmURL(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.StringBoolResults{
+		Results: []params.StringBoolResult{{Result: newURL.String(), Ok: true}},
+	})
+}
+
 func (s *uniterSuite) TestSetCharmURL(c *gc.C) {
 	charmUrl, ok := s.wordpressUnit.CharmURL()
 	c.Assert(charmUrl, gc.IsNil)
@@ -1428,3 +1455,146 @@ func (s *uniterSuite) TestGetOwnerTag(c *gc.C) {
 		Result: "user-admin",
 	})
 }
+
+func (s *uniterSuite) TestNetworkConfig(c *gc.C) {
+	err := s.machine0.SetInstanceInfo("i-am", "fake_nonce", nil,
+		[]state.NetworkInfo{{
+			Name:       "net1",
+			ProviderId: "net1",
+			CIDR:       "0.1.2.0/24",
+			VLANTag:    42,
+		}, {
+			Name:       "net2",
+			ProviderId: "net2",
+			CIDR:       "0.2.2.0/24",
+		}},
+		[]state.NetworkInterfaceInfo{{
+			MACAddress:    "aa:bb:cc:dd:ee:f0",
+			InterfaceName: "eth0.42",
+			NetworkName:   "net1",
+			IsVirtual:     true,
+		}, {
+			MACAddress:    "aa:bb:cc:dd:ee:f1",
+			InterfaceName: "eth1",
+			NetworkName:   "net2",
+		}},
+	)
+	c.Assert(err, gc.IsNil)
+	err = s.machine0.SetAddresses(
+		instance.Address{Value: "0.1.2.3", Type: instance.Ipv4Address, NetworkName: "net1"},
+		instance.Address{Value: "0.1.2.4", Type: instance.Ipv4Address},
+		instance.Address{Value: "0.2.2.3", Type: instance.Ipv4Address, NetworkName: "net2"},
+		instance.Address{Value: "10.0.0.1", Type: instance.Ipv4Address},
+	)
+	c.Assert(err, gc.IsNil)
+
+	args := params.UnitNetworks{UnitNetworks: []params.UnitNetwork{
+		{Unit: "unit-wordpress-0", Network: "network-net1"},
+		{Unit: "unit-wordpress-0", Network: "network-net3"},
+		{Unit: "unit-wordpress-0", Network: "machine-0"},
+		{Unit: "unit-mysql-0", Network: "network-net1"},
+		{Unit: "unit-foo-42", Network: "network-net1"},
+	}}
+	result, err := s.uniter.NetworkConfig(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.UnitNetworkConfigResults{
+		Results: []params.UnitNetworkConfigResult{
+			{Config: params.UnitNetworkConfig{
+				NetworkTag:     "network-net1",
+				CIDR:           "0.1.2.0/24",
+				VLANTag:        42,
+				InterfaceNames: []string{"eth0.42"},
+				Addresses:      []string{"0.1.2.3", "0.1.2.4"},
+			}},
+			{Error: apiservertesting.NotFoundError(`network "net3"`)},
+			{Error: &params.Error{Message: `"machine-0" is not a valid network tag`}},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *uniterSuite) TestRelationNetworks(c *gc.C) {
+	rel := s.addRelation(c, "wordpress", "mysql")
+
+	// Add a related pair of services associated with networks, and a
+	// uniter API for a unit of the first of them.
+	s.AddTestingServiceWithNetworks(c, "wp-net", s.wpCharm, []string{"net1", "net2"}, nil)
+	s.AddTestingServiceWithNetworks(c, "mysql-net", s.AddTestingCharm(c, "mysql"), []string{"net3", "net2", "net1"}, nil)
+	netRel := s.addRelation(c, "wp-net", "mysql-net")
+	wpNetService, err := s.State.Service("wp-net")
+	c.Assert(err, gc.IsNil)
+	wpNetUnit, err := wpNetService.AddUnit()
+	c.Assert(err, gc.IsNil)
+	authorizer := s.authorizer
+	authorizer.Tag = wpNetUnit.Tag()
+	authorizer.Entity = wpNetUnit
+	netUniter, err := uniter.NewUniterAPI(s.State, s.resources, authorizer)
+	c.Assert(err, gc.IsNil)
+
+	args := params.RelationUnits{RelationUnits: []params.RelationUnit{
+		{Relation: netRel.Tag(), Unit: wpNetUnit.Tag()},
+		{Relation: rel.Tag(), Unit: wpNetUnit.Tag()},
+		{Relation: netRel.Tag(), Unit: "unit-wordpress-0"},
+		{Relation: "relation-42", Unit: wpNetUnit.Tag()},
+	}}
+	result, err := netUniter.RelationNetworks(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.StringsResults{
+		Results: []params.StringsResult{
+			{Result: []string{"network-net1", "network-net2"}},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Services without networks share none.
+	args = params.RelationUnits{RelationUnits: []params.RelationUnit{
+		{Relation: rel.Tag(), Unit: "unit-wordpress-0"},
+	}}
+	result, err = s.uniter.RelationNetworks(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.StringsResults{
+		Results: []params.StringsResult{{Result: []string{}}},
+	})
+}
+
+func (s *uniterSuite) TestResource(c *gc.C) {
+	service := s.AddTestingService(c, "resources", s.AddTestingCharm(c, "resources"))
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(s.machine1)
+	c.Assert(err, gc.IsNil)
+	_, err = service.AttachResource("jdk", "http://example.com/jdk", "abc", 42, "admin")
+	c.Assert(err, gc.IsNil)
+	auth := s.authorizer
+	auth.Tag = unit.Tag()
+	auth.Entity = unit
+	resourcesUniter, err := uniter.NewUniterAPI(s.State, s.resources, auth)
+	c.Assert(err, gc.IsNil)
+
+	args := params.UnitResources{UnitResources: []params.UnitResource{
+		{Unit: unit.Tag(), Resource: "jdk"},
+		{Unit: unit.Tag(), Resource: "app"},
+		{Unit: unit.Tag(), Resource: "unknown"},
+		{Unit: "unit-wordpress-0", Resource: "jdk"},
+		{Unit: "unit-foo-42", Resource: "jdk"},
+	}}
+	result, err := resourcesUniter.Resource(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.UnitResourceResults{
+		Results: []params.UnitResourceResult{
+			{Info: params.UnitResourceInfo{
+				Filename: "jdk.tar.gz",
+				Revision: 1,
+				URL:      "http://example.com/jdk",
+				SHA256:   "abc",
+			}},
+			{Error: apiservertesting.NotFoundError(`resource "app" of service "resources"`)},
+			{Error: apiservertesting.NotFoundError(`resource "unknown" in charm "local:quantal/resources-1"`)},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
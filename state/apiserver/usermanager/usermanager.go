@@ -8,6 +8,8 @@ import (
 
 	"github.com/juju/loggo"
 
+	"github.com/juju/core/audit"
+	"github.com/juju/core/names"
 	"github.com/juju/core/state"
 	"github.com/juju/core/state/api/params"
 	"github.com/juju/core/state/apiserver/common"
@@ -19,6 +21,9 @@ var logger = loggo.GetLogger("juju.state.apiserver.usermanager")
 type UserManager interface {
 	AddUser(arg params.EntityPasswords) (params.ErrorResults, error)
 	RemoveUser(arg params.Entities) (params.ErrorResults, error)
+	SetPassword(args params.EntityPasswords) (params.ErrorResults, error)
+	LoginLockouts() (params.LoginLockoutsResult, error)
+	ClearLoginLockouts(args params.LoginLockoutIds) (params.ErrorResults, error)
 }
 
 // UserManagerAPI implements the user manager interface and is the concrete
@@ -104,3 +109,89 @@ func (api *UserManagerAPI) RemoveUser(args params.Entities) (params.ErrorResults
 	}
 	return result, nil
 }
+
+// SetPassword changes the passwords of the given users. Users may
+// change their own password; only the admin user may change the
+// passwords of others.
+func (api *UserManagerAPI) SetPassword(args params.EntityPasswords) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Changes)),
+	}
+	authTag := api.authorizer.GetAuthTag()
+	for i, arg := range args.Changes {
+		if authTag != names.UserTag(state.AdminUser) && authTag != names.UserTag(arg.Tag) {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		if arg.Password == "" {
+			result.Results[i].Error = common.ServerError(fmt.Errorf("password is empty"))
+			continue
+		}
+		user, err := api.state.User(arg.Tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		if err := user.SetPassword(arg.Password); err != nil {
+			err = fmt.Errorf("Failed to set password: %v", err)
+			result.Results[i].Error = common.ServerError(err)
+		}
+	}
+	return result, nil
+}
+
+// isAdmin returns whether the authenticated user is the admin user.
+func (api *UserManagerAPI) isAdmin() bool {
+	return api.authorizer.GetAuthTag() == names.UserTag(state.AdminUser)
+}
+
+// LoginLockouts returns the failed logins recorded against users
+// and remote addresses, including any current lockouts. Only the
+// admin user may list them.
+func (api *UserManagerAPI) LoginLockouts() (params.LoginLockoutsResult, error) {
+	if !api.isAdmin() {
+		return params.LoginLockoutsResult{}, common.ErrPerm
+	}
+	all, err := api.state.AllLoginFailures()
+	if err != nil {
+		return params.LoginLockoutsResult{}, err
+	}
+	result := params.LoginLockoutsResult{
+		Lockouts: make([]params.LoginLockout, len(all)),
+	}
+	for i, f := range all {
+		result.Lockouts[i] = params.LoginLockout{
+			Kind:        string(f.Kind),
+			Name:        f.Name,
+			Failures:    f.Count,
+			LastFailure: f.LastFailure,
+			LockedUntil: f.LockedUntil,
+		}
+	}
+	return result, nil
+}
+
+// ClearLoginLockouts forgets the failed logins recorded against the
+// given users and remote addresses, lifting any lockouts. Only the
+// admin user may clear them.
+func (api *UserManagerAPI) ClearLoginLockouts(args params.LoginLockoutIds) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Ids)),
+	}
+	if !api.isAdmin() {
+		return result, common.ErrPerm
+	}
+	for i, id := range args.Ids {
+		kind := state.LoginFailureKind(id.Kind)
+		if kind != state.LoginFailureUser && kind != state.LoginFailureAddress {
+			result.Results[i].Error = common.ServerError(fmt.Errorf("invalid login lockout kind %q", id.Kind))
+			continue
+		}
+		if err := api.state.ClearLoginFailures(kind, id.Name); err != nil {
+			result.Results[i].Error = common.ServerError(err)
+			continue
+		}
+		audit.Audit(api.authorizer.GetAuthEntity(), "cleared login lockout for %s %q", kind, id.Name)
+	}
+	return result, nil
+}
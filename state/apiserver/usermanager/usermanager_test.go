@@ -4,9 +4,12 @@
 package usermanager_test
 
 import (
+	"time"
+
 	gc "launchpad.net/gocheck"
 
 	jujutesting "github.com/juju/core/juju/testing"
+	"github.com/juju/core/state"
 	"github.com/juju/core/state/api/params"
 	apiservertesting "github.com/juju/core/state/apiserver/testing"
 	"github.com/juju/core/state/apiserver/usermanager"
@@ -111,3 +114,118 @@ func (s *userManagerSuite) TestCannotAddRemoveAdd(c *gc.C) {
 		Results: []params.ErrorResult{
 			params.ErrorResult{expectedError}}})
 }
+
+func (s *userManagerSuite) TestSetPassword(c *gc.C) {
+	_, err := s.State.AddUser("foobar", "password")
+	c.Assert(err, gc.IsNil)
+
+	args := params.EntityPasswords{Changes: []params.EntityPassword{{
+		Tag:      "foobar",
+		Password: "new-password",
+	}, {
+		Tag:      "admin",
+		Password: "",
+	}}}
+	result, err := s.usermanager.SetPassword(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{Error: nil},
+			{Error: apiservertesting.ServerError("password is empty")},
+		}})
+	user, err := s.State.User("foobar")
+	c.Assert(err, gc.IsNil)
+	c.Assert(user.PasswordValid("new-password"), gc.Equals, true)
+	c.Assert(user.PasswordValid("password"), gc.Equals, false)
+}
+
+func (s *userManagerSuite) TestSetPasswordOwnOnly(c *gc.C) {
+	_, err := s.State.AddUser("foobar", "password")
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddUser("other", "password")
+	c.Assert(err, gc.IsNil)
+
+	authorizer := s.authorizer
+	authorizer.Tag = "user-foobar"
+	usermanager, err := usermanager.NewUserManagerAPI(s.State, authorizer)
+	c.Assert(err, gc.IsNil)
+
+	args := params.EntityPasswords{Changes: []params.EntityPassword{{
+		Tag:      "foobar",
+		Password: "new-password",
+	}, {
+		Tag:      "other",
+		Password: "new-password",
+	}}}
+	result, err := usermanager.SetPassword(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{Error: nil},
+			{Error: apiservertesting.ErrUnauthorized},
+		}})
+	user, err := s.State.User("other")
+	c.Assert(err, gc.IsNil)
+	c.Assert(user.PasswordValid("password"), gc.Equals, true)
+}
+
+func (s *userManagerSuite) TestLoginLockouts(c *gc.C) {
+	now := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	_, err := s.State.RecordLoginFailure(state.LoginFailureUser, "user-foobar", now)
+	c.Assert(err, gc.IsNil)
+	err = s.State.LockLogins(state.LoginFailureUser, "user-foobar", now.Add(time.Hour))
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.RecordLoginFailure(state.LoginFailureAddress, "10.0.0.1", now)
+	c.Assert(err, gc.IsNil)
+
+	result, err := s.usermanager.LoginLockouts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Lockouts, gc.HasLen, 2)
+	c.Assert(result.Lockouts[0].Kind, gc.Equals, "address")
+	c.Assert(result.Lockouts[0].Name, gc.Equals, "10.0.0.1")
+	c.Assert(result.Lockouts[0].Failures, gc.Equals, 1)
+	c.Assert(result.Lockouts[0].LockedUntil.IsZero(), gc.Equals, true)
+	c.Assert(result.Lockouts[1].Kind, gc.Equals, "user")
+	c.Assert(result.Lockouts[1].Name, gc.Equals, "user-foobar")
+	c.Assert(result.Lockouts[1].LastFailure.Equal(now), gc.Equals, true)
+	c.Assert(result.Lockouts[1].LockedUntil.Equal(now.Add(time.Hour)), gc.Equals, true)
+}
+
+func (s *userManagerSuite) TestClearLoginLockouts(c *gc.C) {
+	admin, err := s.State.User(state.AdminUser)
+	c.Assert(err, gc.IsNil)
+	s.authorizer.Entity = admin
+	usermanager, err := usermanager.NewUserManagerAPI(s.State, s.authorizer)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.RecordLoginFailure(state.LoginFailureUser, "user-foobar", time.Now())
+	c.Assert(err, gc.IsNil)
+
+	args := params.LoginLockoutIds{Ids: []params.LoginLockoutId{
+		{Kind: "user", Name: "user-foobar"},
+		{Kind: "address", Name: "10.0.0.1"},
+		{Kind: "bad", Name: "foo"},
+	}}
+	result, err := usermanager.ClearLoginLockouts(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Results, gc.HasLen, 3)
+	c.Assert(result.Results[0].Error, gc.IsNil)
+	c.Assert(result.Results[1].Error, gc.ErrorMatches, `login failures for address "10.0.0.1" not found`)
+	c.Assert(result.Results[2].Error, gc.ErrorMatches, `invalid login lockout kind "bad"`)
+	f, err := s.State.LoginFailures(state.LoginFailureUser, "user-foobar")
+	c.Assert(err, gc.IsNil)
+	c.Assert(f.Count, gc.Equals, 0)
+}
+
+func (s *userManagerSuite) TestLoginLockoutsAdminOnly(c *gc.C) {
+	_, err := s.State.AddUser("foobar", "password")
+	c.Assert(err, gc.IsNil)
+	authorizer := s.authorizer
+	authorizer.Tag = "user-foobar"
+	usermanager, err := usermanager.NewUserManagerAPI(s.State, authorizer)
+	c.Assert(err, gc.IsNil)
+
+	_, err = usermanager.LoginLockouts()
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	_, err = usermanager.ClearLoginLockouts(params.LoginLockoutIds{})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
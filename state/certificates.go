@@ -0,0 +1,345 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/juju/errors"
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+
+	"github.com/juju/core/names"
+)
+
+// caCertDoc holds the fields of the state serving info document that
+// are used while the environment's certificates are rotated.
+type caCertDoc struct {
+	CACert            string `bson:"cacert"`
+	PendingPrivateKey string `bson:"pendingprivatekey"`
+
+	// AcknowledgedCACert holds the hash of the CA certificates that
+	// all running agents had acknowledged when the state server
+	// certificate was last switched. Their acknowledgements are no
+	// longer kept.
+	AcknowledgedCACert string `bson:"acknowledgedcacert,omitempty"`
+}
+
+// publishedCACert returns the CA certificates agents are to trust.
+func (st *State) publishedCACert(doc *caCertDoc) string {
+	if doc.CACert == "" {
+		return st.info.CACert
+	}
+	return doc.CACert
+}
+
+func (st *State) caCertDoc() (*caCertDoc, error) {
+	var doc caCertDoc
+	err := st.stateServers.FindId(stateServingInfoKey).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("state serving info")
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot get state serving info: %v", err)
+	}
+	return &doc, nil
+}
+
+// CACert returns the certificate used to validate the state connection.
+// Once the CA certificates have been published with PublishCACert, this
+// is the published bundle. The certificate is cached, and refreshed
+// whenever the state serving info changes.
+func (st *State) CACert() string {
+	st.caCertMu.Lock()
+	defer st.caCertMu.Unlock()
+	return st.caCert
+}
+
+// refreshCACert reads the published CA certificate into the cache.
+func (st *State) refreshCACert() error {
+	doc, err := st.caCertDoc()
+	if errors.IsNotFound(err) {
+		doc = &caCertDoc{}
+	} else if err != nil {
+		return err
+	}
+	st.setCACert(doc.CACert)
+	return nil
+}
+
+func (st *State) setCACert(caCert string) {
+	if caCert == "" {
+		caCert = st.info.CACert
+	}
+	st.caCertMu.Lock()
+	defer st.caCertMu.Unlock()
+	st.caCert = caCert
+}
+
+// PublishCACert publishes the CA certificates that agents and clients
+// trust. It is the first phase of rotating the environment's
+// certificates: the bundle must hold both the CA certificate that
+// signed the current state server certificate and, first, the one that
+// will sign its replacement. Once the agents have acknowledged the
+// bundle, the state server certificate is switched with
+// SetStateServerCert.
+func (st *State) PublishCACert(caCert string) error {
+	if caCert == "" {
+		return fmt.Errorf("cannot publish an empty CA certificate")
+	}
+	ops := []txn.Op{{
+		C:      st.stateServers.Name,
+		Id:     stateServingInfoKey,
+		Assert: txn.DocExists,
+		Update: bson.D{{"$set", bson.D{{"cacert", caCert}}}},
+	}}
+	if err := st.runTransaction(ops); err != nil {
+		return fmt.Errorf("cannot publish CA certificate: %v", onAbort(err, errors.NotFoundf("state serving info")))
+	}
+	st.setCACert(caCert)
+	return nil
+}
+
+// SetPendingStateServerKey records the private key that the next state
+// server certificate is to be issued for. The private key never leaves
+// the state servers; only a certificate for its public key is given to
+// SetStateServerCert.
+func (st *State) SetPendingStateServerKey(privateKey string) error {
+	if privateKey == "" {
+		return fmt.Errorf("cannot set an empty private key")
+	}
+	ops := []txn.Op{{
+		C:      st.stateServers.Name,
+		Id:     stateServingInfoKey,
+		Assert: txn.DocExists,
+		Update: bson.D{{"$set", bson.D{{"pendingprivatekey", privateKey}}}},
+	}}
+	if err := st.runTransaction(ops); err != nil {
+		return fmt.Errorf("cannot set pending state server key: %v", onAbort(err, errors.NotFoundf("state serving info")))
+	}
+	return nil
+}
+
+// PendingStateServerKey returns the private key recorded with
+// SetPendingStateServerKey. It returns a not found error if there
+// is none.
+func (st *State) PendingStateServerKey() (string, error) {
+	doc, err := st.caCertDoc()
+	if err != nil {
+		return "", err
+	}
+	if doc.PendingPrivateKey == "" {
+		return "", errors.NotFoundf("pending state server key")
+	}
+	return doc.PendingPrivateKey, nil
+}
+
+// SetStateServerCert switches the certificate served by the state
+// servers to cert, issued for the given pending private key. It is the
+// second phase of rotating the environment's certificates, and fails
+// if the pending private key has changed in the meantime. All running
+// agents are expected to have acknowledged the published CA
+// certificates, so the acknowledgements are removed.
+func (st *State) SetStateServerCert(cert, privateKey string) error {
+	if cert == "" || privateKey == "" {
+		return fmt.Errorf("incomplete state server certificate")
+	}
+	doc, err := st.caCertDoc()
+	if err != nil {
+		return fmt.Errorf("cannot set state server certificate: %v", err)
+	}
+	hash := caCertHash(st.publishedCACert(doc))
+	ops := []txn.Op{{
+		C:      st.stateServers.Name,
+		Id:     stateServingInfoKey,
+		Assert: bson.D{{"pendingprivatekey", privateKey}},
+		Update: bson.D{
+			{"$set", bson.D{
+				{"cert", cert},
+				{"privatekey", privateKey},
+				{"acknowledgedcacert", hash},
+			}},
+			{"$unset", bson.D{{"pendingprivatekey", 1}}},
+		},
+	}}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		return fmt.Errorf("cannot set state server certificate: pending state server key changed")
+	} else if err != nil {
+		return fmt.Errorf("cannot set state server certificate: %v", err)
+	}
+	if err := st.removeCACertAcks(hash); err != nil {
+		logger.Warningf("cannot remove CA certificate acknowledgements: %v", err)
+	}
+	return nil
+}
+
+// removeCACertAcks removes the acknowledgements of the CA
+// certificates with the given hash. Acknowledgements of other
+// certificates, published since, are kept.
+func (st *State) removeCACertAcks(hash string) error {
+	var acks []caCertAckDoc
+	if err := st.caCertAcks.Find(bson.D{{"hash", hash}}).All(&acks); err != nil {
+		return err
+	}
+	if len(acks) == 0 {
+		return nil
+	}
+	ops := make([]txn.Op, len(acks))
+	for i, ack := range acks {
+		ops[i] = removeCACertAckOp(st, ack.Tag)
+		ops[i].Assert = bson.D{{"hash", hash}}
+	}
+	// The transaction aborts if an agent acknowledged other
+	// certificates meanwhile; the acknowledgements are
+	// removed by the next rotation instead.
+	return onAbort(st.runTransaction(ops), nil)
+}
+
+// removeCACertAckOp returns the operation that removes the
+// acknowledgement of the agent of the entity with the given tag.
+func removeCACertAckOp(st *State, tag string) txn.Op {
+	return txn.Op{
+		C:      st.caCertAcks.Name,
+		Id:     tag,
+		Remove: true,
+	}
+}
+
+// caCertAckDoc records the CA certificates last acknowledged by the
+// agent of a machine or unit. Only a hash of the bundle is kept.
+type caCertAckDoc struct {
+	Tag  string `bson:"_id"`
+	Hash string
+}
+
+func caCertHash(caCert string) string {
+	sum := sha256.Sum256([]byte(caCert))
+	return hex.EncodeToString(sum[:])
+}
+
+// AcknowledgeCACert records that the agent of the machine or unit with
+// the given tag trusts the given CA certificates.
+func (st *State) AcknowledgeCACert(tag, caCert string) error {
+	kind, err := names.TagKind(tag)
+	if err != nil {
+		return err
+	}
+	if kind != names.MachineTagKind && kind != names.UnitTagKind {
+		return fmt.Errorf("cannot acknowledge CA certificate for %q: not an agent", tag)
+	}
+	hash := caCertHash(caCert)
+	doc, err := st.caCertDoc()
+	if errors.IsNotFound(err) {
+		doc = &caCertDoc{}
+	} else if err != nil {
+		return fmt.Errorf("cannot acknowledge CA certificate for %q: %v", tag, err)
+	}
+	if hash == doc.AcknowledgedCACert {
+		// The rotation to these certificates is complete.
+		return nil
+	}
+	// A racing acknowledgement may create the document between
+	// our attempts, in which case the second attempt updates it.
+	for i := 0; i < 2; i++ {
+		var doc caCertAckDoc
+		err := st.caCertAcks.FindId(tag).One(&doc)
+		op := txn.Op{
+			C:  st.caCertAcks.Name,
+			Id: tag,
+		}
+		switch {
+		case err == mgo.ErrNotFound:
+			op.Assert = txn.DocMissing
+			op.Insert = &caCertAckDoc{Tag: tag, Hash: hash}
+		case err != nil:
+			return fmt.Errorf("cannot acknowledge CA certificate for %q: %v", tag, err)
+		case doc.Hash == hash:
+			return nil
+		default:
+			op.Assert = txn.DocExists
+			op.Update = bson.D{{"$set", bson.D{{"hash", hash}}}}
+		}
+		if err := st.runTransaction([]txn.Op{op}); err != txn.ErrAborted {
+			if err != nil {
+				return fmt.Errorf("cannot acknowledge CA certificate for %q: %v", tag, err)
+			}
+			return nil
+		}
+	}
+	return ErrExcessiveContention
+}
+
+// agentEntity is implemented by the entities that run agents.
+type agentEntity interface {
+	Tag() string
+	Life() Life
+	AgentAlive() (bool, error)
+}
+
+// AgentsPendingCACert returns the tags of the machines and units whose
+// agents are running but have not yet acknowledged the CA certificates
+// currently published. Agents that are not running are not waited
+// for; they pick up the CA certificates when they next connect, as
+// long as the certificate they trust still verifies the state servers.
+// Once the state server certificate has been switched, no agents are
+// pending until new CA certificates are published.
+func (st *State) AgentsPendingCACert() ([]string, error) {
+	doc, err := st.caCertDoc()
+	if err != nil {
+		return nil, err
+	}
+	hash := caCertHash(st.publishedCACert(doc))
+	if hash == doc.AcknowledgedCACert {
+		return nil, nil
+	}
+	var acks []caCertAckDoc
+	err = st.caCertAcks.Find(bson.D{{"hash", hash}}).All(&acks)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get CA certificate acknowledgements: %v", err)
+	}
+	acked := make(map[string]bool)
+	for _, ack := range acks {
+		acked[ack.Tag] = true
+	}
+
+	var entities []agentEntity
+	machines, err := st.AllMachines()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range machines {
+		entities = append(entities, m)
+	}
+	services, err := st.AllServices()
+	if err != nil {
+		return nil, err
+	}
+	for _, svc := range services {
+		units, err := svc.AllUnits()
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range units {
+			entities = append(entities, u)
+		}
+	}
+	var pending []string
+	for _, entity := range entities {
+		if entity.Life() == Dead || acked[entity.Tag()] {
+			continue
+		}
+		alive, err := entity.AgentAlive()
+		if err != nil {
+			return nil, err
+		}
+		if alive {
+			pending = append(pending, entity.Tag())
+		}
+	}
+	sort.Strings(pending)
+	return pending, nil
+}
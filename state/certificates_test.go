@@ -0,0 +1,245 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/state/presence"
+	statetesting "github.com/juju/core/state/testing"
+	"github.com/juju/core/testing"
+)
+
+type CertificatesSuite struct {
+	ConnSuite
+	info params.StateServingInfo
+}
+
+var _ = gc.Suite(&CertificatesSuite{})
+
+func (s *CertificatesSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.info = params.StateServingInfo{
+		APIPort:      69,
+		StatePort:    80,
+		Cert:         "Some cert",
+		PrivateKey:   "Some key",
+		SharedSecret: "Some Keyfile",
+	}
+	err := s.State.SetStateServingInfo(s.info)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *CertificatesSuite) TestPublishCACert(c *gc.C) {
+	c.Assert(s.State.CACert(), gc.Equals, testing.CACert)
+	err := s.State.PublishCACert("New CA cert")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.State.CACert(), gc.Equals, "New CA cert")
+
+	// The state server certificate is left alone.
+	info, err := s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, jc.DeepEquals, s.info)
+
+	// Setting the serving info again leaves the CA certificate alone.
+	err = s.State.SetStateServingInfo(info)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.State.CACert(), gc.Equals, "New CA cert")
+
+	err = s.State.PublishCACert("")
+	c.Assert(err, gc.ErrorMatches, "cannot publish an empty CA certificate")
+}
+
+func (s *CertificatesSuite) TestCACertRefreshed(c *gc.C) {
+	st, err := state.Open(state.TestingStateInfo(), state.TestingDialOpts(), state.Policy(nil))
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+	c.Assert(st.CACert(), gc.Equals, testing.CACert)
+
+	// The other state's cached certificate is refreshed
+	// once it observes the change.
+	w := st.WatchCACert()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, st, w)
+	wc.AssertOneChange()
+	err = s.State.PublishCACert("New CA cert")
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+	c.Assert(st.CACert(), gc.Equals, "New CA cert")
+}
+
+func (s *CertificatesSuite) TestSetStateServerCert(c *gc.C) {
+	_, err := s.State.PendingStateServerKey()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	err = s.State.SetStateServerCert("New cert", "New key")
+	c.Assert(err, gc.ErrorMatches, "cannot set state server certificate: pending state server key changed")
+
+	err = s.State.SetPendingStateServerKey("New key")
+	c.Assert(err, gc.IsNil)
+	key, err := s.State.PendingStateServerKey()
+	c.Assert(err, gc.IsNil)
+	c.Assert(key, gc.Equals, "New key")
+
+	// Until the certificate is switched, the old one is served.
+	info, err := s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, jc.DeepEquals, s.info)
+
+	err = s.State.SetStateServerCert("New cert", "Other key")
+	c.Assert(err, gc.ErrorMatches, "cannot set state server certificate: pending state server key changed")
+	err = s.State.SetStateServerCert("New cert", "New key")
+	c.Assert(err, gc.IsNil)
+	info, err = s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	s.info.Cert = "New cert"
+	s.info.PrivateKey = "New key"
+	c.Assert(info, jc.DeepEquals, s.info)
+
+	// The pending key is only used once.
+	_, err = s.State.PendingStateServerKey()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	c.Assert(s.State.CACert(), gc.Equals, testing.CACert)
+}
+
+func (s *CertificatesSuite) TestAgentsPendingCACert(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	_, err = svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+
+	// Agents that are not running are not waited for.
+	pending, err := s.State.AgentsPendingCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.HasLen, 0)
+
+	for _, entity := range []interface {
+		SetAgentAlive() (*presence.Pinger, error)
+		WaitAgentAlive(time.Duration) error
+	}{machine, unit} {
+		pinger, err := entity.SetAgentAlive()
+		c.Assert(err, gc.IsNil)
+		defer pinger.Stop()
+		s.State.StartSync()
+		err = entity.WaitAgentAlive(testing.LongWait)
+		c.Assert(err, gc.IsNil)
+	}
+	pending, err = s.State.AgentsPendingCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.DeepEquals, []string{"machine-0", "unit-wordpress-0"})
+
+	err = s.State.AcknowledgeCACert(machine.Tag(), testing.CACert)
+	c.Assert(err, gc.IsNil)
+	pending, err = s.State.AgentsPendingCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.DeepEquals, []string{"unit-wordpress-0"})
+
+	// Publishing new CA certificates needs them to be acknowledged again.
+	err = s.State.PublishCACert("New CA cert")
+	c.Assert(err, gc.IsNil)
+	err = s.State.AcknowledgeCACert(unit.Tag(), "New CA cert")
+	c.Assert(err, gc.IsNil)
+	pending, err = s.State.AgentsPendingCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.DeepEquals, []string{"machine-0"})
+	err = s.State.AcknowledgeCACert(machine.Tag(), "New CA cert")
+	c.Assert(err, gc.IsNil)
+	pending, err = s.State.AgentsPendingCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.HasLen, 0)
+
+	err = s.State.AcknowledgeCACert("service-wordpress", "New CA cert")
+	c.Assert(err, gc.ErrorMatches, `cannot acknowledge CA certificate for "service-wordpress": not an agent`)
+}
+
+func (s *CertificatesSuite) TestSetStateServerCertRemovesAcks(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	pinger, err := machine.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+	s.State.StartSync()
+	err = machine.WaitAgentAlive(testing.LongWait)
+	c.Assert(err, gc.IsNil)
+
+	err = s.State.PublishCACert("New CA cert")
+	c.Assert(err, gc.IsNil)
+	err = s.State.AcknowledgeCACert(machine.Tag(), "New CA cert")
+	c.Assert(err, gc.IsNil)
+	err = s.State.AcknowledgeCACert("unit-wordpress-0", "Old CA cert")
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetPendingStateServerKey("New key")
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetStateServerCert("New cert", "New key")
+	c.Assert(err, gc.IsNil)
+
+	// Only acknowledgements of other certificates are kept.
+	count, err := state.CACertAckCount(s.State)
+	c.Assert(err, gc.IsNil)
+	c.Assert(count, gc.Equals, 1)
+
+	// The rotation is complete, so no agents are pending,
+	// and acknowledging the certificates records nothing.
+	pending, err := s.State.AgentsPendingCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.HasLen, 0)
+	err = s.State.AcknowledgeCACert(machine.Tag(), "New CA cert")
+	c.Assert(err, gc.IsNil)
+	count, err = state.CACertAckCount(s.State)
+	c.Assert(err, gc.IsNil)
+	c.Assert(count, gc.Equals, 1)
+
+	// Publishing new certificates starts a new rotation.
+	err = s.State.PublishCACert("Newer CA cert")
+	c.Assert(err, gc.IsNil)
+	pending, err = s.State.AgentsPendingCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.DeepEquals, []string{machine.Tag()})
+	err = s.State.AcknowledgeCACert(machine.Tag(), "Newer CA cert")
+	c.Assert(err, gc.IsNil)
+	pending, err = s.State.AgentsPendingCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.HasLen, 0)
+
+	// Acknowledgements are removed with the entity.
+	err = machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = machine.Remove()
+	c.Assert(err, gc.IsNil)
+	count, err = state.CACertAckCount(s.State)
+	c.Assert(err, gc.IsNil)
+	c.Assert(count, gc.Equals, 1)
+}
+
+func (s *CertificatesSuite) TestWatchCACert(c *gc.C) {
+	w := s.State.WatchCACert()
+	defer statetesting.AssertStop(c, w)
+
+	// Initial event.
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+
+	err := s.State.PublishCACert("New CA cert")
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	err = s.State.SetPendingStateServerKey("New key")
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+	err = s.State.SetStateServerCert("New cert", "New key")
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	// Stop, check closed.
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
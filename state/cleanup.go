@@ -9,6 +9,8 @@ import (
 	"github.com/juju/errors"
 	"labix.org/v2/mgo/bson"
 	"labix.org/v2/mgo/txn"
+
+	"github.com/juju/core/state/presence"
 )
 
 type cleanupKind string
@@ -21,6 +23,8 @@ const (
 	cleanupRemovedUnit                 cleanupKind = "removedUnit"
 	cleanupServicesForDyingEnvironment cleanupKind = "services"
 	cleanupForceDestroyedMachine       cleanupKind = "machine"
+	cleanupSettingsHistory             cleanupKind = "settingsHistory"
+	cleanupResourceContent             cleanupKind = "resourceContent"
 )
 
 // cleanupDoc represents a potentially large set of documents that should be
@@ -77,6 +81,12 @@ func (st *State) Cleanup() error {
 			err = st.cleanupServicesForDyingEnvironment()
 		case cleanupForceDestroyedMachine:
 			err = st.cleanupForceDestroyedMachine(doc.Prefix)
+		case cleanupSettingsHistory:
+			err = st.cleanupSettingsHistory(doc.Prefix)
+		case cleanupResourceContent:
+			// Resource content lives in provider storage;
+			// see CleanupResourceContent.
+			continue
 		default:
 			err = fmt.Errorf("unknown cleanup kind %q", doc.Kind)
 		}
@@ -196,7 +206,7 @@ func (st *State) cleanupRemovedUnit(name string) error {
 			return err
 		}
 	}
-	return nil
+	return presence.RemoveInfo(st.presence, unitGlobalKey(name))
 }
 
 // cleanupForceDestroyedMachine systematically destroys and removes all entities
@@ -0,0 +1,194 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+
+	"github.com/juju/core/instance"
+	"github.com/juju/core/version"
+)
+
+// ContainerTemplate describes a template that containers are cloned
+// from, cached on the machine hosting the containers.
+type ContainerTemplate struct {
+	Name    string
+	Type    instance.ContainerType
+	Series  string
+	Arch    string
+	Tools   version.Number
+	Created time.Time
+}
+
+// ContainerTemplatePurge holds a request to purge the container
+// templates cached on a machine. Revno is increased for every request;
+// the machine agent reports the last Revno it acted upon.
+type ContainerTemplatePurge struct {
+	Revno int
+	// Names holds the names of the templates to purge. All the
+	// templates are purged if it is empty.
+	Names []string `bson:",omitempty"`
+}
+
+// containerTemplatesDoc records the container templates cached on a
+// machine, as last reported by its agent, and the requests made to
+// purge them. The document ID is the machine id.
+type containerTemplatesDoc struct {
+	Id          string `bson:"_id"`
+	Templates   []ContainerTemplate
+	Reported    time.Time
+	PurgedRevno int
+	Purge       ContainerTemplatePurge
+}
+
+func (m *Machine) containerTemplatesDoc() (*containerTemplatesDoc, error) {
+	var doc containerTemplatesDoc
+	err := m.st.templates.FindId(m.doc.Id).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot get container templates of machine %s: %v", m, err)
+	}
+	return &doc, nil
+}
+
+// ContainerTemplates returns the container templates cached on the
+// machine, and the time they were last reported by the machine agent.
+// The time is zero if the agent has never reported its templates.
+func (m *Machine) ContainerTemplates() ([]ContainerTemplate, time.Time, error) {
+	doc, err := m.containerTemplatesDoc()
+	if err != nil || doc == nil {
+		return nil, time.Time{}, err
+	}
+	return doc.Templates, doc.Reported, nil
+}
+
+// SetContainerTemplates records the container templates cached on the
+// machine, and the Revno of the last purge request the machine agent
+// acted upon.
+func (m *Machine) SetContainerTemplates(templates []ContainerTemplate, purgedRevno int) error {
+	now := time.Now()
+	// A racing purge request may create the document between our
+	// attempts, in which case the second attempt updates it.
+	for i := 0; i < 2; i++ {
+		doc, err := m.containerTemplatesDoc()
+		if err != nil {
+			return err
+		}
+		op := txn.Op{
+			C:  m.st.templates.Name,
+			Id: m.doc.Id,
+		}
+		if doc == nil {
+			op.Assert = txn.DocMissing
+			op.Insert = &containerTemplatesDoc{
+				Id:          m.doc.Id,
+				Templates:   templates,
+				Reported:    now,
+				PurgedRevno: purgedRevno,
+			}
+		} else {
+			op.Assert = txn.DocExists
+			op.Update = bson.D{{"$set", bson.D{
+				{"templates", templates},
+				{"reported", now},
+				{"purgedrevno", purgedRevno},
+			}}}
+		}
+		ops := []txn.Op{{
+			C:      m.st.machines.Name,
+			Id:     m.doc.Id,
+			Assert: isAliveDoc,
+		}, op}
+		if err := m.st.runTransaction(ops); err != txn.ErrAborted {
+			if err != nil {
+				return fmt.Errorf("cannot set container templates of machine %s: %v", m, err)
+			}
+			return nil
+		}
+		if err := m.Refresh(); err != nil {
+			return err
+		}
+		if m.doc.Life != Alive {
+			return fmt.Errorf("cannot set container templates of machine %s: machine is not alive", m)
+		}
+	}
+	return ErrExcessiveContention
+}
+
+// RequestContainerTemplatePurge asks the machine agent to purge the
+// named container templates cached on the machine, or all of them if
+// no names are given.
+func (m *Machine) RequestContainerTemplatePurge(names ...string) error {
+	for i := 0; i < 2; i++ {
+		doc, err := m.containerTemplatesDoc()
+		if err != nil {
+			return err
+		}
+		op := txn.Op{
+			C:  m.st.templates.Name,
+			Id: m.doc.Id,
+		}
+		if doc == nil {
+			op.Assert = txn.DocMissing
+			op.Insert = &containerTemplatesDoc{
+				Id:    m.doc.Id,
+				Purge: ContainerTemplatePurge{Revno: 1, Names: names},
+			}
+		} else {
+			op.Assert = bson.D{{"purge.revno", doc.Purge.Revno}}
+			op.Update = bson.D{{"$set", bson.D{
+				{"purge", ContainerTemplatePurge{Revno: doc.Purge.Revno + 1, Names: names}},
+			}}}
+		}
+		ops := []txn.Op{{
+			C:      m.st.machines.Name,
+			Id:     m.doc.Id,
+			Assert: isAliveDoc,
+		}, op}
+		if err := m.st.runTransaction(ops); err != txn.ErrAborted {
+			if err != nil {
+				return fmt.Errorf("cannot purge container templates of machine %s: %v", m, err)
+			}
+			return nil
+		}
+		if err := m.Refresh(); err != nil {
+			return err
+		}
+		if m.doc.Life != Alive {
+			return fmt.Errorf("cannot purge container templates of machine %s: machine is not alive", m)
+		}
+	}
+	return ErrExcessiveContention
+}
+
+// ContainerTemplatePurge returns the latest request to purge the
+// container templates cached on the machine, and the Revno of the last
+// request the machine agent acted upon.
+func (m *Machine) ContainerTemplatePurge() (purge ContainerTemplatePurge, purgedRevno int, err error) {
+	doc, err := m.containerTemplatesDoc()
+	if err != nil || doc == nil {
+		return ContainerTemplatePurge{}, 0, err
+	}
+	return doc.Purge, doc.PurgedRevno, nil
+}
+
+// WatchContainerTemplates returns a watcher that notifies when the
+// container templates of the machine are reported or purged.
+func (m *Machine) WatchContainerTemplates() NotifyWatcher {
+	return newEntityWatcher(m.st, m.st.templates, m.doc.Id)
+}
+
+func removeContainerTemplatesOp(st *State, machineId string) txn.Op {
+	return txn.Op{
+		C:      st.templates.Name,
+		Id:     machineId,
+		Remove: true,
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/instance"
+	"github.com/juju/core/state"
+	statetesting "github.com/juju/core/state/testing"
+	"github.com/juju/core/version"
+)
+
+type ContainerTemplatesSuite struct {
+	ConnSuite
+	machine *state.Machine
+}
+
+var _ = gc.Suite(&ContainerTemplatesSuite{})
+
+func (s *ContainerTemplatesSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+}
+
+var testContainerTemplates = []state.ContainerTemplate{{
+	Name:    "juju-precise-amd64-1.19.4-template",
+	Type:    instance.LXC,
+	Series:  "precise",
+	Arch:    "amd64",
+	Tools:   version.MustParse("1.19.4"),
+	Created: time.Date(2014, 6, 1, 12, 0, 0, 0, time.UTC),
+}, {
+	Name:    "juju-trusty-amd64-1.19.4-template",
+	Type:    instance.KVM,
+	Series:  "trusty",
+	Arch:    "amd64",
+	Tools:   version.MustParse("1.19.4"),
+	Created: time.Date(2014, 6, 2, 12, 0, 0, 0, time.UTC),
+}}
+
+func (s *ContainerTemplatesSuite) assertTemplates(c *gc.C, expected []state.ContainerTemplate) {
+	templates, reported, err := s.machine.ContainerTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(reported.IsZero(), gc.Equals, false)
+	c.Assert(templates, gc.HasLen, len(expected))
+	for i, template := range templates {
+		c.Assert(template.Created.Equal(expected[i].Created), gc.Equals, true)
+		template.Created = expected[i].Created
+		c.Assert(template, gc.DeepEquals, expected[i])
+	}
+}
+
+func (s *ContainerTemplatesSuite) TestSetContainerTemplates(c *gc.C) {
+	templates, reported, err := s.machine.ContainerTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 0)
+	c.Assert(reported.IsZero(), gc.Equals, true)
+
+	err = s.machine.SetContainerTemplates(testContainerTemplates, 0)
+	c.Assert(err, gc.IsNil)
+	s.assertTemplates(c, testContainerTemplates)
+
+	err = s.machine.SetContainerTemplates(testContainerTemplates[1:], 0)
+	c.Assert(err, gc.IsNil)
+	s.assertTemplates(c, testContainerTemplates[1:])
+}
+
+func (s *ContainerTemplatesSuite) TestRequestContainerTemplatePurge(c *gc.C) {
+	purge, purgedRevno, err := s.machine.ContainerTemplatePurge()
+	c.Assert(err, gc.IsNil)
+	c.Assert(purge, gc.DeepEquals, state.ContainerTemplatePurge{})
+	c.Assert(purgedRevno, gc.Equals, 0)
+
+	err = s.machine.RequestContainerTemplatePurge(testContainerTemplates[0].Name)
+	c.Assert(err, gc.IsNil)
+	purge, purgedRevno, err = s.machine.ContainerTemplatePurge()
+	c.Assert(err, gc.IsNil)
+	c.Assert(purge, gc.DeepEquals, state.ContainerTemplatePurge{
+		Revno: 1,
+		Names: []string{testContainerTemplates[0].Name},
+	})
+	c.Assert(purgedRevno, gc.Equals, 0)
+
+	// The agent reports the templates left after the purge.
+	err = s.machine.SetContainerTemplates(testContainerTemplates[1:], 1)
+	c.Assert(err, gc.IsNil)
+	s.assertTemplates(c, testContainerTemplates[1:])
+
+	err = s.machine.RequestContainerTemplatePurge()
+	c.Assert(err, gc.IsNil)
+	purge, purgedRevno, err = s.machine.ContainerTemplatePurge()
+	c.Assert(err, gc.IsNil)
+	c.Assert(purge, gc.DeepEquals, state.ContainerTemplatePurge{Revno: 2})
+	c.Assert(purgedRevno, gc.Equals, 1)
+}
+
+func (s *ContainerTemplatesSuite) TestContainerTemplatesDeadMachine(c *gc.C) {
+	err := s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.SetContainerTemplates(testContainerTemplates, 0)
+	c.Assert(err, gc.ErrorMatches, "cannot set container templates of machine 0: machine is not alive")
+	err = s.machine.RequestContainerTemplatePurge()
+	c.Assert(err, gc.ErrorMatches, "cannot purge container templates of machine 0: machine is not alive")
+}
+
+func (s *ContainerTemplatesSuite) TestRemoveMachineRemovesContainerTemplates(c *gc.C) {
+	err := s.machine.SetContainerTemplates(testContainerTemplates, 0)
+	c.Assert(err, gc.IsNil)
+	err = s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.Remove()
+	c.Assert(err, gc.IsNil)
+
+	// The templates were removed with the machine.
+	templates, _, err := s.machine.ContainerTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 0)
+}
+
+func (s *ContainerTemplatesSuite) TestWatchContainerTemplates(c *gc.C) {
+	w := s.machine.WatchContainerTemplates()
+	defer statetesting.AssertStop(c, w)
+
+	// Initial event.
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+
+	err := s.machine.RequestContainerTemplatePurge()
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	err = s.machine.SetContainerTemplates(nil, 1)
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	// Other machines' templates are not reported.
+	other, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = other.RequestContainerTemplatePurge()
+	c.Assert(err, gc.IsNil)
+	wc.AssertNoChange()
+}
@@ -203,6 +203,19 @@ func ClearInstanceDocId(c *gc.C, m *Machine) {
 	c.Assert(err, gc.IsNil)
 }
 
+// SetRolloutUpgrading sets the names of the units recorded as
+// upgrading in the service's rolling upgrade.
+func SetRolloutUpgrading(c *gc.C, s *Service, upgrading []string) {
+	ops := []txn.Op{{
+		C:      s.st.services.Name,
+		Id:     s.doc.Name,
+		Assert: txn.DocExists,
+		Update: bson.D{{"$set", bson.D{{"rollout.upgrading", upgrading}}}},
+	}}
+	err := s.st.runTransaction(ops)
+	c.Assert(err, gc.IsNil)
+}
+
 // SCHEMACHANGE
 // This method is used to reset the ownertag attribute
 func SetServiceOwnerTag(s *Service, ownerTag string) {
@@ -266,3 +279,11 @@ var StateServerAvailable = &stateServerAvailable
 func UnitConstraints(u *Unit) (*constraints.Value, error) {
 	return u.constraints()
 }
+
+var MaxWebhookFailures = &maxWebhookFailures
+
+// CACertAckCount returns the number of recorded CA certificate
+// acknowledgements.
+func CACertAckCount(st *State) (int, error) {
+	return st.caCertAcks.Count()
+}
@@ -0,0 +1,207 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+)
+
+// LoginFailureKind identifies what failed logins are counted against.
+type LoginFailureKind string
+
+const (
+	// LoginFailureUser counts failed logins against the entity
+	// whose credentials were presented.
+	LoginFailureUser LoginFailureKind = "user"
+
+	// LoginFailureAddress counts failed logins against the
+	// remote address they were made from.
+	LoginFailureAddress LoginFailureKind = "address"
+)
+
+// LoginFailures holds the failed logins recently recorded
+// against a user or a remote address, along with the login
+// attempts that are still in progress.
+type LoginFailures struct {
+	Kind        LoginFailureKind
+	Name        string
+	Count       int
+	LastFailure time.Time
+	LockedUntil time.Time
+	Pending     int
+	LastAttempt time.Time
+}
+
+// Locked returns whether logins were locked out at the given time.
+func (f *LoginFailures) Locked(now time.Time) bool {
+	return now.Before(f.LockedUntil)
+}
+
+// loginFailuresDoc is stored in the loginfailures collection.
+// Failed logins are counted outside of transactions, as nothing
+// needs to watch them and they must be cheap to record.
+type loginFailuresDoc struct {
+	Id          string `bson:"_id"`
+	Kind        LoginFailureKind
+	Name        string
+	Count       int
+	LastFailure time.Time
+	LockedUntil time.Time
+	Pending     int
+	LastAttempt time.Time
+}
+
+func loginFailuresId(kind LoginFailureKind, name string) string {
+	return string(kind) + ":" + name
+}
+
+func (doc *loginFailuresDoc) loginFailures() LoginFailures {
+	return LoginFailures{
+		Kind:        doc.Kind,
+		Name:        doc.Name,
+		Count:       doc.Count,
+		LastFailure: doc.LastFailure.UTC(),
+		LockedUntil: doc.LockedUntil.UTC(),
+		Pending:     doc.Pending,
+		LastAttempt: doc.LastAttempt.UTC(),
+	}
+}
+
+// LoginFailures returns the failed logins recorded against the given
+// user or remote address. If none have been recorded, a value with a
+// zero Count is returned.
+func (st *State) LoginFailures(kind LoginFailureKind, name string) (LoginFailures, error) {
+	var doc loginFailuresDoc
+	err := st.loginFailures.FindId(loginFailuresId(kind, name)).One(&doc)
+	if err == mgo.ErrNotFound {
+		return LoginFailures{Kind: kind, Name: name}, nil
+	}
+	if err != nil {
+		return LoginFailures{}, fmt.Errorf("cannot get login failures for %s %q: %v", kind, name, err)
+	}
+	return doc.loginFailures(), nil
+}
+
+// AllLoginFailures returns all the failed logins recorded
+// against any user or remote address.
+func (st *State) AllLoginFailures() ([]LoginFailures, error) {
+	var docs []loginFailuresDoc
+	if err := st.loginFailures.Find(nil).Sort("_id").All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get login failures: %v", err)
+	}
+	all := make([]LoginFailures, len(docs))
+	for i := range docs {
+		all[i] = docs[i].loginFailures()
+	}
+	return all, nil
+}
+
+// RecordLoginFailure records a failed login against the given user or
+// remote address at the given time, and returns the updated record.
+func (st *State) RecordLoginFailure(kind LoginFailureKind, name string, when time.Time) (LoginFailures, error) {
+	change := mgo.Change{
+		Update: bson.D{
+			{"$inc", bson.D{{"count", 1}}},
+			{"$set", bson.D{
+				{"kind", kind},
+				{"name", name},
+				{"lastfailure", when.UTC()},
+			}},
+		},
+		Upsert:    true,
+		ReturnNew: true,
+	}
+	var doc loginFailuresDoc
+	if _, err := st.loginFailures.FindId(loginFailuresId(kind, name)).Apply(change, &doc); err != nil {
+		return LoginFailures{}, fmt.Errorf("cannot record login failure for %s %q: %v", kind, name, err)
+	}
+	return doc.loginFailures(), nil
+}
+
+// ReserveLoginAttempt atomically counts a login attempt against the
+// given user or remote address as in progress, and returns the record
+// as it was before, so that concurrent attempts each see the others.
+// The attempt must be released with ReleaseLoginAttempts once it has
+// finished and any failure has been recorded.
+func (st *State) ReserveLoginAttempt(kind LoginFailureKind, name string, when time.Time) (LoginFailures, error) {
+	change := mgo.Change{
+		Update: bson.D{
+			{"$inc", bson.D{{"pending", 1}}},
+			{"$set", bson.D{
+				{"kind", kind},
+				{"name", name},
+				{"lastattempt", when.UTC()},
+			}},
+		},
+		Upsert: true,
+	}
+	doc := loginFailuresDoc{Kind: kind, Name: name}
+	if _, err := st.loginFailures.FindId(loginFailuresId(kind, name)).Apply(change, &doc); err != nil {
+		return LoginFailures{}, fmt.Errorf("cannot reserve login attempt for %s %q: %v", kind, name, err)
+	}
+	return doc.loginFailures(), nil
+}
+
+// ReleaseLoginAttempts counts n login attempts against the given user
+// or remote address as no longer in progress. The record is removed
+// once no attempts are in progress if no failures have been recorded.
+// It returns an error satisfying errors.IsNotFound if fewer attempts
+// are in progress, which happens when the failures have been cleared
+// in the meantime.
+func (st *State) ReleaseLoginAttempts(kind LoginFailureKind, name string, n int) error {
+	id := loginFailuresId(kind, name)
+	err := st.loginFailures.Update(
+		bson.D{{"_id", id}, {"pending", bson.D{{"$gte", n}}}},
+		bson.D{{"$inc", bson.D{{"pending", -n}}}},
+	)
+	if err == mgo.ErrNotFound {
+		return errors.NotFoundf("%d login attempts in progress for %s %q", n, kind, name)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot release login attempts for %s %q: %v", kind, name, err)
+	}
+	err = st.loginFailures.Remove(bson.D{
+		{"_id", id},
+		{"pending", 0},
+		{"count", bson.D{{"$not", bson.D{{"$gt", 0}}}}},
+	})
+	if err != nil && err != mgo.ErrNotFound {
+		return fmt.Errorf("cannot release login attempts for %s %q: %v", kind, name, err)
+	}
+	return nil
+}
+
+// LockLogins locks out logins for the given user or remote address
+// until the given time. Failed logins must already have been recorded
+// against it.
+func (st *State) LockLogins(kind LoginFailureKind, name string, until time.Time) error {
+	err := st.loginFailures.UpdateId(loginFailuresId(kind, name), bson.D{
+		{"$set", bson.D{{"lockeduntil", until.UTC()}}},
+	})
+	if err == mgo.ErrNotFound {
+		return errors.NotFoundf("login failures for %s %q", kind, name)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot lock logins for %s %q: %v", kind, name, err)
+	}
+	return nil
+}
+
+// ClearLoginFailures forgets the failed logins recorded against the
+// given user or remote address, lifting any lockout.
+func (st *State) ClearLoginFailures(kind LoginFailureKind, name string) error {
+	err := st.loginFailures.RemoveId(loginFailuresId(kind, name))
+	if err == mgo.ErrNotFound {
+		return errors.NotFoundf("login failures for %s %q", kind, name)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot clear login failures for %s %q: %v", kind, name, err)
+	}
+	return nil
+}
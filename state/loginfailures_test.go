@@ -0,0 +1,129 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/state"
+)
+
+type LoginFailuresSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&LoginFailuresSuite{})
+
+func (s *LoginFailuresSuite) TestRecordLoginFailure(c *gc.C) {
+	f, err := s.State.LoginFailures(state.LoginFailureUser, "user-bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(f, gc.DeepEquals, state.LoginFailures{Kind: state.LoginFailureUser, Name: "user-bob"})
+
+	now := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 3; i++ {
+		when := now.Add(time.Duration(i) * time.Second)
+		f, err = s.State.RecordLoginFailure(state.LoginFailureUser, "user-bob", when)
+		c.Assert(err, gc.IsNil)
+		c.Assert(f.Count, gc.Equals, i)
+		c.Assert(f.LastFailure.Equal(when), gc.Equals, true)
+	}
+	stored, err := s.State.LoginFailures(state.LoginFailureUser, "user-bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(stored, jc.DeepEquals, f)
+	c.Assert(stored.Locked(now), gc.Equals, false)
+
+	// Failures are counted separately for addresses.
+	f, err = s.State.RecordLoginFailure(state.LoginFailureAddress, "10.0.0.1", now)
+	c.Assert(err, gc.IsNil)
+	c.Assert(f.Count, gc.Equals, 1)
+}
+
+func (s *LoginFailuresSuite) TestLockLogins(c *gc.C) {
+	now := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	err := s.State.LockLogins(state.LoginFailureUser, "user-bob", now.Add(time.Minute))
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	_, err = s.State.RecordLoginFailure(state.LoginFailureUser, "user-bob", now)
+	c.Assert(err, gc.IsNil)
+	err = s.State.LockLogins(state.LoginFailureUser, "user-bob", now.Add(time.Minute))
+	c.Assert(err, gc.IsNil)
+	f, err := s.State.LoginFailures(state.LoginFailureUser, "user-bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(f.LockedUntil.Equal(now.Add(time.Minute)), gc.Equals, true)
+	c.Assert(f.Locked(now), gc.Equals, true)
+	c.Assert(f.Locked(now.Add(time.Minute)), gc.Equals, false)
+}
+
+func (s *LoginFailuresSuite) TestAllAndClearLoginFailures(c *gc.C) {
+	all, err := s.State.AllLoginFailures()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 0)
+
+	now := time.Now()
+	_, err = s.State.RecordLoginFailure(state.LoginFailureUser, "user-bob", now)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.RecordLoginFailure(state.LoginFailureAddress, "10.0.0.1", now)
+	c.Assert(err, gc.IsNil)
+	all, err = s.State.AllLoginFailures()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 2)
+	c.Assert(all[0].Kind, gc.Equals, state.LoginFailureAddress)
+	c.Assert(all[0].Name, gc.Equals, "10.0.0.1")
+	c.Assert(all[1].Kind, gc.Equals, state.LoginFailureUser)
+	c.Assert(all[1].Name, gc.Equals, "user-bob")
+
+	err = s.State.ClearLoginFailures(state.LoginFailureUser, "user-bob")
+	c.Assert(err, gc.IsNil)
+	err = s.State.ClearLoginFailures(state.LoginFailureUser, "user-bob")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	f, err := s.State.LoginFailures(state.LoginFailureUser, "user-bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(f.Count, gc.Equals, 0)
+	all, err = s.State.AllLoginFailures()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 1)
+}
+
+func (s *LoginFailuresSuite) TestReserveAndReleaseLoginAttempts(c *gc.C) {
+	now := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	f, err := s.State.ReserveLoginAttempt(state.LoginFailureUser, "user-bob", now)
+	c.Assert(err, gc.IsNil)
+	c.Assert(f, gc.DeepEquals, state.LoginFailures{Kind: state.LoginFailureUser, Name: "user-bob"})
+
+	// Each reservation sees the attempts already in progress.
+	later := now.Add(time.Second)
+	f, err = s.State.ReserveLoginAttempt(state.LoginFailureUser, "user-bob", later)
+	c.Assert(err, gc.IsNil)
+	c.Assert(f.Pending, gc.Equals, 1)
+	c.Assert(f.LastAttempt.Equal(now), gc.Equals, true)
+	stored, err := s.State.LoginFailures(state.LoginFailureUser, "user-bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(stored.Pending, gc.Equals, 2)
+	c.Assert(stored.LastAttempt.Equal(later), gc.Equals, true)
+
+	_, err = s.State.RecordLoginFailure(state.LoginFailureUser, "user-bob", later)
+	c.Assert(err, gc.IsNil)
+	err = s.State.ReleaseLoginAttempts(state.LoginFailureUser, "user-bob", 2)
+	c.Assert(err, gc.IsNil)
+	err = s.State.ReleaseLoginAttempts(state.LoginFailureUser, "user-bob", 1)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	stored, err = s.State.LoginFailures(state.LoginFailureUser, "user-bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(stored.Pending, gc.Equals, 0)
+	c.Assert(stored.Count, gc.Equals, 1)
+
+	// Attempts that did not fail leave nothing behind.
+	_, err = s.State.ReserveLoginAttempt(state.LoginFailureAddress, "10.0.0.1", now)
+	c.Assert(err, gc.IsNil)
+	err = s.State.ReleaseLoginAttempts(state.LoginFailureAddress, "10.0.0.1", 1)
+	c.Assert(err, gc.IsNil)
+	all, err := s.State.AllLoginFailures()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 1)
+	c.Assert(all[0].Name, gc.Equals, "user-bob")
+}
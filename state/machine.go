@@ -345,7 +345,7 @@ func (m *Machine) setPasswordHash(passwordHash string) error {
 		Id:     m.doc.Id,
 		Assert: notDeadDoc,
 		Update: bson.D{{"$set", bson.D{{"passwordhash", passwordHash}}}},
-	}}
+	}, removePasswordRotationOp(m.st, m.Tag())}
 	if err := m.st.runTransaction(ops); err != nil {
 		return fmt.Errorf("cannot set password of machine %v: %v", m, onAbort(err, errDead))
 	}
@@ -622,6 +622,9 @@ func (m *Machine) Remove() (err error) {
 		removeConstraintsOp(m.st, m.globalKey()),
 		removeRequestedNetworksOp(m.st, m.globalKey()),
 		annotationRemoveOp(m.st, m.globalKey()),
+		removeContainerTemplatesOp(m.st, m.doc.Id),
+		removePasswordRotationOp(m.st, m.Tag()),
+		removeCACertAckOp(m.st, m.Tag()),
 	}
 	ifacesOps, err := m.removeNetworkInterfacesOps()
 	if err != nil {
@@ -631,7 +634,10 @@ func (m *Machine) Remove() (err error) {
 	ops = append(ops, removeContainerRefOps(m.st, m.Id())...)
 	// The only abort conditions in play indicate that the machine has already
 	// been removed.
-	return onAbort(m.st.runTransaction(ops), nil)
+	if err := onAbort(m.st.runTransaction(ops), nil); err != nil {
+		return err
+	}
+	return presence.RemoveInfo(m.st.presence, m.globalKey())
 }
 
 // Refresh refreshes the contents of the machine from the underlying
@@ -676,6 +682,25 @@ func (m *Machine) WaitAgentAlive(timeout time.Duration) (err error) {
 	panic(fmt.Sprintf("presence reported dead status twice in a row for machine %v", m))
 }
 
+// AgentPresence returns the most recently recorded presence details
+// of the agent for machine m, such as when it was last seen alive.
+func (m *Machine) AgentPresence() (presence.Info, error) {
+	info, err := presence.GetInfo(m.st.presence, m.globalKey())
+	if err != nil {
+		return presence.Info{}, fmt.Errorf("cannot get agent presence for machine %v: %v", m, err)
+	}
+	return info, nil
+}
+
+// SetAgentAPIAddress records the address of the API server
+// that the agent for machine m is connected to.
+func (m *Machine) SetAgentAPIAddress(addr string) error {
+	if err := presence.SetAddress(m.st.presence, m.globalKey(), addr); err != nil {
+		return fmt.Errorf("cannot set agent API address for machine %v: %v", m, err)
+	}
+	return nil
+}
+
 // SetAgentAlive signals that the agent for machine m is alive.
 // It returns the started pinger.
 func (m *Machine) SetAgentAlive() (*presence.Pinger, error) {
@@ -19,6 +19,7 @@ import (
 	"github.com/juju/core/instance"
 	"github.com/juju/core/state"
 	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/state/presence"
 	"github.com/juju/core/state/testing"
 	coretesting "github.com/juju/core/testing"
 	"github.com/juju/core/version"
@@ -314,6 +315,33 @@ func (s *MachineSuite) TestMachineSetAgentAlive(c *gc.C) {
 	c.Assert(alive, gc.Equals, true)
 }
 
+func (s *MachineSuite) TestMachineAgentPresence(c *gc.C) {
+	info, err := s.machine.AgentPresence()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.LastSeen.IsZero(), gc.Equals, true)
+	c.Assert(info.Address, gc.Equals, "")
+
+	err = s.machine.SetAgentAPIAddress("10.0.0.1:17070")
+	c.Assert(err, gc.IsNil)
+	pinger, err := s.machine.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pinger.Kill(), gc.IsNil)
+
+	info, err = s.machine.AgentPresence()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.LastSeen.IsZero(), gc.Equals, false)
+	c.Assert(info.Address, gc.Equals, "10.0.0.1:17070")
+
+	// The details are removed with the machine.
+	err = s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.Remove()
+	c.Assert(err, gc.IsNil)
+	info, err = s.machine.AgentPresence()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, gc.Equals, presence.Info{})
+}
+
 func (s *MachineSuite) TestTag(c *gc.C) {
 	c.Assert(s.machine.Tag(), gc.Equals, "machine-1")
 }
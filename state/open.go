@@ -5,7 +5,6 @@ package state
 
 import (
 	"crypto/tls"
-	"crypto/x509"
 	stderrors "errors"
 	"fmt"
 	"net"
@@ -112,12 +111,10 @@ func DialInfo(info *Info, opts DialOpts) (*mgo.DialInfo, error) {
 	if len(info.CACert) == 0 {
 		return nil, stderrors.New("missing CA certificate")
 	}
-	xcert, err := cert.ParseCert(info.CACert)
+	pool, err := cert.ParseCertPool(info.CACert)
 	if err != nil {
 		return nil, fmt.Errorf("cannot parse CA certificate: %v", err)
 	}
-	pool := x509.NewCertPool()
-	pool.AddCert(xcert)
 	tlsConfig := &tls.Config{
 		RootCAs:    pool,
 		ServerName: "anything",
@@ -215,6 +212,7 @@ var indexes = []struct {
 	{"networkinterfaces", []string{"macaddress", "networkname"}, true},
 	{"networkinterfaces", []string{"networkname"}, false},
 	{"networkinterfaces", []string{"machineid"}, false},
+	{"webhookfailures", []string{"webhookid", "_id"}, false},
 }
 
 // The capped collection used for transaction logs defaults to 10MB.
@@ -299,6 +297,13 @@ func newState(session *mgo.Session, info *Info, policy Policy) (*State, error) {
 		annotations:       db.C("annotations"),
 		statuses:          db.C("statuses"),
 		stateServers:      db.C("stateServers"),
+		passwordRotations: db.C("passwordrotations"),
+		loginFailures:     db.C("loginfailures"),
+		webhooks:          db.C("webhooks"),
+		webhookFailures:   db.C("webhookfailures"),
+		settingsHistory:   db.C("settingshistory"),
+		templates:         db.C("containertemplates"),
+		caCertAcks:        db.C("cacertacks"),
 	}
 	log := db.C("txns.log")
 	logInfo := mgo.CollectionInfo{Capped: true, MaxBytes: logSize}
@@ -332,6 +337,19 @@ func newState(session *mgo.Session, info *Info, policy Policy) (*State, error) {
 	if err := st.createStateServingInfoDoc(); err != nil {
 		return nil, fmt.Errorf("cannot create state serving info document: %v", err)
 	}
+	if err := st.refreshCACert(); err != nil {
+		return nil, err
+	}
+	st.caCertWatcher = newCACertWatcher(st)
+	go func() {
+		// The watcher refreshes the cached CA certificate
+		// itself, so its events need only be consumed.
+		for _ = range st.caCertWatcher.Changes() {
+		}
+		if err := st.caCertWatcher.Err(); err != nil {
+			logger.Warningf("cached CA certificate no longer refreshed: %v", err)
+		}
+	}()
 	return st, nil
 }
 
@@ -415,12 +433,8 @@ func (st *State) createStateServingInfoDoc() error {
 	return onAbort(st.runTransaction(ops), nil)
 }
 
-// CACert returns the certificate used to validate the state connection.
-func (st *State) CACert() string {
-	return st.info.CACert
-}
-
 func (st *State) Close() error {
+	err0 := st.caCertWatcher.Stop()
 	err1 := st.watcher.Stop()
 	err2 := st.pwatcher.Stop()
 	st.mu.Lock()
@@ -430,7 +444,7 @@ func (st *State) Close() error {
 	}
 	st.mu.Unlock()
 	st.db.Session.Close()
-	for _, err := range []error{err1, err2, err3} {
+	for _, err := range []error{err0, err1, err2, err3} {
 		if err != nil {
 			return err
 		}
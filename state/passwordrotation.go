@@ -0,0 +1,103 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+
+	"github.com/juju/core/names"
+)
+
+// passwordRotationDoc records a request for an agent to choose a new
+// password. The Revno is taken from an environment-wide sequence every
+// time a new password is requested, so that it keeps increasing after
+// the document is removed, which happens when the agent sets its new
+// password; agents remember the last Revno they acted upon, and choose
+// a new password whenever a greater one is requested.
+type passwordRotationDoc struct {
+	Tag   string `bson:"_id"`
+	Revno int
+}
+
+// RequestPasswordRotation asks the agent of the machine or unit
+// with the given tag to replace its password.
+func (st *State) RequestPasswordRotation(tag string) error {
+	kind, err := names.TagKind(tag)
+	if err != nil {
+		return err
+	}
+	if kind != names.MachineTagKind && kind != names.UnitTagKind {
+		return fmt.Errorf("cannot rotate password of %q: not an agent", tag)
+	}
+	seq, err := st.sequence("passwordrotation")
+	if err != nil {
+		return fmt.Errorf("cannot rotate password of %q: %v", tag, err)
+	}
+	// Agents that have never rotated their password have acted
+	// upon Revno 0.
+	newRevno := seq + 1
+	// A racing client or agent may create or remove the document
+	// between our attempts, in which case the second attempt
+	// updates or creates it.
+	for i := 0; i < 2; i++ {
+		revno, err := st.PasswordRotation(tag)
+		if err != nil {
+			return err
+		}
+		op := txn.Op{
+			C:  st.passwordRotations.Name,
+			Id: tag,
+		}
+		if revno == 0 {
+			op.Assert = txn.DocMissing
+			op.Insert = &passwordRotationDoc{Tag: tag, Revno: newRevno}
+		} else {
+			op.Assert = txn.DocExists
+			op.Update = bson.D{{"$set", bson.D{{"revno", newRevno}}}}
+		}
+		if err := st.runTransaction([]txn.Op{op}); err != txn.ErrAborted {
+			if err != nil {
+				return fmt.Errorf("cannot rotate password of %q: %v", tag, err)
+			}
+			return nil
+		}
+	}
+	return ErrExcessiveContention
+}
+
+// PasswordRotation returns the revno of the pending request for the
+// agent of the entity with the given tag to replace its password, or
+// 0 if there is none.
+func (st *State) PasswordRotation(tag string) (int, error) {
+	var doc passwordRotationDoc
+	err := st.passwordRotations.FindId(tag).One(&doc)
+	if err == mgo.ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("cannot get password rotation for %q: %v", tag, err)
+	}
+	return doc.Revno, nil
+}
+
+// WatchPasswordRotation returns a watcher that notifies when the agent
+// of the entity with the given tag is asked to replace its password.
+func (st *State) WatchPasswordRotation(tag string) NotifyWatcher {
+	return newEntityWatcher(st, st.passwordRotations, tag)
+}
+
+// removePasswordRotationOp returns the operation that removes any
+// pending request for the agent of the entity with the given tag to
+// replace its password. It's used when the agent sets a new password,
+// which satisfies the request, and when the entity is removed.
+func removePasswordRotationOp(st *State, tag string) txn.Op {
+	return txn.Op{
+		C:      st.passwordRotations.Name,
+		Id:     tag,
+		Remove: true,
+	}
+}
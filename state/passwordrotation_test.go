@@ -0,0 +1,103 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/state"
+	statetesting "github.com/juju/core/state/testing"
+)
+
+type PasswordRotationSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&PasswordRotationSuite{})
+
+func (s *PasswordRotationSuite) TestRequestPasswordRotation(c *gc.C) {
+	revno, err := s.State.PasswordRotation("machine-0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(revno, gc.Equals, 0)
+
+	for i := 1; i <= 3; i++ {
+		err = s.State.RequestPasswordRotation("machine-0")
+		c.Assert(err, gc.IsNil)
+		revno, err = s.State.PasswordRotation("machine-0")
+		c.Assert(err, gc.IsNil)
+		c.Assert(revno, gc.Equals, i)
+	}
+
+	// Other entities are unaffected.
+	revno, err = s.State.PasswordRotation("unit-wordpress-0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(revno, gc.Equals, 0)
+}
+
+func (s *PasswordRotationSuite) TestSetPasswordCompletesRotation(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = s.State.RequestPasswordRotation(machine.Tag())
+	c.Assert(err, gc.IsNil)
+	err = s.State.RequestPasswordRotation(machine.Tag())
+	c.Assert(err, gc.IsNil)
+	revno, err := s.State.PasswordRotation(machine.Tag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(revno, gc.Equals, 2)
+
+	// Setting a new password satisfies the request.
+	err = machine.SetPassword("new-password-long-enough")
+	c.Assert(err, gc.IsNil)
+	revno, err = s.State.PasswordRotation(machine.Tag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(revno, gc.Equals, 0)
+
+	// Later requests are still greater than any the agent acted upon.
+	err = s.State.RequestPasswordRotation(machine.Tag())
+	c.Assert(err, gc.IsNil)
+	revno, err = s.State.PasswordRotation(machine.Tag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(revno, gc.Equals, 3)
+
+	// Pending requests are removed with the entity.
+	err = machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = machine.Remove()
+	c.Assert(err, gc.IsNil)
+	revno, err = s.State.PasswordRotation(machine.Tag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(revno, gc.Equals, 0)
+}
+
+func (s *PasswordRotationSuite) TestRequestPasswordRotationNotAgent(c *gc.C) {
+	err := s.State.RequestPasswordRotation("user-admin")
+	c.Assert(err, gc.ErrorMatches, `cannot rotate password of "user-admin": not an agent`)
+	err = s.State.RequestPasswordRotation("foo")
+	c.Assert(err, gc.ErrorMatches, `"foo" is not a valid tag`)
+}
+
+func (s *PasswordRotationSuite) TestWatchPasswordRotation(c *gc.C) {
+	w := s.State.WatchPasswordRotation("unit-wordpress-0")
+	defer statetesting.AssertStop(c, w)
+
+	// Initial event.
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+
+	err := s.State.RequestPasswordRotation("unit-wordpress-0")
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	err = s.State.RequestPasswordRotation("unit-wordpress-0")
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	// Requests for other entities are not reported.
+	err = s.State.RequestPasswordRotation("machine-0")
+	c.Assert(err, gc.IsNil)
+	wc.AssertNoChange()
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
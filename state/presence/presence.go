@@ -45,6 +45,22 @@ var logger = loggo.GetLogger("juju.state.presence")
 // helper collection. That sequence number is then inserted into the
 // beings collection to establish the mapping between pinger sequence
 // and key.
+//
+// The info collection holds a document keyed by the pinger key, so
+// that the last time a key was seen alive remains available after it
+// is considered dead:
+//
+// {
+//   "_id":      <key>,
+//   "lastseen": <time the key was last seen alive>,
+//   "address":  <address recorded with SetAddress>,
+// }
+//
+// To avoid a write per key per ping, the document is created when an
+// address is first recorded, and the last seen time is only updated
+// when a pinger is stopped or killed, or when a watcher finds that a
+// pinger stopped reporting. The document is never recreated by those
+// updates once it's removed with RemoveInfo.
 
 // BUG(gn): The pings and beings collection currently grow without bound.
 
@@ -427,6 +443,10 @@ func (w *Watcher) sync() error {
 	// the respective events and forget their sequences.
 	for seq, key := range w.beingKey {
 		if dead[seq] || !alive[seq] {
+			if !dead[seq] {
+				// Killed pingers record their own last seen time.
+				w.recordLastSeen(key, slot)
+			}
 			delete(w.beingKey, seq)
 			delete(w.beingSeq, key)
 			for _, ch := range w.watches[key] {
@@ -437,6 +457,27 @@ func (w *Watcher) sync() error {
 	return nil
 }
 
+// recordLastSeen records when key was last seen alive, having been
+// found not to have reported in the two time slots up to slot. Its
+// last ping was made at the latest in the slot before those, so the
+// end of that slot is recorded, unless the pinger recorded a time
+// within that slot itself when it was stopped.
+func (w *Watcher) recordLastSeen(key string, slot int64) {
+	lastSlot := time.Unix(slot-2*period, 0).UTC()
+	sel := bson.D{
+		{"_id", key},
+		{"$or", []bson.D{
+			{{"lastseen", bson.D{{"$exists", false}}}},
+			{{"lastseen", bson.D{{"$lt", lastSlot}}}},
+		}},
+	}
+	lastSeen := time.Unix(slot-period, 0).UTC()
+	err := infoC(w.base).Update(sel, bson.D{{"$set", bson.D{{"lastseen", lastSeen}}}})
+	if err != nil && err != mgo.ErrNotFound {
+		logger.Warningf("cannot record last seen time of %q: %v", key, err)
+	}
+}
+
 // Pinger periodically reports that a specific key is alive, so that
 // watchers interested on that fact can react appropriately.
 type Pinger struct {
@@ -444,6 +485,7 @@ type Pinger struct {
 	tomb     tomb.Tomb
 	base     *mgo.Collection
 	pings    *mgo.Collection
+	info     *mgo.Collection
 	started  bool
 	beingKey string
 	beingSeq int64
@@ -456,7 +498,7 @@ type Pinger struct {
 // NewPinger returns a new Pinger to report that key is alive.
 // It starts reporting after Start is called.
 func NewPinger(base *mgo.Collection, key string) *Pinger {
-	return &Pinger{base: base, pings: pingsC(base), beingKey: key}
+	return &Pinger{base: base, pings: pingsC(base), info: infoC(base), beingKey: key}
 }
 
 // Start starts periodically reporting that p's key is alive.
@@ -491,10 +533,16 @@ func (p *Pinger) Stop() error {
 	if p.started {
 		logger.Tracef("stopping pinger for %q with seq=%d", p.beingKey, p.beingSeq)
 	}
+	wasStarted := p.started
 	p.tomb.Kill(nil)
 	err := p.tomb.Wait()
 	// TODO ping one more time to guarantee a late timeout.
 	p.started = false
+	if wasStarted {
+		if err := p.recordLastSeen(); err != nil {
+			return err
+		}
+	}
 	return err
 
 }
@@ -524,6 +572,9 @@ func (p *Pinger) killStarted() error {
 	if _, err := p.pings.UpsertId(slot, udoc); err != nil {
 		return err
 	}
+	if err := p.recordLastSeen(); err != nil {
+		return err
+	}
 	return killErr
 }
 
@@ -597,10 +648,82 @@ func (p *Pinger) ping() error {
 		return nil
 	}
 	p.lastSlot = slot
-	if _, err := p.pings.UpsertId(slot, bson.D{{"$inc", bson.D{{"alive." + p.fieldKey, p.fieldBit}}}}); err != nil {
+	_, err := p.pings.UpsertId(slot, bson.D{{"$inc", bson.D{{"alive." + p.fieldKey, p.fieldBit}}}})
+	return err
+}
+
+// recordLastSeen records the current time as the last time the
+// pinger's key was seen alive, if the key has an info document.
+func (p *Pinger) recordLastSeen() error {
+	now := time.Now().Add(p.delta).UTC()
+	err := p.info.UpdateId(p.beingKey, bson.D{{"$set", bson.D{{"lastseen", now}}}})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// Info holds the details most recently recorded about a key.
+type Info struct {
+	// LastSeen holds the last time the key was seen alive before
+	// its pinger last stopped, or the zero time if none has stopped
+	// since the key's address was first recorded.
+	LastSeen time.Time "lastseen,omitempty"
+
+	// Address holds the address most recently recorded
+	// for the key with SetAddress.
+	Address string "address,omitempty"
+}
+
+// GetInfo returns the details most recently recorded about key.
+// Unlike liveness, they are read directly from the database
+// and do not depend on any watcher.
+func GetInfo(base *mgo.Collection, key string) (Info, error) {
+	var info Info
+	err := infoC(base).FindId(key).One(&info)
+	if err == mgo.ErrNotFound {
+		return Info{}, nil
+	}
+	if err != nil {
+		return Info{}, err
+	}
+	info.LastSeen = info.LastSeen.UTC()
+	return info, nil
+}
+
+// SetAddress records addr as the address through which key is
+// currently reachable, for example the address of the server that
+// a remote agent is connected to. Nothing is written if addr is
+// already recorded.
+func SetAddress(base *mgo.Collection, key, addr string) error {
+	info := infoC(base)
+	sel := bson.D{{"_id", key}, {"address", bson.D{{"$ne", addr}}}}
+	err := info.Update(sel, bson.D{{"$set", bson.D{{"address", addr}}}})
+	if err != mgo.ErrNotFound {
 		return err
 	}
-	return nil
+	// Either there's no document yet, or the address is unchanged.
+	err = info.Insert(bson.D{{"_id", key}, {"address", addr}})
+	if lerr, ok := err.(*mgo.LastError); !ok || lerr.Code != 11000 {
+		return err
+	}
+	// The document exists, but may have been created concurrently
+	// with another address.
+	err = info.Update(sel, bson.D{{"$set", bson.D{{"address", addr}}}})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// RemoveInfo removes the details recorded about key, for
+// use when whatever key stands for is removed.
+func RemoveInfo(base *mgo.Collection, key string) error {
+	err := infoC(base).RemoveId(key)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
 }
 
 // clockDelta returns the approximate skew between
@@ -731,3 +854,7 @@ func beingsC(base *mgo.Collection) *mgo.Collection {
 func pingsC(base *mgo.Collection) *mgo.Collection {
 	return base.Database.C(base.Name + ".pings")
 }
+
+func infoC(base *mgo.Collection) *mgo.Collection {
+	return base.Database.C(base.Name + ".info")
+}
@@ -480,3 +480,102 @@ func (s *PresenceSuite) TestFindAllBeings(c *gc.C) {
 		c.Fatalf("Sync failed to returned")
 	}
 }
+
+func (s *PresenceSuite) TestInfo(c *gc.C) {
+	info, err := presence.GetInfo(s.presence, "a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, gc.Equals, presence.Info{})
+
+	// Nothing is recorded for keys without an address.
+	p := presence.NewPinger(s.presence, "a")
+	c.Assert(p.Start(), gc.IsNil)
+	c.Assert(p.Stop(), gc.IsNil)
+	info, err = presence.GetInfo(s.presence, "a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, gc.Equals, presence.Info{})
+
+	err = presence.SetAddress(s.presence, "a", "10.0.0.1:17070")
+	c.Assert(err, gc.IsNil)
+	info, err = presence.GetInfo(s.presence, "a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, gc.Equals, presence.Info{Address: "10.0.0.1:17070"})
+
+	// Pings don't record the last seen time; stopping does.
+	// Allow for the skew between the local and database clocks.
+	before := time.Now().Add(-time.Minute)
+	c.Assert(p.Start(), gc.IsNil)
+	info, err = presence.GetInfo(s.presence, "a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.LastSeen.IsZero(), gc.Equals, true)
+	c.Assert(p.Stop(), gc.IsNil)
+	info, err = presence.GetInfo(s.presence, "a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.LastSeen.After(before), gc.Equals, true)
+	c.Assert(info.Address, gc.Equals, "10.0.0.1:17070")
+
+	// The key is still known after the pinger is killed.
+	c.Assert(p.Start(), gc.IsNil)
+	c.Assert(p.Kill(), gc.IsNil)
+	killed, err := presence.GetInfo(s.presence, "a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(killed.LastSeen.Before(info.LastSeen), gc.Equals, false)
+
+	// Recording the same address again changes nothing.
+	err = presence.SetAddress(s.presence, "a", "10.0.0.1:17070")
+	c.Assert(err, gc.IsNil)
+	info, err = presence.GetInfo(s.presence, "a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, gc.Equals, killed)
+
+	err = presence.SetAddress(s.presence, "a", "10.0.0.2:17070")
+	c.Assert(err, gc.IsNil)
+	info, err = presence.GetInfo(s.presence, "a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Address, gc.Equals, "10.0.0.2:17070")
+	c.Assert(info.LastSeen.Equal(killed.LastSeen), gc.Equals, true)
+
+	// Other keys are unaffected.
+	info, err = presence.GetInfo(s.presence, "b")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, gc.Equals, presence.Info{})
+
+	// Removed details aren't recorded again by pingers.
+	err = presence.RemoveInfo(s.presence, "a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(p.Start(), gc.IsNil)
+	c.Assert(p.Stop(), gc.IsNil)
+	info, err = presence.GetInfo(s.presence, "a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, gc.Equals, presence.Info{})
+	err = presence.RemoveInfo(s.presence, "a")
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *PresenceSuite) TestExpiryRecordsLastSeen(c *gc.C) {
+	err := presence.SetAddress(s.presence, "a", "10.0.0.1:17070")
+	c.Assert(err, gc.IsNil)
+	w := presence.NewWatcher(s.presence)
+	p := presence.NewPinger(s.presence, "a")
+	defer w.Stop()
+	defer p.Stop()
+
+	ch := make(chan presence.Change)
+	w.Watch("a", ch)
+	assertChange(c, ch, presence.Change{"a", false})
+	c.Assert(p.Start(), gc.IsNil)
+	w.StartSync()
+	assertChange(c, ch, presence.Change{"a", true})
+	info, err := presence.GetInfo(s.presence, "a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.LastSeen.IsZero(), gc.Equals, true)
+
+	// The watcher records when a pinger that stopped
+	// reporting without being stopped was last seen.
+	presence.FakeTimeSlot(2)
+	w.StartSync()
+	assertChange(c, ch, presence.Change{"a", false})
+	info, err = presence.GetInfo(s.presence, "a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.LastSeen.IsZero(), gc.Equals, false)
+	c.Assert(info.Address, gc.Equals, "10.0.0.1:17070")
+}
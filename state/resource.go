@@ -0,0 +1,208 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+)
+
+// Resource holds a revision of the content of a resource declared
+// by a service's charm, attached to the service.
+type Resource struct {
+	// Name holds the name of the resource in the charm metadata.
+	Name string
+
+	// Revision holds the revision of the resource's content. It's
+	// incremented every time new content is attached.
+	Revision int
+
+	// URL holds where the content can be downloaded from.
+	URL string
+
+	// SHA256 holds the hex-encoded SHA256 hash of the content.
+	SHA256 string
+
+	// Size holds the size of the content in bytes.
+	Size int64
+
+	// User holds the name of the user that attached the content.
+	User string
+
+	// Time holds when the content was attached.
+	Time time.Time
+}
+
+// Resources returns the resources attached to the service,
+// sorted by name.
+func (s *Service) Resources() []Resource {
+	var resources []Resource
+	for _, res := range s.doc.Resources {
+		resources = append(resources, res)
+	}
+	sort.Sort(resourcesByName(resources))
+	return resources
+}
+
+// Resource returns the current revision of the named resource
+// attached to the service.
+func (s *Service) Resource(name string) (Resource, error) {
+	res, ok := s.doc.Resources[name]
+	if !ok {
+		return Resource{}, errors.NotFoundf("resource %q of service %q", name, s)
+	}
+	return res, nil
+}
+
+// AttachResource attaches new content to the named resource, which
+// must be declared by the service's charm, as a new revision of the
+// resource. The content, with the given SHA256 hash and size, must
+// already be available at url.
+func (s *Service) AttachResource(name, url, sha256 string, size int64, user string) (_ Resource, err error) {
+	defer errors.Maskf(&err, "cannot attach resource %q to service %q", name, s)
+	service := &Service{st: s.st, doc: s.doc}
+	for i := 0; i < 5; i++ {
+		if service.doc.Life != Alive {
+			return Resource{}, fmt.Errorf("service is not alive")
+		}
+		ch, _, err := service.Charm()
+		if err != nil {
+			return Resource{}, err
+		}
+		if _, ok := ch.Meta().Resources[name]; !ok {
+			return Resource{}, fmt.Errorf("charm %q declares no such resource", ch.URL())
+		}
+		res := Resource{
+			Name:     name,
+			Revision: service.doc.Resources[name].Revision + 1,
+			URL:      url,
+			SHA256:   sha256,
+			Size:     size,
+			User:     user,
+			Time:     time.Now(),
+		}
+		ops := []txn.Op{{
+			C:      s.st.services.Name,
+			Id:     s.doc.Name,
+			Assert: bson.D{{"txn-revno", service.doc.TxnRevno}},
+			Update: bson.D{{"$set", bson.D{{"resources." + name, res}}}},
+		}}
+		if prev, ok := service.doc.Resources[name]; ok && prev.SHA256 != sha256 {
+			superseded := ResourceStoragePath(s.doc.Name, name, prev.SHA256)
+			ops = append(ops, s.st.newCleanupOp(cleanupResourceContent, superseded))
+		}
+		if err := s.st.runTransaction(ops); err == nil {
+			s.doc = service.doc
+			resources := make(map[string]Resource)
+			for k, v := range s.doc.Resources {
+				resources[k] = v
+			}
+			resources[name] = res
+			s.doc.Resources = resources
+			return res, nil
+		} else if err != txn.ErrAborted {
+			return Resource{}, err
+		}
+		if err := service.Refresh(); err != nil {
+			return Resource{}, err
+		}
+	}
+	return Resource{}, ErrExcessiveContention
+}
+
+// ResourceStoragePath returns the path in provider storage of resource
+// content with the given SHA256 hash, attached to the named resource of
+// the named service.
+func ResourceStoragePath(service, name, sha256 string) string {
+	return path.Join(resourceStoragePrefix(service), name, sha256)
+}
+
+// resourceStoragePrefix returns the prefix of the paths in provider
+// storage of all the resource content attached to the named service.
+func resourceStoragePrefix(service string) string {
+	return path.Join("resources", service) + "/"
+}
+
+// ResourceStorage is the part of the provider storage that
+// CleanupResourceContent needs.
+type ResourceStorage interface {
+	List(prefix string) ([]string, error)
+	Remove(name string) error
+}
+
+// CleanupResourceContent removes resource content from provider storage
+// once it's no longer attached to a service: either because newer content
+// was attached to the resource, or because the service was removed. The
+// state can't reach provider storage itself, so these cleanups are skipped
+// by Cleanup and must be run by something that can.
+func (st *State) CleanupResourceContent(stor ResourceStorage) error {
+	var docs []cleanupDoc
+	err := st.cleanups.Find(bson.D{{"kind", cleanupResourceContent}}).All(&docs)
+	if err != nil {
+		return fmt.Errorf("cannot read cleanup documents: %v", err)
+	}
+	for _, doc := range docs {
+		logger.Debugf("running %q cleanup: %q", doc.Kind, doc.Prefix)
+		if err := st.cleanupResourceContent(stor, doc.Prefix); err != nil {
+			logger.Warningf("cleanup failed: %v", err)
+			continue
+		}
+		ops := []txn.Op{{
+			C:      st.cleanups.Name,
+			Id:     doc.Id,
+			Remove: true,
+		}}
+		if err := st.runTransaction(ops); err != nil {
+			logger.Warningf("cannot remove empty cleanup document: %v", err)
+		}
+	}
+	return nil
+}
+
+// cleanupResourceContent removes the content in provider storage under
+// the given prefix, except for any content still attached to the service
+// the prefix belongs to. The same content may have been attached again
+// since the cleanup was scheduled, and a service of the same name may
+// have been deployed since the old one was removed.
+func (st *State) cleanupResourceContent(stor ResourceStorage, prefix string) error {
+	parts := strings.Split(prefix, "/")
+	if len(parts) < 2 || parts[0] != "resources" {
+		return fmt.Errorf("invalid resource content prefix %q", prefix)
+	}
+	attached := make(map[string]bool)
+	service, err := st.Service(parts[1])
+	if err == nil {
+		for _, res := range service.Resources() {
+			attached[ResourceStoragePath(service.Name(), res.Name, res.SHA256)] = true
+		}
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+	paths, err := stor.List(prefix)
+	if err != nil {
+		return fmt.Errorf("cannot list resource content: %v", err)
+	}
+	for _, p := range paths {
+		if attached[p] {
+			continue
+		}
+		if err := stor.Remove(p); err != nil {
+			return fmt.Errorf("cannot remove resource content %q: %v", p, err)
+		}
+	}
+	return nil
+}
+
+type resourcesByName []Resource
+
+func (r resourcesByName) Len() int           { return len(r) }
+func (r resourcesByName) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r resourcesByName) Less(i, j int) bool { return r[i].Name < r[j].Name }
@@ -0,0 +1,158 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"sort"
+	"strings"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/state"
+)
+
+type ResourceSuite struct {
+	ConnSuite
+	service *state.Service
+}
+
+var _ = gc.Suite(&ResourceSuite{})
+
+func (s *ResourceSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.service = s.AddTestingService(c, "resources", s.AddTestingCharm(c, "resources"))
+}
+
+func (s *ResourceSuite) TestAttachResource(c *gc.C) {
+	c.Assert(s.service.Resources(), gc.HasLen, 0)
+	_, err := s.service.Resource("jdk")
+	c.Assert(err, gc.ErrorMatches, `resource "jdk" of service "resources" not found`)
+
+	res, err := s.service.AttachResource("jdk", "http://example.com/jdk-1", "abc", 42, "admin")
+	c.Assert(err, gc.IsNil)
+	c.Assert(res.Name, gc.Equals, "jdk")
+	c.Assert(res.Revision, gc.Equals, 1)
+	c.Assert(res.Time.IsZero(), gc.Equals, false)
+	_, err = s.service.AttachResource("app", "http://example.com/app-1", "def", 7, "admin")
+	c.Assert(err, gc.IsNil)
+	res, err = s.service.AttachResource("jdk", "http://example.com/jdk-2", "ghi", 43, "bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(res.Revision, gc.Equals, 2)
+
+	err = s.service.Refresh()
+	c.Assert(err, gc.IsNil)
+	res, err = s.service.Resource("jdk")
+	c.Assert(err, gc.IsNil)
+	c.Assert(res.Revision, gc.Equals, 2)
+	c.Assert(res.URL, gc.Equals, "http://example.com/jdk-2")
+	c.Assert(res.SHA256, gc.Equals, "ghi")
+	c.Assert(res.Size, gc.Equals, int64(43))
+	c.Assert(res.User, gc.Equals, "bob")
+	resources := s.service.Resources()
+	c.Assert(resources, gc.HasLen, 2)
+	c.Assert(resources[0].Name, gc.Equals, "app")
+	c.Assert(resources[0].Revision, gc.Equals, 1)
+	c.Assert(resources[1].Name, gc.Equals, "jdk")
+}
+
+func (s *ResourceSuite) TestAttachResourceErrors(c *gc.C) {
+	_, err := s.service.AttachResource("unknown", "http://example.com/x", "abc", 1, "admin")
+	c.Assert(err, gc.ErrorMatches, `cannot attach resource "unknown" to service "resources": charm "local:quantal/resources-1" declares no such resource`)
+
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+	_, err = s.service.AttachResource("jdk", "http://example.com/x", "abc", 1, "admin")
+	c.Assert(err, gc.ErrorMatches, `cannot attach resource "jdk" to service "resources": .*`)
+}
+
+// fakeResourceStorage is a state.ResourceStorage
+// holding the set of stored paths.
+type fakeResourceStorage map[string]bool
+
+func (stor fakeResourceStorage) List(prefix string) ([]string, error) {
+	var paths []string
+	for p := range stor {
+		if strings.HasPrefix(p, prefix) {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func (stor fakeResourceStorage) Remove(name string) error {
+	delete(stor, name)
+	return nil
+}
+
+func (s *ResourceSuite) TestCleanupResourceContent(c *gc.C) {
+	stor := fakeResourceStorage{
+		"resources/resources/jdk/abc": true,
+		"resources/resources/jdk/def": true,
+		"resources/resources/jdk/ghi": true,
+		"resources/resources/app/xyz": true,
+		"resources/other/jdk/abc":     true,
+	}
+	_, err := s.service.AttachResource("jdk", "http://example.com/jdk-1", "abc", 1, "admin")
+	c.Assert(err, gc.IsNil)
+	_, err = s.service.AttachResource("app", "http://example.com/app-1", "xyz", 1, "admin")
+	c.Assert(err, gc.IsNil)
+	_, err = s.service.AttachResource("jdk", "http://example.com/jdk-2", "def", 1, "admin")
+	c.Assert(err, gc.IsNil)
+	// Attaching the superseded content again keeps it.
+	_, err = s.service.AttachResource("jdk", "http://example.com/jdk-3", "abc", 1, "admin")
+	c.Assert(err, gc.IsNil)
+
+	// Cleanup leaves resource content to CleanupResourceContent.
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	needed, err := s.State.NeedsCleanup()
+	c.Assert(err, gc.IsNil)
+	c.Assert(needed, gc.Equals, true)
+
+	err = s.State.CleanupResourceContent(stor)
+	c.Assert(err, gc.IsNil)
+	paths, err := stor.List("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(paths, gc.DeepEquals, []string{
+		"resources/other/jdk/abc",
+		"resources/resources/app/xyz",
+		"resources/resources/jdk/abc",
+		"resources/resources/jdk/ghi",
+	})
+	needed, err = s.State.NeedsCleanup()
+	c.Assert(err, gc.IsNil)
+	c.Assert(needed, gc.Equals, false)
+
+	// Removing the service removes all its content.
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.State.CleanupResourceContent(stor)
+	c.Assert(err, gc.IsNil)
+	paths, err = stor.List("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(paths, gc.DeepEquals, []string{"resources/other/jdk/abc"})
+}
+
+func (s *ResourceSuite) TestCleanupResourceContentKeepsNewService(c *gc.C) {
+	stor := fakeResourceStorage{
+		"resources/resources/jdk/abc": true,
+		"resources/resources/jdk/def": true,
+	}
+	_, err := s.service.AttachResource("jdk", "http://example.com/jdk-1", "abc", 1, "admin")
+	c.Assert(err, gc.IsNil)
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+
+	// A service of the same name, deployed before the cleanup
+	// runs, keeps its content.
+	service := s.AddTestingService(c, "resources", s.AddTestingCharm(c, "resources"))
+	_, err = service.AttachResource("jdk", "http://example.com/jdk-1", "def", 1, "admin")
+	c.Assert(err, gc.IsNil)
+	err = s.State.CleanupResourceContent(stor)
+	c.Assert(err, gc.IsNil)
+	paths, err := stor.List("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(paths, gc.DeepEquals, []string{"resources/resources/jdk/def"})
+}
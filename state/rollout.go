@@ -0,0 +1,279 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/state/api/params"
+)
+
+// Rollout holds the progress of a rolling charm upgrade of a service.
+// The units that were running when the upgrade started are held at
+// their old charm, and released to the service's charm a batch at a
+// time by AdvanceRollout.
+type Rollout struct {
+	// FromCharmURL holds the charm URL the service had
+	// when the upgrade started.
+	FromCharmURL *charm.URL
+
+	// BatchSize holds the number of units released at a time.
+	BatchSize int
+
+	// WaitHealthy holds whether the units of a batch must be
+	// started again after upgrading before the next batch is
+	// released.
+	WaitHealthy bool
+
+	// Pending holds the names of the units not yet released.
+	Pending []string `bson:",omitempty"`
+
+	// Upgrading holds the names of the released units
+	// that have not finished upgrading.
+	Upgrading []string `bson:",omitempty"`
+
+	// Upgraded holds the names of the released units that have
+	// finished upgrading. They are still checked for errors until
+	// the rolling upgrade ends.
+	Upgraded []string `bson:",omitempty"`
+
+	// Halted holds whether the upgrade is halted because a released
+	// unit has an error; Message describes the error.
+	Halted  bool   `bson:",omitempty"`
+	Message string `bson:",omitempty"`
+}
+
+// Rollout returns the progress of the service's rolling charm
+// upgrade, and whether there is one.
+func (s *Service) Rollout() (*Rollout, bool) {
+	if s.doc.Rollout == nil {
+		return nil, false
+	}
+	r := *s.doc.Rollout
+	return &r, true
+}
+
+// CharmURLForUnit returns the charm URL the named unit of the service
+// should run, and whether it should upgrade to the charm with that URL
+// even if it is in an error state. It's the service's charm URL, unless
+// a rolling charm upgrade holds the unit back.
+func (s *Service) CharmURLForUnit(unitName string) (curl *charm.URL, force bool) {
+	if r := s.doc.Rollout; r != nil {
+		for _, name := range r.Pending {
+			if name == unitName {
+				return r.FromCharmURL, false
+			}
+		}
+	}
+	return s.CharmURL()
+}
+
+// SetCharmRollout changes the charm for the service like SetCharm,
+// but upgrades its existing units batchSize at a time: the units of
+// a batch are released only when the units of the previous batch
+// have upgraded and, if waitHealthy is true, have started again. New
+// units are started with the new charm straight away.
+func (s *Service) SetCharmRollout(ch *Charm, force bool, batchSize int, waitHealthy bool) (err error) {
+	defer errors.Maskf(&err, "cannot start rolling upgrade of service %q", s)
+	if batchSize < 1 {
+		return fmt.Errorf("invalid batch size %d", batchSize)
+	}
+	return s.setCharm(ch, force, func(service *Service) (*Rollout, error) {
+		if service.doc.Rollout != nil {
+			return nil, fmt.Errorf("rolling upgrade to %q in progress", service.doc.CharmURL)
+		}
+		if *service.doc.CharmURL == *ch.URL() {
+			return nil, nil
+		}
+		units, err := service.AllUnits()
+		if err != nil {
+			return nil, err
+		}
+		r := &Rollout{
+			FromCharmURL: service.doc.CharmURL,
+			BatchSize:    batchSize,
+			WaitHealthy:  waitHealthy,
+		}
+		for _, unit := range units {
+			// Units that haven't installed a charm yet
+			// can install the new one.
+			if curl, _ := unit.CharmURL(); curl != nil && *curl != *ch.URL() {
+				r.Pending = append(r.Pending, unit.Name())
+			}
+		}
+		if len(r.Pending) == 0 {
+			return nil, nil
+		}
+		sort.Sort(unitNamesByNumber(r.Pending))
+		return r, nil
+	})
+}
+
+// AdvanceRollout moves the service's rolling charm upgrade on, if
+// there is one. A unit has upgraded once it has run the upgrade-charm
+// hook of the service's charm (and started again, if the upgrade waits
+// for health). When every released unit has upgraded the next batch of
+// units is released, and once all units have been released and upgraded
+// the rolling upgrade is complete. No units are released, and the
+// upgrade doesn't complete, while any released unit has an error.
+func (s *Service) AdvanceRollout() (err error) {
+	defer errors.Maskf(&err, "cannot advance rolling upgrade of service %q", s)
+	service := &Service{st: s.st, doc: s.doc}
+	for i := 0; i < 5; i++ {
+		r, changed, err := service.nextRollout()
+		if err != nil {
+			return err
+		}
+		if !changed {
+			s.doc = service.doc
+			return nil
+		}
+		update := bson.D{{"$set", bson.D{{"rollout", r}}}}
+		if r == nil {
+			update = bson.D{{"$unset", bson.D{{"rollout", nil}}}}
+		}
+		ops := []txn.Op{{
+			C:      s.st.services.Name,
+			Id:     s.doc.Name,
+			Assert: bson.D{{"txn-revno", service.doc.TxnRevno}},
+			Update: update,
+		}}
+		if err := s.st.runTransaction(ops); err == nil {
+			s.doc = service.doc
+			s.doc.Rollout = r
+			return nil
+		} else if err != txn.ErrAborted {
+			return err
+		}
+		if err := service.Refresh(); err != nil {
+			return err
+		}
+	}
+	return ErrExcessiveContention
+}
+
+// nextRollout returns the progress the service's rolling charm upgrade
+// should record, and whether it differs from the current one.
+func (s *Service) nextRollout() (next *Rollout, changed bool, err error) {
+	r := s.doc.Rollout
+	if r == nil {
+		return nil, false, nil
+	}
+	next = &Rollout{
+		FromCharmURL: r.FromCharmURL,
+		BatchSize:    r.BatchSize,
+		WaitHealthy:  r.WaitHealthy,
+		Pending:      r.Pending,
+	}
+	released := append(append([]string{}, r.Upgraded...), r.Upgrading...)
+	sort.Sort(unitNamesByNumber(released))
+	for _, name := range released {
+		unit, err := s.st.Unit(name)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, false, err
+		}
+		if unit.Life() != Alive {
+			continue
+		}
+		status, info, _, err := unit.Status()
+		if err != nil {
+			return nil, false, err
+		}
+		if status == params.StatusError && !next.Halted {
+			next.Halted = true
+			next.Message = fmt.Sprintf("unit %s: %s", name, info)
+		}
+		if upgraded, err := s.upgradedUnit(unit, status, info, r.WaitHealthy); err != nil {
+			return nil, false, err
+		} else if upgraded {
+			next.Upgraded = append(next.Upgraded, name)
+		} else {
+			next.Upgrading = append(next.Upgrading, name)
+		}
+	}
+	if len(next.Upgrading) == 0 && !next.Halted {
+		if len(next.Pending) == 0 {
+			return nil, true, nil
+		}
+		n := next.BatchSize
+		if n > len(next.Pending) {
+			n = len(next.Pending)
+		}
+		next.Upgrading, next.Pending = next.Pending[:n], next.Pending[n:]
+		if len(next.Pending) == 0 {
+			next.Pending = nil
+		}
+		return next, true, nil
+	}
+	changed = len(next.Upgrading) != len(r.Upgrading) ||
+		len(next.Upgraded) != len(r.Upgraded) ||
+		next.Halted != r.Halted ||
+		next.Message != r.Message
+	return next, changed, nil
+}
+
+// upgradedUnit returns whether the given unit, with the given status,
+// has finished upgrading to the service's charm. The unit agent sets
+// the charm URL before running the upgrade-charm hook, and reports
+// the unit as upgrading until the hook has succeeded.
+func (s *Service) upgradedUnit(unit *Unit, status params.Status, info string, waitHealthy bool) (bool, error) {
+	curl, _ := unit.CharmURL()
+	if curl == nil || *curl != *s.doc.CharmURL {
+		return false, nil
+	}
+	switch {
+	case status == params.StatusError:
+		return false, nil
+	case status == params.StatusInstalled && info == params.StatusInfoUpgradingCharm:
+		return false, nil
+	case !waitHealthy:
+		return true, nil
+	}
+	if status != params.StatusStarted {
+		return false, nil
+	}
+	return unit.AgentAlive()
+}
+
+// AdvanceRollouts advances the rolling charm upgrades of all services.
+func (st *State) AdvanceRollouts() error {
+	var docs []serviceDoc
+	sel := bson.D{{"rollout", bson.D{{"$exists", true}}}}
+	if err := st.services.Find(sel).All(&docs); err != nil {
+		return fmt.Errorf("cannot get services with rolling upgrades: %v", err)
+	}
+	for i := range docs {
+		// A service whose upgrade cannot advance must not hold up
+		// the upgrades of the others.
+		if err := newService(st, &docs[i]).AdvanceRollout(); err != nil {
+			logger.Errorf("%v", err)
+		}
+	}
+	return nil
+}
+
+// unitNamesByNumber sorts the names of units of
+// the same service by unit number.
+type unitNamesByNumber []string
+
+func (u unitNamesByNumber) Len() int      { return len(u) }
+func (u unitNamesByNumber) Swap(i, j int) { u[i], u[j] = u[j], u[i] }
+func (u unitNamesByNumber) Less(i, j int) bool {
+	return unitNumber(u[i]) < unitNumber(u[j])
+}
+
+func unitNumber(name string) int {
+	n, _ := strconv.Atoi(name[strings.LastIndex(name, "/")+1:])
+	return n
+}
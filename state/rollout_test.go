@@ -0,0 +1,290 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api/params"
+)
+
+type RolloutSuite struct {
+	ConnSuite
+	oldCharm *state.Charm
+	newCharm *state.Charm
+	service  *state.Service
+	units    []*state.Unit
+}
+
+var _ = gc.Suite(&RolloutSuite{})
+
+func (s *RolloutSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.oldCharm = s.AddTestingCharm(c, "mysql")
+	s.newCharm = s.AddConfigCharm(c, "mysql", "options: {}", 99)
+	s.service = s.AddTestingService(c, "mysql", s.oldCharm)
+	s.units = nil
+	for i := 0; i < 3; i++ {
+		unit, err := s.service.AddUnit()
+		c.Assert(err, gc.IsNil)
+		err = unit.SetCharmURL(s.oldCharm.URL())
+		c.Assert(err, gc.IsNil)
+		err = unit.SetStatus(params.StatusStarted, "", nil)
+		c.Assert(err, gc.IsNil)
+		s.units = append(s.units, unit)
+	}
+}
+
+func (s *RolloutSuite) assertRollout(c *gc.C, upgrading, pending []string) {
+	err := s.service.Refresh()
+	c.Assert(err, gc.IsNil)
+	r, ok := s.service.Rollout()
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(r.Upgrading, gc.DeepEquals, upgrading)
+	c.Assert(r.Pending, gc.DeepEquals, pending)
+	for _, name := range pending {
+		curl, force := s.service.CharmURLForUnit(name)
+		c.Assert(curl, gc.DeepEquals, s.oldCharm.URL())
+		c.Assert(force, gc.Equals, false)
+	}
+	for _, name := range upgrading {
+		curl, _ := s.service.CharmURLForUnit(name)
+		c.Assert(curl, gc.DeepEquals, s.newCharm.URL())
+	}
+}
+
+// upgrade sets the new charm URL of the unit, as the unit agent
+// does before running the upgrade-charm hook.
+func (s *RolloutSuite) upgrade(c *gc.C, unit *state.Unit) {
+	err := unit.SetStatus(params.StatusInstalled, params.StatusInfoUpgradingCharm, nil)
+	c.Assert(err, gc.IsNil)
+	err = unit.SetCharmURL(s.newCharm.URL())
+	c.Assert(err, gc.IsNil)
+}
+
+// settle sets the unit started again, as the unit agent does
+// once the upgrade-charm hook has succeeded.
+func (s *RolloutSuite) settle(c *gc.C, unit *state.Unit) {
+	err := unit.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *RolloutSuite) TestRollout(c *gc.C) {
+	// A unit without a charm yet isn't held back.
+	unit, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+
+	err = s.service.SetCharmRollout(s.newCharm, false, 2, false)
+	c.Assert(err, gc.IsNil)
+	curl, _ := s.service.CharmURL()
+	c.Assert(curl, gc.DeepEquals, s.newCharm.URL())
+	r, ok := s.service.Rollout()
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(r, gc.DeepEquals, &state.Rollout{
+		FromCharmURL: s.oldCharm.URL(),
+		BatchSize:    2,
+		Pending:      []string{"mysql/0", "mysql/1", "mysql/2"},
+	})
+	curl, _ = s.service.CharmURLForUnit(unit.Name())
+	c.Assert(curl, gc.DeepEquals, s.newCharm.URL())
+
+	err = s.State.AdvanceRollouts()
+	c.Assert(err, gc.IsNil)
+	s.assertRollout(c, []string{"mysql/0", "mysql/1"}, []string{"mysql/2"})
+
+	// Setting the new charm URL doesn't complete the upgrade:
+	// the upgrade-charm hook has yet to run.
+	s.upgrade(c, s.units[0])
+	s.upgrade(c, s.units[1])
+	err = s.State.AdvanceRollouts()
+	c.Assert(err, gc.IsNil)
+	s.assertRollout(c, []string{"mysql/0", "mysql/1"}, []string{"mysql/2"})
+
+	// Nothing is released until the whole batch has upgraded.
+	s.settle(c, s.units[0])
+	err = s.State.AdvanceRollouts()
+	c.Assert(err, gc.IsNil)
+	s.assertRollout(c, []string{"mysql/1"}, []string{"mysql/2"})
+	r, _ = s.service.Rollout()
+	c.Assert(r.Upgraded, gc.DeepEquals, []string{"mysql/0"})
+
+	s.settle(c, s.units[1])
+	err = s.State.AdvanceRollouts()
+	c.Assert(err, gc.IsNil)
+	s.assertRollout(c, []string{"mysql/2"}, nil)
+	r, _ = s.service.Rollout()
+	c.Assert(r.Upgraded, gc.DeepEquals, []string{"mysql/0", "mysql/1"})
+
+	s.upgrade(c, s.units[2])
+	s.settle(c, s.units[2])
+	err = s.State.AdvanceRollouts()
+	c.Assert(err, gc.IsNil)
+	err = s.service.Refresh()
+	c.Assert(err, gc.IsNil)
+	_, ok = s.service.Rollout()
+	c.Assert(ok, gc.Equals, false)
+}
+
+func (s *RolloutSuite) TestRolloutHaltsOnError(c *gc.C) {
+	err := s.service.SetCharmRollout(s.newCharm, false, 1, false)
+	c.Assert(err, gc.IsNil)
+	err = s.service.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+
+	s.upgrade(c, s.units[0])
+	err = s.units[0].SetStatus(params.StatusError, `hook failed: "upgrade-charm"`, nil)
+	c.Assert(err, gc.IsNil)
+	err = s.service.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+	s.assertRollout(c, []string{"mysql/0"}, []string{"mysql/1", "mysql/2"})
+	r, _ := s.service.Rollout()
+	c.Assert(r.Halted, gc.Equals, true)
+	c.Assert(r.Message, gc.Equals, `unit mysql/0: hook failed: "upgrade-charm"`)
+
+	// Once the error is resolved, the rollout carries on.
+	err = s.units[0].SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	err = s.service.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+	s.assertRollout(c, []string{"mysql/1"}, []string{"mysql/2"})
+	r, _ = s.service.Rollout()
+	c.Assert(r.Halted, gc.Equals, false)
+	c.Assert(r.Message, gc.Equals, "")
+}
+
+func (s *RolloutSuite) TestRolloutHaltsOnErrorAfterUpgrade(c *gc.C) {
+	err := s.service.SetCharmRollout(s.newCharm, false, 2, false)
+	c.Assert(err, gc.IsNil)
+	err = s.service.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+	for _, unit := range s.units[:2] {
+		s.upgrade(c, unit)
+		s.settle(c, unit)
+	}
+	err = s.service.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+	s.assertRollout(c, []string{"mysql/2"}, nil)
+
+	// Units that have upgraded are still watched for errors.
+	err = s.units[0].SetStatus(params.StatusError, `hook failed: "config-changed"`, nil)
+	c.Assert(err, gc.IsNil)
+	s.upgrade(c, s.units[2])
+	s.settle(c, s.units[2])
+	err = s.service.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+	s.assertRollout(c, []string{"mysql/0"}, nil)
+	r, _ := s.service.Rollout()
+	c.Assert(r.Upgraded, gc.DeepEquals, []string{"mysql/1", "mysql/2"})
+	c.Assert(r.Halted, gc.Equals, true)
+	c.Assert(r.Message, gc.Equals, `unit mysql/0: hook failed: "config-changed"`)
+
+	// The upgrade completes once the error is resolved.
+	s.settle(c, s.units[0])
+	err = s.service.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+	err = s.service.Refresh()
+	c.Assert(err, gc.IsNil)
+	_, ok := s.service.Rollout()
+	c.Assert(ok, gc.Equals, false)
+}
+
+func (s *RolloutSuite) TestRolloutWaitHealthy(c *gc.C) {
+	err := s.service.SetCharmRollout(s.newCharm, false, 1, true)
+	c.Assert(err, gc.IsNil)
+	err = s.service.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+
+	s.upgrade(c, s.units[0])
+	err = s.units[0].SetStatus(params.StatusInstalled, "", nil)
+	c.Assert(err, gc.IsNil)
+	err = s.service.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+	s.assertRollout(c, []string{"mysql/0"}, []string{"mysql/1", "mysql/2"})
+
+	// A started unit must have its agent running too.
+	err = s.units[0].SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	err = s.service.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+	s.assertRollout(c, []string{"mysql/0"}, []string{"mysql/1", "mysql/2"})
+
+	pinger, err := s.units[0].SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+	s.State.StartSync()
+	err = s.service.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+	s.assertRollout(c, []string{"mysql/1"}, []string{"mysql/2"})
+}
+
+func (s *RolloutSuite) TestRolloutSkipsRemovedUnits(c *gc.C) {
+	err := s.service.SetCharmRollout(s.newCharm, false, 1, false)
+	c.Assert(err, gc.IsNil)
+	err = s.service.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+	err = s.units[0].Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.service.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+	s.assertRollout(c, []string{"mysql/1"}, []string{"mysql/2"})
+}
+
+func (s *RolloutSuite) TestAdvanceRolloutsContinuesAfterError(c *gc.C) {
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	newWordpress := s.AddConfigCharm(c, "wordpress", "options: {}", 99)
+	_, err := wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = wordpress.SetCharmRollout(newWordpress, false, 1, false)
+	c.Assert(err, gc.IsNil)
+	err = wordpress.AdvanceRollout()
+	c.Assert(err, gc.IsNil)
+	state.SetRolloutUpgrading(c, wordpress, []string{"invalid"})
+
+	err = s.service.SetCharmRollout(s.newCharm, false, 2, false)
+	c.Assert(err, gc.IsNil)
+	err = s.State.AdvanceRollouts()
+	c.Assert(err, gc.IsNil)
+	s.assertRollout(c, []string{"mysql/0", "mysql/1"}, []string{"mysql/2"})
+
+	err = wordpress.Refresh()
+	c.Assert(err, gc.IsNil)
+	r, ok := wordpress.Rollout()
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(r.Upgrading, gc.DeepEquals, []string{"invalid"})
+}
+
+func (s *RolloutSuite) TestSetCharmRolloutErrors(c *gc.C) {
+	err := s.service.SetCharmRollout(s.newCharm, false, 0, false)
+	c.Assert(err, gc.ErrorMatches, `cannot start rolling upgrade of service "mysql": invalid batch size 0`)
+
+	err = s.service.SetCharmRollout(s.newCharm, false, 1, false)
+	c.Assert(err, gc.IsNil)
+	other := s.AddConfigCharm(c, "mysql", "options: {}", 100)
+	err = s.service.SetCharmRollout(other, false, 1, false)
+	c.Assert(err, gc.ErrorMatches, `cannot start rolling upgrade of service "mysql": rolling upgrade to "local:quantal/mysql-99" in progress`)
+}
+
+func (s *RolloutSuite) TestSetCharmEndsRollout(c *gc.C) {
+	err := s.service.SetCharmRollout(s.newCharm, false, 1, false)
+	c.Assert(err, gc.IsNil)
+	err = s.service.SetCharm(s.newCharm, true)
+	c.Assert(err, gc.IsNil)
+	err = s.service.Refresh()
+	c.Assert(err, gc.IsNil)
+	_, ok := s.service.Rollout()
+	c.Assert(ok, gc.Equals, false)
+	curl, force := s.service.CharmURLForUnit("mysql/2")
+	c.Assert(curl, gc.DeepEquals, s.newCharm.URL())
+	c.Assert(force, gc.Equals, true)
+}
+
+func (s *RolloutSuite) TestSetCharmRolloutSameCharm(c *gc.C) {
+	err := s.service.SetCharmRollout(s.oldCharm, false, 1, false)
+	c.Assert(err, gc.IsNil)
+	_, ok := s.service.Rollout()
+	c.Assert(ok, gc.Equals, false)
+	curl, _ := s.service.CharmURLForUnit("mysql/0")
+	c.Assert(curl, gc.DeepEquals, s.oldCharm.URL())
+}
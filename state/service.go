@@ -31,19 +31,23 @@ type Service struct {
 // serviceDoc represents the internal state of a service in MongoDB.
 // Note the correspondence with ServiceInfo in state/api/params.
 type serviceDoc struct {
-	Name          string `bson:"_id"`
-	Series        string
-	Subordinate   bool
-	CharmURL      *charm.URL
-	ForceCharm    bool
-	Life          Life
-	UnitSeq       int
-	UnitCount     int
-	RelationCount int
-	Exposed       bool
-	MinUnits      int
-	OwnerTag      string
-	TxnRevno      int64 `bson:"txn-revno"`
+	Name             string `bson:"_id"`
+	Series           string
+	Subordinate      bool
+	CharmURL         *charm.URL
+	ForceCharm       bool
+	Life             Life
+	UnitSeq          int
+	UnitCount        int
+	RelationCount    int
+	Exposed          bool
+	MinUnits         int
+	OwnerTag         string
+	Rollout          *Rollout `bson:",omitempty"`
+	SettingsRevision int
+	SettingsHistory  string
+	Resources        map[string]Resource `bson:",omitempty"`
+	TxnRevno         int64               `bson:"txn-revno"`
 }
 
 func newService(st *State, doc *serviceDoc) *Service {
@@ -64,6 +68,12 @@ func (s *Service) Name() string {
 	return s.doc.Name
 }
 
+// Series returns the series the service's units are deployed on,
+// which is the series of its charm URL.
+func (s *Service) Series() string {
+	return s.doc.Series
+}
+
 // Tag returns a name identifying the service that is safe to use
 // as a file name.  The returned name will be different from other
 // Tag values returned by any other entities from the same state.
@@ -224,6 +234,12 @@ func (s *Service) removeOps(asserts bson.D) []txn.Op {
 		Id:     s.settingsKey(),
 		Remove: true,
 	}}
+	if s.doc.SettingsHistory != "" {
+		ops = append(ops, s.st.newCleanupOp(cleanupSettingsHistory, s.doc.SettingsHistory))
+	}
+	if len(s.doc.Resources) > 0 {
+		ops = append(ops, s.st.newCleanupOp(cleanupResourceContent, resourceStoragePrefix(s.doc.Name)))
+	}
 	ops = append(ops, removeRequestedNetworksOp(s.st, s.globalKey()))
 	ops = append(ops, removeConstraintsOp(s.st, s.globalKey()))
 	return append(ops, annotationRemoveOp(s.st, s.globalKey()))
@@ -461,6 +477,14 @@ func (s *Service) changeCharmOps(ch *Charm, force bool) ([]txn.Op, error) {
 // this charm, and existing units will be upgraded to use it. If force is true,
 // units will be upgraded even if they are in an error state.
 func (s *Service) SetCharm(ch *Charm, force bool) (err error) {
+	return s.setCharm(ch, force, nil)
+}
+
+// setCharm changes the charm for the service. If rollout is not nil, it's
+// called to return the rolling upgrade the change starts, if any;
+// otherwise any rolling upgrade in progress is ended and all units are
+// upgraded.
+func (s *Service) setCharm(ch *Charm, force bool, rollout func(*Service) (*Rollout, error)) (err error) {
 	if ch.Meta().Subordinate != s.doc.Subordinate {
 		return fmt.Errorf("cannot change a service's subordinacy")
 	}
@@ -490,9 +514,18 @@ func (s *Service) SetCharm(ch *Charm, force bool) (err error) {
 			}
 		}
 
+		var r *Rollout
+		if rollout != nil {
+			if r, err = rollout(s); err != nil {
+				return err
+			}
+		}
+		ops = append(ops, setRolloutOp(s, r, rollout != nil))
+
 		if err := s.st.runTransaction(ops); err == nil {
 			s.doc.CharmURL = ch.URL()
 			s.doc.ForceCharm = force
+			s.doc.Rollout = r
 			return nil
 		} else if err != txn.ErrAborted {
 			return err
@@ -509,6 +542,25 @@ func (s *Service) SetCharm(ch *Charm, force bool) (err error) {
 	return ErrExcessiveContention
 }
 
+// setRolloutOp returns the operation that records r as the service's
+// rolling charm upgrade, or ends the one in progress if r is nil. If
+// starting is true, the operation asserts that there is no rolling
+// upgrade in progress.
+func setRolloutOp(s *Service, r *Rollout, starting bool) txn.Op {
+	op := txn.Op{
+		C:      s.st.services.Name,
+		Id:     s.doc.Name,
+		Update: bson.D{{"$unset", bson.D{{"rollout", nil}}}},
+	}
+	if r != nil {
+		op.Update = bson.D{{"$set", bson.D{{"rollout", r}}}}
+	}
+	if starting {
+		op.Assert = bson.D{{"rollout", bson.D{{"$exists", false}}}}
+	}
+	return op
+}
+
 // String returns the service name.
 func (s *Service) String() string {
 	return s.doc.Name
@@ -674,6 +726,8 @@ func (s *Service) removeUnitOps(u *Unit, asserts bson.D) ([]txn.Op, error) {
 		removeConstraintsOp(s.st, u.globalKey()),
 		removeStatusOp(s.st, u.globalKey()),
 		annotationRemoveOp(s.st, u.globalKey()),
+		removePasswordRotationOp(s.st, u.Tag()),
+		removeCACertAckOp(s.st, u.Tag()),
 		s.st.newCleanupOp(cleanupRemovedUnit, u.doc.Name),
 	)
 	if u.doc.CharmURL != nil {
@@ -768,32 +822,9 @@ func (s *Service) ConfigSettings() (charm.Settings, error) {
 
 // UpdateConfigSettings changes a service's charm config settings. Values set
 // to nil will be deleted; unknown and invalid values will return an error.
+// The change is recorded in the service's settings history.
 func (s *Service) UpdateConfigSettings(changes charm.Settings) error {
-	charm, _, err := s.Charm()
-	if err != nil {
-		return err
-	}
-	changes, err = charm.Config().ValidateSettings(changes)
-	if err != nil {
-		return err
-	}
-	// TODO(fwereade) state.Settings is itself really problematic in just
-	// about every use case. This needs to be resolved some time; but at
-	// least the settings docs are keyed by charm url as well as service
-	// name, so the actual impact of a race is non-threatening.
-	node, err := readSettings(s.st, s.settingsKey())
-	if err != nil {
-		return err
-	}
-	for name, value := range changes {
-		if value == nil {
-			node.Delete(name)
-		} else {
-			node.Set(name, value)
-		}
-	}
-	_, err = node.Write()
-	return err
+	return s.UpdateConfigSettingsBy(changes, "", -1)
 }
 
 var ErrSubordinateConstraints = stderrors.New("constraints do not apply to subordinate services")
@@ -133,6 +133,31 @@ func cacheKeys(caches ...map[string]interface{}) map[string]bool {
 // as a delta applied on top of the latest version of the node, to prevent
 // overwriting unrelated changes made to the node since it was last read.
 func (c *Settings) Write() ([]ItemChange, error) {
+	changes, update := c.delta()
+	if len(changes) == 0 {
+		return []ItemChange{}, nil
+	}
+	ops := []txn.Op{{
+		C:      c.st.settings.Name,
+		Id:     c.key,
+		Assert: txn.DocExists,
+		Update: update,
+	}}
+	err := c.st.runTransaction(ops)
+	if err == txn.ErrAborted {
+		return nil, errors.NotFoundf("settings")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot write settings: %v", err)
+	}
+	c.disk = copyMap(c.core, nil)
+	return changes, nil
+}
+
+// delta returns the changes made to c since it was last read or
+// written, sorted by key, and the update that applies them to its
+// document.
+func (c *Settings) delta() ([]ItemChange, bson.D) {
 	changes := []ItemChange{}
 	updates := map[string]interface{}{}
 	deletions := map[string]int{}
@@ -159,28 +184,11 @@ func (c *Settings) Write() ([]ItemChange, error) {
 		}
 		changes = append(changes, change)
 	}
-	if len(changes) == 0 {
-		return []ItemChange{}, nil
-	}
 	sort.Sort(itemChangeSlice(changes))
-	ops := []txn.Op{{
-		C:      c.st.settings.Name,
-		Id:     c.key,
-		Assert: txn.DocExists,
-		Update: bson.D{
-			{"$set", updates},
-			{"$unset", deletions},
-		},
-	}}
-	err := c.st.runTransaction(ops)
-	if err == txn.ErrAborted {
-		return nil, errors.NotFoundf("settings")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("cannot write settings: %v", err)
+	return changes, bson.D{
+		{"$set", updates},
+		{"$unset", deletions},
 	}
-	c.disk = copyMap(c.core, nil)
-	return changes, nil
 }
 
 func newSettings(st *State, key string) *Settings {
@@ -0,0 +1,274 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+
+	"github.com/juju/core/charm"
+)
+
+// SettingsRevision holds a recorded change to a service's config settings.
+type SettingsRevision struct {
+	// Revision holds the settings revision the change made.
+	Revision int
+
+	// User holds the name of the user that made the change,
+	// if it was made on behalf of a user.
+	User string
+
+	// Time holds when the change was made.
+	Time time.Time
+
+	// CharmURL holds the service's charm URL at the time.
+	CharmURL *charm.URL
+
+	// Settings holds the settings after the change.
+	Settings charm.Settings
+
+	// Changes holds the changed settings, sorted by key.
+	Changes []ItemChange
+}
+
+// settingsRevisionDoc represents a recorded change to a service's
+// config settings in MongoDB. History holds the key of the service's
+// settings history, which is created with its first recorded change;
+// it keeps the history of a service apart from that of a removed
+// service with the same name.
+type settingsRevisionDoc struct {
+	Id       string `bson:"_id"`
+	History  string
+	Revision int
+	User     string
+	Time     time.Time
+	CharmURL *charm.URL
+	Settings map[string]interface{}
+	Changes  []ItemChange
+}
+
+// settingsRevisionKey returns the key of the given
+// revision in the settings history with the given key.
+func settingsRevisionKey(history string, revision int) string {
+	return fmt.Sprintf("%s#%d", history, revision)
+}
+
+func (doc *settingsRevisionDoc) revision() SettingsRevision {
+	return SettingsRevision{
+		Revision: doc.Revision,
+		User:     doc.User,
+		Time:     doc.Time,
+		CharmURL: doc.CharmURL,
+		Settings: copyMap(doc.Settings, unescapeReplacer.Replace),
+		Changes:  doc.Changes,
+	}
+}
+
+// ConfigSettingsRevision returns the revision of the service's config
+// settings. It's incremented by every change to the settings made by
+// UpdateConfigSettings, UpdateConfigSettingsBy or RollbackConfigSettings.
+func (s *Service) ConfigSettingsRevision() int {
+	return s.doc.SettingsRevision
+}
+
+// ConfigSettingsHistory returns the recorded changes
+// to the service's config settings, oldest first.
+func (s *Service) ConfigSettingsHistory() ([]SettingsRevision, error) {
+	var docs []settingsRevisionDoc
+	if s.doc.SettingsHistory == "" {
+		return nil, nil
+	}
+	err := s.st.settingsHistory.Find(bson.D{{"history", s.doc.SettingsHistory}}).Sort("revision").All(&docs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get settings history of service %q: %v", s, err)
+	}
+	revisions := make([]SettingsRevision, len(docs))
+	for i := range docs {
+		revisions[i] = docs[i].revision()
+	}
+	return revisions, nil
+}
+
+// settingsRevisionAssert returns an assertion that a service doc holds
+// the given settings revision. Services added before settings changes
+// were recorded have no settings revision field; their revision is 0.
+func settingsRevisionAssert(revision int) bson.DocElem {
+	if revision == 0 {
+		return bson.DocElem{"settingsrevision", bson.D{{"$in", []interface{}{0, nil}}}}
+	}
+	return bson.DocElem{"settingsrevision", revision}
+}
+
+// UpdateConfigSettingsBy changes a service's charm config settings like
+// UpdateConfigSettings, and records the change as made by the named user.
+// If revision is not -1, the settings are changed only if it's the
+// current revision of the settings.
+func (s *Service) UpdateConfigSettingsBy(changes charm.Settings, user string, revision int) error {
+	service := &Service{st: s.st, doc: s.doc}
+	for i := 0; i < 5; i++ {
+		if revision != -1 && revision != service.doc.SettingsRevision {
+			return fmt.Errorf("cannot update settings of service %q: settings changed since revision %d (now at revision %d)",
+				s, revision, service.doc.SettingsRevision)
+		}
+		ch, _, err := service.Charm()
+		if err != nil {
+			return err
+		}
+		validChanges, err := ch.Config().ValidateSettings(changes)
+		if err != nil {
+			return err
+		}
+		node, err := readSettings(s.st, service.settingsKey())
+		if err != nil {
+			return err
+		}
+		for name, value := range validChanges {
+			if value == nil {
+				node.Delete(name)
+			} else {
+				node.Set(name, value)
+			}
+		}
+		itemChanges, update := node.delta()
+		if len(itemChanges) == 0 {
+			s.doc = service.doc
+			return nil
+		}
+		next := service.doc.SettingsRevision + 1
+		history := service.doc.SettingsHistory
+		if history == "" {
+			history = bson.NewObjectId().Hex()
+		}
+		settingsOp := node.assertUnchangedOp()
+		settingsOp.Update = update
+		ops := []txn.Op{
+			settingsOp,
+			{
+				C:  s.st.services.Name,
+				Id: s.doc.Name,
+				Assert: bson.D{
+					{"charmurl", service.doc.CharmURL},
+					settingsRevisionAssert(service.doc.SettingsRevision),
+				},
+				Update: bson.D{{"$set", bson.D{
+					{"settingsrevision", next},
+					{"settingshistory", history},
+				}}},
+			}, {
+				C:      s.st.settingsHistory.Name,
+				Id:     settingsRevisionKey(history, next),
+				Assert: txn.DocMissing,
+				Insert: &settingsRevisionDoc{
+					Id:       settingsRevisionKey(history, next),
+					History:  history,
+					Revision: next,
+					User:     user,
+					Time:     time.Now(),
+					CharmURL: service.doc.CharmURL,
+					Settings: copyMap(node.core, escapeReplacer.Replace),
+					Changes:  itemChanges,
+				},
+			},
+		}
+		if err := s.st.runTransaction(ops); err == nil {
+			s.doc = service.doc
+			s.doc.SettingsRevision = next
+			s.doc.SettingsHistory = history
+			return nil
+		} else if err != txn.ErrAborted {
+			return err
+		}
+		if err := service.Refresh(); err != nil {
+			return err
+		}
+	}
+	return ErrExcessiveContention
+}
+
+// RollbackConfigSettings restores the service's config settings to those
+// of the given revision, as made by the named user. Revision 0 holds the
+// settings before the first recorded change. The rollback is recorded as
+// a new revision. It fails, changing nothing, if the settings change
+// while rolling back, or if the settings of the revision are not valid
+// for the service's current charm.
+func (s *Service) RollbackConfigSettings(revision int, user string) (err error) {
+	defer errors.Maskf(&err, "cannot roll back settings of service %q to revision %d", s, revision)
+	// Read the latest revision, so that the rollback only
+	// succeeds if no other change is made in the meantime.
+	service := &Service{st: s.st, doc: s.doc}
+	if err := service.Refresh(); err != nil {
+		return err
+	}
+	current := service.doc.SettingsRevision
+	if revision < 0 || revision > current {
+		return fmt.Errorf("no such revision")
+	}
+	if revision == current {
+		s.doc = service.doc
+		return nil
+	}
+	// Revision 0 is the first recorded change, undone.
+	doc := &settingsRevisionDoc{}
+	key := settingsRevisionKey(service.doc.SettingsHistory, revision)
+	if revision == 0 {
+		key = settingsRevisionKey(service.doc.SettingsHistory, 1)
+	}
+	err = s.st.settingsHistory.FindId(key).One(doc)
+	if err == mgo.ErrNotFound {
+		return fmt.Errorf("revision not recorded")
+	} else if err != nil {
+		return err
+	}
+	settings := doc.revision().Settings
+	if revision == 0 {
+		for _, change := range doc.Changes {
+			if change.Type == ItemAdded {
+				delete(settings, change.Key)
+			} else {
+				settings[change.Key] = change.OldValue
+			}
+		}
+	}
+	currentSettings, err := service.ConfigSettings()
+	if err != nil {
+		return err
+	}
+	changes := charm.Settings(settings)
+	for name := range currentSettings {
+		if _, ok := changes[name]; !ok {
+			changes[name] = nil
+		}
+	}
+	// The settings were recorded for the charm in use at the time,
+	// whose options may since have been removed or changed type.
+	ch, _, err := service.Charm()
+	if err != nil {
+		return err
+	}
+	if _, err := ch.Config().ValidateSettings(changes); err != nil {
+		return fmt.Errorf("settings recorded with charm %q are not valid for current charm %q: %v; "+
+			"the settings are unchanged, use juju set to change them instead", doc.CharmURL, ch.URL(), err)
+	}
+	if err := service.UpdateConfigSettingsBy(changes, user, current); err != nil {
+		return err
+	}
+	s.doc = service.doc
+	return nil
+}
+
+// cleanupSettingsHistory removes the recorded settings
+// changes in the settings history with the given key.
+func (st *State) cleanupSettingsHistory(history string) error {
+	// The history of a removed service is not otherwise referenced,
+	// and is therefore safe to delete directly.
+	if _, err := st.settingsHistory.RemoveAll(bson.D{{"history", history}}); err != nil {
+		return fmt.Errorf("cannot remove settings history: %v", err)
+	}
+	return nil
+}
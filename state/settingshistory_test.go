@@ -0,0 +1,218 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"labix.org/v2/mgo/bson"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/state"
+)
+
+type SettingsHistorySuite struct {
+	ConnSuite
+	charm   *state.Charm
+	service *state.Service
+}
+
+var _ = gc.Suite(&SettingsHistorySuite{})
+
+func (s *SettingsHistorySuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.charm = s.AddTestingCharm(c, "dummy")
+	s.service = s.AddTestingService(c, "dummy", s.charm)
+}
+
+func (s *SettingsHistorySuite) assertSettings(c *gc.C, expect charm.Settings) {
+	settings, err := s.service.ConfigSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, expect)
+}
+
+func (s *SettingsHistorySuite) TestHistory(c *gc.C) {
+	c.Assert(s.service.ConfigSettingsRevision(), gc.Equals, 0)
+	history, err := s.service.ConfigSettingsHistory()
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 0)
+
+	err = s.service.UpdateConfigSettingsBy(charm.Settings{"title": "foo", "outlook": "fine"}, "admin", -1)
+	c.Assert(err, gc.IsNil)
+	err = s.service.UpdateConfigSettings(charm.Settings{"title": "bar", "outlook": nil})
+	c.Assert(err, gc.IsNil)
+	// A change that changes nothing isn't recorded.
+	err = s.service.UpdateConfigSettings(charm.Settings{"title": "bar"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.service.ConfigSettingsRevision(), gc.Equals, 2)
+
+	err = s.service.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.service.ConfigSettingsRevision(), gc.Equals, 2)
+	history, err = s.service.ConfigSettingsHistory()
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 2)
+	for i, rev := range history {
+		c.Check(rev.Revision, gc.Equals, i+1)
+		c.Check(rev.CharmURL, gc.DeepEquals, s.charm.URL())
+		c.Check(rev.Time.IsZero(), gc.Equals, false)
+	}
+	c.Assert(history[0].User, gc.Equals, "admin")
+	c.Assert(history[0].Settings, gc.DeepEquals, charm.Settings{"title": "foo", "outlook": "fine"})
+	c.Assert(history[0].Changes, gc.DeepEquals, []state.ItemChange{
+		{Type: state.ItemAdded, Key: "outlook", NewValue: "fine"},
+		{Type: state.ItemAdded, Key: "title", NewValue: "foo"},
+	})
+	c.Assert(history[1].User, gc.Equals, "")
+	c.Assert(history[1].Settings, gc.DeepEquals, charm.Settings{"title": "bar"})
+	c.Assert(history[1].Changes, gc.DeepEquals, []state.ItemChange{
+		{Type: state.ItemDeleted, Key: "outlook", OldValue: "fine"},
+		{Type: state.ItemModified, Key: "title", OldValue: "foo", NewValue: "bar"},
+	})
+}
+
+func (s *SettingsHistorySuite) TestUpdateAtRevision(c *gc.C) {
+	err := s.service.UpdateConfigSettingsBy(charm.Settings{"title": "foo"}, "admin", 0)
+	c.Assert(err, gc.IsNil)
+
+	// Another client changes the settings.
+	other, err := s.State.Service("dummy")
+	c.Assert(err, gc.IsNil)
+	err = other.UpdateConfigSettingsBy(charm.Settings{"title": "bar"}, "other", 1)
+	c.Assert(err, gc.IsNil)
+
+	err = s.service.UpdateConfigSettingsBy(charm.Settings{"title": "baz"}, "admin", 1)
+	c.Assert(err, gc.ErrorMatches, `cannot update settings of service "dummy": settings changed since revision 1 \(now at revision 2\)`)
+	s.assertSettings(c, charm.Settings{"title": "bar"})
+
+	err = s.service.UpdateConfigSettingsBy(charm.Settings{"title": "baz"}, "admin", 2)
+	c.Assert(err, gc.IsNil)
+	s.assertSettings(c, charm.Settings{"title": "baz"})
+}
+
+func (s *SettingsHistorySuite) TestRollback(c *gc.C) {
+	err := s.service.UpdateConfigSettings(charm.Settings{"title": "foo"})
+	c.Assert(err, gc.IsNil)
+	err = s.service.UpdateConfigSettings(charm.Settings{"title": "bar", "skill-level": 5})
+	c.Assert(err, gc.IsNil)
+
+	err = s.service.RollbackConfigSettings(1, "admin")
+	c.Assert(err, gc.IsNil)
+	s.assertSettings(c, charm.Settings{"title": "foo"})
+	c.Assert(s.service.ConfigSettingsRevision(), gc.Equals, 3)
+
+	history, err := s.service.ConfigSettingsHistory()
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 3)
+	c.Assert(history[2].User, gc.Equals, "admin")
+	c.Assert(history[2].Changes, gc.DeepEquals, []state.ItemChange{
+		{Type: state.ItemDeleted, Key: "skill-level", OldValue: int64(5)},
+		{Type: state.ItemModified, Key: "title", OldValue: "bar", NewValue: "foo"},
+	})
+
+	// Revision 0 holds the settings before the first change.
+	err = s.service.RollbackConfigSettings(0, "admin")
+	c.Assert(err, gc.IsNil)
+	s.assertSettings(c, charm.Settings{})
+	c.Assert(s.service.ConfigSettingsRevision(), gc.Equals, 4)
+
+	// Rolling back to the current revision does nothing.
+	err = s.service.RollbackConfigSettings(4, "admin")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.service.ConfigSettingsRevision(), gc.Equals, 4)
+
+	err = s.service.RollbackConfigSettings(5, "admin")
+	c.Assert(err, gc.ErrorMatches, `cannot roll back settings of service "dummy" to revision 5: no such revision`)
+}
+
+func (s *SettingsHistorySuite) TestRollbackConcurrentChange(c *gc.C) {
+	err := s.service.UpdateConfigSettings(charm.Settings{"title": "foo"})
+	c.Assert(err, gc.IsNil)
+	err = s.service.UpdateConfigSettings(charm.Settings{"title": "bar"})
+	c.Assert(err, gc.IsNil)
+
+	// Another client changes the settings while rolling back.
+	defer state.SetBeforeHooks(c, s.State, func() {
+		other, err := s.State.Service("dummy")
+		c.Assert(err, gc.IsNil)
+		err = other.UpdateConfigSettingsBy(charm.Settings{"title": "baz"}, "other", -1)
+		c.Assert(err, gc.IsNil)
+	}).Check()
+	err = s.service.RollbackConfigSettings(1, "admin")
+	c.Assert(err, gc.ErrorMatches, `cannot roll back settings of service "dummy" to revision 1: `+
+		`cannot update settings of service "dummy": settings changed since revision 2 \(now at revision 3\)`)
+	s.assertSettings(c, charm.Settings{"title": "baz"})
+}
+
+func (s *SettingsHistorySuite) TestRollbackInvalidForCurrentCharm(c *gc.C) {
+	err := s.service.UpdateConfigSettings(charm.Settings{"title": "foo", "skill-level": 5})
+	c.Assert(err, gc.IsNil)
+	err = s.service.UpdateConfigSettings(charm.Settings{"skill-level": nil})
+	c.Assert(err, gc.IsNil)
+
+	// The service is upgraded to a charm without the skill-level option.
+	newCharm := s.AddConfigCharm(c, "dummy", `
+options:
+  title: {default: My Title, description: title, type: string}
+`, 2)
+	err = s.service.SetCharm(newCharm, false)
+	c.Assert(err, gc.IsNil)
+
+	err = s.service.RollbackConfigSettings(1, "admin")
+	c.Assert(err, gc.ErrorMatches, `cannot roll back settings of service "dummy" to revision 1: `+
+		`settings recorded with charm "local:quantal/quantal-dummy-1" are not valid for current charm "local:quantal/quantal-dummy-2": `+
+		`unknown option "skill-level"; the settings are unchanged, use juju set to change them instead`)
+	s.assertSettings(c, charm.Settings{"title": "foo"})
+}
+
+func (s *SettingsHistorySuite) TestHistoryRemovedWithService(c *gc.C) {
+	err := s.service.UpdateConfigSettings(charm.Settings{"title": "foo"})
+	c.Assert(err, gc.IsNil)
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+
+	// A new service with the same name starts a new history.
+	s.service = s.AddTestingService(c, "dummy", s.charm)
+	history, err := s.service.ConfigSettingsHistory()
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 0)
+	err = s.service.UpdateConfigSettings(charm.Settings{"title": "bar"})
+	c.Assert(err, gc.IsNil)
+	history, err = s.service.ConfigSettingsHistory()
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 1)
+	c.Assert(history[0].Revision, gc.Equals, 1)
+
+	// The old history is removed by the cleanup.
+	settingsHistory := s.MgoSuite.Session.DB("juju").C("settingshistory")
+	count, err := settingsHistory.Count()
+	c.Assert(err, gc.IsNil)
+	c.Assert(count, gc.Equals, 2)
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	count, err = settingsHistory.Count()
+	c.Assert(err, gc.IsNil)
+	c.Assert(count, gc.Equals, 1)
+}
+
+func (s *SettingsHistorySuite) TestUpdateServiceWithoutSettingsRevision(c *gc.C) {
+	// Services added before settings changes were recorded
+	// have no settings revision.
+	err := s.services.UpdateId("dummy", bson.D{{"$unset", bson.D{
+		{"settingsrevision", nil},
+		{"settingshistory", nil},
+	}}})
+	c.Assert(err, gc.IsNil)
+	service, err := s.State.Service("dummy")
+	c.Assert(err, gc.IsNil)
+	c.Assert(service.ConfigSettingsRevision(), gc.Equals, 0)
+
+	err = service.UpdateConfigSettings(charm.Settings{"title": "foo"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(service.ConfigSettingsRevision(), gc.Equals, 1)
+	err = service.UpdateConfigSettings(charm.Settings{"title": "bar"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(service.ConfigSettingsRevision(), gc.Equals, 2)
+	s.service = service
+	s.assertSettings(c, charm.Settings{"title": "bar"})
+}
@@ -71,6 +71,13 @@ type State struct {
 	annotations       *mgo.Collection
 	statuses          *mgo.Collection
 	stateServers      *mgo.Collection
+	passwordRotations *mgo.Collection
+	loginFailures     *mgo.Collection
+	webhooks          *mgo.Collection
+	webhookFailures   *mgo.Collection
+	settingsHistory   *mgo.Collection
+	templates         *mgo.Collection
+	caCertAcks        *mgo.Collection
 	runner            *txn.Runner
 	transactionHooks  chan ([]transactionHook)
 	watcher           *watcher.Watcher
@@ -78,6 +85,11 @@ type State struct {
 	// mu guards allManager.
 	mu         sync.Mutex
 	allManager *multiwatcher.StoreManager
+	// caCertMu guards caCert, which caches the CA certificate
+	// and is refreshed by caCertWatcher.
+	caCertMu      sync.Mutex
+	caCert        string
+	caCertWatcher NotifyWatcher
 }
 
 // transactionHook holds a pair of functions to be called before and after a
@@ -511,8 +523,12 @@ func (st *State) parseTag(tag string) (coll string, id string, err error) {
 
 // AddCharm adds the ch charm with curl to the state. bundleURL must
 // be set to a URL where the bundle for ch may be downloaded from. On
-// success the newly added charm state is returned.
+// success the newly added charm state is returned. A charm that
+// declares the series it supports must support the series of curl.
 func (st *State) AddCharm(ch charm.Charm, curl *charm.URL, bundleURL *url.URL, bundleSha256 string) (stch *Charm, err error) {
+	if !ch.Meta().SupportsSeries(curl.Series) {
+		return nil, fmt.Errorf("cannot add charm %q: %v", curl, unsupportedSeriesError(ch, curl.Series))
+	}
 	// The charm may already exist in state as a placeholder, so we
 	// check for that situation and update the existing charm record
 	// if necessary, otherwise add a new record.
@@ -840,6 +856,13 @@ func IsCharmAlreadyUploadedError(err interface{}) bool {
 	return ok
 }
 
+// unsupportedSeriesError returns an error reporting that
+// ch doesn't support series.
+func unsupportedSeriesError(ch charm.Charm, series string) error {
+	return fmt.Errorf("series %q not supported by charm, supported series are: %s",
+		series, strings.Join(ch.Meta().DeclaredSeries(), ","))
+}
+
 // ErrCharmRevisionAlreadyModified is returned when a pending or
 // placeholder charm is no longer pending or a placeholder, signaling
 // the charm is available in state with its full information.
@@ -848,6 +871,9 @@ var ErrCharmRevisionAlreadyModified = fmt.Errorf("charm revision already modifie
 // UpdateUploadedCharm marks the given charm URL as uploaded and
 // updates the rest of its data, returning it as *state.Charm.
 func (st *State) UpdateUploadedCharm(ch charm.Charm, curl *charm.URL, bundleURL *url.URL, bundleSha256 string) (*Charm, error) {
+	if !ch.Meta().SupportsSeries(curl.Series) {
+		return nil, fmt.Errorf("cannot update charm %q: %v", curl, unsupportedSeriesError(ch, curl.Series))
+	}
 	doc := &charmDoc{}
 	err := st.charms.FindId(curl).One(&doc)
 	if err == mgo.ErrNotFound {
@@ -1479,6 +1505,12 @@ func (st *State) StartSync() {
 	st.pwatcher.Sync()
 }
 
+// WatcherStats returns statistics about the
+// activity of the state's txn watcher.
+func (st *State) WatcherStats() watcher.Stats {
+	return st.watcher.Stats()
+}
+
 // SetAdminMongoPassword sets the administrative password
 // to access the state. If the password is non-empty,
 // all subsequent attempts to access the state must
@@ -221,7 +221,7 @@ func (u *Unit) setPasswordHash(passwordHash string) error {
 		Id:     u.doc.Name,
 		Assert: notDeadDoc,
 		Update: bson.D{{"$set", bson.D{{"passwordhash", passwordHash}}}},
-	}}
+	}, removePasswordRotationOp(u.st, u.Tag())}
 	err := u.st.runTransaction(ops)
 	if err != nil {
 		return fmt.Errorf("cannot set password of unit %q: %v", u, onAbort(err, errDead))
@@ -784,6 +784,25 @@ func (u *Unit) WaitAgentAlive(timeout time.Duration) (err error) {
 	panic(fmt.Sprintf("presence reported dead status twice in a row for unit %q", u))
 }
 
+// AgentPresence returns the most recently recorded presence details
+// of the agent for unit u, such as when it was last seen alive.
+func (u *Unit) AgentPresence() (presence.Info, error) {
+	info, err := presence.GetInfo(u.st.presence, u.globalKey())
+	if err != nil {
+		return presence.Info{}, fmt.Errorf("cannot get agent presence for unit %q: %v", u, err)
+	}
+	return info, nil
+}
+
+// SetAgentAPIAddress records the address of the API server
+// that the agent for unit u is connected to.
+func (u *Unit) SetAgentAPIAddress(addr string) error {
+	if err := presence.SetAddress(u.st.presence, u.globalKey(), addr); err != nil {
+		return fmt.Errorf("cannot set agent API address for unit %q: %v", u, err)
+	}
+	return nil
+}
+
 // SetAgentAlive signals that the agent for unit u is alive.
 // It returns the started pinger.
 func (u *Unit) SetAgentAlive() (*presence.Pinger, error) {
@@ -14,6 +14,7 @@ import (
 	"github.com/juju/core/instance"
 	"github.com/juju/core/state"
 	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/state/presence"
 	"github.com/juju/core/state/testing"
 	coretesting "github.com/juju/core/testing"
 )
@@ -736,6 +737,35 @@ func (s *UnitSuite) TestUnitSetAgentAlive(c *gc.C) {
 	c.Assert(alive, gc.Equals, true)
 }
 
+func (s *UnitSuite) TestUnitAgentPresence(c *gc.C) {
+	info, err := s.unit.AgentPresence()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.LastSeen.IsZero(), gc.Equals, true)
+	c.Assert(info.Address, gc.Equals, "")
+
+	err = s.unit.SetAgentAPIAddress("10.0.0.1:17070")
+	c.Assert(err, gc.IsNil)
+	pinger, err := s.unit.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pinger.Kill(), gc.IsNil)
+
+	info, err = s.unit.AgentPresence()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.LastSeen.IsZero(), gc.Equals, false)
+	c.Assert(info.Address, gc.Equals, "10.0.0.1:17070")
+
+	// The details are removed with the unit.
+	err = s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.unit.Remove()
+	c.Assert(err, gc.IsNil)
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	info, err = s.unit.AgentPresence()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, gc.Equals, presence.Info{})
+}
+
 func (s *UnitSuite) TestUnitWaitAgentAlive(c *gc.C) {
 	alive, err := s.unit.AgentAlive()
 	c.Assert(err, gc.IsNil)
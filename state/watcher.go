@@ -1169,6 +1169,60 @@ func (st *State) WatchAPIHostPorts() NotifyWatcher {
 	return newEntityWatcher(st, st.stateServers, apiHostPortsKey)
 }
 
+// WatchCACert returns a NotifyWatcher that notifies when
+// the state server certificates are rotated.
+func (st *State) WatchCACert() NotifyWatcher {
+	return newCACertWatcher(st)
+}
+
+// caCertWatcher notifies of changes to the state serving info,
+// refreshing the cached CA certificate before each notification
+// so that CACert returns the new certificate once it is observed.
+type caCertWatcher struct {
+	commonWatcher
+	out chan struct{}
+}
+
+func newCACertWatcher(st *State) NotifyWatcher {
+	w := &caCertWatcher{
+		commonWatcher: commonWatcher{st: st},
+		out:           make(chan struct{}),
+	}
+	go func() {
+		defer w.tomb.Done()
+		defer close(w.out)
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+// Changes returns the event channel for the caCertWatcher.
+func (w *caCertWatcher) Changes() <-chan struct{} {
+	return w.out
+}
+
+func (w *caCertWatcher) loop() error {
+	in := newEntityWatcher(w.st, w.st.stateServers, stateServingInfoKey)
+	defer watcher.Stop(in, &w.tomb)
+	var out chan struct{}
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case _, ok := <-in.Changes():
+			if !ok {
+				return watcher.MustErr(in)
+			}
+			if err := w.st.refreshCACert(); err != nil {
+				return err
+			}
+			out = w.out
+		case out <- struct{}{}:
+			out = nil
+		}
+	}
+}
+
 // WatchConfigSettings returns a watcher for observing changes to the
 // unit's service configuration settings. The unit must have a charm URL
 // set before this method is called, and the returned watcher will be
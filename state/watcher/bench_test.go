@@ -0,0 +1,74 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package watcher_test
+
+import (
+	"time"
+
+	"labix.org/v2/mgo"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/state/watcher"
+)
+
+type BenchmarkSuite struct {
+	oldPeriod        time.Duration
+	oldTailing       bool
+	oldTailingPeriod time.Duration
+}
+
+var _ = gc.Suite(&BenchmarkSuite{})
+
+func (s *BenchmarkSuite) SetUpTest(c *gc.C) {
+	s.oldPeriod = watcher.Period
+	s.oldTailing = watcher.Tailing
+	s.oldTailingPeriod = watcher.TailingPeriod
+}
+
+func (s *BenchmarkSuite) TearDownTest(c *gc.C) {
+	watcher.Period = s.oldPeriod
+	watcher.Tailing = s.oldTailing
+	watcher.TailingPeriod = s.oldTailingPeriod
+}
+
+// benchmarkChangeLatency measures how long it takes for a watcher
+// to report a document change, and logs how many database operations
+// were sent for each change reported.
+func benchmarkChangeLatency(c *gc.C, tailing bool, period time.Duration) {
+	var s watcherSuite
+	s.SetUpSuite(c)
+	defer s.TearDownSuite(c)
+	watcher.Tailing = tailing
+	watcher.Period = period
+	watcher.TailingPeriod = period
+	s.SetUpTest(c)
+	defer s.TearDownTest(c)
+
+	revno := s.insert(c, "test", "a")
+	s.w.StartSync()
+	s.w.Watch("test", "a", revno, s.ch)
+
+	mgo.SetStats(true)
+	defer mgo.SetStats(false)
+	mgo.ResetStats()
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		revno := s.update(c, "test", "a")
+		assertChange(c, s.ch, watcher.Change{"test", "a", revno})
+	}
+	c.StopTimer()
+	c.Logf("%d changes, %.1f ops sent per change", c.N, float64(mgo.GetStats().SentOps)/float64(c.N))
+}
+
+func (*BenchmarkSuite) BenchmarkChangeLatencyTailing(c *gc.C) {
+	// The period is long enough that every change
+	// must be delivered by tailing the changelog.
+	benchmarkChangeLatency(c, true, time.Hour)
+}
+
+func (*BenchmarkSuite) BenchmarkChangeLatencyPolling(c *gc.C) {
+	// Even with a period far shorter than the default,
+	// polling is both slower and busier than tailing.
+	benchmarkChangeLatency(c, false, 100*time.Millisecond)
+}
@@ -4,10 +4,18 @@
 // The watcher package provides an interface for observing changes
 // to arbitrary MongoDB documents that are maintained via the
 // mgo/txn transaction package.
+//
+// Changes are learned from the capped changelog collection written
+// by mgo/txn. The watcher follows the changelog with a tailable
+// cursor, so it hears about new transactions as soon as they are
+// logged, and also synchronizes periodically as a fallback. Tailing
+// the changelog rather than the oplog means this works the same
+// whether or not MongoDB is running as a replica set.
 package watcher
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/juju/loggo"
@@ -48,6 +56,31 @@ type Watcher struct {
 
 	// lastId is the most recent transaction id observed by a sync.
 	lastId interface{}
+
+	// tailed receives a value whenever the changelog tailer
+	// sees a new entry in the changelog.
+	tailed chan struct{}
+
+	// statsMutex guards stats, which is updated by the
+	// loop goroutine and read by Stats.
+	statsMutex sync.Mutex
+	stats      Stats
+}
+
+// Stats holds statistics about a Watcher's activity.
+type Stats struct {
+	// Watches holds the number of watches currently registered.
+	Watches int
+
+	// Syncs holds the number of times the watcher has
+	// synchronized with the changelog.
+	Syncs int64
+
+	// SyncTime holds the total time spent synchronizing.
+	SyncTime time.Duration
+
+	// LastSyncTime holds the time taken by the most recent sync.
+	LastSyncTime time.Duration
 }
 
 // A Change holds information about a document change.
@@ -109,6 +142,7 @@ func New(changelog *mgo.Collection) *Watcher {
 		watches: make(map[watchKey][]watchInfo),
 		current: make(map[watchKey]int64),
 		request: make(chan interface{}),
+		tailed:  make(chan struct{}, 1),
 	}
 	go func() {
 		w.tomb.Kill(w.loop())
@@ -128,6 +162,13 @@ func (w *Watcher) Dead() <-chan struct{} {
 	return w.tomb.Dead()
 }
 
+// Stats returns statistics about the watcher's activity.
+func (w *Watcher) Stats() Stats {
+	w.statsMutex.Lock()
+	defer w.statsMutex.Unlock()
+	return w.stats
+}
+
 // Err returns the error with which the watcher stopped.
 // It returns nil if the watcher stopped cleanly, tomb.ErrStillAlive
 // if the watcher is still running properly, or the respective error
@@ -204,26 +245,64 @@ func (w *Watcher) StartSync() {
 // It must not be changed when any watchers are active.
 var Period time.Duration = 5 * time.Second
 
+// Tailing specifies whether watchers follow the changelog with a
+// tailable cursor, syncing as soon as a transaction is logged rather
+// than waiting for the next period.
+// It must not be changed when any watchers are active.
+var Tailing = true
+
+// TailingPeriod takes the place of Period while tailing. Syncs
+// triggered by it are only a fallback, so it can be much longer.
+// It must not be changed when any watchers are active.
+var TailingPeriod time.Duration = 1 * time.Minute
+
+var (
+	// tailTimeout is how long the tailer waits for new changelog
+	// entries before checking whether it should stop.
+	tailTimeout = 5 * time.Second
+
+	// tailRetryDelay is how long the tailer waits before reopening
+	// its cursor when the cursor dies or fails.
+	tailRetryDelay = 1 * time.Second
+)
+
 // loop implements the main watcher loop.
 func (w *Watcher) loop() error {
-	next := time.After(Period)
+	period := Period
+	if Tailing {
+		period = TailingPeriod
+	}
+	next := time.After(period)
 	w.needSync = true
 	if err := w.initLastId(); err != nil {
 		return err
 	}
+	if Tailing {
+		// The tailable cursor blocks its socket, so it
+		// gets a session of its own.
+		session := w.log.Database.Session.Copy()
+		stop := make(chan struct{})
+		go w.tail(w.log.With(session), stop)
+		defer func() {
+			close(stop)
+			session.Close()
+		}()
+	}
 	for {
 		if w.needSync {
 			if err := w.sync(); err != nil {
 				return err
 			}
 			w.flush()
-			next = time.After(Period)
+			next = time.After(period)
 		}
 		select {
 		case <-w.tomb.Dying():
 			return tomb.ErrDying
 		case <-next:
-			next = time.After(Period)
+			next = time.After(period)
+			w.needSync = true
+		case <-w.tailed:
 			w.needSync = true
 		case req := <-w.request:
 			w.handle(req)
@@ -287,6 +366,7 @@ func (w *Watcher) handle(req interface{}) {
 			w.requestEvents = append(w.requestEvents, event{r.info.ch, r.key, revno})
 		}
 		w.watches[r.key] = append(w.watches[r.key], r.info)
+		w.addWatchCount(1)
 	case reqUnwatch:
 		watches := w.watches[r.key]
 		removed := false
@@ -301,6 +381,7 @@ func (w *Watcher) handle(req interface{}) {
 		if !removed {
 			panic(fmt.Errorf("tried to remove missing channel %v for %s", r.ch, r.key))
 		}
+		w.addWatchCount(-1)
 		for i := range w.requestEvents {
 			e := &w.requestEvents[i]
 			if r.key.match(e.key) && e.ch == r.ch {
@@ -318,6 +399,63 @@ func (w *Watcher) handle(req interface{}) {
 	}
 }
 
+// tail follows the changelog, notifying the main loop of every new
+// entry, until stop is closed. The cursor dies when the changelog is
+// empty or when it wraps around past the cursor's position, in which
+// case it is reopened.
+func (w *Watcher) tail(log *mgo.Collection, stop <-chan struct{}) {
+	for {
+		err := w.tailOnce(log, stop)
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if err != nil {
+			logger.Warningf("cannot tail changelog: %v", err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(tailRetryDelay):
+		}
+	}
+}
+
+// tailOnce follows the changelog from its most recent entry
+// until the cursor dies or stop is closed.
+func (w *Watcher) tailOnce(log *mgo.Collection, stop <-chan struct{}) error {
+	n, err := log.Count()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		// A tailable cursor whose initial query returns
+		// nothing dies immediately.
+		return nil
+	}
+	iter := log.Find(nil).Select(bson.D{{"_id", 1}}).Sort("$natural").Skip(n - 1).Tail(tailTimeout)
+	var entry bson.D
+	for {
+		for iter.Next(&entry) {
+			select {
+			case w.tailed <- struct{}{}:
+			default:
+			}
+		}
+		if iter.Err() != nil || !iter.Timeout() {
+			break
+		}
+		select {
+		case <-stop:
+			iter.Close()
+			return nil
+		default:
+		}
+	}
+	return iter.Close()
+}
+
 type logInfo struct {
 	Docs   []interface{} `bson:"d"`
 	Revnos []int64       `bson:"r"`
@@ -338,10 +476,30 @@ func (w *Watcher) initLastId() error {
 	return nil
 }
 
+// addWatchCount adjusts the number of watches recorded in the stats.
+func (w *Watcher) addWatchCount(delta int) {
+	w.statsMutex.Lock()
+	w.stats.Watches += delta
+	w.statsMutex.Unlock()
+}
+
+// recordSync records the time taken by a sync in the stats.
+func (w *Watcher) recordSync(d time.Duration) {
+	w.statsMutex.Lock()
+	w.stats.Syncs++
+	w.stats.SyncTime += d
+	w.stats.LastSyncTime = d
+	w.statsMutex.Unlock()
+}
+
 // sync updates the watcher knowledge from the database, and
 // queues events to observing channels.
 func (w *Watcher) sync() error {
 	w.needSync = false
+	start := time.Now()
+	defer func() {
+		w.recordSync(time.Since(start))
+	}()
 	// Iterate through log events in reverse insertion order (newest first).
 	iter := w.log.Find(nil).Batch(10).Sort("-$natural").Iter()
 	seen := make(map[watchKey]bool)
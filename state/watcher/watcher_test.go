@@ -48,12 +48,14 @@ type watcherSuite struct {
 	testing.MgoSuite
 	testing.BaseSuite
 
-	log       *mgo.Collection
-	stash     *mgo.Collection
-	runner    *txn.Runner
-	w         *watcher.Watcher
-	ch        chan watcher.Change
-	oldPeriod time.Duration
+	log              *mgo.Collection
+	stash            *mgo.Collection
+	runner           *txn.Runner
+	w                *watcher.Watcher
+	ch               chan watcher.Change
+	oldPeriod        time.Duration
+	oldTailing       bool
+	oldTailingPeriod time.Duration
 }
 
 // FastPeriodSuite implements tests that should
@@ -65,6 +67,7 @@ type FastPeriodSuite struct {
 func (s *FastPeriodSuite) SetUpSuite(c *gc.C) {
 	s.watcherSuite.SetUpSuite(c)
 	watcher.Period = fastPeriod
+	watcher.TailingPeriod = fastPeriod
 }
 
 var _ = gc.Suite(&FastPeriodSuite{})
@@ -73,12 +76,16 @@ func (s *watcherSuite) SetUpSuite(c *gc.C) {
 	s.BaseSuite.SetUpSuite(c)
 	s.MgoSuite.SetUpSuite(c)
 	s.oldPeriod = watcher.Period
+	s.oldTailing = watcher.Tailing
+	s.oldTailingPeriod = watcher.TailingPeriod
 }
 
 func (s *watcherSuite) TearDownSuite(c *gc.C) {
 	s.MgoSuite.TearDownSuite(c)
 	s.BaseSuite.TearDownSuite(c)
 	watcher.Period = s.oldPeriod
+	watcher.Tailing = s.oldTailing
+	watcher.TailingPeriod = s.oldTailingPeriod
 }
 
 func (s *watcherSuite) SetUpTest(c *gc.C) {
@@ -211,6 +218,30 @@ func (s *FastPeriodSuite) TestErrAndDead(c *gc.C) {
 	}
 }
 
+func (s *FastPeriodSuite) TestStats(c *gc.C) {
+	s.w.Watch("test", "a", -1, s.ch)
+	s.w.WatchCollection("test", s.ch)
+	revno := s.insert(c, "test", "a")
+
+	// Once the change has been delivered, both watch
+	// requests and at least one sync have been handled.
+	s.w.StartSync()
+	assertChange(c, s.ch, watcher.Change{"test", "a", revno})
+	assertChange(c, s.ch, watcher.Change{"test", "a", revno})
+	stats := s.w.Stats()
+	c.Assert(stats.Watches, gc.Equals, 2)
+	c.Assert(stats.Syncs > 0, gc.Equals, true)
+	c.Assert(stats.SyncTime >= stats.LastSyncTime, gc.Equals, true)
+
+	s.w.Unwatch("test", "a", s.ch)
+	s.w.UnwatchCollection("test", s.ch)
+	s.w.Watch("test", "b", -1, s.ch)
+	revno = s.insert(c, "test", "b")
+	s.w.StartSync()
+	assertChange(c, s.ch, watcher.Change{"test", "b", revno})
+	c.Assert(s.w.Stats().Watches, gc.Equals, 1)
+}
+
 func (s *FastPeriodSuite) TestWatchBeforeKnown(c *gc.C) {
 	s.w.Watch("test", "a", -1, s.ch)
 	assertNoChange(c, s.ch)
@@ -609,7 +640,8 @@ func (s *FastPeriodSuite) TestNonMutatingTxn(c *gc.C) {
 
 // SlowPeriodSuite implements tests
 // that are flaky when the watcher refresh period
-// is small.
+// is small. Tailing is disabled, so that changes
+// are only seen when the watcher syncs.
 type SlowPeriodSuite struct {
 	watcherSuite
 }
@@ -617,6 +649,7 @@ type SlowPeriodSuite struct {
 func (s *SlowPeriodSuite) SetUpSuite(c *gc.C) {
 	s.watcherSuite.SetUpSuite(c)
 	watcher.Period = slowPeriod
+	watcher.Tailing = false
 }
 
 var _ = gc.Suite(&SlowPeriodSuite{})
@@ -702,3 +735,59 @@ func (s *SlowPeriodSuite) TestStartSyncStartsImmediately(c *gc.C) {
 	case <-time.After(justLongEnough):
 	}
 }
+
+// TailingSuite implements tests that rely on the
+// watcher tailing the changelog. The refresh period
+// is long enough that only tailing can deliver
+// changes within the tests' timeouts.
+type TailingSuite struct {
+	watcherSuite
+}
+
+func (s *TailingSuite) SetUpSuite(c *gc.C) {
+	s.watcherSuite.SetUpSuite(c)
+	watcher.Tailing = true
+	watcher.TailingPeriod = time.Hour
+}
+
+var _ = gc.Suite(&TailingSuite{})
+
+func (s *TailingSuite) TestChangesDeliveredWithoutSync(c *gc.C) {
+	revno1 := s.insert(c, "test", "a")
+	s.w.StartSync()
+	s.w.Watch("test", "a", revno1, s.ch)
+	assertNoChange(c, s.ch)
+
+	revno2 := s.update(c, "test", "a")
+	assertChange(c, s.ch, watcher.Change{"test", "a", revno2})
+
+	revno3 := s.remove(c, "test", "a")
+	assertChange(c, s.ch, watcher.Change{"test", "a", revno3})
+	assertNoChange(c, s.ch)
+}
+
+func (s *TailingSuite) TestWatchCollectionWithoutSync(c *gc.C) {
+	s.insert(c, "test", "a")
+	s.w.StartSync()
+	s.w.WatchCollection("test", s.ch)
+	assertNoChange(c, s.ch)
+
+	revno := s.insert(c, "test", "b")
+	assertChange(c, s.ch, watcher.Change{"test", "b", revno})
+	assertNoChange(c, s.ch)
+}
+
+func (s *TailingSuite) TestStopWhileTailing(c *gc.C) {
+	s.insert(c, "test", "a")
+	s.w.StartSync()
+	done := make(chan error)
+	go func() {
+		done <- s.w.Stop()
+	}()
+	select {
+	case err := <-done:
+		c.Assert(err, gc.IsNil)
+	case <-time.After(worstCase):
+		c.Fatalf("watcher did not stop")
+	}
+}
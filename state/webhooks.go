@@ -0,0 +1,307 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+)
+
+// webhookEntityKinds holds the kinds of entity whose
+// changes can be delivered to webhooks.
+var webhookEntityKinds = map[string]bool{
+	"machine":    true,
+	"service":    true,
+	"unit":       true,
+	"relation":   true,
+	"annotation": true,
+}
+
+// WebhookInfo holds the parameters for adding a webhook.
+type WebhookInfo struct {
+	// URL holds the http or https URL that changes are posted to.
+	URL string
+
+	// Secret holds the key used to sign the posted changes.
+	Secret string
+
+	// EntityKinds, if not empty, restricts the changes
+	// posted to those of entities of the given kinds.
+	EntityKinds []string
+
+	// EntityIds, if not empty, restricts the changes
+	// posted to those of entities with the given ids.
+	EntityIds []string
+}
+
+// webhookDoc represents a webhook subscription in MongoDB.
+type webhookDoc struct {
+	Id          string `bson:"_id"`
+	URL         string
+	Secret      string
+	EntityKinds []string
+	EntityIds   []string
+}
+
+// Webhook represents a subscription to changes in the environment,
+// which are posted to a URL as they happen.
+type Webhook struct {
+	st  *State
+	doc webhookDoc
+}
+
+func newWebhook(st *State, doc *webhookDoc) *Webhook {
+	return &Webhook{st: st, doc: *doc}
+}
+
+// Id returns the webhook id.
+func (w *Webhook) Id() string {
+	return w.doc.Id
+}
+
+// URL returns the URL that changes are posted to.
+func (w *Webhook) URL() string {
+	return w.doc.URL
+}
+
+// Secret returns the key used to sign the posted changes.
+func (w *Webhook) Secret() string {
+	return w.doc.Secret
+}
+
+// EntityKinds returns the kinds of entity whose changes are
+// posted, or nil if changes to all kinds are posted.
+func (w *Webhook) EntityKinds() []string {
+	return w.doc.EntityKinds
+}
+
+// EntityIds returns the ids of the entities whose changes are
+// posted, or nil if changes to all entities are posted.
+func (w *Webhook) EntityIds() []string {
+	return w.doc.EntityIds
+}
+
+// Matches returns whether changes to the entity with
+// the given kind and id are posted to the webhook.
+func (w *Webhook) Matches(kind, id string) bool {
+	return matchesAny(w.doc.EntityKinds, kind) && matchesAny(w.doc.EntityIds, id)
+}
+
+// matchesAny returns whether s is in filter,
+// or true if the filter is empty.
+func matchesAny(filter []string, s string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if f == s {
+			return true
+		}
+	}
+	return false
+}
+
+// AddWebhook adds a webhook subscription to the environment.
+func (st *State) AddWebhook(info WebhookInfo) (w *Webhook, err error) {
+	defer errors.Contextf(&err, "cannot add webhook for %q", info.URL)
+	u, err := url.Parse(info.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("URL must be an absolute http or https URL")
+	}
+	if info.Secret == "" {
+		return nil, fmt.Errorf("secret must be not empty")
+	}
+	for _, kind := range info.EntityKinds {
+		if !webhookEntityKinds[kind] {
+			return nil, fmt.Errorf("unknown entity kind %q", kind)
+		}
+	}
+	seq, err := st.sequence("webhook")
+	if err != nil {
+		return nil, err
+	}
+	doc := &webhookDoc{
+		Id:          strconv.Itoa(seq),
+		URL:         info.URL,
+		Secret:      info.Secret,
+		EntityKinds: info.EntityKinds,
+		EntityIds:   info.EntityIds,
+	}
+	ops := []txn.Op{{
+		C:      st.webhooks.Name,
+		Id:     doc.Id,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	if err := st.runTransaction(ops); err != nil {
+		return nil, onAbort(err, fmt.Errorf("webhook %s already exists", doc.Id))
+	}
+	return newWebhook(st, doc), nil
+}
+
+// Webhook returns the webhook with the given id.
+func (st *State) Webhook(id string) (*Webhook, error) {
+	doc := &webhookDoc{}
+	err := st.webhooks.FindId(id).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("webhook %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get webhook %s: %v", id, err)
+	}
+	return newWebhook(st, doc), nil
+}
+
+// AllWebhooks returns all the webhooks in the environment,
+// ordered by id.
+func (st *State) AllWebhooks() ([]*Webhook, error) {
+	docs := webhookDocSlice{}
+	if err := st.webhooks.Find(nil).All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get webhooks: %v", err)
+	}
+	sort.Sort(docs)
+	webhooks := make([]*Webhook, len(docs))
+	for i := range docs {
+		webhooks[i] = newWebhook(st, &docs[i])
+	}
+	return webhooks, nil
+}
+
+type webhookDocSlice []webhookDoc
+
+func (ws webhookDocSlice) Len() int      { return len(ws) }
+func (ws webhookDocSlice) Swap(i, j int) { ws[i], ws[j] = ws[j], ws[i] }
+func (ws webhookDocSlice) Less(i, j int) bool {
+	// Webhook ids are sequence numbers, so
+	// shorter ids sort before longer ones.
+	if len(ws[i].Id) != len(ws[j].Id) {
+		return len(ws[i].Id) < len(ws[j].Id)
+	}
+	return ws[i].Id < ws[j].Id
+}
+
+// RemoveWebhook removes the webhook with the given
+// id, along with the deliveries that failed for it.
+func (st *State) RemoveWebhook(id string) error {
+	ops := []txn.Op{{
+		C:      st.webhooks.Name,
+		Id:     id,
+		Assert: txn.DocExists,
+		Remove: true,
+	}}
+	if err := st.runTransaction(ops); err != nil {
+		return onAbort(err, errors.NotFoundf("webhook %s", id))
+	}
+	_, err := st.webhookFailures.RemoveAll(bson.D{{"webhookid", id}})
+	if err != nil {
+		return fmt.Errorf("cannot remove failed deliveries for webhook %s: %v", id, err)
+	}
+	return nil
+}
+
+// WebhookFailure records a delivery to a webhook that
+// could not be made, even after retrying.
+type WebhookFailure struct {
+	WebhookId string
+	URL       string
+	Payload   string
+	Attempts  int
+	Error     string
+	Time      time.Time
+}
+
+// webhookFailureDoc is stored in the webhookfailures collection,
+// which holds the dead letters of webhook deliveries. They are
+// recorded outside of transactions, as nothing needs to watch them.
+type webhookFailureDoc struct {
+	Id        bson.ObjectId `bson:"_id"`
+	WebhookId string
+	URL       string
+	Payload   string
+	Attempts  int
+	Error     string
+	Time      time.Time
+}
+
+// maxWebhookFailures holds the number of failed deliveries kept for
+// each webhook. When more fail, the oldest ones are forgotten.
+var maxWebhookFailures = 100
+
+// AddWebhookFailure records a failed webhook delivery. Only the
+// most recent failed deliveries to each webhook are kept.
+func (st *State) AddWebhookFailure(f WebhookFailure) error {
+	doc := &webhookFailureDoc{
+		Id:        bson.NewObjectId(),
+		WebhookId: f.WebhookId,
+		URL:       f.URL,
+		Payload:   f.Payload,
+		Attempts:  f.Attempts,
+		Error:     f.Error,
+		Time:      f.Time.UTC(),
+	}
+	if err := st.webhookFailures.Insert(doc); err != nil {
+		return fmt.Errorf("cannot record failed delivery for webhook %s: %v", f.WebhookId, err)
+	}
+	// Forget the failures older than the oldest one kept.
+	var oldest struct {
+		Id bson.ObjectId `bson:"_id"`
+	}
+	err := st.webhookFailures.Find(bson.D{{"webhookid", f.WebhookId}}).
+		Sort("-_id").Skip(maxWebhookFailures - 1).Select(bson.D{{"_id", 1}}).One(&oldest)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	if err == nil {
+		_, err = st.webhookFailures.RemoveAll(bson.D{
+			{"webhookid", f.WebhookId},
+			{"_id", bson.D{{"$lt", oldest.Id}}},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("cannot forget old failed deliveries for webhook %s: %v", f.WebhookId, err)
+	}
+	return nil
+}
+
+// WebhookFailureCount returns the number of failed deliveries
+// recorded for the webhook with the given id.
+func (st *State) WebhookFailureCount(id string) (int, error) {
+	n, err := st.webhookFailures.Find(bson.D{{"webhookid", id}}).Count()
+	if err != nil {
+		return 0, fmt.Errorf("cannot count failed deliveries for webhook %s: %v", id, err)
+	}
+	return n, nil
+}
+
+// WebhookFailures returns the failed deliveries recorded
+// for the webhook with the given id, oldest first.
+func (st *State) WebhookFailures(id string) ([]WebhookFailure, error) {
+	var docs []webhookFailureDoc
+	if err := st.webhookFailures.Find(bson.D{{"webhookid", id}}).Sort("_id").All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get failed deliveries for webhook %s: %v", id, err)
+	}
+	failures := make([]WebhookFailure, len(docs))
+	for i, doc := range docs {
+		failures[i] = WebhookFailure{
+			WebhookId: doc.WebhookId,
+			URL:       doc.URL,
+			Payload:   doc.Payload,
+			Attempts:  doc.Attempts,
+			Error:     doc.Error,
+			Time:      doc.Time.UTC(),
+		}
+	}
+	return failures, nil
+}
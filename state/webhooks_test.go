@@ -0,0 +1,183 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/state"
+)
+
+type WebhooksSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&WebhooksSuite{})
+
+func (s *WebhooksSuite) TestAddWebhook(c *gc.C) {
+	w, err := s.State.AddWebhook(state.WebhookInfo{
+		URL:         "https://example.com/hook",
+		Secret:      "s3cret",
+		EntityKinds: []string{"unit"},
+		EntityIds:   []string{"wordpress/0"},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(w.URL(), gc.Equals, "https://example.com/hook")
+	c.Assert(w.Secret(), gc.Equals, "s3cret")
+	c.Assert(w.EntityKinds(), gc.DeepEquals, []string{"unit"})
+	c.Assert(w.EntityIds(), gc.DeepEquals, []string{"wordpress/0"})
+
+	w1, err := s.State.Webhook(w.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(w1.URL(), gc.Equals, w.URL())
+	c.Assert(w1.EntityIds(), gc.DeepEquals, w.EntityIds())
+}
+
+func (s *WebhooksSuite) TestAddWebhookInvalid(c *gc.C) {
+	for i, test := range []struct {
+		info state.WebhookInfo
+		err  string
+	}{{
+		info: state.WebhookInfo{URL: "ftp://example.com", Secret: "x"},
+		err:  `cannot add webhook for "ftp://example.com": URL must be an absolute http or https URL`,
+	}, {
+		info: state.WebhookInfo{URL: "/hook", Secret: "x"},
+		err:  `cannot add webhook for "/hook": URL must be an absolute http or https URL`,
+	}, {
+		info: state.WebhookInfo{URL: "http://example.com"},
+		err:  `cannot add webhook for "http://example.com": secret must be not empty`,
+	}, {
+		info: state.WebhookInfo{URL: "http://example.com", Secret: "x", EntityKinds: []string{"bogus"}},
+		err:  `cannot add webhook for "http://example.com": unknown entity kind "bogus"`,
+	}} {
+		c.Logf("test %d", i)
+		_, err := s.State.AddWebhook(test.info)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *WebhooksSuite) TestMatches(c *gc.C) {
+	w, err := s.State.AddWebhook(state.WebhookInfo{URL: "http://example.com", Secret: "x"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(w.Matches("machine", "0"), jc.IsTrue)
+
+	w, err = s.State.AddWebhook(state.WebhookInfo{
+		URL:         "http://example.com",
+		Secret:      "x",
+		EntityKinds: []string{"unit", "service"},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(w.Matches("unit", "wordpress/0"), jc.IsTrue)
+	c.Assert(w.Matches("machine", "0"), jc.IsFalse)
+
+	w, err = s.State.AddWebhook(state.WebhookInfo{
+		URL:         "http://example.com",
+		Secret:      "x",
+		EntityKinds: []string{"unit"},
+		EntityIds:   []string{"wordpress/0"},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(w.Matches("unit", "wordpress/0"), jc.IsTrue)
+	c.Assert(w.Matches("unit", "wordpress/1"), jc.IsFalse)
+	c.Assert(w.Matches("service", "wordpress/0"), jc.IsFalse)
+}
+
+func (s *WebhooksSuite) TestAllAndRemoveWebhooks(c *gc.C) {
+	all, err := s.State.AllWebhooks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 0)
+
+	var ids []string
+	for i := 0; i < 11; i++ {
+		w, err := s.State.AddWebhook(state.WebhookInfo{URL: "http://example.com", Secret: "x"})
+		c.Assert(err, gc.IsNil)
+		ids = append(ids, w.Id())
+	}
+	all, err = s.State.AllWebhooks()
+	c.Assert(err, gc.IsNil)
+	var allIds []string
+	for _, w := range all {
+		allIds = append(allIds, w.Id())
+	}
+	c.Assert(allIds, gc.DeepEquals, ids)
+
+	err = s.State.AddWebhookFailure(state.WebhookFailure{
+		WebhookId: ids[0],
+		URL:       "http://example.com",
+		Payload:   "[]",
+		Attempts:  3,
+		Error:     "connection refused",
+		Time:      time.Now(),
+	})
+	c.Assert(err, gc.IsNil)
+
+	err = s.State.RemoveWebhook(ids[0])
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.Webhook(ids[0])
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	failures, err := s.State.WebhookFailures(ids[0])
+	c.Assert(err, gc.IsNil)
+	c.Assert(failures, gc.HasLen, 0)
+
+	err = s.State.RemoveWebhook(ids[0])
+	c.Assert(err, gc.ErrorMatches, "webhook .* not found")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *WebhooksSuite) TestWebhookFailures(c *gc.C) {
+	w, err := s.State.AddWebhook(state.WebhookInfo{URL: "http://example.com", Secret: "x"})
+	c.Assert(err, gc.IsNil)
+	now := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		err = s.State.AddWebhookFailure(state.WebhookFailure{
+			WebhookId: w.Id(),
+			URL:       w.URL(),
+			Payload:   "[]",
+			Attempts:  i + 1,
+			Error:     "connection refused",
+			Time:      now,
+		})
+		c.Assert(err, gc.IsNil)
+	}
+	failures, err := s.State.WebhookFailures(w.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(failures, gc.HasLen, 2)
+	c.Assert(failures[0].Attempts, gc.Equals, 1)
+	c.Assert(failures[1].Attempts, gc.Equals, 2)
+	c.Assert(failures[1].Time.Equal(now), jc.IsTrue)
+	c.Assert(failures[1].Error, gc.Equals, "connection refused")
+	n, err := s.State.WebhookFailureCount(w.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(n, gc.Equals, 2)
+}
+
+func (s *WebhooksSuite) TestWebhookFailuresCapped(c *gc.C) {
+	s.PatchValue(state.MaxWebhookFailures, 3)
+	w, err := s.State.AddWebhook(state.WebhookInfo{URL: "http://example.com", Secret: "x"})
+	c.Assert(err, gc.IsNil)
+	for i := 0; i < 5; i++ {
+		err = s.State.AddWebhookFailure(state.WebhookFailure{
+			WebhookId: w.Id(),
+			URL:       w.URL(),
+			Payload:   "[]",
+			Attempts:  i + 1,
+			Error:     "connection refused",
+			Time:      time.Now(),
+		})
+		c.Assert(err, gc.IsNil)
+	}
+	// Only the most recent failures are kept.
+	failures, err := s.State.WebhookFailures(w.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(failures, gc.HasLen, 3)
+	c.Assert(failures[0].Attempts, gc.Equals, 3)
+	c.Assert(failures[2].Attempts, gc.Equals, 5)
+	n, err := s.State.WebhookFailureCount(w.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(n, gc.Equals, 3)
+}
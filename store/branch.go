@@ -11,6 +11,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/juju/core/charm"
 )
@@ -86,7 +87,72 @@ NewTip:
 		}
 	}
 
-	ch, err := charm.ReadDir(branchDir)
+	return publishCharmDir(store, pub, urls, digest, branchDir)
+}
+
+// PublishGitRepository clones the Git repository from repoURL and
+// publishes the commit at its head at urls in the given store. The
+// digest parameter must be the most recent known commit id of the
+// repository's head. If publishing this specific digest for these
+// URLs has been attempted already, the publishing procedure may abort
+// early. As with Bazaar branches, the published digest is the commit
+// id of the cloned repository's head, which may differ from the digest
+// parameter: publishing any other commit would allow an old version to
+// overwrite a newer one.
+func PublishGitRepository(store *Store, urls []*charm.URL, repoURL string, digest string) error {
+
+	// Prevent other publishers from updating these specific URLs
+	// concurrently.
+	lock, err := store.LockUpdates(urls)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	var repoDir string
+NewHead:
+	pub, err := store.CharmPublisher(urls, digest)
+	if err != nil {
+		return err
+	}
+	event, err := store.CharmEvent(urls[0], digest)
+	if err == nil && event.Kind != EventPublished {
+		return fmt.Errorf("charm publishing previously failed: %s", strings.Join(event.Errors, "; "))
+	} else if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	if repoDir == "" {
+		// History doesn't matter here, so a shallow
+		// clone of the head is enough.
+		tempDir, err := ioutil.TempDir("", "publish-repository-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tempDir)
+		repoDir = filepath.Join(tempDir, "repository")
+		cmd := exec.Command("git", "clone", "-q", "--depth", "1", repoURL, repoDir)
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		output, err := runTimeout(cmd, gitTimeout)
+		if err != nil {
+			return outputErr(output, err)
+		}
+		headDigest, err := gitRun(repoDir, "rev-parse", "HEAD")
+		if err != nil {
+			return err
+		}
+		if headDigest != digest {
+			digest = headDigest
+			goto NewHead
+		}
+	}
+	return publishCharmDir(store, pub, urls, digest, repoDir)
+}
+
+// publishCharmDir publishes the charm in dir with pub, and logs the
+// outcome as a charm event for urls and digest.
+func publishCharmDir(store *Store, pub *CharmPublisher, urls []*charm.URL, digest string, dir string) error {
+	ch, err := charm.ReadDir(dir)
 	if err == nil {
 		// Hand over the charm to the store for bundling and
 		// streaming its content into the database.
@@ -104,7 +170,7 @@ NewTip:
 	}
 
 	// Publishing is done. Log failure or error.
-	event = &CharmEvent{
+	event := &CharmEvent{
 		URLs:   urls,
 		Digest: digest,
 	}
@@ -125,6 +191,45 @@ NewTip:
 	return err
 }
 
+// gitTimeout holds the time a git command run while publishing
+// may take before it is killed.
+var gitTimeout = 5 * time.Minute
+
+// gitRun runs git with args in repoDir and returns its
+// output with surrounding white space removed.
+func gitRun(repoDir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoDir
+	output, err := runTimeout(cmd, gitTimeout)
+	if err != nil {
+		return "", outputErr(output, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// runTimeout runs cmd and returns its combined output. The
+// command is killed if it doesn't finish within timeout.
+func runTimeout(cmd *exec.Cmd, timeout time.Duration) ([]byte, error) {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		return output.Bytes(), err
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		<-done
+		return output.Bytes(), fmt.Errorf("%s timed out after %v", cmd.Args[0], timeout)
+	}
+}
+
 // bzrRevisionId returns the Bazaar revision id for the branch in branchDir.
 func bzrRevisionId(branchDir string) (string, error) {
 	cmd := exec.Command("bzr", "revision-info")
@@ -166,6 +166,90 @@ func (s *StoreSuite) TestPublishErrorInCharm(c *gc.C) {
 	c.Assert(event.Warnings, gc.IsNil)
 }
 
+func (s *StoreSuite) dummyRepository(c *gc.C, suffix string) gitDir {
+	tmpDir := c.MkDir()
+	if suffix != "" {
+		tmpDir = filepath.Join(tmpDir, suffix)
+		err := os.MkdirAll(tmpDir, 0755)
+		c.Assert(err, gc.IsNil)
+	}
+	repo := gitDir(tmpDir)
+	repo.run("init", "-q")
+	copyCharmDir(repo.path(), testing.Charms.Dir("dummy"))
+	repo.run("add", ".")
+	repo.run("commit", "-q", "-m", "Imported charm.")
+	return repo
+}
+
+func (s *StoreSuite) TestPublishGit(c *gc.C) {
+	repo := s.dummyRepository(c, "")
+	digest0 := repo.digest()
+
+	err := store.PublishGitRepository(s.store, urls, repo.path(), digest0)
+	c.Assert(err, gc.IsNil)
+	info, err := s.store.CharmInfo(urls[0])
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Revision(), gc.Equals, 0)
+	c.Assert(info.Digest(), gc.Equals, digest0)
+
+	err = store.PublishGitRepository(s.store, urls, repo.path(), digest0)
+	c.Assert(err, gc.Equals, store.ErrRedundantUpdate)
+
+	// The head of the repository is published whatever
+	// the digest given, so an old commit can never be
+	// published over a newer one.
+	repo.change()
+	oldDigest := repo.digest()
+	repo.change()
+	digest1 := repo.digest()
+	err = store.PublishGitRepository(s.store, urls, repo.path(), oldDigest)
+	c.Assert(err, gc.IsNil)
+	info, err = s.store.CharmInfo(urls[1])
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Revision(), gc.Equals, 1)
+	c.Assert(info.Digest(), gc.Equals, digest1)
+	_, err = s.store.CharmEvent(urls[0], oldDigest)
+	c.Assert(err, gc.Equals, store.ErrNotFound)
+
+	for i, digest := range []string{digest0, digest1} {
+		event, err := s.store.CharmEvent(urls[0], digest)
+		c.Assert(err, gc.IsNil)
+		c.Assert(event.Kind, gc.Equals, store.EventPublished)
+		c.Assert(event.Revision, gc.Equals, i)
+	}
+}
+
+func (s *StoreSuite) TestPublishGitBadRepository(c *gc.C) {
+	err := store.PublishGitRepository(s.store, urls, c.MkDir(), "0123456789012345678901234567890123456789")
+	c.Assert(err, gc.ErrorMatches, "(?s).*exit status.*")
+
+	_, err = s.store.CharmInfo(urls[0])
+	c.Assert(err, gc.Equals, store.ErrNotFound)
+}
+
+func (s *StoreSuite) TestRunTimeout(c *gc.C) {
+	output, err := store.RunTimeout(exec.Command("echo", "hello"), testing.LongWait)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(output), gc.Equals, "hello\n")
+
+	_, err = store.RunTimeout(exec.Command("sleep", "60"), testing.ShortWait)
+	c.Assert(err, gc.ErrorMatches, "sleep timed out after .*")
+}
+
+func (s *StoreSuite) TestPublishGitErrorInCharm(c *gc.C) {
+	repo := s.dummyRepository(c, "")
+	repo.run("rm", "-q", "metadata.yaml")
+	repo.run("commit", "-q", "-m", "Removed metadata.yaml.")
+
+	err := store.PublishGitRepository(s.store, urls, repo.path(), repo.digest())
+	c.Assert(err, gc.ErrorMatches, ".*/metadata.yaml: no such file or directory")
+
+	event, err := s.store.CharmEvent(urls[0], repo.digest())
+	c.Assert(err, gc.IsNil)
+	c.Assert(event.Kind, gc.Equals, store.EventPublishError)
+	c.Assert(event.Errors[0], gc.Matches, ".*/metadata.yaml: no such file or directory")
+}
+
 type bzrDir string
 
 func (dir bzrDir) path(args ...string) string {
@@ -241,3 +325,42 @@ func copyCharmDir(dst string, dir *charm.Dir) {
 		panic(err)
 	}
 }
+
+type gitDir string
+
+func (dir gitDir) path(args ...string) string {
+	return filepath.Join(append([]string{string(dir)}, args...)...)
+}
+
+func (dir gitDir) run(args ...string) []byte {
+	cmd := exec.Command("git", args...)
+	// git will refuse to commit without an identity,
+	// so provide one through the environment.
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=nobody",
+		"GIT_AUTHOR_EMAIL=nobody@testing.invalid",
+		"GIT_COMMITTER_NAME=nobody",
+		"GIT_COMMITTER_EMAIL=nobody@testing.invalid",
+	)
+	cmd.Dir = string(dir)
+	output, err := cmd.Output()
+	if err != nil {
+		panic(fmt.Sprintf("command failed: git %s\n%s", strings.Join(args, " "), output))
+	}
+	return output
+}
+
+func (dir gitDir) change() {
+	t := time.Now().String()
+	err := ioutil.WriteFile(dir.path("timestamp"), []byte(t), 0644)
+	if err != nil {
+		panic(err)
+	}
+	dir.run("add", "timestamp")
+	dir.run("commit", "-q", "-m", "Revision bumped at "+t)
+}
+
+func (dir gitDir) digest() string {
+	return strings.TrimSpace(string(dir.run("rev-parse", "HEAD")))
+}
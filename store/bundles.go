@@ -0,0 +1,168 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+
+	"github.com/juju/core/charm"
+)
+
+// BundleInfo holds the details of a bundle revision stored in the store.
+type BundleInfo struct {
+	revision int
+	digest   string
+	sha256   string
+	data     []byte
+}
+
+// Revision returns the store bundle's revision.
+func (bi *BundleInfo) Revision() int {
+	return bi.revision
+}
+
+// Digest returns the unique identifier that represents the bundle
+// data imported. This is typically set to the VCS revision digest.
+func (bi *BundleInfo) Digest() string {
+	return bi.digest
+}
+
+// Sha256 returns the sha256 checksum for the bundle data.
+func (bi *BundleInfo) Sha256() string {
+	return bi.sha256
+}
+
+// Data returns the content of the bundle.yaml file of the bundle.
+func (bi *BundleInfo) Data() []byte {
+	return bi.data
+}
+
+// BundleData returns the parsed content of the bundle.
+func (bi *BundleInfo) BundleData() (*charm.BundleData, error) {
+	return charm.ReadBundleData(bytes.NewReader(bi.data))
+}
+
+// bundleDoc represents the document stored in MongoDB for a bundle.
+type bundleDoc struct {
+	URLs     []*charm.URL
+	Revision int
+	Digest   string
+	Sha256   string
+	Data     []byte
+}
+
+// PublishBundle verifies the bundle with the given bundle.yaml content
+// and makes it available in the store at all of the provided urls, which
+// must have the bundle series and no revision. Every charm used by the
+// bundle must be in the store, and the endpoints of its relations must
+// match the interfaces of those charms. The digest parameter must contain
+// the unique identifier that represents the bundle data being imported
+// (e.g. the VCS revision sha1). ErrRedundantUpdate is returned if all of
+// the provided urls are already associated to that digest.
+func (s *Store) PublishBundle(urls []*charm.URL, digest string, data []byte) (*BundleInfo, error) {
+	logger.Infof("trying to add bundles %v with key %q...", urls, digest)
+	if err := mustLackRevision("PublishBundle", urls...); err != nil {
+		return nil, err
+	}
+	for _, url := range urls {
+		if url.Series != charm.BundleSeries {
+			return nil, fmt.Errorf("PublishBundle: got non-bundle URL: %s", url)
+		}
+	}
+	bd, err := charm.ReadBundleData(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	err = bd.Verify(func(curl *charm.URL) (*charm.Meta, error) {
+		info, err := s.CharmInfo(curl)
+		if err != nil {
+			return nil, err
+		}
+		return info.Meta(), nil
+	})
+	if err != nil {
+		logger.Errorf("cannot publish bundles %v: %v", urls, err)
+		return nil, err
+	}
+
+	session := s.session.Copy()
+	defer session.Close()
+	bundles := session.Bundles()
+	maxRev := -1
+	newKey := false
+	for _, url := range urls {
+		var doc bundleDoc
+		err := bundles.Find(bson.D{{"urls", url}}).Sort("-revision").One(&doc)
+		if err == mgo.ErrNotFound {
+			newKey = true
+			continue
+		}
+		if err != nil {
+			logger.Errorf("unknown error looking for bundle %s: %s", url, err)
+			return nil, err
+		}
+		if doc.Digest != digest {
+			newKey = true
+		}
+		if doc.Revision > maxRev {
+			maxRev = doc.Revision
+		}
+	}
+	if !newKey {
+		logger.Infof("all bundles have revision key %q. Nothing to update.", digest)
+		return nil, ErrRedundantUpdate
+	}
+	hash := sha256.New()
+	hash.Write(data)
+	doc := bundleDoc{
+		URLs:     urls,
+		Revision: maxRev + 1,
+		Digest:   digest,
+		Sha256:   hex.EncodeToString(hash.Sum(nil)),
+		Data:     data,
+	}
+	if err := bundles.Insert(&doc); err != nil {
+		err = maybeConflict(err)
+		logger.Errorf("failed to insert new revision of bundle %v: %v", urls, err)
+		return nil, err
+	}
+	return doc.info(), nil
+}
+
+// BundleInfo retrieves the BundleInfo value for the bundle at url.
+// If url has no revision, the latest revision is returned.
+func (s *Store) BundleInfo(url *charm.URL) (*BundleInfo, error) {
+	session := s.session.Copy()
+	defer session.Close()
+
+	query := bson.D{{"urls", url.WithRevision(-1)}}
+	if url.Revision != -1 {
+		query = append(query, bson.DocElem{"revision", url.Revision})
+	}
+	var doc bundleDoc
+	err := session.Bundles().Find(query).Sort("-revision").One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		logger.Errorf("failed to find bundle %s: %v", url, err)
+		return nil, err
+	}
+	return doc.info(), nil
+}
+
+func (doc *bundleDoc) info() *BundleInfo {
+	return &BundleInfo{
+		revision: doc.Revision,
+		digest:   doc.Digest,
+		sha256:   doc.Sha256,
+		data:     doc.Data,
+	}
+}
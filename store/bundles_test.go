@@ -0,0 +1,171 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package store_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/store"
+)
+
+const testBundle = `
+series: precise
+services:
+    wordpress:
+        charm: wordpress
+        num_units: 2
+    mysql:
+        charm: cs:precise/mysql
+relations:
+    - [wordpress, mysql]
+`
+
+func (s *StoreSuite) publishBundleCharms(c *gc.C) {
+	s.publishTestCharm(c, "wordpress", "cs:precise/wordpress")
+	s.publishTestCharm(c, "mysql", "cs:precise/mysql")
+}
+
+func (s *StoreSuite) TestPublishBundle(c *gc.C) {
+	s.publishBundleCharms(c)
+	urls := []*charm.URL{
+		charm.MustParseURL("cs:bundle/wordpress-simple"),
+		charm.MustParseURL("cs:~joe/bundle/wordpress"),
+	}
+	info, err := s.store.PublishBundle(urls, "digest-0", []byte(testBundle))
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Revision(), gc.Equals, 0)
+	c.Assert(info.Digest(), gc.Equals, "digest-0")
+	c.Assert(info.Sha256(), gc.HasLen, 64)
+
+	for _, url := range urls {
+		info, err := s.store.BundleInfo(url)
+		c.Assert(err, gc.IsNil)
+		c.Assert(info.Revision(), gc.Equals, 0)
+		c.Assert(string(info.Data()), gc.Equals, testBundle)
+		bd, err := info.BundleData()
+		c.Assert(err, gc.IsNil)
+		c.Assert(bd.Services["wordpress"].NumUnits, gc.Equals, 2)
+	}
+
+	// Publishing the same digest again is redundant.
+	_, err = s.store.PublishBundle(urls, "digest-0", []byte(testBundle))
+	c.Assert(err, gc.Equals, store.ErrRedundantUpdate)
+
+	// A new digest makes a new revision, and old revisions remain available.
+	info, err = s.store.PublishBundle(urls[:1], "digest-1", []byte(testBundle+"    - [wordpress, mysql]\n"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Revision(), gc.Equals, 1)
+	info, err = s.store.BundleInfo(urls[0])
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Revision(), gc.Equals, 1)
+	info, err = s.store.BundleInfo(urls[0].WithRevision(0))
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Digest(), gc.Equals, "digest-0")
+	info, err = s.store.BundleInfo(urls[1])
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Revision(), gc.Equals, 0)
+}
+
+func (s *StoreSuite) TestPublishBundleErrors(c *gc.C) {
+	s.publishTestCharm(c, "wordpress", "cs:precise/wordpress")
+	burl := charm.MustParseURL("cs:bundle/wordpress-simple")
+
+	_, err := s.store.PublishBundle([]*charm.URL{burl.WithRevision(1)}, "digest", []byte(testBundle))
+	c.Assert(err, gc.ErrorMatches, "PublishBundle: got charm URL with revision: cs:bundle/wordpress-simple-1")
+
+	_, err = s.store.PublishBundle([]*charm.URL{charm.MustParseURL("cs:precise/wordpress")}, "digest", []byte(testBundle))
+	c.Assert(err, gc.ErrorMatches, "PublishBundle: got non-bundle URL: cs:precise/wordpress")
+
+	// The mysql charm is not in the store.
+	_, err = s.store.PublishBundle([]*charm.URL{burl}, "digest", []byte(testBundle))
+	c.Assert(err, gc.ErrorMatches, `bundle verification failed: cannot get charm "cs:precise/mysql" of service "mysql": entry not found`)
+	c.Assert(err, gc.FitsTypeOf, &charm.VerificationError{})
+
+	_, err = s.store.BundleInfo(burl)
+	c.Assert(err, gc.Equals, store.ErrNotFound)
+}
+
+func (s *StoreSuite) prepareBundleServer(c *gc.C) (*store.Server, *charm.URL) {
+	s.publishBundleCharms(c)
+	burl := charm.MustParseURL("cs:bundle/wordpress-simple")
+	_, err := s.store.PublishBundle([]*charm.URL{burl}, "some-digest", []byte(testBundle))
+	c.Assert(err, gc.IsNil)
+	server, err := store.NewServer(s.store)
+	c.Assert(err, gc.IsNil)
+	return server, burl
+}
+
+func (s *StoreSuite) TestServerBundleInfo(c *gc.C) {
+	server, burl := s.prepareBundleServer(c)
+	info, err := s.store.BundleInfo(burl)
+	c.Assert(err, gc.IsNil)
+	req, err := http.NewRequest("GET", "/bundle-info", nil)
+	c.Assert(err, gc.IsNil)
+	req.Form = url.Values{"bundles": {
+		burl.String(),
+		"cs:bundle/missing",
+		"cs:precise/wordpress",
+	}}
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	c.Assert(rec.Header().Get("Content-Type"), gc.Equals, "application/json")
+
+	var obtained map[string]interface{}
+	err = json.NewDecoder(rec.Body).Decode(&obtained)
+	c.Assert(err, gc.IsNil)
+	c.Assert(obtained, gc.DeepEquals, map[string]interface{}{
+		burl.String(): map[string]interface{}{
+			"canonical-url": burl.String(),
+			"revision":      float64(0),
+			"sha256":        info.Sha256(),
+			"digest":        "some-digest",
+		},
+		"cs:bundle/missing": map[string]interface{}{
+			"revision": float64(0),
+			"errors":   []interface{}{"entry not found"},
+		},
+		"cs:precise/wordpress": map[string]interface{}{
+			"revision": float64(0),
+			"errors":   []interface{}{`not a bundle URL: "cs:precise/wordpress"`},
+		},
+	})
+	s.checkCounterSum(c, []string{"bundle-info", "bundle", "wordpress-simple"}, false, 1)
+	s.checkCounterSum(c, []string{"bundle-missing", "bundle", "missing"}, false, 1)
+}
+
+func (s *StoreSuite) TestServerBundleDownload(c *gc.C) {
+	server, burl := s.prepareBundleServer(c)
+	for i, test := range []struct {
+		path string
+		code int
+	}{
+		{"/bundle/bundle/wordpress-simple", 200},
+		{"/bundle/bundle/wordpress-simple-0", 200},
+		{"/bundle/bundle/wordpress-simple-1", 404},
+		{"/bundle/precise/wordpress", 404},
+		{"/bundle/bad-url", 404},
+	} {
+		c.Logf("test %d: %s", i, test.path)
+		req, err := http.NewRequest("GET", test.path, nil)
+		c.Assert(err, gc.IsNil)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		c.Assert(rec.Code, gc.Equals, test.code)
+		if test.code != 200 {
+			continue
+		}
+		data, err := ioutil.ReadAll(rec.Body)
+		c.Assert(err, gc.IsNil)
+		c.Assert(string(data), gc.Equals, testBundle)
+		c.Assert(rec.Header().Get("Content-Type"), gc.Equals, "application/x-yaml")
+	}
+	s.checkCounterSum(c, []string{"bundle-download", burl.Series, burl.Name}, false, 2)
+}
@@ -14,6 +14,21 @@ import (
 type Config struct {
 	MongoURL string `yaml:"mongo-url"`
 	APIAddr  string `yaml:"api-addr"`
+
+	// MirrorDir, if set, holds the directory of a charm mirror
+	// to serve read-only instead of the store held in MongoDB.
+	MirrorDir string `yaml:"mirror-dir"`
+
+	// GitHosts holds the locations under which users keep the Git
+	// repositories that charms may be published from. The charms of
+	// cs:~user/... are published from repositories under
+	// <git host>user/.
+	GitHosts []string `yaml:"git-hosts"`
+
+	// SignersKeyring, if set, holds the path of a file with the
+	// armored OpenPGP public keys trusted to sign charms. Signatures
+	// of charm archives made with them may be uploaded to the store.
+	SignersKeyring string `yaml:"signers-keyring"`
 }
 
 func ReadConfig(path string) (*Config, error) {
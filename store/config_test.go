@@ -22,6 +22,9 @@ var _ = gc.Suite(&ConfigSuite{})
 
 const testConfig = `
 mongo-url: localhost:23456
+mirror-dir: /srv/charm-mirror
+git-hosts: ["https://github.com/"]
+signers-keyring: /etc/charmd/signers.asc
 foo: 1
 bar: false
 `
@@ -47,4 +50,7 @@ func (s *ConfigSuite) TestReadConfig(c *gc.C) {
 	dstr, err := store.ReadConfig(cfgPath)
 	c.Assert(err, gc.IsNil)
 	c.Assert(dstr.MongoURL, gc.Equals, "localhost:23456")
+	c.Assert(dstr.MirrorDir, gc.Equals, "/srv/charm-mirror")
+	c.Assert(dstr.GitHosts, gc.DeepEquals, []string{"https://github.com/"})
+	c.Assert(dstr.SignersKeyring, gc.Equals, "/etc/charmd/signers.asc")
 }
@@ -3,4 +3,13 @@
 
 package store
 
-var TimeToStamp = timeToStamp
+var (
+	TimeToStamp = timeToStamp
+	RunTimeout  = runTimeout
+)
+
+// SetPublishLimit sets the number of charms the server
+// publishes from Git repositories concurrently.
+func SetPublishLimit(s *Server, n int) {
+	s.publishing = make(chan struct{}, n)
+}
@@ -0,0 +1,184 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package store
+
+import (
+	"strings"
+	"unicode"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+
+	"github.com/juju/core/charm"
+)
+
+// SearchParams holds the criteria used to search for charms.
+type SearchParams struct {
+	// Text holds the words to look for. A charm matches
+	// if all of them are found in its name, summary,
+	// description, categories or relation interfaces.
+	Text string
+
+	// Series and Owner, if not empty, restrict the search
+	// to charms of the given series and owner.
+	Series string
+	Owner  string
+
+	// Limit, if positive, restricts the number of results.
+	Limit int
+}
+
+// SearchResult describes the latest revision of a charm found by Search.
+type SearchResult struct {
+	URL      *charm.URL
+	Revision int
+	Summary  string
+}
+
+// searchDoc represents the document stored in MongoDB
+// to index the latest revision of a charm for searching.
+type searchDoc struct {
+	URL      string `bson:"_id"`
+	Name     string
+	Owner    string
+	Series   string
+	Revision int
+	Summary  string
+	Keywords []string
+}
+
+// Search returns the charms matching the given parameters,
+// ordered by name, series and owner.
+func (s *Store) Search(params SearchParams) ([]SearchResult, error) {
+	session := s.session.Copy()
+	defer session.Close()
+
+	query := bson.D{}
+	if keywords := searchKeywords(params.Text); len(keywords) > 0 {
+		query = append(query, bson.DocElem{"keywords", bson.D{{"$all", keywords}}})
+	}
+	if params.Series != "" {
+		query = append(query, bson.DocElem{"series", params.Series})
+	}
+	if params.Owner != "" {
+		query = append(query, bson.DocElem{"owner", params.Owner})
+	}
+	q := session.CharmSearch().Find(query).Sort("name", "series", "owner")
+	if params.Limit > 0 {
+		q = q.Limit(params.Limit)
+	}
+	var docs []searchDoc
+	if err := q.All(&docs); err != nil {
+		logger.Errorf("failed to search charms: %v", err)
+		return nil, err
+	}
+	results := make([]SearchResult, len(docs))
+	for i, doc := range docs {
+		curl, err := charm.ParseURL(doc.URL)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = SearchResult{
+			URL:      curl,
+			Revision: doc.Revision,
+			Summary:  doc.Summary,
+		}
+	}
+	return results, nil
+}
+
+// indexCharm updates the search index entry for the charm at url,
+// which must not have a revision, from its latest revision. The entry
+// is removed if no revision of the charm is left in the store.
+func indexCharm(session *storeSession, url *charm.URL) error {
+	var cdoc charmDoc
+	err := session.Charms().Find(bson.D{{"urls", url}}).Sort("-revision").One(&cdoc)
+	if err == mgo.ErrNotFound {
+		err = session.CharmSearch().RemoveId(url.String())
+		if err == mgo.ErrNotFound {
+			err = nil
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	doc := searchDoc{
+		URL:      url.String(),
+		Name:     url.Name,
+		Owner:    url.User,
+		Series:   url.Series,
+		Revision: cdoc.Revision,
+		Keywords: metaKeywords(url, cdoc.Meta),
+	}
+	if cdoc.Meta != nil {
+		doc.Summary = cdoc.Meta.Summary
+	}
+	_, err = session.CharmSearch().UpsertId(doc.URL, &doc)
+	return err
+}
+
+// indexMissingCharms adds search index entries for the charms in
+// the store that have none, such as those published before charms
+// were indexed for searching.
+func indexMissingCharms(session *storeSession) error {
+	var urls, indexed []string
+	if err := session.Charms().Find(nil).Distinct("urls", &urls); err != nil {
+		return err
+	}
+	if err := session.CharmSearch().Find(nil).Distinct("_id", &indexed); err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, url := range indexed {
+		seen[url] = true
+	}
+	for _, s := range urls {
+		if seen[s] {
+			continue
+		}
+		url, err := charm.ParseURL(s)
+		if err != nil {
+			return err
+		}
+		logger.Infof("indexing charm %s for searching", url)
+		if err := indexCharm(session, url); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metaKeywords returns the keywords that a search
+// must use to find the charm with the given metadata.
+func metaKeywords(url *charm.URL, meta *charm.Meta) []string {
+	words := []string{url.Name}
+	if meta != nil {
+		words = append(words, meta.Summary, meta.Description)
+		words = append(words, meta.Categories...)
+		for _, relations := range []map[string]charm.Relation{meta.Provides, meta.Requires} {
+			for _, rel := range relations {
+				words = append(words, rel.Interface)
+			}
+		}
+	}
+	return searchKeywords(strings.Join(words, " "))
+}
+
+// searchKeywords splits text into lower case words,
+// dropping repeated ones.
+func searchKeywords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	seen := make(map[string]bool)
+	var keywords []string
+	for _, field := range fields {
+		if !seen[field] {
+			seen[field] = true
+			keywords = append(keywords, field)
+		}
+	}
+	return keywords
+}
@@ -7,7 +7,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -20,15 +22,29 @@ const DefaultSeries = "precise"
 // Server is an http.Handler that serves the HTTP API of juju
 // so that juju clients can retrieve published charms.
 type Server struct {
-	store *Store
-	mux   *http.ServeMux
+	store    *Store
+	mux      *http.ServeMux
+	gitHosts []string
+
+	// signersKeyring holds the armored public keys
+	// trusted to sign the charms in the store.
+	signersKeyring string
+
+	// publishing holds a value for each charm being
+	// published from a Git repository.
+	publishing chan struct{}
 }
 
+// maxPublishing holds the maximum number of charms
+// published from Git repositories concurrently.
+const maxPublishing = 4
+
 // NewServer returns a new *Server using store.
 func NewServer(store *Store) (*Server, error) {
 	s := &Server{
-		store: store,
-		mux:   http.NewServeMux(),
+		store:      store,
+		mux:        http.NewServeMux(),
+		publishing: make(chan struct{}, maxPublishing),
 	}
 	s.mux.HandleFunc("/charm-info", func(w http.ResponseWriter, r *http.Request) {
 		s.serveInfo(w, r)
@@ -36,9 +52,24 @@ func NewServer(store *Store) (*Server, error) {
 	s.mux.HandleFunc("/charm-event", func(w http.ResponseWriter, r *http.Request) {
 		s.serveEvent(w, r)
 	})
+	s.mux.HandleFunc("/charm-publish", func(w http.ResponseWriter, r *http.Request) {
+		s.servePublish(w, r)
+	})
 	s.mux.HandleFunc("/charm/", func(w http.ResponseWriter, r *http.Request) {
 		s.serveCharm(w, r)
 	})
+	s.mux.HandleFunc("/charm-signature/", func(w http.ResponseWriter, r *http.Request) {
+		s.serveSignature(w, r)
+	})
+	s.mux.HandleFunc("/bundle-info", func(w http.ResponseWriter, r *http.Request) {
+		s.serveBundleInfo(w, r)
+	})
+	s.mux.HandleFunc("/bundle/", func(w http.ResponseWriter, r *http.Request) {
+		s.serveBundle(w, r)
+	})
+	s.mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		s.serveSearch(w, r)
+	})
 	s.mux.HandleFunc("/stats/counter/", func(w http.ResponseWriter, r *http.Request) {
 		s.serveStats(w, r)
 	})
@@ -51,6 +82,20 @@ func NewServer(store *Store) (*Server, error) {
 	return s, nil
 }
 
+// AllowGitPublishing enables requests to publish charms from Git
+// repositories kept under the given hosts. The charms of cs:~user/...
+// may only be published from repositories under <host>user/.
+func (s *Server) AllowGitPublishing(hosts ...string) {
+	s.gitHosts = hosts
+}
+
+// AllowSignatureUploads enables requests to store signatures of
+// charm archives. Only signatures made by one of the keys in the
+// given armored public keyring are accepted.
+func (s *Server) AllowSignatureUploads(signersKeyring string) {
+	s.signersKeyring = signersKeyring
+}
+
 // ServeHTTP serves an http request.
 // This method turns *Server into an http.Handler.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -194,6 +239,76 @@ func (s *Server) serveEvent(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+var fullCommitId = regexp.MustCompile("^[0-9a-f]{40}$")
+
+// servePublish publishes a charm from the head of the Git repository
+// given in the request, whose commit id is expected to be the digest
+// given. Publishing happens in the background, and its outcome is
+// logged as a charm event. Requests are refused while too many charms
+// are being published.
+func (s *Server) servePublish(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/charm-publish" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if len(s.gitHosts) == 0 {
+		http.Error(w, "publishing from git repositories is not enabled", http.StatusForbidden)
+		return
+	}
+	r.ParseForm()
+	curl, err := charm.ParseURL(r.Form.Get("charm"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if curl.User == "" || curl.Revision != -1 {
+		http.Error(w, fmt.Sprintf("charm URL must have a user and no revision: %q", curl), http.StatusBadRequest)
+		return
+	}
+	repoURL := r.Form.Get("repository")
+	if !s.allowedRepository(curl.User, repoURL) {
+		http.Error(w, fmt.Sprintf("repository %q does not belong to user %q", repoURL, curl.User), http.StatusForbidden)
+		return
+	}
+	digest := r.Form.Get("digest")
+	if !fullCommitId.MatchString(digest) {
+		http.Error(w, fmt.Sprintf("digest must be a full git commit id: %q", digest), http.StatusBadRequest)
+		return
+	}
+	select {
+	case s.publishing <- struct{}{}:
+	default:
+		http.Error(w, "too many charms being published; try again later", http.StatusServiceUnavailable)
+		return
+	}
+	go func() {
+		defer func() { <-s.publishing }()
+		err := PublishGitRepository(s.store, []*charm.URL{curl}, repoURL, digest)
+		if err != nil && err != ErrRedundantUpdate {
+			logger.Errorf("cannot publish %s from %s at %s: %v", curl, repoURL, digest, err)
+		}
+	}()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// allowedRepository reports whether the charms of user may be
+// published from the Git repository at repoURL.
+func (s *Server) allowedRepository(user, repoURL string) bool {
+	if strings.Contains(repoURL, "..") {
+		return false
+	}
+	for _, host := range s.gitHosts {
+		if strings.HasPrefix(repoURL, host+user+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Server) serveCharm(w http.ResponseWriter, r *http.Request) {
 	if !strings.HasPrefix(r.URL.Path, "/charm/") {
 		panic("serveCharm: bad url")
@@ -226,6 +341,227 @@ func (s *Server) serveCharm(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) serveSignature(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/charm-signature/") {
+		panic("serveSignature: bad url")
+	}
+	curl, err := charm.ParseURL("cs:" + r.URL.Path[len("/charm-signature/"):])
+	if err != nil || curl.Revision == -1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case "GET":
+	case "POST":
+		s.uploadSignature(w, r, curl)
+		return
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	info, err := s.store.CharmInfo(curl)
+	if err == ErrNotFound || err == nil && info.Signature() == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Errorf("cannot get charm %q: %v", curl, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pgp-signature")
+	if _, err := w.Write(info.Signature()); err != nil {
+		logger.Errorf("cannot write content: %v", err)
+	}
+}
+
+// maxSignatureSize holds the size of the
+// largest charm signature that is accepted.
+const maxSignatureSize = 64 * 1024
+
+// uploadSignature stores the signature in the request body as the
+// signature of the archive of the charm at curl. The signature must
+// verify against the archive with one of the trusted signers' keys,
+// which is what authenticates the request.
+func (s *Server) uploadSignature(w http.ResponseWriter, r *http.Request, curl *charm.URL) {
+	if s.signersKeyring == "" {
+		http.Error(w, "uploading charm signatures is not enabled", http.StatusForbidden)
+		return
+	}
+	signature, err := ioutil.ReadAll(io.LimitReader(r.Body, maxSignatureSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, rc, err := s.store.OpenCharm(curl)
+	if err == ErrNotFound {
+		http.Error(w, fmt.Sprintf("charm %q not found", curl), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Errorf("cannot open charm %q: %v", curl, err)
+		return
+	}
+	defer rc.Close()
+	identities, err := charm.CheckArchiveSignature(rc, signature, s.signersKeyring)
+	if _, ok := err.(*charm.SignatureError); ok {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err == nil {
+		err = s.store.SetCharmSignature(curl, signature)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Errorf("cannot store signature of charm %q: %v", curl, err)
+		return
+	}
+	logger.Infof("stored signature of charm %q by %s", curl, strings.Join(identities, ", "))
+	w.WriteHeader(http.StatusOK)
+}
+
+// parseBundleURL parses the URL of a bundle, which must have the
+// bundle series.
+func parseBundleURL(url string) (*charm.URL, error) {
+	burl, err := charm.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	if burl.Series != charm.BundleSeries {
+		return nil, fmt.Errorf("not a bundle URL: %q", url)
+	}
+	return burl, nil
+}
+
+func (s *Server) serveBundleInfo(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/bundle-info" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	r.ParseForm()
+	response := map[string]*charm.InfoResponse{}
+	for _, url := range r.Form["bundles"] {
+		c := &charm.InfoResponse{}
+		response[url] = c
+		burl, err := parseBundleURL(url)
+		var info *BundleInfo
+		if err == nil {
+			info, err = s.store.BundleInfo(burl)
+		}
+		var skey []string
+		if err == nil {
+			skey = charmStatsKey(burl, "bundle-info")
+			c.CanonicalURL = burl.String()
+			c.Sha256 = info.Sha256()
+			c.Revision = info.Revision()
+			c.Digest = info.Digest()
+		} else {
+			if err == ErrNotFound {
+				skey = charmStatsKey(burl, "bundle-missing")
+			}
+			c.Errors = append(c.Errors, err.Error())
+		}
+		if skey != nil && statsEnabled(r) {
+			go s.store.IncCounter(skey)
+		}
+	}
+	data, err := json.Marshal(response)
+	if err == nil {
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(data)
+	}
+	if err != nil {
+		logger.Errorf("cannot write content: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) serveBundle(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/bundle/") {
+		panic("serveBundle: bad url")
+	}
+	burl, err := parseBundleURL("cs:" + r.URL.Path[len("/bundle/"):])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	info, err := s.store.BundleInfo(burl)
+	if err == ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Errorf("cannot get bundle %q: %v", burl, err)
+		return
+	}
+	if statsEnabled(r) {
+		go s.store.IncCounter(charmStatsKey(burl, "bundle-download"))
+	}
+	data := info.Data()
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if _, err := w.Write(data); err != nil {
+		logger.Errorf("failed to send bundle %q: %v", burl, err)
+	}
+}
+
+func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/search" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	r.ParseForm()
+	params := SearchParams{
+		Text:   r.Form.Get("text"),
+		Series: r.Form.Get("series"),
+		Owner:  r.Form.Get("owner"),
+	}
+	if v := r.Form.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("Invalid 'limit' value: %q", v)))
+			return
+		}
+		params.Limit = limit
+	}
+	results, err := s.store.Search(params)
+	if err != nil {
+		logger.Errorf("cannot search charms: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	response := &charm.SearchResponse{
+		Results: make([]charm.SearchResult, len(results)),
+	}
+	for i, result := range results {
+		response.Results[i] = charm.SearchResult{
+			URL:      result.URL.String(),
+			Name:     result.URL.Name,
+			Owner:    result.URL.User,
+			Series:   result.URL.Series,
+			Revision: result.Revision,
+			Summary:  result.Summary,
+		}
+	}
+	if statsEnabled(r) {
+		go s.store.IncCounter([]string{"charm-search"})
+	}
+	data, err := json.Marshal(response)
+	if err == nil {
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(data)
+	}
+	if err != nil {
+		logger.Errorf("cannot write content: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 func (s *Server) serveStats(w http.ResponseWriter, r *http.Request) {
 	// TODO: Adopt a smarter mux that simplifies this logic.
 	const dir = "/stats/counter/"
@@ -19,6 +19,7 @@ import (
 
 	"github.com/juju/core/charm"
 	"github.com/juju/core/store"
+	"github.com/juju/core/testing"
 )
 
 func (s *StoreSuite) prepareServer(c *gc.C) (*store.Server, *charm.URL) {
@@ -217,6 +218,71 @@ func (s *StoreSuite) TestServerCharmEvent(c *gc.C) {
 	c.Assert(obtained, jc.DeepEquals, expected)
 }
 
+func (s *StoreSuite) TestServerPublish(c *gc.C) {
+	server, _ := s.prepareServer(c)
+	repo := s.dummyRepository(c, "joe/dummy")
+	host := strings.TrimSuffix(repo.path(), "joe/dummy")
+	curl := charm.MustParseURL("cs:~joe/oneiric/dummy")
+
+	publish := func(form url.Values) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/charm-publish", strings.NewReader(form.Encode()))
+		c.Assert(err, gc.IsNil)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+	form := url.Values{
+		"charm":      {curl.String()},
+		"repository": {repo.path()},
+		"digest":     {repo.digest()},
+	}
+
+	// Publishing from git is disabled by default.
+	rec := publish(form)
+	c.Assert(rec.Code, gc.Equals, http.StatusForbidden)
+	c.Assert(rec.Body.String(), gc.Equals, "publishing from git repositories is not enabled\n")
+
+	server.AllowGitPublishing(host)
+	var tests = []struct{ key, value, err string }{
+		{"charm", "cs:oneiric/dummy", `charm URL must have a user and no revision: "cs:oneiric/dummy"`},
+		{"charm", "cs:~joe/oneiric/dummy-1", `charm URL must have a user and no revision: "cs:~joe/oneiric/dummy-1"`},
+		{"charm", "cs:~bob/oneiric/dummy", `repository ".*" does not belong to user "bob"`},
+		{"repository", host + "joe/../bob/dummy", `repository ".*" does not belong to user "joe"`},
+		{"digest", "HEAD", `digest must be a full git commit id: "HEAD"`},
+	}
+	for _, t := range tests {
+		bad := url.Values{}
+		for key, value := range form {
+			bad[key] = value
+		}
+		bad.Set(t.key, t.value)
+		rec = publish(bad)
+		c.Assert(rec.Code/100, gc.Equals, 4)
+		c.Assert(rec.Body.String(), gc.Matches, t.err+"\n")
+	}
+
+	// Requests are refused while too many charms are being published.
+	store.SetPublishLimit(server, 0)
+	rec = publish(form)
+	c.Assert(rec.Code, gc.Equals, http.StatusServiceUnavailable)
+	c.Assert(rec.Body.String(), gc.Equals, "too many charms being published; try again later\n")
+
+	store.SetPublishLimit(server, 1)
+	rec = publish(form)
+	c.Assert(rec.Code, gc.Equals, http.StatusAccepted)
+	for a := testing.LongAttempt.Start(); a.Next(); {
+		event, err := s.store.CharmEvent(curl, repo.digest())
+		if err == store.ErrNotFound {
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		c.Assert(event.Kind, gc.Equals, store.EventPublished)
+		return
+	}
+	c.Fatalf("charm was never published")
+}
+
 func (s *StoreSuite) TestSeriesNotFound(c *gc.C) {
 	server, err := store.NewServer(s.store)
 	req, err := http.NewRequest("GET", "/charm-info?charms=cs:not-found", nil)
@@ -314,6 +380,8 @@ func (s *StoreSuite) TestServerStatus(c *gc.C) {
 		{"/stats/counter/any/", 404},
 		{"/stats/", 404},
 		{"/stats/any", 404},
+		{"/search/any", 404},
+		{"/search?limit=-1", 400},
 	}
 	for _, test := range tests {
 		req, err := http.NewRequest("GET", test.path, nil)
@@ -324,6 +392,44 @@ func (s *StoreSuite) TestServerStatus(c *gc.C) {
 	}
 }
 
+func (s *StoreSuite) TestServerSearch(c *gc.C) {
+	server, curl := s.prepareServer(c)
+	req, err := http.NewRequest("GET", "/search", nil)
+	c.Assert(err, gc.IsNil)
+
+	for i, test := range []struct {
+		form    url.Values
+		results []interface{}
+	}{{
+		form: url.Values{"text": {"fake testing"}},
+		results: []interface{}{map[string]interface{}{
+			"url":      curl.String(),
+			"name":     "wordpress",
+			"series":   "precise",
+			"revision": float64(0),
+			"summary":  "Fake charm for testing purposes.",
+		}},
+	}, {
+		form:    url.Values{"text": {"fake"}, "series": {"trusty"}},
+		results: []interface{}{},
+	}, {
+		form:    url.Values{"owner": {"joe"}},
+		results: []interface{}{},
+	}} {
+		c.Logf("test %d: %v", i, test.form)
+		req.Form = test.form
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		c.Assert(rec.Code, gc.Equals, http.StatusOK)
+		c.Assert(rec.Header().Get("Content-Type"), gc.Equals, "application/json")
+		var obtained map[string]interface{}
+		err = json.NewDecoder(rec.Body).Decode(&obtained)
+		c.Assert(err, gc.IsNil)
+		c.Assert(obtained, gc.DeepEquals, map[string]interface{}{"results": test.results})
+	}
+	s.checkCounterSum(c, []string{"charm-search"}, false, 3)
+}
+
 func (s *StoreSuite) TestRootRedirect(c *gc.C) {
 	server, err := store.NewServer(s.store)
 	c.Assert(err, gc.IsNil)
@@ -7,6 +7,7 @@
 package store
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
@@ -19,6 +20,7 @@ import (
 	"sync"
 	"time"
 
+	"code.google.com/p/go.crypto/openpgp/armor"
 	"github.com/juju/loggo"
 	"labix.org/v2/mgo"
 	"labix.org/v2/mgo/bson"
@@ -32,6 +34,8 @@ var logger = loggo.GetLogger("juju.store")
 //
 //     juju.events        - Log of events relating to the lifecycle of charms
 //     juju.charms        - Information about the stored charms
+//     juju.charmsearch   - Search index of the latest revision of each charm
+//     juju.bundles       - Information about the stored bundles and their data
 //     juju.charmfs.*     - GridFS with the charm files
 //     juju.locks         - Has unique keys with url of updating charms
 //     juju.stat.counters - Counters for statistics
@@ -85,6 +89,11 @@ func Open(mongoAddr string) (store *Store, err error) {
 		session.Close()
 		return nil, err
 	}
+	if err := indexMissingCharms(store.session); err != nil {
+		logger.Errorf("error indexing charms for searching: %v", err)
+		session.Close()
+		return nil, err
+	}
 
 	// Put the used socket back in the pool.
 	session.Refresh()
@@ -108,6 +117,12 @@ func (s *Store) ensureIndexes() error {
 	}, {
 		session.Events(),
 		mgo.Index{Key: []string{"urls", "digest"}},
+	}, {
+		session.CharmSearch(),
+		mgo.Index{Key: []string{"keywords"}},
+	}, {
+		session.Bundles(),
+		mgo.Index{Key: []string{"urls", "revision"}, Unique: true},
 	}}
 	for _, idx := range indexes {
 		err := idx.c.EnsureIndex(idx.i)
@@ -658,9 +673,14 @@ func (w *charmWriter) finish() error {
 		return err
 	}
 	charms := w.session.Charms()
+	urls, err := w.declaredSeriesURLs(charms)
+	if err != nil {
+		logger.Errorf("failed to find series of charm %v: %v", w.urls, err)
+		return err
+	}
 	sha256 := hex.EncodeToString(w.sha256.Sum(nil))
 	charm := charmDoc{
-		w.urls,
+		urls,
 		w.revision,
 		w.digest,
 		sha256,
@@ -671,20 +691,27 @@ func (w *charmWriter) finish() error {
 	}
 	if err = charms.Insert(&charm); err != nil {
 		err = maybeConflict(err)
-		logger.Errorf("failed to insert new revision of charm %v: %v", w.urls, err)
+		logger.Errorf("failed to insert new revision of charm %v: %v", urls, err)
 		return err
 	}
+	for _, url := range urls {
+		if err = indexCharm(w.session, url); err != nil {
+			logger.Errorf("failed to index charm %v: %v", url, err)
+			return err
+		}
+	}
 	return nil
 }
 
 type CharmInfo struct {
-	revision int
-	digest   string
-	sha256   string
-	size     int64
-	fileId   bson.ObjectId
-	meta     *charm.Meta
-	config   *charm.Config
+	revision  int
+	digest    string
+	sha256    string
+	size      int64
+	fileId    bson.ObjectId
+	meta      *charm.Meta
+	config    *charm.Config
+	signature []byte
 }
 
 // Statically ensure CharmInfo is a charm.Charm.
@@ -711,6 +738,12 @@ func (ci *CharmInfo) Digest() string {
 	return ci.digest
 }
 
+// Signature returns the armored detached OpenPGP signature of the
+// stored charm bundle, or nil if the bundle isn't signed.
+func (ci *CharmInfo) Signature() []byte {
+	return ci.signature
+}
+
 // Meta returns the charm.Meta details for the stored charm.
 func (ci *CharmInfo) Meta() *charm.Meta {
 	return ci.meta
@@ -740,6 +773,46 @@ func (s byPreferredSeries) Less(i, j int) bool {
 	return iLts
 }
 
+// declaredSeriesURLs returns the URLs the charm being written is
+// published at: w.urls, and the same URLs with each of the series the
+// charm declares, so that it can be found under any of them. URLs
+// that already hold this revision or a later one, being published
+// separately, are left alone.
+func (w *charmWriter) declaredSeriesURLs(charms *mgo.Collection) ([]*charm.URL, error) {
+	urls := append([]*charm.URL(nil), w.urls...)
+	meta := w.charm.Meta()
+	if meta == nil {
+		return urls, nil
+	}
+	found := make(map[string]bool)
+	for _, url := range urls {
+		found[url.String()] = true
+	}
+	for _, url := range w.urls {
+		for _, series := range meta.DeclaredSeries() {
+			surl := &charm.URL{Reference: url.Reference, Series: series}
+			urlStr := surl.String()
+			if found[urlStr] {
+				continue
+			}
+			found[urlStr] = true
+			n, err := charms.Find(bson.D{
+				{"urls", urlStr},
+				{"revision", bson.D{{"$gte", w.revision}}},
+			}).Count()
+			if err != nil {
+				return nil, err
+			}
+			if n > 0 {
+				logger.Infof("charm %s is published separately; not adding revision %d", urlStr, w.revision)
+				continue
+			}
+			urls = append(urls, surl)
+		}
+	}
+	return urls, nil
+}
+
 // Series returns all the series available for a charm reference, in descending
 // order of preference. LTS releases preferred over non-LTS
 func (s *Store) Series(ref charm.Reference) ([]string, error) {
@@ -775,9 +848,42 @@ func (s *Store) Series(ref charm.Reference) ([]string, error) {
 		result = append(result, series)
 	}
 	sort.Sort(byPreferredSeries(result))
+
+	// A charm that supports several series declares
+	// which it prefers; the latest revision decides.
+	var latest *charmDoc
+	for i := range cdocs {
+		if latest == nil || cdocs[i].Revision > latest.Revision {
+			latest = &cdocs[i]
+		}
+	}
+	if latest != nil && latest.Meta != nil && len(result) > 1 {
+		result = preferSeries(result, latest.Meta.DeclaredSeries())
+	}
 	return result, nil
 }
 
+// preferSeries returns series reordered so that any of the
+// preferred series come first, in the order they're given.
+func preferSeries(series, preferred []string) []string {
+	result := make([]string, 0, len(series))
+	found := make(map[string]bool)
+	for _, p := range preferred {
+		for _, s := range series {
+			if s == p && !found[s] {
+				result = append(result, s)
+				found[s] = true
+			}
+		}
+	}
+	for _, s := range series {
+		if !found[s] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
 // getRevisions returns at most the last n revisions for charm at url,
 // in descending revision order. For limit n=0, all revisions are returned.
 func (s *Store) getRevisions(url *charm.URL, n int) ([]*CharmInfo, error) {
@@ -814,6 +920,7 @@ func (s *Store) getRevisions(url *charm.URL, n int) ([]*CharmInfo, error) {
 			cdoc.FileId,
 			cdoc.Meta,
 			cdoc.Config,
+			cdoc.Signature,
 		})
 	}
 	return infos, nil
@@ -850,6 +957,28 @@ func (s *Store) OpenCharm(url *charm.URL) (info *CharmInfo, rc io.ReadCloser, er
 	return
 }
 
+// SetCharmSignature stores signature as the armored detached OpenPGP
+// signature of the bundle of the charm at url. The store doesn't
+// judge who signed the charm; that's up to whoever downloads it.
+func (s *Store) SetCharmSignature(url *charm.URL, signature []byte) error {
+	info, err := s.CharmInfo(url)
+	if err != nil {
+		return err
+	}
+	if _, err := armor.Decode(bytes.NewReader(signature)); err != nil {
+		return fmt.Errorf("invalid charm signature: %v", err)
+	}
+	session := s.session.Copy()
+	defer session.Close()
+	err = session.Charms().Update(
+		bson.D{{"urls", url.WithRevision(-1)}, {"revision", info.Revision()}},
+		bson.D{{"$set", bson.D{{"signature", signature}}}})
+	if err == mgo.ErrNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
 // DeleteCharm deletes the charms matching url. If no revision is specified,
 // all revisions of the charm are deleted.
 func (s *Store) DeleteCharm(url *charm.URL) ([]*CharmInfo, error) {
@@ -878,6 +1007,10 @@ func (s *Store) DeleteCharm(url *charm.URL) ([]*CharmInfo, error) {
 		}
 		deleted = append(deleted, info)
 	}
+	if err := indexCharm(session, url.WithRevision(-1)); err != nil {
+		logger.Errorf("failed to index charm %s: %v", url, err)
+		return deleted, err
+	}
 	return deleted, err
 }
 
@@ -900,14 +1033,15 @@ func (r *reader) Close() error {
 
 // charmDoc represents the document stored in MongoDB for a charm.
 type charmDoc struct {
-	URLs     []*charm.URL
-	Revision int
-	Digest   string
-	Sha256   string
-	Size     int64
-	FileId   bson.ObjectId
-	Meta     *charm.Meta
-	Config   *charm.Config
+	URLs      []*charm.URL
+	Revision  int
+	Digest    string
+	Sha256    string
+	Size      int64
+	FileId    bson.ObjectId
+	Meta      *charm.Meta
+	Config    *charm.Config
+	Signature []byte `bson:",omitempty"`
 }
 
 // LockUpdates acquires a server-side lock for updating a single charm
@@ -1017,6 +1151,17 @@ func (s *storeSession) Charms() *mgo.Collection {
 	return s.DB("juju").C("charms")
 }
 
+// CharmSearch returns the mongo collection where the search index of
+// charms is stored.
+func (s *storeSession) CharmSearch() *mgo.Collection {
+	return s.DB("juju").C("charmsearch")
+}
+
+// Bundles returns the mongo collection where bundles are stored.
+func (s *storeSession) Bundles() *mgo.Collection {
+	return s.DB("juju").C("bundles")
+}
+
 // CharmFS returns a mgo.GridFS to read and write charms.
 func (s *storeSession) CharmFS() *mgo.GridFS {
 	return s.DB("juju").GridFS("charmfs")
@@ -4,10 +4,13 @@
 package store_test
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
 	"sync"
@@ -18,6 +21,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/core/charm"
+	charmtesting "github.com/juju/core/charm/testing"
 	"github.com/juju/core/store"
 	"github.com/juju/core/testing"
 )
@@ -79,16 +83,18 @@ func (s *StoreSuite) TearDownTest(c *gc.C) {
 type FakeCharmDir struct {
 	revision interface{} // so we can tell if it's not set.
 	error    string
+	series   []string
 }
 
 func (d *FakeCharmDir) Meta() *charm.Meta {
 	return &charm.Meta{
-		Name:        "fakecharm",
-		Summary:     "Fake charm for testing purposes.",
-		Description: "This is a fake charm for testing purposes.\n",
-		Provides:    make(map[string]charm.Relation),
-		Requires:    make(map[string]charm.Relation),
-		Peers:       make(map[string]charm.Relation),
+		Name:            "fakecharm",
+		Summary:         "Fake charm for testing purposes.",
+		Description:     "This is a fake charm for testing purposes.\n",
+		Provides:        make(map[string]charm.Relation),
+		Requires:        make(map[string]charm.Relation),
+		Peers:           make(map[string]charm.Relation),
+		SupportedSeries: d.series,
 	}
 }
 
@@ -208,6 +214,213 @@ func (s *StoreSuite) TestDeleteCharm(c *gc.C) {
 	c.Assert(err, gc.Not(gc.IsNil))
 }
 
+func (s *StoreSuite) publishTestCharm(c *gc.C, name string, urls ...string) {
+	var curls []*charm.URL
+	for _, url := range urls {
+		curls = append(curls, charm.MustParseURL(url))
+	}
+	pub, err := s.store.CharmPublisher(curls, "digest-"+name)
+	c.Assert(err, gc.IsNil)
+	err = pub.Publish(testing.Charms.ClonedDir(c.MkDir(), name))
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *StoreSuite) TestCharmSignature(c *gc.C) {
+	s.publishTestCharm(c, "wordpress", "cs:precise/wordpress")
+	curl := charm.MustParseURL("cs:precise/wordpress-0")
+	info, rc, err := s.store.OpenCharm(curl)
+	c.Assert(err, gc.IsNil)
+	signature, err := charm.SignArchive(rc, charmtesting.SigningPrivateKey, "")
+	rc.Close()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Signature(), gc.IsNil)
+
+	server, err := store.NewServer(s.store)
+	c.Assert(err, gc.IsNil)
+	get := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		c.Assert(err, gc.IsNil)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+	c.Assert(get("/charm-signature/precise/wordpress-0").Code, gc.Equals, http.StatusNotFound)
+
+	err = s.store.SetCharmSignature(curl, []byte("bad signature"))
+	c.Assert(err, gc.ErrorMatches, "invalid charm signature: .*")
+	err = s.store.SetCharmSignature(curl.WithRevision(1), signature)
+	c.Assert(err, gc.Equals, store.ErrNotFound)
+	err = s.store.SetCharmSignature(curl, signature)
+	c.Assert(err, gc.IsNil)
+
+	info, err = s.store.CharmInfo(curl)
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Signature(), gc.DeepEquals, signature)
+
+	rec := get("/charm-signature/precise/wordpress-0")
+	c.Assert(rec.Code, gc.Equals, http.StatusOK)
+	c.Assert(rec.Header().Get("Content-Type"), gc.Equals, "application/pgp-signature")
+	c.Assert(rec.Body.Bytes(), gc.DeepEquals, signature)
+
+	// Signatures are only served for specific revisions.
+	c.Assert(get("/charm-signature/precise/wordpress").Code, gc.Equals, http.StatusNotFound)
+	c.Assert(get("/charm-signature/precise/wordpress-1").Code, gc.Equals, http.StatusNotFound)
+}
+
+func (s *StoreSuite) TestUploadCharmSignature(c *gc.C) {
+	s.publishTestCharm(c, "wordpress", "cs:precise/wordpress")
+	curl := charm.MustParseURL("cs:precise/wordpress-0")
+	_, rc, err := s.store.OpenCharm(curl)
+	c.Assert(err, gc.IsNil)
+	archive, err := ioutil.ReadAll(rc)
+	rc.Close()
+	c.Assert(err, gc.IsNil)
+	sign := func(privateKey string) []byte {
+		signature, err := charm.SignArchive(bytes.NewReader(archive), privateKey, "")
+		c.Assert(err, gc.IsNil)
+		return signature
+	}
+	signature := sign(charmtesting.SigningPrivateKey)
+
+	server, err := store.NewServer(s.store)
+	c.Assert(err, gc.IsNil)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	repo := &charm.CharmStore{BaseURL: httpServer.URL}
+
+	// Uploading signatures is disabled by default.
+	err = repo.UploadSignature(curl, signature)
+	c.Assert(err, gc.ErrorMatches, `charm store refused signature of cs:precise/wordpress-0: uploading charm signatures is not enabled`)
+
+	// Only signatures made by trusted signers are accepted.
+	server.AllowSignatureUploads(charmtesting.SigningPublicKey)
+	err = repo.UploadSignature(curl, sign(charmtesting.UntrustedPrivateKey))
+	c.Assert(err, gc.ErrorMatches, `charm store refused signature of cs:precise/wordpress-0: charm signature verification failed: .*`)
+	err = repo.UploadSignature(curl, []byte("bad signature"))
+	c.Assert(err, gc.ErrorMatches, `charm store refused signature of cs:precise/wordpress-0: charm signature verification failed: .*`)
+	err = repo.UploadSignature(curl.WithRevision(1), signature)
+	c.Assert(err, gc.ErrorMatches, `charm store refused signature of cs:precise/wordpress-1: charm "cs:precise/wordpress-1" not found`)
+	got, err := repo.Signature(curl)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got, gc.IsNil)
+
+	err = repo.UploadSignature(curl, signature)
+	c.Assert(err, gc.IsNil)
+
+	// Clients can verify the charm archive with the stored signature.
+	got, err = repo.Signature(curl)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got, gc.DeepEquals, signature)
+	identities, err := charm.CheckArchiveSignature(bytes.NewReader(archive), got, charmtesting.SigningPublicKey)
+	c.Assert(err, gc.IsNil)
+	c.Assert(identities, gc.DeepEquals, []string{"Charm Signer <signer@example.com>"})
+}
+
+func searchURLs(results []store.SearchResult) []string {
+	var urls []string
+	for _, result := range results {
+		urls = append(urls, result.URL.String())
+	}
+	return urls
+}
+
+func (s *StoreSuite) TestSearch(c *gc.C) {
+	s.publishTestCharm(c, "wordpress", "cs:precise/wordpress", "cs:~joe/oneiric/wordpress")
+	s.publishTestCharm(c, "mysql", "cs:precise/mysql")
+	s.publishTestCharm(c, "category", "cs:~joe/precise/categories")
+
+	for i, test := range []struct {
+		params store.SearchParams
+		urls   []string
+	}{{
+		params: store.SearchParams{},
+		urls: []string{
+			"cs:~joe/precise/categories",
+			"cs:precise/mysql",
+			"cs:~joe/oneiric/wordpress",
+			"cs:precise/wordpress",
+		},
+	}, {
+		// Matches the name of mysql and the interface required by wordpress.
+		params: store.SearchParams{Text: "MySQL"},
+		urls:   []string{"cs:precise/mysql", "cs:~joe/oneiric/wordpress", "cs:precise/wordpress"},
+	}, {
+		// Matches the summary of mysql and the category of categories.
+		params: store.SearchParams{Text: "database"},
+		urls:   []string{"cs:~joe/precise/categories", "cs:precise/mysql"},
+	}, {
+		params: store.SearchParams{Text: "popular blog"},
+		urls:   []string{"cs:~joe/oneiric/wordpress", "cs:precise/wordpress"},
+	}, {
+		params: store.SearchParams{Text: "blog", Series: "precise"},
+		urls:   []string{"cs:precise/wordpress"},
+	}, {
+		params: store.SearchParams{Owner: "joe"},
+		urls:   []string{"cs:~joe/precise/categories", "cs:~joe/oneiric/wordpress"},
+	}, {
+		params: store.SearchParams{Text: "popular", Limit: 1},
+		urls:   []string{"cs:precise/mysql"},
+	}, {
+		params: store.SearchParams{Text: "nothing"},
+	}} {
+		c.Logf("test %d: %+v", i, test.params)
+		results, err := s.store.Search(test.params)
+		c.Assert(err, gc.IsNil)
+		c.Assert(searchURLs(results), gc.DeepEquals, test.urls)
+	}
+}
+
+func (s *StoreSuite) TestSearchFollowsRevisions(c *gc.C) {
+	url := charm.MustParseURL("cs:precise/mysql")
+	s.publishTestCharm(c, "dummy", url.String())
+	s.publishTestCharm(c, "mysql", url.String())
+
+	results, err := s.store.Search(store.SearchParams{Text: "database"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 1)
+	c.Assert(results[0].URL, gc.DeepEquals, url)
+	c.Assert(results[0].Revision, gc.Equals, 1)
+	c.Assert(results[0].Summary, gc.Equals, "Database engine")
+
+	// Deleting the latest revision indexes the previous one.
+	_, err = s.store.DeleteCharm(url.WithRevision(1))
+	c.Assert(err, gc.IsNil)
+	results, err = s.store.Search(store.SearchParams{Text: "database"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 0)
+	results, err = s.store.Search(store.SearchParams{Text: "dummy"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 1)
+	c.Assert(results[0].Revision, gc.Equals, 0)
+
+	_, err = s.store.DeleteCharm(url)
+	c.Assert(err, gc.IsNil)
+	results, err = s.store.Search(store.SearchParams{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 0)
+}
+
+func (s *StoreSuite) TestOpenIndexesCharmsForSearch(c *gc.C) {
+	s.publishTestCharm(c, "wordpress", "cs:precise/wordpress")
+	s.publishTestCharm(c, "mysql", "cs:precise/mysql")
+
+	// Forget a search index entry, as for charms published
+	// before they were indexed for searching.
+	err := s.Session.DB("juju").C("charmsearch").RemoveId("cs:precise/wordpress")
+	c.Assert(err, gc.IsNil)
+
+	// Opening the store indexes the charms that are missing.
+	st, err := store.Open(testing.MgoServer.Addr())
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+	results, err := st.Search(store.SearchParams{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(searchURLs(results), gc.DeepEquals, []string{"cs:precise/mysql", "cs:precise/wordpress"})
+	results, err = st.Search(store.SearchParams{Text: "blog"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(searchURLs(results), gc.DeepEquals, []string{"cs:precise/wordpress"})
+}
+
 func (s *StoreSuite) TestCharmPublishError(c *gc.C) {
 	url := charm.MustParseURL("cs:oneiric/wordpress")
 	urls := []*charm.URL{url}
@@ -402,6 +615,59 @@ func (s *StoreSuite) TestSeriesSolver(c *gc.C) {
 	c.Check(series[1], gc.Equals, "def")
 }
 
+func (s *StoreSuite) TestSeriesSolverMultiSeries(c *gc.C) {
+	urls := []*charm.URL{
+		charm.MustParseURL("cs:trusty/haproxy"),
+		charm.MustParseURL("cs:precise/haproxy"),
+		charm.MustParseURL("cs:quantal/haproxy"),
+	}
+	pub, err := s.store.CharmPublisher(urls, "some-haproxy-digest")
+	c.Assert(err, gc.IsNil)
+	err = pub.Publish(&FakeCharmDir{series: []string{"quantal", "precise"}})
+	c.Assert(err, gc.IsNil)
+
+	// The series the charm declares come first, in its order
+	// of preference.
+	ref, _, err := charm.ParseReference("cs:haproxy")
+	c.Assert(err, gc.IsNil)
+	series, err := s.store.Series(ref)
+	c.Assert(err, gc.IsNil)
+	c.Assert(series, gc.DeepEquals, []string{"quantal", "precise", "trusty"})
+}
+
+func (s *StoreSuite) TestPublishDeclaredSeries(c *gc.C) {
+	publish := func(url string, digest string, series ...string) {
+		pub, err := s.store.CharmPublisher([]*charm.URL{charm.MustParseURL(url)}, digest)
+		c.Assert(err, gc.IsNil)
+		err = pub.Publish(&FakeCharmDir{series: series})
+		c.Assert(err, gc.IsNil)
+	}
+	// The charm is published separately for quantal.
+	publish("cs:quantal/haproxy", "quantal-digest-0")
+	publish("cs:quantal/haproxy", "quantal-digest-1")
+
+	// A charm is found under all the series it declares.
+	publish("cs:trusty/haproxy", "some-haproxy-digest", "trusty", "precise", "quantal")
+	info, err := s.store.CharmInfo(charm.MustParseURL("cs:precise/haproxy"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Digest(), gc.Equals, "some-haproxy-digest")
+	c.Assert(info.Revision(), gc.Equals, 0)
+	results, err := s.store.Search(store.SearchParams{Series: "precise"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(searchURLs(results), gc.DeepEquals, []string{"cs:precise/haproxy"})
+
+	// Series holding later revisions are left alone.
+	info, err = s.store.CharmInfo(charm.MustParseURL("cs:quantal/haproxy"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Digest(), gc.Equals, "quantal-digest-1")
+
+	ref, _, err := charm.ParseReference("cs:haproxy")
+	c.Assert(err, gc.IsNil)
+	series, err := s.store.Series(ref)
+	c.Assert(err, gc.IsNil)
+	c.Assert(series, gc.DeepEquals, []string{"trusty", "precise", "quantal"})
+}
+
 var mysqlSeriesCharms = []struct {
 	fakeDigest string
 	urls       []string
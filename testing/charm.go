@@ -129,13 +129,17 @@ func (r *Repo) Bundle(dst, name string) *charm.Bundle {
 // that would otherwise need to hit the real charm store.
 type MockCharmStore struct {
 	charms        map[string]map[int]*charm.Bundle
+	signatures    map[string][]byte
 	AuthAttrs     string
 	TestMode      bool
 	DefaultSeries string
 }
 
 func NewMockCharmStore() *MockCharmStore {
-	return &MockCharmStore{charms: map[string]map[int]*charm.Bundle{}}
+	return &MockCharmStore{
+		charms:     map[string]map[int]*charm.Bundle{},
+		signatures: map[string][]byte{},
+	}
 }
 
 func (s *MockCharmStore) WithAuthAttrs(auth string) charm.Repository {
@@ -185,6 +189,22 @@ func (s *MockCharmStore) SetCharm(charmURL *charm.URL, bundle *charm.Bundle) err
 	return nil
 }
 
+// SetSignature sets the signature of the archive of the charm
+// at charmURL, which must be revisioned. If signature is nil,
+// the archive becomes unsigned.
+func (s *MockCharmStore) SetSignature(charmURL *charm.URL, signature []byte) {
+	if signature == nil {
+		delete(s.signatures, charmURL.String())
+		return
+	}
+	s.signatures[charmURL.String()] = signature
+}
+
+// Signature implements charm.SignatureSource.Signature.
+func (s *MockCharmStore) Signature(charmURL *charm.URL) ([]byte, error) {
+	return s.signatures[charmURL.String()], nil
+}
+
 // interpret extracts from charmURL information relevant to both Latest and
 // Get. The returned "base" is always the string representation of the
 // unrevisioned part of charmURL; the "rev" wil be taken from the charmURL if
@@ -0,0 +1,110 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package vcs offers a common interface to the version control
+// systems that charms can be published from: Bazaar and Git.
+package vcs
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/juju/core/bzr"
+	"github.com/juju/core/git"
+)
+
+// Branch represents a working tree under version control.
+type Branch interface {
+	// Kind returns the name of the version control
+	// system: "bzr" or "git".
+	Kind() string
+
+	// Location returns the location of the branch.
+	Location() string
+
+	// Join returns the branch's location with parts
+	// appended as path components.
+	Join(parts ...string) string
+
+	// Init initializes a new branch at the branch's location.
+	Init() error
+
+	// Add adds to the branch the path resultant
+	// from calling Join(parts...).
+	Add(parts ...string) error
+
+	// Commit commits pending changes into the branch.
+	Commit(message string) error
+
+	// RevisionId returns the revision id of the tip of the branch.
+	RevisionId() (string, error)
+
+	// PushLocation returns the default push location of the branch.
+	PushLocation() (string, error)
+
+	// Push pushes the new revisions in the branch. See PushAttr.
+	Push(attr *PushAttr) error
+
+	// CheckClean returns an error if the working tree
+	// has uncommitted changes.
+	CheckClean() error
+}
+
+// PushAttr holds options for the Branch.Push method.
+type PushAttr struct {
+	Location string // Location to push to. Use the default push location if empty.
+	Remember bool   // Whether to remember the location being pushed to as the default.
+}
+
+// NewBzr returns a Branch for the Bazaar branch at location.
+func NewBzr(location string) Branch {
+	return bzrBranch{bzr.New(location)}
+}
+
+// NewGit returns a Branch for the Git working tree at location.
+func NewGit(location string) Branch {
+	return gitBranch{git.New(location)}
+}
+
+// Detect returns the Branch for the working tree at
+// location, whichever version control system it uses.
+func Detect(location string) (Branch, error) {
+	for _, b := range []Branch{NewBzr(location), NewGit(location)} {
+		if _, err := os.Stat(b.Join("." + b.Kind())); err == nil {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("no bzr branch or git repository found at %s", location)
+}
+
+type bzrBranch struct {
+	*bzr.Branch
+}
+
+func (bzrBranch) Kind() string {
+	return "bzr"
+}
+
+func (b bzrBranch) Push(attr *PushAttr) error {
+	var bzrAttr *bzr.PushAttr
+	if attr != nil {
+		bzrAttr = &bzr.PushAttr{Location: attr.Location, Remember: attr.Remember}
+	}
+	return b.Branch.Push(bzrAttr)
+}
+
+type gitBranch struct {
+	*git.Repository
+}
+
+func (gitBranch) Kind() string {
+	return "git"
+}
+
+func (b gitBranch) Push(attr *PushAttr) error {
+	var gitAttr *git.PushAttr
+	if attr != nil {
+		gitAttr = &git.PushAttr{Location: attr.Location, Remember: attr.Remember}
+	}
+	return b.Repository.Push(gitAttr)
+}
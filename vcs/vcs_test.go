@@ -0,0 +1,99 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package vcs_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	stdtesting "testing"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/testing"
+	"github.com/juju/core/vcs"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+var _ = gc.Suite(&VCSSuite{})
+
+type VCSSuite struct {
+	testing.GitSuite
+}
+
+func (s *VCSSuite) SetUpTest(c *gc.C) {
+	s.GitSuite.SetUpTest(c)
+	// bzr refuses to commit without knowing who's committing.
+	s.PatchEnvironment("EMAIL", "testing <test@example.com>")
+}
+
+func (s *VCSSuite) TestDetect(c *gc.C) {
+	for i, newBranch := range []func(string) vcs.Branch{vcs.NewBzr, vcs.NewGit} {
+		c.Logf("test %d", i)
+		b := newBranch(c.MkDir())
+		c.Assert(b.Init(), gc.IsNil)
+		err := os.Mkdir(b.Join("dir"), 0755)
+		c.Assert(err, gc.IsNil)
+
+		detected, err := vcs.Detect(b.Join("dir"))
+		c.Assert(err, gc.IsNil)
+		c.Assert(detected.Kind(), gc.Equals, b.Kind())
+		path, err := filepath.EvalSymlinks(b.Location())
+		c.Assert(err, gc.IsNil)
+		c.Assert(detected.Location(), gc.Equals, path)
+	}
+}
+
+func (s *VCSSuite) TestDetectNothing(c *gc.C) {
+	dir := c.MkDir()
+	_, err := vcs.Detect(dir)
+	c.Assert(err, gc.ErrorMatches, "no bzr branch or git repository found at "+dir)
+}
+
+func (s *VCSSuite) TestCommitAndPush(c *gc.C) {
+	for i, newBranch := range []func(string) vcs.Branch{vcs.NewBzr, vcs.NewGit} {
+		c.Logf("test %d", i)
+		b := newBranch(c.MkDir())
+		c.Assert(b.Init(), gc.IsNil)
+		_, err := b.RevisionId()
+		c.Assert(err, gc.ErrorMatches, ".* has no content")
+
+		f, err := os.Create(b.Join("file"))
+		c.Assert(err, gc.IsNil)
+		f.Close()
+		c.Assert(b.CheckClean(), gc.ErrorMatches, ".* is not clean .*")
+		c.Assert(b.Add("file"), gc.IsNil)
+		c.Assert(b.Commit("added file"), gc.IsNil)
+		c.Assert(b.CheckClean(), gc.IsNil)
+		revid, err := b.RevisionId()
+		c.Assert(err, gc.IsNil)
+
+		target := newTarget(c, b.Kind())
+		err = b.Push(&vcs.PushAttr{Location: target, Remember: true})
+		c.Assert(err, gc.IsNil)
+		location, err := b.PushLocation()
+		c.Assert(err, gc.IsNil)
+		c.Assert(location, gc.Equals, target)
+		pushed, err := newBranch(target).RevisionId()
+		c.Assert(err, gc.IsNil)
+		c.Assert(pushed, gc.Equals, revid)
+	}
+}
+
+// newTarget returns the location of a new
+// branch of the given kind to push to.
+func newTarget(c *gc.C, kind string) string {
+	dir := c.MkDir()
+	if kind == "bzr" {
+		c.Assert(vcs.NewBzr(dir).Init(), gc.IsNil)
+		return dir
+	}
+	// Git can only push to repositories without a working tree.
+	output, err := exec.Command("git", "init", "-q", "--bare", dir).CombinedOutput()
+	c.Assert(err, gc.IsNil, gc.Commentf("%s", output))
+	return dir
+}
@@ -0,0 +1,121 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package certupdater
+
+import (
+	"fmt"
+
+	"github.com/juju/loggo"
+
+	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/state/api/watcher"
+	"github.com/juju/core/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.certupdater")
+
+// CertificateUpdater is responsible for updating an agent's
+// configuration when the environment's certificates are rotated.
+type CertificateUpdater struct {
+	getter       CACertGetter
+	setter       CACertSetter
+	acknowledger CACertAcknowledger
+	infoGetter   StateServingInfoGetter
+	infoSetter   StateServingInfoSetter
+}
+
+// CACertGetter is an interface that is provided to NewCertificateUpdater
+// which can be used to watch for changes to the CA certificate.
+type CACertGetter interface {
+	CACert() (string, error)
+	WatchCACert() (watcher.NotifyWatcher, error)
+}
+
+// CACertSetter is an interface that is provided to NewCertificateUpdater
+// whose SetCACert method will be invoked whenever the CA certificate
+// changes.
+type CACertSetter interface {
+	SetCACert(caCert string) error
+}
+
+// CACertAcknowledger is an interface that is provided to
+// NewCertificateUpdater whose AcknowledgeCACert method will be invoked
+// once the agent trusts the new CA certificate, so that the state
+// server certificate is only switched when no agent would reject it.
+type CACertAcknowledger interface {
+	AcknowledgeCACert(caCert string) error
+}
+
+// StateServingInfoGetter is an interface that is provided to
+// NewStateServerCertificateUpdater which can be used to fetch
+// the state server certificate.
+type StateServingInfoGetter interface {
+	StateServingInfo() (params.StateServingInfo, error)
+}
+
+// StateServingInfoSetter is an interface that is provided to
+// NewStateServerCertificateUpdater whose SetStateServingInfo method
+// will be invoked whenever the certificates are rotated.
+type StateServingInfoSetter interface {
+	CACertSetter
+	SetStateServingInfo(info params.StateServingInfo) error
+}
+
+// NewCertificateUpdater returns a worker.Worker that updates the
+// agent's CA certificate whenever the environment's certificates
+// are rotated.
+func NewCertificateUpdater(getter CACertGetter, setter CACertSetter, acknowledger CACertAcknowledger) worker.Worker {
+	return worker.NewNotifyWorker(&CertificateUpdater{
+		getter:       getter,
+		setter:       setter,
+		acknowledger: acknowledger,
+	})
+}
+
+// NewStateServerCertificateUpdater returns a worker.Worker that, as
+// well as updating the CA certificate, updates a state server agent's
+// serving information with the new state server certificate.
+func NewStateServerCertificateUpdater(getter CACertGetter, infoGetter StateServingInfoGetter, setter StateServingInfoSetter, acknowledger CACertAcknowledger) worker.Worker {
+	return worker.NewNotifyWorker(&CertificateUpdater{
+		getter:       getter,
+		setter:       setter,
+		acknowledger: acknowledger,
+		infoGetter:   infoGetter,
+		infoSetter:   setter,
+	})
+}
+
+func (u *CertificateUpdater) SetUp() (watcher.NotifyWatcher, error) {
+	return u.getter.WatchCACert()
+}
+
+func (u *CertificateUpdater) Handle() error {
+	caCert, err := u.getter.CACert()
+	if err != nil {
+		return fmt.Errorf("error getting CA certificate: %v", err)
+	}
+	if err := u.setter.SetCACert(caCert); err != nil {
+		return fmt.Errorf("error setting CA certificate: %v", err)
+	}
+	logger.Debugf("CA certificate updated")
+	if err := u.acknowledger.AcknowledgeCACert(caCert); err != nil {
+		return fmt.Errorf("error acknowledging CA certificate: %v", err)
+	}
+	if u.infoGetter == nil {
+		return nil
+	}
+	info, err := u.infoGetter.StateServingInfo()
+	if err != nil {
+		return fmt.Errorf("error getting state serving info: %v", err)
+	}
+	if err := u.infoSetter.SetStateServingInfo(info); err != nil {
+		return fmt.Errorf("error setting state serving info: %v", err)
+	}
+	logger.Debugf("state server certificate updated")
+	return nil
+}
+
+func (u *CertificateUpdater) TearDown() error {
+	return nil
+}
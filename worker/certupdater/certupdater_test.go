@@ -0,0 +1,144 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package certupdater_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	jujutesting "github.com/juju/core/juju/testing"
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api/params"
+	coretesting "github.com/juju/core/testing"
+	"github.com/juju/core/worker/certupdater"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type CertificateUpdaterSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&CertificateUpdaterSuite{})
+
+func (s *CertificateUpdaterSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	err := s.State.SetStateServingInfo(params.StateServingInfo{
+		APIPort:    1234,
+		StatePort:  4321,
+		Cert:       coretesting.ServerCert,
+		PrivateKey: coretesting.ServerKey,
+	})
+	c.Assert(err, gc.IsNil)
+}
+
+type certificateSetter struct {
+	caCerts chan string
+	acks    chan string
+	infos   chan params.StateServingInfo
+}
+
+func (s *certificateSetter) SetCACert(caCert string) error {
+	s.caCerts <- caCert
+	return nil
+}
+
+func (s *certificateSetter) AcknowledgeCACert(caCert string) error {
+	s.acks <- caCert
+	return nil
+}
+
+func (s *certificateSetter) SetStateServingInfo(info params.StateServingInfo) error {
+	s.infos <- info
+	return nil
+}
+
+func newCertificateSetter() *certificateSetter {
+	return &certificateSetter{
+		caCerts: make(chan string, 1),
+		acks:    make(chan string, 1),
+		infos:   make(chan params.StateServingInfo, 1),
+	}
+}
+
+func (s *certificateSetter) assertCACert(c *gc.C, expected string) {
+	select {
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for SetCACert to be called")
+	case caCert := <-s.caCerts:
+		c.Assert(caCert, gc.Equals, expected)
+	}
+	select {
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for AcknowledgeCACert to be called")
+	case caCert := <-s.acks:
+		c.Assert(caCert, gc.Equals, expected)
+	}
+}
+
+func (s *certificateSetter) assertStateServingInfo(c *gc.C, expectedCert string) {
+	select {
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for SetStateServingInfo to be called")
+	case info := <-s.infos:
+		c.Assert(info.Cert, gc.Equals, expectedCert)
+	}
+}
+
+func (s *CertificateUpdaterSuite) TestStartStop(c *gc.C) {
+	st, _ := s.OpenAPIAsNewMachine(c, state.JobHostUnits)
+	setter := newCertificateSetter()
+	worker := certupdater.NewCertificateUpdater(st.Machiner(), setter, setter)
+	worker.Kill()
+	c.Assert(worker.Wait(), gc.IsNil)
+}
+
+func (s *CertificateUpdaterSuite) TestCACertChange(c *gc.C) {
+	setter := newCertificateSetter()
+	st, _ := s.OpenAPIAsNewMachine(c, state.JobHostUnits)
+	worker := certupdater.NewCertificateUpdater(st.Machiner(), setter, setter)
+	defer func() { c.Assert(worker.Wait(), gc.IsNil) }()
+	defer worker.Kill()
+
+	// SetCACert should be called with the initial value.
+	setter.assertCACert(c, coretesting.CACert)
+
+	err := s.State.PublishCACert("new CA cert")
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	setter.assertCACert(c, "new CA cert")
+}
+
+func (s *CertificateUpdaterSuite) TestStateServerCertificateChange(c *gc.C) {
+	setter := newCertificateSetter()
+	st, _ := s.OpenAPIAsNewMachine(c, state.JobManageEnviron)
+	worker := certupdater.NewStateServerCertificateUpdater(st.Machiner(), st.Agent(), setter, setter)
+	defer func() { c.Assert(worker.Wait(), gc.IsNil) }()
+	defer worker.Kill()
+
+	setter.assertCACert(c, coretesting.CACert)
+	setter.assertStateServingInfo(c, coretesting.ServerCert)
+
+	err := s.State.PublishCACert("new CA cert")
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	setter.assertCACert(c, "new CA cert")
+	setter.assertStateServingInfo(c, coretesting.ServerCert)
+
+	err = s.State.SetPendingStateServerKey("new key")
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	setter.assertCACert(c, "new CA cert")
+	setter.assertStateServingInfo(c, coretesting.ServerCert)
+
+	err = s.State.SetStateServerCert("new cert", "new key")
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	setter.assertCACert(c, "new CA cert")
+	setter.assertStateServingInfo(c, "new cert")
+}
@@ -0,0 +1,122 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charmmirror
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+
+	"github.com/juju/core/environs"
+	"github.com/juju/core/environs/charmmirror"
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/watcher"
+)
+
+var logger = loggo.GetLogger("juju.worker.charmmirror")
+
+// Worker serves the charm mirror held in the environment's storage,
+// as written by juju charm-mirror, on the port given by the
+// environment's charm-mirror-port setting.
+type Worker struct {
+	tomb tomb.Tomb
+	st   *state.State
+
+	// port holds the port the mirror is being served on,
+	// and listener the listener serving it, which is nil
+	// when the mirror is not being served.
+	port     int
+	listener net.Listener
+}
+
+// NewWorker returns a worker that serves the charm mirror in the
+// environment's storage over HTTP while the environment's
+// charm-mirror-port setting is set, so that environments can use it
+// by setting charm-store-url to the address of a state server.
+func NewWorker(st *state.State) *Worker {
+	w := &Worker{st: st}
+	go func() {
+		defer w.tomb.Done()
+		defer w.stopServing()
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+func (w *Worker) String() string {
+	return "charm mirror worker"
+}
+
+// Kill implements worker.Worker.Kill.
+func (w *Worker) Kill() {
+	w.tomb.Kill(nil)
+}
+
+// Wait implements worker.Worker.Wait.
+func (w *Worker) Wait() error {
+	return w.tomb.Wait()
+}
+
+func (w *Worker) loop() error {
+	configWatcher := w.st.WatchForEnvironConfigChanges()
+	defer watcher.Stop(configWatcher, &w.tomb)
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case _, ok := <-configWatcher.Changes():
+			if !ok {
+				return watcher.MustErr(configWatcher)
+			}
+			if err := w.update(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// update starts, moves or stops serving the mirror
+// to follow the environment configuration.
+func (w *Worker) update() error {
+	cfg, err := w.st.EnvironConfig()
+	if err != nil {
+		return err
+	}
+	port, ok := cfg.CharmMirrorPort()
+	if !ok {
+		w.stopServing()
+		return nil
+	}
+	if w.listener != nil && port == w.port {
+		return nil
+	}
+	environ, err := environs.New(cfg)
+	if err != nil {
+		logger.Warningf("cannot serve charm mirror: %v", err)
+		return nil
+	}
+	w.stopServing()
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		logger.Errorf("cannot serve charm mirror on port %d: %v", port, err)
+		return nil
+	}
+	logger.Infof("serving charm mirror on port %d", port)
+	w.port = port
+	w.listener = listener
+	go http.Serve(listener, charmmirror.NewServer(environ.Storage()))
+	return nil
+}
+
+func (w *Worker) stopServing() {
+	if w.listener == nil {
+		return
+	}
+	logger.Infof("no longer serving charm mirror on port %d", w.port)
+	w.listener.Close()
+	w.listener = nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charmmirror_test
+
+import (
+	"fmt"
+	"net"
+	stdtesting "testing"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/charm"
+	charmtesting "github.com/juju/core/charm/testing"
+	"github.com/juju/core/environs/charmmirror"
+	"github.com/juju/core/juju/testing"
+	coretesting "github.com/juju/core/testing"
+	"github.com/juju/core/worker"
+	charmmirrorworker "github.com/juju/core/worker/charmmirror"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type WorkerSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&WorkerSuite{})
+
+var _ worker.Worker = (*charmmirrorworker.Worker)(nil)
+
+// freePort returns a port that nothing is listening on.
+func freePort(c *gc.C) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, gc.IsNil)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *WorkerSuite) TestServesEnvironmentStorage(c *gc.C) {
+	s.PatchValue(&charm.CacheDir, c.MkDir())
+	store := charmtesting.NewMockStore(c, map[string]int{"cs:precise/wordpress": 3})
+	err := charmmirror.Mirror(&charmmirror.Context{
+		Source: charm.NewStore(store.Address()),
+		Target: s.Conn.Environ.Storage(),
+		URLs:   []string{"cs:precise/wordpress"},
+	})
+	store.Close()
+	c.Assert(err, gc.IsNil)
+
+	port := freePort(c)
+	err = s.State.UpdateEnvironConfig(map[string]interface{}{"charm-mirror-port": port}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	w := charmmirrorworker.NewWorker(s.State)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	mirror := charm.NewStore(fmt.Sprintf("http://127.0.0.1:%d", port))
+	curl := charm.MustParseURL("cs:precise/wordpress")
+	var revs []charm.CharmRevision
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		if revs, err = mirror.Latest(curl); err == nil {
+			break
+		}
+	}
+	c.Assert(err, gc.IsNil)
+	c.Assert(revs[0].Revision, gc.Equals, 3)
+	ch, err := mirror.Get(curl.WithRevision(3))
+	c.Assert(err, gc.IsNil)
+	c.Assert(ch.Meta().Name, gc.Equals, "dummy")
+
+	// The mirror stops being served when the setting is removed.
+	err = s.State.UpdateEnvironConfig(nil, []string{"charm-mirror-port"}, nil)
+	c.Assert(err, gc.IsNil)
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		if _, err = mirror.Latest(curl); err != nil {
+			break
+		}
+	}
+	c.Assert(err, gc.NotNil)
+}
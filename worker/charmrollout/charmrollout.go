@@ -0,0 +1,76 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charmrollout
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+)
+
+var logger = loggo.GetLogger("juju.worker.charmrollout")
+
+// defaultInterval is the standard value for the interval setting.
+const defaultInterval = 5 * time.Second
+
+// interval sets how often rolling charm upgrades are advanced.
+var interval = defaultInterval
+
+// RolloutAdvancer defines the interface for types capable of
+// advancing rolling charm upgrades.
+type RolloutAdvancer interface {
+	// AdvanceRollouts releases the next batch of units of every
+	// rolling charm upgrade whose current batch has upgraded.
+	AdvanceRollouts() error
+}
+
+// Worker is responsible for periodically advancing the rolling
+// charm upgrades of services.
+type Worker struct {
+	tomb tomb.Tomb
+	ra   RolloutAdvancer
+}
+
+// NewWorker returns a worker that periodically advances
+// rolling charm upgrades.
+func NewWorker(ra RolloutAdvancer) *Worker {
+	w := &Worker{ra: ra}
+	go func() {
+		defer w.tomb.Done()
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+func (w *Worker) String() string {
+	return fmt.Sprintf("charm rollout worker")
+}
+
+func (w *Worker) Kill() {
+	w.tomb.Kill(nil)
+}
+
+func (w *Worker) Stop() error {
+	w.tomb.Kill(nil)
+	return w.tomb.Wait()
+}
+
+func (w *Worker) Wait() error {
+	return w.tomb.Wait()
+}
+
+func (w *Worker) loop() error {
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case <-time.After(interval):
+			if err := w.ra.AdvanceRollouts(); err != nil {
+				logger.Errorf("cannot advance rolling charm upgrades: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charmrollout_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/juju/testing"
+	coretesting "github.com/juju/core/testing"
+	"github.com/juju/core/worker/charmrollout"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type WorkerSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&WorkerSuite{})
+
+func (s *WorkerSuite) TestAdvancesRollouts(c *gc.C) {
+	charmrollout.SetInterval(10 * time.Millisecond)
+	defer charmrollout.RestoreInterval()
+
+	service := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	curl, _ := service.CharmURL()
+	err = unit.SetCharmURL(curl)
+	c.Assert(err, gc.IsNil)
+	newCharm := s.AddTestingCharm(c, "mysql-alternative")
+	err = service.SetCharmRollout(newCharm, false, 1, false)
+	c.Assert(err, gc.IsNil)
+
+	w := charmrollout.NewWorker(s.State)
+	defer func() { c.Assert(w.Stop(), gc.IsNil) }()
+
+	timeout := time.After(coretesting.LongWait)
+	for {
+		select {
+		case <-time.After(coretesting.ShortWait):
+			err := service.Refresh()
+			c.Assert(err, gc.IsNil)
+			if r, ok := service.Rollout(); ok && len(r.Upgrading) == 1 {
+				c.Assert(r.Upgrading, gc.DeepEquals, []string{unit.Name()})
+				return
+			}
+		case <-timeout:
+			c.Fatalf("rolling upgrade not advanced")
+		}
+	}
+}
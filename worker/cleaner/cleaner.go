@@ -6,6 +6,7 @@ package cleaner
 import (
 	"github.com/juju/loggo"
 
+	"github.com/juju/core/environs"
 	"github.com/juju/core/state"
 	"github.com/juju/core/state/api/watcher"
 	"github.com/juju/core/worker"
@@ -19,7 +20,8 @@ type Cleaner struct {
 }
 
 // NewCleaner returns a worker.Worker that runs state.Cleanup()
-// if the CleanupWatcher signals documents marked for deletion.
+// and state.CleanupResourceContent() if the CleanupWatcher
+// signals documents marked for deletion.
 func NewCleaner(st *state.State) worker.Worker {
 	return worker.NewNotifyWorker(&Cleaner{st: st})
 }
@@ -32,8 +34,15 @@ func (c *Cleaner) Handle() error {
 	if err := c.st.Cleanup(); err != nil {
 		logger.Errorf("cannot cleanup state: %v", err)
 	}
-	// We do not return the err from Cleanup, because we don't want to stop
-	// the loop as a failure
+	// Resource content lives in provider storage, which
+	// the state can't reach, so it's cleaned up here.
+	if storage, err := environs.GetStorage(c.st); err != nil {
+		logger.Errorf("cannot access provider storage: %v", err)
+	} else if err := c.st.CleanupResourceContent(storage); err != nil {
+		logger.Errorf("cannot cleanup resource content: %v", err)
+	}
+	// We do not return the errors from cleaning up, because we don't
+	// want to stop the loop as a failure
 	return nil
 }
 
@@ -0,0 +1,177 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package containertemplates
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+
+	"github.com/juju/core/container"
+	"github.com/juju/core/state/api/params"
+	apiwatcher "github.com/juju/core/state/api/watcher"
+	"github.com/juju/core/state/watcher"
+	"github.com/juju/core/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.containertemplates")
+
+// refreshInterval is how often the worker looks for templates built or
+// removed on the machine since they were last reported.
+var refreshInterval = 5 * time.Minute
+
+// Entity is the machine agent's view of its machine, through which the
+// container templates are reported and purge requests received.
+type Entity interface {
+	ContainerTemplatePurge() (params.ContainerTemplatePurgeResult, error)
+	SetContainerTemplates(templates []params.ContainerTemplate, purgedRevno int) error
+	WatchContainerTemplates() (apiwatcher.NotifyWatcher, error)
+}
+
+// templatesWorker reports the container templates cached on the machine
+// to the state servers, and purges them when asked.
+type templatesWorker struct {
+	tomb     tomb.Tomb
+	entity   Entity
+	managers []container.TemplateManager
+
+	reported    bool
+	templates   []params.ContainerTemplate
+	purgedRevno int
+}
+
+// NewWorker returns a worker that reports the templates kept by the
+// given template managers, and purges them when requested through
+// the API.
+func NewWorker(entity Entity, managers []container.TemplateManager) worker.Worker {
+	w := &templatesWorker{
+		entity:   entity,
+		managers: managers,
+	}
+	go func() {
+		defer w.tomb.Done()
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+func (w *templatesWorker) String() string {
+	return "container templates worker"
+}
+
+// Kill is defined on the worker.Worker interface.
+func (w *templatesWorker) Kill() {
+	w.tomb.Kill(nil)
+}
+
+// Wait is defined on the worker.Worker interface.
+func (w *templatesWorker) Wait() error {
+	return w.tomb.Wait()
+}
+
+func (w *templatesWorker) loop() error {
+	watch, err := w.entity.WatchContainerTemplates()
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop(watch, &w.tomb)
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case _, ok := <-watch.Changes():
+			if !ok {
+				return watcher.MustErr(watch)
+			}
+			if err := w.handlePurge(); err != nil {
+				return err
+			}
+		case <-time.After(refreshInterval):
+			if err := w.report(w.purgedRevno); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handlePurge purges the templates if a new purge has been requested,
+// and reports the templates on the machine.
+func (w *templatesWorker) handlePurge() error {
+	purge, err := w.entity.ContainerTemplatePurge()
+	if err != nil {
+		return fmt.Errorf("cannot get container template purge request: %v", err)
+	}
+	if purge.Revno > purge.PurgedRevno {
+		w.purge(purge.Names)
+	}
+	return w.report(purge.Revno)
+}
+
+// purge purges the named templates, or all of them if no names are
+// given. Templates that cannot be purged, because containers still use
+// them, are logged; they remain in the reported templates.
+func (w *templatesWorker) purge(names []string) {
+	for _, manager := range w.managers {
+		templates, err := manager.ListTemplates()
+		if err != nil {
+			logger.Errorf("cannot list container templates: %v", err)
+			continue
+		}
+		selected := selectNames(templates, names)
+		if len(selected) == 0 {
+			continue
+		}
+		if err := manager.PurgeTemplates(selected...); err != nil {
+			logger.Errorf("%v", err)
+			continue
+		}
+		logger.Infof("purged container templates %v", selected)
+	}
+}
+
+// selectNames returns the names of the templates in the list that
+// should be purged. The named templates may be of other container
+// types, so names not in the list are ignored.
+func selectNames(templates []container.TemplateInfo, names []string) []string {
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var selected []string
+	for _, info := range templates {
+		if len(names) == 0 || wanted[info.Name] {
+			selected = append(selected, info.Name)
+		}
+	}
+	return selected
+}
+
+// report records the templates on the machine, and the revno of the
+// last purge request acted upon, unless they are unchanged since they
+// were last reported.
+func (w *templatesWorker) report(purgedRevno int) error {
+	var templates []params.ContainerTemplate
+	for _, manager := range w.managers {
+		infos, err := manager.ListTemplates()
+		if err != nil {
+			return fmt.Errorf("cannot list container templates: %v", err)
+		}
+		for _, info := range infos {
+			templates = append(templates, params.ContainerTemplate(info))
+		}
+	}
+	if w.reported && purgedRevno == w.purgedRevno && reflect.DeepEqual(templates, w.templates) {
+		return nil
+	}
+	if err := w.entity.SetContainerTemplates(templates, purgedRevno); err != nil {
+		return fmt.Errorf("cannot set container templates: %v", err)
+	}
+	w.reported = true
+	w.templates = templates
+	w.purgedRevno = purgedRevno
+	return nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package containertemplates_test
+
+import (
+	"fmt"
+	"sync"
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/container"
+	"github.com/juju/core/instance"
+	jujutesting "github.com/juju/core/juju/testing"
+	"github.com/juju/core/state"
+	coretesting "github.com/juju/core/testing"
+	"github.com/juju/core/version"
+	"github.com/juju/core/worker"
+	"github.com/juju/core/worker/containertemplates"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type ContainerTemplatesSuite struct {
+	jujutesting.JujuConnSuite
+	machine  *state.Machine
+	worker   worker.Worker
+	lxc, kvm *fakeTemplateManager
+}
+
+var _ = gc.Suite(&ContainerTemplatesSuite{})
+
+// fakeTemplateManager implements container.TemplateManager.
+type fakeTemplateManager struct {
+	mu        sync.Mutex
+	templates []container.TemplateInfo
+	inUse     map[string]bool
+}
+
+func (m *fakeTemplateManager) ListTemplates() ([]container.TemplateInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]container.TemplateInfo(nil), m.templates...), nil
+}
+
+func (m *fakeTemplateManager) PurgeTemplates(names ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	purge := make(map[string]bool)
+	for _, name := range names {
+		purge[name] = true
+	}
+	var kept []container.TemplateInfo
+	var failed []string
+	for _, info := range m.templates {
+		switch {
+		case !purge[info.Name]:
+			kept = append(kept, info)
+		case m.inUse[info.Name]:
+			kept = append(kept, info)
+			failed = append(failed, info.Name)
+		}
+	}
+	m.templates = kept
+	if len(failed) > 0 {
+		return fmt.Errorf("cannot purge templates: %v", failed)
+	}
+	return nil
+}
+
+func (m *fakeTemplateManager) add(info container.TemplateInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.templates = append(m.templates, info)
+}
+
+func templateInfo(ctype instance.ContainerType, series string) container.TemplateInfo {
+	key := container.TemplateKey{
+		Series: series,
+		Arch:   "amd64",
+		Tools:  version.MustParse("1.19.4"),
+	}
+	return container.TemplateInfo{
+		Name:    key.Name(),
+		Type:    ctype,
+		Series:  key.Series,
+		Arch:    key.Arch,
+		Tools:   key.Tools,
+		Created: time.Date(2014, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func (s *ContainerTemplatesSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	st, machine := s.OpenAPIAsNewMachine(c)
+	s.machine = machine
+	entity, err := st.Agent().Entity(machine.Tag())
+	c.Assert(err, gc.IsNil)
+	s.lxc = &fakeTemplateManager{
+		templates: []container.TemplateInfo{
+			templateInfo(instance.LXC, "precise"),
+			templateInfo(instance.LXC, "trusty"),
+		},
+		inUse: map[string]bool{"juju-trusty-amd64-1.19.4-template": true},
+	}
+	s.kvm = &fakeTemplateManager{
+		templates: []container.TemplateInfo{templateInfo(instance.KVM, "trusty")},
+	}
+	s.PatchValue(containertemplates.RefreshInterval, 10*time.Millisecond)
+	s.worker = containertemplates.NewWorker(entity, []container.TemplateManager{s.lxc, s.kvm})
+	s.AddCleanup(func(c *gc.C) {
+		s.worker.Kill()
+		c.Assert(s.worker.Wait(), gc.IsNil)
+	})
+}
+
+// waitTemplates waits for the machine's reported templates to have
+// the given names, and returns the purge revno last acted upon.
+func (s *ContainerTemplatesSuite) waitTemplates(c *gc.C, names ...string) int {
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		templates, _, err := s.machine.ContainerTemplates()
+		c.Assert(err, gc.IsNil)
+		var reported []string
+		for _, t := range templates {
+			reported = append(reported, t.Name)
+		}
+		if fmt.Sprint(reported) != fmt.Sprint(names) {
+			continue
+		}
+		_, purgedRevno, err := s.machine.ContainerTemplatePurge()
+		c.Assert(err, gc.IsNil)
+		return purgedRevno
+	}
+	c.Fatalf("templates %v never reported", names)
+	return 0
+}
+
+func (s *ContainerTemplatesSuite) TestReportsTemplates(c *gc.C) {
+	s.waitTemplates(c,
+		"juju-precise-amd64-1.19.4-template",
+		"juju-trusty-amd64-1.19.4-template",
+		"juju-trusty-amd64-1.19.4-template",
+	)
+
+	// Templates built later are reported when the worker next looks.
+	s.lxc.add(templateInfo(instance.LXC, "utopic"))
+	s.waitTemplates(c,
+		"juju-precise-amd64-1.19.4-template",
+		"juju-trusty-amd64-1.19.4-template",
+		"juju-utopic-amd64-1.19.4-template",
+		"juju-trusty-amd64-1.19.4-template",
+	)
+}
+
+func (s *ContainerTemplatesSuite) TestPurgeNamedTemplates(c *gc.C) {
+	err := s.machine.RequestContainerTemplatePurge("juju-precise-amd64-1.19.4-template")
+	c.Assert(err, gc.IsNil)
+	purgedRevno := s.waitTemplates(c,
+		"juju-trusty-amd64-1.19.4-template",
+		"juju-trusty-amd64-1.19.4-template",
+	)
+	c.Assert(purgedRevno, gc.Equals, 1)
+}
+
+func (s *ContainerTemplatesSuite) TestPurgeAllTemplates(c *gc.C) {
+	err := s.machine.RequestContainerTemplatePurge()
+	c.Assert(err, gc.IsNil)
+	// The lxc template in use is kept.
+	purgedRevno := s.waitTemplates(c, "juju-trusty-amd64-1.19.4-template")
+	c.Assert(purgedRevno, gc.Equals, 1)
+	templates, err := s.kvm.ListTemplates()
+	c.Assert(err, gc.IsNil)
+	c.Assert(templates, gc.HasLen, 0)
+}
@@ -0,0 +1,95 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package passwordrotator
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/juju/loggo"
+
+	"github.com/juju/core/agent"
+	"github.com/juju/core/state/api/watcher"
+	"github.com/juju/core/utils"
+	"github.com/juju/core/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.passwordrotator")
+
+// Entity is the agent's view of its own entity, through which
+// password rotation requests are received and the new password set.
+type Entity interface {
+	PasswordRotation() (int, error)
+	WatchPasswordRotation() (watcher.NotifyWatcher, error)
+	SetPassword(password string) error
+}
+
+// ConfigChanger provides access to the agent's configuration.
+type ConfigChanger interface {
+	CurrentConfig() agent.Config
+	ChangeConfig(func(agent.ConfigSetter)) error
+}
+
+// PasswordRotator replaces the agent's password whenever the state
+// servers request it.
+type PasswordRotator struct {
+	entity Entity
+	config ConfigChanger
+}
+
+// NewPasswordRotator returns a worker.Worker that chooses a new
+// password for the agent whenever a rotation is requested for the
+// given entity.
+func NewPasswordRotator(entity Entity, config ConfigChanger) worker.Worker {
+	return worker.NewNotifyWorker(&PasswordRotator{
+		entity: entity,
+		config: config,
+	})
+}
+
+func (r *PasswordRotator) SetUp() (watcher.NotifyWatcher, error) {
+	return r.entity.WatchPasswordRotation()
+}
+
+func (r *PasswordRotator) Handle() error {
+	revno, err := r.entity.PasswordRotation()
+	if err != nil {
+		return fmt.Errorf("cannot get password rotation: %v", err)
+	}
+	agentConfig := r.config.CurrentConfig()
+	done, _ := strconv.Atoi(agentConfig.Value(agent.PasswordRotation))
+	if revno <= done {
+		return nil
+	}
+	newPassword, err := utils.RandomPassword()
+	if err != nil {
+		return err
+	}
+	// As when an agent first connects, change the configuration
+	// *before* setting the entity password, keeping the current
+	// password as the fallback. Should setting the entity password
+	// fail, the agent can still connect with the old password and
+	// will choose a new one then.
+	oldPassword := agentConfig.APIInfo().Password
+	if err := r.config.ChangeConfig(func(c agent.ConfigSetter) {
+		c.SetPassword(newPassword)
+		c.SetOldPassword(oldPassword)
+	}); err != nil {
+		return fmt.Errorf("cannot write new password: %v", err)
+	}
+	if err := r.entity.SetPassword(newPassword); err != nil {
+		return fmt.Errorf("cannot set new password: %v", err)
+	}
+	if err := r.config.ChangeConfig(func(c agent.ConfigSetter) {
+		c.SetValue(agent.PasswordRotation, strconv.Itoa(revno))
+	}); err != nil {
+		return fmt.Errorf("cannot record password rotation: %v", err)
+	}
+	logger.Infof("agent password rotated")
+	return nil
+}
+
+func (r *PasswordRotator) TearDown() error {
+	return nil
+}
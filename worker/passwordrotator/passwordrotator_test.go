@@ -0,0 +1,144 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package passwordrotator_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/agent"
+	jujutesting "github.com/juju/core/juju/testing"
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api"
+	apiagent "github.com/juju/core/state/api/agent"
+	coretesting "github.com/juju/core/testing"
+	"github.com/juju/core/version"
+	"github.com/juju/core/worker"
+	"github.com/juju/core/worker/passwordrotator"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type PasswordRotatorSuite struct {
+	jujutesting.JujuConnSuite
+	machine *state.Machine
+	entity  *apiagent.Entity
+	config  *fakeConfig
+}
+
+var _ = gc.Suite(&PasswordRotatorSuite{})
+
+type fakeConfig struct {
+	config  agent.ConfigSetterWriter
+	changed chan struct{}
+}
+
+func (f *fakeConfig) CurrentConfig() agent.Config {
+	return f.config.Clone()
+}
+
+func (f *fakeConfig) ChangeConfig(change func(agent.ConfigSetter)) error {
+	change(f.config)
+	select {
+	case f.changed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (s *PasswordRotatorSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	var st *api.State
+	st, s.machine = s.OpenAPIAsNewMachine(c)
+	var err error
+	s.entity, err = st.Agent().Entity(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+
+	config, err := agent.NewAgentConfig(agent.AgentConfigParams{
+		DataDir:           c.MkDir(),
+		Tag:               s.machine.Tag(),
+		UpgradedToVersion: version.Current.Number,
+		Password:          "initial-password",
+		CACert:            coretesting.CACert,
+		APIAddresses:      []string{"localhost:1234"},
+	})
+	c.Assert(err, gc.IsNil)
+	config.SetPassword("current-password")
+	s.config = &fakeConfig{config: config, changed: make(chan struct{}, 10)}
+}
+
+func (s *PasswordRotatorSuite) startWorker(c *gc.C) worker.Worker {
+	w := passwordrotator.NewPasswordRotator(s.entity, s.config)
+	s.AddCleanup(func(c *gc.C) {
+		w.Kill()
+		c.Assert(w.Wait(), gc.IsNil)
+	})
+	return w
+}
+
+func (s *PasswordRotatorSuite) waitRotated(c *gc.C, revno string) agent.Config {
+	timeout := time.After(coretesting.LongWait)
+	for {
+		select {
+		case <-timeout:
+			c.Fatalf("timed out waiting for password rotation %s", revno)
+		case <-s.config.changed:
+			config := s.config.CurrentConfig()
+			if config.Value(agent.PasswordRotation) == revno {
+				return config
+			}
+		}
+	}
+}
+
+func (s *PasswordRotatorSuite) TestNoRotationRequested(c *gc.C) {
+	s.startWorker(c)
+	select {
+	case <-s.config.changed:
+		c.Fatalf("unexpected configuration change")
+	case <-time.After(coretesting.ShortWait):
+	}
+	config := s.config.CurrentConfig()
+	c.Assert(config.APIInfo().Password, gc.Equals, "current-password")
+}
+
+func (s *PasswordRotatorSuite) TestRotatePassword(c *gc.C) {
+	s.startWorker(c)
+	err := s.State.RequestPasswordRotation(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+
+	config := s.waitRotated(c, "1")
+	newPassword := config.APIInfo().Password
+	c.Assert(newPassword, gc.Not(gc.Equals), "current-password")
+	c.Assert(config.OldPassword(), gc.Equals, "current-password")
+	err = s.machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.PasswordValid(newPassword), gc.Equals, true)
+
+	// A second request rotates the password again.
+	err = s.State.RequestPasswordRotation(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	config = s.waitRotated(c, "2")
+	c.Assert(config.OldPassword(), gc.Equals, newPassword)
+	c.Assert(config.APIInfo().Password, gc.Not(gc.Equals), newPassword)
+}
+
+func (s *PasswordRotatorSuite) TestRotationAlreadyDone(c *gc.C) {
+	err := s.State.RequestPasswordRotation(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+	s.config.config.SetValue(agent.PasswordRotation, "1")
+
+	s.startWorker(c)
+	select {
+	case <-s.config.changed:
+		c.Fatalf("unexpected configuration change")
+	case <-time.After(coretesting.ShortWait):
+	}
+}
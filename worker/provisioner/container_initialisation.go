@@ -9,6 +9,7 @@ import (
 
 	"github.com/juju/core/agent"
 	"github.com/juju/core/container"
+	"github.com/juju/core/container/docker"
 	"github.com/juju/core/container/kvm"
 	"github.com/juju/core/container/lxc"
 	"github.com/juju/core/environs"
@@ -180,6 +181,15 @@ func (cs *ContainerSetup) getContainerArtifacts(containerType instance.Container
 			logger.Errorf("failed to create new kvm broker")
 			return nil, nil, err
 		}
+	case instance.DOCKER:
+		// Docker is installed on demand, and the daemon set up to use
+		// the same bridge the broker attaches containers to.
+		initialiser = docker.NewContainerInitialiser(dockerBridgeDevice(cs.config))
+		broker, err = NewDockerBroker(cs.provisioner, tools, cs.config, managerConfig)
+		if err != nil {
+			logger.Errorf("failed to create new docker broker")
+			return nil, nil, err
+		}
 	default:
 		return nil, nil, fmt.Errorf("unknown container type: %v", containerType)
 	}
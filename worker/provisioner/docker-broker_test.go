@@ -0,0 +1,135 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package provisioner_test
+
+import (
+	"fmt"
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/agent"
+	"github.com/juju/core/constraints"
+	"github.com/juju/core/container"
+	"github.com/juju/core/container/docker/mock"
+	dockertesting "github.com/juju/core/container/docker/testing"
+	"github.com/juju/core/environs"
+	"github.com/juju/core/instance"
+	instancetest "github.com/juju/core/instance/testing"
+	jujutesting "github.com/juju/core/juju/testing"
+	coretesting "github.com/juju/core/testing"
+	coretools "github.com/juju/core/tools"
+	"github.com/juju/core/version"
+	"github.com/juju/core/worker/provisioner"
+)
+
+type dockerSuite struct {
+	dockertesting.TestSuite
+	events chan mock.Event
+}
+
+type dockerBrokerSuite struct {
+	dockerSuite
+	broker      environs.InstanceBroker
+	agentConfig agent.Config
+}
+
+var _ = gc.Suite(&dockerBrokerSuite{})
+
+func (s *dockerSuite) SetUpTest(c *gc.C) {
+	s.TestSuite.SetUpTest(c)
+	s.events = make(chan mock.Event)
+	go func() {
+		for event := range s.events {
+			c.Output(3, fmt.Sprintf("docker event: <%s, %s>", event.Action, event.InstanceId))
+		}
+	}()
+	s.TestSuite.Factory.AddListener(s.events)
+}
+
+func (s *dockerSuite) TearDownTest(c *gc.C) {
+	close(s.events)
+	s.TestSuite.TearDownTest(c)
+}
+
+func (s *dockerBrokerSuite) SetUpTest(c *gc.C) {
+	s.dockerSuite.SetUpTest(c)
+	tools := &coretools.Tools{
+		Version: version.MustParseBinary("2.3.4-foo-bar"),
+		URL:     "http://tools.testing.invalid/2.3.4-foo-bar.tgz",
+	}
+	var err error
+	s.agentConfig, err = agent.NewAgentConfig(
+		agent.AgentConfigParams{
+			DataDir:           "/not/used/here",
+			Tag:               "tag",
+			UpgradedToVersion: version.Current.Number,
+			Password:          "dummy-secret",
+			Nonce:             "nonce",
+			APIAddresses:      []string{"10.0.0.1:1234"},
+			CACert:            coretesting.CACert,
+		})
+	c.Assert(err, gc.IsNil)
+	managerConfig := container.ManagerConfig{container.ConfigName: "juju"}
+	s.broker, err = provisioner.NewDockerBroker(&fakeAPI{}, tools, s.agentConfig, managerConfig)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *dockerBrokerSuite) startInstance(c *gc.C, machineId string) instance.Instance {
+	machineNonce := "fake-nonce"
+	stateInfo := jujutesting.FakeStateInfo(machineId)
+	apiInfo := jujutesting.FakeAPIInfo(machineId)
+	machineConfig := environs.NewMachineConfig(machineId, machineNonce, nil, nil, stateInfo, apiInfo)
+	cons := constraints.Value{}
+	possibleTools := s.broker.(coretools.HasTools).Tools("precise")
+	docker, _, _, err := s.broker.StartInstance(environs.StartInstanceParams{
+		Constraints:   cons,
+		Tools:         possibleTools,
+		MachineConfig: machineConfig,
+	})
+	c.Assert(err, gc.IsNil)
+	return docker
+}
+
+func (s *dockerBrokerSuite) TestStopInstance(c *gc.C) {
+	docker0 := s.startInstance(c, "1/docker/0")
+	docker1 := s.startInstance(c, "1/docker/1")
+	docker2 := s.startInstance(c, "1/docker/2")
+
+	err := s.broker.StopInstances(docker0.Id())
+	c.Assert(err, gc.IsNil)
+	s.assertInstances(c, docker1, docker2)
+	c.Assert(s.dockerContainerDir(docker0), jc.DoesNotExist)
+	c.Assert(s.dockerRemovedContainerDir(docker0), jc.IsDirectory)
+
+	err = s.broker.StopInstances(docker1.Id(), docker2.Id())
+	c.Assert(err, gc.IsNil)
+	s.assertInstances(c)
+}
+
+func (s *dockerBrokerSuite) TestAllInstances(c *gc.C) {
+	docker0 := s.startInstance(c, "1/docker/0")
+	docker1 := s.startInstance(c, "1/docker/1")
+	s.assertInstances(c, docker0, docker1)
+
+	err := s.broker.StopInstances(docker1.Id())
+	c.Assert(err, gc.IsNil)
+	docker2 := s.startInstance(c, "1/docker/2")
+	s.assertInstances(c, docker0, docker2)
+}
+
+func (s *dockerBrokerSuite) assertInstances(c *gc.C, inst ...instance.Instance) {
+	results, err := s.broker.AllInstances()
+	c.Assert(err, gc.IsNil)
+	instancetest.MatchInstances(c, results, inst...)
+}
+
+func (s *dockerBrokerSuite) dockerContainerDir(inst instance.Instance) string {
+	return filepath.Join(s.ContainerDir, string(inst.Id()))
+}
+
+func (s *dockerBrokerSuite) dockerRemovedContainerDir(inst instance.Instance) string {
+	return filepath.Join(s.RemovedDir, string(inst.Id()))
+}
@@ -82,19 +82,31 @@ func (s *kvmBrokerSuite) SetUpTest(c *gc.C) {
 }
 
 func (s *kvmBrokerSuite) startInstance(c *gc.C, machineId string) instance.Instance {
+	kvm, _ := s.startInstanceWithConstraints(c, machineId, constraints.Value{})
+	return kvm
+}
+
+func (s *kvmBrokerSuite) startInstanceWithConstraints(c *gc.C, machineId string, cons constraints.Value) (instance.Instance, *instance.HardwareCharacteristics) {
 	machineNonce := "fake-nonce"
 	stateInfo := jujutesting.FakeStateInfo(machineId)
 	apiInfo := jujutesting.FakeAPIInfo(machineId)
 	machineConfig := environs.NewMachineConfig(machineId, machineNonce, nil, nil, stateInfo, apiInfo)
-	cons := constraints.Value{}
 	possibleTools := s.broker.(coretools.HasTools).Tools("precise")
-	kvm, _, _, err := s.broker.StartInstance(environs.StartInstanceParams{
+	kvm, hardware, _, err := s.broker.StartInstance(environs.StartInstanceParams{
 		Constraints:   cons,
 		Tools:         possibleTools,
 		MachineConfig: machineConfig,
 	})
 	c.Assert(err, gc.IsNil)
-	return kvm
+	return kvm, hardware
+}
+
+func (s *kvmBrokerSuite) TestStartInstanceWithConstraints(c *gc.C) {
+	cons := constraints.MustParse("mem=2G cpu-cores=2 root-disk=20G")
+	_, hardware := s.startInstanceWithConstraints(c, "1/kvm/0", cons)
+	c.Assert(*hardware.Mem, gc.Equals, uint64(2048))
+	c.Assert(*hardware.CpuCores, gc.Equals, uint64(2))
+	c.Assert(*hardware.RootDisk, gc.Equals, uint64(20480))
 }
 
 func (s *kvmBrokerSuite) TestStopInstance(c *gc.C) {
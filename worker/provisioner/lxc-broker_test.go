@@ -84,19 +84,23 @@ func (s *lxcBrokerSuite) SetUpTest(c *gc.C) {
 }
 
 func (s *lxcBrokerSuite) startInstance(c *gc.C, machineId string) instance.Instance {
+	lxc, _ := s.startInstanceWithConstraints(c, machineId, constraints.Value{})
+	return lxc
+}
+
+func (s *lxcBrokerSuite) startInstanceWithConstraints(c *gc.C, machineId string, cons constraints.Value) (instance.Instance, *instance.HardwareCharacteristics) {
 	machineNonce := "fake-nonce"
 	stateInfo := jujutesting.FakeStateInfo(machineId)
 	apiInfo := jujutesting.FakeAPIInfo(machineId)
 	machineConfig := environs.NewMachineConfig(machineId, machineNonce, nil, nil, stateInfo, apiInfo)
-	cons := constraints.Value{}
 	possibleTools := s.broker.(coretools.HasTools).Tools("precise")
-	lxc, _, _, err := s.broker.StartInstance(environs.StartInstanceParams{
+	lxc, hardware, _, err := s.broker.StartInstance(environs.StartInstanceParams{
 		Constraints:   cons,
 		Tools:         possibleTools,
 		MachineConfig: machineConfig,
 	})
 	c.Assert(err, gc.IsNil)
-	return lxc
+	return lxc, hardware
 }
 
 func (s *lxcBrokerSuite) TestStartInstance(c *gc.C) {
@@ -126,6 +130,17 @@ func (s *lxcBrokerSuite) TestStartInstanceWithBridgeEnviron(c *gc.C) {
 	c.Assert(string(lxcConfContents), jc.Contains, "lxc.network.link = br0")
 }
 
+func (s *lxcBrokerSuite) TestStartInstanceWithConstraints(c *gc.C) {
+	cons := constraints.MustParse("mem=2G cpu-cores=2")
+	lxc, hardware := s.startInstanceWithConstraints(c, "1/lxc/0", cons)
+	c.Assert(*hardware.Mem, gc.Equals, uint64(2048))
+	c.Assert(*hardware.CpuCores, gc.Equals, uint64(2))
+	config, err := ioutil.ReadFile(filepath.Join(s.LxcDir, string(lxc.Id()), "config"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(config), jc.Contains, "lxc.cgroup.memory.limit_in_bytes = 2147483648\n")
+	c.Assert(string(config), jc.Contains, "lxc.cgroup.cpu.cfs_period_us = 100000\nlxc.cgroup.cpu.cfs_quota_us = 200000\n")
+}
+
 func (s *lxcBrokerSuite) TestStopInstance(c *gc.C) {
 	lxc0 := s.startInstance(c, "1/lxc/0")
 	lxc1 := s.startInstance(c, "1/lxc/1")
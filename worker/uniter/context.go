@@ -85,11 +85,15 @@ type HookContext struct {
 
 	// proxySettings are the current proxy settings that the uniter knows about
 	proxySettings proxy.Settings
+
+	// resources holds the local copies of the service's resources.
+	resources *ResourcesDir
 }
 
 func NewHookContext(unit *uniter.Unit, id, uuid, envName string,
 	relationId int, remoteUnitName string, relations map[int]*ContextRelation,
-	apiAddrs []string, serviceOwner string, proxySettings proxy.Settings) (*HookContext, error) {
+	apiAddrs []string, serviceOwner string, proxySettings proxy.Settings,
+	resources *ResourcesDir) (*HookContext, error) {
 	ctx := &HookContext{
 		unit:           unit,
 		id:             id,
@@ -101,6 +105,7 @@ func NewHookContext(unit *uniter.Unit, id, uuid, envName string,
 		apiAddrs:       apiAddrs,
 		serviceOwner:   serviceOwner,
 		proxySettings:  proxySettings,
+		resources:      resources,
 	}
 	// Get and cache the addresses.
 	var err error
@@ -139,6 +144,18 @@ func (ctx *HookContext) OwnerTag() string {
 	return ctx.serviceOwner
 }
 
+func (ctx *HookContext) NetworkConfig(networkName string) (params.UnitNetworkConfig, error) {
+	return ctx.unit.NetworkConfig(networkName)
+}
+
+func (ctx *HookContext) ResourcePath(name string) (string, error) {
+	info, err := ctx.unit.Resource(name)
+	if err != nil {
+		return "", err
+	}
+	return ctx.resources.Read(name, info)
+}
+
 func (ctx *HookContext) ConfigSettings() (charm.Settings, error) {
 	if ctx.configSettings == nil {
 		var err error
@@ -443,3 +460,7 @@ func (ctx *ContextRelation) ReadSettings(unit string) (settings params.RelationS
 	}
 	return settings, nil
 }
+
+func (ctx *ContextRelation) Networks() ([]string, error) {
+	return ctx.ru.Relation().Networks()
+}
@@ -644,6 +644,13 @@ func (s *InterfaceSuite) TestConfigCaching(c *gc.C) {
 	c.Assert(settings, gc.DeepEquals, charm.Settings{"blog-title": "My Title"})
 }
 
+func (s *ContextRelationSuite) TestNetworks(c *gc.C) {
+	ctx := uniter.NewContextRelation(s.apiRelUnit, nil)
+	networks, err := ctx.Networks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(networks, gc.HasLen, 0)
+}
+
 type HookContextSuite struct {
 	testing.JujuConnSuite
 	service  *state.Service
@@ -726,7 +733,7 @@ func (s *HookContextSuite) getHookContext(c *gc.C, uuid string, relid int,
 	}
 	context, err := uniter.NewHookContext(s.apiUnit, "TestCtx", uuid,
 		"test-env-name", relid, remote, s.relctxs, apiAddrs, "test-owner",
-		proxies)
+		proxies, uniter.NewResourcesDir(c.MkDir()))
 	c.Assert(err, gc.IsNil)
 	return context
 }
@@ -55,6 +55,15 @@ type Context interface {
 
 	// OwnerTag returns the owner of the service the executing units belongs to
 	OwnerTag() string
+
+	// NetworkConfig returns the executing unit's configuration on the
+	// named network.
+	NetworkConfig(networkName string) (params.UnitNetworkConfig, error)
+
+	// ResourcePath returns the path of the local copy of the current
+	// revision of the named resource of the executing unit's service,
+	// downloading it first if necessary.
+	ResourcePath(name string) (string, error)
 }
 
 // ContextRelation expresses the capabilities of a hook with respect to a relation.
@@ -81,6 +90,10 @@ type ContextRelation interface {
 
 	// ReadSettings returns the settings of any remote unit in the relation.
 	ReadSettings(unit string) (params.RelationSettings, error)
+
+	// Networks returns the names of the networks shared by the services
+	// in the relation.
+	Networks() ([]string, error)
 }
 
 // Settings is implemented by types that manipulate unit settings.
@@ -0,0 +1,131 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"fmt"
+
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/names"
+	"github.com/juju/core/state/api/params"
+)
+
+// NetworkGetCommand implements the network-get command.
+type NetworkGetCommand struct {
+	cmd.CommandBase
+	ctx         Context
+	RelationId  int
+	NetworkName string
+	out         cmd.Output
+}
+
+func NewNetworkGetCommand(ctx Context) cmd.Command {
+	// Unlike other relation commands, network-get is not scoped to the
+	// relation of a relation hook by default.
+	return &NetworkGetCommand{ctx: ctx, RelationId: -1}
+}
+
+func (c *NetworkGetCommand) Info() *cmd.Info {
+	doc := `
+network-get prints the addresses and interface names the unit has on the
+named network, along with the network's CIDR and VLAN tag.
+
+When -r is specified, only the networks shared by the services in the
+relation are considered, and the network name may be omitted to print the
+unit's configuration on all of them.
+`
+	return &cmd.Info{
+		Name:    "network-get",
+		Args:    "[<network name>]",
+		Purpose: "print network configuration",
+		Doc:     doc,
+	}
+}
+
+func (c *NetworkGetCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters)
+	f.Var(&relationIdValue{result: &c.RelationId, ctx: c.ctx}, "r", "specify a relation by id")
+}
+
+func (c *NetworkGetCommand) Init(args []string) error {
+	if len(args) > 0 {
+		c.NetworkName = args[0]
+		if !names.IsNetwork(c.NetworkName) {
+			return fmt.Errorf("invalid network name %q", c.NetworkName)
+		}
+		args = args[1:]
+	} else if c.RelationId == -1 {
+		return fmt.Errorf("no network name specified")
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// networkConfig holds the unit's configuration on a network, for
+// output.
+type networkConfig struct {
+	Network    string   `yaml:"network" json:"network"`
+	CIDR       string   `yaml:"cidr,omitempty" json:"cidr,omitempty"`
+	VLANTag    int      `yaml:"vlan-tag,omitempty" json:"vlan-tag,omitempty"`
+	Interfaces []string `yaml:"interfaces,omitempty" json:"interfaces,omitempty"`
+	Addresses  []string `yaml:"addresses,omitempty" json:"addresses,omitempty"`
+}
+
+func (c *NetworkGetCommand) networkConfig(networkName string) (networkConfig, error) {
+	config, err := c.ctx.NetworkConfig(networkName)
+	if err != nil {
+		return networkConfig{}, err
+	}
+	return newNetworkConfig(networkName, config), nil
+}
+
+func newNetworkConfig(networkName string, config params.UnitNetworkConfig) networkConfig {
+	return networkConfig{
+		Network:    networkName,
+		CIDR:       config.CIDR,
+		VLANTag:    config.VLANTag,
+		Interfaces: config.InterfaceNames,
+		Addresses:  config.Addresses,
+	}
+}
+
+func (c *NetworkGetCommand) Run(ctx *cmd.Context) error {
+	if c.RelationId == -1 {
+		config, err := c.networkConfig(c.NetworkName)
+		if err != nil {
+			return err
+		}
+		return c.out.Write(ctx, config)
+	}
+	r, found := c.ctx.Relation(c.RelationId)
+	if !found {
+		return fmt.Errorf("unknown relation id")
+	}
+	networkNames, err := r.Networks()
+	if err != nil {
+		return err
+	}
+	if c.NetworkName != "" {
+		for _, name := range networkNames {
+			if name == c.NetworkName {
+				config, err := c.networkConfig(name)
+				if err != nil {
+					return err
+				}
+				return c.out.Write(ctx, config)
+			}
+		}
+		return fmt.Errorf("network %q is not shared by relation %s", c.NetworkName, r.FakeId())
+	}
+	configs := []networkConfig{}
+	for _, name := range networkNames {
+		config, err := c.networkConfig(name)
+		if err != nil {
+			return err
+		}
+		configs = append(configs, config)
+	}
+	return c.out.Write(ctx, configs)
+}
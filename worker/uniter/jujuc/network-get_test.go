@@ -0,0 +1,130 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	"fmt"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/testing"
+	"github.com/juju/core/worker/uniter/jujuc"
+)
+
+type NetworkGetSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&NetworkGetSuite{})
+
+const net1YAML = `
+network: net1
+cidr: 10.0.1.0/24
+vlan-tag: 42
+interfaces:
+- eth0.42
+addresses:
+- 10.0.1.99`
+
+var networkGetTests = []struct {
+	summary string
+	relid   int
+	args    []string
+	code    int
+	out     string
+}{
+	{
+		summary: "no network name",
+		relid:   -1,
+		code:    2,
+		out:     "no network name specified",
+	}, {
+		summary: "relation hook, no network name",
+		relid:   1,
+		code:    2,
+		out:     "no network name specified",
+	}, {
+		summary: "invalid network name",
+		relid:   -1,
+		args:    []string{"$bad"},
+		code:    2,
+		out:     `invalid network name "\$bad"`,
+	}, {
+		summary: "too many args",
+		relid:   -1,
+		args:    []string{"net1", "net2"},
+		code:    2,
+		out:     `unrecognized args: \["net2"\]`,
+	}, {
+		summary: "unknown relation",
+		relid:   -1,
+		args:    []string{"-r", "unknown:123"},
+		code:    2,
+		out:     `invalid value "unknown:123" for flag -r: unknown relation id`,
+	}, {
+		summary: "unknown network",
+		relid:   -1,
+		args:    []string{"net3"},
+		code:    1,
+		out:     `network "net3" not found`,
+	}, {
+		summary: "network",
+		relid:   -1,
+		args:    []string{"net1"},
+		out:     net1YAML[1:],
+	}, {
+		summary: "relation hook does not scope the network",
+		relid:   0,
+		args:    []string{"net1"},
+		out:     net1YAML[1:],
+	}, {
+		summary: "json formatting",
+		relid:   -1,
+		args:    []string{"--format", "json", "net2"},
+		out:     `{"network":"net2","cidr":"10.0.2.0/24","interfaces":["eth1"],"addresses":["10.0.2.99"]}`,
+	}, {
+		summary: "relation network",
+		relid:   -1,
+		args:    []string{"-r", "peer1:1", "net1"},
+		out:     net1YAML[1:],
+	}, {
+		summary: "network not shared by relation",
+		relid:   -1,
+		args:    []string{"-r", "peer0:0", "net1"},
+		code:    1,
+		out:     `network "net1" is not shared by relation peer0:0`,
+	}, {
+		summary: "all relation networks",
+		relid:   -1,
+		args:    []string{"-r", "peer1:1", "--format", "json"},
+		out: `[{"network":"net1","cidr":"10.0.1.0/24","vlan-tag":42,"interfaces":["eth0.42"],"addresses":["10.0.1.99"]},` +
+			`{"network":"net2","cidr":"10.0.2.0/24","interfaces":["eth1"],"addresses":["10.0.2.99"]}]`,
+	}, {
+		summary: "relation without networks",
+		relid:   -1,
+		args:    []string{"-r", "peer0:0"},
+		out:     "[]",
+	},
+}
+
+func (s *NetworkGetSuite) TestNetworkGet(c *gc.C) {
+	for i, t := range networkGetTests {
+		c.Logf("test %d: %s", i, t.summary)
+		hctx := s.GetHookContext(c, t.relid, "")
+		com, err := jujuc.NewCommand(hctx, "network-get")
+		c.Assert(err, gc.IsNil)
+		ctx := testing.Context(c)
+		code := cmd.Main(com, ctx, t.args)
+		c.Assert(code, gc.Equals, t.code)
+		if code == 0 {
+			c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+			c.Assert(bufferString(ctx.Stdout), gc.Equals, t.out+"\n")
+		} else {
+			c.Assert(bufferString(ctx.Stdout), gc.Equals, "")
+			expect := fmt.Sprintf(`(.|\n)*error: %s\n`, t.out)
+			c.Assert(bufferString(ctx.Stderr), gc.Matches, expect)
+		}
+	}
+}
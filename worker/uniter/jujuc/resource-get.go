@@ -0,0 +1,58 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/juju/core/charm"
+	"github.com/juju/core/cmd"
+)
+
+// ResourceGetCommand implements the resource-get command.
+type ResourceGetCommand struct {
+	cmd.CommandBase
+	ctx  Context
+	Name string
+}
+
+func NewResourceGetCommand(ctx Context) cmd.Command {
+	return &ResourceGetCommand{ctx: ctx}
+}
+
+func (c *ResourceGetCommand) Info() *cmd.Info {
+	doc := `
+resource-get downloads the current revision of the named resource attached
+to the unit's service, if the unit doesn't have a copy of it yet, and prints
+the path of the local copy. The file has the name declared for the resource
+in the charm metadata.
+`
+	return &cmd.Info{
+		Name:    "resource-get",
+		Args:    "<resource name>",
+		Purpose: "fetch a service resource",
+		Doc:     doc,
+	}
+}
+
+func (c *ResourceGetCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no resource name specified")
+	}
+	c.Name = args[0]
+	if !charm.IsValidName(c.Name) {
+		return fmt.Errorf("invalid resource name %q", c.Name)
+	}
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *ResourceGetCommand) Run(ctx *cmd.Context) error {
+	path, err := c.ctx.ResourcePath(c.Name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(ctx.Stdout, path)
+	return nil
+}
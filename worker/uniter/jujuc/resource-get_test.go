@@ -0,0 +1,72 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	"fmt"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/cmd"
+	"github.com/juju/core/testing"
+	"github.com/juju/core/worker/uniter/jujuc"
+)
+
+type ResourceGetSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&ResourceGetSuite{})
+
+var resourceGetTests = []struct {
+	summary string
+	args    []string
+	code    int
+	out     string
+}{
+	{
+		summary: "no resource name",
+		code:    2,
+		out:     "no resource name specified",
+	}, {
+		summary: "invalid resource name",
+		args:    []string{"$bad"},
+		code:    2,
+		out:     `invalid resource name "\$bad"`,
+	}, {
+		summary: "too many args",
+		args:    []string{"jdk", "app"},
+		code:    2,
+		out:     `unrecognized args: \["app"\]`,
+	}, {
+		summary: "unknown resource",
+		args:    []string{"app"},
+		code:    1,
+		out:     `resource "app" not found`,
+	}, {
+		summary: "resource",
+		args:    []string{"jdk"},
+		out:     "/var/lib/juju/resources/jdk/jdk.tar.gz",
+	},
+}
+
+func (s *ResourceGetSuite) TestResourceGet(c *gc.C) {
+	for i, t := range resourceGetTests {
+		c.Logf("test %d: %s", i, t.summary)
+		hctx := s.GetHookContext(c, -1, "")
+		com, err := jujuc.NewCommand(hctx, "resource-get")
+		c.Assert(err, gc.IsNil)
+		ctx := testing.Context(c)
+		code := cmd.Main(com, ctx, t.args)
+		c.Assert(code, gc.Equals, t.code)
+		if code == 0 {
+			c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+			c.Assert(bufferString(ctx.Stdout), gc.Equals, t.out+"\n")
+		} else {
+			c.Assert(bufferString(ctx.Stdout), gc.Equals, "")
+			expect := fmt.Sprintf(`(.|\n)*error: %s\n`, t.out)
+			c.Assert(bufferString(ctx.Stderr), gc.Matches, expect)
+		}
+	}
+}
@@ -28,11 +28,13 @@ var newCommands = map[string]func(Context) cmd.Command{
 	"close-port":    NewClosePortCommand,
 	"config-get":    NewConfigGetCommand,
 	"juju-log":      NewJujuLogCommand,
+	"network-get":   NewNetworkGetCommand,
 	"open-port":     NewOpenPortCommand,
 	"relation-get":  NewRelationGetCommand,
 	"relation-ids":  NewRelationIdsCommand,
 	"relation-list": NewRelationListCommand,
 	"relation-set":  NewRelationSetCommand,
+	"resource-get":  NewResourceGetCommand,
 	"unit-get":      NewUnitGetCommand,
 	"owner-get":     NewOwnerGetCommand,
 }
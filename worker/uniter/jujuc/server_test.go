@@ -194,11 +194,13 @@ var newCommandTests = []struct {
 	{"close-port", ""},
 	{"config-get", ""},
 	{"juju-log", ""},
+	{"network-get", ""},
 	{"open-port", ""},
 	{"relation-get", ""},
 	{"relation-ids", ""},
 	{"relation-list", ""},
 	{"relation-set", ""},
+	{"resource-get", ""},
 	{"unit-get", ""},
 	{"random", "unknown command: random"},
 }
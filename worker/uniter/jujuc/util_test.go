@@ -51,6 +51,7 @@ func (s *ContextSuite) SetUpTest(c *gc.C) {
 			units: map[string]Settings{
 				"u/0": {"private-address": "u-0.testing.invalid"},
 			},
+			networks: []string{"net1", "net2"},
 		},
 	}
 }
@@ -142,10 +143,39 @@ func (c *Context) OwnerTag() string {
 	return "test-owner"
 }
 
+func (c *Context) NetworkConfig(networkName string) (params.UnitNetworkConfig, error) {
+	switch networkName {
+	case "net1":
+		return params.UnitNetworkConfig{
+			NetworkTag:     "network-net1",
+			CIDR:           "10.0.1.0/24",
+			VLANTag:        42,
+			InterfaceNames: []string{"eth0.42"},
+			Addresses:      []string{"10.0.1.99"},
+		}, nil
+	case "net2":
+		return params.UnitNetworkConfig{
+			NetworkTag:     "network-net2",
+			CIDR:           "10.0.2.0/24",
+			InterfaceNames: []string{"eth1"},
+			Addresses:      []string{"10.0.2.99"},
+		}, nil
+	}
+	return params.UnitNetworkConfig{}, fmt.Errorf("network %q not found", networkName)
+}
+
+func (c *Context) ResourcePath(name string) (string, error) {
+	if name != "jdk" {
+		return "", fmt.Errorf("resource %q not found", name)
+	}
+	return "/var/lib/juju/resources/jdk/jdk.tar.gz", nil
+}
+
 type ContextRelation struct {
-	id    int
-	name  string
-	units map[string]Settings
+	id       int
+	name     string
+	units    map[string]Settings
+	networks []string
 }
 
 func (r *ContextRelation) Id() int {
@@ -181,6 +211,10 @@ func (r *ContextRelation) ReadSettings(name string) (params.RelationSettings, er
 	return s.Map(), nil
 }
 
+func (r *ContextRelation) Networks() ([]string, error) {
+	return r.networks, nil
+}
+
 type Settings params.RelationSettings
 
 func (s Settings) Get(k string) (interface{}, bool) {
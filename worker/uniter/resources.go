@@ -0,0 +1,103 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/core/downloader"
+	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/utils"
+)
+
+// ResourcesDir is responsible for storing and retrieving the content
+// of the resources of a unit's service.
+type ResourcesDir struct {
+	path string
+}
+
+// NewResourcesDir returns a new ResourcesDir which uses path for storage.
+func NewResourcesDir(path string) *ResourcesDir {
+	return &ResourcesDir{path}
+}
+
+// Read returns the path of the local copy of the named resource with
+// the given info. If there's no copy yet, the content is downloaded
+// and validated and copied into the directory first, and the copies
+// of the resource's earlier revisions are removed. The file has the
+// name the charm declares for the resource.
+func (d *ResourcesDir) Read(name string, info params.UnitResourceInfo) (string, error) {
+	path := filepath.Join(d.path, name, info.SHA256, info.Filename)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	if err := d.download(name, info, path); err != nil {
+		return "", err
+	}
+	d.prune(name, info.SHA256)
+	return path, nil
+}
+
+// prune removes the local copies of the named resource
+// other than the one with the given sha256 hash.
+func (d *ResourcesDir) prune(name, sha256 string) {
+	dir := filepath.Join(d.path, name)
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		logger.Warningf("cannot read resource directory %q: %v", dir, err)
+		return
+	}
+	for _, entry := range entries {
+		if entry.Name() == sha256 {
+			continue
+		}
+		old := filepath.Join(dir, entry.Name())
+		if err := os.RemoveAll(old); err != nil {
+			logger.Warningf("cannot remove old resource content %q: %v", old, err)
+		}
+	}
+}
+
+// download fetches the content of the named resource and checks that
+// it has the correct sha256 hash, then moves it to path.
+func (d *ResourcesDir) download(name string, info params.UnitResourceInfo, path string) (err error) {
+	defer errors.Maskf(&err, "failed to download resource %q revision %d from %q", name, info.Revision, info.URL)
+	dir := filepath.Join(d.path, "downloads")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	logger.Infof("downloading resource %q revision %d from %s", name, info.Revision, info.URL)
+	hostnameVerification := utils.VerifySSLHostnames
+	if info.DisableSSLHostnameVerification {
+		logger.Infof("SSL hostname verification disabled")
+		hostnameVerification = utils.NoVerifySSLHostnames
+	}
+	dl := downloader.New(info.URL, dir, hostnameVerification)
+	defer dl.Stop()
+	st := <-dl.Done()
+	if st.Err != nil {
+		return st.Err
+	}
+	defer st.File.Close()
+	actualSha256, _, err := utils.ReadSHA256(st.File)
+	if err != nil {
+		return err
+	}
+	if actualSha256 != info.SHA256 {
+		os.Remove(st.File.Name())
+		return fmt.Errorf("expected sha256 %q, got %q", info.SHA256, actualSha256)
+	}
+	logger.Infof("download verified")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.Rename(st.File.Name(), path)
+}
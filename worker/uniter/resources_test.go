@@ -0,0 +1,84 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/state/api/params"
+	coretesting "github.com/juju/core/testing"
+	"github.com/juju/core/utils"
+	"github.com/juju/core/worker/uniter"
+)
+
+type ResourcesDirSuite struct {
+	coretesting.HTTPSuite
+}
+
+var _ = gc.Suite(&ResourcesDirSuite{})
+
+func (s *ResourcesDirSuite) TestRead(c *gc.C) {
+	dir := filepath.Join(c.MkDir(), "resources")
+	d := uniter.NewResourcesDir(dir)
+	content := []byte("the jdk")
+	sha256, _, err := utils.ReadSHA256(bytes.NewReader(content))
+	c.Assert(err, gc.IsNil)
+	info := params.UnitResourceInfo{
+		Filename: "jdk.tar.gz",
+		Revision: 2,
+		URL:      s.URL("/resources/jdk"),
+		SHA256:   sha256,
+	}
+	prefix := fmt.Sprintf(`failed to download resource "jdk" revision 2 from %q: `, info.URL)
+
+	// Try to get the resource when the content doesn't match.
+	coretesting.Server.Response(200, nil, []byte("roflcopter"))
+	_, err = d.Read("jdk", info)
+	c.Assert(err, gc.ErrorMatches, prefix+fmt.Sprintf(`expected sha256 %q, got ".*"`, sha256))
+
+	// Try to get a resource whose content doesn't exist.
+	coretesting.Server.Response(404, nil, nil)
+	_, err = d.Read("jdk", info)
+	c.Assert(err, gc.ErrorMatches, prefix+`.* 404 Not Found`)
+
+	// Get a resource whose content exists and matches.
+	coretesting.Server.Response(200, nil, content)
+	path, err := d.Read("jdk", info)
+	c.Assert(err, gc.IsNil)
+	c.Assert(path, gc.Equals, filepath.Join(dir, "jdk", sha256, "jdk.tar.gz"))
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, gc.IsNil)
+	c.Assert(data, gc.DeepEquals, content)
+
+	// Get the same resource again, without preparing a response
+	// from the server.
+	path, err = d.Read("jdk", info)
+	c.Assert(err, gc.IsNil)
+	c.Assert(path, gc.Equals, filepath.Join(dir, "jdk", sha256, "jdk.tar.gz"))
+
+	// No downloads are left behind.
+	downloads, err := ioutil.ReadDir(filepath.Join(dir, "downloads"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(downloads, gc.HasLen, 0)
+
+	// Get a new revision; the old revision's copy is removed.
+	newContent := []byte("the new jdk")
+	newSHA256, _, err := utils.ReadSHA256(bytes.NewReader(newContent))
+	c.Assert(err, gc.IsNil)
+	info.Revision = 3
+	info.SHA256 = newSHA256
+	coretesting.Server.Response(200, nil, newContent)
+	path, err = d.Read("jdk", info)
+	c.Assert(err, gc.IsNil)
+	c.Assert(path, gc.Equals, filepath.Join(dir, "jdk", newSHA256, "jdk.tar.gz"))
+	copies, err := ioutil.ReadDir(filepath.Join(dir, "jdk"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(copies, gc.HasLen, 1)
+	c.Assert(copies[0].Name(), gc.Equals, newSHA256)
+}
@@ -73,6 +73,7 @@ type Uniter struct {
 	relationsDir string
 	charmPath    string
 	deployer     charm.Deployer
+	resources    *ResourcesDir
 	s            *State
 	sf           *StateFile
 	rand         *rand.Rand
@@ -205,6 +206,7 @@ func (u *Uniter) init(unitTag string) (err error) {
 	if err != nil {
 		return fmt.Errorf("cannot create deployer: %v", err)
 	}
+	u.resources = NewResourcesDir(filepath.Join(u.baseDir, "state", "resources"))
 	u.sf = NewStateFile(filepath.Join(u.baseDir, "state", "uniter"))
 	u.rand = rand.New(rand.NewSource(time.Now().Unix()))
 
@@ -283,6 +285,15 @@ func (u *Uniter) deploy(curl *corecharm.URL, reason Op) error {
 			return err
 		}
 
+		// Report the unit as upgrading until it settles again after
+		// the upgrade-charm hook, so that rolling upgrades don't take
+		// the new charm URL to mean that the upgrade is complete.
+		if reason == Upgrade && hi == nil {
+			if err := u.unit.SetStatus(params.StatusInstalled, params.StatusInfoUpgradingCharm, nil); err != nil {
+				return err
+			}
+		}
+
 		// Set the new charm URL - this returns when the operation is complete,
 		// at which point we can refresh the local copy of the unit to get a
 		// version with the correct charm URL, and can go ahead and deploy
@@ -347,7 +358,7 @@ func (u *Uniter) getHookContext(hctxId string, relationId int, remoteUnitName st
 	// Make a copy of the proxy settings.
 	proxySettings := u.proxy
 	return NewHookContext(u.unit, hctxId, u.uuid, u.envName, relationId,
-		remoteUnitName, ctxRelations, apiAddrs, ownerTag, proxySettings)
+		remoteUnitName, ctxRelations, apiAddrs, ownerTag, proxySettings, u.resources)
 }
 
 func (u *Uniter) acquireHookLock(message string) (err error) {
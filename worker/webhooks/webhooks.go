@@ -0,0 +1,330 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api/params"
+	"github.com/juju/core/state/multiwatcher"
+	"github.com/juju/core/utils"
+)
+
+var logger = loggo.GetLogger("juju.worker.webhooks")
+
+// SignatureHeader holds the name of the HTTP header that carries
+// the signature of each delivery: "sha256=" followed by the hex
+// encoded HMAC-SHA256 of the request body, keyed with the webhook's
+// secret.
+const SignatureHeader = "X-Juju-Signature"
+
+// WebhookIdHeader holds the name of the HTTP header that carries
+// the id of the webhook a delivery is made to.
+const WebhookIdHeader = "X-Juju-Webhook-Id"
+
+// deliveryAttempt defines how deliveries are retried before
+// they are recorded as failed.
+var deliveryAttempt = utils.AttemptStrategy{
+	Total: time.Minute,
+	Delay: 10 * time.Second,
+	Min:   3,
+}
+
+// httpClient is used to make the deliveries.
+var httpClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: 30 * time.Second,
+	},
+}
+
+// Worker posts the changes in the environment
+// to the webhooks subscribed to them.
+type Worker struct {
+	tomb tomb.Tomb
+	st   *state.State
+
+	// stopping is closed when the worker's loop
+	// finishes, to stop the delivery queues.
+	stopping chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewWorker returns a worker that watches the environment and posts
+// each batch of changes, as a JSON-encoded list of params.Delta, to
+// the webhooks whose filters match them. The state of the environment
+// when the worker starts is not posted. Deliveries that still fail
+// after retrying are recorded in the state.
+func NewWorker(st *state.State) *Worker {
+	w := &Worker{
+		st:       st,
+		stopping: make(chan struct{}),
+	}
+	go func() {
+		defer w.tomb.Done()
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+func (w *Worker) String() string {
+	return "webhooks"
+}
+
+// Kill implements worker.Worker.Kill.
+func (w *Worker) Kill() {
+	w.tomb.Kill(nil)
+}
+
+// Wait implements worker.Worker.Wait.
+func (w *Worker) Wait() error {
+	return w.tomb.Wait()
+}
+
+func (w *Worker) loop() error {
+	watcher := w.st.Watch()
+	queues := make(map[string]*queue)
+	defer func() {
+		close(w.stopping)
+		w.wg.Wait()
+	}()
+	go func() {
+		select {
+		case <-w.tomb.Dying():
+		case <-w.stopping:
+		}
+		watcher.Stop()
+	}()
+	// The first changes describe the whole environment,
+	// which the webhooks are not interested in.
+	if _, err := watcher.Next(); err != nil {
+		return w.watchError(err)
+	}
+	for {
+		deltas, err := watcher.Next()
+		if err != nil {
+			return w.watchError(err)
+		}
+		if err := w.queueAll(queues, deltas); err != nil {
+			return err
+		}
+	}
+}
+
+func (w *Worker) watchError(err error) error {
+	if err == multiwatcher.ErrWatcherStopped {
+		return tomb.ErrDying
+	}
+	return err
+}
+
+// queueAll queues the given changes for delivery to all the webhooks
+// that match them. Each webhook has its own queue, so that a slow or
+// failing webhook doesn't hold up the deliveries to the others, and
+// the changes are delivered to each webhook in order. The queues of
+// removed webhooks are stopped.
+func (w *Worker) queueAll(queues map[string]*queue, deltas []params.Delta) error {
+	webhooks, err := w.st.AllWebhooks()
+	if err != nil {
+		return err
+	}
+	current := make(map[string]bool)
+	for _, hook := range webhooks {
+		current[hook.Id()] = true
+		matched := matchingDeltas(hook, deltas)
+		if len(matched) == 0 {
+			continue
+		}
+		payload, err := json.Marshal(matched)
+		if err != nil {
+			return fmt.Errorf("cannot marshal changes: %v", err)
+		}
+		q, ok := queues[hook.Id()]
+		if !ok {
+			q = w.newQueue(hook)
+			queues[hook.Id()] = q
+		}
+		q.add(payload)
+	}
+	for id, q := range queues {
+		if !current[id] {
+			q.stop()
+			delete(queues, id)
+		}
+	}
+	return nil
+}
+
+// maxQueuedDeliveries holds the number of deliveries that may wait
+// for a webhook before further ones are recorded as failed without
+// being attempted.
+var maxQueuedDeliveries = 100
+
+// queue delivers payloads to a single webhook in the order
+// they are added.
+type queue struct {
+	w        *Worker
+	hook     *state.Webhook
+	payloads chan []byte
+	stopped  chan struct{}
+}
+
+func (w *Worker) newQueue(hook *state.Webhook) *queue {
+	q := &queue{
+		w:        w,
+		hook:     hook,
+		payloads: make(chan []byte, maxQueuedDeliveries),
+		stopped:  make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		q.loop()
+	}()
+	return q
+}
+
+// add queues the payload for delivery. If too many deliveries are
+// waiting already, the delivery is recorded as failed.
+func (q *queue) add(payload []byte) {
+	select {
+	case q.payloads <- payload:
+	default:
+		logger.Errorf("too many deliveries queued for webhook %s", q.hook.Id())
+		q.w.recordFailure(q.hook, payload, 0, fmt.Errorf("too many deliveries queued"))
+	}
+}
+
+// stop stops the queue of a removed webhook.
+// Deliveries still waiting are dropped.
+func (q *queue) stop() {
+	close(q.stopped)
+}
+
+func (q *queue) loop() {
+	for {
+		select {
+		case <-q.stopped:
+			return
+		case <-q.w.stopping:
+			// Record the waiting deliveries as failed,
+			// so that they're not lost.
+			for {
+				select {
+				case payload := <-q.payloads:
+					q.w.recordFailure(q.hook, payload, 0, fmt.Errorf("worker stopped before delivery"))
+				default:
+					return
+				}
+			}
+		case payload := <-q.payloads:
+			q.w.deliver(q.hook, payload)
+		}
+	}
+}
+
+// matchingDeltas returns the deltas of entities
+// whose changes are posted to the given webhook.
+func matchingDeltas(hook *state.Webhook, deltas []params.Delta) []params.Delta {
+	var matched []params.Delta
+	for _, d := range deltas {
+		id := d.Entity.EntityId()
+		if hook.Matches(id.Kind, fmt.Sprint(id.Id)) {
+			matched = append(matched, d)
+		}
+	}
+	return matched
+}
+
+// deliver posts the payload to the webhook, retrying on failure,
+// and records the delivery as failed if it cannot be made.
+func (w *Worker) deliver(hook *state.Webhook, payload []byte) {
+	var err error
+	attempts := 0
+	for a := deliveryAttempt.Start(); a.Next(); {
+		attempts++
+		if err = post(hook, payload); err == nil {
+			return
+		}
+		logger.Warningf("cannot deliver changes to webhook %s: %v", hook.Id(), err)
+		if w.dying() {
+			// Don't keep the worker from stopping; the
+			// delivery is recorded as failed below.
+			break
+		}
+	}
+	logger.Errorf("giving up delivering changes to webhook %s after %d attempts", hook.Id(), attempts)
+	w.recordFailure(hook, payload, attempts, err)
+}
+
+// recordFailure records that the delivery of the payload to the
+// webhook failed after the given number of attempts.
+func (w *Worker) recordFailure(hook *state.Webhook, payload []byte, attempts int, err error) {
+	failure := state.WebhookFailure{
+		WebhookId: hook.Id(),
+		URL:       hook.URL(),
+		Payload:   string(payload),
+		Attempts:  attempts,
+		Error:     err.Error(),
+		Time:      time.Now(),
+	}
+	if err := w.st.AddWebhookFailure(failure); err != nil {
+		logger.Errorf("%v", err)
+	}
+}
+
+func (w *Worker) dying() bool {
+	select {
+	case <-w.tomb.Dying():
+		return true
+	case <-w.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// post makes a single delivery of the payload to the webhook.
+func post(hook *state.Webhook, payload []byte) error {
+	req, err := http.NewRequest("POST", hook.URL(), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookIdHeader, hook.Id())
+	req.Header.Set(SignatureHeader, Signature(hook.Secret(), payload))
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Read the body so that the connection can be reused.
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Signature returns the value of the signature header
+// for a delivery of the given payload.
+func Signature(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,166 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package webhooks_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/core/juju/testing"
+	"github.com/juju/core/state"
+	"github.com/juju/core/state/api/params"
+	coretesting "github.com/juju/core/testing"
+	"github.com/juju/core/utils"
+	"github.com/juju/core/worker"
+	"github.com/juju/core/worker/webhooks"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type webhooksSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&webhooksSuite{})
+
+var _ worker.Worker = (*webhooks.Worker)(nil)
+
+// delivery holds a request received by a webhook.
+type delivery struct {
+	header http.Header
+	body   []byte
+}
+
+// startServer starts an HTTP server that sends the requests it
+// receives on the returned channel and replies with the given status.
+func startServer(c *gc.C, status int) (*httptest.Server, <-chan delivery) {
+	deliveries := make(chan delivery, 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		c.Check(err, gc.IsNil)
+		deliveries <- delivery{r.Header, body}
+		w.WriteHeader(status)
+	}))
+	return srv, deliveries
+}
+
+// waitForDelivery changes the annotations of the machine until a
+// delivery is received, because changes made before the worker
+// starts watching the environment are not delivered.
+func (s *webhooksSuite) waitForDelivery(c *gc.C, m *state.Machine, deliveries <-chan delivery) delivery {
+	timeout := time.After(coretesting.LongWait)
+	for i := 0; ; i++ {
+		err := m.SetAnnotations(map[string]string{"count": fmt.Sprint(i)})
+		c.Assert(err, gc.IsNil)
+		select {
+		case d := <-deliveries:
+			return d
+		case <-time.After(coretesting.ShortWait):
+		case <-timeout:
+			c.Fatalf("timed out waiting for delivery")
+		}
+	}
+}
+
+func (s *webhooksSuite) TestDelivery(c *gc.C) {
+	srv, deliveries := startServer(c, http.StatusOK)
+	defer srv.Close()
+	hook, err := s.State.AddWebhook(state.WebhookInfo{
+		URL:         srv.URL,
+		Secret:      "s3cret",
+		EntityKinds: []string{"annotation"},
+	})
+	c.Assert(err, gc.IsNil)
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	w := webhooks.NewWorker(s.State)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	d := s.waitForDelivery(c, m, deliveries)
+	c.Assert(d.header.Get("Content-Type"), gc.Equals, "application/json")
+	c.Assert(d.header.Get(webhooks.WebhookIdHeader), gc.Equals, hook.Id())
+	c.Assert(d.header.Get(webhooks.SignatureHeader), gc.Equals, webhooks.Signature("s3cret", d.body))
+	var deltas []params.Delta
+	err = json.Unmarshal(d.body, &deltas)
+	c.Assert(err, gc.IsNil)
+	c.Assert(deltas, gc.Not(gc.HasLen), 0)
+	for _, delta := range deltas {
+		c.Assert(delta.Entity.EntityId().Kind, gc.Equals, "annotation")
+		c.Assert(delta.Entity.EntityId().Id, gc.Equals, m.Tag())
+	}
+}
+
+func (s *webhooksSuite) TestFailedDelivery(c *gc.C) {
+	s.PatchValue(webhooks.DeliveryAttempt, utils.AttemptStrategy{Min: 2})
+	srv, deliveries := startServer(c, http.StatusInternalServerError)
+	defer srv.Close()
+	hook, err := s.State.AddWebhook(state.WebhookInfo{
+		URL:         srv.URL,
+		Secret:      "s3cret",
+		EntityKinds: []string{"annotation"},
+	})
+	c.Assert(err, gc.IsNil)
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	w := webhooks.NewWorker(s.State)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	s.waitForDelivery(c, m, deliveries)
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		failures, err := s.State.WebhookFailures(hook.Id())
+		c.Assert(err, gc.IsNil)
+		if len(failures) == 0 {
+			if !a.HasNext() {
+				c.Fatalf("failed delivery not recorded")
+			}
+			continue
+		}
+		c.Assert(failures[0].URL, gc.Equals, srv.URL)
+		c.Assert(failures[0].Attempts, gc.Equals, 2)
+		c.Assert(failures[0].Error, gc.Equals, "webhook returned 500 Internal Server Error")
+		break
+	}
+}
+
+func (s *webhooksSuite) TestSlowWebhookDoesNotHoldUpOthers(c *gc.C) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	srv, deliveries := startServer(c, http.StatusOK)
+	defer srv.Close()
+	for _, url := range []string{slow.URL, srv.URL} {
+		_, err := s.State.AddWebhook(state.WebhookInfo{
+			URL:         url,
+			Secret:      "s3cret",
+			EntityKinds: []string{"annotation"},
+		})
+		c.Assert(err, gc.IsNil)
+	}
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	w := webhooks.NewWorker(s.State)
+	defer func() {
+		close(release)
+		c.Assert(worker.Stop(w), gc.IsNil)
+	}()
+
+	// Changes keep being delivered to the other
+	// webhook while the slow one doesn't answer.
+	s.waitForDelivery(c, m, deliveries)
+	s.waitForDelivery(c, m, deliveries)
+}